@@ -0,0 +1,112 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"cosmodrom/protocol"
+)
+
+func TestParseRamp(t *testing.T) {
+	tests := []struct {
+		value   string
+		rockets int
+		starts  []time.Duration // Подключение ракет по порядку
+		total   time.Duration
+		wantErr bool
+	}{
+		{value: "", rockets: 3, starts: []time.Duration{0, 0, 0}},
+		{value: "30s", rockets: 3, starts: []time.Duration{10 * time.Second, 20 * time.Second, 30 * time.Second}, total: 30 * time.Second},
+		{value: "10s:2,5s:2,10s:4", rockets: 4,
+			starts: []time.Duration{5 * time.Second, 10 * time.Second, 20 * time.Second, 25 * time.Second}, total: 25 * time.Second},
+		// Ракеты сверх последней ступени подключаются в ее конце
+		{value: "0s:1,10s:2", rockets: 4, starts: []time.Duration{0, 10 * time.Second, 10 * time.Second, 10 * time.Second}, total: 10 * time.Second},
+		{value: "10s:5,10s:3", rockets: 5, wantErr: true},
+		{value: "10s:6", rockets: 5, wantErr: true},
+		{value: "минута", rockets: 5, wantErr: true},
+		{value: "10s:много", rockets: 5, wantErr: true},
+	}
+	for _, tc := range tests {
+		schedule, err := parseRamp(tc.value, tc.rockets)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%q: ожидалась ошибка, получено %v", tc.value, schedule)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", tc.value, err)
+			continue
+		}
+		for i, want := range tc.starts {
+			if got := schedule.startAt(i); got != want {
+				t.Errorf("%q: ракета %d подключается через %v, ожидалось %v", tc.value, i, got, want)
+			}
+		}
+		if got := schedule.total(); got != tc.total {
+			t.Errorf("%q: разгон %v, ожидалось %v", tc.value, got, tc.total)
+		}
+	}
+}
+
+func TestLatencyHistogram(t *testing.T) {
+	var h latencyHistogram
+	if h.percentile(0.95) != 0 {
+		t.Error("пустая гистограмма: перцентиль не 0")
+	}
+	// 1..1000 мкс: p50 около 500 мкс, p95 около 950 мкс с погрешностью корзины
+	for i := 1; i <= 1000; i++ {
+		h.record(time.Duration(i) * time.Microsecond)
+	}
+	for _, tc := range []struct {
+		p    float64
+		want time.Duration
+	}{{0.5, 500 * time.Microsecond}, {0.95, 950 * time.Microsecond}, {1, time.Millisecond}} {
+		got := h.percentile(tc.p)
+		if got < tc.want || float64(got) > float64(tc.want)*(1+1.0/histogramSubBuckets) {
+			t.Errorf("p%.0f = %v, ожидалось %v с погрешностью до 1/16", tc.p*100, got, tc.want)
+		}
+	}
+	result := h.result()
+	if result.Samples != 1000 || result.Max != 1 || math.Abs(result.Mean-0.5005) > 1e-3 {
+		t.Errorf("итог %+v", result)
+	}
+
+	// Корзины идут подряд и не пересекаются
+	for bucket := 1; bucket < 60*histogramSubBuckets; bucket++ {
+		lower := histogramUpper(bucket-1) + 1
+		if histogramBucket(lower) != bucket || histogramBucket(histogramUpper(bucket)) != bucket {
+			t.Fatalf("корзина %d: границы %d..%d", bucket, lower, histogramUpper(bucket))
+		}
+	}
+}
+
+func TestSyntheticFlight(t *testing.T) {
+	config := syntheticConfig(0)
+	if err := protocol.ValidateRocketConfig(&config); err != nil {
+		t.Fatalf("сервер отклонит конфигурацию: %v", err)
+	}
+
+	const rockets = 300
+	previous := syntheticState(0, rockets, 0)
+	for step := 1; step <= 1000; step++ {
+		state := syntheticState(0, rockets, float64(step))
+		if state.Altitude < previous.Altitude || state.Speed < previous.Speed {
+			t.Fatalf("T+%d: высота %.0f м и скорость %.0f м/с меньше, чем секундой раньше", step, state.Altitude, state.Speed)
+		}
+		if r := math.Hypot(state.Position.X, state.Position.Y); math.Abs(r-protocol.EarthRadius-state.Altitude) > 1e-3 {
+			t.Fatalf("T+%d: позиция на %.0f м от центра при высоте %.0f м", step, r, state.Altitude)
+		}
+		previous = state
+	}
+	if !previous.InOrbit || previous.Altitude != syntheticOrbit || previous.OrbitPeriapsis != syntheticOrbit {
+		t.Errorf("через 1000 с ракета не на орбите: %+v", previous)
+	}
+
+	// Соседние ракеты на старте дальше опасного сближения (1 км)
+	a, b := syntheticState(0, rockets, 0), syntheticState(1, rockets, 0)
+	if distance := math.Hypot(a.Position.X-b.Position.X, a.Position.Y-b.Position.Y); distance < 1000 {
+		t.Errorf("ракеты на старте в %.0f м друг от друга", distance)
+	}
+}
@@ -0,0 +1,194 @@
+// Команда loadgen - нагрузочный тест сервера: M ракет шлют синтетическую
+// телеметрию без физической модели, K наблюдателей читают рассылку. После
+// разгона по расписанию -ramp идет замер длительностью -duration; итог -
+// достигнутые частоты, задержка рассылки и ошибки сервера - пишется в JSON,
+// чтобы сравнивать версии сервера между собой.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"cosmodrom/protocol"
+)
+
+type config struct {
+	server        string
+	rockets       int
+	observers     int
+	rate          float64 // Кадров телеметрии в секунду на ракету
+	duration      time.Duration
+	ramp          rampSchedule
+	codec         protocol.Codec
+	observerToken string
+	prefix        string // Начало ID ракет и наблюдателей
+}
+
+// result - итог прогона для сравнения между версиями сервера. Частоты -
+// в сообщениях в секунду за окно замера.
+type result struct {
+	Server    string    `json:"server"`
+	Codec     string    `json:"codec"`
+	StartedAt time.Time `json:"started_at"`
+
+	Rockets    int     `json:"rockets"`
+	Observers  int     `json:"observers"`
+	TargetRate float64 `json:"target_rate"` // Кадров в секунду на ракету
+	Ramp       string  `json:"ramp,omitempty"`
+	Duration   float64 `json:"duration_s"` // Фактическое окно замера
+
+	RocketsConnected   int64 `json:"rockets_connected"` // В конце замера
+	ObserversConnected int64 `json:"observers_connected"`
+
+	TelemetrySent      uint64  `json:"telemetry_sent"`
+	TelemetryRate      float64 `json:"telemetry_rate"`
+	BroadcastsReceived uint64  `json:"broadcasts_received"`
+	BroadcastRate      float64 `json:"broadcast_rate"`
+	// DeliveryRatio - доля ожидаемых рассылок, дошедших до наблюдателей:
+	// каждый кадр телеметрии должен прийти каждому наблюдателю
+	DeliveryRatio float64 `json:"delivery_ratio"`
+
+	BroadcastLatency latencyResult `json:"broadcast_latency_ms"`
+	ClockSkewed      uint64        `json:"clock_skewed"` // Рассылки с временем сервера позже приема
+
+	ServerErrors      map[string]uint64 `json:"server_errors"`
+	ServerErrorsTotal uint64            `json:"server_errors_total"`
+	Warnings          uint64            `json:"warnings"`
+	ConnectFailures   uint64            `json:"connect_failures"`
+	Disconnects       uint64            `json:"disconnects"`
+}
+
+func main() {
+	cfg := config{}
+	flag.StringVar(&cfg.server, "server", "ws://localhost:8080/ws", "URL сервера (ws:// или wss://)")
+	flag.IntVar(&cfg.rockets, "rockets", 100, "Число ракет")
+	flag.IntVar(&cfg.observers, "observers", 10, "Число наблюдателей")
+	flag.Float64Var(&cfg.rate, "rate", 10, "Кадров телеметрии в секунду на ракету")
+	flag.DurationVar(&cfg.duration, "duration", time.Minute, "Длительность замера после разгона")
+	rampFlag := flag.String("ramp", "", `Разгон: пусто - все ракеты сразу, "30s" - равномерно за 30 с, "10s:50,20s:200" - по ступеням`)
+	codecName := flag.String("codec", "json", "Кодек сообщений: json или cbor")
+	flag.StringVar(&cfg.observerToken, "observer-token", "", "Токен наблюдателей, если сервер запущен с -observer-token")
+	flag.StringVar(&cfg.prefix, "prefix", "loadgen", "Начало ID ракет и наблюдателей")
+	out := flag.String("out", "", "Файл для JSON с итогом (пусто - stdout)")
+	flag.Parse()
+
+	var err error
+	if cfg.codec, err = protocol.CodecByName(*codecName); err != nil {
+		fatalf("Ошибка в -codec: %v", err)
+	}
+	if cfg.rockets < 0 || cfg.observers < 0 {
+		fatalf("Ошибка параметров: число ракет и наблюдателей не может быть отрицательным")
+	}
+	if cfg.rate <= 0 {
+		fatalf("Ошибка в -rate: частота должна быть больше 0")
+	}
+	if cfg.duration <= 0 {
+		fatalf("Ошибка в -duration: длительность должна быть больше 0")
+	}
+	if cfg.ramp, err = parseRamp(*rampFlag, cfg.rockets); err != nil {
+		fatalf("Ошибка в -ramp: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	res := run(ctx, &cfg, newStats())
+	data, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		fatalf("Ошибка кодирования итога: %v", err)
+	}
+	data = append(data, '\n')
+	if *out == "" {
+		os.Stdout.Write(data)
+	} else if err := os.WriteFile(*out, data, 0o644); err != nil {
+		fatalf("Ошибка записи %s: %v", *out, err)
+	}
+	fmt.Fprintf(os.Stderr, "Телеметрия %.0f кадр/с, рассылка %.0f сообщ/с (доставлено %.1f%%), задержка p95 %.2f мс, ошибок сервера %d\n",
+		res.TelemetryRate, res.BroadcastRate, res.DeliveryRatio*100, res.BroadcastLatency.P95, res.ServerErrorsTotal)
+}
+
+// run подключает наблюдателей, затем ракеты по расписанию разгона, ведет
+// замер и отключает всех. Прерывание завершает замер досрочно.
+func run(ctx context.Context, cfg *config, st *stats) result {
+	res := result{
+		Server:     cfg.server,
+		Codec:      fmt.Sprint(cfg.codec),
+		StartedAt:  time.Now(),
+		Rockets:    cfg.rockets,
+		Observers:  cfg.observers,
+		TargetRate: cfg.rate,
+		Ramp:       cfg.ramp.String(),
+	}
+
+	traffic, stopTraffic := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	// Наблюдатели подключаются первыми, чтобы видеть все ракеты
+	for i := 0; i < cfg.observers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runObserver(traffic, cfg, st, i)
+		}()
+	}
+	start := time.Now()
+	for i := 0; i < cfg.rockets; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case <-time.After(time.Until(start.Add(cfg.ramp.startAt(i)))):
+				runRocket(traffic, cfg, st, i)
+			case <-traffic.Done():
+			}
+		}()
+	}
+
+	fmt.Fprintf(os.Stderr, "Разгон %v: %d ракет, %d наблюдателей\n", cfg.ramp.total(), cfg.rockets, cfg.observers)
+	select {
+	case <-time.After(time.Until(start.Add(cfg.ramp.total()))):
+	case <-ctx.Done():
+	}
+	fmt.Fprintf(os.Stderr, "Замер %v: подключено ракет %d, наблюдателей %d\n", cfg.duration, st.rocketsConnected.Load(), st.observersConnected.Load())
+	measureStart := time.Now()
+	st.measuring.Store(true)
+	select {
+	case <-time.After(cfg.duration):
+	case <-ctx.Done():
+	}
+	st.measuring.Store(false)
+	window := time.Since(measureStart).Seconds()
+
+	res.Duration = window
+	res.RocketsConnected = st.rocketsConnected.Load()
+	res.ObserversConnected = st.observersConnected.Load()
+	res.TelemetrySent = st.telemetrySent.Load()
+	res.BroadcastsReceived = st.broadcastsReceived.Load()
+	if window > 0 {
+		res.TelemetryRate = float64(res.TelemetrySent) / window
+		res.BroadcastRate = float64(res.BroadcastsReceived) / window
+	}
+	if expected := res.TelemetrySent * uint64(res.ObserversConnected); expected > 0 {
+		res.DeliveryRatio = float64(res.BroadcastsReceived) / float64(expected)
+	}
+	res.BroadcastLatency = st.latency.result()
+	res.ClockSkewed = st.clockSkewed.Load()
+
+	stopTraffic()
+	wg.Wait()
+	res.ServerErrors, res.ServerErrorsTotal = st.errorCounts()
+	res.Warnings = st.warnings.Load()
+	res.ConnectFailures = st.connectFailures.Load()
+	res.Disconnects = st.disconnects.Load()
+	return res
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
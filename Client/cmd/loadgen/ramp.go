@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rampStage - ступень разгона: за over число ракет равномерно растет до
+// rockets. Ступень с прежним числом ракет - пауза.
+type rampStage struct {
+	over    time.Duration
+	rockets int
+}
+
+// rampSchedule - порядок подключения ракет. Пустое расписание - все ракеты
+// сразу; ракеты сверх последней ступени подключаются в ее конце.
+type rampSchedule []rampStage
+
+// parseRamp разбирает -ramp: пусто - все сразу, "30s" - равномерно до
+// rockets за 30 с, "10s:50,20s:200" - до 50 ракет за 10 с, затем до 200
+// еще за 20 с
+func parseRamp(value string, rockets int) (rampSchedule, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	if !strings.Contains(value, ":") {
+		over, err := time.ParseDuration(value)
+		if err != nil || over < 0 {
+			return nil, fmt.Errorf("ожидается длительность или список длительность:ракет, получено %q", value)
+		}
+		return rampSchedule{{over: over, rockets: rockets}}, nil
+	}
+
+	var schedule rampSchedule
+	previous := 0
+	for _, part := range strings.Split(value, ",") {
+		overText, countText, _ := strings.Cut(strings.TrimSpace(part), ":")
+		over, err := time.ParseDuration(overText)
+		if err != nil || over < 0 {
+			return nil, fmt.Errorf("ступень %q: неверная длительность", part)
+		}
+		count, err := strconv.Atoi(countText)
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("ступень %q: неверное число ракет", part)
+		}
+		if count < previous {
+			return nil, fmt.Errorf("ступень %q: число ракет не может уменьшаться (было %d)", part, previous)
+		}
+		if count > rockets {
+			return nil, fmt.Errorf("ступень %q: ракет больше, чем -rockets %d", part, rockets)
+		}
+		schedule = append(schedule, rampStage{over: over, rockets: count})
+		previous = count
+	}
+	return schedule, nil
+}
+
+// startAt - когда от начала прогона подключается ракета с номером i (с 0)
+func (r rampSchedule) startAt(i int) time.Duration {
+	var elapsed time.Duration
+	previous := 0
+	for _, stage := range r {
+		if i < stage.rockets {
+			done := float64(i+1-previous) / float64(stage.rockets-previous)
+			return elapsed + time.Duration(done*float64(stage.over))
+		}
+		previous = stage.rockets
+		elapsed += stage.over
+	}
+	return elapsed
+}
+
+// total - длительность разгона; замер начинается после нее
+func (r rampSchedule) total() time.Duration {
+	var elapsed time.Duration
+	for _, stage := range r {
+		elapsed += stage.over
+	}
+	return elapsed
+}
+
+func (r rampSchedule) String() string {
+	parts := make([]string, len(r))
+	for i, stage := range r {
+		parts[i] = fmt.Sprintf("%v:%d", stage.over, stage.rockets)
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"math"
+	"math/bits"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	histogramSubBits    = 4 // 16 корзин на степень двойки: погрешность до 1/16
+	histogramSubBuckets = 1 << histogramSubBits
+)
+
+// latencyHistogram - гистограмма задержек в наносекундах с
+// логарифмическими корзинами. Память не растет с числом замеров, запись
+// без блокировок: наблюдатели пишут в нее из своих горутин.
+type latencyHistogram struct {
+	counts [64 * histogramSubBuckets]atomic.Uint64
+	total  atomic.Uint64
+	sum    atomic.Uint64
+	max    atomic.Uint64
+}
+
+// histogramBucket - номер корзины значения: до 16 нс по корзине на
+// наносекунду, дальше 16 корзин на каждую степень двойки
+func histogramBucket(value uint64) int {
+	if value < histogramSubBuckets {
+		return int(value)
+	}
+	shift := bits.Len64(value) - histogramSubBits - 1
+	return (shift+1)*histogramSubBuckets + int(value>>shift) - histogramSubBuckets
+}
+
+// histogramUpper - наибольшее значение в корзине bucket
+func histogramUpper(bucket int) uint64 {
+	if bucket < histogramSubBuckets {
+		return uint64(bucket)
+	}
+	shift := bucket/histogramSubBuckets - 1
+	lower := uint64(bucket%histogramSubBuckets+histogramSubBuckets) << shift
+	return lower + 1<<shift - 1
+}
+
+func (h *latencyHistogram) record(latency time.Duration) {
+	value := uint64(max(latency, 0))
+	h.counts[histogramBucket(value)].Add(1)
+	h.total.Add(1)
+	h.sum.Add(value)
+	for {
+		current := h.max.Load()
+		if value <= current || h.max.CompareAndSwap(current, value) {
+			return
+		}
+	}
+}
+
+// percentile - задержка, которую не превышает доля p замеров (p от 0 до 1),
+// с точностью до корзины; оценка сверху, но не больше максимума
+func (h *latencyHistogram) percentile(p float64) time.Duration {
+	total := h.total.Load()
+	if total == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(p * float64(total)))
+	var seen uint64
+	for bucket := range h.counts {
+		seen += h.counts[bucket].Load()
+		if seen >= rank && seen > 0 {
+			return time.Duration(min(histogramUpper(bucket), h.max.Load()))
+		}
+	}
+	return time.Duration(h.max.Load())
+}
+
+// latencyResult - задержки рассылки в миллисекундах
+type latencyResult struct {
+	Samples uint64  `json:"samples"`
+	Mean    float64 `json:"mean"`
+	P50     float64 `json:"p50"`
+	P95     float64 `json:"p95"`
+	P99     float64 `json:"p99"`
+	Max     float64 `json:"max"`
+}
+
+func (h *latencyHistogram) result() latencyResult {
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	total := h.total.Load()
+	result := latencyResult{
+		Samples: total,
+		P50:     ms(h.percentile(0.50)),
+		P95:     ms(h.percentile(0.95)),
+		P99:     ms(h.percentile(0.99)),
+		Max:     ms(time.Duration(h.max.Load())),
+	}
+	if total > 0 {
+		result.Mean = ms(time.Duration(h.sum.Load() / total))
+	}
+	return result
+}
+
+// stats - счетчики прогона. Телеметрия, рассылки и задержки считаются
+// только в окне замера, после разгона; ошибки сервера и сбои соединений -
+// за весь прогон.
+type stats struct {
+	measuring atomic.Bool
+
+	telemetrySent      atomic.Uint64
+	broadcastsReceived atomic.Uint64
+	latency            latencyHistogram
+	clockSkewed        atomic.Uint64 // Рассылки со временем сервера позже приема
+
+	rocketsConnected   atomic.Int64
+	observersConnected atomic.Int64
+	connectFailures    atomic.Uint64
+	disconnects        atomic.Uint64
+	warnings           atomic.Uint64
+
+	mu           sync.Mutex
+	serverErrors map[string]uint64 // Код error или "rejected/<код>" -> число
+}
+
+func newStats() *stats {
+	return &stats{serverErrors: make(map[string]uint64)}
+}
+
+// serverError учитывает ошибку сервера; count больше 1, если сервер
+// сообщил о пропущенных ошибках с тем же кодом
+func (s *stats) serverError(code string, count uint64) {
+	s.mu.Lock()
+	s.serverErrors[code] += count
+	s.mu.Unlock()
+}
+
+// broadcast учитывает рассылку, отправленную сервером в sentAt
+func (s *stats) broadcast(sentAt, receivedAt time.Time) {
+	if !s.measuring.Load() {
+		return
+	}
+	s.broadcastsReceived.Add(1)
+	latency := receivedAt.Sub(sentAt)
+	if latency < 0 {
+		s.clockSkewed.Add(1)
+	}
+	s.latency.record(latency)
+}
+
+func (s *stats) telemetry() {
+	if s.measuring.Load() {
+		s.telemetrySent.Add(1)
+	}
+}
+
+func (s *stats) errorCounts() (map[string]uint64, uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make(map[string]uint64, len(s.serverErrors))
+	var total uint64
+	for code, count := range s.serverErrors {
+		counts[code] = count
+		total += count
+	}
+	return counts, total
+}
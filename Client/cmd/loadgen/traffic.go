@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"cosmodrom/protocol"
+
+	"github.com/gorilla/websocket"
+)
+
+const registerTimeout = 10 * time.Second
+
+// Синтетический полет: разгон с постоянным ускорением до круговой орбиты
+// над экватором. Ракеты стартуют с разных долгот, и орбита каждой на 2 км
+// выше предыдущей, чтобы сервер не засыпал их предупреждениями о сближении.
+const (
+	syntheticAccel    = 20.0    // м/с2
+	syntheticOrbit    = 200e3   // Высота орбиты первой ракеты, м
+	syntheticOrbitGap = 2e3     // Шаг высоты орбит, м
+	syntheticFuel     = 20000.0 // Топливо на старте, кг
+	syntheticBurn     = 50.0    // Расход топлива при разгоне, кг/с
+	syntheticDryMass  = 3000.0  // кг
+)
+
+// syntheticConfig - конфигурация ракеты, которую принимает сервер
+func syntheticConfig(index int) protocol.RocketConfig {
+	return protocol.RocketConfig{
+		Name:            fmt.Sprintf("Нагрузка %d", index+1),
+		MassEmpty:       syntheticDryMass,
+		MassFuel:        syntheticFuel,
+		MassFuelMax:     syntheticFuel,
+		FuelType:        protocol.FuelTypeKerosene,
+		Engines:         []protocol.Engine{{Thrust: 150000, FuelConsumption: syntheticBurn, IsActive: true}},
+		DragCoefficient: 0.3,
+		CrossSection:    3,
+		Labels:          map[string]string{"source": "loadgen"},
+	}
+}
+
+// syntheticState - правдоподобная телеметрия ракеты index из rockets через
+// t секунд после старта, без физической модели
+func syntheticState(index, rockets int, t float64) protocol.RocketState {
+	orbit := syntheticOrbit + float64(index)*syntheticOrbitGap
+	radius := protocol.EarthRadius + orbit
+	orbitSpeed := math.Sqrt(protocol.GConstant * protocol.EarthMass / radius)
+	burnTime := orbitSpeed / syntheticAccel
+
+	// Высота плавно растет до орбиты за время разгона, пройденный путь
+	// считается по горизонтальной скорости
+	var altitude, speed, distance float64
+	if t < burnTime {
+		altitude = orbit * (1 - math.Cos(math.Pi*t/burnTime)) / 2
+		speed = syntheticAccel * t
+		distance = syntheticAccel * t * t / 2
+	} else {
+		altitude = orbit
+		speed = orbitSpeed
+		distance = syntheticAccel*burnTime*burnTime/2 + orbitSpeed*(t-burnTime)
+	}
+	r := protocol.EarthRadius + altitude
+	angle := 2*math.Pi*float64(index)/float64(rockets) + distance/r
+	sin, cos := math.Sincos(angle)
+	fuel := math.Max(0, syntheticFuel-syntheticBurn*t)
+
+	state := protocol.RocketState{
+		Position:       protocol.Vector3{X: r * cos, Y: r * sin},
+		Velocity:       protocol.Vector3{X: -speed * sin, Y: speed * cos},
+		Altitude:       altitude,
+		Speed:          speed,
+		MassCurrent:    syntheticDryMass + fuel,
+		FuelRemaining:  fuel,
+		InOrbit:        t >= burnTime,
+		Time:           t,
+		OrbitApoapsis:  -1,
+		OrbitPeriapsis: -1,
+		Longitude:      math.Remainder(angle*180/math.Pi, 360),
+		GroundSpeed:    speed,
+		OrbitIsStable:  t >= burnTime,
+	}
+	if t < burnTime {
+		state.Acceleration = protocol.Vector3{X: -syntheticAccel * sin, Y: syntheticAccel * cos}
+		state.GForce = syntheticAccel / 9.81
+	} else {
+		state.OrbitApoapsis, state.OrbitPeriapsis = orbit, orbit
+		state.OrbitRequiredVelocity = orbitSpeed
+	}
+	return state
+}
+
+// dial подключается к серверу. Отказ сервера при подключении (например,
+// 429 от лимита подключений с одного IP) учитывается как ошибка "http_<код>".
+func dial(ctx context.Context, st *stats, server string) (*websocket.Conn, bool) {
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, server, nil)
+	if err != nil {
+		if resp != nil {
+			st.serverError(fmt.Sprintf("http_%d", resp.StatusCode), 1)
+		} else if ctx.Err() == nil {
+			st.connectFailures.Add(1)
+		}
+		return nil, false
+	}
+	return conn, true
+}
+
+func send(conn *websocket.Conn, codec protocol.Codec, msgType protocol.MessageType, seq uint64, data interface{}) error {
+	payload, err := codec.Encode(protocol.Message{Type: msgType, Timestamp: time.Now(), Seq: seq, Data: data})
+	if err != nil {
+		return err
+	}
+	frame := websocket.TextMessage
+	if codec.Binary() {
+		frame = websocket.BinaryMessage
+	}
+	return conn.WriteMessage(frame, payload)
+}
+
+// countServerMessage учитывает ошибки и предупреждения сервера
+func countServerMessage(st *stats, msg protocol.Message) {
+	switch data := msg.Data.(type) {
+	case protocol.ErrorMessage:
+		st.serverError(string(data.Code), 1+data.Suppressed)
+	case protocol.RejectedMessage:
+		st.serverError("rejected/"+string(data.Code), 1)
+	case protocol.WarningMessage:
+		st.warnings.Add(1)
+	}
+}
+
+// runRocket подключает ракету index, регистрирует ее и шлет телеметрию с
+// частотой rate до отмены ctx, затем отключается через disconnect
+func runRocket(ctx context.Context, cfg *config, st *stats, index int) {
+	conn, ok := dial(ctx, st, cfg.server)
+	if !ok {
+		return
+	}
+	defer conn.Close()
+
+	id := fmt.Sprintf("%s-%d", cfg.prefix, index+1)
+	if err := send(conn, cfg.codec, protocol.MsgTypeRegister, 1, protocol.RegisterMessage{RocketID: id, Config: syntheticConfig(index)}); err != nil {
+		st.connectFailures.Add(1)
+		return
+	}
+	conn.SetReadDeadline(time.Now().Add(registerTimeout))
+	for {
+		msg, err := readMessage(conn, cfg.codec)
+		if err != nil {
+			st.connectFailures.Add(1)
+			return
+		}
+		countServerMessage(st, msg)
+		if msg.Type == protocol.MsgTypeRejected {
+			return
+		}
+		if msg.Type == protocol.MsgTypeAccepted {
+			break
+		}
+	}
+	conn.SetReadDeadline(time.Time{})
+	st.rocketsConnected.Add(1)
+	defer st.rocketsConnected.Add(-1)
+
+	// Ответы сервера читаются в фоне: ошибки и предупреждения считаются,
+	// обрыв связи останавливает отправку
+	lost := make(chan struct{})
+	go func() {
+		defer close(lost)
+		for {
+			msg, err := readMessage(conn, cfg.codec)
+			if err != nil {
+				return
+			}
+			countServerMessage(st, msg)
+		}
+	}()
+
+	// Первый кадр уходит сразу: до телеметрии сервер видит ракету в центре
+	// Земли и предупреждает о сближении со всеми такими же
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / cfg.rate))
+	defer ticker.Stop()
+	start := time.Now()
+	seq := uint64(1)
+	for tick := start; ; {
+		seq++
+		state := syntheticState(index, cfg.rockets, tick.Sub(start).Seconds())
+		if err := send(conn, cfg.codec, protocol.MsgTypeTelemetry, seq, protocol.TelemetryMessage{RocketID: id, State: state}); err != nil {
+			st.disconnects.Add(1)
+			return
+		}
+		st.telemetry()
+
+		select {
+		case <-ctx.Done():
+			seq++
+			send(conn, cfg.codec, protocol.MsgTypeDisconnect, seq, protocol.DisconnectMessage{RocketID: id, Reason: "loadgen"})
+			return
+		case <-lost:
+			st.disconnects.Add(1)
+			return
+		case tick = <-ticker.C:
+		}
+	}
+}
+
+// runObserver подписывает наблюдателя index на все ракеты и до отмены ctx
+// читает рассылку, замеряя задержку от отправки сервером до приема
+func runObserver(ctx context.Context, cfg *config, st *stats, index int) {
+	conn, ok := dial(ctx, st, cfg.server)
+	if !ok {
+		return
+	}
+	defer conn.Close()
+
+	subscribe := protocol.SubscribeMessage{ObserverID: fmt.Sprintf("%s-observer-%d", cfg.prefix, index+1), Token: cfg.observerToken}
+	if err := send(conn, cfg.codec, protocol.MsgTypeSubscribe, 1, subscribe); err != nil {
+		st.connectFailures.Add(1)
+		return
+	}
+	st.observersConnected.Add(1)
+	defer st.observersConnected.Add(-1)
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	for {
+		_, payload, err := conn.ReadMessage()
+		receivedAt := time.Now()
+		if err != nil {
+			if ctx.Err() == nil {
+				st.disconnects.Add(1)
+			}
+			return
+		}
+		h, err := readHeader(cfg.codec, payload)
+		if err != nil {
+			st.serverError("undecodable", 1)
+			continue
+		}
+		switch h.Type {
+		case protocol.MsgTypeBroadcast:
+			st.broadcast(h.Timestamp, receivedAt)
+		case protocol.MsgTypeError:
+			if msg, err := cfg.codec.Decode(payload); err == nil {
+				countServerMessage(st, msg)
+			}
+		}
+	}
+}
+
+func readMessage(conn *websocket.Conn, codec protocol.Codec) (protocol.Message, error) {
+	_, payload, err := conn.ReadMessage()
+	if err != nil {
+		return protocol.Message{}, err
+	}
+	return codec.Decode(payload)
+}
+
+// header - тип и время сообщения сервера без разбора данных
+type header struct {
+	Type      protocol.MessageType `json:"type"`
+	Timestamp time.Time            `json:"timestamp"`
+}
+
+// readHeader читает тип и время рассылки. JSON-кодек при чтении берет время
+// из timestamp_ms, а задержке на одной машине нужны доли миллисекунды:
+// для JSON время читается из timestamp с наносекундами.
+func readHeader(codec protocol.Codec, payload []byte) (header, error) {
+	if codec.Binary() {
+		msg, err := codec.Decode(payload)
+		return header{Type: msg.Type, Timestamp: msg.Timestamp}, err
+	}
+	var h header
+	err := json.Unmarshal(payload, &h)
+	return h, err
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cosmodrom/protocol"
+)
+
+const (
+	maxEvents      = 200             // Событий в памяти для панели предупреждений
+	staleTelemetry = 5 * time.Second // Без трансляций дольше - статус "нет данных"
+)
+
+// rocketView - ракета на табло: конфигурация из rocket_joined и последнее
+// состояние из broadcast
+type rocketView struct {
+	id       string
+	name     string
+	config   protocol.RocketConfig
+	mission  *protocol.Mission
+	state    protocol.RocketState
+	received time.Time // Последняя трансляция; нулевое - телеметрии еще не было
+	aborted  string    // Причина abort
+	left     string    // Причина rocket_left
+}
+
+type event struct {
+	at       time.Time
+	severity protocol.Severity
+	text     string
+}
+
+// board - табло центра управления: ракеты, события и выбор оператора.
+// Реализует rocketclient.ObserverHandler.
+type board struct {
+	mu        sync.Mutex
+	rockets   map[string]*rocketView
+	events    []event
+	selected  string // ID выбранной ракеты
+	detail    bool   // Подробный вид выбранной ракеты вместо таблицы
+	connected bool
+	status    string // Строка состояния: связь или результат последней команды
+	clock     protocol.Clock
+}
+
+func newBoard(clock protocol.Clock) *board {
+	return &board{rockets: make(map[string]*rocketView), clock: clock, status: "подключение..."}
+}
+
+// Subscribed очищает табло: после переподключения сервер заново присылает
+// rocket_joined всех ракет, а ракеты, пропавшие за время обрыва, не вернутся
+func (b *board) Subscribed() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rockets = make(map[string]*rocketView)
+	b.connected = true
+	b.status = "подписка оформлена"
+}
+
+func (b *board) Lost(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.connected {
+		b.addEvent(protocol.SeverityHigh, "связь с сервером потеряна: "+err.Error())
+	}
+	b.connected = false
+	b.status = "нет связи, переподключение..."
+}
+
+func (b *board) Message(msg protocol.Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch msg.Type {
+	case protocol.MsgTypeRocketJoined:
+		joined, err := protocol.DecodeData[protocol.RocketJoinedMessage](msg)
+		if err != nil {
+			return
+		}
+		rocket := b.rocket(joined.RocketID)
+		rocket.name, rocket.config, rocket.mission = joined.Name, joined.Config, joined.Mission
+		rocket.left, rocket.aborted = "", ""
+		b.addEvent(protocol.SeverityLow, fmt.Sprintf("%s (%s) на сервере", joined.RocketID, joined.Name))
+
+	case protocol.MsgTypeBroadcast:
+		broadcast, err := protocol.DecodeData[protocol.BroadcastMessage](msg)
+		if err != nil {
+			return
+		}
+		rocket := b.rocket(broadcast.RocketID)
+		if rocket.name == "" {
+			rocket.name = broadcast.Name
+		}
+		rocket.state = broadcast.State
+		rocket.received = b.clock.Now()
+		rocket.left = ""
+
+	case protocol.MsgTypeRocketLeft:
+		left, err := protocol.DecodeData[protocol.RocketLeftMessage](msg)
+		if err != nil {
+			return
+		}
+		reason := left.Reason
+		if reason == "" {
+			reason = "без причины"
+		}
+		if rocket, ok := b.rockets[left.RocketID]; ok {
+			rocket.left = reason
+		}
+		b.addEvent(protocol.SeverityMedium, fmt.Sprintf("%s отключилась: %s", left.RocketID, reason))
+
+	case protocol.MsgTypeAbort:
+		abort, err := protocol.DecodeData[protocol.AbortMessage](msg)
+		if err != nil {
+			return
+		}
+		b.rocket(abort.RocketID).aborted = abort.Reason
+		b.addEvent(protocol.SeverityCritical, fmt.Sprintf("%s прекратила полет: %s (T+%.1f с, высота %.2f км)",
+			abort.RocketID, abort.Reason, abort.Time, abort.Altitude/1000.0))
+
+	case protocol.MsgTypeMissionEvent:
+		mission, err := protocol.DecodeData[protocol.MissionEventMessage](msg)
+		if err != nil {
+			return
+		}
+		b.addEvent(protocol.SeverityLow, fmt.Sprintf("%s: %s", mission.RocketID, mission.Message))
+
+	case protocol.MsgTypeError:
+		errMsg, err := protocol.DecodeData[protocol.ErrorMessage](msg)
+		if err != nil {
+			return
+		}
+		b.addEvent(protocol.SeverityMedium, fmt.Sprintf("сервер отбросил %s (%s): %s", errMsg.RefType, errMsg.Code, errMsg.Detail))
+	}
+}
+
+// rocket возвращает ракету по ID, создавая ее: broadcast может прийти
+// раньше rocket_joined
+func (b *board) rocket(id string) *rocketView {
+	rocket, ok := b.rockets[id]
+	if !ok {
+		rocket = &rocketView{id: id}
+		b.rockets[id] = rocket
+	}
+	// Выбор переходит на первую известную ракету, если выбранной больше нет
+	if _, ok := b.rockets[b.selected]; !ok {
+		b.selected = id
+	}
+	return rocket
+}
+
+func (b *board) addEvent(severity protocol.Severity, text string) {
+	b.events = append(b.events, event{at: b.clock.Now(), severity: severity, text: text})
+	if len(b.events) > maxEvents {
+		b.events = b.events[len(b.events)-maxEvents:]
+	}
+}
+
+// sortedIDs - ракеты по ID, чтобы строки таблицы не прыгали
+func (b *board) sortedIDs() []string {
+	ids := make([]string, 0, len(b.rockets))
+	for id := range b.rockets {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// move сдвигает выбор на delta строк таблицы
+func (b *board) move(delta int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ids := b.sortedIDs()
+	if len(ids) == 0 {
+		return
+	}
+	index := sort.SearchStrings(ids, b.selected)
+	index = min(max(index+delta, 0), len(ids)-1)
+	b.selected = ids[index]
+}
+
+func (b *board) toggleDetail() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.detail = !b.detail
+}
+
+// target - выбранная ракета для команды; ok = false, если ее нет или она отключилась
+func (b *board) target() (id string, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	rocket, exists := b.rockets[b.selected]
+	return b.selected, exists && rocket.left == ""
+}
+
+func (b *board) setStatus(format string, args ...interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.status = fmt.Sprintf(format, args...)
+}
+
+// rocketStatus - краткий статус для таблицы
+func (b *board) rocketStatus(rocket *rocketView, now time.Time) string {
+	switch {
+	case rocket.left != "":
+		return "отключена"
+	case rocket.state.Crashed:
+		return "крушение"
+	case rocket.state.Landed:
+		return "посадка"
+	case rocket.aborted != "":
+		return "авария"
+	case rocket.received.IsZero():
+		return "ожидание"
+	case now.Sub(rocket.received) > staleTelemetry:
+		return "нет данных"
+	case rocket.state.InOrbit:
+		return "орбита"
+	}
+	return "полет"
+}
+
+// fuelPercent - остаток топлива от заправки ракеты, -1 если заправка неизвестна
+func fuelPercent(rocket *rocketView) float64 {
+	total := rocket.config.MassFuel
+	if len(rocket.config.Stages) > 0 {
+		total = 0
+		for _, stage := range rocket.config.Stages {
+			total += stage.MassFuel
+		}
+	}
+	if total <= 0 {
+		return -1
+	}
+	return 100 * rocket.state.FuelRemaining / total
+}
+
+// render рисует табло в w размером width x height символов. Строки
+// заканчиваются \r\n: терминал в raw-режиме.
+func (b *board) render(w io.Writer, server string, width, height int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.clock.Now()
+	var lines []string
+	connection := "подключено"
+	if !b.connected {
+		connection = "нет связи"
+	}
+	lines = append(lines, fmt.Sprintf("Центр управления полетами · %s · %s · ракет: %d", server, connection, len(b.rockets)), "")
+
+	if rocket, ok := b.rockets[b.selected]; ok && b.detail {
+		lines = append(lines, b.detailLines(rocket, now)...)
+	} else {
+		lines = append(lines, b.tableLines(now)...)
+	}
+
+	footer := []string{"", "↑/↓ выбор · Enter подробно · s отделить ступень · p сбросить нагрузку · c парашют · q выход", b.status}
+	// Событиям достается место, оставшееся от таблицы и подсказки
+	lines = append(lines, "", "Предупреждения и события")
+	room := max(height-len(lines)-len(footer), 1)
+	events := b.events[max(len(b.events)-room, 0):]
+	if len(events) == 0 {
+		lines = append(lines, "  нет")
+	}
+	for _, e := range events {
+		lines = append(lines, fmt.Sprintf("  %s %-8s %s", e.at.Format("15:04:05"), "["+string(e.severity)+"]", e.text))
+	}
+	lines = append(lines, footer...)
+
+	var out strings.Builder
+	out.WriteString("\033[H\033[2J")
+	for _, line := range lines {
+		if runes := []rune(line); len(runes) > width {
+			line = string(runes[:width])
+		}
+		out.WriteString(line + "\r\n")
+	}
+	io.WriteString(w, out.String())
+}
+
+func (b *board) tableLines(now time.Time) []string {
+	lines := []string{fmt.Sprintf("  %-14s %-16s %-10s %11s %14s %8s %13s %14s",
+		"ID", "Название", "Статус", "Высота, км", "Скорость, м/с", "Топливо", "Апоцентр, км", "Перицентр, км")}
+	for _, id := range b.sortedIDs() {
+		rocket := b.rockets[id]
+		cursor := " "
+		if id == b.selected {
+			cursor = ">"
+		}
+		fuel := "-"
+		if percent := fuelPercent(rocket); percent >= 0 {
+			fuel = fmt.Sprintf("%.0f%%", percent)
+		}
+		lines = append(lines, fmt.Sprintf("%s %-14s %-16s %-10s %11.2f %14.1f %8s %13.1f %14.1f", cursor,
+			truncate(id, 14), truncate(rocket.name, 16), b.rocketStatus(rocket, now),
+			rocket.state.Altitude/1000.0, rocket.state.Speed, fuel,
+			rocket.state.OrbitApoapsis/1000.0, rocket.state.OrbitPeriapsis/1000.0))
+	}
+	if len(b.rockets) == 0 {
+		lines = append(lines, "  ракет на сервере нет")
+	}
+	return lines
+}
+
+func (b *board) detailLines(rocket *rocketView, now time.Time) []string {
+	state := rocket.state
+	lines := []string{
+		fmt.Sprintf("Ракета %s (%s) - %s", rocket.id, rocket.name, b.rocketStatus(rocket, now)),
+		fmt.Sprintf("  Время полета     T+%.1f с", state.Time),
+		fmt.Sprintf("  Высота           %.2f км, вертикальная скорость %.1f м/с", state.Altitude/1000.0, state.VerticalSpeed),
+		fmt.Sprintf("  Скорость         %.1f м/с, над поверхностью %.1f м/с", state.Speed, state.GroundSpeed),
+		fmt.Sprintf("  Положение        %.3f°, %.3f°", state.Latitude, state.Longitude),
+		fmt.Sprintf("  Масса            %.0f кг, топливо %.0f кг", state.MassCurrent, state.FuelRemaining),
+		fmt.Sprintf("  Орбита           %.1f x %.1f км, наклонение %.1f°, e=%.4f",
+			state.OrbitPeriapsis/1000.0, state.OrbitApoapsis/1000.0, state.OrbitInclination, state.OrbitEccentricity),
+		fmt.Sprintf("  Перегрузка       %.2f g, скоростной напор %.0f Па", state.GForce, state.DynamicPressure),
+	}
+	if state.Stage > 0 {
+		lines = append(lines, fmt.Sprintf("  Ступень          %d из %d", state.Stage, len(rocket.config.Stages)))
+	}
+	for i, engine := range state.EngineStatus {
+		name := engine.ID
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		condition := "выключен"
+		switch {
+		case engine.Failed:
+			condition = "отказ"
+		case engine.Active:
+			condition = fmt.Sprintf("дроссель %.0f%%", engine.Throttle*100)
+		}
+		lines = append(lines, fmt.Sprintf("  Двигатель %-6s %s, работал %.1f с", name, condition, engine.BurnTime))
+	}
+	if rocket.mission != nil && rocket.mission.TargetOrbit > 0 {
+		lines = append(lines, fmt.Sprintf("  Цель миссии      орбита %.0f км", rocket.mission.TargetOrbit/1000.0))
+	}
+	if rocket.aborted != "" {
+		lines = append(lines, "  Авария           "+rocket.aborted)
+	}
+	if rocket.left != "" {
+		lines = append(lines, "  Отключена        "+rocket.left)
+	}
+	return lines
+}
+
+func truncate(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n-1]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cosmodrom/client/rocketclient"
+	"cosmodrom/protocol"
+
+	"github.com/gorilla/websocket"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func joined(id, name string) protocol.Message {
+	return protocol.Message{Type: protocol.MsgTypeRocketJoined, Data: protocol.RocketJoinedMessage{
+		RocketID: id,
+		Name:     name,
+		Config:   protocol.RocketConfig{Name: name, MassFuel: 1000},
+	}}
+}
+
+func broadcast(id string, state protocol.RocketState) protocol.Message {
+	return protocol.Message{Type: protocol.MsgTypeBroadcast, Data: protocol.BroadcastMessage{RocketID: id, State: state}}
+}
+
+func rendered(b *board) string {
+	var out strings.Builder
+	b.render(&out, "ws://test/ws", 200, 40)
+	return out.String()
+}
+
+func TestBoardMessages(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	b := newBoard(clock)
+	b.Subscribed()
+
+	b.Message(joined("r1", "Восток"))
+	b.Message(broadcast("r1", protocol.RocketState{Altitude: 200000, Speed: 7800, FuelRemaining: 250, InOrbit: true,
+		OrbitApoapsis: 210000, OrbitPeriapsis: 195000}))
+	b.Message(joined("r2", "Союз"))
+
+	screen := rendered(b)
+	for _, want := range []string{"Восток", "орбита", "200.00", "25%", "210.0", "195.0", "Союз", "ожидание", "r1 (Восток) на сервере"} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("на табло нет %q:\n%s", want, screen)
+		}
+	}
+
+	// Трансляции прекратились - данные устарели
+	clock.now = clock.now.Add(staleTelemetry + time.Second)
+	if screen := rendered(b); !strings.Contains(screen, "нет данных") {
+		t.Errorf("устаревшая телеметрия не отмечена:\n%s", screen)
+	}
+
+	b.Message(protocol.Message{Type: protocol.MsgTypeAbort, Data: protocol.AbortMessage{RocketID: "r2", Reason: "max-g"}})
+	b.Message(protocol.Message{Type: protocol.MsgTypeRocketLeft, Data: protocol.RocketLeftMessage{RocketID: "r1", Reason: "disconnected"}})
+	screen = rendered(b)
+	for _, want := range []string{"авария", "[critical] r2 прекратила полет: max-g", "отключена", "r1 отключилась: disconnected"} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("на табло нет %q:\n%s", want, screen)
+		}
+	}
+	if _, ok := b.target(); ok {
+		t.Error("отключившаяся ракета доступна для команд")
+	}
+
+	b.move(1)
+	b.toggleDetail()
+	if screen := rendered(b); !strings.Contains(screen, "Ракета r2 (Союз) - авария") || !strings.Contains(screen, "Авария           max-g") {
+		t.Errorf("подробный вид r2:\n%s", screen)
+	}
+
+	// После переподключения табло собирается заново из rocket_joined
+	b.Lost(context.Canceled)
+	b.Subscribed()
+	b.Message(joined("r3", "Прогресс"))
+	if id, ok := b.target(); len(b.rockets) != 1 || !ok || id != "r3" {
+		t.Errorf("после переподключения ракеты %v, выбрана %s", b.sortedIDs(), id)
+	}
+	if screen := rendered(b); !strings.Contains(screen, "Ракета r3 (Прогресс)") || !strings.Contains(screen, "связь с сервером потеряна") {
+		t.Errorf("табло после переподключения:\n%s", screen)
+	}
+}
+
+// Наблюдатель подписывается заново после перезапуска сервера
+func TestObserverResubscribes(t *testing.T) {
+	var connections atomic.Int32
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var msg protocol.Message
+		if err := conn.ReadJSON(&msg); err != nil || msg.Type != protocol.MsgTypeSubscribe {
+			return
+		}
+		if connections.Add(1) == 1 {
+			// Первый сервер знает r1 и "падает"
+			conn.WriteJSON(joined("r1", "Восток"))
+			return
+		}
+		conn.WriteJSON(joined("r2", "Союз"))
+		conn.ReadMessage()
+	}))
+	defer server.Close()
+
+	observer, err := rocketclient.NewObserver(rocketclient.ObserverConfig{
+		ServerURL:         "ws" + strings.TrimPrefix(server.URL, "http"),
+		ObserverID:        "mcc",
+		ReconnectMaxDelay: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b := newBoard(protocol.SystemClock)
+	go observer.Run(ctx, b)
+
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		b.mu.Lock()
+		_, stale := b.rockets["r1"]
+		_, current := b.rockets["r2"]
+		connected := b.connected
+		b.mu.Unlock()
+		if current && !stale && connected {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("наблюдатель не подписался заново:\n%s", rendered(b))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCommander(t *testing.T) {
+	commands, err := newCommander("wss://mcc.example:8443/ws?x=1", "")
+	if err != nil || commands.url != "https://mcc.example:8443/api/command" {
+		t.Fatalf("адрес команд %v, %v", commands, err)
+	}
+
+	var got protocol.CommandMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "token required", http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := protocol.DecodeStrict(body, &got); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if got.Command.DeployPayload {
+			http.Error(w, "command rejected: нагрузка уже отделена", http.StatusConflict)
+			return
+		}
+		w.Write([]byte(`{"status":"sent"}`))
+	}))
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+	anonymous, _ := newCommander(url, "")
+	if err := anonymous.send(protocol.CommandMessage{RocketID: "r1"}); err == nil || !strings.Contains(err.Error(), "нет прав") {
+		t.Errorf("без токена: %v", err)
+	}
+
+	operator, _ := newCommander(url, "secret")
+	if err := operator.send(protocol.CommandMessage{RocketID: "r1", Command: protocol.ControlCommand{StageSeparate: true}}); err != nil {
+		t.Fatal(err)
+	}
+	if got.RocketID != "r1" || !got.Command.StageSeparate || len(got.Command.EngineThrottle) != 0 {
+		t.Errorf("сервер получил %+v", got)
+	}
+	err = operator.send(protocol.CommandMessage{RocketID: "r1", Command: protocol.ControlCommand{DeployPayload: true}})
+	if err == nil || !strings.Contains(err.Error(), "409") || !strings.Contains(err.Error(), "уже отделена") {
+		t.Errorf("невыполнимое действие: %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"cosmodrom/protocol"
+)
+
+// commander отправляет команды через POST /api/command того же сервера
+type commander struct {
+	url    string // http(s)://хост/api/command
+	token  string // Bearer-токен, пусто - без заголовка
+	client *http.Client
+}
+
+// newCommander выводит адрес /api/command из адреса WebSocket:
+// ws://хост:8080/ws -> http://хост:8080/api/command
+func newCommander(serverURL, token string) (*commander, error) {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return nil, fmt.Errorf("-server: %w", err)
+	}
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	default:
+		return nil, fmt.Errorf("-server: ожидается ws:// или wss://, получено %q", serverURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/ws") + "/api/command"
+	u.RawQuery = ""
+	return &commander{url: u.String(), token: token, client: &http.Client{Timeout: 5 * time.Second}}, nil
+}
+
+// send отправляет команду и возвращает ошибку с ответом сервера, если
+// команда не принята: 401 - токен не подходит, 409 - действие невыполнимо
+func (c *commander) send(command protocol.CommandMessage) error {
+	body, err := json.Marshal(command)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	text, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	reason := strings.TrimSpace(string(text))
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("нет прав на команды (-token): %s", reason)
+	case http.StatusNotFound:
+		return fmt.Errorf("ракета не найдена на сервере")
+	}
+	return fmt.Errorf("сервер ответил %d: %s", resp.StatusCode, reason)
+}
@@ -0,0 +1,162 @@
+// Команда observer - терминальный центр управления полетами: подписывается
+// на события сервера как наблюдатель и показывает табло ракет, панель
+// предупреждений и подробности выбранной ракеты. Команды ракетам уходят
+// через POST /api/command.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"cosmodrom/client/rocketclient"
+	"cosmodrom/protocol"
+
+	"golang.org/x/term"
+)
+
+const redrawInterval = 500 * time.Millisecond
+
+func main() {
+	cfg := rocketclient.ObserverConfig{Labels: map[string]string{}}
+	flag.StringVar(&cfg.ServerURL, "server", "ws://localhost:8080/ws", "URL сервера (ws:// или wss://)")
+	flag.StringVar(&cfg.ObserverID, "id", fmt.Sprintf("observer-%d", rand.Intn(10000)), "ID наблюдателя")
+	flag.StringVar(&cfg.CACert, "ca-cert", "", "PEM-файл с сертификатом CA сервера для wss://")
+	flag.BoolVar(&cfg.InsecureSkipVerify, "insecure-skip-verify", false, "Не проверять TLS-сертификат сервера (только для отладки)")
+	flag.DurationVar(&cfg.ReconnectMaxDelay, "reconnect-max-delay", 30*time.Second, "Максимальная задержка между попытками переподключения")
+	flag.Func("label", "Показывать только ракеты с меткой ключ=значение (можно повторять; пустое значение - любое)", func(value string) error {
+		key, val, _ := strings.Cut(value, "=")
+		if key == "" {
+			return fmt.Errorf("ожидается ключ=значение")
+		}
+		cfg.Labels[key] = val
+		return nil
+	})
+	flag.StringVar(&cfg.Token, "observer-token", "", "Токен наблюдателя, если сервер запущен с -observer-token")
+	codecName := flag.String("codec", "json", "Кодек сообщений: json или cbor")
+	token := flag.String("token", "", "Токен для команд ракетам (заголовок Authorization: Bearer)")
+	flag.Parse()
+
+	var err error
+	if cfg.Codec, err = protocol.CodecByName(*codecName); err != nil {
+		fatalf("Ошибка в -codec: %v", err)
+	}
+	commands, err := newCommander(cfg.ServerURL, *token)
+	if err != nil {
+		fatalf("Ошибка параметров: %v", err)
+	}
+	observer, err := rocketclient.NewObserver(cfg)
+	if err != nil {
+		fatalf("Ошибка параметров: %v", err)
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		fatalf("observer требует терминала на stdin")
+	}
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		fatalf("Не удалось перевести терминал в raw-режим: %v", err)
+	}
+	defer func() {
+		term.Restore(fd, oldState)
+		// Курсор возвращается на место, экран очищается от табло
+		fmt.Print("\033[?25h\033[H\033[2J")
+	}()
+	fmt.Print("\033[?25l")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	b := newBoard(protocol.SystemClock)
+	go observer.Run(ctx, b)
+	go readKeys(ctx, cancel, b, commands)
+
+	ticker := time.NewTicker(redrawInterval)
+	defer ticker.Stop()
+	for {
+		width, height, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			width, height = 120, 40
+		}
+		b.render(os.Stdout, cfg.ServerURL, width, height)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// readKeys обрабатывает клавиши оператора до отмены ctx. Команды уходят в
+// отдельной горутине, чтобы медленный сервер не задерживал табло.
+func readKeys(ctx context.Context, cancel context.CancelFunc, b *board, commands *commander) {
+	buf := make([]byte, 16)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil || ctx.Err() != nil {
+			return
+		}
+
+		for i := 0; i < n; i++ {
+			// Стрелки приходят как ESC [ A..D
+			if buf[i] == 0x1b && i+2 < n && buf[i+1] == '[' {
+				switch buf[i+2] {
+				case 'A':
+					b.move(-1)
+				case 'B':
+					b.move(1)
+				}
+				i += 2
+				continue
+			}
+
+			switch buf[i] {
+			case 'k':
+				b.move(-1)
+			case 'j':
+				b.move(1)
+			case '\r', '\n':
+				b.toggleDetail()
+			case 's':
+				go sendAction(b, commands, "отделение ступени", protocol.ControlCommand{StageSeparate: true})
+			case 'p':
+				go sendAction(b, commands, "сброс полезной нагрузки", protocol.ControlCommand{DeployPayload: true})
+			case 'c':
+				go sendAction(b, commands, "раскрытие парашюта", protocol.ControlCommand{DeployParachute: true})
+			case 'q', 0x03: // Ctrl+C в raw-режиме не превращается в SIGINT
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+// sendAction отправляет выбранной ракете команду-действие без дросселей:
+// автопилот ракеты продолжает управлять тягой и ориентацией
+func sendAction(b *board, commands *commander, name string, command protocol.ControlCommand) {
+	rocketID, ok := b.target()
+	if !ok {
+		b.setStatus("Команда %s: ракета не выбрана", name)
+		return
+	}
+	b.setStatus("Команда %s для %s отправлена...", name, rocketID)
+	if err := commands.send(protocol.CommandMessage{RocketID: rocketID, Command: command}); err != nil {
+		b.setStatus("Команда %s для %s не принята: %v", name, rocketID, err)
+		return
+	}
+	b.setStatus("Команда %s для %s принята", name, rocketID)
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
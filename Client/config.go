@@ -0,0 +1,226 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"cosmodrom/client/physics"
+	"cosmodrom/client/rocketclient"
+	"cosmodrom/protocol"
+)
+
+// configFlags собирает RocketConfig из флагов командной строки. Основа -
+// пресет -preset или rocketclient.DefaultConfig, явно заданные флаги
+// заменяют ее поля, а -stages - ступени. С -vehicle конфигурацию целиком
+// присылает сервер, и флаги ракеты с ним несовместимы.
+type configFlags struct {
+	flags             *flag.FlagSet
+	defaults          protocol.RocketConfig
+	vehicle           *string
+	preset            *string
+	massEmpty         *float64
+	fuel              *float64
+	drag              *float64
+	crossSection      *float64
+	engineThrust      *float64
+	engineConsumption *float64
+	engines           *int
+	stages            *string
+}
+
+// rocketFlags - флаги, задающие ракету; с -vehicle их задает сервер
+var rocketFlags = []string{"preset", "name", "mass-empty", "fuel", "drag", "cross-section", "engines", "engine-thrust", "engine-consumption", "stages"}
+
+func registerConfigFlags(flags *flag.FlagSet, defaults protocol.RocketConfig) *configFlags {
+	names := make([]string, 0)
+	for _, preset := range rocketclient.Presets() {
+		names = append(names, preset.Name)
+	}
+
+	engine := defaults.Engines[0]
+	return &configFlags{
+		flags:             flags,
+		defaults:          defaults,
+		vehicle:           flags.String("vehicle", "", "Взять конфигурацию ракеты из каталога сервера по имени"),
+		preset:            flags.String("preset", "", "Встроенная конфигурация ракеты: "+strings.Join(names, ", ")+" (list - показать характеристики)"),
+		massEmpty:         flags.Float64("mass-empty", defaults.MassEmpty, "Масса пустой ракеты (кг)"),
+		fuel:              flags.Float64("fuel", defaults.MassFuel, "Масса топлива (кг)"),
+		drag:              flags.Float64("drag", defaults.DragCoefficient, "Аэродинамический коэффициент"),
+		crossSection:      flags.Float64("cross-section", defaults.CrossSection, "Площадь сечения (м2)"),
+		engineThrust:      flags.Float64("engine-thrust", engine.Thrust, "Тяга одного двигателя (Н)"),
+		engineConsumption: flags.Float64("engine-consumption", engine.FuelConsumption, "Расход топлива одного двигателя (кг/с)"),
+		engines:           flags.Int("engines", len(defaults.Engines), "Количество одинаковых двигателей"),
+		stages:            flags.String("stages", "", "JSON-файл со ступенями (заменяет -mass-empty, -fuel и параметры двигателей)"),
+	}
+}
+
+// listPresets - флаг -preset list
+func (f *configFlags) listPresets() bool {
+	return *f.preset == "list"
+}
+
+// vehicleName - имя ракеты в каталоге сервера (-vehicle)
+func (f *configFlags) vehicleName() string {
+	return *f.vehicle
+}
+
+func (f *configFlags) build(name string) (protocol.RocketConfig, error) {
+	set := make(map[string]bool)
+	f.flags.Visit(func(fl *flag.Flag) { set[fl.Name] = true })
+
+	config := f.defaults
+	config.Engines = append([]protocol.Engine(nil), f.defaults.Engines...)
+	// Конфигурация по умолчанию нужна только до ответа сервера
+	if *f.vehicle != "" {
+		for _, rocketFlag := range rocketFlags {
+			if set[rocketFlag] {
+				return config, fmt.Errorf("-%s нельзя использовать с -vehicle: конфигурацию ракеты задает сервер", rocketFlag)
+			}
+		}
+		return config, nil
+	}
+	if *f.preset != "" {
+		preset, err := rocketclient.PresetByName(*f.preset)
+		if err != nil {
+			return config, err
+		}
+		config = preset.Config()
+	}
+	if set["name"] || *f.preset == "" {
+		config.Name = name
+	}
+
+	// У пресета со ступенями массы и двигатели задаются только ступенями
+	if len(config.Stages) > 0 && *f.stages == "" {
+		for _, massFlag := range []string{"mass-empty", "fuel", "engines", "engine-thrust", "engine-consumption"} {
+			if set[massFlag] {
+				return config, fmt.Errorf("-%s нельзя использовать с пресетом %s со ступенями: задайте ступени через -stages", massFlag, *f.preset)
+			}
+		}
+	}
+
+	if set["mass-empty"] {
+		config.MassEmpty = *f.massEmpty
+	}
+	if set["fuel"] {
+		config.MassFuel = *f.fuel
+		config.MassFuelMax = *f.fuel
+	}
+	if set["drag"] {
+		config.DragCoefficient = *f.drag
+	}
+	if set["cross-section"] {
+		config.CrossSection = *f.crossSection
+	}
+	if set["engines"] || set["engine-thrust"] || set["engine-consumption"] {
+		engine := config.Engines[0]
+		count := len(config.Engines)
+		if set["engines"] {
+			count = *f.engines
+		}
+		if set["engine-thrust"] {
+			engine.Thrust = *f.engineThrust
+		}
+		if set["engine-consumption"] {
+			engine.FuelConsumption = *f.engineConsumption
+		}
+		engine.IsActive = true
+		config.Engines = nil
+		for i := 0; i < count; i++ {
+			config.Engines = append(config.Engines, engine)
+		}
+	}
+
+	if *f.stages != "" {
+		stages, err := loadStages(*f.stages)
+		if err != nil {
+			return config, err
+		}
+		config.Stages = stages
+		config.ApplyStages()
+	}
+
+	err := protocol.ValidateRocketConfig(&config)
+	var problems protocol.ValidationErrors
+	if errors.As(err, &problems) {
+		messages := make([]string, len(problems))
+		for i, problem := range problems {
+			messages[i] = fmt.Sprintf("некорректное значение %s: %s", flagForField(problem, &config), problem.Message)
+		}
+		return config, errors.New(strings.Join(messages, "; "))
+	}
+	return config, err
+}
+
+// printPresets печатает таблицу пресетов для -preset list
+func printPresets(w io.Writer) {
+	earth := physics.EarthDefault()
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "ПРЕСЕТ\tСТУПЕНИ\tМАССА, т\tТОПЛИВО\tTWR\tDELTA-V, м/с\tОПИСАНИЕ")
+	for _, preset := range rocketclient.Presets() {
+		config := preset.Config()
+		stages := len(config.Stages)
+		if stages == 0 {
+			stages = 1
+		}
+		fmt.Fprintf(table, "%s\t%d\t%.1f\t%s\t%.2f\t%.0f\t%s\n",
+			preset.Name, stages, (config.MassEmpty+config.MassFuel)/1000.0, config.FuelType,
+			rocketclient.LiftoffTWR(config, earth), rocketclient.DeltaV(config), preset.Description)
+	}
+	table.Flush()
+}
+
+// flagForField сопоставляет поле конфигурации с флагом, из которого оно получено
+func flagForField(validationErr *protocol.ValidationError, config *protocol.RocketConfig) string {
+	// Масса, топливо и двигатели ракеты со ступенями берутся из файла ступеней
+	if len(config.Stages) > 0 {
+		switch validationErr.Field {
+		case "mass_empty", "mass_fuel", "mass_fuel_max", "engines":
+			return "-stages"
+		}
+	}
+
+	switch validationErr.Field {
+	case "name":
+		return "-name"
+	case "mass_empty":
+		return "-mass-empty"
+	case "mass_fuel", "mass_fuel_max":
+		return "-fuel"
+	case "drag_coefficient":
+		return "-drag"
+	case "cross_section":
+		return "-cross-section"
+	case "stages":
+		return fmt.Sprintf("-stages (ступень %d)", validationErr.Index+1)
+	case "engines":
+		if len(config.Engines) == 0 {
+			return "-engines"
+		}
+		return "-engine-thrust/-engine-consumption"
+	}
+	return validationErr.Field
+}
+
+// loadStages читает ступени из JSON-массива в формате protocol.Stage.
+// Незнакомое поле - ошибка: опечатка дала бы ступень без топлива.
+func loadStages(path string) ([]protocol.Stage, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать файл ступеней: %w", err)
+	}
+
+	var stages []protocol.Stage
+	if err := protocol.DecodeStrict(data, &stages); err != nil {
+		return nil, fmt.Errorf("некорректный файл ступеней %s: %w", path, err)
+	}
+	if len(stages) == 0 {
+		return nil, fmt.Errorf("файл ступеней %s не содержит ступеней", path)
+	}
+	return stages, nil
+}
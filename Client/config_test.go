@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cosmodrom/client/rocketclient"
+	"cosmodrom/protocol"
+)
+
+// Порядок сборки конфигурации: -vehicle (сервер) исключает остальные флаги
+// ракеты; иначе основа - -preset или значения по умолчанию, поверх нее -
+// явно заданные флаги, затем -stages.
+func TestConfigFlagsPrecedence(t *testing.T) {
+	defaults := rocketclient.DefaultConfig().Rocket
+	sounding, err := rocketclient.PresetByName("sounding")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		args        []string
+		wantErr     string
+		wantName    string
+		wantFuel    float64
+		wantVehicle string
+	}{
+		{name: "по умолчанию", wantName: "Ракета", wantFuel: defaults.MassFuel},
+		{name: "пресет", args: []string{"-preset", "sounding"}, wantName: sounding.Config().Name, wantFuel: sounding.Config().MassFuel},
+		{name: "флаг поверх пресета", args: []string{"-preset", "sounding", "-fuel", "1000"}, wantName: sounding.Config().Name, wantFuel: 1000},
+		{name: "-name поверх пресета", args: []string{"-preset", "sounding", "-name", "Ракета"}, wantName: "Ракета", wantFuel: sounding.Config().MassFuel},
+		{name: "пресет со ступенями и -fuel", args: []string{"-preset", "falcon-1ish", "-fuel", "1000"}, wantErr: "-fuel нельзя использовать с пресетом"},
+		{name: "каталог сервера", args: []string{"-vehicle", "soyuz"}, wantName: defaults.Name, wantFuel: defaults.MassFuel, wantVehicle: "soyuz"},
+		{name: "-vehicle и -preset", args: []string{"-vehicle", "soyuz", "-preset", "sounding"}, wantErr: "-preset нельзя использовать с -vehicle"},
+		{name: "-vehicle и -fuel", args: []string{"-vehicle", "soyuz", "-fuel", "1000"}, wantErr: "-fuel нельзя использовать с -vehicle"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := flag.NewFlagSet("client", flag.ContinueOnError)
+			flags.SetOutput(io.Discard)
+			rocketName := flags.String("name", "Ракета", "")
+			configFlags := registerConfigFlags(flags, defaults)
+			if err := flags.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			config, err := configFlags.build(*rocketName)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ошибка %v, ожидалась содержащая %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if config.Name != tt.wantName {
+				t.Errorf("название %q, ожидалось %q", config.Name, tt.wantName)
+			}
+			if config.MassFuel != tt.wantFuel {
+				t.Errorf("топливо %.0f кг, ожидалось %.0f", config.MassFuel, tt.wantFuel)
+			}
+			if got := configFlags.vehicleName(); got != tt.wantVehicle {
+				t.Errorf("-vehicle %q, ожидалось %q", got, tt.wantVehicle)
+			}
+		})
+	}
+}
+
+// Все некорректные флаги ракеты перечисляются в одной ошибке
+func TestConfigFlagsAllProblems(t *testing.T) {
+	flags := flag.NewFlagSet("client", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	configFlags := registerConfigFlags(flags, rocketclient.DefaultConfig().Rocket)
+	if err := flags.Parse([]string{"-drag", "-1", "-cross-section", "0"}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := configFlags.build("Ракета")
+	if err == nil {
+		t.Fatal("ожидалась ошибка")
+	}
+	for _, want := range []string{"некорректное значение -drag", "некорректное значение -cross-section"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("ошибка %q не содержит %q", err, want)
+		}
+	}
+}
+
+// Опечатка в файле ступеней останавливает запуск с путем к полю
+func TestLoadStagesUnknownField(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stages.json")
+	content := `[{"mass_empty": 500, "mass_fuel": 3400, "engines": [{"thrust": 31000, "fuel_consumtion": 10}]}]`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := loadStages(path)
+	var unknown *protocol.UnknownFieldError
+	if !errors.As(err, &unknown) || unknown.Path != "[0].engines[0].fuel_consumtion" {
+		t.Errorf("ошибка %v, ожидалось неизвестное поле fuel_consumtion", err)
+	}
+}
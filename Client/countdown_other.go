@@ -0,0 +1,12 @@
+//go:build !unix
+
+package main
+
+import (
+	"context"
+
+	"cosmodrom/client/rocketclient"
+)
+
+// Без SIGUSR1/SIGUSR2 отсчетом управляют только команды сервера
+func watchCountdownSignals(ctx context.Context, client *rocketclient.RocketClient) {}
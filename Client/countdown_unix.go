@@ -0,0 +1,34 @@
+//go:build unix
+
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"cosmodrom/client/rocketclient"
+)
+
+// watchCountdownSignals: SIGUSR1 останавливает отсчет или продолжает его,
+// SIGUSR2 отменяет пуск
+func watchCountdownSignals(ctx context.Context, client *rocketclient.RocketClient) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2)
+	go func() {
+		defer signal.Stop(signals)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-signals:
+				if sig == syscall.SIGUSR2 {
+					client.ScrubCountdown("SIGUSR2")
+				} else {
+					client.ToggleCountdown("SIGUSR1")
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/client/rocketclient"
+)
+
+const fleetReportInterval = 10 * time.Second
+
+// fleet запускает несколько ракет в одном процессе для нагрузки на сервер.
+// У каждой ракеты свой клиент, физика и журнал с префиксом ID.
+type fleet struct {
+	cfg    rocketclient.Config
+	radius float64 // м
+	jitter time.Duration
+
+	flying   int
+	failed   int // Не удалось подключиться или зарегистрироваться
+	outcomes map[rocketclient.MissionOutcome]int
+	mu       sync.Mutex
+}
+
+// runFleet запускает size ракет и ждет их завершения. Возвращает наибольший
+// код выхода среди ракет, 1 если хотя бы одна не смогла стартовать. ID ракет
+// получаются из cfg.ID добавлением номера.
+func runFleet(ctx context.Context, cfg rocketclient.Config, size int, radiusKm float64, jitter time.Duration) int {
+	f := &fleet{
+		cfg:      cfg,
+		radius:   radiusKm * 1000.0,
+		jitter:   jitter,
+		outcomes: make(map[rocketclient.MissionOutcome]int),
+	}
+	logging.Default().Info("fleet_start", logging.F("size", size), logging.F("radius_km", radiusKm), logging.F("jitter", jitter))
+
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt)
+	go func() {
+		<-sigChan
+		logging.Default().Warn("fleet_interrupted")
+		stop()
+	}()
+
+	done := make(chan struct{})
+	go f.report(done)
+
+	var wg sync.WaitGroup
+	for i := 1; i <= size; i++ {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			f.fly(ctx, id)
+		}(fmt.Sprintf("%s-%03d", cfg.ID, i))
+	}
+	wg.Wait()
+	close(done)
+
+	f.logProgress()
+	logging.Default().Info("fleet_done")
+	return f.exitCode()
+}
+
+func (f *fleet) fly(ctx context.Context, id string) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Duration(rand.Int63n(int64(f.jitter) + 1))):
+	}
+	if !f.add(ctx) {
+		return
+	}
+
+	cfg := f.cfg
+	cfg.ID = id
+	cfg.Logger = logging.Default().WithRocket(id)
+	cfg.Latitude, cfg.Longitude = scatter(cfg.Latitude, cfg.Longitude, f.radius, cfg.Planet.Radius)
+	if cfg.RecordPath != "" {
+		cfg.RecordPath = fleetRecordPath(cfg.RecordPath, id)
+	}
+	if cfg.TelemetryFile != "" {
+		cfg.TelemetryFile = fleetRecordPath(cfg.TelemetryFile, id)
+	}
+	if cfg.CheckpointFile != "" {
+		cfg.CheckpointFile = fleetRecordPath(cfg.CheckpointFile, id)
+	}
+
+	client, err := rocketclient.New(cfg)
+	if err == nil && !cfg.Offline {
+		if err = client.Connect(); err == nil {
+			err = client.Register()
+		}
+	}
+	var summary rocketclient.MissionSummary
+	if err == nil {
+		summary, err = client.Launch(ctx)
+	}
+	if err != nil {
+		cfg.Logger.Error("fleet_rocket_failed", logging.F("error", err))
+		if client != nil {
+			client.Close()
+		}
+		f.mu.Lock()
+		f.flying--
+		f.failed++
+		f.mu.Unlock()
+		return
+	}
+
+	summary.Log(cfg.Logger)
+
+	f.mu.Lock()
+	f.flying--
+	f.outcomes[summary.Outcome]++
+	f.mu.Unlock()
+}
+
+// add учитывает ракету в полете; после остановки флота новые ракеты не стартуют
+func (f *fleet) add(ctx context.Context) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if ctx.Err() != nil {
+		f.outcomes[rocketclient.OutcomeInterrupted]++
+		return false
+	}
+	f.flying++
+	return true
+}
+
+func (f *fleet) report(done <-chan struct{}) {
+	ticker := time.NewTicker(fleetReportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			f.logProgress()
+		}
+	}
+}
+
+func (f *fleet) logProgress() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	logging.Default().Info("fleet_progress",
+		logging.F("flying", f.flying),
+		logging.F("orbit", f.outcomes[rocketclient.OutcomeOrbit]),
+		logging.F("landed", f.outcomes[rocketclient.OutcomeLanded]),
+		logging.F("crashed", f.outcomes[rocketclient.OutcomeCrashed]),
+		logging.F("aborted", f.outcomes[rocketclient.OutcomeAborted]+f.outcomes[rocketclient.OutcomeInterrupted]),
+		logging.F("failed", f.failed))
+}
+
+func (f *fleet) exitCode() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	code := 0
+	if f.failed > 0 {
+		code = 1
+	}
+	for outcome, count := range f.outcomes {
+		if count > 0 && outcome.ExitCode() > code {
+			code = outcome.ExitCode()
+		}
+	}
+	return code
+}
+
+// scatter выбирает случайную точку в круге радиуса radius (м) вокруг точки старта
+func scatter(latitude, longitude, radius, planetRadius float64) (float64, float64) {
+	distance := radius * math.Sqrt(rand.Float64())
+	bearing := rand.Float64() * 2 * math.Pi
+	angle := distance / planetRadius * 180.0 / math.Pi
+
+	latitude += angle * math.Cos(bearing)
+	longitude += angle * math.Sin(bearing) / math.Max(math.Cos(latitude*math.Pi/180.0), 0.01)
+	return latitude, longitude
+}
+
+// fleetRecordPath добавляет ID ракеты к имени файла записи: flight.csv -> flight-rocket-001.csv
+func fleetRecordPath(path, id string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + id + ext
+}
@@ -2,4 +2,14 @@ module cosmodrom/client
 
 go 1.25.5
 
-require github.com/gorilla/websocket v1.5.3
+require (
+	github.com/gorilla/websocket v1.5.3
+	golang.org/x/term v0.38.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require golang.org/x/sys v0.39.0 // indirect
+
+require cosmodrom/protocol v0.0.0
+
+replace cosmodrom/protocol => ../protocol
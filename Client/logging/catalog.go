@@ -0,0 +1,282 @@
+package logging
+
+import "cosmodrom/protocol"
+
+// Catalog - тексты записей журнала клиента по коду. Код и поля записи
+// попадают в JSON-журнал как есть, текст собирается на языке SetLang.
+var Catalog = protocol.LogCatalog{
+	// Запуск клиента
+	"flag_invalid":    {RU: "Ошибка параметров {flag}: {error}", EN: "Invalid {flag}: {error}"},
+	"flags_invalid":   {RU: "Ошибка параметров: {error}", EN: "Invalid flags: {error}"},
+	"flags_conflict":  {RU: "Ошибка параметров: {flag} нельзя совмещать с {other}", EN: "Invalid flags: {flag} cannot be combined with {other}"},
+	"config_invalid":  {RU: "Ошибка конфигурации ракеты: {error}", EN: "Invalid rocket configuration: {error}"},
+	"physics_backend": {RU: "Физическая модель: {backend}", EN: "Physics backend: {backend}"},
+	"planet":          {RU: "Планета старта: {planet}", EN: "Launch planet: {planet}"},
+	"manual_failed":   {RU: "Ошибка ручного управления: {error}", EN: "Manual control error: {error}"},
+	"interrupted":     {RU: "Получен сигнал прерывания, завершение...", EN: "Interrupt received, shutting down..."},
+	"launch_failed":   {RU: "Ошибка инициализации: {error}", EN: "Initialization error: {error}"},
+	"client_done":     {RU: "Клиент завершил работу", EN: "Client finished"},
+	"manual_help": {RU: "Ручное управление: ↑/↓ тангаж, ←/→ рыскание, +/- дроссель, пробел - выключить двигатели, q - выход",
+		EN: "Manual control: ↑/↓ pitch, ←/→ yaw, +/- throttle, space - engines off, q - quit"},
+
+	// Подключение и регистрация
+	"connect_failed":   {RU: "Ошибка подключения: {error}", EN: "Connection error: {error}"},
+	"vehicle_unknown":  {RU: "Сервер не знает ракету -vehicle: {reason}", EN: "Server does not know the -vehicle rocket: {reason}"},
+	"register_timeout": {RU: "{error}: сервер доступен, но не отвечает", EN: "{error}: server is reachable but not responding"},
+	"id_taken":         {RU: "ID {rocket_id} уже занят: укажите другой -id или используйте -auto-id", EN: "ID {rocket_id} is taken: pass another -id or use -auto-id"},
+	"auth_failed":      {RU: "Сервер отказал в доступе: проверьте токен авторизации ({reason})", EN: "Server denied access: check the authorization token ({reason})"},
+	"config_rejected":  {RU: "Сервер отклонил конфигурацию ракеты: {reason}", EN: "Server rejected the rocket configuration: {reason}"},
+	"unknown_field_rejected": {RU: "Сервер не знает поля регистрации: {reason}; версии клиента и сервера различаются?",
+		EN: "Server does not know a registration field: {reason}; do client and server versions differ?"},
+	"server_busy":     {RU: "Сервер сейчас не принимает ракеты ({code}), попробуйте позже", EN: "Server is not accepting rockets right now ({code}), try again later"},
+	"register_failed": {RU: "Ошибка регистрации: {error}", EN: "Registration error: {error}"},
+
+	// Флот и прогоны Монте-Карло
+	"fleet_start": {RU: "Запуск флота из {size} ракет, разброс точек старта {radius_km:%.0f} км, задержка старта до {jitter}",
+		EN: "Launching a fleet of {size} rockets, launch site spread {radius_km:%.0f} km, start delay up to {jitter}"},
+	"fleet_interrupted":   {RU: "Получен сигнал прерывания, остановка флота...", EN: "Interrupt received, stopping the fleet..."},
+	"fleet_done":          {RU: "Флот завершил работу", EN: "Fleet finished"},
+	"fleet_rocket_failed": {RU: "Ракета не стартовала: {error}", EN: "Rocket failed to start: {error}"},
+	"fleet_progress": {RU: "Флот: в полете {flying}, на орбите {orbit}, посадка {landed}, разбились {crashed}, прервано {aborted}, не стартовали {failed}",
+		EN: "Fleet: flying {flying}, in orbit {orbit}, landed {landed}, crashed {crashed}, aborted {aborted}, failed to start {failed}"},
+	"mc_start":       {RU: "Монте-Карло: {runs} прогонов на {workers} потоках, seed {seed}", EN: "Monte Carlo: {runs} runs on {workers} workers, seed {seed}"},
+	"mc_interrupted": {RU: "Получен сигнал прерывания, остановка прогонов...", EN: "Interrupt received, stopping runs..."},
+	"mc_progress":    {RU: "Монте-Карло: выполнено {done} из {runs}", EN: "Monte Carlo: {done} of {runs} done"},
+	"mc_csv_failed":  {RU: "Ошибка записи {path}: {error}", EN: "Failed to write {path}: {error}"},
+	"mc_csv_written": {RU: "Результаты прогонов записаны в {path}", EN: "Run results written to {path}"},
+
+	// Физика
+	"physics_leaked": {RU: "Физика ракеты {name:%q} не закрыта: память освобождена сборщиком мусора",
+		EN: "Rocket physics {name:%q} was not closed: memory freed by the garbage collector"},
+
+	// Регистрация
+	"registered":             {RU: "Регистрация принята: {message} (соединение {conn_id})", EN: "Registration accepted: {message} (connection {conn_id})"},
+	"accepted_decode_failed": {RU: "Ошибка декодирования ответа на регистрацию: {error}", EN: "Failed to decode the registration reply: {error}"},
+	"id_taken_retry":         {RU: "ID {rocket_id} уже занят, повторная регистрация как {new_id}", EN: "ID {rocket_id} is taken, registering again as {new_id}"},
+	"vehicle_received": {RU: "Конфигурация {vehicle:%q} получена с сервера: {name}, двигателей {engines}, {mass_t:%.1f} т",
+		EN: "Vehicle {vehicle:%q} received from the server: {name}, {engines} engines, {mass_t:%.1f} t"},
+
+	// Отказы, время, запись полета
+	"failures_enabled": {RU: "Имитация отказов: {rate:%.3g} отказа на двигатель в минуту, seed {seed}",
+		EN: "Failure simulation: {rate:%.3g} failures per engine per minute, seed {seed}"},
+	"failure_no_engine": {RU: "Отказ двигателя {engine} не выполнен: у ракеты {engines} двигателей, такого нет",
+		EN: "Engine {engine} failure skipped: the rocket has {engines} engines, no such engine"},
+	"engine_failed": {RU: "ОТКАЗ ДВИГАТЕЛЯ {engine} ({number} из {engines}) на T+{time:%.1f} с, высота {altitude_km:%.1f} км",
+		EN: "ENGINE FAILURE {engine} ({number} of {engines}) at T+{time:%.1f} s, altitude {altitude_km:%.1f} km"},
+	"time_warp":       {RU: "Ускорение времени x{warp:%g}", EN: "Time warp x{warp:%g}"},
+	"real_time":       {RU: "Реальное время: {reason}", EN: "Real time: {reason}"},
+	"blackbox_failed": {RU: "Ошибка выгрузки черного ящика: {error}", EN: "Failed to dump the black box: {error}"},
+	"blackbox_saved": {RU: "Черный ящик: последние {window:%.0f} с полета сохранены в {path}",
+		EN: "Black box: last {window:%.0f} s of flight saved to {path}"},
+	"record_failed": {RU: "Ошибка записи полета в {path}, запись остановлена: {error}", EN: "Failed to write flight record to {path}, recording stopped: {error}"},
+	"record_saved":  {RU: "Запись полета сохранена в {path}", EN: "Flight record saved to {path}"},
+
+	// Аварии
+	"impact_failed": {RU: "Прогноз падения не построен: {error}", EN: "Impact prediction failed: {error}"},
+	"impact_none":   {RU: "Траектория не пересекает поверхность, падения не ожидается", EN: "Trajectory does not cross the surface, no impact expected"},
+	"impact": {RU: "Прогноз падения: через {time_to_impact:%.0f} с в точке {latitude:%.3f}°, {longitude:%.3f}°, скорость {speed:%.0f} м/с",
+		EN: "Predicted impact: in {time_to_impact:%.0f} s at {latitude:%.3f}°, {longitude:%.3f}°, speed {speed:%.0f} m/s"},
+	"physics_error": {RU: "Ошибка физики: {error}", EN: "Physics error: {error}"},
+	"physics_last_state": {
+		RU: "Последнее корректное состояние: T+{time:%.2f} с, высота {altitude:%.1f} м, скорость {speed:%.1f} м/с, масса {mass:%.0f} кг, топливо {fuel:%.0f} кг, тангаж {pitch:%.1f}°, дроссель {throttle:%.0f}%",
+		EN: "Last valid state: T+{time:%.2f} s, altitude {altitude:%.1f} m, speed {speed:%.1f} m/s, mass {mass:%.0f} kg, fuel {fuel:%.0f} kg, pitch {pitch:%.1f}°, throttle {throttle:%.0f}%",
+	},
+	"physics_config": {
+		RU: "Ракета {name:%q}: сухая масса {mass_empty:%.0f} кг, топливо {fuel:%.0f}/{fuel_max:%.0f} кг, Cx {drag:%g}, сечение {cross_section:%g} м2, двигателей {engines}",
+		EN: "Rocket {name:%q}: dry mass {mass_empty:%.0f} kg, fuel {fuel:%.0f}/{fuel_max:%.0f} kg, Cd {drag:%g}, cross section {cross_section:%g} m2, {engines} engines",
+	},
+
+	// Полет
+	"connected":        {RU: "Подключено к серверу {server}", EN: "Connected to server {server}"},
+	"surface_rotation": {RU: "Скорость вращения планеты в точке старта: {speed:%.0f} м/с на восток", EN: "Planet rotation at the launch site: {speed:%.0f} m/s eastward"},
+	"physics_ready":    {RU: "Физический движок инициализирован", EN: "Physics engine initialized"},
+	"delta_v": {RU: "Запас характеристической скорости {delta_v:%.0f} м/с, работа двигателей на полной тяге {burn_time:%.0f} с",
+		EN: "Delta-v budget {delta_v:%.0f} m/s, full-thrust burn time {burn_time:%.0f} s"},
+	"gravity_turn": {RU: "Целевая орбита: {target_orbit_km:%.0f} км, начало поворота: {turn_start:%.0f} м, окончание: {turn_end_km:%.0f} км",
+		EN: "Target orbit: {target_orbit_km:%.0f} km, turn start: {turn_start:%.0f} m, turn end: {turn_end_km:%.0f} km"},
+	"simulation_start": {RU: "Запуск симуляции ракеты {rocket_id}", EN: "Starting simulation of rocket {rocket_id}"},
+	"simulation_config": {RU: "Конфигурация: {name}, двигатели: {engines} x {thrust_kn:%.0f} кН",
+		EN: "Configuration: {name}, engines: {engines} x {thrust_kn:%.0f} kN"},
+	"engine_warning": {RU: "Двигатель {warning} - проверьте тягу и расход", EN: "Engine {warning} - check thrust and flow rate"},
+	"simulation_behind": {RU: "Симуляция отстает от реального времени, пропущено {dropped:%.3f} с",
+		EN: "Simulation is behind real time, skipped {dropped:%.3f} s"},
+	"autopilot_step": {
+		RU: "T+{time:%.1f} с, фаза {phase}: тангаж {pitch:%.1f}°, рыскание {yaw:%.1f}°, дроссель {throttle:%.0f}%, высота {altitude_km:%.2f} км, апоцентр {apoapsis_km:%.1f} км",
+		EN: "T+{time:%.1f} s, phase {phase}: pitch {pitch:%.1f}°, yaw {yaw:%.1f}°, throttle {throttle:%.0f}%, altitude {altitude_km:%.2f} km, apoapsis {apoapsis_km:%.1f} km",
+	},
+	"status": {
+		RU: "T+{time:%.0f} с: высота {altitude_km:%.2f} км, скорость {speed:%.1f} м/с, топливо {fuel:%.0f} кг, апоцентр {apoapsis_km:%.1f} км, перицентр {periapsis_km:%.1f} км[[, RTT {rtt_ms:%.1f} мс]][[, RTT нет данных{rtt_unknown:}]][[, до цели {target_distance_km:%.2f} км, сближение {closing:%.1f} м/с]][[, цель {target_hidden} не видна]]",
+		EN: "T+{time:%.0f} s: altitude {altitude_km:%.2f} km, speed {speed:%.1f} m/s, fuel {fuel:%.0f} kg, apoapsis {apoapsis_km:%.1f} km, periapsis {periapsis_km:%.1f} km[[, RTT {rtt_ms:%.1f} ms]][[, RTT unknown{rtt_unknown:}]][[, target {target_distance_km:%.2f} km away, closing {closing:%.1f} m/s]][[, target {target_hidden} not visible]]",
+	},
+	"flight_aborted": {RU: "Полет ракеты {rocket_id} прекращен ({reason}), конечная высота {final_altitude:%.2f} м, скорость касания {final_speed:%.1f} м/с",
+		EN: "Flight of rocket {rocket_id} aborted ({reason}), final altitude {final_altitude:%.2f} m, touchdown speed {final_speed:%.1f} m/s"},
+	"landed":       {RU: "Ракета {rocket_id} успешно приземлилась", EN: "Rocket {rocket_id} landed successfully"},
+	"crashed":      {RU: "Ракета {rocket_id} разбилась", EN: "Rocket {rocket_id} crashed"},
+	"touchdown":    {RU: "Конечная высота: {final_altitude:%.2f} м, скорость касания: {final_speed:%.1f} м/с", EN: "Final altitude: {final_altitude:%.2f} m, touchdown speed: {final_speed:%.1f} m/s"},
+	"orbit_failed": {RU: "Прогноз орбиты недоступен: {error}", EN: "Orbit prediction unavailable: {error}"},
+	"hop_mode":     {RU: "Режим подскока: подъем до {target_altitude:%.0f} м и посадка", EN: "Hop mode: climb to {target_altitude:%.0f} m and land"},
+	"chase_mode":   {RU: "Режим преследования: {offset:%.0f} м позади ракеты {target}", EN: "Chase mode: {offset:%.0f} m behind rocket {target}"},
+	"script_loaded": {RU: "Сценарий {name:%q}: {actions} действий, автопилот -mode не используется",
+		EN: "Script {name:%q}: {actions} actions, the -mode autopilot is not used"},
+	"tls_insecure": {RU: "Проверка TLS-сертификата сервера отключена (-insecure-skip-verify)",
+		EN: "Server TLS certificate verification is disabled (-insecure-skip-verify)"},
+	"time_warp_limited": {RU: "Ускорение времени ограничено x{warp:%g} при работе с сервером", EN: "Time warp limited to x{warp:%g} when flying with a server"},
+
+	// Сообщения сервера
+	"shutdown_received":     {RU: "Получена команда на выключение от сервера", EN: "Shutdown command received from the server"},
+	"message_decode_failed": {RU: "Ошибка декодирования сообщения {type}: {error}", EN: "Failed to decode {type} message: {error}"},
+	"server_dropped": {RU: "СЕРВЕР ОТБРОСИЛ СООБЩЕНИЕ {type:%q} #{seq} [{code}]: {detail}[[ (и еще {suppressed} таких же)]]",
+		EN: "SERVER DROPPED MESSAGE {type:%q} #{seq} [{code}]: {detail}[[ ({suppressed} more like it)]]"},
+	"command_invalid":  {RU: "Некорректная команда: {error}", EN: "Invalid command: {error}"},
+	"attitude_invalid": {RU: "Некорректная команда ориентации: {error}", EN: "Invalid attitude command: {error}"},
+	"command_actions":  {RU: "Получена команда сервера без дросселей: {actions}", EN: "Server command without throttles received: {actions}"},
+	"command_received": {RU: "Получена команда управления от сервера (приоритет {hold})", EN: "Control command received from the server (priority {hold})"},
+	"command_expired":  {RU: "Команда сервера истекла, управление возвращено автопилоту", EN: "Server command expired, control returned to the autopilot"},
+	"command_failed": {RU: "Команда сервера не выполнена: {error}, управление возвращено автопилоту",
+		EN: "Server command failed: {error}, control returned to the autopilot"},
+	"command_engines_mismatch": {RU: "Команда сервера на {command_engines} двигателей, у ракеты {engines}: управление возвращено автопилоту",
+		EN: "Server command is for {command_engines} engines, the rocket has {engines}: control returned to the autopilot"},
+	"action_failed": {RU: "Действие команды не выполнено: {error}", EN: "Command action failed: {error}"},
+	"payload_released": {RU: "Отделение нагрузки {name:%q} ({mass:%.0f} кг) на высоте {altitude_km:%.1f} км, скорость {speed:%.0f} м/с",
+		EN: "Payload {name:%q} ({mass:%.0f} kg) released at {altitude_km:%.1f} km, speed {speed:%.0f} m/s"},
+	"warning_invalid": {RU: "Предупреждение отброшено: {error}", EN: "Warning dropped: {error}"},
+	"warning":         {RU: "ПРЕДУПРЕЖДЕНИЕ [{severity}]: {warning}", EN: "WARNING [{severity}]: {warning}"},
+	"proximity": {RU: "Сближение с {other_rocket_id}: {distance:%.0f} м через {time_to_closest:%.1f} с",
+		EN: "Approach with {other_rocket_id}: {distance:%.0f} m in {time_to_closest:%.1f} s"},
+	"ground_proximity":    {RU: "До поверхности {time_to_impact:%.1f} с", EN: "{time_to_impact:%.1f} s to the surface"},
+	"warning_unknown":     {RU: "Неизвестный вид предупреждения {code:%q}", EN: "Unknown warning kind {code:%q}"},
+	"attitude_released":   {RU: "Удержание ориентации снято ({source})", EN: "Attitude hold released ({source})"},
+	"attitude_pitch":      {RU: "Удержание тангажа {pitch:%.1f}° ({source})", EN: "Holding pitch {pitch:%.1f}° ({source})"},
+	"attitude_hold":       {RU: "Удержание ориентации {mode} ({source})", EN: "Holding attitude {mode} ({source})"},
+	"trajectory_done":     {RU: "Траектория пройдена, управление возвращено автопилоту", EN: "Trajectory complete, control returned to the autopilot"},
+	"trajectory_empty":    {RU: "Получена пустая траектория, управление у автопилота", EN: "Empty trajectory received, the autopilot is in control"},
+	"trajectory_received": {RU: "Получена траектория от сервера: {waypoints} контрольных точек", EN: "Trajectory received from the server: {waypoints} waypoints"},
+	"waypoint_reached":    {RU: "Контрольная точка {waypoint}/{waypoints} пройдена", EN: "Waypoint {waypoint}/{waypoints} reached"},
+	"waypoint_skipped":    {RU: "Контрольная точка {waypoint}/{waypoints} позади ракеты, пропущена", EN: "Waypoint {waypoint}/{waypoints} is behind the rocket, skipped"},
+
+	// Связь
+	"heartbeat_missed": {RU: "ПРЕДУПРЕЖДЕНИЕ: нет ответа сервера на heartbeat за {timeout} (пропущено подряд: {missed})",
+		EN: "WARNING: no heartbeat reply from the server in {timeout} (missed in a row: {missed})"},
+	"heartbeat_restored": {RU: "Сервер снова отвечает на heartbeat, RTT {rtt_ms:%.1f} мс", EN: "Server answers heartbeats again, RTT {rtt_ms:%.1f} ms"},
+	"clock_skew": {RU: "ПРЕДУПРЕЖДЕНИЕ: часы сервера расходятся с часами ракеты на {skew}, метки времени в телеметрии неточны",
+		EN: "WARNING: server clock is off from the rocket clock by {skew}, telemetry timestamps are inaccurate"},
+	"connection_lost":    {RU: "Соединение с сервером потеряно: {error}", EN: "Connection to the server lost: {error}"},
+	"reconnect_wait":     {RU: "Переподключение через {delay} (попытка {attempt})", EN: "Reconnecting in {delay} (attempt {attempt})"},
+	"reconnect_failed":   {RU: "Попытка {attempt}: {error}", EN: "Attempt {attempt}: {error}"},
+	"reconnect_rejected": {RU: "Сервер отклонил повторную регистрацию: {error}", EN: "Server rejected the re-registration: {error}"},
+	"reconnected":        {RU: "Соединение восстановлено, телеметрия возобновлена", EN: "Connection restored, telemetry resumed"},
+	"reconnect_gave_up": {RU: "Не удалось восстановить соединение после {attempts} попыток, завершение работы...",
+		EN: "Could not restore the connection after {attempts} attempts, shutting down..."},
+	"backlog_flush": {RU: "Связь восстановлена: досылается {frames} кадров телеметрии (T+{first_time:%.0f}..{last_time:%.0f} с)",
+		EN: "Link restored: resending {frames} telemetry frames (T+{first_time:%.0f}..{last_time:%.0f} s)"},
+	"telemetry_file": {RU: "Телеметрия записывается в {path}", EN: "Telemetry is written to {path}"},
+	"offline_mode": {RU: "Автономный режим: сервер не используется, команды и предупреждения недоступны",
+		EN: "Offline mode: no server, commands and warnings are unavailable"},
+	"telemetry_write_stopped": {RU: "Ошибка записи телеметрии, запись остановлена: {error}", EN: "Failed to write telemetry, recording stopped: {error}"},
+	"telemetry_write_failed":  {RU: "Ошибка записи телеметрии: {error}", EN: "Failed to write telemetry: {error}"},
+	"noise_enabled": {
+		RU: "Искажение телеметрии: шум позиции {position_sigma:%.1f} м, скорости {velocity_sigma:%.2f} м/с, высоты {altitude_sigma:%.1f} м, потеря кадров {dropout:%.0f}%, задержка {latency}, seed {seed}",
+		EN: "Telemetry distortion: position noise {position_sigma:%.1f} m, velocity {velocity_sigma:%.2f} m/s, altitude {altitude_sigma:%.1f} m, frame loss {dropout:%.0f}%, latency {latency}, seed {seed}",
+	},
+	"noise_frame_dropped": {RU: "Кадр телеметрии T+{time:%.1f} с потерян (-noise-dropout)", EN: "Telemetry frame T+{time:%.1f} s lost (-noise-dropout)"},
+
+	// Преследование
+	"chase_target_back":   {RU: "Цель {target} снова видна, преследование продолжено", EN: "Target {target} is visible again, chase resumed"},
+	"chase_station":       {RU: "Скорость цели {target} уравнена: до цели {distance:%.0f} м", EN: "Matched speed with target {target}: {distance:%.0f} m away"},
+	"chase_approach":      {RU: "Строй с целью {target} нарушен, сближение", EN: "Formation with target {target} broken, closing in"},
+	"chase_target_lost":   {RU: "Цель {target} потеряна: {reason}, ориентация удерживается", EN: "Target {target} lost: {reason}, holding attitude"},
+	"chase_subscribed":    {RU: "Подписка на телеметрию цели {target}", EN: "Subscribed to telemetry of target {target}"},
+	"chase_watch_lost":    {RU: "Наблюдение за целью прервано: {error}", EN: "Target watch interrupted: {error}"},
+	"chase_target_joined": {RU: "Цель {target} ({name}) на сервере", EN: "Target {target} ({name}) is on the server"},
+
+	// Предстартовый отсчет
+	"countdown_started":  {RU: "Предстартовый отсчет: T-{remaining}", EN: "Countdown: T-{remaining}"},
+	"countdown_ignition": {RU: "T-0: зажигание", EN: "T-0: ignition"},
+	"countdown_mark":     {RU: "T-{remaining}", EN: "T-{remaining}"},
+	"countdown_hold":     {RU: "ЗАДЕРЖКА на T-{remaining}: {reason}", EN: "HOLD at T-{remaining}: {reason}"},
+	"countdown_resumed":  {RU: "Отсчет продолжен с T-{remaining}: {reason}", EN: "Countdown resumed at T-{remaining}: {reason}"},
+	"countdown_scrubbed": {RU: "ПУСК ОТМЕНЕН на T-{remaining}: {reason}", EN: "LAUNCH SCRUBBED at T-{remaining}: {reason}"},
+
+	// Программа полета
+	"hop_cutoff": {RU: "Двигатели выключены на высоте {altitude:%.0f} м, вертикальная скорость {vertical_speed:%.1f} м/с",
+		EN: "Engines cut off at {altitude:%.0f} m, vertical speed {vertical_speed:%.1f} m/s"},
+	"hop_fuel_out":  {RU: "Топливо закончилось на подъеме, высота {altitude:%.0f} м", EN: "Fuel ran out during the climb, altitude {altitude:%.0f} m"},
+	"hop_parachute": {RU: "Раскрытие парашюта на высоте {altitude:%.0f} м, скорость {speed:%.1f} м/с", EN: "Parachute deployed at {altitude:%.0f} m, speed {speed:%.1f} m/s"},
+	"hop_landing_burn": {RU: "Включение двигателей для посадки на высоте {altitude:%.0f} м, скорость {speed:%.1f} м/с",
+		EN: "Landing burn at {altitude:%.0f} m, speed {speed:%.1f} m/s"},
+	"hop_parachute_torn": {RU: "Парашют порван на скорости {speed:%.1f} м/с, посадка на двигателях", EN: "Parachute torn at {speed:%.1f} m/s, landing on engines"},
+	"hop_thrust":         {RU: "Тяга для посадки пересчитана: {thrust_kn:%.0f} кН", EN: "Landing thrust recalculated: {thrust_kn:%.0f} kN"},
+	"stage_commanded": {RU: "Ступень {stage} отделена по команде, не выработано {leftover:%.0f} кг топлива",
+		EN: "Stage {stage} separated on command, {leftover:%.0f} kg of fuel unused"},
+	"stage_separated": {
+		RU: "Отделение ступени {stage} ({name}) на высоте {altitude_km:%.1f} км, скорость {speed:%.0f} м/с: сброшено {dropped:%.0f} кг, двигателей {engines}, тяга {thrust_kn:%.0f} кН",
+		EN: "Stage {stage} ({name}) separated at {altitude_km:%.1f} km, speed {speed:%.0f} m/s: dropped {dropped:%.0f} kg, {engines} engines, thrust {thrust_kn:%.0f} kN",
+	},
+	"transfer_fuel_out":  {RU: "Топливо закончилось во время перехода на {target_km:%.1f} км", EN: "Fuel ran out during the transfer to {target_km:%.1f} km"},
+	"all_engines_failed": {RU: "Все двигатели отказали, выход на орбиту невозможен", EN: "All engines failed, orbit cannot be reached"},
+	"thrust_reduced": {RU: "Доступная тяга {thrust_kn:%.0f} кН ({ratio:%.0f}% номинальной), разгон и скругление будут дольше",
+		EN: "Available thrust {thrust_kn:%.0f} kN ({ratio:%.0f}% of nominal), ascent and circularization will take longer"},
+	"apoapsis_dropped": {RU: "Апоцентр упал до {apoapsis_km:%.1f} км, повторное включение двигателей", EN: "Apoapsis dropped to {apoapsis_km:%.1f} km, relighting engines"},
+	"meco": {RU: "MECO: апоцентр {apoapsis_km:%.1f} км достигнут на высоте {altitude_km:%.1f} км, полет к апоцентру ({time_to_apoapsis:%.0f} с)",
+		EN: "MECO: apoapsis {apoapsis_km:%.1f} km reached at {altitude_km:%.1f} km, coasting to apoapsis ({time_to_apoapsis:%.0f} s)"},
+	"circularize": {RU: "Скругление орбиты на высоте {altitude_km:%.1f} км (перицентр {periapsis_km:%.1f} км)",
+		EN: "Circularizing at {altitude_km:%.1f} km (periapsis {periapsis_km:%.1f} km)"},
+	"orbit_reached": {
+		RU: "Орбита сформирована: апоцентр {apoapsis_km:%.1f} км, перицентр {periapsis_km:%.1f} км, эксцентриситет {eccentricity:%.4f}, период {period_min:%.1f} мин, топливо {fuel:%.0f} кг",
+		EN: "Orbit reached: apoapsis {apoapsis_km:%.1f} km, periapsis {periapsis_km:%.1f} km, eccentricity {eccentricity:%.4f}, period {period_min:%.1f} min, fuel {fuel:%.0f} kg",
+	},
+	"orbit_inclination": {RU: "Наклонение орбиты {inclination:%.2f}° (цель {target_inclination:%.2f}°)",
+		EN: "Orbit inclination {inclination:%.2f}° (target {target_inclination:%.2f}°)"},
+	"transfer_plan": {
+		RU: "Переход Хомана {from_km:%.1f} -> {target_km:%.1f} км: импульсы {delta_v1:%+.1f} и {delta_v2:%+.1f} м/с (всего {delta_v:%.1f} м/с), перелет {transfer_min:%.1f} мин",
+		EN: "Hohmann transfer {from_km:%.1f} -> {target_km:%.1f} km: burns {delta_v1:%+.1f} and {delta_v2:%+.1f} m/s (total {delta_v:%.1f} m/s), transfer {transfer_min:%.1f} min",
+	},
+	"transfer_coast": {RU: "Первый импульс выполнен за {burn_time:%.1f} с: апоцентр {apoapsis_km:%.1f} км, перицентр {periapsis_km:%.1f} км, полет по переходной орбите",
+		EN: "First burn done in {burn_time:%.1f} s: apoapsis {apoapsis_km:%.1f} km, periapsis {periapsis_km:%.1f} km, coasting on the transfer orbit"},
+	"transfer_circularize": {RU: "Второй импульс на высоте {altitude_km:%.1f} км", EN: "Second burn at {altitude_km:%.1f} km"},
+	"fuel_depleted": {RU: "Топливо закончилось до выхода на орбиту: апоцентр {apoapsis_km:%.1f} км, перицентр {periapsis_km:%.1f} км",
+		EN: "Fuel ran out before orbit: apoapsis {apoapsis_km:%.1f} km, periapsis {periapsis_km:%.1f} km"},
+	"circularize_plan_failed": {RU: "План скругления не построен: {error}", EN: "Circularization plan failed: {error}"},
+	"circularize_plan": {RU: "План скругления: {delta_v:%.0f} м/с, работа {burn_time:%.0f} с на полной тяге, включение через {time_to_ignition:%.0f} с",
+		EN: "Circularization plan: {delta_v:%.0f} m/s, {burn_time:%.0f} s at full thrust, ignition in {time_to_ignition:%.0f} s"},
+	"circularize_short": {RU: "На скругление не хватает топлива: нужно {delta_v:%.0f} м/с, запас {delta_v_remaining:%.0f} м/с",
+		EN: "Not enough fuel to circularize: need {delta_v:%.0f} m/s, have {delta_v_remaining:%.0f} m/s"},
+	"apoapsis_control": {RU: "Регулятор апоцентра включен: апоцентр {apoapsis_km:%.1f} км, цель {target_km:%.1f} км",
+		EN: "Apoapsis controller engaged: apoapsis {apoapsis_km:%.1f} km, target {target_km:%.1f} km"},
+	"max_q":         {RU: "Max-Q: {max_q_kpa:%.1f} кПа на T+{time:%.1f} с", EN: "Max-Q: {max_q_kpa:%.1f} kPa at T+{time:%.1f} s"},
+	"max_q_below":   {RU: "Скоростной напор ниже {limit_kpa:%.1f} кПа, полная тяга", EN: "Dynamic pressure below {limit_kpa:%.1f} kPa, full thrust"},
+	"max_q_above":   {RU: "Скоростной напор выше предела {limit_kpa:%.1f} кПа, тяга снижена", EN: "Dynamic pressure above the {limit_kpa:%.1f} kPa limit, thrust reduced"},
+	"avoid_cleared": {RU: "Уклонение завершено: опасность снизилась до {severity}", EN: "Avoidance finished: danger dropped to {severity}"},
+	"avoid_critical": {RU: "Уклонение: критическое сближение с {other_rocket_id}, тяга {throttle:%.0f}%, отворот {yaw_offset:%.0f}°",
+		EN: "Avoidance: critical approach with {other_rocket_id}, throttle {throttle:%.0f}%, yaw off {yaw_offset:%.0f}°"},
+	"avoid": {RU: "Уклонение: сближение с {other_rocket_id}, тяга {throttle:%.0f}% на {duration}",
+		EN: "Avoidance: approach with {other_rocket_id}, throttle {throttle:%.0f}% for {duration}"},
+	"avoid_timeout": {RU: "Уклонение завершено по таймауту, возврат к программе полета", EN: "Avoidance timed out, back to the flight program"},
+
+	// Сценарий и контрольные точки
+	"script_fuel_out": {RU: "Сценарий: топливо закончилось при скруглении, перицентр {periapsis_km:%.1f} км",
+		EN: "Script: fuel ran out while circularizing, periapsis {periapsis_km:%.1f} km"},
+	"script_orbit": {RU: "Сценарий: орбита сформирована, апоцентр {apoapsis_km:%.1f} км, перицентр {periapsis_km:%.1f} км",
+		EN: "Script: orbit reached, apoapsis {apoapsis_km:%.1f} km, periapsis {periapsis_km:%.1f} km"},
+	"script_action": {RU: "Сценарий, T+{time:%.1f} с (план T+{planned:%g}): {action}, высота {altitude_km:%.2f} км",
+		EN: "Script, T+{time:%.1f} s (planned T+{planned:%g}): {action}, altitude {altitude_km:%.2f} km"},
+	"checkpoint_failed": {RU: "Ошибка сохранения контрольной точки в {path}, сохранение остановлено: {error}",
+		EN: "Failed to save checkpoint to {path}, checkpointing stopped: {error}"},
+	"checkpoint_vehicle_mismatch": {RU: "Контрольная точка {path} сохранена для ракеты {saved:%q}, а не {name:%q}",
+		EN: "Checkpoint {path} was saved for rocket {saved:%q}, not {name:%q}"},
+	"checkpoint_resumed": {RU: "Полет продолжен с T+{time:%.1f} с из {path}: высота {altitude_km:%.1f} км, скорость {speed:%.0f} м/с",
+		EN: "Flight resumed at T+{time:%.1f} s from {path}: altitude {altitude_km:%.1f} km, speed {speed:%.0f} m/s"},
+
+	// Итог миссии
+	"summary_outcome": {RU: "Итог миссии: {outcome}", EN: "Mission outcome: {outcome}"},
+	"summary_flight": {RU: "Макс. высота: {max_altitude_km:%.2f} км, макс. скорость: {max_speed:%.1f} м/с, израсходовано топлива: {fuel_used:%.0f} кг, время полета: {flight_time:%.1f} с",
+		EN: "Max altitude: {max_altitude_km:%.2f} km, max speed: {max_speed:%.1f} m/s, fuel used: {fuel_used:%.0f} kg, flight time: {flight_time:%.1f} s"},
+	"summary_orbit":         {RU: "Орбита: апоцентр {apoapsis_km:%.1f} км, перицентр {periapsis_km:%.1f} км", EN: "Orbit: apoapsis {apoapsis_km:%.1f} km, periapsis {periapsis_km:%.1f} km"},
+	"summary_server_errors": {RU: "Сервер отбросил сообщений клиента: {errors}", EN: "Client messages dropped by the server: {errors}"},
+	"flight_abort": {RU: "АВАРИЙНОЕ ПРЕКРАЩЕНИЕ ПОЛЕТА на T+{time:%.1f} с, высота {altitude_km:%.2f} км: {reason}",
+		EN: "FLIGHT ABORT at T+{time:%.1f} s, altitude {altitude_km:%.2f} km: {reason}"},
+	"flight_abort_physics": {RU: "АВАРИЙНОЕ ПРЕКРАЩЕНИЕ ПОЛЕТА: {error}", EN: "FLIGHT ABORT: {error}"},
+	"orbit_achieved":       {RU: "Ракета {rocket_id} вышла на орбиту", EN: "Rocket {rocket_id} reached orbit"},
+	"launch_azimuth": {RU: "Наклонение {inclination:%.1f}°: азимут пуска {azimuth:%.2f}° (без учета вращения планеты {inertial_azimuth:%.2f}°)",
+		EN: "Inclination {inclination:%.1f}°: launch azimuth {azimuth:%.2f}° ({inertial_azimuth:%.2f}° ignoring planet rotation)"},
+}
@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCatalogComplete(t *testing.T) {
+	if err := Catalog.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Каждый код, который клиент передает Debug, Info, Warn, Error и Fatal,
+// есть в каталоге, и в каталоге нет неиспользуемых кодов
+func TestCatalogCodesUsed(t *testing.T) {
+	levels := map[string]bool{"Debug": true, "Info": true, "Warn": true, "Error": true, "Fatal": true}
+	used := map[string]bool{}
+	fset := token.NewFileSet()
+	err := filepath.WalkDir("..", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return err
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) == 0 {
+				return true
+			}
+			fn, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok || !levels[fn.Sel.Name] {
+				return true
+			}
+			literal, ok := call.Args[0].(*ast.BasicLit)
+			if !ok || literal.Kind != token.STRING {
+				return true
+			}
+			code, _ := strconv.Unquote(literal.Value)
+			if _, ok := Catalog[code]; !ok {
+				t.Errorf("%s: кода %q нет в каталоге", fset.Position(call.Pos()), code)
+			}
+			used[code] = true
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for code := range Catalog {
+		if !used[code] {
+			t.Errorf("код %q не используется", code)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+// Package logging - журнал клиента с уровнями и выводом текстом или JSON.
+// Журналы ракет флота пишут в общий вывод, каждый со своим ID. Запись -
+// код из Catalog и поля; текст собирается на языке SetLang.
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"cosmodrom/protocol"
+)
+
+type Level int
+
+const (
+	LevelDebug Level = iota // Подробности каждого шага автопилота
+	LevelInfo               // Этапы полета
+	LevelWarn               // Предупреждения, потеря связи
+	LevelError              // Аварии и ошибки
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+	return "level(" + strconv.Itoa(int(l)) + ")"
+}
+
+// Field - дополнительное поле записи, например высота
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// output общий для журнала и всех производных от него
+type output struct {
+	mu      sync.Mutex
+	w       io.Writer
+	level   Level
+	json    bool
+	lang    protocol.LogLang
+	catalog protocol.LogCatalog
+	now     func() time.Time
+}
+
+// Logger пишет записи не ниже заданного уровня. Безопасен для
+// использования из нескольких горутин.
+type Logger struct {
+	out      *output
+	rocketID *string // Общий у журнала ракеты и его With, меняется SetRocket
+	fields   []Field
+}
+
+// New создает журнал; jsonFormat - одна JSON-запись на строку
+func New(w io.Writer, level Level, jsonFormat bool) *Logger {
+	return &Logger{
+		out:      &output{w: w, level: level, json: jsonFormat, lang: protocol.LogLangRU, catalog: Catalog, now: time.Now},
+		rocketID: new(string),
+	}
+}
+
+var defaultLogger = New(os.Stderr, LevelInfo, false)
+
+// Default - журнал процесса; main заменяет его по флагам через SetDefault
+func Default() *Logger {
+	return defaultLogger
+}
+
+func SetDefault(l *Logger) {
+	defaultLogger = l
+}
+
+// WithRocket возвращает журнал ракеты: в тексте строки помечены [ID],
+// в JSON - полем rocket_id
+func (l *Logger) WithRocket(id string) *Logger {
+	return &Logger{out: l.out, rocketID: &id, fields: l.fields}
+}
+
+// SetRocket меняет ID ракеты у журнала и всех журналов, полученных из него
+// через With (например, после повторной регистрации с другим ID)
+func (l *Logger) SetRocket(id string) {
+	l.out.mu.Lock()
+	*l.rocketID = id
+	l.out.mu.Unlock()
+}
+
+// With возвращает журнал, добавляющий поля к каждой записи
+func (l *Logger) With(fields ...Field) *Logger {
+	merged := make([]Field, 0, len(l.fields)+len(fields))
+	merged = append(merged, l.fields...)
+	merged = append(merged, fields...)
+	return &Logger{out: l.out, rocketID: l.rocketID, fields: merged}
+}
+
+// SetOutput меняет вывод у всех журналов с общим выводом
+func (l *Logger) SetOutput(w io.Writer) {
+	l.out.mu.Lock()
+	l.out.w = w
+	l.out.mu.Unlock()
+}
+
+// SetLang меняет язык текста записей у всех журналов с общим выводом;
+// код и поля записи от языка не зависят
+func (l *Logger) SetLang(lang protocol.LogLang) {
+	l.out.mu.Lock()
+	l.out.lang = lang
+	l.out.mu.Unlock()
+}
+
+func (l *Logger) Writer() io.Writer {
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	return l.out.w
+}
+
+func (l *Logger) Enabled(level Level) bool {
+	return level >= l.out.level
+}
+
+// Debug пишет запись с кодом из Catalog; values - поля для текста записи.
+// Шаблону доступны и поля журнала из With.
+func (l *Logger) Debug(code string, values ...Field) {
+	l.log(LevelDebug, code, values)
+}
+
+func (l *Logger) Info(code string, values ...Field) {
+	l.log(LevelInfo, code, values)
+}
+
+func (l *Logger) Warn(code string, values ...Field) {
+	l.log(LevelWarn, code, values)
+}
+
+func (l *Logger) Error(code string, values ...Field) {
+	l.log(LevelError, code, values)
+}
+
+// Fatal пишет ошибку и завершает процесс с кодом 1
+func (l *Logger) Fatal(code string, values ...Field) {
+	l.log(LevelError, code, values)
+	os.Exit(1)
+}
+
+func (l *Logger) log(level Level, code string, values []Field) {
+	if !l.Enabled(level) {
+		return
+	}
+	normalized := make([]Field, len(values))
+	fields := make(protocol.LogFields, len(l.fields)+len(values))
+	for _, field := range l.fields {
+		fields[field.Key] = protocol.LogValue(field.Value)
+	}
+	for i, field := range values {
+		normalized[i] = F(field.Key, protocol.LogValue(field.Value))
+		fields[field.Key] = normalized[i].Value
+	}
+
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+
+	entry := record{
+		level:    level,
+		time:     l.out.now(),
+		rocketID: *l.rocketID,
+		code:     code,
+		message:  l.out.catalog.Format(l.out.lang, code, fields),
+		fields:   l.fields,
+		values:   normalized,
+	}
+	var line []byte
+	if l.out.json {
+		line = entry.appendJSON(nil)
+	} else {
+		line = entry.appendText(nil)
+	}
+	l.out.w.Write(line)
+}
+
+type record struct {
+	level    Level
+	time     time.Time
+	rocketID string
+	code     string
+	message  string
+	fields   []Field // Поля журнала из With
+	values   []Field // Поля записи; в тексте они уже подставлены в message
+}
+
+// appendText - формат стандартного log: "2006/01/02 15:04:05 [ID] сообщение key=value"
+func (e record) appendText(buf []byte) []byte {
+	buf = e.time.AppendFormat(buf, "2006/01/02 15:04:05 ")
+	if e.rocketID != "" {
+		buf = append(buf, '[')
+		buf = append(buf, e.rocketID...)
+		buf = append(buf, "] "...)
+	}
+	buf = append(buf, e.message...)
+	for _, field := range e.fields {
+		buf = append(buf, ' ')
+		buf = append(buf, field.Key...)
+		buf = append(buf, '=')
+		buf = append(buf, fmt.Sprint(field.Value)...)
+	}
+	return append(buf, '\n')
+}
+
+// appendJSON пишет поля в постоянном порядке: level, time, rocket_id (если
+// задан), code, message, затем поля журнала и поля записи в порядке
+// добавления
+func (e record) appendJSON(buf []byte) []byte {
+	buf = append(buf, `{"level":`...)
+	buf = appendJSONValue(buf, e.level.String())
+	buf = append(buf, `,"time":`...)
+	buf = appendJSONValue(buf, e.time.Format(time.RFC3339Nano))
+	if e.rocketID != "" {
+		buf = append(buf, `,"rocket_id":`...)
+		buf = appendJSONValue(buf, e.rocketID)
+	}
+	buf = append(buf, `,"code":`...)
+	buf = appendJSONValue(buf, e.code)
+	buf = append(buf, `,"message":`...)
+	buf = appendJSONValue(buf, e.message)
+	for _, fields := range [][]Field{e.fields, e.values} {
+		for _, field := range fields {
+			buf = append(buf, ',')
+			buf = appendJSONValue(buf, field.Key)
+			buf = append(buf, ':')
+			buf = appendJSONValue(buf, field.Value)
+		}
+	}
+	return append(buf, "}\n"...)
+}
+
+// appendJSONValue кодирует значение; то, что не кодируется в JSON
+// (NaN, Inf, каналы), пишется строкой. "<", ">" и "&" не экранируются:
+// журнал не вставляется в HTML.
+func appendJSONValue(buf []byte, value interface{}) []byte {
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		data.Reset()
+		encoder.Encode(fmt.Sprint(value))
+	}
+	return append(buf, bytes.TrimSuffix(data.Bytes(), []byte("\n"))...)
+}
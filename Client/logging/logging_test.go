@@ -0,0 +1,136 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"cosmodrom/protocol"
+)
+
+var testTime = time.Date(2025, 3, 14, 9, 26, 53, 589000000, time.UTC)
+
+var testCatalog = protocol.LogCatalog{
+	"meco":    {RU: "MECO на высоте {altitude_km:%.1f} км", EN: "MECO at {altitude_km:%.1f} km"},
+	"fleet":   {RU: "флот", EN: "fleet"},
+	"quotes":  {RU: "кавычки \" и\nперевод строки, a < b & c > d", EN: "quotes"},
+	"step":    {RU: "шаг T+{time:%.0f} на высоте {altitude:%.0f} м", EN: "step T+{time:%.0f} at {altitude:%.0f} m"},
+	"started": {RU: "старт", EN: "liftoff"},
+}
+
+func newTestLogger(level Level, jsonFormat bool) (*Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	l := New(&buf, level, jsonFormat)
+	l.out.now = func() time.Time { return testTime }
+	l.out.catalog = testCatalog
+	return l, &buf
+}
+
+func TestJSONRecord(t *testing.T) {
+	l, buf := newTestLogger(LevelInfo, true)
+	l.WithRocket("rocket-001").With(F("altitude", 1250.5), F("stage", 2)).Info("meco", F("altitude_km", 1.25))
+
+	want := `{"level":"info","time":"2025-03-14T09:26:53.589Z","rocket_id":"rocket-001","code":"meco","message":"MECO на высоте 1.2 км","altitude":1250.5,"stage":2,"altitude_km":1.25}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("запись:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestJSONWithoutRocket(t *testing.T) {
+	l, buf := newTestLogger(LevelInfo, true)
+	l.Warn("fleet")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("некорректный JSON %q: %v", buf.String(), err)
+	}
+	if _, ok := entry["rocket_id"]; ok {
+		t.Errorf("rocket_id без ракеты: %q", buf.String())
+	}
+	if entry["level"] != "warn" {
+		t.Errorf("level = %v, ожидался warn", entry["level"])
+	}
+}
+
+func TestJSONEscaping(t *testing.T) {
+	l, buf := newTestLogger(LevelInfo, true)
+	l.Info("quotes")
+
+	if strings.Count(buf.String(), "\n") != 1 {
+		t.Errorf("запись должна занимать одну строку: %q", buf.String())
+	}
+	var entry struct{ Message string }
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("некорректный JSON %q: %v", buf.String(), err)
+	}
+	if entry.Message != "кавычки \" и\nперевод строки, a < b & c > d" {
+		t.Errorf("message = %q", entry.Message)
+	}
+	if strings.Contains(buf.String(), `\u003c`) {
+		t.Errorf("HTML-экранирование в журнале: %q", buf.String())
+	}
+}
+
+func TestJSONUnsupportedValue(t *testing.T) {
+	l, buf := newTestLogger(LevelInfo, true)
+	l.With(F("apoapsis", math.Inf(1)), F("speed", math.NaN())).Info("forecast")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("некорректный JSON %q: %v", buf.String(), err)
+	}
+	if entry["apoapsis"] != "+Inf" || entry["speed"] != "NaN" {
+		t.Errorf("apoapsis = %v, speed = %v", entry["apoapsis"], entry["speed"])
+	}
+}
+
+func TestLevelFilter(t *testing.T) {
+	l, buf := newTestLogger(LevelWarn, true)
+	l.Debug("step")
+	l.Info("meco")
+	l.Warn("fleet")
+	l.Error("started")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("записей %d, ожидалось 2: %q", len(lines), buf.String())
+	}
+	if !strings.HasPrefix(lines[0], `{"level":"warn"`) || !strings.HasPrefix(lines[1], `{"level":"error"`) {
+		t.Errorf("записи: %q", lines)
+	}
+}
+
+func TestSetRocket(t *testing.T) {
+	l, buf := newTestLogger(LevelInfo, false)
+	rocket := l.WithRocket("rocket-001")
+	child := rocket.With(F("stage", 1))
+	rocket.SetRocket("rocket-001-42")
+	child.Info("started")
+
+	want := "2025/03/14 09:26:53 [rocket-001-42] старт stage=1\n"
+	if got := buf.String(); got != want {
+		t.Errorf("запись:\n got %q\nwant %q", got, want)
+	}
+}
+
+// Текст собирается из полей записи и полей With на языке SetLang; в тексте
+// поля записи не повторяются, в JSON выводятся после полей журнала
+func TestRecordLanguage(t *testing.T) {
+	l, buf := newTestLogger(LevelInfo, false)
+	event := l.With(F("altitude", 1250.5))
+	event.Info("step", F("time", 42.0))
+	l.SetLang(protocol.LogLangEN)
+	event.Info("step", F("time", 43.0))
+	l.Info("no_such_code", F("error", errors.New("обрыв")))
+
+	want := "2025/03/14 09:26:53 шаг T+42 на высоте 1250 м altitude=1250.5\n" +
+		"2025/03/14 09:26:53 step T+43 at 1250 m altitude=1250.5\n" +
+		"2025/03/14 09:26:53 no_such_code error=обрыв\n"
+	if got := buf.String(); got != want {
+		t.Errorf("записи:\n got %q\nwant %q", got, want)
+	}
+}
@@ -1,332 +1,274 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
 	"math/rand"
 	"os"
 	"os/signal"
+	"runtime"
 	"time"
 
+	"cosmodrom/client/logging"
 	"cosmodrom/client/physics"
-	"cosmodrom/client/protocol"
-
-	"github.com/gorilla/websocket"
+	"cosmodrom/client/rocketclient"
+	"cosmodrom/protocol"
 )
 
-type RocketClient struct {
-	ID          string
-	config      protocol.RocketConfig
-	physics     *physics.RocketPhysics
-	conn        *websocket.Conn
-	serverURL   string
-	command     protocol.ControlCommand
-	registered  bool
-	running     bool
-	telemetryHz float64
-}
+func main() {
+	cfg := rocketclient.DefaultConfig()
 
-func NewRocketClient(id string, config protocol.RocketConfig, serverURL string) *RocketClient {
-	return &RocketClient{
-		ID:          id,
-		config:      config,
-		serverURL:   serverURL,
-		telemetryHz: 10.0,
-		running:     true,
-	}
-}
+	flag.StringVar(&cfg.ServerURL, "server", cfg.ServerURL, "URL сервера (ws:// или wss://)")
+	flag.StringVar(&cfg.CACert, "ca-cert", "", "PEM-файл с сертификатом CA сервера для wss:// (в дополнение к системным)")
+	flag.BoolVar(&cfg.InsecureSkipVerify, "insecure-skip-verify", false, "Не проверять TLS-сертификат сервера (только для отладки)")
+	codecName := flag.String("codec", "json", "Кодек сообщений: json или cbor (сервер должен быть запущен с тем же -codec)")
+	rocketID := flag.String("id", fmt.Sprintf("rocket-%d", rand.Intn(10000)), "ID ракеты")
+	rocketName := flag.String("name", cfg.Rocket.Name, "Название ракеты")
+	flag.Float64Var(&cfg.Latitude, "lat", cfg.Latitude, "Широта запуска")
+	flag.Float64Var(&cfg.Longitude, "lon", cfg.Longitude, "Долгота запуска")
+	flag.Float64Var(&cfg.Altitude, "alt", cfg.Altitude, "Высота над уровнем моря")
+	flag.Float64Var(&cfg.TargetOrbit, "target-orbit", cfg.TargetOrbit, "Целевая высота орбиты для автопилота гравитационного разворота (м)")
+	flag.Float64Var(&cfg.TargetOrbit, "orbit", cfg.TargetOrbit, "Устаревший синоним -target-orbit")
+	flag.Float64Var(&cfg.PostOrbitRaise, "post-orbit-raise", 0, "После выхода на орбиту перейти по Хоману на эту высоту (м), выше или ниже -target-orbit; 0 - без перехода")
+	flag.Float64Var(&cfg.TargetInclination, "target-inclination", cfg.TargetInclination, "Наклонение целевой орбиты в градусах; отрицательное - рыскание не управляется")
+	flag.StringVar(&cfg.LaunchSite, "launch-site", "", "Название космодрома для цели миссии, которую видит сервер")
+	flag.BoolVar(&cfg.Crewed, "crewed", false, "Пилотируемая миссия (только метка для сервера)")
+	flag.StringVar(&cfg.MissionNote, "mission", "", "Описание миссии для сервера, до 256 символов")
+	flag.IntVar(&cfg.ReconnectAttempts, "reconnect-attempts", cfg.ReconnectAttempts, "Максимум попыток переподключения (0 - без ограничения)")
+	mode := flag.String("mode", string(cfg.Mode), "Режим полета: orbit, hop или chase")
+	flag.Float64Var(&cfg.HopAltitude, "hop-altitude", cfg.HopAltitude, "Высота подъема в режиме hop (м)")
+	flag.StringVar(&cfg.ChaseTarget, "chase-target", "", "ID ракеты, за которой летит -mode chase")
+	flag.Float64Var(&cfg.ChaseOffset, "chase-offset", cfg.ChaseOffset, "Отставание от цели вдоль ее скорости в режиме chase (м)")
+	flag.Float64Var(&cfg.ChaseTolerance, "chase-tolerance", cfg.ChaseTolerance, "Точность уравнивания скорости с целью в режиме chase (м/с)")
+	flag.BoolVar(&cfg.AutoAvoid, "auto-avoid", false, "Автоматически уклоняться при предупреждениях о сближении")
+	manual := flag.Bool("manual", false, "Ручное управление с клавиатуры")
+	configFlags := registerConfigFlags(flag.CommandLine, cfg.Rocket)
+	flag.Float64Var(&cfg.TelemetryHz, "telemetry-hz", cfg.TelemetryHz, "Частота отправки телеметрии (Гц)")
+	flag.Float64Var(&cfg.Dt, "dt", cfg.Dt, "Шаг физики (с)")
+	flag.DurationVar(&cfg.CommandHold, "command-hold", cfg.CommandHold, "Время приоритета команды сервера над автопилотом")
+	flag.DurationVar(&cfg.ReconnectMaxDelay, "reconnect-max-delay", cfg.ReconnectMaxDelay, "Максимальная задержка между попытками переподключения")
+	flag.DurationVar(&cfg.Heartbeat, "heartbeat", cfg.Heartbeat, "Интервал проверки связи с сервером (0 - выключить)")
+	flag.DurationVar(&cfg.HeartbeatTimeout, "heartbeat-timeout", cfg.HeartbeatTimeout, "Время ожидания ответа на heartbeat")
+	flag.DurationVar(&cfg.RegisterTimeout, "register-timeout", cfg.RegisterTimeout, "Время ожидания ответа сервера на регистрацию")
+	flag.BoolVar(&cfg.AutoID, "auto-id", false, "Если ID занят, повторить регистрацию с суффиксом")
+	flag.StringVar(&cfg.RecordPath, "record", "", "Записывать полет в CSV-файл")
+	flag.Float64Var(&cfg.RecordHz, "record-hz", cfg.RecordHz, "Частота записи полета (Гц, по времени симуляции)")
+	flag.StringVar(&cfg.CheckpointFile, "checkpoint-file", "", "Сохранять снимок физики каждые 10 с и при остановке, чтобы продолжить полет с -resume-from")
+	flag.StringVar(&cfg.ResumeFrom, "resume-from", "", "Продолжить полет из снимка -checkpoint-file вместо старта")
+	flag.Float64Var(&cfg.FailureRate, "failure-rate", 0, "Вероятность отказа каждого двигателя в минуту")
+	flag.StringVar(&cfg.FailEngineAt, "fail-engine-at", "", "Отказ двигателя в заданный момент: индекс@секунды или ID@секунды, например 0@45")
+	flag.Int64Var(&cfg.FailureSeed, "failure-seed", 0, "Seed случайных отказов (0 - случайный)")
+	flag.Float64Var(&cfg.NoisePosition, "noise-position", 0, "Шум позиции в телеметрии: сигма по каждой оси (м)")
+	flag.Float64Var(&cfg.NoiseVelocity, "noise-velocity", 0, "Шум скорости в телеметрии: сигма по каждой оси (м/с)")
+	flag.Float64Var(&cfg.NoiseAltitude, "noise-altitude", 0, "Шум высоты в телеметрии: сигма (м)")
+	flag.Float64Var(&cfg.NoiseDropout, "noise-dropout", 0, "Вероятность потери кадра телеметрии (0-1)")
+	flag.DurationVar(&cfg.NoiseLatency, "noise-latency", 0, "Задержка отправки телеметрии, например 200ms")
+	flag.Int64Var(&cfg.NoiseSeed, "noise-seed", 0, "Seed искажения телеметрии (0 - случайный)")
+	flag.StringVar(&cfg.Attitude, "attitude", "", "Удержание ориентации: prograde, retrograde, radial_out или surface_pitch:градусы")
+	flag.StringVar(&cfg.Script, "script", "", "Сценарий полета (YAML): действия по времени вместо автопилота")
+	flag.BoolVar(&cfg.StrictCommands, "strict-commands", false, "Прекращать полет, если автопилот выдал дроссель вне 0-1 или угол вне -180..180, вместо приведения к диапазону")
+	flag.Float64Var(&cfg.SlewRate, "slew-rate", 0, "Скорость поворота ракеты (°/с): тяга идет по достигнутой ориентации (0 - поворот мгновенный)")
+	physicsBackend := flag.String("physics", string(physics.DefaultBackend), "Физическая модель: c (librocket_physics) или go (без cgo)")
+	planetName := flag.String("planet", "earth", "Планета старта: earth, moon или mars")
+	flag.BoolVar(&cfg.NoRotation, "no-earth-rotation", false, "Не учитывать вращение планеты (старт из состояния покоя, как раньше)")
+	flag.DurationVar(&cfg.Countdown, "countdown", 0, "Предстартовый отсчет, например 10s (0 - старт сразу)")
+	flag.Float64Var(&cfg.TimeWarp, "time-warp", cfg.TimeWarp, "Ускорение времени на пассивных участках (1-100)")
+	flag.Float64Var(&cfg.WarpMinAltitude, "warp-min-altitude", cfg.WarpMinAltitude, "Ниже этой высоты (м) ускорение времени не действует")
+	flag.BoolVar(&cfg.Offline, "offline", false, "Автономный режим: полет без подключения к серверу")
+	flag.StringVar(&cfg.TelemetryFile, "telemetry-file", "", "В автономном режиме записывать телеметрию в файл (JSON по строке)")
+	flag.Float64Var(&cfg.MaxG, "max-g", 0, "Прекратить полет, если перегрузка выше заданной (g) дольше 0.5 с (0 - без ограничения)")
+	flag.DurationVar(&cfg.MaxFlightTime, "max-flight-time", 0, "Прекратить полет, если он длится дольше, по времени симуляции (0 - без ограничения)")
+	flag.StringVar(&cfg.MinAltitudeAfter, "min-altitude-after", "", "Прекратить полет, если к моменту T+секунды высота ниже заданной: метры@секунды, например 1000@30")
+	flag.Float64Var(&cfg.ApoKp, "apo-kp", cfg.ApoKp, "Пропорциональный коэффициент регулятора апоцентра (0 вместе с -apo-ki и -apo-kd - MECO без регулирования тяги)")
+	flag.Float64Var(&cfg.ApoKi, "apo-ki", cfg.ApoKi, "Интегральный коэффициент регулятора апоцентра")
+	flag.Float64Var(&cfg.ApoKd, "apo-kd", cfg.ApoKd, "Дифференциальный коэффициент регулятора апоцентра")
+	flag.Float64Var(&cfg.MinThrottle, "min-throttle", cfg.MinThrottle, "Минимальный дроссель регулятора апоцентра")
+	flag.Float64Var(&cfg.MaxQ, "max-q", 0, "Предел скоростного напора (Па), выше которого тяга снижается (0 - без ограничения)")
+	fleetSize := flag.Int("fleet", 0, "Запустить флот из N ракет в одном процессе")
+	fleetRadius := flag.Float64("fleet-radius", 20.0, "Радиус разброса точек старта флота (км)")
+	fleetJitter := flag.Duration("fleet-jitter", 5*time.Second, "Максимальная задержка старта ракеты флота")
+	runs := flag.Int("runs", 0, "Монте-Карло: N автономных прогонов с разбросом -disperse без реального времени")
+	disperse := flag.String("disperse", "", "Разброс прогонов -runs: thrust, drag, fuel (±%) и noise (сигма шума датчиков автопилота), например thrust=2%,drag=10%")
+	mcSeed := flag.Int64("seed", 1, "Главный seed прогонов -runs: при том же seed итог повторяется")
+	mcWorkers := flag.Int("workers", runtime.NumCPU(), "Число параллельных прогонов -runs")
+	mcCSV := flag.String("mc-csv", "", "CSV-файл с итогом каждого прогона -runs")
+
+	verbose := flag.Bool("v", false, "Подробный журнал: каждый шаг автопилота")
+	quiet := flag.Bool("quiet", false, "Только предупреждения и ошибки")
+	logJSON := flag.Bool("log-json", false, "Журнал в JSON, одна запись на строку")
+	logLang := flag.String("lang", "ru", "Язык журнала: ru или en (код и поля записей в -log-json от языка не зависят)")
 
-func (r *RocketClient) Connect() error {
-	var err error
-	r.conn, _, err = websocket.DefaultDialer.Dial(r.serverURL, nil)
+	flag.Parse()
+
+	level := logging.LevelInfo
+	switch {
+	case *verbose && *quiet:
+		fmt.Fprintln(os.Stderr, "Ошибка параметров: -v нельзя совмещать с -quiet")
+		os.Exit(2)
+	case *verbose:
+		level = logging.LevelDebug
+	case *quiet:
+		level = logging.LevelWarn
+	}
+	lang, err := protocol.ParseLogLang(*logLang)
 	if err != nil {
-		return fmt.Errorf("Ошибка подключения к серверу: %w", err)
+		fmt.Fprintln(os.Stderr, "Ошибка параметров:", err)
+		os.Exit(2)
 	}
+	logger := logging.New(os.Stderr, level, *logJSON)
+	logger.SetLang(lang)
+	logging.SetDefault(logger)
 
-	log.Printf("Подключено к серверу %s", r.serverURL)
-	return nil
-}
-
-func (r *RocketClient) Register() error {
-	msg := protocol.Message{
-		Type:      protocol.MsgTypeRegister,
-		Timestamp: time.Now(),
-		Data: protocol.RegisterMessage{
-			RocketID: r.ID,
-			Config:   r.config,
-		},
+	if configFlags.listPresets() {
+		printPresets(os.Stdout)
+		os.Exit(0)
 	}
 
-	if err := r.conn.WriteJSON(msg); err != nil {
-		return fmt.Errorf("Ошибка отправки регистрации: %w", err)
+	cfg.ID = *rocketID
+	cfg.Mode = rocketclient.FlightMode(*mode)
+	cfg.Rocket, err = configFlags.build(*rocketName)
+	if err != nil {
+		logger.Fatal("config_invalid", logging.F("error", err))
 	}
-
-	var response protocol.Message
-	if err := r.conn.ReadJSON(&response); err != nil {
-		return fmt.Errorf("Ошибка чтения ответа: %w", err)
+	cfg.Vehicle = configFlags.vehicleName()
+	if cfg.Codec, err = protocol.CodecByName(*codecName); err != nil {
+		logger.Fatal("flag_invalid", logging.F("flag", "-codec"), logging.F("error", err))
 	}
-
-	switch response.Type {
-	case protocol.MsgTypeAccepted:
-		data, _ := json.Marshal(response.Data)
-		var acceptedMsg protocol.AcceptedMessage
-		json.Unmarshal(data, &acceptedMsg)
-		log.Printf("Регистрация принята: %s", acceptedMsg.Message)
-		r.registered = true
-		return nil
-
-	case protocol.MsgTypeRejected:
-		data, _ := json.Marshal(response.Data)
-		var rejectedMsg protocol.RejectedMessage
-		json.Unmarshal(data, &rejectedMsg)
-		return fmt.Errorf("Регистрация отклонена: %s", rejectedMsg.Reason)
-
-	default:
-		return fmt.Errorf("Неожиданный ответ от сервера: %s", response.Type)
+	cfg.Physics = physics.Backend(*physicsBackend)
+	if cfg.Physics != physics.DefaultBackend {
+		logger.Info("physics_backend", logging.F("backend", cfg.Physics))
 	}
-}
 
-func (r *RocketClient) InitPhysics(latitude, longitude, altitude, targetOrbit float64) error {
-	initialPos := physics.SphericalToCartesian(latitude, longitude, altitude)
-
-	var err error
-	r.physics, err = physics.NewRocketPhysics(&r.config, initialPos)
+	cfg.Planet, err = physics.PlanetByName(*planetName)
 	if err != nil {
-		return fmt.Errorf("Ошибка инициализации физики: %w", err)
+		logger.Fatal("flag_invalid", logging.F("flag", "-planet"), logging.F("error", err))
 	}
-
-	planet := physics.EarthDefault()
-	r.physics.SetPlanet(planet)
-
-	gtConfig := physics.GravityTurnForOrbit(planet, targetOrbit)
-	r.physics.SetGravityTurn(gtConfig)
-
-	r.command = protocol.ControlCommand{
-		EngineThrottle: make([]float64, len(r.config.Engines)),
-		Pitch:          0.0,
-		Yaw:            0.0,
-		Roll:           0.0,
+	if *planetName != "earth" {
+		logger.Info("planet", logging.F("planet", *planetName))
 	}
 
-	for i := range r.command.EngineThrottle {
-		r.command.EngineThrottle[i] = 1.0
+	// Seed выбирается один раз, чтобы у ракет флота он был общий
+	if cfg.FailureSeed == 0 {
+		cfg.FailureSeed = time.Now().UnixNano()
+	}
+	if cfg.NoiseSeed == 0 {
+		cfg.NoiseSeed = time.Now().UnixNano()
 	}
 
-	log.Printf("Физический движок инициализирован")
-	log.Printf("Целевая орбита: %.0f км, начало поворота: %.0f м, окончание: %.0f км",
-		targetOrbit/1000.0, gtConfig.TurnStartAlt, gtConfig.TurnEndAlt/1000.0)
-	return nil
-}
-
-func (r *RocketClient) Run() {
-	defer r.physics.Free()
-
-	go r.receiveMessages()
-
-	dt := 0.01
-	telemetryInterval := 1.0 / r.telemetryHz
-	lastTelemetry := time.Now()
-
-	ticker := time.NewTicker(time.Duration(dt * float64(time.Second)))
-	defer ticker.Stop()
-
-	log.Printf("Запуск симуляции ракеты %s", r.ID)
-	log.Printf("Конфигурация: %s, двигатели: %d x %.0f кН",
-		r.config.Name,
-		len(r.config.Engines),
-		r.config.Engines[0].Thrust/1000.0)
-
-	for r.running {
-		<-ticker.C
-
-		r.command.Pitch = r.physics.CalculateOptimalPitch()
-
-		r.physics.Update(&r.command, dt)
-
-		state := r.physics.GetState()
-
-		if state.FuelRemaining <= 0 {
-			for i := range r.command.EngineThrottle {
-				r.command.EngineThrottle[i] = 0.0
-			}
-		}
-
-		if time.Since(lastTelemetry).Seconds() >= telemetryInterval {
+	if err := cfg.Validate(); err != nil {
+		logger.Fatal("flags_invalid", logging.F("error", err))
+	}
 
-			orbit := r.physics.PredictOrbit()
-			state.OrbitApoapsis = orbit.Apoapsis
-			state.OrbitPeriapsis = orbit.Periapsis
-			state.OrbitEccentricity = orbit.Eccentricity
-			state.OrbitRequiredVelocity = orbit.RequiredVelocity
-			state.OrbitIsStable = orbit.IsStable
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-			if err := r.sendTelemetry(state); err != nil {
-				log.Printf("Соединение потеряно, завершение работы...")
-				break
-			}
-			lastTelemetry = time.Now()
+	// -runs проверяется раньше -fleet: их сочетание - ошибка checkMonteCarlo
+	if *runs > 0 {
+		if err := checkMonteCarlo(cfg, *manual, *fleetSize, *mcWorkers); err != nil {
+			logger.Fatal("flags_invalid", logging.F("error", err))
 		}
-
-		if state.Landed {
-			log.Printf("Ракета %s успешно приземлилась", r.ID)
-			log.Printf("Конечная высота: %.2f м, скорость: %.1f м/с", state.Altitude, state.Speed)
-			r.running = false
+		spread, err := parseDispersion(*disperse)
+		if err != nil {
+			logger.Fatal("flag_invalid", logging.F("flag", "-disperse"), logging.F("error", err))
 		}
+		os.Exit(runMonteCarlo(ctx, cfg, *runs, *mcWorkers, spread, *mcSeed, *mcCSV))
+	}
 
-		if state.Crashed {
-			log.Printf("Ракета %s разбилась", r.ID)
-			log.Printf("Конечная высота: %.2f м, скорость: %.1f м/с", state.Altitude, state.Speed)
-			r.running = false
+	if *fleetSize > 0 {
+		if *manual {
+			logger.Fatal("flags_conflict", logging.F("flag", "-manual"), logging.F("other", "-fleet"))
 		}
-
-		if state.InOrbit {
-			log.Printf("Ракета %s вышла на орбиту!", r.ID)
-			log.Printf("Высота: %.2f км, скорость: %.1f м/с, топливо: %.0f кг",
-				state.Altitude/1000.0, state.Speed, state.FuelRemaining)
+		if cfg.ResumeFrom != "" {
+			logger.Fatal("flags_conflict", logging.F("flag", "-resume-from"), logging.F("other", "-fleet"))
 		}
+		os.Exit(runFleet(ctx, cfg, *fleetSize, *fleetRadius, *fleetJitter))
 	}
 
-	r.disconnect()
-}
-
-func (r *RocketClient) sendTelemetry(state protocol.RocketState) error {
-	if !r.registered || r.conn == nil {
-		return nil
+	var keyboard *manualControl
+	if *manual {
+		if keyboard, err = newManualControl(); err != nil {
+			logger.Fatal("manual_failed", logging.F("error", err))
+		}
+		cfg.Autopilot = keyboard
 	}
 
-	msg := protocol.Message{
-		Type:      protocol.MsgTypeTelemetry,
-		Timestamp: time.Now(),
-		Data: protocol.TelemetryMessage{
-			RocketID: r.ID,
-			State:    state,
-		},
+	client, err := rocketclient.New(cfg)
+	if err != nil {
+		logger.Fatal("flags_invalid", logging.F("error", err))
 	}
 
-	if err := r.conn.WriteJSON(msg); err != nil {
-		r.running = false
-		return err
+	if !cfg.Offline {
+		connectAndRegister(client)
 	}
-	return nil
-}
 
-func (r *RocketClient) receiveMessages() {
-	for r.running {
-		var msg protocol.Message
-		if err := r.conn.ReadJSON(&msg); err != nil {
-			if r.running {
-				log.Printf("Соединение с сервером потеряно: %v", err)
-				r.running = false
-			}
-			return
-		}
-
-		switch msg.Type {
-		case protocol.MsgTypeCommand:
-			r.handleCommand(msg)
-
-		case protocol.MsgTypeWarning:
-			r.handleWarning(msg)
-
-		case protocol.MsgTypeShutdown:
-			log.Printf("Получена команда на выключение от сервера")
-			r.running = false
+	restoreTerminal := func() {}
+	if keyboard != nil {
+		restoreTerminal, err = keyboard.start(ctx, client)
+		if err != nil {
+			logger.Fatal("manual_failed", logging.F("error", err))
 		}
+		// Восстанавливает терминал при панике; при обычном выходе вызывается до os.Exit
+		defer restoreTerminal()
 	}
-}
-
-func (r *RocketClient) handleCommand(msg protocol.Message) {
-	data, _ := json.Marshal(msg.Data)
-	var commandMsg protocol.CommandMessage
-	if err := json.Unmarshal(data, &commandMsg); err != nil {
-		log.Printf("Ошибка декодирования команды: %v", err)
-		return
-	}
-
-	r.command = commandMsg.Command
-	log.Printf("Получена команда управления от сервера")
-}
 
-func (r *RocketClient) handleWarning(msg protocol.Message) {
-	data, _ := json.Marshal(msg.Data)
-	var warningMsg protocol.WarningMessage
-	if err := json.Unmarshal(data, &warningMsg); err != nil {
-		log.Printf("Ошибка декодирования предупреждения: %v", err)
-		return
+	if cfg.Countdown > 0 {
+		watchCountdownSignals(ctx, client)
 	}
 
-	log.Printf("ПРЕДУПРЕЖДЕНИЕ [%s]: %s", warningMsg.Severity, warningMsg.Warning)
-}
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt)
+	go func() {
+		<-sigChan
+		client.Logger().Warn("interrupted")
+		cancel()
+	}()
 
-func (r *RocketClient) disconnect() {
-	if r.conn != nil {
-		msg := protocol.Message{
-			Type:      protocol.MsgTypeDisconnect,
-			Timestamp: time.Now(),
-			Data: protocol.DisconnectMessage{
-				RocketID: r.ID,
-				Reason:   "Завершение полёта",
-			},
-		}
-		_ = r.conn.WriteJSON(msg)
-		r.conn.Close()
-		r.conn = nil
+	summary, err := client.Launch(ctx)
+	cancel()
+	restoreTerminal()
+	if err != nil {
+		client.Logger().Fatal("launch_failed", logging.F("error", err))
 	}
-}
 
-func (r *RocketClient) Stop() {
-	r.running = false
+	summary.Log(client.Logger())
+	client.Logger().Info("client_done")
+	os.Exit(summary.Outcome.ExitCode())
 }
 
-func main() {
-	serverURL := flag.String("server", "ws://localhost:8080/ws", "URL сервера")
-	rocketID := flag.String("id", fmt.Sprintf("rocket-%d", rand.Intn(10000)), "ID ракеты")
-	rocketName := flag.String("name", "Test Rocket", "Название ракеты")
-	latitude := flag.Float64("lat", 45.0, "Широта запуска")
-	longitude := flag.Float64("lon", 63.0, "Долгота запуска")
-	altitude := flag.Float64("alt", 100.0, "Высота над уровнем моря")
-	targetOrbit := flag.Float64("orbit", 200000.0, "Целевая высота орбиты (м)")
-
-	flag.Parse()
-
-	config := protocol.RocketConfig{
-		Name:            *rocketName,
-		MassEmpty:       20000.0,  // Масса пустой ракеты
-		MassFuel:        400000.0, // Топливо (достаточно для орбиты)
-		MassFuelMax:     400000.0,
-		FuelType:        protocol.FuelTypeKerosene,
-		DragCoefficient: 0.3,  // Аэродинамический коэффициент
-		CrossSection:    12.0, // Площадь сечения м2
-		Engines: []protocol.Engine{
-			{Thrust: 7600000.0, FuelConsumption: 2500.0, IsActive: true}, // Merlin engine
-		},
-	}
-
-	client := NewRocketClient(*rocketID, config, *serverURL)
-
+// connectAndRegister подключается к серверу и регистрирует ракету, а при
+// ошибке завершает процесс с понятным сообщением
+func connectAndRegister(client *rocketclient.RocketClient) {
+	logger := client.Logger()
 	if err := client.Connect(); err != nil {
-		log.Fatalf("Ошибка подключения: %v", err)
+		var rejected *rocketclient.RejectedError
+		if errors.As(err, &rejected) && rejected.Code == protocol.RejectCodeUnknownVehicle {
+			logger.Fatal("vehicle_unknown", logging.F("reason", rejected.Reason))
+		}
+		logger.Fatal("connect_failed", logging.F("error", err))
 	}
 
 	if err := client.Register(); err != nil {
-		log.Fatalf("Ошибка регистрации: %v", err)
-	}
-
-	if err := client.InitPhysics(*latitude, *longitude, *altitude, *targetOrbit); err != nil {
-		log.Fatalf("Ошибка инициализации физики: %v", err)
+		var rejected *rocketclient.RejectedError
+		var timeout *rocketclient.RegisterTimeoutError
+		if errors.As(err, &timeout) {
+			logger.Fatal("register_timeout", logging.F("error", err))
+		}
+		if errors.As(err, &rejected) {
+			switch rejected.Code {
+			case protocol.RejectCodeDuplicateID:
+				logger.Fatal("id_taken", logging.F("rocket_id", client.ID))
+			case protocol.RejectCodeAuthFailed:
+				logger.Fatal("auth_failed", logging.F("reason", rejected.Reason))
+			case protocol.RejectCodeInvalidConfig:
+				logger.Fatal("config_rejected", logging.F("reason", rejected.Reason))
+			case protocol.RejectCodeUnknownField:
+				logger.Fatal("unknown_field_rejected", logging.F("reason", rejected.Reason))
+			case protocol.RejectCodeDraining, protocol.RejectCodeServerFull:
+				logger.Fatal("server_busy", logging.F("code", rejected.Code))
+			}
+		}
+		logger.Fatal("register_failed", logging.F("error", err))
 	}
-
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt)
-	go func() {
-		<-sigChan
-		log.Println("Получен сигнал прерывания, завершение...")
-		client.Stop()
-	}()
-
-	client.Run()
-
-	log.Println("Клиент завершил работу")
 }
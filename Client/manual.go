@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/client/rocketclient"
+	"cosmodrom/protocol"
+
+	"golang.org/x/term"
+)
+
+const (
+	manualAngleStep    = 1.0  // Градусы на нажатие стрелки
+	manualThrottleStep = 0.05 // Шаг дросселя на нажатие +/-
+)
+
+// manualControl - программа полета для ручного управления с клавиатуры.
+// Клавиши меняют тангаж, рыскание и общий дроссель, а Step возвращает их
+// как команду автопилота. Команда сервера, как и при автопилоте, имеет
+// приоритет на время -command-hold.
+type manualControl struct {
+	pitch    float64
+	yaw      float64
+	throttle float64
+	state    protocol.RocketState // С прогнозом орбиты
+	mu       sync.Mutex
+
+	fd          int
+	oldState    *term.State
+	logger      *logging.Logger
+	logOutput   io.Writer
+	restoreOnce sync.Once
+}
+
+func (m *manualControl) Step(state protocol.RocketState, dt float64) protocol.ControlCommand {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.state = state
+	return protocol.ControlCommand{EngineThrottle: []float64{m.throttle}, Pitch: m.pitch, Yaw: m.yaw}
+}
+
+// При ручном управлении полет заканчивается только посадкой, крушением или остановкой
+func (m *manualControl) Outcome() rocketclient.MissionOutcome {
+	return ""
+}
+
+func (m *manualControl) Phase() string {
+	return "manual"
+}
+
+// newManualControl проверяет, что stdin - терминал. Программа передается
+// клиенту в Config.Autopilot, а клавиатура включается в start.
+func newManualControl() (*manualControl, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, fmt.Errorf("ручное управление требует терминала на stdin")
+	}
+	return &manualControl{fd: fd}, nil
+}
+
+// start переводит терминал в raw-режим и передает управление клавиатуре до
+// отмены ctx. Возвращенную функцию нужно вызвать при выходе, в том числе при панике.
+func (m *manualControl) start(ctx context.Context, client *rocketclient.RocketClient) (restore func(), err error) {
+	m.oldState, err = term.MakeRaw(m.fd)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось перевести терминал в raw-режим: %w", err)
+	}
+
+	m.logger = client.Logger()
+	m.logOutput = m.logger.Writer()
+	// В raw-режиме перевод строки не возвращает каретку
+	m.logger.SetOutput(crlfWriter{m.logOutput})
+
+	m.logger.Info("manual_help")
+
+	go m.readKeys(ctx, client)
+	go m.drawStatus(ctx)
+	return m.restore, nil
+}
+
+func (m *manualControl) restore() {
+	m.restoreOnce.Do(func() {
+		term.Restore(m.fd, m.oldState)
+		m.logger.SetOutput(m.logOutput)
+		fmt.Fprintln(os.Stderr)
+	})
+}
+
+func (m *manualControl) readKeys(ctx context.Context, client *rocketclient.RocketClient) {
+	buf := make([]byte, 16)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		for i := 0; i < n; i++ {
+			// Стрелки приходят как ESC [ A..D
+			if buf[i] == 0x1b && i+2 < n && buf[i+1] == '[' {
+				m.arrow(buf[i+2])
+				i += 2
+				continue
+			}
+
+			switch buf[i] {
+			case '+', '=':
+				m.adjustThrottle(manualThrottleStep)
+			case '-', '_':
+				m.adjustThrottle(-manualThrottleStep)
+			case ' ':
+				m.adjustThrottle(-1)
+			case 'q', 0x03: // Ctrl+C в raw-режиме не превращается в SIGINT
+				client.Stop()
+				return
+			}
+		}
+	}
+}
+
+func (m *manualControl) arrow(code byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch code {
+	case 'A':
+		m.pitch = math.Min(m.pitch+manualAngleStep, 180.0)
+	case 'B':
+		m.pitch = math.Max(m.pitch-manualAngleStep, 0.0)
+	case 'C':
+		m.yaw += manualAngleStep
+	case 'D':
+		m.yaw -= manualAngleStep
+	}
+}
+
+func (m *manualControl) adjustThrottle(delta float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.throttle = math.Max(0.0, math.Min(1.0, m.throttle+delta))
+}
+
+// drawStatus раз в секунду перерисовывает строку состояния
+func (m *manualControl) drawStatus(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		m.mu.Lock()
+		line := fmt.Sprintf("Высота %.2f км | скорость %.1f м/с | апоцентр %.1f км | перицентр %.1f км | топливо %.0f кг | тангаж %.0f° рыскание %.0f° дроссель %.0f%%",
+			m.state.Altitude/1000.0, m.state.Speed,
+			m.state.OrbitApoapsis/1000.0, m.state.OrbitPeriapsis/1000.0,
+			m.state.FuelRemaining, m.pitch, m.yaw, m.throttle*100)
+		m.mu.Unlock()
+
+		fmt.Fprintf(os.Stderr, "\r\033[K%s", line)
+	}
+}
+
+// crlfWriter заменяет \n на \r\n для вывода лога в raw-режиме терминала
+type crlfWriter struct {
+	w io.Writer
+}
+
+func (c crlfWriter) Write(p []byte) (int, error) {
+	out := bytes.ReplaceAll(p, []byte("\n"), []byte("\r\n"))
+	if _, err := c.w.Write(append([]byte("\r\033[K"), out...)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
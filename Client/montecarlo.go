@@ -0,0 +1,390 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/client/rocketclient"
+	"cosmodrom/protocol"
+)
+
+// dispersion - разброс параметров прогонов Монте-Карло (-disperse). Тяга,
+// сопротивление и заправка меняются равномерно в пределах ±доли, Noise -
+// относительная сигма шума датчиков автопилота.
+type dispersion struct {
+	Thrust float64
+	Drag   float64
+	Fuel   float64
+	Noise  float64
+}
+
+// parseDispersion разбирает список вида thrust=2%,drag=10%. Значение без
+// знака процента - доля: fuel=0.01 то же, что fuel=1%.
+func parseDispersion(s string) (dispersion, error) {
+	var d dispersion
+	if strings.TrimSpace(s) == "" {
+		return d, nil
+	}
+	for _, item := range strings.Split(s, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			return d, fmt.Errorf("ожидается параметр=процент: %q", item)
+		}
+		scale := 1.0
+		if strings.HasSuffix(value, "%") {
+			value = strings.TrimSuffix(value, "%")
+			scale = 0.01
+		}
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 || math.IsInf(parsed, 0) {
+			return d, fmt.Errorf("неверный разброс %s: %q", name, value)
+		}
+		parsed *= scale
+
+		switch name {
+		case "thrust":
+			d.Thrust = parsed
+		case "drag":
+			d.Drag = parsed
+		case "fuel":
+			d.Fuel = parsed
+		case "noise":
+			d.Noise = parsed
+		default:
+			return d, fmt.Errorf("неизвестный параметр разброса %q (ожидается thrust, drag, fuel или noise)", name)
+		}
+	}
+	if d.Thrust >= 1 || d.Drag >= 1 || d.Fuel >= 1 {
+		return d, fmt.Errorf("разброс тяги, сопротивления и заправки должен быть меньше 100%%")
+	}
+	return d, nil
+}
+
+// checkMonteCarlo отклоняет флаги, которые не имеют смысла для пакетных
+// прогонов: каждому прогону понадобился бы свой файл или терминал
+func checkMonteCarlo(cfg rocketclient.Config, manual bool, fleetSize, workers int) error {
+	switch {
+	case manual:
+		return fmt.Errorf("-manual нельзя совмещать с -runs")
+	case fleetSize > 0:
+		return fmt.Errorf("-fleet нельзя совмещать с -runs")
+	case cfg.Mode == rocketclient.FlightModeChase:
+		return fmt.Errorf("-mode chase требует сервера и несовместим с -runs")
+	case cfg.ResumeFrom != "" || cfg.CheckpointFile != "":
+		return fmt.Errorf("-resume-from и -checkpoint-file несовместимы с -runs")
+	case cfg.RecordPath != "" || cfg.TelemetryFile != "":
+		return fmt.Errorf("-record и -telemetry-file несовместимы с -runs, итоги прогонов пишет -mc-csv")
+	case cfg.Countdown > 0:
+		return fmt.Errorf("-countdown несовместим с -runs")
+	case workers < 1:
+		return fmt.Errorf("-workers должен быть положительным")
+	}
+	return nil
+}
+
+// monteCarloRun - параметры и итог одного прогона
+type monteCarloRun struct {
+	Index  int
+	Seed   int64
+	Thrust float64 // Множитель тяги
+	Drag   float64 // Множитель коэффициента сопротивления
+	Fuel   float64 // Множитель заправки
+
+	Summary    rocketclient.MissionSummary
+	FuelMargin float64 // кг, остаток топлива
+	Err        error   // Прогон не стартовал
+}
+
+// draw выбирает seed и множители прогона из общего генератора
+func (d dispersion) draw(rng *rand.Rand, run *monteCarloRun) {
+	run.Seed = rng.Int63()
+	run.Thrust = 1 + d.Thrust*(2*rng.Float64()-1)
+	run.Drag = 1 + d.Drag*(2*rng.Float64()-1)
+	run.Fuel = 1 + d.Fuel*(2*rng.Float64()-1)
+}
+
+// configure применяет к cfg множители прогона и шум датчиков. Срезы
+// двигателей и ступеней копируются: cfg общий для всех прогонов.
+func (d dispersion) configure(cfg rocketclient.Config, run *monteCarloRun) rocketclient.Config {
+	rocket := cfg.Rocket
+	rocket.DragCoefficient *= run.Drag
+	if len(rocket.Stages) > 0 {
+		rocket.Stages = append([]protocol.Stage(nil), rocket.Stages...)
+		for i := range rocket.Stages {
+			rocket.Stages[i].MassFuel *= run.Fuel
+			rocket.Stages[i].Engines = scaleThrust(rocket.Stages[i].Engines, run.Thrust)
+		}
+		rocket.ApplyStages()
+	} else {
+		rocket.MassFuel *= run.Fuel
+		rocket.MassFuelMax = math.Max(rocket.MassFuelMax, rocket.MassFuel)
+		rocket.Engines = scaleThrust(rocket.Engines, run.Thrust)
+	}
+
+	cfg.Rocket = rocket
+	cfg.ID = fmt.Sprintf("%s-mc%04d", cfg.ID, run.Index+1)
+	cfg.SensorNoise = d.Noise
+	cfg.NoiseSeed = run.Seed
+	cfg.FailureSeed = run.Seed
+	return cfg
+}
+
+func scaleThrust(engines []protocol.Engine, factor float64) []protocol.Engine {
+	scaled := append([]protocol.Engine(nil), engines...)
+	for i := range scaled {
+		scaled[i].Thrust *= factor
+	}
+	return scaled
+}
+
+// discardSink - получатель телеметрии прогонов: итог берется из MissionSummary
+type discardSink struct{}
+
+func (discardSink) Start()                            {}
+func (discardSink) Send(protocol.RocketState) error   { return nil }
+func (discardSink) Abort(protocol.AbortMessage) error { return nil }
+func (discardSink) Close(string)                      {}
+
+// runMonteCarlo выполняет runs автономных прогонов без реального времени на
+// workers горутинах. Разброс прогонов выбирается из seed по порядку, поэтому
+// результат не зависит от числа горутин и порядка их завершения.
+// Возвращает код выхода: 1, если хотя бы один прогон не стартовал, 130 -
+// если прогоны прерваны.
+func runMonteCarlo(ctx context.Context, cfg rocketclient.Config, runs, workers int, spread dispersion, seed int64, csvPath string) int {
+	logger := logging.Default()
+	logger.Info("mc_start", logging.F("runs", runs), logging.F("workers", workers), logging.F("seed", seed))
+
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt)
+	go func() {
+		<-sigChan
+		logger.Warn("mc_interrupted")
+		stop()
+	}()
+
+	results := simulateRuns(ctx, cfg, runs, workers, spread, seed)
+
+	writeMonteCarloSummary(os.Stdout, results)
+	if csvPath != "" {
+		if err := writeMonteCarloCSV(csvPath, results); err != nil {
+			logger.Error("mc_csv_failed", logging.F("path", csvPath), logging.F("error", err))
+			return 1
+		}
+		logger.Info("mc_csv_written", logging.F("path", csvPath))
+	}
+
+	for _, run := range results {
+		if run.Err != nil {
+			return 1
+		}
+	}
+	if ctx.Err() != nil {
+		return rocketclient.OutcomeInterrupted.ExitCode()
+	}
+	return 0
+}
+
+// simulateRuns выполняет прогоны и возвращает их итоги в порядке номеров.
+// Прогоны, не начатые до остановки ctx, получают исход interrupted.
+func simulateRuns(ctx context.Context, cfg rocketclient.Config, runs, workers int, spread dispersion, seed int64) []monteCarloRun {
+	logger := logging.Default()
+	cfg.Sink = discardSink{}
+	cfg.Unpaced = true
+	cfg.TimeWarp = 1
+	cfg.Logger = logging.New(io.Discard, logging.LevelError, false)
+
+	results := make([]monteCarloRun, runs)
+	master := rand.New(rand.NewSource(seed))
+	for i := range results {
+		results[i].Index = i
+		spread.draw(master, &results[i])
+	}
+
+	jobs := make(chan *monteCarloRun)
+	var done int
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for run := range jobs {
+				simulate(ctx, spread.configure(cfg, run), run)
+
+				mu.Lock()
+				done++
+				if done%max(1, runs/10) == 0 {
+					logger.Info("mc_progress", logging.F("done", done), logging.F("runs", runs))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range results {
+		if ctx.Err() != nil {
+			results[i].Summary.Outcome = rocketclient.OutcomeInterrupted
+			continue
+		}
+		jobs <- &results[i]
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+func simulate(ctx context.Context, cfg rocketclient.Config, run *monteCarloRun) {
+	client, err := rocketclient.New(cfg)
+	if err != nil {
+		run.Err = err
+		return
+	}
+	run.Summary, err = client.Launch(ctx)
+	if err != nil {
+		client.Close()
+		run.Err = err
+		return
+	}
+	run.FuelMargin = cfg.Rocket.MassFuel - run.Summary.FuelUsed
+}
+
+// sampleStats - среднее, стандартное отклонение и крайние значения выборки
+type sampleStats struct {
+	count               int
+	mean, std, min, max float64
+}
+
+func newSampleStats(values []float64) sampleStats {
+	s := sampleStats{count: len(values)}
+	if s.count == 0 {
+		return s
+	}
+	s.min, s.max = math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		s.mean += v
+		s.min = math.Min(s.min, v)
+		s.max = math.Max(s.max, v)
+	}
+	s.mean /= float64(s.count)
+	for _, v := range values {
+		s.std += (v - s.mean) * (v - s.mean)
+	}
+	s.std = math.Sqrt(s.std / float64(s.count))
+	return s
+}
+
+// writeMonteCarloSummary печатает сводную таблицу: исходы, а также орбиту
+// успешных прогонов и остаток топлива всех завершенных
+func writeMonteCarloSummary(w io.Writer, results []monteCarloRun) {
+	outcomes := make(map[rocketclient.MissionOutcome]int)
+	failed := 0
+	var apoapsis, periapsis, margin []float64
+	for _, run := range results {
+		if run.Err != nil {
+			failed++
+			continue
+		}
+		outcomes[run.Summary.Outcome]++
+		if run.Summary.Outcome == rocketclient.OutcomeInterrupted {
+			continue
+		}
+		margin = append(margin, run.FuelMargin)
+		if run.Summary.Outcome == rocketclient.OutcomeOrbit {
+			apoapsis = append(apoapsis, run.Summary.Apoapsis/1000.0)
+			periapsis = append(periapsis, run.Summary.Periapsis/1000.0)
+		}
+	}
+
+	total := float64(len(results))
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "ИСХОД\tПРОГОНОВ\tДОЛЯ")
+	names := make([]string, 0, len(outcomes))
+	for outcome := range outcomes {
+		names = append(names, string(outcome))
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		count := outcomes[rocketclient.MissionOutcome(name)]
+		fmt.Fprintf(table, "%s\t%d\t%.1f%%\n", name, count, float64(count)/total*100)
+	}
+	if failed > 0 {
+		fmt.Fprintf(table, "не стартовал\t%d\t%.1f%%\n", failed, float64(failed)/total*100)
+	}
+	fmt.Fprintln(table)
+
+	fmt.Fprintln(table, "ВЕЛИЧИНА\tПРОГОНОВ\tСРЕДНЕЕ\tСКО\tМИН\tМАКС")
+	for _, row := range []struct {
+		name   string
+		values []float64
+	}{
+		{"апоцентр, км", apoapsis},
+		{"перицентр, км", periapsis},
+		{"остаток топлива, кг", margin},
+	} {
+		s := newSampleStats(row.values)
+		if s.count == 0 {
+			fmt.Fprintf(table, "%s\t0\t-\t-\t-\t-\n", row.name)
+			continue
+		}
+		fmt.Fprintf(table, "%s\t%d\t%.1f\t%.1f\t%.1f\t%.1f\n", row.name, s.count, s.mean, s.std, s.min, s.max)
+	}
+	table.Flush()
+}
+
+var monteCarloHeader = []string{
+	"run", "seed", "thrust", "drag", "fuel", "outcome",
+	"apoapsis_km", "periapsis_km", "fuel_margin_kg", "flight_time", "max_altitude_km", "max_q_kpa", "error",
+}
+
+// writeMonteCarloCSV записывает строку на каждый прогон в порядке номеров
+func writeMonteCarloCSV(path string, results []monteCarloRun) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	w.Write(monteCarloHeader)
+	for _, run := range results {
+		errText := ""
+		if run.Err != nil {
+			errText = run.Err.Error()
+		}
+		s := run.Summary
+		w.Write([]string{
+			strconv.Itoa(run.Index + 1),
+			strconv.FormatInt(run.Seed, 10),
+			strconv.FormatFloat(run.Thrust, 'f', 4, 64),
+			strconv.FormatFloat(run.Drag, 'f', 4, 64),
+			strconv.FormatFloat(run.Fuel, 'f', 4, 64),
+			string(s.Outcome),
+			strconv.FormatFloat(s.Apoapsis/1000.0, 'f', 2, 64),
+			strconv.FormatFloat(s.Periapsis/1000.0, 'f', 2, 64),
+			strconv.FormatFloat(run.FuelMargin, 'f', 0, 64),
+			strconv.FormatFloat(s.FlightTime, 'f', 1, 64),
+			strconv.FormatFloat(s.MaxAltitude/1000.0, 'f', 2, 64),
+			strconv.FormatFloat(s.MaxQ/1000.0, 'f', 1, 64),
+			errText,
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"cosmodrom/client/physics"
+	"cosmodrom/client/rocketclient"
+)
+
+func TestParseDispersion(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    dispersion
+		wantErr string
+	}{
+		{in: "", want: dispersion{}},
+		{in: "thrust=2%,drag=10%", want: dispersion{Thrust: 0.02, Drag: 0.1}},
+		{in: "fuel=0.01, noise=0.5%", want: dispersion{Fuel: 0.01, Noise: 0.005}},
+		{in: "thrust", wantErr: "ожидается параметр=процент"},
+		{in: "mass=1%", wantErr: "неизвестный параметр"},
+		{in: "drag=-5%", wantErr: "неверный разброс"},
+		{in: "thrust=100%", wantErr: "меньше 100%"},
+	}
+
+	for _, tt := range tests {
+		got, err := parseDispersion(tt.in)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%q: ошибка %v, ожидалась %q", tt.in, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: %+v, ожидалось %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCheckMonteCarlo(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  func(cfg *rocketclient.Config)
+		manual  bool
+		fleet   int
+		workers int
+		wantErr string
+	}{
+		{name: "только -runs", workers: 4},
+		{name: "-manual", manual: true, workers: 4, wantErr: "-manual нельзя совмещать с -runs"},
+		{name: "-fleet", fleet: 3, workers: 4, wantErr: "-fleet нельзя совмещать с -runs"},
+		{name: "-mode chase", config: func(cfg *rocketclient.Config) { cfg.Mode = rocketclient.FlightModeChase }, workers: 4, wantErr: "-mode chase"},
+		{name: "-resume-from", config: func(cfg *rocketclient.Config) { cfg.ResumeFrom = "snapshot.json" }, workers: 4, wantErr: "-resume-from"},
+		{name: "-checkpoint-file", config: func(cfg *rocketclient.Config) { cfg.CheckpointFile = "snapshot.json" }, workers: 4, wantErr: "-checkpoint-file"},
+		{name: "-record", config: func(cfg *rocketclient.Config) { cfg.RecordPath = "flight.csv" }, workers: 4, wantErr: "-record"},
+		{name: "-telemetry-file", config: func(cfg *rocketclient.Config) { cfg.TelemetryFile = "telemetry.jsonl" }, workers: 4, wantErr: "-telemetry-file"},
+		{name: "-countdown", config: func(cfg *rocketclient.Config) { cfg.Countdown = time.Second }, workers: 4, wantErr: "-countdown"},
+		{name: "-workers 0", wantErr: "-workers"},
+		// Первым сообщается конфликт режима, а не число потоков
+		{name: "-fleet и -workers 0", fleet: 3, wantErr: "-fleet"},
+	}
+
+	for _, tt := range tests {
+		cfg := rocketclient.DefaultConfig()
+		if tt.config != nil {
+			tt.config(&cfg)
+		}
+		err := checkMonteCarlo(cfg, tt.manual, tt.fleet, tt.workers)
+		if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("%s: ошибка %v, ожидалась %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+// Прогон меняет копию конфигурации: двигатели и ступени общего cfg не трогаются
+func TestDispersionConfigureCopies(t *testing.T) {
+	preset, err := rocketclient.PresetByName("falcon-1ish")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := rocketclient.DefaultConfig()
+	cfg.ID = "mc"
+	cfg.Rocket = preset.Config()
+	thrust := cfg.Rocket.Stages[0].Engines[0].Thrust
+	fuel := cfg.Rocket.MassFuel
+
+	run := monteCarloRun{Thrust: 1.1, Drag: 1, Fuel: 0.9}
+	got := dispersion{}.configure(cfg, &run)
+
+	if cfg.Rocket.Stages[0].Engines[0].Thrust != thrust || cfg.Rocket.MassFuel != fuel {
+		t.Fatalf("исходная конфигурация изменена")
+	}
+	if want := thrust * 1.1; got.Rocket.Engines[0].Thrust != want {
+		t.Errorf("тяга %g, ожидалось %g", got.Rocket.Engines[0].Thrust, want)
+	}
+	if want := fuel * 0.9; got.Rocket.MassFuel < want*0.999 || got.Rocket.MassFuel > want*1.001 {
+		t.Errorf("топливо %g, ожидалось %g", got.Rocket.MassFuel, want)
+	}
+}
+
+// Итог прогонов зависит только от seed, но не от числа потоков
+func TestMonteCarloReproducible(t *testing.T) {
+	preset, err := rocketclient.PresetByName("sounding")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := rocketclient.DefaultConfig()
+	cfg.ID = "mc"
+	cfg.Rocket = preset.Config()
+	cfg.Physics = physics.BackendGo
+	cfg.Mode = rocketclient.FlightModeHop
+	cfg.HopAltitude = 500
+	spread := dispersion{Thrust: 0.05, Drag: 0.1, Fuel: 0.02, Noise: 0.01}
+
+	first := simulateRuns(context.Background(), cfg, 4, 1, spread, 7)
+	second := simulateRuns(context.Background(), cfg, 4, 3, spread, 7)
+	if !reflect.DeepEqual(first, second) {
+		t.Fatalf("итоги различаются:\n%+v\n%+v", first, second)
+	}
+	for _, run := range first {
+		if run.Err != nil {
+			t.Fatalf("прогон %d: %v", run.Index, run.Err)
+		}
+		if run.Summary.Outcome != rocketclient.OutcomeLanded {
+			t.Errorf("прогон %d: исход %s, ожидалась посадка", run.Index, run.Summary.Outcome)
+		}
+	}
+
+	other := simulateRuns(context.Background(), cfg, 4, 2, spread, 8)
+	if reflect.DeepEqual(first, other) {
+		t.Errorf("другой seed дал те же прогоны")
+	}
+}
@@ -0,0 +1,50 @@
+package physics
+
+import (
+	"math"
+
+	"cosmodrom/protocol"
+)
+
+// Атмосфера на уровне моря при SurfacePressure = 1.0, как SEA_LEVEL_DENSITY
+// и SEA_LEVEL_PRESSURE в движке на C
+const (
+	SeaLevelDensity  = 1.225    // кг/м3
+	SeaLevelPressure = 101325.0 // Па
+)
+
+// Экспоненциальная атмосфера: плотность и давление падают с масштабной
+// высотой ScaleHeight, ниже поверхности они как на поверхности, а от
+// AtmosphereHeight и выше ровно 0 - там же движок перестает считать
+// сопротивление.
+//
+// Скорости звука в модели нет, поэтому нет и числа Маха. Модель скорости
+// звука по высоте встанет рядом с этими функциями, а число Маха - методом
+// Mach() у PhysicsEngine по образцу DynamicPressure: airspeed / скорость звука.
+
+func atmosphericDensity(planet PlanetConfig, altitude float64) float64 {
+	return exponentialAtmosphere(planet, altitude, SeaLevelDensity)
+}
+
+func atmosphericPressure(planet PlanetConfig, altitude float64) float64 {
+	return exponentialAtmosphere(planet, altitude, SeaLevelPressure)
+}
+
+// exponentialAtmosphere - величина, равная seaLevel на уровне моря Земли.
+// Порядок действий как в atmosphere_density, чтобы результаты совпадали до бита.
+func exponentialAtmosphere(planet PlanetConfig, altitude, seaLevel float64) float64 {
+	if altitude < 0 {
+		altitude = 0
+	}
+	if altitude >= planet.AtmosphereHeight {
+		return 0
+	}
+	return planet.SurfacePressure * seaLevel * math.Exp(-altitude/planet.ScaleHeight)
+}
+
+// dynamicPressure - скоростной напор q = ρv²/2 по скорости относительно
+// вращающейся атмосферы (Па)
+func dynamicPressure(planet PlanetConfig, state protocol.RocketState) float64 {
+	v := airspeed(planet, state)
+	return 0.5 * atmosphericDensity(planet, state.Altitude) * v * v
+}
@@ -0,0 +1,62 @@
+package physics
+
+import (
+	"fmt"
+	"math"
+
+	"cosmodrom/protocol"
+)
+
+// checkCommand проверяет команду перед шагом: дросселей должно быть ровно
+// по числу двигателей, NaN и Inf не допускаются. В строгом режиме
+// (SetStrictCommands) ошибка и для дросселей вне 0-1 и углов вне -180..180,
+// иначе они приводятся к диапазону: clampThrottle и normalizeAngle.
+func checkCommand(command *protocol.ControlCommand, engines int, strict bool) error {
+	if command == nil {
+		return &PhysicsError{Kind: ErrorKindInvalid, Message: "команда: нет команды"}
+	}
+	if len(command.EngineThrottle) != engines {
+		return &PhysicsError{Kind: ErrorKindInvalid, Message: fmt.Sprintf("команда: %d дросселей, у ракеты %d двигателей", len(command.EngineThrottle), engines)}
+	}
+	for i, throttle := range command.EngineThrottle {
+		if math.IsNaN(throttle) || math.IsInf(throttle, 0) {
+			return &PhysicsError{Kind: ErrorKindInvalid, Message: fmt.Sprintf("команда: дроссель двигателя %d = %g", i, throttle)}
+		}
+		if strict && (throttle < 0 || throttle > 1) {
+			return &PhysicsError{Kind: ErrorKindInvalid, Message: fmt.Sprintf("команда: дроссель двигателя %d = %g вне 0-1", i, throttle)}
+		}
+	}
+	for _, angle := range []struct {
+		name  string
+		value float64
+	}{{"тангаж", command.Pitch}, {"рыскание", command.Yaw}, {"крен", command.Roll}} {
+		if math.IsNaN(angle.value) || math.IsInf(angle.value, 0) {
+			return &PhysicsError{Kind: ErrorKindInvalid, Message: fmt.Sprintf("команда: %s = %g", angle.name, angle.value)}
+		}
+		if strict && math.Abs(angle.value) > 180 {
+			return &PhysicsError{Kind: ErrorKindInvalid, Message: fmt.Sprintf("команда: %s %g° вне -180..180", angle.name, angle.value)}
+		}
+	}
+	return nil
+}
+
+// clampThrottle приводит дроссель к 0-1
+func clampThrottle(throttle float64) float64 {
+	return math.Max(0, math.Min(1, throttle))
+}
+
+// normalizeAngle приводит угол в градусах к -180..180. Углы в диапазоне не
+// меняются, чтобы не вносить ошибку округления.
+func normalizeAngle(degrees float64) float64 {
+	if degrees >= -180 && degrees <= 180 {
+		return degrees
+	}
+	degrees = math.Mod(degrees, 360)
+	switch {
+	case degrees > 180:
+		degrees -= 360
+	case degrees < -180:
+		degrees += 360
+	}
+	return degrees
+}
@@ -0,0 +1,88 @@
+package physics
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"cosmodrom/protocol"
+)
+
+func TestCommandValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  *protocol.ControlCommand
+		lenient  bool    // Принимается без строгого режима
+		throttle float64 // Ожидаемый дроссель после приведения
+		pitch    float64 // Ожидаемый тангаж после приведения
+	}{
+		{"корректная", &protocol.ControlCommand{EngineThrottle: []float64{0.5, 0.5}, Pitch: 30, Yaw: -20}, true, 0.5, 30},
+		{"дроссель больше 1", &protocol.ControlCommand{EngineThrottle: []float64{7.3, 7.3}}, true, 1, 0},
+		{"отрицательный дроссель", &protocol.ControlCommand{EngineThrottle: []float64{-1, -1}}, true, 0, 0},
+		{"тангаж 5000°", &protocol.ControlCommand{EngineThrottle: []float64{1, 1}, Pitch: 5000}, true, 1, -40},
+		{"рыскание -200°", &protocol.ControlCommand{EngineThrottle: []float64{1, 1}, Yaw: -200}, true, 1, 0},
+		{"крен 720°", &protocol.ControlCommand{EngineThrottle: []float64{1, 1}, Roll: 720}, true, 1, 0},
+		{"лишний дроссель", &protocol.ControlCommand{EngineThrottle: []float64{1, 1, 1}}, false, 0, 0},
+		{"не хватает дросселя", &protocol.ControlCommand{EngineThrottle: []float64{1}}, false, 0, 0},
+		{"без дросселей", &protocol.ControlCommand{}, false, 0, 0},
+		{"нет команды", nil, false, 0, 0},
+		{"NaN в дросселе", &protocol.ControlCommand{EngineThrottle: []float64{math.NaN(), 1}}, false, 0, 0},
+		{"Inf в тангаже", &protocol.ControlCommand{EngineThrottle: []float64{1, 1}, Pitch: math.Inf(1)}, false, 0, 0},
+	}
+
+	config := testConfig(2)
+	for _, backend := range availableBackends() {
+		for _, strict := range []bool{false, true} {
+			for _, tt := range tests {
+				p, err := NewEngine(backend, &config, EarthDefault().Position(45, 63, 100))
+				if err != nil {
+					t.Fatal(err)
+				}
+				p.SetStrictCommands(strict)
+
+				err = p.Update(tt.command, 0.01)
+				valid := tt.name == "корректная"
+				accepted := valid || (tt.lenient && !strict)
+				switch {
+				case accepted && err != nil:
+					t.Errorf("%s, строгий %v, %s: ошибка %v", backend, strict, tt.name, err)
+				case !accepted && err == nil:
+					t.Errorf("%s, строгий %v, %s: команда принята", backend, strict, tt.name)
+				case !accepted:
+					var physicsErr *PhysicsError
+					if !errors.As(err, &physicsErr) || physicsErr.Kind != ErrorKindInvalid {
+						t.Errorf("%s, %s: ошибка %v, ожидался вид invalid", backend, tt.name, err)
+					}
+					if state, _ := p.GetState(); state.Time != 0 {
+						t.Errorf("%s, %s: отклоненная команда сделала шаг", backend, tt.name)
+					}
+				}
+				if accepted {
+					// Приведенная команда дает тот же шаг, что и ожидаемая корректная
+					want, _ := NewEngine(backend, &config, EarthDefault().Position(45, 63, 100))
+					expected := &protocol.ControlCommand{EngineThrottle: []float64{tt.throttle, tt.throttle}, Pitch: tt.pitch, Yaw: normalizeAngle(tt.command.Yaw)}
+					if err := want.Update(expected, 0.01); err != nil {
+						t.Fatal(err)
+					}
+					got, _ := p.GetState()
+					wantState, _ := want.GetState()
+					if math.Abs(got.Velocity.X-wantState.Velocity.X) > 1e-9 || math.Abs(got.FuelRemaining-wantState.FuelRemaining) > 1e-9 {
+						t.Errorf("%s, %s: шаг %+v, ожидался %+v", backend, tt.name, got.Velocity, wantState.Velocity)
+					}
+					want.Close()
+				}
+				p.Close()
+			}
+		}
+	}
+}
+
+func TestNormalizeAngle(t *testing.T) {
+	for _, tt := range []struct{ in, want float64 }{
+		{0, 0}, {180, 180}, {-180, -180}, {181, -179}, {-181, 179}, {360, 0}, {5000, -40}, {-725, -5},
+	} {
+		if got := normalizeAngle(tt.in); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("normalizeAngle(%g) = %g, ожидалось %g", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package physics
+
+import (
+	"fmt"
+
+	"cosmodrom/protocol"
+)
+
+// PhysicsEngine - физическая модель ракеты. Реализации: RocketPhysics (движок
+// на C, librocket_physics) и GoPhysics (та же модель на Go, без cgo). Методы
+// можно вызывать из разных горутин; после Close они возвращают ErrFreed.
+// GetState и RunSteps проверяют состояние: при NaN или Inf ошибка - вид
+// ErrNonFinite, при невозможных массе и топливе - ErrInconsistent, а само
+// состояние возвращается для диагностики. Update и RunSteps отклоняют
+// команду, если дросселей не столько, сколько двигателей, или в ней NaN;
+// дроссели приводятся к 0-1, углы к -180..180 (см. SetStrictCommands). Тяга
+// направлена по ориентации ракеты, которая поворачивается к углам команды
+// не быстрее SetSlewRate.
+// Обработчики OnEvent вызываются из Update и RunSteps после шага.
+type PhysicsEngine interface {
+	Update(command *protocol.ControlCommand, deltaTime float64) error
+	RunSteps(command *protocol.ControlCommand, deltaTime float64, n int) (protocol.RocketState, int, error)
+	SetStrictCommands(strict bool)
+	SetSlewRate(degreesPerSecond float64)
+	OnEvent(kind EventKind, fn func(protocol.RocketState))
+	GetState() (protocol.RocketState, error)
+	SetStage(massEmpty float64, engines []protocol.Engine) error
+	JettisonFuel(mass float64) error
+	SetPlanet(planet PlanetConfig) error
+	SetInitialVelocity(velocity protocol.Vector3) error
+	SetGravityTurn(gt GravityTurnConfig)
+	CalculateOptimalPitch() (float64, error)
+	Airspeed() (float64, error)
+	AtmosphereDensity(altitude float64) float64
+	AtmospherePressure(altitude float64) float64
+	DynamicPressure() (float64, error)
+	PredictOrbit() (OrbitPrediction, error)
+	ThrustToWeight() (float64, error)
+	DeltaVRemaining() (float64, error)
+	BurnTimeRemaining(throttle float64) (float64, error)
+	PlanCircularization(targetAlt float64) (BurnPlan, error)
+	PlanHohmann(fromAlt, toAlt float64) (HohmannPlan, error)
+	PredictImpact() (ImpactPrediction, error)
+	GroundTrack() (GroundPoint, error)
+	PredictGroundTrack(duration, step float64) ([]GroundPoint, error)
+	Propagate(duration, step float64) ([]protocol.Vector3, error)
+	Snapshot() ([]byte, error)
+	Restore(data []byte) error
+	Close() error
+}
+
+// Backend - реализация физики: флаг клиента -physics
+type Backend string
+
+const (
+	BackendC  Backend = "c"  // librocket_physics через cgo
+	BackendGo Backend = "go" // GoPhysics
+)
+
+// CheckBackend проверяет, что физика backend есть в этой сборке. Пустое
+// значение - DefaultBackend.
+func CheckBackend(backend Backend) error {
+	switch backend {
+	case "", BackendGo:
+		return nil
+	case BackendC:
+		if !cgoAvailable {
+			return fmt.Errorf("физика c недоступна: клиент собран без cgo (тег nocgo), используйте -physics go")
+		}
+		return nil
+	}
+	return fmt.Errorf("неизвестная физика -physics %q: ожидается c или go", backend)
+}
+
+// NewEngine создает физику backend для ракеты config в точке initialPos
+func NewEngine(backend Backend, config *protocol.RocketConfig, initialPos protocol.Vector3) (PhysicsEngine, error) {
+	if backend == "" {
+		backend = DefaultBackend
+	}
+	if err := CheckBackend(backend); err != nil {
+		return nil, err
+	}
+	if backend == BackendGo {
+		return NewGoPhysics(config, initialPos)
+	}
+	return newCEngine(config, initialPos)
+}
+
+// NewEngineFromSnapshot создает физику backend в состоянии из Snapshot.
+// Снимок любой физики подходит для любой другой.
+func NewEngineFromSnapshot(backend Backend, data []byte) (PhysicsEngine, error) {
+	if backend == "" {
+		backend = DefaultBackend
+	}
+	if err := CheckBackend(backend); err != nil {
+		return nil, err
+	}
+	if backend == BackendGo {
+		return NewGoPhysicsFromSnapshot(data)
+	}
+	return newCEngineFromSnapshot(data)
+}
@@ -0,0 +1,20 @@
+//go:build cgo && !nocgo
+
+package physics
+
+import "cosmodrom/protocol"
+
+// DefaultBackend - физика по умолчанию в этой сборке
+const DefaultBackend = BackendC
+
+const cgoAvailable = true
+
+var _ PhysicsEngine = (*RocketPhysics)(nil)
+
+func newCEngine(config *protocol.RocketConfig, initialPos protocol.Vector3) (PhysicsEngine, error) {
+	return NewRocketPhysics(config, initialPos)
+}
+
+func newCEngineFromSnapshot(data []byte) (PhysicsEngine, error) {
+	return NewRocketPhysicsFromSnapshot(data)
+}
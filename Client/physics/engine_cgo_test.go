@@ -0,0 +1,66 @@
+//go:build cgo && !nocgo
+
+package physics
+
+import (
+	"math"
+	"testing"
+
+	"cosmodrom/protocol"
+)
+
+// Эталонный подъем с гравитационным поворотом: физика на Go должна совпадать
+// с движком на C в пределах нескольких процентов.
+func TestBackendsAgree(t *testing.T) {
+	planet := EarthDefault()
+	config := testConfig(2)
+	start := planet.Position(45, 63, 100)
+
+	run := func(backend Backend) []protocol.RocketState {
+		p, err := NewEngine(backend, &config, start)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer p.Close()
+		p.SetPlanet(planet)
+		p.SetInitialVelocity(planet.SurfaceVelocity(start))
+		p.SetGravityTurn(GravityTurnForOrbit(planet, 200000))
+
+		var samples []protocol.RocketState
+		command := &protocol.ControlCommand{EngineThrottle: []float64{0.8, 0.8}}
+		for i := 1; i <= 10000; i++ {
+			pitch, err := p.CalculateOptimalPitch()
+			if err != nil {
+				t.Fatal(err)
+			}
+			command.Pitch = pitch
+			if err := p.Update(command, 0.01); err != nil {
+				t.Fatal(err)
+			}
+			if i%2000 == 0 {
+				state, err := p.GetState()
+				if err != nil {
+					t.Fatal(err)
+				}
+				samples = append(samples, state)
+			}
+		}
+		return samples
+	}
+
+	c, gophysics := run(BackendC), run(BackendGo)
+	within := func(name string, t0, got, want float64) {
+		if math.Abs(got-want) > 0.02*math.Abs(want)+1e-6 {
+			t.Errorf("T+%.0f с: %s %.3f (go), %.3f (c)", t0, name, got, want)
+		}
+	}
+	for i := range c {
+		within("высота", c[i].Time, gophysics[i].Altitude, c[i].Altitude)
+		within("скорость", c[i].Time, gophysics[i].Speed, c[i].Speed)
+		within("топливо", c[i].Time, gophysics[i].FuelRemaining, c[i].FuelRemaining)
+		within("масса", c[i].Time, gophysics[i].MassCurrent, c[i].MassCurrent)
+	}
+	if last := c[len(c)-1]; last.Altitude < 10000 || last.Crashed {
+		t.Errorf("эталонный подъем не удался: высота %.0f м", last.Altitude)
+	}
+}
@@ -0,0 +1,36 @@
+//go:build !cgo || nocgo
+
+package physics
+
+import (
+	"math"
+
+	"cosmodrom/protocol"
+)
+
+// DefaultBackend - физика по умолчанию в этой сборке: без cgo доступна только Go
+const DefaultBackend = BackendGo
+
+const cgoAvailable = false
+
+func newCEngine(config *protocol.RocketConfig, initialPos protocol.Vector3) (PhysicsEngine, error) {
+	return nil, &PhysicsError{Kind: ErrorKindUnavailable, Message: "клиент собран без cgo"}
+}
+
+func newCEngineFromSnapshot(data []byte) (PhysicsEngine, error) {
+	return nil, &PhysicsError{Kind: ErrorKindUnavailable, Message: "клиент собран без cgo"}
+}
+
+// SphericalToCartesian - как spherical_to_cartesian в движке: от радиуса Земли
+func SphericalToCartesian(latitude, longitude, altitude float64) protocol.Vector3 {
+	return EarthDefault().Position(latitude, longitude, altitude)
+}
+
+// CartesianToSpherical - как cartesian_to_spherical в движке: от радиуса Земли
+func CartesianToSpherical(pos protocol.Vector3) (latitude, longitude, altitude float64) {
+	r := math.Sqrt(dot(pos, pos))
+	altitude = r - EarthDefault().Radius
+	latitude = math.Asin(pos.Z/r) * 180.0 / math.Pi
+	longitude = math.Atan2(pos.Y, pos.X) * 180.0 / math.Pi
+	return latitude, longitude, altitude
+}
@@ -0,0 +1,360 @@
+package physics
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"cosmodrom/protocol"
+)
+
+func testConfig(engines int) protocol.RocketConfig {
+	config := protocol.RocketConfig{
+		Name:            "Test",
+		MassEmpty:       20000,
+		MassFuel:        400000,
+		MassFuelMax:     400000,
+		FuelType:        protocol.FuelTypeKerosene,
+		DragCoefficient: 0.3,
+		CrossSection:    12,
+	}
+	for i := 0; i < engines; i++ {
+		config.Engines = append(config.Engines, protocol.Engine{Thrust: 7600000, FuelConsumption: 2500, IsActive: true})
+	}
+	return config
+}
+
+func TestGoPhysicsHop(t *testing.T) {
+	config := testConfig(1)
+	p, err := NewEngine(BackendGo, &config, EarthDefault().Position(45, 63, 0.5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// 10 с на полной тяге вертикально, затем падение до земли
+	command := &protocol.ControlCommand{EngineThrottle: []float64{1}}
+	for i := 0; i < 1000; i++ {
+		if err := p.Update(command, 0.01); err != nil {
+			t.Fatal(err)
+		}
+	}
+	state, err := p.GetState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 400000 - 2500*10.0; math.Abs(state.FuelRemaining-want) > 1e-6 {
+		t.Errorf("топливо %.3f кг, ожидалось %.3f", state.FuelRemaining, want)
+	}
+	if state.Altitude < 100 || state.Altitude > 1000 {
+		t.Errorf("высота %.0f м через 10 с, ожидалось несколько сотен", state.Altitude)
+	}
+
+	idle := &protocol.ControlCommand{EngineThrottle: []float64{0}}
+	for i := 0; i < 100000 && !state.Crashed && !state.Landed; i++ {
+		p.Update(idle, 0.01)
+		state, _ = p.GetState()
+	}
+	if !state.Crashed {
+		t.Errorf("ракета без тяги не разбилась: высота %.0f м", state.Altitude)
+	}
+}
+
+func TestGoPhysicsUseAfterClose(t *testing.T) {
+	config := testConfig(1)
+	p, err := NewGoPhysics(&config, EarthDefault().Position(45, 63, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Close()
+	p.Close()
+
+	if err := p.Update(&protocol.ControlCommand{EngineThrottle: []float64{1}}, 0.01); !errors.Is(err, ErrFreed) {
+		t.Errorf("Update после Close: ошибка %v, ожидалась ErrFreed", err)
+	}
+	if _, err := p.PredictOrbit(); !errors.Is(err, ErrFreed) {
+		t.Errorf("PredictOrbit после Close: ошибка %v, ожидалась ErrFreed", err)
+	}
+}
+
+func TestCheckBackend(t *testing.T) {
+	for _, backend := range []Backend{"", BackendGo, DefaultBackend} {
+		if err := CheckBackend(backend); err != nil {
+			t.Errorf("%q: %v", backend, err)
+		}
+	}
+	if err := CheckBackend("fortran"); err == nil {
+		t.Error("неизвестная физика принята")
+	}
+}
+
+// availableBackends - физики этой сборки: go всегда, c - если есть cgo
+func availableBackends() []Backend {
+	if DefaultBackend == BackendGo {
+		return []Backend{BackendGo}
+	}
+	return []Backend{BackendGo, DefaultBackend}
+}
+
+func TestPropulsionEstimates(t *testing.T) {
+	// Ожидаемые значения посчитаны вручную на высоте 100 м: g = 9.81922 м/с2
+	falcon := protocol.RocketConfig{
+		Name: "Falcon 1-ish", MassEmpty: 2300, MassFuel: 24400, MassFuelMax: 24400, CrossSection: 2.3,
+		// Первая ступень: 450 кН, Isp 290 с
+		Engines: []protocol.Engine{{Thrust: 450000, FuelConsumption: 450000 / (290 * 9.80665), IsActive: true}},
+	}
+	oneOut := testConfig(2)
+	oneOut.Engines[1].IsActive = false
+	mixed := protocol.RocketConfig{
+		Name: "Mixed", MassEmpty: 3000, MassFuel: 8000, MassFuelMax: 8000, CrossSection: 1,
+		Engines: []protocol.Engine{
+			{Thrust: 1000000, FuelConsumption: 400, IsActive: true},
+			{Thrust: 500000, FuelConsumption: 100, IsActive: true},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		config    protocol.RocketConfig
+		throttles []float64
+		twr       float64
+		deltaV    float64
+		burnAt    float64 // Дроссель для BurnTimeRemaining
+		burnTime  float64
+	}{
+		{
+			// ve = 7600 кН / 2500 кг/с = 3040 м/с, dv = 3040 * ln(420/20)
+			name: "по умолчанию, полная тяга", config: testConfig(1), throttles: []float64{1},
+			twr: 1.84284, deltaV: 9255.35, burnAt: 1, burnTime: 160,
+		},
+		{
+			name: "по умолчанию, половина тяги", config: testConfig(1), throttles: []float64{0.5},
+			twr: 0.92142, deltaV: 9255.35, burnAt: 0.5, burnTime: 320,
+		},
+		{
+			// Заглушенные двигатели: скорость истечения при полной тяге
+			name: "двигатели заглушены", config: testConfig(1), throttles: []float64{0},
+			twr: 0, deltaV: 9255.35, burnAt: 0, burnTime: math.Inf(1),
+		},
+		{
+			name: "отказ второго двигателя", config: oneOut, throttles: []float64{1, 1},
+			twr: 1.84284, deltaV: 9255.35, burnAt: 1, burnTime: 160,
+		},
+		{
+			// ve = 290 * g0 = 2843.93 м/с, dv = ve * ln(26700/2300)
+			name: "falcon-1ish, первая ступень", config: falcon, throttles: []float64{1},
+			twr: 1.71642, deltaV: 6972.61, burnAt: 1, burnTime: 154.204,
+		},
+		{
+			// Тяга 1250 кН при расходе 450 кг/с: ve = 2777.78 м/с
+			name: "разные двигатели и дроссели", config: mixed, throttles: []float64{1, 0.5},
+			twr: 11.5728, deltaV: 3609.12, burnAt: 0.5, burnTime: 32,
+		},
+	}
+
+	for _, backend := range availableBackends() {
+		for _, tt := range tests {
+			t.Run(string(backend)+"/"+tt.name, func(t *testing.T) {
+				p, err := NewEngine(backend, &tt.config, EarthDefault().Position(45, 63, 100))
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer p.Close()
+				// Шаг нулевой длины только запоминает команду
+				if err := p.Update(&protocol.ControlCommand{EngineThrottle: tt.throttles}, 0); err != nil {
+					t.Fatal(err)
+				}
+
+				check := func(name string, got, want, tolerance float64) {
+					if math.IsInf(want, 1) && math.IsInf(got, 1) {
+						return
+					}
+					if math.Abs(got-want) > tolerance {
+						t.Errorf("%s %.5f, ожидалось %.5f", name, got, want)
+					}
+				}
+				twr, err := p.ThrustToWeight()
+				if err != nil {
+					t.Fatal(err)
+				}
+				check("тяговооруженность", twr, tt.twr, 1e-4)
+				deltaV, err := p.DeltaVRemaining()
+				if err != nil {
+					t.Fatal(err)
+				}
+				check("запас dv", deltaV, tt.deltaV, 0.01)
+				burnTime, err := p.BurnTimeRemaining(tt.burnAt)
+				if err != nil {
+					t.Fatal(err)
+				}
+				check("время работы", burnTime, tt.burnTime, 1e-3)
+			})
+		}
+	}
+}
+
+// TestDeltaVByFuelType сравнивает запас скорости ракет одной массы с
+// двигателями по типовому удельному импульсу топлива: с заданным расходом
+// (EngineFromIsp) и без него
+func TestDeltaVByFuelType(t *testing.T) {
+	fuels := []protocol.FuelType{protocol.FuelTypeLiquidH2, protocol.FuelTypeKerosene, protocol.FuelTypeSolid}
+
+	for _, backend := range availableBackends() {
+		for _, consumption := range []bool{true, false} {
+			name := string(backend) + "/расход по Isp"
+			if !consumption {
+				name = string(backend) + "/расход не задан"
+			}
+			t.Run(name, func(t *testing.T) {
+				var deltaVs []float64
+				for _, fuel := range fuels {
+					config := testConfig(0)
+					config.FuelType = fuel
+					engine := protocol.EngineFromIsp(7600000, fuel)
+					if !consumption {
+						engine.FuelConsumption = 0
+					}
+					config.Engines = []protocol.Engine{engine}
+
+					p, err := NewEngine(backend, &config, EarthDefault().Position(45, 63, 100))
+					if err != nil {
+						t.Fatal(err)
+					}
+					deltaV, err := p.DeltaVRemaining()
+					p.Close()
+					if err != nil {
+						t.Fatal(err)
+					}
+					want := fuel.ExhaustVelocity() * math.Log(420.0/20.0)
+					if math.Abs(deltaV-want) > 0.01 {
+						t.Errorf("%s: запас dv %.2f м/с, ожидалось %.2f", fuel, deltaV, want)
+					}
+					deltaVs = append(deltaVs, deltaV)
+				}
+				if !(deltaVs[0] > deltaVs[1] && deltaVs[1] > deltaVs[2]) {
+					t.Errorf("запас dv H2 %.0f, керосин %.0f, твердое %.0f м/с: ожидалось H2 > керосин > твердое",
+						deltaVs[0], deltaVs[1], deltaVs[2])
+				}
+			})
+		}
+	}
+}
+
+func TestJettisonFuel(t *testing.T) {
+	for _, backend := range availableBackends() {
+		t.Run(string(backend), func(t *testing.T) {
+			config := testConfig(1)
+			p, err := NewEngine(backend, &config, EarthDefault().Position(45, 63, 100))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer p.Close()
+
+			if err := p.JettisonFuel(150000); err != nil {
+				t.Fatal(err)
+			}
+			state, _ := p.GetState()
+			if state.FuelRemaining != 250000 || state.MassCurrent != 270000 {
+				t.Errorf("топливо %.0f, масса %.0f, ожидалось 250000 и 270000", state.FuelRemaining, state.MassCurrent)
+			}
+
+			p.JettisonFuel(1e9)
+			if state, _ = p.GetState(); state.FuelRemaining != 0 || state.MassCurrent != 20000 {
+				t.Errorf("сброс сверх запаса: топливо %.0f, масса %.0f", state.FuelRemaining, state.MassCurrent)
+			}
+		})
+	}
+}
+
+func TestRunStepsMatchesUpdate(t *testing.T) {
+	config := testConfig(2)
+	// Прожорливый двигатель вырабатывает топливо за 15 с, пока ракета еще летит
+	upper := []protocol.Engine{{Thrust: 9000000, FuelConsumption: 30000, IsActive: true}}
+	for _, backend := range availableBackends() {
+		t.Run(string(backend), func(t *testing.T) {
+			single, _ := NewEngine(backend, &config, EarthDefault().Position(45, 63, 100))
+			batch, _ := NewEngine(backend, &config, EarthDefault().Position(45, 63, 100))
+			defer single.Close()
+			defer batch.Close()
+
+			command := &protocol.ControlCommand{EngineThrottle: []float64{0.9, 0.7}, Pitch: 12, Yaw: 5}
+			for i := 0; i < 500; i++ {
+				single.Update(command, 0.01)
+			}
+			state, done, err := batch.RunSteps(command, 0.01, 500)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, _ := single.GetState()
+			if done != 500 || !reflect.DeepEqual(state, want) {
+				t.Errorf("RunSteps: %d шагов, %+v; ожидалось 500, %+v", done, state, want)
+			}
+
+			// Топливо кончается на середине пакета: пакет останавливается на этом шаге
+			single.SetStage(8000, upper)
+			batch.SetStage(8000, upper)
+			command.EngineThrottle = []float64{0.9}
+			var steps int
+			for want.FuelRemaining > 0 && !want.Crashed {
+				if err := single.Update(command, 0.01); err != nil {
+					t.Fatal(err)
+				}
+				want, _ = single.GetState()
+				steps++
+			}
+			state, done, _ = batch.RunSteps(command, 0.01, steps+1000)
+			if done != steps || !reflect.DeepEqual(state, want) {
+				t.Errorf("выработка топлива: %d шагов, ожидалось %d", done, steps)
+			}
+
+			// Без топлива ракета падает: пакет останавливается на крушении
+			state, done, _ = batch.RunSteps(command, 0.01, 1000000)
+			if !state.Crashed || done == 1000000 {
+				t.Errorf("падение: %d шагов, разбилась %v", done, state.Crashed)
+			}
+			if _, again, _ := batch.RunSteps(command, 0.01, 10); again != 0 {
+				t.Errorf("после крушения сделано %d шагов", again)
+			}
+		})
+	}
+}
+
+// BenchmarkCoast сравнивает цену шага физики по одному вызову Update и
+// пакетом RunSteps по 100 шагов на орбите. Для движка на C разница - цена
+// перехода в cgo на каждом шаге.
+func BenchmarkCoast(b *testing.B) {
+	command := &protocol.ControlCommand{EngineThrottle: []float64{0}}
+	for _, backend := range availableBackends() {
+		b.Run(string(backend)+"/update", func(b *testing.B) {
+			p := benchmarkOrbit(b, backend)
+			defer p.Close()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				p.Update(command, 0.01)
+			}
+		})
+		b.Run(string(backend)+"/run-steps", func(b *testing.B) {
+			p := benchmarkOrbit(b, backend)
+			defer p.Close()
+			b.ResetTimer()
+			for i := 0; i < b.N; i += 100 {
+				p.RunSteps(command, 0.01, min(100, b.N-i))
+			}
+		})
+	}
+}
+
+// benchmarkOrbit - ракета на круговой орбите 400 км: шаги не кончаются падением
+func benchmarkOrbit(b *testing.B, backend Backend) PhysicsEngine {
+	planet := EarthDefault()
+	config := testConfig(1)
+	start := planet.Position(0, 0, 400000)
+	p, err := NewEngine(backend, &config, start)
+	if err != nil {
+		b.Fatal(err)
+	}
+	r := planet.Radius + 400000
+	p.SetInitialVelocity(protocol.Vector3{Y: math.Sqrt(protocol.GConstant * planet.Mass / r)})
+	return p
+}
@@ -0,0 +1,89 @@
+package physics
+
+import (
+	"fmt"
+	"math"
+
+	"cosmodrom/protocol"
+)
+
+// ErrorKind - вид ошибки физики, по нему вызывающий решает, что делать:
+// неверные параметры можно исправить, а испорченное состояние - только
+// прекратить полет
+type ErrorKind string
+
+const (
+	ErrorKindInvalid      ErrorKind = "invalid"      // Неверные параметры: планета, шаг, снимок
+	ErrorKindUnavailable  ErrorKind = "unavailable"  // Движок не создан или недоступен в сборке
+	ErrorKindFreed        ErrorKind = "freed"        // Вызов после Close
+	ErrorKindNonFinite    ErrorKind = "non_finite"   // NaN или Inf в состоянии ракеты
+	ErrorKindInconsistent ErrorKind = "inconsistent" // Невозможное состояние: отрицательная масса, топлива больше бака
+)
+
+// PhysicsError - ошибка физики. errors.Is сравнивает ее с образцами ErrFreed,
+// ErrNonFinite и ErrInconsistent по виду Kind; Err - исходная ошибка, если есть.
+type PhysicsError struct {
+	Kind    ErrorKind
+	Message string
+	Err     error
+}
+
+func (e *PhysicsError) Error() string {
+	message := e.Message
+	if message == "" {
+		message = string(e.Kind)
+	}
+	if e.Err != nil {
+		message += ": " + e.Err.Error()
+	}
+	return "Physics error: " + message
+}
+
+func (e *PhysicsError) Unwrap() error {
+	return e.Err
+}
+
+// Is сравнивает ошибку с образцом того же вида: errors.Is(err, ErrNonFinite)
+func (e *PhysicsError) Is(target error) bool {
+	t, ok := target.(*PhysicsError)
+	return ok && t.Kind != "" && t.Kind == e.Kind && t.Err == nil
+}
+
+var (
+	// ErrFreed - ошибка вызова после Close
+	ErrFreed = &PhysicsError{Kind: ErrorKindFreed, Message: "физический движок уже освобожден"}
+	// ErrNonFinite - образец для errors.Is: в состоянии ракеты NaN или Inf
+	ErrNonFinite = &PhysicsError{Kind: ErrorKindNonFinite, Message: "в состоянии ракеты NaN или Inf"}
+	// ErrInconsistent - образец для errors.Is: состояние ракеты невозможно
+	ErrInconsistent = &PhysicsError{Kind: ErrorKindInconsistent, Message: "невозможное состояние ракеты"}
+)
+
+// checkState проверяет состояние после шага: NaN или Inf в векторах и
+// скалярах, отрицательные масса и топливо, топлива больше бака fuelMax
+// (0 - без проверки бака). Такое состояние нельзя отправлять в телеметрию.
+func checkState(state protocol.RocketState, fuelMax float64) error {
+	values := []struct {
+		name  string
+		value float64
+	}{
+		{"position.x", state.Position.X}, {"position.y", state.Position.Y}, {"position.z", state.Position.Z},
+		{"velocity.x", state.Velocity.X}, {"velocity.y", state.Velocity.Y}, {"velocity.z", state.Velocity.Z},
+		{"acceleration.x", state.Acceleration.X}, {"acceleration.y", state.Acceleration.Y}, {"acceleration.z", state.Acceleration.Z},
+		{"altitude", state.Altitude}, {"speed", state.Speed},
+		{"mass_current", state.MassCurrent}, {"fuel_remaining", state.FuelRemaining}, {"time", state.Time},
+	}
+	for _, v := range values {
+		if math.IsNaN(v.value) || math.IsInf(v.value, 0) {
+			return &PhysicsError{Kind: ErrorKindNonFinite, Message: fmt.Sprintf("%s = %g на T+%g с", v.name, v.value, state.Time)}
+		}
+	}
+	switch {
+	case state.MassCurrent < 0:
+		return &PhysicsError{Kind: ErrorKindInconsistent, Message: fmt.Sprintf("отрицательная масса %g кг", state.MassCurrent)}
+	case state.FuelRemaining < 0:
+		return &PhysicsError{Kind: ErrorKindInconsistent, Message: fmt.Sprintf("отрицательный запас топлива %g кг", state.FuelRemaining)}
+	case fuelMax > 0 && state.FuelRemaining > fuelMax:
+		return &PhysicsError{Kind: ErrorKindInconsistent, Message: fmt.Sprintf("топлива %g кг больше бака %g кг", state.FuelRemaining, fuelMax)}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package physics
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"cosmodrom/protocol"
+)
+
+func TestCheckState(t *testing.T) {
+	good := protocol.RocketState{
+		Position:    protocol.Vector3{X: 6371000},
+		MassCurrent: 1000, FuelRemaining: 500, Time: 12,
+	}
+	if err := checkState(good, 500); err != nil {
+		t.Fatalf("корректное состояние: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*protocol.RocketState)
+		kind   error
+	}{
+		{"NaN в позиции", func(s *protocol.RocketState) { s.Position.Y = math.NaN() }, ErrNonFinite},
+		{"Inf в скорости", func(s *protocol.RocketState) { s.Velocity.Z = math.Inf(-1) }, ErrNonFinite},
+		{"NaN в массе", func(s *protocol.RocketState) { s.MassCurrent = math.NaN() }, ErrNonFinite},
+		{"отрицательная масса", func(s *protocol.RocketState) { s.MassCurrent = -1 }, ErrInconsistent},
+		{"отрицательное топливо", func(s *protocol.RocketState) { s.FuelRemaining = -0.5 }, ErrInconsistent},
+		{"топлива больше бака", func(s *protocol.RocketState) { s.FuelRemaining = 501 }, ErrInconsistent},
+	}
+	for _, tt := range tests {
+		state := good
+		tt.modify(&state)
+		err := checkState(state, 500)
+		if !errors.Is(err, tt.kind) {
+			t.Errorf("%s: ошибка %v, ожидался вид %v", tt.name, err, tt.kind)
+		}
+		if errors.Is(err, ErrFreed) {
+			t.Errorf("%s: ошибка совпала с ErrFreed", tt.name)
+		}
+	}
+}
+
+func TestGetStateRejectsNaN(t *testing.T) {
+	config := testConfig(1)
+	for _, backend := range availableBackends() {
+		p, err := NewEngine(backend, &config, EarthDefault().Position(45, 63, 100))
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.SetInitialVelocity(protocol.Vector3{X: math.NaN()})
+		p.Update(&protocol.ControlCommand{EngineThrottle: []float64{1}}, 0.01)
+		if _, err := p.GetState(); !errors.Is(err, ErrNonFinite) {
+			t.Errorf("%s: GetState с NaN вернул %v, ожидалась ErrNonFinite", backend, err)
+		}
+		if _, _, err := p.RunSteps(&protocol.ControlCommand{EngineThrottle: []float64{0}}, 0.01, 3); !errors.Is(err, ErrNonFinite) {
+			t.Errorf("%s: RunSteps с NaN вернул %v, ожидалась ErrNonFinite", backend, err)
+		}
+		var physicsErr *PhysicsError
+		if _, err := p.Snapshot(); !errors.As(err, &physicsErr) || physicsErr.Kind != ErrorKindNonFinite {
+			t.Errorf("%s: снимок с NaN: %v", backend, err)
+		}
+		p.Close()
+		if _, err := p.GetState(); !errors.Is(err, ErrFreed) || errors.Is(err, ErrNonFinite) {
+			t.Errorf("%s: после Close %v, ожидалась ErrFreed", backend, err)
+		}
+	}
+}
+
+func TestPhysicsErrorWraps(t *testing.T) {
+	_, err := DecodeSnapshot([]byte("{"))
+	var syntax *json.SyntaxError
+	if !errors.As(err, &syntax) {
+		t.Errorf("ошибка разбора снимка %v не оборачивает json.SyntaxError", err)
+	}
+	var physicsErr *PhysicsError
+	if !errors.As(err, &physicsErr) || physicsErr.Kind != ErrorKindInvalid {
+		t.Errorf("ошибка %v, ожидался вид invalid", err)
+	}
+	if errors.Is(err, ErrNonFinite) || errors.Is(err, ErrInconsistent) {
+		t.Errorf("ошибка разбора снимка %v совпала с образцом другого вида", err)
+	}
+}
@@ -0,0 +1,124 @@
+package physics
+
+import (
+	"cosmodrom/protocol"
+)
+
+// EventKind - событие полета для OnEvent
+type EventKind string
+
+const (
+	EventApogee            EventKind = "apogee"             // Вертикальная скорость сменила знак с + на -
+	EventFuelEmpty         EventKind = "fuel_empty"         // Топливо закончилось
+	EventAtmosphereExited  EventKind = "atmosphere_exited"  // Подъем выше AtmosphereHeight
+	EventAtmosphereEntered EventKind = "atmosphere_entered" // Спуск ниже AtmosphereHeight
+	EventMaxQ              EventKind = "max_q"              // Первый максимум скоростного напора
+	EventLanded            EventKind = "landed"
+	EventCrashed           EventKind = "crashed"
+)
+
+// events - подписки OnEvent и поиск переходов между соседними состояниями.
+// Общая часть RocketPhysics и GoPhysics, вызывается под их мьютексом; сами
+// обработчики вызываются после шага, когда мьютекс уже отпущен.
+type events struct {
+	handlers map[EventKind][]func(protocol.RocketState)
+
+	prev     protocol.RocketState
+	prevQ    float64
+	observed bool // prev заполнено
+	maxQ     bool // Максимум напора уже пройден
+}
+
+// firedEvents - обработчики сработавших событий с состоянием после шага
+type firedEvents struct {
+	state    protocol.RocketState
+	handlers []func(protocol.RocketState)
+}
+
+func (f firedEvents) dispatch() {
+	for _, fn := range f.handlers {
+		fn(f.state)
+	}
+}
+
+func (e *events) on(kind EventKind, fn func(protocol.RocketState)) {
+	if fn == nil {
+		return
+	}
+	if e.handlers == nil {
+		e.handlers = make(map[EventKind][]func(protocol.RocketState))
+		// Без подписок состояния не сравнивались: отсчет с текущего шага
+		e.observed = false
+	}
+	e.handlers[kind] = append(e.handlers[kind], fn)
+}
+
+// watching - есть подписки; без них состояние не нужно даже получать
+func (e *events) watching() bool {
+	return len(e.handlers) > 0
+}
+
+// reset забывает предыдущее состояние, например после Restore: переход из
+// старого состояния в восстановленное - не событие полета
+func (e *events) reset() {
+	e.observed = false
+}
+
+// clear снимает все подписки (Close)
+func (e *events) clear() {
+	*e = events{}
+}
+
+// observe сравнивает состояние после шага с предыдущим и собирает
+// обработчики каждого перехода: событие срабатывает один раз на переход
+func (e *events) observe(state protocol.RocketState, planet PlanetConfig) firedEvents {
+	fired := firedEvents{state: state}
+	if !e.watching() {
+		return fired
+	}
+	q := dynamicPressure(planet, state)
+	prev, prevQ, observed := e.prev, e.prevQ, e.observed
+	e.prev, e.prevQ, e.observed = state, q, true
+	if !observed {
+		return fired
+	}
+
+	add := func(kind EventKind) {
+		fired.handlers = append(fired.handlers, e.handlers[kind]...)
+	}
+	flying := !state.Landed && !state.Crashed
+	if flying && verticalSpeedOf(prev) > 0 && verticalSpeedOf(state) <= 0 {
+		add(EventApogee)
+	}
+	if prev.FuelRemaining > 0 && state.FuelRemaining <= 0 {
+		add(EventFuelEmpty)
+	}
+	if top := planet.AtmosphereHeight; top > 0 {
+		if prev.Altitude < top && state.Altitude >= top {
+			add(EventAtmosphereExited)
+		}
+		if prev.Altitude >= top && state.Altitude < top {
+			add(EventAtmosphereEntered)
+		}
+	}
+	if !e.maxQ && prevQ > 0 && q < prevQ {
+		e.maxQ = true
+		add(EventMaxQ)
+	}
+	if !prev.Landed && state.Landed {
+		add(EventLanded)
+	}
+	if !prev.Crashed && state.Crashed {
+		add(EventCrashed)
+	}
+	return fired
+}
+
+// verticalSpeedOf - проекция скорости на направление от центра планеты
+func verticalSpeedOf(state protocol.RocketState) float64 {
+	r := state.Position.Norm()
+	if r == 0 {
+		return 0
+	}
+	return state.Position.Dot(state.Velocity) / r
+}
@@ -0,0 +1,121 @@
+package physics
+
+import (
+	"reflect"
+	"testing"
+
+	"cosmodrom/protocol"
+)
+
+// eventRecorder подписывается на все события и запоминает их по порядку
+func eventRecorder(subscribe func(EventKind, func(protocol.RocketState))) *[]EventKind {
+	var fired []EventKind
+	for _, kind := range []EventKind{EventApogee, EventFuelEmpty, EventAtmosphereExited, EventAtmosphereEntered, EventMaxQ, EventLanded, EventCrashed} {
+		subscribe(kind, func(protocol.RocketState) { fired = append(fired, kind) })
+	}
+	return &fired
+}
+
+// TestEventTransitions прогоняет через events синтетическую
+// последовательность состояний: подъем сквозь атмосферу, выработка топлива,
+// апогей, спуск и падение
+func TestEventTransitions(t *testing.T) {
+	planet := EarthDefault()
+	planet.RotationPeriod = 0 // Воздушная скорость равна скорости
+	at := func(altitude, verticalSpeed, fuel float64) protocol.RocketState {
+		return protocol.RocketState{
+			Position:      protocol.Vector3{X: planet.Radius + altitude},
+			Velocity:      protocol.Vector3{X: verticalSpeed},
+			Altitude:      altitude,
+			FuelRemaining: fuel,
+		}
+	}
+	crashed := at(0, 0, 0)
+	crashed.Crashed = true
+
+	steps := []struct {
+		state protocol.RocketState
+		want  []EventKind
+	}{
+		{at(0, 0, 100), nil},
+		{at(1000, 300, 90), nil},
+		{at(10000, 800, 80), nil},
+		// Скоростной напор падает с высотой при почти той же скорости
+		{at(20000, 900, 70), []EventKind{EventMaxQ}},
+		{at(99000, 1500, 10), nil},
+		{at(101000, 1500, 0), []EventKind{EventFuelEmpty, EventAtmosphereExited}},
+		{at(150000, 200, 0), nil},
+		{at(151000, -5, 0), []EventKind{EventApogee}},
+		{at(151000, -5, 0), nil},
+		{at(99000, -1500, 0), []EventKind{EventAtmosphereEntered}},
+		// Напор снова растет и падает, но максимум уже был
+		{at(10000, -300, 0), nil},
+		{at(1000, -250, 0), nil},
+		{crashed, []EventKind{EventCrashed}},
+		{crashed, nil},
+	}
+
+	var e events
+	fired := eventRecorder(e.on)
+	for i, step := range steps {
+		*fired = nil
+		e.observe(step.state, planet).dispatch()
+		if !reflect.DeepEqual(*fired, step.want) {
+			t.Errorf("шаг %d (высота %.0f м): события %v, ожидались %v", i, step.state.Altitude, *fired, step.want)
+		}
+	}
+
+	e.clear()
+	if e.watching() {
+		t.Error("после clear подписки остались")
+	}
+}
+
+func TestOnEventHop(t *testing.T) {
+	for _, backend := range availableBackends() {
+		t.Run(string(backend), func(t *testing.T) {
+			config := testConfig(1)
+			p, err := NewEngine(backend, &config, EarthDefault().Position(45, 63, 0.5))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer p.Close()
+
+			fired := eventRecorder(p.OnEvent)
+			var apogee protocol.RocketState
+			p.OnEvent(EventApogee, func(state protocol.RocketState) {
+				// Мьютекс уже отпущен: физику можно спрашивать из обработчика
+				current, err := p.GetState()
+				if err != nil || current.Time != state.Time {
+					t.Errorf("GetState из обработчика: T+%.2f, %v; событие на T+%.2f", current.Time, err, state.Time)
+				}
+				apogee = state
+			})
+
+			burn := &protocol.ControlCommand{EngineThrottle: []float64{1}}
+			idle := &protocol.ControlCommand{EngineThrottle: []float64{0}}
+			for i := 0; i < 100000; i++ {
+				command := idle
+				if i < 1000 {
+					command = burn
+				}
+				if err := p.Update(command, 0.01); err != nil {
+					t.Fatal(err)
+				}
+				if state, _ := p.GetState(); state.Crashed {
+					break
+				}
+			}
+
+			if want := []EventKind{EventMaxQ, EventApogee, EventCrashed}; !reflect.DeepEqual(*fired, want) {
+				t.Errorf("события %v, ожидались %v", *fired, want)
+			}
+			if apogee.Altitude < 100 || verticalSpeedOf(apogee) > 0 {
+				t.Errorf("апогей на высоте %.0f м, вертикальная скорость %.2f м/с", apogee.Altitude, verticalSpeedOf(apogee))
+			}
+
+			p.Close()
+			p.OnEvent(EventLanded, func(protocol.RocketState) { t.Error("обработчик после Close") })
+		})
+	}
+}
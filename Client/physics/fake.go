@@ -0,0 +1,257 @@
+package physics
+
+import (
+	"fmt"
+	"sync"
+
+	"cosmodrom/protocol"
+)
+
+// FakeFrame - кадр сценария FakePhysics: с момента State.Time и до
+// следующего кадра физика отдает это состояние
+type FakeFrame struct {
+	State protocol.RocketState
+	// Orbit - прогноз орбиты кадра; nil - кеплеровский прогноз по State,
+	// как у настоящих физик
+	Orbit *OrbitPrediction
+}
+
+// FakePhysics - физика для тестов программ полета: ничего не интегрирует, а
+// проигрывает таблицу кадров по времени полета. Update и RunSteps только
+// продвигают время и запоминают команды, GetState возвращает кадр, время
+// которого наступило, со временем полета вместо времени кадра. Тангаж
+// разворота, атмосфера и прогноз орбиты считаются по кадру, как у
+// настоящих физик; смена ступени, планы маневров, снимки и прогнозы
+// траектории не поддерживаются и возвращают ошибку вида
+// ErrorKindUnavailable.
+type FakePhysics struct {
+	mu       sync.Mutex
+	closed   bool
+	frames   []FakeFrame // По возрастанию State.Time
+	time     float64
+	planet   PlanetConfig
+	gtConfig GravityTurnConfig
+	commands []protocol.ControlCommand
+}
+
+var _ PhysicsEngine = (*FakePhysics)(nil)
+
+// NewFakePhysics создает физику по кадрам frames, упорядоченным по времени.
+// Время полета начинается с первого кадра.
+func NewFakePhysics(frames []FakeFrame) (*FakePhysics, error) {
+	if len(frames) == 0 {
+		return nil, &PhysicsError{Kind: ErrorKindInvalid, Message: "в сценарии нет кадров"}
+	}
+	for i := 1; i < len(frames); i++ {
+		if frames[i].State.Time < frames[i-1].State.Time {
+			return nil, &PhysicsError{Kind: ErrorKindInvalid, Message: fmt.Sprintf("кадр %d на T+%g с раньше предыдущего", i, frames[i].State.Time)}
+		}
+	}
+	return &FakePhysics{frames: frames, time: frames[0].State.Time, planet: EarthDefault()}, nil
+}
+
+// frame - кадр на текущее время полета
+func (p *FakePhysics) frame() FakeFrame {
+	i := len(p.frames) - 1
+	for i > 0 && p.frames[i].State.Time > p.time {
+		i--
+	}
+	frame := p.frames[i]
+	frame.State.Time = p.time
+	return frame
+}
+
+// Commands - команды всех шагов Update и RunSteps по порядку
+func (p *FakePhysics) Commands() []protocol.ControlCommand {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]protocol.ControlCommand(nil), p.commands...)
+}
+
+func (p *FakePhysics) Update(command *protocol.ControlCommand, deltaTime float64) error {
+	_, _, err := p.RunSteps(command, deltaTime, 1)
+	return err
+}
+
+func (p *FakePhysics) RunSteps(command *protocol.ControlCommand, deltaTime float64, n int) (protocol.RocketState, int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return protocol.RocketState{}, 0, ErrFreed
+	}
+	if deltaTime <= 0 || n <= 0 {
+		return p.frame().State, 0, &PhysicsError{Kind: ErrorKindInvalid, Message: fmt.Sprintf("шаг %g с x %d", deltaTime, n)}
+	}
+	recorded := *command
+	recorded.EngineThrottle = append([]float64(nil), command.EngineThrottle...)
+	for i := 0; i < n; i++ {
+		p.commands = append(p.commands, recorded)
+	}
+	p.time += deltaTime * float64(n)
+	return p.frame().State, n, nil
+}
+
+func (p *FakePhysics) SetStrictCommands(strict bool) {}
+
+func (p *FakePhysics) SetSlewRate(degreesPerSecond float64) {}
+
+// OnEvent ничего не подписывает: события в кадрах не записаны
+func (p *FakePhysics) OnEvent(kind EventKind, fn func(protocol.RocketState)) {}
+
+func (p *FakePhysics) GetState() (protocol.RocketState, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return protocol.RocketState{}, ErrFreed
+	}
+	return p.frame().State, nil
+}
+
+func (p *FakePhysics) SetStage(massEmpty float64, engines []protocol.Engine) error {
+	return p.unavailable("SetStage")
+}
+
+func (p *FakePhysics) JettisonFuel(mass float64) error {
+	return p.unavailable("JettisonFuel")
+}
+
+func (p *FakePhysics) SetPlanet(planet PlanetConfig) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrFreed
+	}
+	p.planet = planet
+	return nil
+}
+
+// SetInitialVelocity ничего не меняет: скорость задана в кадрах
+func (p *FakePhysics) SetInitialVelocity(velocity protocol.Vector3) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrFreed
+	}
+	return nil
+}
+
+func (p *FakePhysics) SetGravityTurn(gt GravityTurnConfig) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.gtConfig = gt
+}
+
+func (p *FakePhysics) CalculateOptimalPitch() (float64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return 0, ErrFreed
+	}
+	return p.gtConfig.Pitch(p.frame().State.Altitude), nil
+}
+
+func (p *FakePhysics) Airspeed() (float64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return 0, ErrFreed
+	}
+	return airspeed(p.planet, p.frame().State), nil
+}
+
+func (p *FakePhysics) AtmosphereDensity(altitude float64) float64 {
+	p.mu.Lock()
+	planet := p.planet
+	p.mu.Unlock()
+	return atmosphericDensity(planet, altitude)
+}
+
+func (p *FakePhysics) AtmospherePressure(altitude float64) float64 {
+	p.mu.Lock()
+	planet := p.planet
+	p.mu.Unlock()
+	return atmosphericPressure(planet, altitude)
+}
+
+func (p *FakePhysics) DynamicPressure() (float64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return 0, ErrFreed
+	}
+	return dynamicPressure(p.planet, p.frame().State), nil
+}
+
+func (p *FakePhysics) PredictOrbit() (OrbitPrediction, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return noOrbit, ErrFreed
+	}
+	frame := p.frame()
+	if frame.Orbit != nil {
+		return *frame.Orbit, nil
+	}
+	return predictOrbit(frame.State, p.planet)
+}
+
+func (p *FakePhysics) ThrustToWeight() (float64, error) {
+	return 0, p.unavailable("ThrustToWeight")
+}
+
+func (p *FakePhysics) DeltaVRemaining() (float64, error) {
+	return 0, p.unavailable("DeltaVRemaining")
+}
+
+func (p *FakePhysics) BurnTimeRemaining(throttle float64) (float64, error) {
+	return 0, p.unavailable("BurnTimeRemaining")
+}
+
+func (p *FakePhysics) PlanCircularization(targetAlt float64) (BurnPlan, error) {
+	return BurnPlan{}, p.unavailable("PlanCircularization")
+}
+
+func (p *FakePhysics) PlanHohmann(fromAlt, toAlt float64) (HohmannPlan, error) {
+	return HohmannPlan{}, p.unavailable("PlanHohmann")
+}
+
+func (p *FakePhysics) PredictImpact() (ImpactPrediction, error) {
+	return ImpactPrediction{}, p.unavailable("PredictImpact")
+}
+
+func (p *FakePhysics) GroundTrack() (GroundPoint, error) {
+	return GroundPoint{}, p.unavailable("GroundTrack")
+}
+
+func (p *FakePhysics) PredictGroundTrack(duration, step float64) ([]GroundPoint, error) {
+	return nil, p.unavailable("PredictGroundTrack")
+}
+
+func (p *FakePhysics) Propagate(duration, step float64) ([]protocol.Vector3, error) {
+	return nil, p.unavailable("Propagate")
+}
+
+func (p *FakePhysics) Snapshot() ([]byte, error) {
+	return nil, p.unavailable("Snapshot")
+}
+
+func (p *FakePhysics) Restore(data []byte) error {
+	return p.unavailable("Restore")
+}
+
+func (p *FakePhysics) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return nil
+}
+
+// unavailable - ошибка метода, которого нет в FakePhysics; после Close - ErrFreed
+func (p *FakePhysics) unavailable(method string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrFreed
+	}
+	return &PhysicsError{Kind: ErrorKindUnavailable, Message: method + " не поддерживается в FakePhysics"}
+}
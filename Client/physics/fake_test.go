@@ -0,0 +1,72 @@
+package physics
+
+import (
+	"errors"
+	"testing"
+
+	"cosmodrom/protocol"
+)
+
+func TestFakePhysics(t *testing.T) {
+	orbit := OrbitPrediction{Apoapsis: 150000, TimeToApoapsis: 60}
+	p, err := NewFakePhysics([]FakeFrame{
+		{State: protocol.RocketState{Time: 0, Altitude: 100}},
+		{State: protocol.RocketState{Time: 1, Altitude: 5000}},
+		{State: protocol.RocketState{Time: 2, Altitude: 20000}, Orbit: &orbit},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	turn := GravityTurnConfig{TurnStartAlt: 1000, TurnEndAlt: 10000, AutoPitch: true}
+	p.SetGravityTurn(turn)
+
+	command := &protocol.ControlCommand{EngineThrottle: []float64{0.5}}
+	for _, tt := range []struct {
+		time     float64 // После шага
+		altitude float64
+		pitch    float64
+	}{
+		{0.5, 100, 0},
+		{1.0, 5000, turn.Pitch(5000)},
+		{1.5, 5000, turn.Pitch(5000)},
+		{2.0, 20000, 90},
+	} {
+		if err := p.Update(command, 0.5); err != nil {
+			t.Fatal(err)
+		}
+		state, err := p.GetState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.Time != tt.time || state.Altitude != tt.altitude {
+			t.Errorf("T+%g с: состояние на T+%g с, высота %g м, ожидалась %g м", tt.time, state.Time, state.Altitude, tt.altitude)
+		}
+		if pitch, _ := p.CalculateOptimalPitch(); pitch != tt.pitch {
+			t.Errorf("T+%g с: тангаж %g, ожидался %g", tt.time, pitch, tt.pitch)
+		}
+	}
+	if got, _ := p.PredictOrbit(); got != orbit {
+		t.Errorf("прогноз орбиты %+v, в кадре %+v", got, orbit)
+	}
+
+	command.EngineThrottle[0] = 1
+	commands := p.Commands()
+	if len(commands) != 4 || commands[0].EngineThrottle[0] != 0.5 {
+		t.Errorf("записанные команды %+v: ожидалось 4 команды с дросселем 0.5", commands)
+	}
+
+	if _, err := p.PlanHohmann(200000, 400000); !errors.Is(err, &PhysicsError{Kind: ErrorKindUnavailable}) {
+		t.Errorf("PlanHohmann: %v, ожидалась ошибка вида unavailable", err)
+	}
+	p.Close()
+	if _, err := p.GetState(); !errors.Is(err, ErrFreed) {
+		t.Errorf("GetState после Close: %v", err)
+	}
+	if _, err := p.PlanHohmann(200000, 400000); !errors.Is(err, ErrFreed) {
+		t.Errorf("PlanHohmann после Close: %v", err)
+	}
+
+	if _, err := NewFakePhysics([]FakeFrame{{State: protocol.RocketState{Time: 2}}, {State: protocol.RocketState{Time: 1}}}); err == nil {
+		t.Error("кадры не по порядку приняты")
+	}
+}
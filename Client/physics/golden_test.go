@@ -0,0 +1,192 @@
+package physics
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cosmodrom/protocol"
+)
+
+var updateGolden = flag.Bool("update-golden", false, "Перезаписать эталонные траектории testdata/golden_*.json")
+
+// Допуски сравнения с эталоном: траектория разойдется сильнее только при
+// изменении модели, а не из-за порядка операций с плавающей точкой
+const (
+	goldenPosition = 5.0  // м
+	goldenVelocity = 0.05 // м/с
+	goldenMass     = 1.0  // кг, топливо и масса
+)
+
+const (
+	goldenDt       = 0.01
+	goldenDuration = 200 // с
+)
+
+// goldenSegment - команда, которая держится до времени until
+type goldenSegment struct {
+	until   float64
+	command protocol.ControlCommand
+}
+
+// goldenSchedule - вертикальный подъем (тангаж 0), наклон к востоку, полет на
+// неполной тяге, баллистический участок без тяги и довыведение
+var goldenSchedule = []goldenSegment{
+	{10, protocol.ControlCommand{EngineThrottle: []float64{1}}},
+	{60, protocol.ControlCommand{EngineThrottle: []float64{1}, Pitch: 20, Yaw: 90}},
+	{120, protocol.ControlCommand{EngineThrottle: []float64{0.8}, Pitch: 45, Yaw: 90}},
+	{150, protocol.ControlCommand{EngineThrottle: []float64{0}, Pitch: 60, Yaw: 90}},
+	{goldenDuration, protocol.ControlCommand{EngineThrottle: []float64{1}, Pitch: 70, Yaw: 90}},
+}
+
+// goldenState - состояние эталона раз в секунду полета
+type goldenState struct {
+	Time     float64          `json:"time"`
+	Position protocol.Vector3 `json:"position"`
+	Velocity protocol.Vector3 `json:"velocity"`
+	Altitude float64          `json:"altitude"`
+	Fuel     float64          `json:"fuel"`
+	Mass     float64          `json:"mass"`
+}
+
+// goldenRun проводит ракету по расписанию: конфигурация клиента по
+// умолчанию, старт с Байконура с вращением Земли, шаг goldenDt
+func goldenRun(t *testing.T, backend Backend) []goldenState {
+	t.Helper()
+	planet := EarthDefault()
+	config := testConfig(1)
+	start := planet.Position(45, 63, 100)
+	p, err := NewEngine(backend, &config, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	p.SetPlanet(planet)
+	p.SetInitialVelocity(planet.SurfaceVelocity(start))
+
+	stepsPerSecond := int(math.Round(1 / goldenDt))
+	states := make([]goldenState, 0, goldenDuration)
+	segment := 0
+	for second := 1; second <= goldenDuration; second++ {
+		for float64(second) > goldenSchedule[segment].until {
+			segment++
+		}
+		for i := 0; i < stepsPerSecond; i++ {
+			if err := p.Update(&goldenSchedule[segment].command, goldenDt); err != nil {
+				t.Fatalf("T+%d с: %v", second, err)
+			}
+		}
+		state, err := p.GetState()
+		if err != nil {
+			t.Fatalf("T+%d с: %v", second, err)
+		}
+		if state.Crashed || state.Landed {
+			t.Fatalf("T+%d с: полет по расписанию эталона закончился на земле", second)
+		}
+		states = append(states, goldenState{
+			Time:     state.Time,
+			Position: state.Position,
+			Velocity: state.Velocity,
+			Altitude: state.Altitude,
+			Fuel:     state.FuelRemaining,
+			Mass:     state.MassCurrent,
+		})
+	}
+	return states
+}
+
+func goldenPath(backend Backend) string {
+	return filepath.Join("testdata", "golden_"+string(backend)+".json")
+}
+
+// writeGolden пишет эталон по состоянию на строку, чтобы изменения модели
+// были видны в diff
+func writeGolden(path string, states []goldenState) error {
+	var buf bytes.Buffer
+	buf.WriteString("[\n")
+	for i, state := range states {
+		line, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		if i < len(states)-1 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('\n')
+	}
+	buf.WriteString("]\n")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+// compareGolden возвращает первое расхождение с эталоном больше допуска
+func compareGolden(got, want []goldenState) error {
+	if len(got) != len(want) {
+		return fmt.Errorf("%d состояний, в эталоне %d", len(got), len(want))
+	}
+	distance := func(a, b protocol.Vector3) float64 {
+		return math.Sqrt((a.X-b.X)*(a.X-b.X) + (a.Y-b.Y)*(a.Y-b.Y) + (a.Z-b.Z)*(a.Z-b.Z))
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		switch {
+		case math.Abs(g.Time-w.Time) > goldenDt/2:
+			return fmt.Errorf("состояние %d: время %.3f с, в эталоне %.3f с", i, g.Time, w.Time)
+		case distance(g.Position, w.Position) > goldenPosition:
+			return fmt.Errorf("T+%.0f с: позиция в %.2f м от эталона (допуск %g м)", w.Time, distance(g.Position, w.Position), goldenPosition)
+		case distance(g.Velocity, w.Velocity) > goldenVelocity:
+			return fmt.Errorf("T+%.0f с: скорость отличается от эталона на %.3f м/с (допуск %g м/с)", w.Time, distance(g.Velocity, w.Velocity), goldenVelocity)
+		case math.Abs(g.Altitude-w.Altitude) > goldenPosition:
+			return fmt.Errorf("T+%.0f с: высота %.2f м, в эталоне %.2f м", w.Time, g.Altitude, w.Altitude)
+		case math.Abs(g.Fuel-w.Fuel) > goldenMass:
+			return fmt.Errorf("T+%.0f с: топливо %.2f кг, в эталоне %.2f кг", w.Time, g.Fuel, w.Fuel)
+		case math.Abs(g.Mass-w.Mass) > goldenMass:
+			return fmt.Errorf("T+%.0f с: масса %.2f кг, в эталоне %.2f кг", w.Time, g.Mass, w.Mass)
+		}
+	}
+	return nil
+}
+
+// Полет по фиксированному расписанию команд совпадает с эталоном каждой
+// физики из этой сборки: без cgo проверяется только физика на Go. После
+// намеренного изменения модели эталоны обновляются:
+//
+//	go test ./physics -run TestGoldenTrajectory -update-golden
+func TestGoldenTrajectory(t *testing.T) {
+	backends := []Backend{BackendGo}
+	if cgoAvailable {
+		backends = append(backends, BackendC)
+	}
+	for _, backend := range backends {
+		t.Run(string(backend), func(t *testing.T) {
+			got := goldenRun(t, backend)
+			path := goldenPath(backend)
+			if *updateGolden {
+				if err := writeGolden(path, got); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v: эталон создается флагом -update-golden", err)
+			}
+			var want []goldenState
+			if err := json.Unmarshal(data, &want); err != nil {
+				t.Fatalf("%s: %v", path, err)
+			}
+			if err := compareGolden(got, want); err != nil {
+				t.Errorf("физика %s разошлась с эталоном %s: %v", backend, path, err)
+			}
+		})
+	}
+}
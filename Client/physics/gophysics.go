@@ -0,0 +1,494 @@
+package physics
+
+import (
+	"math"
+	"sync"
+
+	"cosmodrom/protocol"
+)
+
+// GoPhysics - модель движка на C, переписанная на Go: точечная гравитация,
+// экспоненциальная атмосфера, тяга по тангажу и рысканию команды, расход
+// топлива и явный метод Эйлера с тем же порядком шагов. Не требует cgo и
+// librocket_physics; результаты совпадают с движком на C с точностью до
+// округления.
+type GoPhysics struct {
+	mu          sync.Mutex
+	closed      bool
+	state       protocol.RocketState
+	massEmpty   float64
+	drag        float64 // Коэффициент сопротивления x площадь сечения, м2
+	planet      PlanetConfig
+	gtConfig    GravityTurnConfig
+	rocket      protocol.RocketConfig // Название, топливо и сопротивление для Snapshot
+	strict      bool                  // SetStrictCommands
+	events      events                // Подписки OnEvent
+	parachute   parachute
+	orientation orientation // Ориентация с ограничением скорости поворота
+	propulsion
+}
+
+var _ PhysicsEngine = (*GoPhysics)(nil)
+
+func NewGoPhysics(config *protocol.RocketConfig, initialPos protocol.Vector3) (*GoPhysics, error) {
+	p := &GoPhysics{
+		massEmpty: config.MassEmpty,
+		drag:      config.DragCoefficient * config.CrossSection,
+		planet:    EarthDefault(),
+		rocket:    snapshotConfig(config),
+	}
+	p.fuelType = config.FuelType
+	p.setEngines(config.Engines)
+	p.parachute.setConfig(config.Parachute)
+	p.state.Position = initialPos
+	p.state.MassCurrent = config.MassEmpty + config.MassFuel
+	p.state.FuelRemaining = config.MassFuel
+	p.state.Altitude = vectorLength(initialPos) - p.planet.Radius
+	return p, nil
+}
+
+func (p *GoPhysics) Update(command *protocol.ControlCommand, deltaTime float64) error {
+	fired, err := p.step(command, deltaTime)
+	fired.dispatch()
+	return err
+}
+
+func (p *GoPhysics) step(command *protocol.ControlCommand, deltaTime float64) (firedEvents, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return firedEvents{}, ErrFreed
+	}
+	if err := checkCommand(command, len(p.engines), p.strict); err != nil {
+		return firedEvents{}, err
+	}
+	p.update(command, deltaTime)
+	if !p.events.watching() {
+		return firedEvents{}, nil
+	}
+	return p.events.observe(p.current(), p.planet), nil
+}
+
+// RunSteps - как RocketPhysics.RunSteps: до n шагов Update с одной командой
+func (p *GoPhysics) RunSteps(command *protocol.ControlCommand, deltaTime float64, n int) (protocol.RocketState, int, error) {
+	fired, done, err := p.runSteps(command, deltaTime, n)
+	fired.dispatch()
+	return fired.state, done, err
+}
+
+func (p *GoPhysics) runSteps(command *protocol.ControlCommand, deltaTime float64, n int) (firedEvents, int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return firedEvents{}, 0, ErrFreed
+	}
+	if err := checkCommand(command, len(p.engines), p.strict); err != nil {
+		return firedEvents{state: p.current()}, 0, err
+	}
+	done := 0
+	for done < n && !p.state.Landed && !p.state.Crashed {
+		hadFuel := p.state.FuelRemaining > 0
+		p.update(command, deltaTime)
+		done++
+		if hadFuel && p.state.FuelRemaining <= 0 {
+			break
+		}
+	}
+	return p.events.observe(p.current(), p.planet), done, checkState(p.state, p.rocket.MassFuelMax)
+}
+
+// current - состояние ракеты вместе с ориентацией
+func (p *GoPhysics) current() protocol.RocketState {
+	state := p.state
+	p.orientation.report(&state)
+	return state
+}
+
+// OnEvent - см. RocketPhysics.OnEvent
+func (p *GoPhysics) OnEvent(kind EventKind, fn func(protocol.RocketState)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.closed {
+		p.events.on(kind, fn)
+	}
+}
+
+func (p *GoPhysics) update(command *protocol.ControlCommand, deltaTime float64) {
+	s := &p.state
+	if s.Landed || s.Crashed {
+		return
+	}
+	if command.DeployParachute {
+		p.parachute.deploy(p.planet, *s)
+		p.parachute.report(s)
+	}
+
+	var force protocol.Vector3
+	distance := vectorLength(s.Position)
+	if distance > p.planet.Radius {
+		g := protocol.GConstant * p.planet.Mass / (distance * distance)
+		force = addScaled(force, s.Position, -g*s.MassCurrent/distance)
+	}
+
+	if s.Altitude < p.planet.AtmosphereHeight && s.Altitude > 0 {
+		rho := atmosphericDensity(p.planet, s.Altitude)
+		wind := p.planet.SurfaceVelocity(s.Position)
+		air := protocol.Vector3{X: s.Velocity.X - wind.X, Y: s.Velocity.Y - wind.Y, Z: s.Velocity.Z - wind.Z}
+		if v := vectorLength(air); v > 1e-6 {
+			force = addScaled(force, air, -0.5*rho*v*p.drag)
+		}
+	}
+
+	// Без топлива двигатели не работают, какой бы ни была команда
+	p.setThrottles(command.EngineThrottle)
+	p.orientation.slew(command, deltaTime)
+	thrust, consumption := p.output(p.command)
+	if s.FuelRemaining > 0 && thrust >= 1e-6 {
+		force = addScaled(force, thrustDirection(s.Position, p.orientation.pitch, p.orientation.yaw), thrust)
+	}
+
+	s.Acceleration = protocol.Vector3{}
+	if s.MassCurrent > 0 {
+		s.Acceleration = addScaled(s.Acceleration, force, 1.0/s.MassCurrent)
+	}
+	s.Velocity = addScaled(s.Velocity, s.Acceleration, deltaTime)
+	s.Speed = vectorLength(s.Velocity)
+	s.Position = addScaled(s.Position, s.Velocity, deltaTime)
+
+	s.FuelRemaining = math.Max(0, s.FuelRemaining-consumption*deltaTime)
+	s.MassCurrent = p.massEmpty + s.FuelRemaining
+
+	distance = vectorLength(s.Position)
+	s.Altitude = distance - p.planet.Radius
+	if distance <= p.planet.Radius {
+		// Скорость касания считается относительно вращающейся поверхности
+		ground := p.planet.SurfaceVelocity(s.Position)
+		relative := protocol.Vector3{X: s.Velocity.X - ground.X, Y: s.Velocity.Y - ground.Y, Z: s.Velocity.Z - ground.Z}
+		if vectorLength(relative) < 5.0 {
+			s.Landed = true
+		} else {
+			s.Crashed = true
+		}
+		s.Velocity = ground
+		s.Speed = vectorLength(ground)
+		s.Acceleration = protocol.Vector3{}
+		return
+	}
+
+	p.parachute.brake(p.planet, s, deltaTime)
+
+	orbit, _ := predictOrbit(*s, p.planet)
+	s.InOrbit = orbit.IsStable
+	s.Time += deltaTime
+}
+
+// thrustDirection - как calculate_thrust: тангаж от местной вертикали,
+// рыскание от востока к югу
+func thrustDirection(position protocol.Vector3, pitch, yaw float64) protocol.Vector3 {
+	up, east, north := localBasis(position)
+
+	yawRad := yaw * math.Pi / 180.0
+	horizontal := addScaled(scaled(east, math.Cos(yawRad)), north, -math.Sin(yawRad))
+	pitchRad := pitch * math.Pi / 180.0
+	return addScaled(scaled(up, math.Cos(pitchRad)), horizontal, math.Sin(pitchRad))
+}
+
+// localBasis - местный базис calculate_thrust: зенит, восток и север. Над
+// полюсом восток берется от оси x.
+func localBasis(position protocol.Vector3) (up, east, north protocol.Vector3) {
+	up, _ = unit(position)
+	east = cross(protocol.Vector3{Z: 1}, up)
+	if vectorLength(east) < 0.01 {
+		east = cross(protocol.Vector3{X: 1}, up)
+	}
+	east, _ = unit(east)
+	return up, east, cross(up, east)
+}
+
+func (p *GoPhysics) SetStrictCommands(strict bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.strict = strict
+}
+
+func (p *GoPhysics) SetStage(massEmpty float64, engines []protocol.Engine) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrFreed
+	}
+	p.massEmpty = massEmpty
+	p.setEngines(engines)
+	p.state.MassCurrent = massEmpty + p.state.FuelRemaining
+	return nil
+}
+
+// JettisonFuel - см. RocketPhysics.JettisonFuel
+func (p *GoPhysics) JettisonFuel(mass float64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrFreed
+	}
+	p.state.FuelRemaining = math.Max(p.state.FuelRemaining-math.Max(mass, 0), 0)
+	p.state.MassCurrent = p.massEmpty + p.state.FuelRemaining
+	return nil
+}
+
+func (p *GoPhysics) GetState() (protocol.RocketState, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return protocol.RocketState{}, ErrFreed
+	}
+	return p.current(), checkState(p.state, p.rocket.MassFuelMax)
+}
+
+// SetSlewRate - см. RocketPhysics.SetSlewRate
+func (p *GoPhysics) SetSlewRate(degreesPerSecond float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.orientation.setRate(degreesPerSecond)
+}
+
+func (p *GoPhysics) SetPlanet(planet PlanetConfig) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrFreed
+	}
+	p.planet = planet
+	p.state.Altitude = vectorLength(p.state.Position) - planet.Radius
+	return nil
+}
+
+func (p *GoPhysics) SetInitialVelocity(velocity protocol.Vector3) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrFreed
+	}
+	p.state.Velocity = velocity
+	p.state.Speed = vectorLength(velocity)
+	return nil
+}
+
+func (p *GoPhysics) SetGravityTurn(gt GravityTurnConfig) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.gtConfig = gt
+}
+
+func (p *GoPhysics) CalculateOptimalPitch() (float64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return 0, ErrFreed
+	}
+	return p.gtConfig.Pitch(p.state.Altitude), nil
+}
+
+func (p *GoPhysics) Airspeed() (float64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return 0, ErrFreed
+	}
+	return airspeed(p.planet, p.state), nil
+}
+
+func (p *GoPhysics) AtmosphereDensity(altitude float64) float64 {
+	p.mu.Lock()
+	planet := p.planet
+	p.mu.Unlock()
+	return atmosphericDensity(planet, altitude)
+}
+
+func (p *GoPhysics) AtmospherePressure(altitude float64) float64 {
+	p.mu.Lock()
+	planet := p.planet
+	p.mu.Unlock()
+	return atmosphericPressure(planet, altitude)
+}
+
+func (p *GoPhysics) DynamicPressure() (float64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return 0, ErrFreed
+	}
+	return dynamicPressure(p.planet, p.state), nil
+}
+
+func (p *GoPhysics) PredictOrbit() (OrbitPrediction, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return noOrbit, ErrFreed
+	}
+	return predictOrbit(p.state, p.planet)
+}
+
+func (p *GoPhysics) ThrustToWeight() (float64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return 0, ErrFreed
+	}
+	return p.thrustToWeight(p.state, p.planet), nil
+}
+
+func (p *GoPhysics) DeltaVRemaining() (float64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return 0, ErrFreed
+	}
+	return p.deltaVRemaining(p.state, p.massEmpty), nil
+}
+
+func (p *GoPhysics) PlanCircularization(targetAlt float64) (BurnPlan, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return BurnPlan{}, ErrFreed
+	}
+	return p.planCircularization(p.state, p.planet, p.massEmpty, targetAlt)
+}
+
+func (p *GoPhysics) PlanHohmann(fromAlt, toAlt float64) (HohmannPlan, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return HohmannPlan{}, ErrFreed
+	}
+	return planHohmann(p.planet, fromAlt, toAlt)
+}
+
+func (p *GoPhysics) PredictImpact() (ImpactPrediction, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ImpactPrediction{}, ErrFreed
+	}
+	return predictImpact(p.planet, p.state, p.drag)
+}
+
+func (p *GoPhysics) BurnTimeRemaining(throttle float64) (float64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return 0, ErrFreed
+	}
+	return p.burnTimeRemaining(p.state.FuelRemaining, throttle), nil
+}
+
+func (p *GoPhysics) GroundTrack() (GroundPoint, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return GroundPoint{}, ErrFreed
+	}
+	return p.planet.GroundPoint(p.state.Position, p.state.Time), nil
+}
+
+func (p *GoPhysics) PredictGroundTrack(duration, step float64) ([]GroundPoint, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, ErrFreed
+	}
+	return predictGroundTrack(p.planet, p.state, duration, step)
+}
+
+func (p *GoPhysics) Propagate(duration, step float64) ([]protocol.Vector3, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, ErrFreed
+	}
+	return propagate(p.planet, p.state, duration, step)
+}
+
+// Snapshot - то же, что RocketPhysics.Snapshot; снимки двух физик
+// взаимозаменяемы
+func (p *GoPhysics) Snapshot() ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, ErrFreed
+	}
+	return encodeSnapshot(Snapshot{
+		State:       p.current(),
+		Planet:      p.planet,
+		GravityTurn: p.gtConfig,
+		Config:      p.stageConfig(),
+		Throttles:   p.command,
+	})
+}
+
+func (p *GoPhysics) Restore(data []byte) error {
+	s, err := DecodeSnapshot(data)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrFreed
+	}
+	p.rocket = snapshotConfig(&s.Config)
+	p.massEmpty = s.Config.MassEmpty
+	p.drag = s.Config.DragCoefficient * s.Config.CrossSection
+	p.planet = s.Planet
+	p.gtConfig = s.GravityTurn
+	p.state = s.State
+	p.events.reset()
+	p.fuelType = s.Config.FuelType
+	p.parachute.setConfig(s.Config.Parachute)
+	p.parachute.restore(s.State)
+	p.orientation.restore(s.State)
+	p.setEngines(s.Config.Engines)
+	p.setThrottles(s.Throttles)
+	return nil
+}
+
+// stageConfig - конфигурация текущей ступени для Snapshot
+func (p *GoPhysics) stageConfig() protocol.RocketConfig {
+	config := p.rocket
+	config.MassEmpty = p.massEmpty
+	config.MassFuel = p.state.FuelRemaining
+	config.Engines = append([]protocol.Engine(nil), p.engines...)
+	return config
+}
+
+// NewGoPhysicsFromSnapshot создает физику сразу в состоянии из Snapshot
+func NewGoPhysicsFromSnapshot(data []byte) (*GoPhysics, error) {
+	p := &GoPhysics{}
+	if err := p.Restore(data); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Close помечает физику закрытой; памяти вне Go у нее нет
+func (p *GoPhysics) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	p.events.clear()
+	return nil
+}
+
+func vectorLength(v protocol.Vector3) float64 {
+	return math.Sqrt(dot(v, v))
+}
+
+func scaled(v protocol.Vector3, k float64) protocol.Vector3 {
+	return protocol.Vector3{X: v.X * k, Y: v.Y * k, Z: v.Z * k}
+}
+
+// addScaled - a + b*k
+func addScaled(a, b protocol.Vector3, k float64) protocol.Vector3 {
+	return protocol.Vector3{X: a.X + b.X*k, Y: a.Y + b.Y*k, Z: a.Z + b.Z*k}
+}
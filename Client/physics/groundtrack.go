@@ -0,0 +1,108 @@
+package physics
+
+import (
+	"math"
+
+	"cosmodrom/protocol"
+)
+
+// GroundPoint - точка трассы полета: широта и долгота под ракетой на
+// вращающейся планете
+type GroundPoint struct {
+	Time      float64 // Время симуляции (с)
+	Latitude  float64 // Градусы, -90..90
+	Longitude float64 // Градусы, -180..180, нулевой меридиан - ось x в момент T+0
+	Altitude  float64 // Над поверхностью планеты (м)
+}
+
+// maxPropagationStep - наибольший шаг интегрирования прогноза (с); шаг
+// трассы делится на несколько таких
+const maxPropagationStep = 10.0
+
+// Spherical - широта, долгота (градусы) и высота над поверхностью планеты
+// для декартовых координат, обратное к Position. CartesianToSpherical
+// считает от радиуса Земли.
+func (p PlanetConfig) Spherical(position protocol.Vector3) (latitude, longitude, altitude float64) {
+	r := math.Sqrt(dot(position, position))
+	if r == 0 {
+		return 0, 0, -p.Radius
+	}
+	latitude = math.Asin(math.Max(-1, math.Min(1, position.Z/r))) * 180.0 / math.Pi
+	longitude = math.Atan2(position.Y, position.X) * 180.0 / math.Pi
+	return latitude, longitude, r - p.Radius
+}
+
+// GroundPoint - точка под ракетой в позиции position в момент time. Планета
+// поворачивается от T+0, поэтому долгота сдвигается на запад на угол поворота.
+func (p PlanetConfig) GroundPoint(position protocol.Vector3, time float64) GroundPoint {
+	latitude, longitude, altitude := p.Spherical(position)
+	longitude -= p.RotationRate() * time * 180.0 / math.Pi
+	return GroundPoint{Time: time, Latitude: latitude, Longitude: normalizeLongitude(longitude), Altitude: altitude}
+}
+
+// GroundSpeed - горизонтальная скорость относительно вращающейся
+// поверхности (м/с): без вертикальной составляющей и без скорости самой
+// поверхности под ракетой
+func (p PlanetConfig) GroundSpeed(position, velocity protocol.Vector3) float64 {
+	relative := velocity.Sub(p.SurfaceVelocity(position))
+	up := position.Normalize()
+	return relative.Sub(up.Scale(relative.Dot(up))).Norm()
+}
+
+// normalizeLongitude приводит долготу к -180..180
+func normalizeLongitude(longitude float64) float64 {
+	longitude = math.Mod(longitude+180, 360)
+	if longitude < 0 {
+		longitude += 360
+	}
+	return longitude - 180
+}
+
+// predictGroundTrack - трасса на duration секунд вперед с шагом step по
+// задаче двух тел: без тяги и сопротивления. Трасса обрывается, если ракета
+// достигает поверхности.
+func predictGroundTrack(planet PlanetConfig, state protocol.RocketState, duration, step float64) ([]GroundPoint, error) {
+	positions, err := propagate(planet, state, duration, step)
+	if err != nil {
+		return nil, err
+	}
+	track := make([]GroundPoint, len(positions))
+	for n, position := range positions {
+		track[n] = planet.GroundPoint(position, state.Time+float64(n)*step)
+	}
+	return track, nil
+}
+
+// twoBodyStep - шаг Рунге-Кутты 4 порядка в поле точечной массы mu
+func twoBodyStep(position, velocity protocol.Vector3, mu, dt float64) (protocol.Vector3, protocol.Vector3) {
+	return rk4Step(position, velocity, func(r, _ protocol.Vector3) protocol.Vector3 {
+		return gravityAcceleration(r, mu)
+	}, dt)
+}
+
+// gravityAcceleration - ускорение свободного падения в точке r
+func gravityAcceleration(r protocol.Vector3, mu float64) protocol.Vector3 {
+	d := math.Sqrt(dot(r, r))
+	return scaled(r, -mu/(d*d*d))
+}
+
+// rk4Step - шаг Рунге-Кутты 4 порядка для ускорения acceleration(позиция, скорость)
+func rk4Step(position, velocity protocol.Vector3, acceleration func(r, v protocol.Vector3) protocol.Vector3, dt float64) (protocol.Vector3, protocol.Vector3) {
+	k1r, k1v := velocity, acceleration(position, velocity)
+	k2r := addScaled(velocity, k1v, dt/2)
+	k2v := acceleration(addScaled(position, k1r, dt/2), k2r)
+	k3r := addScaled(velocity, k2v, dt/2)
+	k3v := acceleration(addScaled(position, k2r, dt/2), k3r)
+	k4r := addScaled(velocity, k3v, dt)
+	k4v := acceleration(addScaled(position, k3r, dt), k4r)
+
+	position = addScaled(position, k1r, dt/6)
+	position = addScaled(position, k2r, dt/3)
+	position = addScaled(position, k3r, dt/3)
+	position = addScaled(position, k4r, dt/6)
+	velocity = addScaled(velocity, k1v, dt/6)
+	velocity = addScaled(velocity, k2v, dt/3)
+	velocity = addScaled(velocity, k3v, dt/3)
+	velocity = addScaled(velocity, k4v, dt/6)
+	return position, velocity
+}
@@ -0,0 +1,210 @@
+package physics
+
+import (
+	"math"
+	"testing"
+
+	"cosmodrom/protocol"
+)
+
+// circularOrbit - круговая орбита высотой altitude с наклонением inclination,
+// восходящий узел на оси x
+func circularOrbit(planet PlanetConfig, altitude, inclination float64) protocol.RocketState {
+	r := planet.Radius + altitude
+	v := math.Sqrt(protocol.GConstant * planet.Mass / r)
+	i := inclination * math.Pi / 180.0
+	velocity := protocol.Vector3{Y: v * math.Cos(i), Z: v * math.Sin(i)}
+	return protocol.RocketState{
+		Position: protocol.Vector3{X: r},
+		Velocity: velocity,
+		Speed:    v,
+		Altitude: altitude,
+	}
+}
+
+func TestPredictGroundTrack(t *testing.T) {
+	earth := EarthDefault()
+	period := func(altitude float64) float64 {
+		r := earth.Radius + altitude
+		return 2 * math.Pi * math.Sqrt(r*r*r/(protocol.GConstant*earth.Mass))
+	}
+
+	t.Run("полярная орбита проходит все широты", func(t *testing.T) {
+		track, err := predictGroundTrack(earth, circularOrbit(earth, 400000, 90), period(400000), 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		minLat, maxLat := 90.0, -90.0
+		for _, point := range track {
+			minLat = math.Min(minLat, point.Latitude)
+			maxLat = math.Max(maxLat, point.Latitude)
+			if math.Abs(point.Altitude-400000) > 100 {
+				t.Fatalf("T+%.0f с: высота %.0f м на круговой орбите 400 км", point.Time, point.Altitude)
+			}
+		}
+		if maxLat < 89 || minLat > -89 {
+			t.Errorf("широты от %.1f до %.1f, ожидалось от -90 до 90", minLat, maxLat)
+		}
+	})
+
+	t.Run("экваториальная орбита остается на экваторе", func(t *testing.T) {
+		track, err := predictGroundTrack(earth, circularOrbit(earth, 400000, 0), 2*period(400000), 60)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, point := range track {
+			if math.Abs(point.Latitude) > 1e-9 {
+				t.Fatalf("T+%.0f с: широта %v", point.Time, point.Latitude)
+			}
+		}
+		// Ракета обходит планету за период, а планета поворачивается на восток:
+		// трасса отстает на угол поворота
+		last := track[len(track)-1]
+		want := normalizeLongitude(360*last.Time/period(400000) - 360*last.Time/earth.RotationPeriod)
+		if math.Abs(last.Longitude-want) > 0.1 {
+			t.Errorf("долгота через два витка %.2f°, ожидалось %.2f°", last.Longitude, want)
+		}
+	})
+
+	t.Run("геостационарная орбита висит над точкой", func(t *testing.T) {
+		mu := protocol.GConstant * earth.Mass
+		omega := earth.RotationRate()
+		altitude := math.Cbrt(mu/(omega*omega)) - earth.Radius
+		track, err := predictGroundTrack(earth, circularOrbit(earth, altitude, 0), earth.RotationPeriod, 600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, point := range track {
+			if math.Abs(point.Longitude) > 0.01 {
+				t.Fatalf("T+%.0f с: долгота %.4f°, ожидалось 0", point.Time, point.Longitude)
+			}
+		}
+	})
+
+	t.Run("суборбитальная трасса обрывается у поверхности", func(t *testing.T) {
+		state := protocol.RocketState{Position: earth.Position(0, 0, 100000), Velocity: protocol.Vector3{Y: 1000}}
+		track, err := predictGroundTrack(earth, state, 3600, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if last := track[len(track)-1]; len(track) >= 361 || last.Altitude <= 0 {
+			t.Errorf("%d точек, последняя на высоте %.0f м", len(track), last.Altitude)
+		}
+	})
+}
+
+func TestPredictGroundTrackErrors(t *testing.T) {
+	earth := EarthDefault()
+	state := circularOrbit(earth, 400000, 51.6)
+	tests := []struct {
+		name           string
+		duration, step float64
+	}{
+		{name: "нулевой шаг", duration: 600, step: 0},
+		{name: "отрицательная длительность", duration: -1, step: 10},
+		{name: "слишком много точек", duration: 1e6, step: 1},
+		{name: "NaN", duration: math.NaN(), step: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := predictGroundTrack(earth, state, tt.duration, tt.step); err == nil {
+				t.Error("ошибки нет")
+			}
+		})
+	}
+}
+
+func TestGroundTrackAtLaunchSite(t *testing.T) {
+	config := testConfig(1)
+	for _, backend := range availableBackends() {
+		t.Run(string(backend), func(t *testing.T) {
+			p, err := NewEngine(backend, &config, EarthDefault().Position(45, 63, 100))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer p.Close()
+			point, err := p.GroundTrack()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(point.Latitude-45) > 1e-9 || math.Abs(point.Longitude-63) > 1e-9 || math.Abs(point.Altitude-100) > 1e-6 {
+				t.Errorf("точка старта %.6f, %.6f, %.3f м; ожидалось 45, 63, 100 м", point.Latitude, point.Longitude, point.Altitude)
+			}
+		})
+	}
+}
+
+// Широта и долгота обратны Position и на полюсах, и у антимеридиана, где
+// долгота переходит от 180 к -180
+func TestGroundPointRoundTrip(t *testing.T) {
+	planet := EarthDefault()
+	tests := []struct {
+		latitude, longitude float64
+	}{
+		{0, 0},
+		{45, 63},
+		{-33.5, -70.6},
+		{0, 180},
+		{0, -179.999},
+		{12, 179.999},
+		{89.999, 135},
+		{-89.999, -45},
+		{90, 0},
+		{-90, 0},
+	}
+
+	for _, tt := range tests {
+		point := planet.GroundPoint(planet.Position(tt.latitude, tt.longitude, 1000), 0)
+		if math.Abs(point.Latitude-tt.latitude) > 1e-9 || math.Abs(point.Altitude-1000) > 1e-6 {
+			t.Errorf("%g, %g: широта %.12f, высота %.6f", tt.latitude, tt.longitude, point.Latitude, point.Altitude)
+		}
+		if point.Longitude < -180 || point.Longitude > 180 {
+			t.Errorf("%g, %g: долгота %g вне -180..180", tt.latitude, tt.longitude, point.Longitude)
+		}
+		// На самом полюсе долгота не определена
+		if math.Abs(tt.latitude) == 90 {
+			continue
+		}
+		if diff := math.Abs(normalizeLongitude(point.Longitude - tt.longitude)); diff > 1e-9 {
+			t.Errorf("%g, %g: долгота %.12f", tt.latitude, tt.longitude, point.Longitude)
+		}
+	}
+
+	// Планета поворачивается под ракетой: долгота переходит антимеридиан с
+	// запада на восток и остается в -180..180
+	position := planet.Position(10, -179.5, 0)
+	point := planet.GroundPoint(position, 1/planet.RotationRate()*math.Pi/180)
+	if math.Abs(point.Longitude-179.5) > 1e-9 {
+		t.Errorf("через поворот на 1°: долгота %.12f, ожидалось 179.5", point.Longitude)
+	}
+}
+
+func TestGroundSpeed(t *testing.T) {
+	planet := EarthDefault()
+	position := planet.Position(30, 60, 5000)
+	up := position.Normalize()
+	surface := planet.SurfaceVelocity(position)
+	east := protocol.Vector3{X: -math.Sin(60 * math.Pi / 180), Y: math.Cos(60 * math.Pi / 180)}
+
+	tests := []struct {
+		name     string
+		velocity protocol.Vector3
+		want     float64
+	}{
+		{"стоит на месте", surface, 0},
+		{"вертикальный подъем", surface.Add(up.Scale(300)), 0},
+		{"на восток", surface.Add(east.Scale(250)).Add(up.Scale(-40)), 250},
+		{"без вращения планеты", protocol.Vector3{}, surface.Norm()},
+	}
+	for _, tt := range tests {
+		if got := planet.GroundSpeed(position, tt.velocity); math.Abs(got-tt.want) > 1e-6 {
+			t.Errorf("%s: %g м/с, ожидалось %g", tt.name, got, tt.want)
+		}
+	}
+
+	// На полюсе поверхность не движется
+	pole := planet.Position(90, 0, 0)
+	if got := planet.GroundSpeed(pole, protocol.Vector3{X: 100, Z: 50}); math.Abs(got-100) > 1e-6 {
+		t.Errorf("на полюсе %g м/с, ожидалось 100", got)
+	}
+}
@@ -0,0 +1,108 @@
+package physics
+
+import (
+	"math"
+
+	"cosmodrom/protocol"
+)
+
+const (
+	maxImpactDuration = 24 * 3600.0 // Дальше прогноз падения не считается (с)
+	atmosphereStep    = 0.1         // Шаг прогноза в атмосфере с сопротивлением (с)
+	impactTimeStep    = 1e-3        // Точность момента касания (с)
+)
+
+// ImpactPrediction - где и когда ракета упадет, если двигатели не включатся
+type ImpactPrediction struct {
+	Impact       bool    // Траектория пересекает поверхность в пределах суток
+	TimeToImpact float64 // До касания (с)
+	ImpactSpeed  float64 // Скорость касания относительно поверхности (м/с)
+	Latitude     float64 // Точка падения с учетом вращения планеты, градусы
+	Longitude    float64
+}
+
+// predictImpact - баллистический полет из состояния state до поверхности:
+// гравитация и, при drag > 0 (коэффициент сопротивления на площадь сечения,
+// м2), сопротивление атмосферы ракеты массой state.MassCurrent. Орбита с
+// перицентром над поверхностью (с сопротивлением - над атмосферой) и уход
+// от планеты по гиперболе или параболе сразу дают "падения нет".
+func predictImpact(planet PlanetConfig, state protocol.RocketState, drag float64) (ImpactPrediction, error) {
+	orbit, err := predictOrbit(state, planet)
+	if err != nil {
+		return ImpactPrediction{}, err
+	}
+	if state.Landed || state.Crashed || state.Altitude <= 0 {
+		return ImpactPrediction{}, nil
+	}
+	floor := 0.0
+	if drag > 0 && state.MassCurrent > 0 {
+		floor = planet.AtmosphereHeight
+	} else {
+		drag = 0
+	}
+	if orbit.IsClosed && orbit.Periapsis > floor {
+		return ImpactPrediction{}, nil
+	}
+
+	mu := protocol.GConstant * planet.Mass
+	escaping := dot(state.Velocity, state.Velocity)/2 >= mu/vectorLength(state.Position)
+	if escaping && dot(state.Position, state.Velocity) >= 0 {
+		return ImpactPrediction{}, nil
+	}
+
+	acceleration := func(r, v protocol.Vector3) protocol.Vector3 {
+		a := gravityAcceleration(r, mu)
+		if drag == 0 {
+			return a
+		}
+		altitude := math.Sqrt(dot(r, r)) - planet.Radius
+		if altitude <= 0 || altitude >= planet.AtmosphereHeight {
+			return a
+		}
+		wind := planet.SurfaceVelocity(r)
+		air := protocol.Vector3{X: v.X - wind.X, Y: v.Y - wind.Y, Z: v.Z - wind.Z}
+		rho := atmosphericDensity(planet, altitude)
+		return addScaled(a, air, -0.5*rho*vectorLength(air)*drag/state.MassCurrent)
+	}
+
+	position, velocity := state.Position, state.Velocity
+	altitude := state.Altitude
+	for t := 0.0; t < maxImpactDuration; {
+		dt := maxPropagationStep
+		if drag > 0 {
+			// К атмосфере подходим шагами не длиннее пути до ее границы
+			above := altitude - planet.AtmosphereHeight
+			dt = math.Min(dt, math.Max(atmosphereStep, above/math.Max(vectorLength(velocity), 1)))
+		}
+
+		nextPosition, nextVelocity := rk4Step(position, velocity, acceleration, dt)
+		nextAltitude := vectorLength(nextPosition) - planet.Radius
+		if nextAltitude > 0 {
+			position, velocity, altitude = nextPosition, nextVelocity, nextAltitude
+			t += dt
+			continue
+		}
+		// Шаг ушел под поверхность: делим его, пока момент касания не
+		// определится с точностью impactTimeStep
+		for dt > impactTimeStep {
+			dt /= 2
+			p, v := rk4Step(position, velocity, acceleration, dt)
+			if a := vectorLength(p) - planet.Radius; a > 0 {
+				position, velocity, altitude = p, v, a
+				t += dt
+			}
+		}
+		t += dt
+		position, velocity = rk4Step(position, velocity, acceleration, dt)
+		ground := planet.SurfaceVelocity(position)
+		point := planet.GroundPoint(position, state.Time+t)
+		return ImpactPrediction{
+			Impact:       true,
+			TimeToImpact: t,
+			ImpactSpeed:  vectorLength(protocol.Vector3{X: velocity.X - ground.X, Y: velocity.Y - ground.Y, Z: velocity.Z - ground.Z}),
+			Latitude:     point.Latitude,
+			Longitude:    point.Longitude,
+		}, nil
+	}
+	return ImpactPrediction{}, nil
+}
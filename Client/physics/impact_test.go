@@ -0,0 +1,150 @@
+package physics
+
+import (
+	"math"
+	"testing"
+
+	"cosmodrom/protocol"
+)
+
+// stillMoon - Луна без вращения: скорость относительно поверхности равна инерциальной
+func stillMoon() PlanetConfig {
+	moon := MoonDefault()
+	moon.RotationPeriod = 0
+	return moon
+}
+
+func impactRocket(t *testing.T, backend Backend, planet PlanetConfig, start protocol.Vector3, velocity protocol.Vector3) PhysicsEngine {
+	t.Helper()
+	config := testConfig(1)
+	p, err := NewEngine(backend, &config, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetPlanet(planet); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetInitialVelocity(velocity); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestPredictImpactFreeFall(t *testing.T) {
+	planet := stillMoon()
+	const height = 1000.0
+	g := protocol.GConstant * planet.Mass / (planet.Radius * planet.Radius)
+	wantTime := math.Sqrt(2 * height / g)
+
+	for _, backend := range availableBackends() {
+		t.Run(string(backend), func(t *testing.T) {
+			p := impactRocket(t, backend, planet, planet.Position(30, 40, height), protocol.Vector3{})
+			defer p.Close()
+
+			impact, err := p.PredictImpact()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !impact.Impact {
+				t.Fatal("падение с 1 км не предсказано")
+			}
+			// g на километр выше поверхности меньше на 0.1%: допуск по времени 0.1%
+			if math.Abs(impact.TimeToImpact-wantTime) > 1e-3*wantTime {
+				t.Errorf("падение через %.3f с, ожидалось %.3f", impact.TimeToImpact, wantTime)
+			}
+			if want := g * wantTime; math.Abs(impact.ImpactSpeed-want) > 1e-3*want {
+				t.Errorf("скорость касания %.2f м/с, ожидалось %.2f", impact.ImpactSpeed, want)
+			}
+			if math.Abs(impact.Latitude-30) > 1e-6 || math.Abs(impact.Longitude-40) > 1e-6 {
+				t.Errorf("точка падения %.6f°, %.6f°, ожидалась 30°, 40°", impact.Latitude, impact.Longitude)
+			}
+		})
+	}
+}
+
+// TestPredictImpactLob сверяет прогноз с полетом самой физики без тяги:
+// на Луне по баллистике, на Земле с вращением и сопротивлением атмосферы
+func TestPredictImpactLob(t *testing.T) {
+	tests := []struct {
+		name   string
+		planet PlanetConfig
+		speed  float64 // м/с относительно поверхности, под 45° на восток
+	}{
+		{name: "Луна", planet: stillMoon(), speed: 500},
+		{name: "Земля", planet: EarthDefault(), speed: 1000},
+	}
+
+	for _, tt := range tests {
+		for _, backend := range availableBackends() {
+			t.Run(tt.name+"/"+string(backend), func(t *testing.T) {
+				start := tt.planet.Position(10, 20, 100)
+				velocity := addScaled(tt.planet.SurfaceVelocity(start), thrustDirection(start, 45, 0), tt.speed)
+				p := impactRocket(t, backend, tt.planet, start, velocity)
+				defer p.Close()
+
+				impact, err := p.PredictImpact()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !impact.Impact {
+					t.Fatal("падение не предсказано")
+				}
+
+				getState := func() protocol.RocketState {
+					state, err := p.GetState()
+					if err != nil {
+						t.Fatal(err)
+					}
+					return state
+				}
+				idle := &protocol.ControlCommand{EngineThrottle: []float64{0}}
+				state := getState()
+				before := state
+				for i := 0; i < 1000000 && !state.Crashed && !state.Landed; i++ {
+					before = state
+					if err := p.Update(idle, 0.01); err != nil {
+						t.Fatal(err)
+					}
+					state = getState()
+				}
+				if !state.Crashed {
+					t.Fatalf("ракета не упала: высота %.0f м", state.Altitude)
+				}
+				point := tt.planet.GroundPoint(state.Position, state.Time)
+				ground := tt.planet.SurfaceVelocity(before.Position)
+				speed := vectorLength(protocol.Vector3{X: before.Velocity.X - ground.X, Y: before.Velocity.Y - ground.Y, Z: before.Velocity.Z - ground.Z})
+
+				if math.Abs(impact.TimeToImpact-state.Time) > 0.05 {
+					t.Errorf("падение через %.2f с, физика упала на T+%.2f с", impact.TimeToImpact, state.Time)
+				}
+				if math.Abs(impact.Latitude-point.Latitude) > 0.005 || math.Abs(impact.Longitude-point.Longitude) > 0.005 {
+					t.Errorf("точка падения %.4f°, %.4f°, физика упала в %.4f°, %.4f°",
+						impact.Latitude, impact.Longitude, point.Latitude, point.Longitude)
+				}
+				if math.Abs(impact.ImpactSpeed-speed) > 0.01*speed {
+					t.Errorf("скорость касания %.1f м/с, у физики %.1f", impact.ImpactSpeed, speed)
+				}
+			})
+		}
+	}
+}
+
+func TestPredictImpactOrbit(t *testing.T) {
+	planet := EarthDefault()
+	for _, backend := range availableBackends() {
+		t.Run(string(backend), func(t *testing.T) {
+			r := planet.Radius + 400000
+			p := impactRocket(t, backend, planet, planet.Position(0, 0, 400000),
+				protocol.Vector3{Y: math.Sqrt(protocol.GConstant * planet.Mass / r)})
+			defer p.Close()
+
+			impact, err := p.PredictImpact()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if impact.Impact {
+				t.Errorf("на круговой орбите предсказано падение через %.0f с", impact.TimeToImpact)
+			}
+		})
+	}
+}
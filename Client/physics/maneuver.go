@@ -0,0 +1,103 @@
+package physics
+
+import (
+	"fmt"
+	"math"
+
+	"cosmodrom/protocol"
+)
+
+// BurnPlan - план импульса скругления в апоцентре
+type BurnPlan struct {
+	DeltaV         float64 // Требуемое приращение скорости (м/с)
+	BurnTime       float64 // Время работы на полной тяге (с); +Inf, если тяги нет
+	TimeToIgnition float64 // До включения, чтобы середина импульса пришлась на апоцентр (с); < 0 - уже пора
+	TimeToApoapsis float64 // До апоцентра (с)
+
+	DeltaVRemaining float64 // Запас характеристической скорости (м/с), см. DeltaVRemaining
+	Feasible        bool    // Запаса и тяги хватает на импульс
+}
+
+// planCircularization - импульс в апоцентре, после которого вторая апсида
+// орбиты окажется на высоте targetAlt: если апоцентр уже на целевой высоте,
+// орбита станет круговой. Скорости до и после импульса - по формуле
+// vis-viva, время работы - по формуле Циолковского при текущей массе и полной
+// тяге исправных двигателей.
+func (p *propulsion) planCircularization(state protocol.RocketState, planet PlanetConfig, massEmpty, targetAlt float64) (BurnPlan, error) {
+	if targetAlt <= 0 || math.IsNaN(targetAlt) || math.IsInf(targetAlt, 0) {
+		return BurnPlan{}, &PhysicsError{Kind: ErrorKindInvalid, Message: fmt.Sprintf("скругление: целевая высота %g м", targetAlt)}
+	}
+	orbit, err := predictOrbit(state, planet)
+	if err != nil {
+		return BurnPlan{}, err
+	}
+	if !orbit.IsClosed {
+		return BurnPlan{}, &PhysicsError{Kind: ErrorKindInvalid, Message: "скругление: орбита не замкнута, апоцентра нет"}
+	}
+
+	mu := protocol.GConstant * planet.Mass
+	rA := planet.Radius + orbit.Apoapsis
+	rP := planet.Radius + orbit.Periapsis
+	rT := planet.Radius + targetAlt
+	before := math.Sqrt(2 * mu * rP / (rA * (rA + rP)))
+	after := math.Sqrt(2 * mu * rT / (rA * (rA + rT)))
+
+	plan := BurnPlan{
+		DeltaV:          math.Abs(after - before),
+		BurnTime:        math.Inf(1),
+		TimeToApoapsis:  orbit.TimeToApoapsis,
+		DeltaVRemaining: p.deltaVRemaining(state, massEmpty),
+	}
+	thrust, consumption := p.output(p.uniform(1))
+	if thrust > 0 && consumption > 0 && state.MassCurrent > 0 {
+		burned := state.MassCurrent * (1 - math.Exp(-plan.DeltaV*consumption/thrust))
+		plan.BurnTime = burned / consumption
+	}
+	plan.TimeToIgnition = orbit.TimeToApoapsis - plan.BurnTime/2
+	plan.Feasible = !math.IsInf(plan.BurnTime, 1) && plan.DeltaV <= plan.DeltaVRemaining
+	return plan, nil
+}
+
+// HohmannPlan - двухимпульсный переход Хомана между круговыми орбитами.
+// Импульс положителен по скорости (подъем) и отрицателен против (спуск).
+type HohmannPlan struct {
+	DeltaV1      float64 // Первый импульс, с исходной орбиты на переходную (м/с)
+	DeltaV2      float64 // Второй импульс, скругление на целевой высоте (м/с)
+	TotalDeltaV  float64 // |DeltaV1| + |DeltaV2| (м/с)
+	TransferTime float64 // Полет по переходной орбите - полпериода (с)
+}
+
+// planHohmann - переход Хомана с круговой орбиты fromAlt на круговую toAlt
+// вокруг planet. Ошибка, если высота не положительна или ниже атмосферы:
+// круговая орбита там не держится.
+func planHohmann(planet PlanetConfig, fromAlt, toAlt float64) (HohmannPlan, error) {
+	if planet.Radius <= 0 || planet.Mass <= 0 {
+		return HohmannPlan{}, &PhysicsError{
+			Kind:    ErrorKindInvalid,
+			Message: fmt.Sprintf("у планеты должны быть положительные радиус и масса (%g м, %g кг)", planet.Radius, planet.Mass),
+		}
+	}
+	for _, alt := range []float64{fromAlt, toAlt} {
+		if alt <= 0 || math.IsNaN(alt) || math.IsInf(alt, 0) {
+			return HohmannPlan{}, &PhysicsError{Kind: ErrorKindInvalid, Message: fmt.Sprintf("переход Хомана: высота орбиты %g м", alt)}
+		}
+		if alt < planet.AtmosphereHeight {
+			return HohmannPlan{}, &PhysicsError{
+				Kind:    ErrorKindInvalid,
+				Message: fmt.Sprintf("переход Хомана: орбита %.1f км ниже атмосферы (%.1f км)", alt/1000.0, planet.AtmosphereHeight/1000.0),
+			}
+		}
+	}
+
+	mu := protocol.GConstant * planet.Mass
+	r1 := planet.Radius + fromAlt
+	r2 := planet.Radius + toAlt
+	a := (r1 + r2) / 2
+	plan := HohmannPlan{
+		DeltaV1:      math.Sqrt(mu/r1) * (math.Sqrt(r2/a) - 1),
+		DeltaV2:      math.Sqrt(mu/r2) * (1 - math.Sqrt(r1/a)),
+		TransferTime: math.Pi * math.Sqrt(a*a*a/mu),
+	}
+	plan.TotalDeltaV = math.Abs(plan.DeltaV1) + math.Abs(plan.DeltaV2)
+	return plan, nil
+}
@@ -0,0 +1,136 @@
+package physics
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"cosmodrom/protocol"
+)
+
+// ellipticRocket - ракета в перицентре perigee орбиты с апоцентром apogee
+func ellipticRocket(t *testing.T, backend Backend, config protocol.RocketConfig, perigee, apogee float64) PhysicsEngine {
+	t.Helper()
+	planet := EarthDefault()
+	p, err := NewEngine(backend, &config, planet.Position(0, 0, perigee))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mu := protocol.GConstant * planet.Mass
+	rP, rA := planet.Radius+perigee, planet.Radius+apogee
+	p.SetInitialVelocity(protocol.Vector3{Y: math.Sqrt(2 * mu * rA / (rP * (rP + rA)))})
+	return p
+}
+
+func TestPlanCircularization(t *testing.T) {
+	earth := EarthDefault()
+	mu := protocol.GConstant * earth.Mass
+	rP, rA := earth.Radius+150000, earth.Radius+200000
+	vA := math.Sqrt(2 * mu * rP / (rA * (rA + rP)))
+	wantDeltaV := math.Sqrt(mu/rA) - vA
+	halfPeriod := math.Pi * math.Sqrt(math.Pow((rA+rP)/2, 3)/mu)
+
+	for _, backend := range availableBackends() {
+		t.Run(string(backend), func(t *testing.T) {
+			config := testConfig(1)
+			p := ellipticRocket(t, backend, config, 150000, 200000)
+			defer p.Close()
+
+			plan, err := p.PlanCircularization(200000)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(plan.DeltaV-wantDeltaV) > 0.01 {
+				t.Errorf("приращение %.3f м/с, ожидалось %.3f", plan.DeltaV, wantDeltaV)
+			}
+			if math.Abs(plan.TimeToApoapsis-halfPeriod) > 0.5 {
+				t.Errorf("до апоцентра %.1f с, ожидалось %.1f", plan.TimeToApoapsis, halfPeriod)
+			}
+
+			mass := config.MassEmpty + config.MassFuel
+			ve := config.Engines[0].Thrust / config.Engines[0].FuelConsumption
+			wantBurn := mass * (1 - math.Exp(-wantDeltaV/ve)) / config.Engines[0].FuelConsumption
+			if math.Abs(plan.BurnTime-wantBurn) > 0.01 {
+				t.Errorf("время работы %.3f с, ожидалось %.3f", plan.BurnTime, wantBurn)
+			}
+			if math.Abs(plan.TimeToIgnition-(plan.TimeToApoapsis-wantBurn/2)) > 1e-9 {
+				t.Errorf("включение через %.3f с: импульс не симметричен относительно апоцентра", plan.TimeToIgnition)
+			}
+			if !plan.Feasible {
+				t.Errorf("план невыполним при запасе %.0f м/с", plan.DeltaVRemaining)
+			}
+		})
+	}
+}
+
+func TestPlanCircularizationInfeasible(t *testing.T) {
+	for _, backend := range availableBackends() {
+		t.Run(string(backend), func(t *testing.T) {
+			config := testConfig(1)
+			config.MassFuel = 10
+			p := ellipticRocket(t, backend, config, 150000, 200000)
+			defer p.Close()
+
+			plan, err := p.PlanCircularization(200000)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if plan.Feasible || plan.DeltaV <= plan.DeltaVRemaining {
+				t.Errorf("план выполним: приращение %.1f м/с при запасе %.1f", plan.DeltaV, plan.DeltaVRemaining)
+			}
+
+			if _, err := p.PlanCircularization(-1); !errors.Is(err, &PhysicsError{Kind: ErrorKindInvalid}) {
+				t.Errorf("отрицательная высота: ошибка %v, ожидалась invalid", err)
+			}
+			p.SetInitialVelocity(protocol.Vector3{Y: 20000})
+			if _, err := p.PlanCircularization(200000); !errors.Is(err, &PhysicsError{Kind: ErrorKindInvalid}) {
+				t.Errorf("гипербола: ошибка %v, ожидалась invalid", err)
+			}
+		})
+	}
+}
+
+func TestPlanHohmann(t *testing.T) {
+	config := testConfig(1)
+	p, err := NewEngine(BackendGo, &config, EarthDefault().Position(0, 0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// 200 -> 400 км вокруг Земли: 58.16 + 57.72 м/с, перелет 45 мин
+	raise, err := p.PlanHohmann(200000, 400000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := HohmannPlan{DeltaV1: 58.156, DeltaV2: 57.722, TotalDeltaV: 115.879, TransferTime: 2711.34}
+	for _, c := range []struct {
+		name      string
+		got, want float64
+	}{
+		{"первый импульс", raise.DeltaV1, want.DeltaV1},
+		{"второй импульс", raise.DeltaV2, want.DeltaV2},
+		{"суммарное приращение", raise.TotalDeltaV, want.TotalDeltaV},
+		{"время перелета", raise.TransferTime, want.TransferTime},
+	} {
+		if math.Abs(c.got-c.want) > 0.01 {
+			t.Errorf("%s %.3f, ожидалось %.3f", c.name, c.got, c.want)
+		}
+	}
+
+	// Спуск - те же импульсы в обратном порядке и против скорости
+	lower, err := p.PlanHohmann(400000, 200000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(lower.DeltaV1+raise.DeltaV2) > 1e-9 || math.Abs(lower.DeltaV2+raise.DeltaV1) > 1e-9 ||
+		math.Abs(lower.TotalDeltaV-raise.TotalDeltaV) > 1e-9 || math.Abs(lower.TransferTime-raise.TransferTime) > 1e-9 {
+		t.Errorf("спуск %+v не зеркален подъему %+v", lower, raise)
+	}
+
+	for _, alts := range [][2]float64{{0, 400000}, {200000, -1}, {50000, 400000}, {200000, 99999}} {
+		if _, err := p.PlanHohmann(alts[0], alts[1]); !errors.Is(err, &PhysicsError{Kind: ErrorKindInvalid}) {
+			t.Errorf("PlanHohmann(%g, %g): ошибка %v, ожидалась invalid", alts[0], alts[1], err)
+		}
+	}
+}
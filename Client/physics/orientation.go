@@ -0,0 +1,81 @@
+package physics
+
+import (
+	"math"
+
+	"cosmodrom/protocol"
+)
+
+// orientation - ориентация ракеты: углы команды, к которым ракета
+// поворачивается не быстрее rate. Тяга направлена по этой ориентации, а не
+// по команде. Общая часть RocketPhysics и GoPhysics, вызывается под их
+// мьютексом; движок на C ориентацию не ведет и получает ее углы в команде.
+type orientation struct {
+	rate             float64 // SetSlewRate, °/с; 0 - поворот мгновенный
+	pitch, yaw, roll float64 // Градусы, как в ControlCommand
+}
+
+func (o *orientation) setRate(degreesPerSecond float64) {
+	o.rate = 0
+	if degreesPerSecond > 0 && !math.IsInf(degreesPerSecond, 1) {
+		o.rate = degreesPerSecond
+	}
+}
+
+// slew поворачивает ракету к углам command за шаг dt. Каждый угол идет
+// кратчайшим путем и меняется не больше чем на rate * dt.
+func (o *orientation) slew(command *protocol.ControlCommand, dt float64) {
+	o.pitch = o.slewAngle(o.pitch, normalizeAngle(command.Pitch), dt)
+	o.yaw = o.slewAngle(o.yaw, normalizeAngle(command.Yaw), dt)
+	o.roll = o.slewAngle(o.roll, normalizeAngle(command.Roll), dt)
+}
+
+func (o *orientation) slewAngle(current, target, dt float64) float64 {
+	if o.rate == 0 {
+		return target
+	}
+	diff := normalizeAngle(target - current)
+	step := o.rate * dt
+	if math.Abs(diff) <= step {
+		return target
+	}
+	return normalizeAngle(current + math.Copysign(step, diff))
+}
+
+// settled - ракета уже повернута по command, и шаги с этой командой
+// ориентацию не меняют
+func (o *orientation) settled(command *protocol.ControlCommand) bool {
+	return o.rate == 0 || (o.pitch == normalizeAngle(command.Pitch) &&
+		o.yaw == normalizeAngle(command.Yaw) && o.roll == normalizeAngle(command.Roll))
+}
+
+// report переносит ориентацию в состояние ракеты
+func (o *orientation) report(state *protocol.RocketState) {
+	state.Orientation = &protocol.Orientation{
+		Quaternion: orientationQuaternion(state.Position, o.pitch, o.yaw, o.roll),
+		Pitch:      o.pitch,
+		Yaw:        o.yaw,
+		Roll:       o.roll,
+	}
+}
+
+// restore - ориентация из снимка; в старых снимках ее нет
+func (o *orientation) restore(state protocol.RocketState) {
+	o.pitch, o.yaw, o.roll = 0, 0, 0
+	if s := state.Orientation; s != nil {
+		o.pitch, o.yaw, o.roll = s.Pitch, s.Yaw, s.Roll
+	}
+}
+
+// orientationQuaternion - поворот связанных осей ракеты в оси планеты:
+// местный базис (зенит, восток, север), рыскание вокруг зенита к югу,
+// тангаж от зенита и крен вокруг продольной оси. Нос попадает туда же,
+// куда thrustDirection направляет тягу.
+func orientationQuaternion(position protocol.Vector3, pitch, yaw, roll float64) protocol.Quaternion {
+	up, east, north := localBasis(position)
+	x, z := protocol.Vector3{X: 1}, protocol.Vector3{Z: 1}
+	q := protocol.QuaternionFromBasis(up, east, north)
+	q = q.Mul(protocol.QuaternionFromAxisAngle(x, -yaw*math.Pi/180.0))
+	q = q.Mul(protocol.QuaternionFromAxisAngle(z, pitch*math.Pi/180.0))
+	return q.Mul(protocol.QuaternionFromAxisAngle(x, roll*math.Pi/180.0))
+}
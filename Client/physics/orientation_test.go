@@ -0,0 +1,139 @@
+package physics
+
+import (
+	"math"
+	"testing"
+
+	"cosmodrom/protocol"
+)
+
+// TestSlewRate: команда на тангаж 90° при скорости поворота 5°/с
+// выполняется за 18 с, а тяга все это время идет по достигнутому тангажу
+func TestSlewRate(t *testing.T) {
+	const dt = 0.1
+	for _, backend := range availableBackends() {
+		t.Run(string(backend), func(t *testing.T) {
+			planet := EarthDefault()
+			config := testConfig(1)
+			p, err := NewEngine(backend, &config, planet.Position(0, 0, 400000))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer p.Close()
+			p.SetSlewRate(5)
+
+			command := &protocol.ControlCommand{EngineThrottle: []float64{0}, Pitch: 90}
+			for step := 1; step <= 200; step++ {
+				if err := p.Update(command, dt); err != nil {
+					t.Fatal(err)
+				}
+				state, err := p.GetState()
+				if err != nil {
+					t.Fatal(err)
+				}
+				want := math.Min(90, 5*float64(step)*dt)
+				if got := state.Orientation.Pitch; math.Abs(got-want) > 1e-9 {
+					t.Fatalf("T+%.1f с: тангаж %.3f°, ожидалось %.3f°", float64(step)*dt, got, want)
+				}
+				if step == 179 && state.Orientation.Pitch >= 90 {
+					t.Fatal("поворот на 90° закончился раньше 18 с")
+				}
+			}
+		})
+	}
+}
+
+func TestSlewShortestPath(t *testing.T) {
+	o := orientation{rate: 10}
+	o.yaw = 170
+	o.slew(&protocol.ControlCommand{Yaw: -170}, 1)
+	if o.yaw != -180 && o.yaw != 180 {
+		t.Errorf("рыскание с 170° к -170° через 180°: %.1f°", o.yaw)
+	}
+	o.slew(&protocol.ControlCommand{Yaw: -170}, 1)
+	if o.yaw != -170 {
+		t.Errorf("рыскание %.1f°, ожидалось -170°", o.yaw)
+	}
+
+	instant := orientation{}
+	instant.slew(&protocol.ControlCommand{Pitch: 45, Yaw: 400, Roll: -30}, 0.01)
+	if instant.pitch != 45 || instant.yaw != 40 || instant.roll != -30 {
+		t.Errorf("без ограничения ориентация %.0f°, %.0f°, %.0f°", instant.pitch, instant.yaw, instant.roll)
+	}
+}
+
+// TestOrientationQuaternion: нос ракеты по кватерниону смотрит туда же,
+// куда направлена тяга, и крен не сдвигает нос
+func TestOrientationQuaternion(t *testing.T) {
+	planet := EarthDefault()
+	positions := []protocol.Vector3{
+		planet.Position(0, 0, 0),
+		planet.Position(45.9, 63.3, 1000),
+		planet.Position(-30, -120, 200000),
+		planet.Position(90, 0, 0),
+	}
+	for _, position := range positions {
+		for _, angles := range [][3]float64{{0, 0, 0}, {45, 90, 0}, {90, -30, 60}, {-20, 180, -90}} {
+			q := orientationQuaternion(position, angles[0], angles[1], angles[2])
+			nose := q.Rotate(protocol.Vector3{X: 1})
+			if want := thrustDirection(position, angles[0], angles[1]); nose.Distance(want) > 1e-9 {
+				t.Errorf("позиция %+v, углы %v: нос %+v, тяга %+v", position, angles, nose, want)
+			}
+		}
+	}
+
+	// При нулевых углах оси ракеты - зенит, восток, север
+	up, east, north := localBasis(positions[1])
+	q := orientationQuaternion(positions[1], 0, 0, 0)
+	for i, pair := range [][2]protocol.Vector3{{{X: 1}, up}, {{Y: 1}, east}, {{Z: 1}, north}} {
+		if got := q.Rotate(pair[0]); got.Distance(pair[1]) > 1e-9 {
+			t.Errorf("ось %d: %+v, ожидалось %+v", i, got, pair[1])
+		}
+	}
+}
+
+// TestSlewRunSteps: серия шагов во время поворота дает то же, что
+// отдельные Update
+func TestSlewRunSteps(t *testing.T) {
+	for _, backend := range availableBackends() {
+		t.Run(string(backend), func(t *testing.T) {
+			planet := EarthDefault()
+			config := testConfig(1)
+			start := planet.Position(45, 63, 0.5)
+			newEngine := func() PhysicsEngine {
+				p, err := NewEngine(backend, &config, start)
+				if err != nil {
+					t.Fatal(err)
+				}
+				p.SetInitialVelocity(planet.SurfaceVelocity(start))
+				p.SetSlewRate(2)
+				return p
+			}
+			a, b := newEngine(), newEngine()
+			defer a.Close()
+			defer b.Close()
+
+			command := &protocol.ControlCommand{EngineThrottle: []float64{1}, Pitch: 30, Yaw: 10}
+			for i := 0; i < 300; i++ {
+				if err := a.Update(command, 0.05); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := a.GetState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, done, err := b.RunSteps(command, 0.05, 300)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if done != 300 || got.Position.Distance(want.Position) > 1e-6 || *got.Orientation != *want.Orientation {
+				t.Errorf("RunSteps: %d шагов, позиция %+v, ориентация %+v; Update: %+v, %+v",
+					done, got.Position, *got.Orientation, want.Position, *want.Orientation)
+			}
+			if want.Orientation.Pitch != 30 {
+				t.Errorf("за 15 с при 2°/с тангаж %.2f°, ожидалось 30°", want.Orientation.Pitch)
+			}
+		})
+	}
+}
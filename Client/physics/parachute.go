@@ -0,0 +1,67 @@
+package physics
+
+import (
+	"cosmodrom/protocol"
+)
+
+// parachute - парашют ракеты: раскрытие по команде и торможение куполом.
+// Общая часть RocketPhysics и GoPhysics, вызывается под их мьютексом.
+// Сопротивление купола считается после шага движка отдельной поправкой
+// скорости, поэтому физика на C его не знает и одинакова в обеих реализациях.
+type parachute struct {
+	config   *protocol.Parachute // nil - парашюта нет
+	deployed bool
+	failed   bool
+}
+
+func (c *parachute) setConfig(config *protocol.Parachute) {
+	c.config = nil
+	if config != nil {
+		copied := *config
+		c.config = &copied
+	}
+}
+
+// deploy раскрывает парашют по команде DeployParachute. На воздушной
+// скорости больше ParachuteMaxSpeed купол рвется, и повторно раскрыть его
+// уже нельзя.
+func (c *parachute) deploy(planet PlanetConfig, state protocol.RocketState) {
+	if c.config == nil || c.deployed || c.failed || state.Landed || state.Crashed {
+		return
+	}
+	if airspeed(planet, state) > protocol.ParachuteMaxSpeed {
+		c.failed = true
+		return
+	}
+	c.deployed = true
+}
+
+// brake гасит воздушную скорость куполом за шаг dt. Уравнение dv/dt = -k v²
+// решается точно: v' = v / (1 + k v dt), поэтому даже раскрытие на большой
+// скорости не раскачивает явную схему движка.
+func (c *parachute) brake(planet PlanetConfig, state *protocol.RocketState, dt float64) bool {
+	if !c.deployed || state.Landed || state.Crashed || state.MassCurrent <= 0 {
+		return false
+	}
+	if state.Altitude <= 0 || state.Altitude >= planet.AtmosphereHeight {
+		return false
+	}
+	wind := planet.SurfaceVelocity(state.Position)
+	air := state.Velocity.Sub(wind)
+	k := 0.5 * atmosphericDensity(planet, state.Altitude) * c.config.DragCoefficient * c.config.Area / state.MassCurrent
+	state.Velocity = wind.Add(air.Scale(1 / (1 + k*air.Norm()*dt)))
+	state.Speed = state.Velocity.Norm()
+	return true
+}
+
+// report переносит состояние парашюта в состояние ракеты
+func (c *parachute) report(state *protocol.RocketState) {
+	state.ParachuteDeployed = c.deployed
+	state.ParachuteFailed = c.failed
+}
+
+// restore - состояние парашюта из снимка
+func (c *parachute) restore(state protocol.RocketState) {
+	c.deployed = state.ParachuteDeployed
+	c.failed = state.ParachuteFailed
+}
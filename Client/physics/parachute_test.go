@@ -0,0 +1,147 @@
+package physics
+
+import (
+	"math"
+	"testing"
+
+	"cosmodrom/protocol"
+)
+
+func parachuteRocket(t *testing.T, backend Backend, altitude, descent float64) (PhysicsEngine, protocol.RocketConfig, PlanetConfig) {
+	t.Helper()
+	planet := EarthDefault()
+	planet.RotationPeriod = 0
+	config := testConfig(1)
+	config.MassFuel, config.MassFuelMax = 0, 0
+	config.Parachute = &protocol.Parachute{DeployAltitude: 3000, DragCoefficient: 1.5, Area: 16000}
+
+	start := planet.Position(0, 0, altitude)
+	p, err := NewEngine(backend, &config, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetPlanet(planet); err != nil {
+		t.Fatal(err)
+	}
+	down, _ := unit(start)
+	if err := p.SetInitialVelocity(down.Scale(-descent)); err != nil {
+		t.Fatal(err)
+	}
+	return p, config, planet
+}
+
+// TestParachuteTerminalVelocity: под куполом скорость снижения выходит на
+// установившуюся, при которой сопротивление ½ρv²CdA уравновешивает вес, и
+// ракета садится, а не разбивается
+func TestParachuteTerminalVelocity(t *testing.T) {
+	for _, backend := range availableBackends() {
+		t.Run(string(backend), func(t *testing.T) {
+			p, config, planet := parachuteRocket(t, backend, 2000, 100)
+			defer p.Close()
+			// Купол тормозит после шага, а вес разгоняет за шаг на g*dt: шаг
+			// мельче обычного, чтобы это не сказалось на сверке
+			const dt = 0.005
+
+			command := &protocol.ControlCommand{EngineThrottle: []float64{0}, DeployParachute: true}
+			state, _, err := p.RunSteps(command, dt, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !state.ParachuteDeployed || state.ParachuteFailed {
+				t.Fatalf("парашют не раскрыт на 100 м/с: раскрыт %v, порван %v", state.ParachuteDeployed, state.ParachuteFailed)
+			}
+
+			for state.Altitude > 100 {
+				if state, _, err = p.RunSteps(command, dt, 10); err != nil {
+					t.Fatal(err)
+				}
+			}
+			drag := config.DragCoefficient*config.CrossSection + config.Parachute.DragCoefficient*config.Parachute.Area
+			r := planet.Radius + state.Altitude
+			weight := state.MassCurrent * protocol.GConstant * planet.Mass / (r * r)
+			want := math.Sqrt(2 * weight / (atmosphericDensity(planet, state.Altitude) * drag))
+			if got := airspeed(planet, state); math.Abs(got-want) > 0.02*want {
+				t.Errorf("скорость снижения на %.0f м %.3f м/с, установившаяся %.3f", state.Altitude, got, want)
+			}
+
+			for !state.Landed && !state.Crashed {
+				if state, _, err = p.RunSteps(command, dt, 100); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if !state.Landed {
+				t.Error("ракета под парашютом разбилась")
+			}
+			if !state.ParachuteDeployed {
+				t.Error("после посадки парашют не отмечен раскрытым")
+			}
+		})
+	}
+}
+
+func TestParachuteTooFast(t *testing.T) {
+	for _, backend := range availableBackends() {
+		t.Run(string(backend), func(t *testing.T) {
+			p, _, _ := parachuteRocket(t, backend, 2000, protocol.ParachuteMaxSpeed+50)
+			defer p.Close()
+
+			command := &protocol.ControlCommand{EngineThrottle: []float64{0}, DeployParachute: true}
+			if err := p.Update(command, 0.02); err != nil {
+				t.Fatal(err)
+			}
+			state, err := p.GetState()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if state.ParachuteDeployed || !state.ParachuteFailed {
+				t.Fatalf("раскрытие на %.0f м/с: раскрыт %v, порван %v", state.Speed, state.ParachuteDeployed, state.ParachuteFailed)
+			}
+
+			// Порванный купол не тормозит и не раскрывается повторно
+			for !state.Landed && !state.Crashed {
+				if state, _, err = p.RunSteps(command, 0.02, 100); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if !state.Crashed || state.ParachuteDeployed {
+				t.Errorf("с порванным парашютом: разбилась %v, раскрыт %v", state.Crashed, state.ParachuteDeployed)
+			}
+		})
+	}
+}
+
+func TestParachuteSnapshot(t *testing.T) {
+	for _, backend := range availableBackends() {
+		t.Run(string(backend), func(t *testing.T) {
+			p, _, _ := parachuteRocket(t, backend, 2000, 100)
+			defer p.Close()
+			command := &protocol.ControlCommand{EngineThrottle: []float64{0}, DeployParachute: true}
+			if err := p.Update(command, 0.02); err != nil {
+				t.Fatal(err)
+			}
+			data, err := p.Snapshot()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			restored, err := NewEngineFromSnapshot(backend, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer restored.Close()
+			// Без команды раскрытия купол из снимка продолжает тормозить
+			idle := &protocol.ControlCommand{EngineThrottle: []float64{0}}
+			want, _, err := p.RunSteps(idle, 0.02, 500)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, _, err := restored.RunSteps(idle, 0.02, 500)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.ParachuteDeployed || got.Velocity != want.Velocity {
+				t.Errorf("после восстановления раскрыт %v, скорость %+v, ожидалась %+v", got.ParachuteDeployed, got.Velocity, want.Velocity)
+			}
+		})
+	}
+}
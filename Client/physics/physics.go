@@ -0,0 +1,416 @@
+package physics
+
+import (
+	"fmt"
+	"math"
+
+	"cosmodrom/protocol"
+)
+
+type PlanetConfig struct {
+	Radius           float64 // Радиус планеты (м)
+	Mass             float64 // Масса планеты (кг)
+	AtmosphereHeight float64 // Высота атмосферы (м)
+	SurfacePressure  float64 // Давление на поверхности (1.0 для Земли)
+	ScaleHeight      float64 // Масштабная высота атмосферы (м)
+	RotationPeriod   float64 // Период вращения вокруг оси z (с), 0 - планета не вращается
+}
+
+// RotationRate - угловая скорость вращения планеты (рад/с)
+func (p PlanetConfig) RotationRate() float64 {
+	if p.RotationPeriod == 0 {
+		return 0
+	}
+	return 2 * math.Pi / p.RotationPeriod
+}
+
+// SurfaceVelocity - скорость точки position, вращающейся вместе с планетой.
+// Для точки старта это скорость, которую ракета получает бесплатно.
+func (p PlanetConfig) SurfaceVelocity(position protocol.Vector3) protocol.Vector3 {
+	omega := p.RotationRate()
+	return protocol.Vector3{X: -omega * position.Y, Y: omega * position.X}
+}
+
+type GravityTurnConfig struct {
+	TargetAltitude float64 // Целевая высота орбиты (м)
+	TurnStartAlt   float64 // Высота начала поворота (м)
+	TurnEndAlt     float64 // Высота окончания поворота (м)
+	AutoPitch      bool    // Включен ли автоматический pitch
+}
+
+type OrbitPrediction struct {
+	Apoapsis         float64 // Апоцентр (м)
+	Periapsis        float64 // Перицентр (м)
+	Eccentricity     float64 // Эксцентриситет
+	OrbitalVelocity  float64 // Текущая скорость
+	RequiredVelocity float64 // Нужная скорость для круговой орбиты
+	IsStable         bool    // Стабильна ли орбита
+	Inclination      float64 // Наклонение к экватору (градусы, 0-180)
+
+	// Ориентация орбиты, градусы 0-360. У экваториальной орбиты узлов нет:
+	// долгота узла 0, а аргумент перицентра отсчитывается от оси x. У круговой
+	// нет перицентра: аргумент перицентра 0.
+	LongitudeOfAscendingNode float64
+	ArgumentOfPeriapsis      float64
+	Equatorial               bool // Долгота восходящего узла не определена
+	Circular                 bool // Аргумент перицентра не определен
+
+	// Время по кеплеровской орбите; -1, если величина не определена
+	IsClosed        bool    // Эллипс: у орбиты есть период и апоцентр
+	Period          float64 // Период обращения (с)
+	TimeToApoapsis  float64 // До ближайшего прохождения апоцентра (с)
+	TimeToPeriapsis float64 // До ближайшего перицентра (с); на уходящей гиперболе -1
+}
+
+func EarthDefault() PlanetConfig {
+	return PlanetConfig{
+		Radius:           6371000.0,
+		Mass:             5.972e24,
+		AtmosphereHeight: 100000.0,
+		SurfacePressure:  1.0,
+		ScaleHeight:      8500.0,
+		RotationPeriod:   86164.1, // Звездные сутки
+	}
+}
+
+// MoonDefault - Луна: атмосферы нет, вращение синхронно с обращением вокруг Земли
+func MoonDefault() PlanetConfig {
+	return PlanetConfig{
+		Radius:         1737400.0,
+		Mass:           7.342e22,
+		RotationPeriod: 2360591.5,
+	}
+}
+
+// MarsDefault - Марс: плотность атмосферы у поверхности около 1.6% земной
+func MarsDefault() PlanetConfig {
+	return PlanetConfig{
+		Radius:           3389500.0,
+		Mass:             6.4171e23,
+		AtmosphereHeight: 125000.0,
+		SurfacePressure:  0.016,
+		ScaleHeight:      11100.0,
+		RotationPeriod:   88642.7,
+	}
+}
+
+// PlanetByName возвращает планету по имени: earth, moon или mars
+func PlanetByName(name string) (PlanetConfig, error) {
+	switch name {
+	case "earth":
+		return EarthDefault(), nil
+	case "moon":
+		return MoonDefault(), nil
+	case "mars":
+		return MarsDefault(), nil
+	}
+	return PlanetConfig{}, fmt.Errorf("неизвестная планета %q, доступны earth, moon, mars", name)
+}
+
+// Position - декартовы координаты точки на высоте altitude над поверхностью
+// планеты. SphericalToCartesian считает от радиуса Земли.
+func (p PlanetConfig) Position(latitude, longitude, altitude float64) protocol.Vector3 {
+	lat := latitude * math.Pi / 180.0
+	lon := longitude * math.Pi / 180.0
+	r := p.Radius + altitude
+	return protocol.Vector3{
+		X: r * math.Cos(lat) * math.Cos(lon),
+		Y: r * math.Cos(lat) * math.Sin(lon),
+		Z: r * math.Sin(lat),
+	}
+}
+
+func GravityTurnForOrbit(planet PlanetConfig, targetOrbitAltitude float64) GravityTurnConfig {
+	config := GravityTurnConfig{
+		TargetAltitude: targetOrbitAltitude,
+		AutoPitch:      true,
+	}
+
+	config.TurnStartAlt = targetOrbitAltitude * 0.01
+	if config.TurnStartAlt < 1000.0 {
+		config.TurnStartAlt = 1000.0
+	}
+
+	// Без атмосферы вертикальный участок только теряет скорость на
+	// гравитацию, поэтому разворот короткий и начинается сразу после отрыва
+	if planet.AtmosphereHeight == 0 {
+		config.TurnStartAlt = 100.0
+		config.TurnEndAlt = targetOrbitAltitude * 0.05
+		return config
+	}
+
+	// С учетом гравитационных потерь разворот нужно закончить в верхних слоях
+	// атмосферы, иначе на скругление орбиты не хватает горизонтальной скорости
+	config.TurnEndAlt = targetOrbitAltitude * 0.3
+
+	if config.TurnEndAlt < planet.AtmosphereHeight*0.5 {
+		config.TurnEndAlt = planet.AtmosphereHeight * 0.5
+	}
+
+	return config
+}
+
+// AttitudeToward переводит направление direction в точке position в тангаж
+// (от местной вертикали, 0-180) и рыскание (от востока вправо, как курс) в
+// осях, которые использует движок. ok = false, если направление почти нулевое:
+// углы тогда не определены, и возвращается вертикаль.
+func AttitudeToward(position, direction protocol.Vector3) (pitch, yaw float64, ok bool) {
+	up, ok := unit(position)
+	if !ok {
+		return 0, 0, false
+	}
+	d, ok := unit(direction)
+	if !ok {
+		return 0, 0, false
+	}
+
+	// Восток - как в calculate_thrust, с запасной осью у полюса
+	east := cross(protocol.Vector3{Z: 1}, up)
+	if math.Sqrt(dot(east, east)) < 0.01 {
+		east = cross(protocol.Vector3{X: 1}, up)
+	}
+	east, _ = unit(east)
+	north := cross(up, east)
+
+	vertical := dot(d, up)
+	horizontal := math.Sqrt(math.Max(0, 1-vertical*vertical))
+	pitch = math.Atan2(horizontal, vertical) * 180.0 / math.Pi
+	// Вдоль вертикали рыскание не влияет на тягу
+	if horizontal > 1e-9 {
+		yaw = math.Atan2(-dot(d, north), dot(d, east)) * 180.0 / math.Pi
+	}
+	return pitch, yaw, true
+}
+
+func unit(v protocol.Vector3) (protocol.Vector3, bool) {
+	n := math.Sqrt(dot(v, v))
+	if n < 1e-9 {
+		return protocol.Vector3{}, false
+	}
+	return protocol.Vector3{X: v.X / n, Y: v.Y / n, Z: v.Z / n}, true
+}
+
+func dot(a, b protocol.Vector3) float64 {
+	return a.X*b.X + a.Y*b.Y + a.Z*b.Z
+}
+
+func cross(a, b protocol.Vector3) protocol.Vector3 {
+	return protocol.Vector3{
+		X: a.Y*b.Z - a.Z*b.Y,
+		Y: a.Z*b.X - a.X*b.Z,
+		Z: a.X*b.Y - a.Y*b.X,
+	}
+}
+
+// noOrbit - прогноз при ошибке: ни апсид, ни времени до них
+var noOrbit = OrbitPrediction{Apoapsis: -1, Period: -1, TimeToApoapsis: -1, TimeToPeriapsis: -1}
+
+// predictOrbit - кеплеровская орбита по состоянию state. Ошибка, если у
+// планеты неположительные радиус или масса, а в состоянии NaN или Inf:
+// прогноз тогда бессмыслен, и вместо него возвращается noOrbit.
+func predictOrbit(state protocol.RocketState, planet PlanetConfig) (OrbitPrediction, error) {
+	if planet.Radius <= 0 || planet.Mass <= 0 {
+		return noOrbit, &PhysicsError{
+			Kind:    ErrorKindInvalid,
+			Message: fmt.Sprintf("у планеты должны быть положительные радиус и масса (%g м, %g кг)", planet.Radius, planet.Mass),
+		}
+	}
+	if !state.Position.IsFinite() || !state.Velocity.IsFinite() {
+		return noOrbit, &PhysicsError{Kind: ErrorKindNonFinite, Message: "в состоянии ракеты NaN или Inf, прогноз орбиты невозможен"}
+	}
+
+	r := state.Position.Norm()
+	v := state.Speed
+
+	mu := 6.674e-11 * planet.Mass
+	specificEnergy := (v*v)/2.0 - mu/r
+
+	// Момент импульса на единицу массы
+	hVector := state.Position.Cross(state.Velocity)
+	h := hVector.Norm()
+
+	pred := OrbitPrediction{}
+	// Ось вращения планеты - z, поэтому наклонение - угол момента импульса к ней
+	if h > 0 {
+		pred.Inclination = math.Acos(math.Max(-1, math.Min(1, hVector.Z/h))) * 180.0 / math.Pi
+	}
+
+	var a float64
+	if math.Abs(specificEnergy) < 1e-10 {
+		a = math.Inf(1)
+		pred.Eccentricity = 1.0
+	} else {
+		a = -mu / (2.0 * specificEnergy)
+	}
+
+	if !math.IsInf(a, 1) {
+		eSq := 1.0 - (h*h)/(mu*a)
+		if eSq < 0 {
+			eSq = 0
+		}
+		pred.Eccentricity = math.Sqrt(eSq)
+	}
+
+	if pred.Eccentricity < 1.0 && a > 0 {
+		pred.Apoapsis = a*(1.0+pred.Eccentricity) - planet.Radius
+		pred.Periapsis = a*(1.0-pred.Eccentricity) - planet.Radius
+	} else {
+		pred.Apoapsis = -1
+		pred.Periapsis = state.Altitude
+	}
+
+	pred.OrbitalVelocity = v
+	pred.RequiredVelocity = math.Sqrt(mu / (planet.Radius + state.Altitude))
+	pred.IsStable = pred.Periapsis > planet.AtmosphereHeight && pred.Eccentricity < 1.0
+
+	pred.IsClosed = pred.Eccentricity < 1.0 && a > 0
+	pred.orient(hVector, eccentricityVector(state, mu))
+	pred.Period, pred.TimeToApoapsis, pred.TimeToPeriapsis = orbitTiming(state, mu, a, pred.Eccentricity, h)
+	return pred, nil
+}
+
+// circularEccentricity - ниже этого эксцентриситета перицентр не определен:
+// он считается в текущей точке, апоцентр - через полпериода
+const circularEccentricity = 1e-6
+
+// eccentricityVector - вектор эксцентриситета: направлен в перицентр, длина e
+func eccentricityVector(state protocol.RocketState, mu float64) protocol.Vector3 {
+	r := math.Sqrt(dot(state.Position, state.Position))
+	rv := dot(state.Position, state.Velocity)
+	v2 := dot(state.Velocity, state.Velocity)
+	return protocol.Vector3{
+		X: ((v2-mu/r)*state.Position.X - rv*state.Velocity.X) / mu,
+		Y: ((v2-mu/r)*state.Position.Y - rv*state.Velocity.Y) / mu,
+		Z: ((v2-mu/r)*state.Position.Z - rv*state.Velocity.Z) / mu,
+	}
+}
+
+// equatorialSine - ниже этого синуса наклонения линия узлов не определена
+const equatorialSine = 1e-6
+
+// orient заполняет долготу восходящего узла и аргумент перицентра по моменту
+// импульса hVector и вектору эксцентриситета eVector. Узлы - пересечение
+// с экватором, линия узлов n = z x h.
+func (pred *OrbitPrediction) orient(hVector, eVector protocol.Vector3) {
+	h := hVector.Norm()
+	nodeLength := math.Hypot(hVector.X, hVector.Y)
+	pred.Equatorial = h == 0 || nodeLength < equatorialSine*h
+	pred.Circular = pred.Eccentricity < circularEccentricity
+	if h == 0 {
+		// Радиальное движение: плоскости орбиты нет
+		pred.Circular = true
+		return
+	}
+
+	if !pred.Equatorial {
+		nx, ny := -hVector.Y, hVector.X
+		pred.LongitudeOfAscendingNode = degrees360(math.Atan2(ny, nx))
+		if !pred.Circular {
+			// Угол от узла к перицентру в плоскости орбиты, по движению
+			cosW := (nx*eVector.X + ny*eVector.Y) / (nodeLength * math.Sqrt(dot(eVector, eVector)))
+			w := math.Acos(math.Max(-1, math.Min(1, cosW)))
+			if eVector.Z < 0 {
+				w = 2*math.Pi - w
+			}
+			pred.ArgumentOfPeriapsis = w * 180.0 / math.Pi
+		}
+		return
+	}
+
+	if !pred.Circular {
+		// Долгота перицентра от оси x по направлению движения
+		w := math.Atan2(eVector.Y, eVector.X)
+		if hVector.Z < 0 {
+			w = -w
+		}
+		pred.ArgumentOfPeriapsis = degrees360(w)
+	}
+}
+
+// degrees360 переводит угол в радианах в градусы 0-360
+func degrees360(rad float64) float64 {
+	deg := math.Mod(rad*180.0/math.Pi, 360)
+	if deg < 0 {
+		deg += 360
+	}
+	return deg
+}
+
+// orbitTiming - период и время до апсид по большой полуоси a, эксцентриситету
+// e и моменту импульса h. Истинная аномалия берется из вектора
+// эксцентриситета, время - из средней аномалии (эллипс), гиперболической
+// аномалии (гипербола) или уравнения Баркера (парабола).
+func orbitTiming(state protocol.RocketState, mu, a, e, h float64) (period, toApoapsis, toPeriapsis float64) {
+	period, toApoapsis, toPeriapsis = -1, -1, -1
+	r := math.Sqrt(dot(state.Position, state.Position))
+	if h <= 0 || r <= 0 {
+		// Радиальное движение: орбита вырождена в отрезок
+		return period, toApoapsis, toPeriapsis
+	}
+
+	rv := dot(state.Position, state.Velocity)
+	nu := 0.0
+	if e >= circularEccentricity {
+		if eDir, ok := unit(eccentricityVector(state, mu)); ok {
+			nu = math.Acos(math.Max(-1, math.Min(1, dot(eDir, state.Position)/r)))
+			if rv < 0 {
+				nu = -nu // До перицентра
+			}
+		}
+	}
+
+	switch {
+	case e < 1.0 && a > 0:
+		n := math.Sqrt(mu / (a * a * a))
+		period = 2 * math.Pi / n
+		E := 2 * math.Atan(math.Sqrt((1-e)/(1+e))*math.Tan(nu/2))
+		M := math.Mod(E-e*math.Sin(E)+2*math.Pi, 2*math.Pi)
+		toPeriapsis = math.Mod((2*math.Pi-M)/n, period)
+		toApoapsis = (math.Pi - M) / n
+		if toApoapsis < 0 {
+			toApoapsis += period
+		}
+	case rv > 0:
+		// Гипербола или парабола после перицентра: апсид впереди нет
+	case e > 1.0 && a < 0:
+		n := math.Sqrt(mu / (-a * a * a))
+		F := 2 * math.Atanh(math.Sqrt((e-1)/(e+1))*math.Tan(nu/2))
+		toPeriapsis = -(e*math.Sinh(F) - F) / n
+	default:
+		p := h * h / mu
+		D := math.Tan(nu / 2)
+		toPeriapsis = -math.Sqrt(p*p*p/mu) / 2 * (D + D*D*D/3)
+	}
+	return period, toApoapsis, toPeriapsis
+}
+
+// Pitch - тангаж гравитационного разворота на высоте altitude: 0 до начала
+// поворота, 90 после его конца. Без AutoPitch ракета идет вертикально.
+func (gt GravityTurnConfig) Pitch(altitude float64) float64 {
+	if !gt.AutoPitch {
+		return 0.0
+	}
+
+	start := gt.TurnStartAlt
+	end := gt.TurnEndAlt
+
+	if altitude < start {
+		return 0.0
+	}
+
+	if altitude >= end {
+		return 90.0
+	}
+
+	progress := (altitude - start) / (end - start)
+	smoothProgress := math.Sin(progress * math.Pi / 2.0)
+
+	return smoothProgress * 90.0
+}
+
+// airspeed - скорость относительно вращающейся вместе с планетой атмосферы (м/с)
+func airspeed(planet PlanetConfig, state protocol.RocketState) float64 {
+	wind := planet.SurfaceVelocity(state.Position)
+	dx, dy, dz := state.Velocity.X-wind.X, state.Velocity.Y-wind.Y, state.Velocity.Z-wind.Z
+	return math.Sqrt(dx*dx + dy*dy + dz*dz)
+}
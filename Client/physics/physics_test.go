@@ -0,0 +1,352 @@
+package physics
+
+import (
+	"math"
+	"testing"
+
+	"cosmodrom/protocol"
+)
+
+// orbitState - ракета на расстоянии r от центра Земли с радиальной скоростью
+// vr и трансверсальной vt (в плоскости экватора, на восток)
+func orbitState(r, vr, vt float64) protocol.RocketState {
+	velocity := protocol.Vector3{X: vr, Y: vt}
+	return protocol.RocketState{
+		Position: protocol.Vector3{X: r},
+		Velocity: velocity,
+		Speed:    math.Hypot(vr, vt),
+		Altitude: r - EarthDefault().Radius,
+	}
+}
+
+func TestPredictOrbitTiming(t *testing.T) {
+	earth := EarthDefault()
+	mu := protocol.GConstant * earth.Mass
+
+	// Круговая НОО 400 км: T = 2*pi*sqrt(r^3/mu) = 5545.06 с
+	leo := earth.Radius + 400000
+	// ГПО 200 x 35786 км: a = 24364 км, e = 0.730299, T = 37848.59 с
+	rp, ra := earth.Radius+200000, earth.Radius+35786000
+	a := (rp + ra) / 2
+	e := (ra - rp) / (ra + rp)
+	p := a * (1 - e*e)      // 11369.79 км
+	vp := math.Sqrt(mu / p) // 5920.75 м/с
+
+	tests := []struct {
+		name             string
+		state            protocol.RocketState
+		period           float64
+		toApoapsis       float64
+		toPeriapsis      float64
+		tolerance        float64
+		wantEccentricity float64
+	}{
+		{
+			name:  "круговая НОО",
+			state: orbitState(leo, 0, math.Sqrt(mu/leo)),
+			// Перицентр круговой орбиты - текущая точка
+			period: 5545.06, toApoapsis: 2772.53, toPeriapsis: 0, tolerance: 0.5,
+		},
+		{
+			name:   "ГПО в перицентре",
+			state:  orbitState(rp, 0, math.Sqrt(mu*(2/rp-1/a))),
+			period: 37848.59, toApoapsis: 18924.30, toPeriapsis: 0, tolerance: 0.5,
+			wantEccentricity: 0.730299,
+		},
+		{
+			// nu = 90: r = p, vr = sqrt(mu/p)*e, vt = sqrt(mu/p); E = 0.6687 рад,
+			// M = 0.2524 рад, t от перицентра 1524.92 с
+			name:   "ГПО через четверть оборота после перицентра",
+			state:  orbitState(p, vp*e, vp),
+			period: 37848.59, toApoapsis: 17399.38, toPeriapsis: 36323.67, tolerance: 0.5,
+			wantEccentricity: 0.730299,
+		},
+		{
+			name:   "ГПО за четверть оборота до перицентра",
+			state:  orbitState(p, -vp*e, vp),
+			period: 37848.59, toApoapsis: 20449.22, toPeriapsis: 1524.92, tolerance: 0.5,
+			wantEccentricity: 0.730299,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orbit, err := predictOrbit(tt.state, earth)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !orbit.IsClosed {
+				t.Fatal("эллиптическая орбита не замкнута")
+			}
+			if tt.wantEccentricity > 0 && math.Abs(orbit.Eccentricity-tt.wantEccentricity) > 1e-6 {
+				t.Errorf("эксцентриситет %.6f, ожидалось %.6f", orbit.Eccentricity, tt.wantEccentricity)
+			}
+			check := func(name string, got, want float64) {
+				if math.Abs(got-want) > tt.tolerance {
+					t.Errorf("%s %.2f с, ожидалось %.2f", name, got, want)
+				}
+			}
+			check("период", orbit.Period, tt.period)
+			check("до апоцентра", orbit.TimeToApoapsis, tt.toApoapsis)
+			check("до перицентра", orbit.TimeToPeriapsis, tt.toPeriapsis)
+		})
+	}
+}
+
+func TestPredictOrbitTimingOpen(t *testing.T) {
+	earth := EarthDefault()
+	mu := protocol.GConstant * earth.Mass
+
+	// Гипербола e = 2 с перицентром 7000 км, nu = -60: r = 10500 км,
+	// F = -0.6251, до перицентра 748.49 с
+	p := 21000000.0
+	v := math.Sqrt(mu / p)
+	nu := -math.Pi / 3
+	incoming := orbitState(p/(1+2*math.Cos(nu)), v*2*math.Sin(nu), v*(1+2*math.Cos(nu)))
+	outgoing := orbitState(p/(1+2*math.Cos(nu)), -v*2*math.Sin(nu), v*(1+2*math.Cos(nu)))
+
+	// Парабола: v = sqrt(2*mu/r) в перицентре
+	parabola := orbitState(7000000, 0, math.Sqrt(2*mu/7000000))
+
+	tests := []struct {
+		name        string
+		state       protocol.RocketState
+		toPeriapsis float64
+	}{
+		{name: "гипербола до перицентра", state: incoming, toPeriapsis: 748.49},
+		{name: "гипербола после перицентра", state: outgoing, toPeriapsis: -1},
+		{name: "парабола в перицентре", state: parabola, toPeriapsis: 0},
+		{name: "вертикальный полет", state: orbitState(7000000, 100, 0), toPeriapsis: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orbit, err := predictOrbit(tt.state, earth)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, value := range map[string]float64{
+				"период": orbit.Period, "до апоцентра": orbit.TimeToApoapsis, "до перицентра": orbit.TimeToPeriapsis,
+			} {
+				if math.IsNaN(value) || math.IsInf(value, 0) {
+					t.Errorf("%s: %v", name, value)
+				}
+			}
+			if orbit.IsClosed {
+				t.Error("разомкнутая орбита помечена замкнутой")
+			}
+			if orbit.Period != -1 && !orbit.IsClosed {
+				t.Errorf("период %v у разомкнутой орбиты, ожидалось -1", orbit.Period)
+			}
+			if math.Abs(orbit.TimeToPeriapsis-tt.toPeriapsis) > 0.5 {
+				t.Errorf("до перицентра %.2f с, ожидалось %.2f", orbit.TimeToPeriapsis, tt.toPeriapsis)
+			}
+		})
+	}
+}
+
+// stateFromElements - состояние на орбите с перицентром rp, эксцентриситетом e,
+// наклонением i, долготой узла raan, аргументом перицентра w и истинной
+// аномалией nu (углы в градусах)
+func stateFromElements(rp, e, i, raan, w, nu float64) protocol.RocketState {
+	mu := protocol.GConstant * EarthDefault().Mass
+	rad := math.Pi / 180.0
+	p := rp * (1 + e)
+	r := p / (1 + e*math.Cos(nu*rad))
+	position := [3]float64{r * math.Cos(nu*rad), r * math.Sin(nu*rad), 0}
+	velocity := [3]float64{-math.Sqrt(mu/p) * math.Sin(nu*rad), math.Sqrt(mu/p) * (e + math.Cos(nu*rad)), 0}
+
+	// Перифокальная система -> экваториальная: Rz(raan) * Rx(i) * Rz(w)
+	rotate := func(v [3]float64) protocol.Vector3 {
+		cw, sw := math.Cos(w*rad), math.Sin(w*rad)
+		x, y := cw*v[0]-sw*v[1], sw*v[0]+cw*v[1]
+		ci, si := math.Cos(i*rad), math.Sin(i*rad)
+		y, z := ci*y, si*y
+		co, so := math.Cos(raan*rad), math.Sin(raan*rad)
+		return protocol.Vector3{X: co*x - so*y, Y: so*x + co*y, Z: z}
+	}
+	state := protocol.RocketState{Position: rotate(position), Velocity: rotate(velocity)}
+	state.Speed = math.Sqrt(dot(state.Velocity, state.Velocity))
+	state.Altitude = r - EarthDefault().Radius
+	return state
+}
+
+func TestPredictOrbitOrientation(t *testing.T) {
+	earth := EarthDefault()
+	tests := []struct {
+		name                 string
+		state                protocol.RocketState
+		inclination, raan, w float64
+		equatorial, circular bool
+	}{
+		{
+			name:        "НОО как у МКС",
+			state:       stateFromElements(earth.Radius+400000, 0.001, 51.6, 120, 45, 30),
+			inclination: 51.6, raan: 120, w: 45,
+		},
+		{
+			name:        "ГПО с перицентром под экватором",
+			state:       stateFromElements(earth.Radius+200000, 0.730299, 28.5, 10, 270, 200),
+			inclination: 28.5, raan: 10, w: 270,
+		},
+		{
+			name:        "солнечно-синхронная ретроградная",
+			state:       stateFromElements(earth.Radius+700000, 0.05, 98, 300, 200, 350),
+			inclination: 98, raan: 300, w: 200,
+		},
+		{
+			name:        "экваториальная",
+			state:       stateFromElements(earth.Radius+300000, 0.2, 0, 0, 135, 10),
+			inclination: 0, raan: 0, w: 135, equatorial: true,
+		},
+		{
+			name:        "экваториальная ретроградная",
+			state:       stateFromElements(earth.Radius+300000, 0.2, 180, 0, 60, 10),
+			inclination: 180, raan: 0, w: 60, equatorial: true,
+		},
+		{
+			name:        "круговая наклонная",
+			state:       stateFromElements(earth.Radius+400000, 0, 63.4, 75, 0, 40),
+			inclination: 63.4, raan: 75, w: 0, circular: true,
+		},
+		{
+			name:        "круговая экваториальная",
+			state:       stateFromElements(earth.Radius+400000, 0, 0, 0, 0, 40),
+			inclination: 0, raan: 0, w: 0, equatorial: true, circular: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orbit, err := predictOrbit(tt.state, earth)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if orbit.Equatorial != tt.equatorial || orbit.Circular != tt.circular {
+				t.Errorf("экваториальная %v, круговая %v; ожидалось %v, %v",
+					orbit.Equatorial, orbit.Circular, tt.equatorial, tt.circular)
+			}
+			check := func(name string, got, want float64) {
+				// 0 и 360 - один угол
+				if diff := math.Mod(math.Abs(got-want), 360); math.Min(diff, 360-diff) > 1e-3 {
+					t.Errorf("%s %.4f°, ожидалось %.4f°", name, got, want)
+				}
+			}
+			check("наклонение", orbit.Inclination, tt.inclination)
+			check("долгота узла", orbit.LongitudeOfAscendingNode, tt.raan)
+			check("аргумент перицентра", orbit.ArgumentOfPeriapsis, tt.w)
+		})
+	}
+}
+
+func TestAtmosphere(t *testing.T) {
+	earth := EarthDefault()
+	mars := MarsDefault()
+	tests := []struct {
+		name     string
+		planet   PlanetConfig
+		altitude float64
+		density  float64
+		pressure float64
+	}{
+		{name: "уровень моря", planet: earth, altitude: 0, density: 1.225, pressure: 101325},
+		{name: "ниже поверхности", planet: earth, altitude: -50, density: 1.225, pressure: 101325},
+		// exp(-1) = 0.367879
+		{name: "масштабная высота", planet: earth, altitude: 8500, density: 0.450652, pressure: 37275.4},
+		{name: "граница атмосферы", planet: earth, altitude: 100000, density: 0, pressure: 0},
+		{name: "выше атмосферы", planet: earth, altitude: 400000, density: 0, pressure: 0},
+		{name: "Марс у поверхности", planet: mars, altitude: 0, density: 1.225 * mars.SurfacePressure, pressure: 101325 * mars.SurfacePressure},
+		{name: "Луна", planet: MoonDefault(), altitude: 0, density: 0, pressure: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			density := atmosphericDensity(tt.planet, tt.altitude)
+			pressure := atmosphericPressure(tt.planet, tt.altitude)
+			if tt.density == 0 && density != 0 || math.Abs(density-tt.density) > 1e-6 {
+				t.Errorf("плотность %.6f кг/м3, ожидалось %.6f", density, tt.density)
+			}
+			if tt.pressure == 0 && pressure != 0 || math.Abs(pressure-tt.pressure) > 0.1 {
+				t.Errorf("давление %.1f Па, ожидалось %.1f", pressure, tt.pressure)
+			}
+		})
+	}
+}
+
+func TestDynamicPressure(t *testing.T) {
+	earth := EarthDefault()
+	earth.RotationPeriod = 0
+
+	// 300 м/с на высоте 10 км: q = 0.5 * 1.225 * exp(-10/8.5) * 300^2
+	state := protocol.RocketState{
+		Position: earth.Position(0, 0, 10000),
+		Velocity: protocol.Vector3{Z: 300},
+		Altitude: 10000,
+	}
+	if q, want := dynamicPressure(earth, state), 16998.6; math.Abs(q-want) > 0.1 {
+		t.Errorf("скоростной напор %.1f Па, ожидалось %.1f", q, want)
+	}
+
+	state.Position = earth.Position(0, 0, 150000)
+	state.Altitude = 150000
+	if q := dynamicPressure(earth, state); q != 0 {
+		t.Errorf("скоростной напор %v Па выше атмосферы, ожидалось 0", q)
+	}
+}
+
+func TestGravityTurnPitch(t *testing.T) {
+	tests := []struct {
+		name   string
+		planet PlanetConfig
+		target float64 // Высота орбиты, м
+	}{
+		{name: "Земля, 200 км", planet: EarthDefault(), target: 200000},
+		{name: "Земля, 50 км", planet: EarthDefault(), target: 50000},
+		{name: "Марс, 300 км", planet: MarsDefault(), target: 300000},
+		{name: "Луна, 100 км", planet: MoonDefault(), target: 100000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			turn := GravityTurnForOrbit(tt.planet, tt.target)
+			start, end := turn.TurnStartAlt, turn.TurnEndAlt
+			if start >= end {
+				t.Fatalf("разворот с %.0f м до %.0f м", start, end)
+			}
+
+			if pitch := turn.Pitch(start - 1); pitch != 0 {
+				t.Errorf("тангаж %g° до начала разворота, ожидался 0°", pitch)
+			}
+			if pitch := turn.Pitch(start); pitch != 0 {
+				t.Errorf("тангаж %g° в начале разворота, ожидался 0°", pitch)
+			}
+			if pitch := turn.Pitch(end); pitch != 90 {
+				t.Errorf("тангаж %g° в конце разворота, ожидался 90°", pitch)
+			}
+			if pitch := turn.Pitch(tt.target); pitch != 90 {
+				t.Errorf("тангаж %g° на высоте орбиты, ожидался 90°", pitch)
+			}
+			// Профиль непрерывен у конца: за метр до него почти горизонт
+			if pitch := turn.Pitch(end - 1); pitch >= 90 || pitch < 89.99 {
+				t.Errorf("тангаж %g° за метр до конца разворота", pitch)
+			}
+
+			prev := 0.0
+			for i := 0; i <= 100; i++ {
+				altitude := start + (end-start)*float64(i)/100
+				pitch := turn.Pitch(altitude)
+				if pitch < prev || pitch > 90 {
+					t.Fatalf("тангаж %g° на %.0f м после %g°", pitch, altitude, prev)
+				}
+				prev = pitch
+			}
+		})
+	}
+
+	// Без AutoPitch ракета идет вертикально на любой высоте
+	turn := GravityTurnForOrbit(EarthDefault(), 200000)
+	turn.AutoPitch = false
+	if pitch := turn.Pitch(turn.TurnEndAlt); pitch != 0 {
+		t.Errorf("тангаж %g° без AutoPitch, ожидался 0°", pitch)
+	}
+}
@@ -1,3 +1,5 @@
+//go:build cgo && !nocgo
+
 package physics
 
 /*
@@ -8,72 +10,40 @@ package physics
 */
 import "C"
 import (
-	"cosmodrom/client/protocol"
+	"cosmodrom/client/logging"
+	"cosmodrom/protocol"
 	"math"
+	"runtime"
+	"sync"
 	"unsafe"
 )
 
-type PlanetConfig struct {
-	Radius           float64 // Радиус планеты (м)
-	Mass             float64 // Масса планеты (кг)
-	AtmosphereHeight float64 // Высота атмосферы (м)
-	SurfacePressure  float64 // Давление на поверхности (1.0 для Земли)
-	ScaleHeight      float64 // Масштабная высота атмосферы (м)
-}
-
-type GravityTurnConfig struct {
-	TargetAltitude float64 // Целевая высота орбиты (м)
-	TurnStartAlt   float64 // Высота начала поворота (м)
-	TurnEndAlt     float64 // Высота окончания поворота (м)
-	AutoPitch      bool    // Включен ли автоматический pitch
-}
-
-type OrbitPrediction struct {
-	Apoapsis         float64 // Апоцентр (м)
-	Periapsis        float64 // Перицентр (м)
-	Eccentricity     float64 // Эксцентриситет
-	OrbitalVelocity  float64 // Текущая скорость
-	RequiredVelocity float64 // Нужная скорость для круговой орбиты
-	IsStable         bool    // Стабильна ли орбита
-}
-
+// RocketPhysics - состояние ракеты в движке на C. Методы можно вызывать из
+// разных горутин; после Close они возвращают ErrFreed.
 type RocketPhysics struct {
-	state    *C.RocketState
+	mu       sync.Mutex     // Защищает все поля: движок не потокобезопасен
+	name     string         // Название ракеты для журнала финализатора
+	state    *C.RocketState // nil после Close
 	config   C.RocketConfig
 	planet   PlanetConfig
+	cPlanet  C.PlanetConfig // Копия planet для движка
 	gtConfig GravityTurnConfig
-}
+	strict   bool   // Ошибка вместо приведения команды к диапазону (SetStrictCommands)
+	events   events // Подписки OnEvent
 
-func EarthDefault() PlanetConfig {
-	return PlanetConfig{
-		Radius:           6371000.0,
-		Mass:             5.972e24,
-		AtmosphereHeight: 100000.0,
-		SurfacePressure:  1.0,
-		ScaleHeight:      8500.0,
-	}
-}
+	// Буфер дросселей для rocket_update: выделяется один раз и
+	// переиспользуется, пока число двигателей в команде не изменится
+	throttles     *C.double
+	throttleCount int
 
-func GravityTurnForOrbit(planet PlanetConfig, targetOrbitAltitude float64) GravityTurnConfig {
-	config := GravityTurnConfig{
-		TargetAltitude: targetOrbitAltitude,
-		AutoPitch:      true,
-	}
-
-	config.TurnStartAlt = targetOrbitAltitude * 0.01
-	if config.TurnStartAlt < 1000.0 {
-		config.TurnStartAlt = 1000.0
-	}
-
-	config.TurnEndAlt = targetOrbitAltitude * 0.7
-
-	if config.TurnEndAlt < planet.AtmosphereHeight*0.5 {
-		config.TurnEndAlt = planet.AtmosphereHeight * 0.5
-	}
-
-	return config
+	propulsion  // Двигатели и дроссели в Go для ThrustToWeight и других оценок
+	parachute   parachute
+	orientation orientation // Ориентация с ограничением скорости поворота
 }
 
+// throttleAllocations считает выделения буфера дросселей (для тестов)
+var throttleAllocations int
+
 func NewRocketPhysics(config *protocol.RocketConfig, initialPos protocol.Vector3) (*RocketPhysics, error) {
 	cConfig := C.RocketConfig{
 		mass_empty:       C.double(config.MassEmpty),
@@ -123,40 +93,259 @@ func NewRocketPhysics(config *protocol.RocketConfig, initialPos protocol.Vector3
 		if cConfig.engines != nil {
 			C.free(unsafe.Pointer(cConfig.engines))
 		}
-		return nil, &PhysicsError{Message: "не удалось инициализировать физический движок"}
+		return nil, &PhysicsError{Kind: ErrorKindUnavailable, Message: "не удалось инициализировать физический движок"}
 	}
 
-	return &RocketPhysics{
+	p := &RocketPhysics{
+		name:   config.Name,
 		state:  state,
 		config: cConfig,
-	}, nil
+	}
+	p.SetPlanet(EarthDefault())
+	p.ensureThrottles(len(config.Engines))
+	p.fuelType = config.FuelType
+	p.setEngines(config.Engines)
+	p.parachute.setConfig(config.Parachute)
+	runtime.SetFinalizer(p, (*RocketPhysics).finalize)
+	return p, nil
 }
 
-func (p *RocketPhysics) Update(command *protocol.ControlCommand, deltaTime float64) {
+// ensureThrottles держит буфер дросселей на count двигателей
+func (p *RocketPhysics) ensureThrottles(count int) {
+	if count == p.throttleCount && (p.throttles != nil || count == 0) {
+		return
+	}
+	if p.throttles != nil {
+		C.free(unsafe.Pointer(p.throttles))
+		p.throttles = nil
+	}
+	p.throttleCount = count
+	if count > 0 {
+		p.throttles = (*C.double)(C.malloc(C.size_t(count) * C.size_t(unsafe.Sizeof(C.double(0)))))
+		throttleAllocations++
+	}
+}
+
+func (p *RocketPhysics) Update(command *protocol.ControlCommand, deltaTime float64) error {
+	fired, err := p.step(command, deltaTime)
+	fired.dispatch()
+	return err
+}
+
+func (p *RocketPhysics) step(command *protocol.ControlCommand, deltaTime float64) (firedEvents, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == nil {
+		return firedEvents{}, ErrFreed
+	}
+	if err := checkCommand(command, len(p.engines), p.strict); err != nil {
+		return firedEvents{}, err
+	}
+
+	cCommand := p.cCommand(command)
+	p.deployParachute(command)
+	p.slew(&cCommand, command, deltaTime)
+	C.rocket_update_with_planet(p.state, &p.config, &cCommand, &p.cPlanet, C.double(deltaTime))
+	p.brakeParachute(deltaTime)
+	if !p.events.watching() {
+		return firedEvents{}, nil
+	}
+	return p.events.observe(p.getState(), p.planet), nil
+}
+
+// RunSteps делает до n шагов Update с одной командой за один вызов движка:
+// переход в C стоит дороже шага физики. Останавливается раньше, если ракета
+// приземлилась, разбилась или выработала топливо. Возвращает состояние после
+// последнего шага и число сделанных шагов. События OnEvent ищутся между
+// состояниями до и после всей серии шагов.
+func (p *RocketPhysics) RunSteps(command *protocol.ControlCommand, deltaTime float64, n int) (protocol.RocketState, int, error) {
+	fired, done, err := p.runSteps(command, deltaTime, n)
+	fired.dispatch()
+	return fired.state, done, err
+}
+
+func (p *RocketPhysics) runSteps(command *protocol.ControlCommand, deltaTime float64, n int) (firedEvents, int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == nil {
+		return firedEvents{}, 0, ErrFreed
+	}
+	if err := checkCommand(command, len(p.engines), p.strict); err != nil {
+		return firedEvents{state: p.getState()}, 0, err
+	}
+	if n <= 0 {
+		return firedEvents{state: p.getState()}, 0, nil
+	}
+
+	cCommand := p.cCommand(command)
+	p.deployParachute(command)
+	var done int
+	if p.parachute.deployed || !p.orientation.settled(command) {
+		// Купол тормозит и ракета поворачивается на каждом шаге: серия шагов
+		// идет из Go
+		for done < n && !bool(p.state.landed) && !bool(p.state.crashed) {
+			hadFuel := p.state.fuel_remaining > 0
+			p.slew(&cCommand, command, deltaTime)
+			C.rocket_update_with_planet(p.state, &p.config, &cCommand, &p.cPlanet, C.double(deltaTime))
+			p.brakeParachute(deltaTime)
+			done++
+			if hadFuel && p.state.fuel_remaining <= 0 {
+				break
+			}
+		}
+	} else {
+		p.slew(&cCommand, command, deltaTime)
+		done = int(C.rocket_update_n(p.state, &p.config, &cCommand, &p.cPlanet, C.double(deltaTime), C.int(n)))
+	}
+	state := p.getState()
+	return p.events.observe(state, p.planet), done, checkState(state, float64(p.config.mass_fuel_max))
+}
+
+// slew поворачивает ракету к углам command за шаг и передает движку
+// углы, которых она достигла
+func (p *RocketPhysics) slew(cCommand *C.ControlCommand, command *protocol.ControlCommand, deltaTime float64) {
+	if p.state.landed || p.state.crashed {
+		return
+	}
+	p.orientation.slew(command, deltaTime)
+	cCommand.pitch = C.double(p.orientation.pitch)
+	cCommand.yaw = C.double(p.orientation.yaw)
+	cCommand.roll = C.double(p.orientation.roll)
+}
+
+// SetSlewRate ограничивает скорость поворота ракеты degreesPerSecond
+// градусами в секунду по каждому углу: тяга направлена по достигнутой
+// ориентации (Orientation в состоянии), а не по команде. 0 - поворот
+// мгновенный, как без ограничения.
+func (p *RocketPhysics) SetSlewRate(degreesPerSecond float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.orientation.setRate(degreesPerSecond)
+}
+
+// deployParachute раскрывает парашют по команде, пока ракета еще в полете
+func (p *RocketPhysics) deployParachute(command *protocol.ControlCommand) {
+	if command.DeployParachute && !p.parachute.deployed && !p.parachute.failed {
+		p.parachute.deploy(p.planet, p.getState())
+	}
+}
+
+// brakeParachute - торможение раскрытым куполом после шага движка
+func (p *RocketPhysics) brakeParachute(deltaTime float64) {
+	if !p.parachute.deployed {
+		return
+	}
+	state := p.getState()
+	if p.parachute.brake(p.planet, &state, deltaTime) {
+		p.state.velocity = cVector(state.Velocity)
+		p.state.speed = C.double(state.Speed)
+	}
+}
+
+// OnEvent подписывает fn на событие kind. Update и RunSteps сравнивают
+// состояние после шага с предыдущим и вызывают fn один раз на каждый переход,
+// с состоянием после шага, уже отпустив мьютекс: из обработчика можно
+// вызывать методы физики. Close снимает все подписки.
+func (p *RocketPhysics) OnEvent(kind EventKind, fn func(protocol.RocketState)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state != nil {
+		p.events.on(kind, fn)
+	}
+}
+
+// cCommand переводит проверенную checkCommand команду для движка: дроссели
+// приводятся к 0-1 и копируются в буфер throttles, углы - к -180..180
+func (p *RocketPhysics) cCommand(command *protocol.ControlCommand) C.ControlCommand {
 	cCommand := C.ControlCommand{
 		engine_count: C.uint32_t(len(command.EngineThrottle)),
-		pitch:        C.double(command.Pitch),
-		yaw:          C.double(command.Yaw),
-		roll:         C.double(command.Roll),
+		pitch:        C.double(normalizeAngle(command.Pitch)),
+		yaw:          C.double(normalizeAngle(command.Yaw)),
+		roll:         C.double(normalizeAngle(command.Roll)),
 	}
 
 	if len(command.EngineThrottle) > 0 {
-		cCommand.engine_throttle = (*C.double)(C.malloc(C.size_t(len(command.EngineThrottle)) * C.size_t(unsafe.Sizeof(C.double(0)))))
-		throttles := (*[1 << 30]C.double)(unsafe.Pointer(cCommand.engine_throttle))[:len(command.EngineThrottle):len(command.EngineThrottle)]
-
+		p.ensureThrottles(len(command.EngineThrottle))
+		throttles := unsafe.Slice(p.throttles, p.throttleCount)
 		for i, throttle := range command.EngineThrottle {
-			throttles[i] = C.double(throttle)
+			throttles[i] = C.double(clampThrottle(throttle))
+		}
+		cCommand.engine_throttle = p.throttles
+	}
+	p.setThrottles(command.EngineThrottle)
+	return cCommand
+}
+
+// SetStrictCommands включает строгую проверку команд: дроссели вне 0-1 и
+// углы вне -180..180 дают ошибку Update вместо приведения к диапазону
+func (p *RocketPhysics) SetStrictCommands(strict bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.strict = strict
+}
+
+// SetStage меняет сухую массу и двигатели посреди полета, например при
+// отделении ступени. Оставшееся топливо, позиция и скорость сохраняются.
+func (p *RocketPhysics) SetStage(massEmpty float64, engines []protocol.Engine) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == nil {
+		return ErrFreed
+	}
+	p.setStage(massEmpty, engines)
+	return nil
+}
+
+// JettisonFuel сбрасывает mass кг топлива, например вместе со ступенью,
+// отделенной до выработки. Топлива не становится меньше нуля.
+func (p *RocketPhysics) JettisonFuel(mass float64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == nil {
+		return ErrFreed
+	}
+	fuel := math.Max(float64(p.state.fuel_remaining)-math.Max(mass, 0), 0)
+	p.state.fuel_remaining = C.double(fuel)
+	p.state.mass_current = p.config.mass_empty + p.state.fuel_remaining
+	return nil
+}
+
+func (p *RocketPhysics) setStage(massEmpty float64, engines []protocol.Engine) {
+	if p.config.engines != nil {
+		C.free(unsafe.Pointer(p.config.engines))
+		p.config.engines = nil
+	}
+
+	p.config.engine_count = C.uint32_t(len(engines))
+	if len(engines) > 0 {
+		p.config.engines = (*C.Engine)(C.malloc(C.size_t(len(engines)) * C.size_t(unsafe.Sizeof(C.Engine{}))))
+		cEngines := (*[1 << 30]C.Engine)(unsafe.Pointer(p.config.engines))[:len(engines):len(engines)]
+
+		for i, engine := range engines {
+			cEngines[i] = C.Engine{
+				thrust:           C.double(engine.Thrust),
+				fuel_consumption: C.double(engine.FuelConsumption),
+				is_active:        C.bool(engine.IsActive),
+			}
 		}
 	}
 
-	C.rocket_update(p.state, &p.config, &cCommand, C.double(deltaTime))
+	p.config.mass_empty = C.double(massEmpty)
+	p.state.mass_current = p.config.mass_empty + p.state.fuel_remaining
+	p.setEngines(engines)
+}
 
-	if cCommand.engine_throttle != nil {
-		C.free(unsafe.Pointer(cCommand.engine_throttle))
+func (p *RocketPhysics) GetState() (protocol.RocketState, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == nil {
+		return protocol.RocketState{}, ErrFreed
 	}
+	state := p.getState()
+	return state, checkState(state, float64(p.config.mass_fuel_max))
 }
 
-func (p *RocketPhysics) GetState() protocol.RocketState {
+func (p *RocketPhysics) getState() protocol.RocketState {
 	state := protocol.RocketState{
 		Position: protocol.Vector3{
 			X: float64(p.state.position.x),
@@ -182,11 +371,44 @@ func (p *RocketPhysics) GetState() protocol.RocketState {
 		Crashed:       bool(p.state.crashed),
 		Time:          float64(p.state.time),
 	}
+	p.parachute.report(&state)
+	p.orientation.report(&state)
 
 	return state
 }
 
+// Close освобождает память движка. Повторный вызов ничего не делает.
+// Незакрытую физику освободит сборщик мусора, но с предупреждением в журнале.
+func (p *RocketPhysics) Close() error {
+	runtime.SetFinalizer(p, nil)
+	p.release()
+	return nil
+}
+
+// Free - синоним Close
 func (p *RocketPhysics) Free() {
+	p.Close()
+}
+
+// finalize - финализатор для физики, которую забыли закрыть
+func (p *RocketPhysics) finalize() {
+	if p.release() {
+		finalizerHook(p.name)
+	}
+}
+
+// finalizerHook вызывается, когда память освободил финализатор, а не Close.
+// Тесты подменяют его, чтобы увидеть срабатывание.
+var finalizerHook = func(name string) {
+	logging.Default().Warn("physics_leaked", logging.F("name", name))
+}
+
+// release освобождает память C и сообщает, была ли она еще занята
+func (p *RocketPhysics) release() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	held := p.state != nil
+	p.events.clear()
 	if p.state != nil {
 		C.rocket_free(p.state)
 		p.state = nil
@@ -195,86 +417,324 @@ func (p *RocketPhysics) Free() {
 		C.free(unsafe.Pointer(p.config.engines))
 		p.config.engines = nil
 	}
+	if p.throttles != nil {
+		C.free(unsafe.Pointer(p.throttles))
+		p.throttles = nil
+		p.throttleCount = 0
+	}
+	return held
 }
 
-func (p *RocketPhysics) SetPlanet(planet PlanetConfig) {
+// SetPlanet задает планету: ее гравитацию, атмосферу и радиус поверхности
+// для движка и прогноза орбиты. Вращение влияет на сопротивление (атмосфера
+// вращается вместе с планетой) и на проверку посадки; начальную скорость
+// вращения нужно задать отдельно через SetInitialVelocity. По умолчанию -
+// EarthDefault.
+func (p *RocketPhysics) SetPlanet(planet PlanetConfig) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == nil {
+		return ErrFreed
+	}
+	p.setPlanet(planet)
+	return nil
+}
+
+func (p *RocketPhysics) setPlanet(planet PlanetConfig) {
 	p.planet = planet
+	p.cPlanet = C.planet_create(C.double(planet.Radius), C.double(planet.Mass),
+		C.double(planet.AtmosphereHeight), C.double(planet.SurfacePressure), C.double(planet.ScaleHeight))
+	C.rocket_set_rotation(p.state, C.double(planet.RotationRate()))
+	// rocket_init считает высоту от радиуса Земли
+	p.state.altitude = C.vector_magnitude(&p.state.position) - C.double(planet.Radius)
 }
 
-func (p *RocketPhysics) SetGravityTurn(gt GravityTurnConfig) {
-	p.gtConfig = gt
+// Snapshot сохраняет полное состояние движка, планету, гравитационный
+// разворот и текущую ступень; продолжить полет можно через Restore или
+// NewRocketPhysicsFromSnapshot
+func (p *RocketPhysics) Snapshot() ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == nil {
+		return nil, ErrFreed
+	}
+	config := protocol.RocketConfig{
+		Name:            p.name,
+		MassEmpty:       float64(p.config.mass_empty),
+		MassFuel:        float64(p.state.fuel_remaining),
+		MassFuelMax:     float64(p.config.mass_fuel_max),
+		Engines:         append([]protocol.Engine(nil), p.engines...),
+		DragCoefficient: float64(p.config.drag_coefficient),
+		CrossSection:    float64(p.config.cross_section),
+		Parachute:       p.parachute.config,
+	}
+	switch p.config.fuel_type {
+	case C.FUEL_TYPE_KEROSENE:
+		config.FuelType = protocol.FuelTypeKerosene
+	case C.FUEL_TYPE_LIQUID_H2:
+		config.FuelType = protocol.FuelTypeLiquidH2
+	case C.FUEL_TYPE_SOLID:
+		config.FuelType = protocol.FuelTypeSolid
+	}
+	return encodeSnapshot(Snapshot{
+		State:       p.getState(),
+		Planet:      p.planet,
+		GravityTurn: p.gtConfig,
+		Config:      config,
+		Throttles:   p.command,
+	})
 }
 
-func (p *RocketPhysics) CalculateOptimalPitch() float64 {
-	if !p.gtConfig.AutoPitch {
-		return 0.0
+// Restore возвращает движок в состояние из Snapshot: после него Update
+// продолжает траекторию так же, как продолжила бы исходная физика
+func (p *RocketPhysics) Restore(data []byte) error {
+	s, err := DecodeSnapshot(data)
+	if err != nil {
+		return err
 	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == nil {
+		return ErrFreed
+	}
+	p.config.drag_coefficient = C.double(s.Config.DragCoefficient)
+	p.config.cross_section = C.double(s.Config.CrossSection)
+	p.config.mass_fuel_max = C.double(s.Config.MassFuelMax)
+	p.setPlanet(s.Planet)
+	p.state.fuel_remaining = C.double(s.State.FuelRemaining)
+	p.setStage(s.Config.MassEmpty, s.Config.Engines)
+	p.ensureThrottles(len(s.Config.Engines))
+	p.fuelType = s.Config.FuelType
+	p.parachute.setConfig(s.Config.Parachute)
+	p.parachute.restore(s.State)
+	p.orientation.restore(s.State)
+
+	p.state.position = cVector(s.State.Position)
+	p.state.velocity = cVector(s.State.Velocity)
+	p.state.acceleration = cVector(s.State.Acceleration)
+	p.state.altitude = C.double(s.State.Altitude)
+	p.state.speed = C.double(s.State.Speed)
+	p.state.mass_current = C.double(s.State.MassCurrent)
+	p.state.in_orbit = C.bool(s.State.InOrbit)
+	p.state.landed = C.bool(s.State.Landed)
+	p.state.crashed = C.bool(s.State.Crashed)
+	p.state.time = C.double(s.State.Time)
+	p.gtConfig = s.GravityTurn
+	p.setThrottles(s.Throttles)
+	p.events.reset()
+	return nil
+}
 
-	alt := float64(p.state.altitude)
-	start := p.gtConfig.TurnStartAlt
-	end := p.gtConfig.TurnEndAlt
+// NewRocketPhysicsFromSnapshot создает движок сразу в состоянии из Snapshot
+func NewRocketPhysicsFromSnapshot(data []byte) (*RocketPhysics, error) {
+	s, err := DecodeSnapshot(data)
+	if err != nil {
+		return nil, err
+	}
+	p, err := NewRocketPhysics(&s.Config, s.State.Position)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Restore(data); err != nil {
+		p.Close()
+		return nil, err
+	}
+	return p, nil
+}
 
-	if alt < start {
-		return 0.0
+func cVector(v protocol.Vector3) C.Vector3 {
+	return C.Vector3{x: C.double(v.X), y: C.double(v.Y), z: C.double(v.Z)}
+}
+
+// SetInitialVelocity задает скорость до первого Update
+func (p *RocketPhysics) SetInitialVelocity(velocity protocol.Vector3) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == nil {
+		return ErrFreed
 	}
 
-	if alt >= end {
-		return 90.0
+	p.state.velocity = C.Vector3{
+		x: C.double(velocity.X),
+		y: C.double(velocity.Y),
+		z: C.double(velocity.Z),
 	}
+	p.state.speed = C.vector_magnitude(&p.state.velocity)
+	return nil
+}
 
-	progress := (alt - start) / (end - start)
-	smoothProgress := math.Sin(progress * math.Pi / 2.0)
+// Airspeed - скорость относительно вращающейся вместе с планетой атмосферы (м/с)
+func (p *RocketPhysics) Airspeed() (float64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == nil {
+		return 0, ErrFreed
+	}
+	return airspeed(p.planet, p.getState()), nil
+}
 
-	return smoothProgress * 90.0
+func (p *RocketPhysics) SetGravityTurn(gt GravityTurnConfig) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.gtConfig = gt
 }
 
-func (p *RocketPhysics) PredictOrbit() OrbitPrediction {
-	state := p.GetState()
+// AtmosphereDensity - плотность атмосферы планеты на высоте altitude (кг/м3),
+// по той же экспоненциальной модели, что и сопротивление в движке
+func (p *RocketPhysics) AtmosphereDensity(altitude float64) float64 {
+	p.mu.Lock()
+	planet := p.planet
+	p.mu.Unlock()
+	return atmosphericDensity(planet, altitude)
+}
 
-	r := math.Sqrt(state.Position.X*state.Position.X +
-		state.Position.Y*state.Position.Y +
-		state.Position.Z*state.Position.Z)
-	v := state.Speed
+// AtmospherePressure - давление атмосферы планеты на высоте altitude (Па)
+func (p *RocketPhysics) AtmospherePressure(altitude float64) float64 {
+	p.mu.Lock()
+	planet := p.planet
+	p.mu.Unlock()
+	return atmosphericPressure(planet, altitude)
+}
 
-	mu := 6.674e-11 * p.planet.Mass
-	specificEnergy := (v*v)/2.0 - mu/r
+// engineAtmosphereDensity - плотность по atmosphere_density из движка на C:
+// тесты сверяют с ней модель на Go
+func engineAtmosphereDensity(planet PlanetConfig, altitude float64) float64 {
+	cPlanet := C.planet_create(C.double(planet.Radius), C.double(planet.Mass),
+		C.double(planet.AtmosphereHeight), C.double(planet.SurfacePressure), C.double(planet.ScaleHeight))
+	return float64(C.atmosphere_density(&cPlanet, C.double(altitude)))
+}
 
-	hx := state.Position.Y*state.Velocity.Z - state.Position.Z*state.Velocity.Y
-	hy := state.Position.Z*state.Velocity.X - state.Position.X*state.Velocity.Z
-	hz := state.Position.X*state.Velocity.Y - state.Position.Y*state.Velocity.X
-	h := math.Sqrt(hx*hx + hy*hy + hz*hz)
+// DynamicPressure - скоростной напор q = ρv²/2 в текущем состоянии (Па)
+func (p *RocketPhysics) DynamicPressure() (float64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == nil {
+		return 0, ErrFreed
+	}
+	return dynamicPressure(p.planet, p.getState()), nil
+}
 
-	pred := OrbitPrediction{}
+func (p *RocketPhysics) CalculateOptimalPitch() (float64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == nil {
+		return 0, ErrFreed
+	}
+	return p.gtConfig.Pitch(float64(p.state.altitude)), nil
+}
 
-	var a float64
-	if math.Abs(specificEnergy) < 1e-10 {
-		a = math.Inf(1)
-		pred.Eccentricity = 1.0
-	} else {
-		a = -mu / (2.0 * specificEnergy)
+// PredictOrbit - кеплеровская орбита по текущему состоянию (см. predictOrbit)
+func (p *RocketPhysics) PredictOrbit() (OrbitPrediction, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == nil {
+		return noOrbit, ErrFreed
 	}
+	return predictOrbit(p.getState(), p.planet)
+}
 
-	if !math.IsInf(a, 1) {
-		eSq := 1.0 - (h*h)/(mu*a)
-		if eSq < 0 {
-			eSq = 0
-		}
-		pred.Eccentricity = math.Sqrt(eSq)
+// ThrustToWeight - тяговооруженность: тяга исправных двигателей при дросселях
+// последней команды к весу ракеты на текущей высоте
+func (p *RocketPhysics) ThrustToWeight() (float64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == nil {
+		return 0, ErrFreed
 	}
+	return p.thrustToWeight(p.getState(), p.planet), nil
+}
 
-	if pred.Eccentricity < 1.0 && a > 0 {
-		pred.Apoapsis = a*(1.0+pred.Eccentricity) - p.planet.Radius
-		pred.Periapsis = a*(1.0-pred.Eccentricity) - p.planet.Radius
-	} else {
-		pred.Apoapsis = -1
-		pred.Periapsis = state.Altitude
+// DeltaVRemaining - запас характеристической скорости (м/с) по формуле
+// Циолковского со скоростью истечения двигателей текущей ступени
+func (p *RocketPhysics) DeltaVRemaining() (float64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == nil {
+		return 0, ErrFreed
 	}
+	return p.deltaVRemaining(p.getState(), float64(p.config.mass_empty)), nil
+}
 
-	pred.OrbitalVelocity = v
-	pred.RequiredVelocity = math.Sqrt(mu / (p.planet.Radius + state.Altitude))
-	pred.IsStable = pred.Periapsis > p.planet.AtmosphereHeight && pred.Eccentricity < 1.0
+// PlanCircularization - импульс скругления в апоцентре до высоты targetAlt:
+// приращение скорости, время работы на полной тяге и время до включения.
+// Feasible в плане ложно, если на импульс не хватает DeltaVRemaining.
+func (p *RocketPhysics) PlanCircularization(targetAlt float64) (BurnPlan, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == nil {
+		return BurnPlan{}, ErrFreed
+	}
+	return p.planCircularization(p.getState(), p.planet, float64(p.config.mass_empty), targetAlt)
+}
 
-	return pred
+// PlanHohmann - переход Хомана между круговыми орбитами fromAlt и toAlt
+// вокруг планеты SetPlanet: оба импульса, суммарное приращение и время перелета
+func (p *RocketPhysics) PlanHohmann(fromAlt, toAlt float64) (HohmannPlan, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == nil {
+		return HohmannPlan{}, ErrFreed
+	}
+	return planHohmann(p.planet, fromAlt, toAlt)
+}
+
+// PredictImpact - точка и время падения при полете без тяги: гравитация и
+// сопротивление атмосферы (см. ImpactPrediction)
+func (p *RocketPhysics) PredictImpact() (ImpactPrediction, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == nil {
+		return ImpactPrediction{}, ErrFreed
+	}
+	drag := float64(p.config.drag_coefficient) * float64(p.config.cross_section)
+	return predictImpact(p.planet, p.getState(), drag)
+}
+
+// BurnTimeRemaining - время работы (с) до выработки топлива при дросселе
+// throttle на всех исправных двигателях; +Inf, если расхода нет
+func (p *RocketPhysics) BurnTimeRemaining(throttle float64) (float64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == nil {
+		return 0, ErrFreed
+	}
+	return p.burnTimeRemaining(float64(p.state.fuel_remaining), throttle), nil
+}
+
+// GroundTrack - точка под ракетой сейчас: широта, долгота с учетом вращения
+// планеты и высота
+func (p *RocketPhysics) GroundTrack() (GroundPoint, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == nil {
+		return GroundPoint{}, ErrFreed
+	}
+	return p.planet.GroundPoint(p.getState().Position, float64(p.state.time)), nil
+}
+
+// PredictGroundTrack - трасса на duration секунд вперед с шагом step, если
+// ракета пойдет по инерции (задача двух тел, без сопротивления). Первая
+// точка - текущая; трасса обрывается у поверхности.
+func (p *RocketPhysics) PredictGroundTrack(duration, step float64) ([]GroundPoint, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == nil {
+		return nil, ErrFreed
+	}
+	return predictGroundTrack(p.planet, p.getState(), duration, step)
+}
+
+// Propagate - позиции на duration секунд вперед с шагом step, если ракета
+// пойдет по инерции (задача двух тел, без тяги и сопротивления). Первая
+// позиция - текущая; у поверхности прогноз обрывается. Без экземпляра
+// физики - функция Propagate.
+func (p *RocketPhysics) Propagate(duration, step float64) ([]protocol.Vector3, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.state == nil {
+		return nil, ErrFreed
+	}
+	return propagate(p.planet, p.getState(), duration, step)
 }
 
 func SphericalToCartesian(latitude, longitude, altitude float64) protocol.Vector3 {
@@ -298,11 +758,3 @@ func CartesianToSpherical(pos protocol.Vector3) (latitude, longitude, altitude f
 
 	return float64(lat), float64(lon), float64(alt)
 }
-
-type PhysicsError struct {
-	Message string
-}
-
-func (e *PhysicsError) Error() string {
-	return "Physics error: " + e.Message
-}
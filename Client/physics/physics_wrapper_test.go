@@ -0,0 +1,306 @@
+//go:build cgo && !nocgo
+
+package physics
+
+import (
+	"errors"
+	"math"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"cosmodrom/protocol"
+)
+
+func testRocket(t testing.TB, engines int) *RocketPhysics {
+	t.Helper()
+	config := testConfig(engines)
+	planet := EarthDefault()
+	p, err := NewRocketPhysics(&config, planet.Position(45, 63, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.SetPlanet(planet)
+	return p
+}
+
+func mustState(t testing.TB, p *RocketPhysics) protocol.RocketState {
+	t.Helper()
+	state, err := p.GetState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return state
+}
+
+func TestPredictOrbitDefaultPlanet(t *testing.T) {
+	// Без SetPlanet прогноз считается для Земли, а не для нулевой массы
+	config := protocol.RocketConfig{
+		Name: "Test", MassEmpty: 1000, CrossSection: 1,
+		Engines: []protocol.Engine{{Thrust: 20000, IsActive: true}},
+	}
+	radius := EarthDefault().Radius + 400000
+	p, err := NewRocketPhysics(&config, protocol.Vector3{X: radius})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	p.SetInitialVelocity(protocol.Vector3{Y: math.Sqrt(protocol.GConstant * EarthDefault().Mass / radius)})
+
+	orbit, err := p.PredictOrbit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.IsNaN(orbit.Eccentricity) || orbit.Eccentricity > 1e-3 {
+		t.Errorf("эксцентриситет %v, ожидалась круговая орбита", orbit.Eccentricity)
+	}
+	if math.Abs(orbit.Apoapsis-400000) > 1000 || math.Abs(orbit.Periapsis-400000) > 1000 {
+		t.Errorf("апоцентр %.0f м, перицентр %.0f м, ожидалось 400 км", orbit.Apoapsis, orbit.Periapsis)
+	}
+	if !orbit.IsStable {
+		t.Error("круговая орбита 400 км не стабильна")
+	}
+}
+
+func TestPredictOrbitErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(p *RocketPhysics)
+	}{
+		{name: "нулевая планета", setup: func(p *RocketPhysics) { p.SetPlanet(PlanetConfig{}) }},
+		{name: "отрицательный радиус", setup: func(p *RocketPhysics) {
+			planet := EarthDefault()
+			planet.Radius = -1
+			p.SetPlanet(planet)
+		}},
+		{name: "NaN в скорости", setup: func(p *RocketPhysics) { p.SetInitialVelocity(protocol.Vector3{X: math.NaN()}) }},
+		{name: "Inf в скорости", setup: func(p *RocketPhysics) { p.SetInitialVelocity(protocol.Vector3{Z: math.Inf(1)}) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := testRocket(t, 1)
+			defer p.Close()
+			tt.setup(p)
+
+			orbit, err := p.PredictOrbit()
+			var physicsErr *PhysicsError
+			if !errors.As(err, &physicsErr) {
+				t.Fatalf("ошибка %v, ожидалась PhysicsError", err)
+			}
+			if orbit.Apoapsis != -1 {
+				t.Errorf("апоцентр %v при ошибке, ожидалось -1", orbit.Apoapsis)
+			}
+		})
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	p := testRocket(t, 2)
+	defer p.Close()
+
+	// Телеметрия и прогноз читаются из других горутин во время шагов физики
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if _, err := p.GetState(); err != nil {
+					t.Error(err)
+					return
+				}
+				p.PredictOrbit()
+				p.DynamicPressure()
+				p.CalculateOptimalPitch()
+				p.SetGravityTurn(GravityTurnForOrbit(EarthDefault(), 200000))
+			}
+		}()
+	}
+
+	command := throttleCommand(2, 1)
+	for i := 0; i < 2000; i++ {
+		if err := p.Update(command, 0.01); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(done)
+	wg.Wait()
+}
+
+func TestUseAfterClose(t *testing.T) {
+	p := testRocket(t, 1)
+	p.Close()
+	p.Close() // Повторный Close безопасен
+
+	calls := map[string]func() error{
+		"Update":             func() error { return p.Update(throttleCommand(1, 1), 0.01) },
+		"GetState":           func() error { _, err := p.GetState(); return err },
+		"SetStage":           func() error { return p.SetStage(1000, nil) },
+		"JettisonFuel":       func() error { return p.JettisonFuel(1) },
+		"SetPlanet":          func() error { return p.SetPlanet(EarthDefault()) },
+		"SetInitialVelocity": func() error { return p.SetInitialVelocity(protocol.Vector3{}) },
+		"Airspeed":           func() error { _, err := p.Airspeed(); return err },
+		"DynamicPressure":    func() error { _, err := p.DynamicPressure(); return err },
+		"CalculateOptimalPitch": func() error {
+			_, err := p.CalculateOptimalPitch()
+			return err
+		},
+		"PredictOrbit": func() error { _, err := p.PredictOrbit(); return err },
+	}
+	for name, call := range calls {
+		if err := call(); !errors.Is(err, ErrFreed) {
+			t.Errorf("%s после Close: ошибка %v, ожидалась ErrFreed", name, err)
+		}
+	}
+}
+
+func TestCloseDuringUpdates(t *testing.T) {
+	p := testRocket(t, 1)
+
+	// Close из другой горутины посреди полета: шаги после него получают ErrFreed
+	errs := make(chan error, 1)
+	go func() {
+		command := throttleCommand(1, 1)
+		for {
+			if err := p.Update(command, 0.01); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}()
+	time.Sleep(10 * time.Millisecond)
+	p.Close()
+	if err := <-errs; !errors.Is(err, ErrFreed) {
+		t.Errorf("ошибка %v, ожидалась ErrFreed", err)
+	}
+}
+
+func TestFinalizerReleasesLeakedPhysics(t *testing.T) {
+	leaked := make(chan string, 4)
+	hook := finalizerHook
+	finalizerHook = func(name string) { leaked <- name }
+	defer func() { finalizerHook = hook }()
+
+	// Закрытая физика финализатору не видна
+	closed := testRocket(t, 1)
+	closed.Close()
+
+	func() {
+		testRocket(t, 1) // Забыли Close
+	}()
+
+	deadline := time.After(5 * time.Second)
+	for {
+		runtime.GC()
+		select {
+		case name := <-leaked:
+			if name != "Test" {
+				t.Errorf("финализатор сообщил о ракете %q", name)
+			}
+			runtime.GC()
+			select {
+			case name := <-leaked:
+				t.Errorf("лишнее срабатывание финализатора: %q", name)
+			case <-time.After(100 * time.Millisecond):
+			}
+			return
+		case <-deadline:
+			t.Fatal("финализатор не сработал")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func throttleCommand(engines int, throttle float64) *protocol.ControlCommand {
+	command := &protocol.ControlCommand{EngineThrottle: make([]float64, engines)}
+	for i := range command.EngineThrottle {
+		command.EngineThrottle[i] = throttle
+	}
+	return command
+}
+
+func TestUpdateReusesThrottleBuffer(t *testing.T) {
+	p := testRocket(t, 2)
+	defer p.Close()
+
+	before := throttleAllocations
+	command := throttleCommand(2, 1)
+	for i := 0; i < 100; i++ {
+		p.Update(command, 0.01)
+	}
+	if got := throttleAllocations - before; got != 0 {
+		t.Errorf("%d выделений буфера за 100 шагов, ожидалось 0", got)
+	}
+	if mustState(t, p).FuelRemaining >= 400000 {
+		t.Error("двигатели не работали: топливо не расходуется")
+	}
+}
+
+func TestUpdateEngineCountMismatch(t *testing.T) {
+	p := testRocket(t, 2)
+	defer p.Close()
+
+	// Команда на три двигателя при двух в конфигурации отклоняется до
+	// движка: буфер не растет, шаг не делается
+	before := throttleAllocations
+	if err := p.Update(throttleCommand(3, 1), 0.01); err == nil {
+		t.Error("команда на 3 двигателя при 2 принята")
+	}
+	if got := throttleAllocations - before; got != 0 {
+		t.Errorf("%d выделений буфера для отклоненной команды", got)
+	}
+	if state := mustState(t, p); state.Time != 0 || state.FuelRemaining != 400000 {
+		t.Errorf("отклоненная команда сделала шаг: T+%g с, топливо %g кг", state.Time, state.FuelRemaining)
+	}
+
+	// После смены ступени на три двигателя буфер растет один раз
+	engines := append(append([]protocol.Engine(nil), p.engines...), p.engines[0])
+	if err := p.SetStage(20000, engines); err != nil {
+		t.Fatal(err)
+	}
+	p.Update(throttleCommand(3, 1), 0.01)
+	p.Update(throttleCommand(3, 1), 0.01)
+	if got := throttleAllocations - before; got != 1 {
+		t.Errorf("%d выделений буфера при смене числа двигателей, ожидалось 1", got)
+	}
+	if p.throttleCount != 3 {
+		t.Errorf("буфер на %d двигателей, ожидалось 3", p.throttleCount)
+	}
+	fuel := mustState(t, p).FuelRemaining
+	if want := 400000 - 2*3*2500*0.01; fuel < want-1e-6 || fuel > want+1e-6 {
+		t.Errorf("топливо %.3f кг, ожидалось %.3f: расход трех двигателей", fuel, want)
+	}
+}
+
+func BenchmarkUpdate(b *testing.B) {
+	p := testRocket(b, 4)
+	defer p.Close()
+	command := throttleCommand(4, 0.5)
+
+	before := throttleAllocations
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.Update(command, 0.01)
+	}
+	b.ReportMetric(float64(throttleAllocations-before)/float64(b.N), "cgo-allocs/op")
+}
+
+func TestAtmosphereMatchesEngine(t *testing.T) {
+	for _, planet := range []PlanetConfig{EarthDefault(), MarsDefault(), MoonDefault()} {
+		for _, altitude := range []float64{-10, 0, 1000, 8500, 42000, 99999, 100000, 250000} {
+			want := engineAtmosphereDensity(planet, altitude)
+			if got := atmosphericDensity(planet, altitude); got != want {
+				t.Errorf("высота %.0f м, поверхность %.3f: плотность %v, в движке %v", altitude, planet.SurfacePressure, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package physics
+
+import (
+	"fmt"
+	"math"
+
+	"cosmodrom/protocol"
+)
+
+// maxPropagationPoints ограничивает Propagate и PredictGroundTrack: при
+// большем числе точек нужно увеличить шаг
+const maxPropagationPoints = 10000
+
+// Propagate - позиции ракеты в состоянии state на duration секунд вперед с
+// шагом step, если она пойдет по инерции: задача двух тел, без тяги и
+// сопротивления. Первая позиция - текущая; суборбитальная траектория
+// обрывается на последней точке над поверхностью. Не требует cgo и
+// экземпляра физики: подходит для прогноза по телеметрии.
+func Propagate(state protocol.RocketState, planet PlanetConfig, duration, step float64) ([]protocol.Vector3, error) {
+	return propagate(planet, state, duration, step)
+}
+
+func propagate(planet PlanetConfig, state protocol.RocketState, duration, step float64) ([]protocol.Vector3, error) {
+	if !(step > 0) || !(duration >= 0) || math.IsInf(duration, 0) {
+		return nil, &PhysicsError{Kind: ErrorKindInvalid, Message: fmt.Sprintf("прогноз: нужны длительность >= 0 и шаг > 0 (%g с, %g с)", duration, step)}
+	}
+	if count := duration/step + 1; count > maxPropagationPoints {
+		return nil, &PhysicsError{Kind: ErrorKindInvalid, Message: fmt.Sprintf("прогноз: %.0f точек, больше %d - увеличьте шаг", count, maxPropagationPoints)}
+	}
+	if planet.Radius <= 0 || planet.Mass <= 0 {
+		return nil, &PhysicsError{Kind: ErrorKindInvalid, Message: "прогноз: у планеты должны быть положительные радиус и масса"}
+	}
+	if !state.Position.IsFinite() || !state.Velocity.IsFinite() {
+		return nil, &PhysicsError{Kind: ErrorKindNonFinite, Message: "прогноз: в состоянии ракеты NaN или Inf"}
+	}
+
+	mu := protocol.GConstant * planet.Mass
+	position, velocity := state.Position, state.Velocity
+	positions := []protocol.Vector3{position}
+	substeps := int(math.Ceil(step / maxPropagationStep))
+	dt := step / float64(substeps)
+	steps := int(math.Floor(duration/step + 1e-9))
+	for n := 1; n <= steps; n++ {
+		for i := 0; i < substeps; i++ {
+			position, velocity = twoBodyStep(position, velocity, mu, dt)
+		}
+		if position.Norm() <= planet.Radius {
+			break
+		}
+		positions = append(positions, position)
+	}
+	return positions, nil
+}
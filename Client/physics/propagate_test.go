@@ -0,0 +1,114 @@
+package physics
+
+import (
+	"math"
+	"testing"
+
+	"cosmodrom/protocol"
+)
+
+func TestPropagateCircularOrbit(t *testing.T) {
+	earth := EarthDefault()
+	state := circularOrbit(earth, 400000, 51.6)
+	r := earth.Radius + 400000
+	period := 2 * math.Pi * math.Sqrt(r*r*r/(protocol.GConstant*earth.Mass))
+
+	positions, err := Propagate(state, earth, period, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := int(period/30) + 1; len(positions) != want {
+		t.Fatalf("%d точек, ожидалось %d", len(positions), want)
+	}
+	if positions[0] != state.Position {
+		t.Errorf("первая точка %+v, ожидалась текущая позиция %+v", positions[0], state.Position)
+	}
+	for i, position := range positions {
+		if math.Abs(position.Norm()-r) > 1 {
+			t.Fatalf("точка %d: радиус %.1f м на круговой орбите %.1f м", i, position.Norm(), r)
+		}
+	}
+	// Через виток ракета возвращается в начальную точку
+	last := positions[len(positions)-1]
+	if d := last.Distance(state.Position); d > 30*state.Speed {
+		t.Errorf("через виток в %.0f км от начала", d/1000)
+	}
+}
+
+func TestPropagateEngine(t *testing.T) {
+	earth := EarthDefault()
+	state := circularOrbit(earth, 400000, 0)
+	for _, backend := range availableBackends() {
+		t.Run(string(backend), func(t *testing.T) {
+			config := testConfig(1)
+			p, err := NewEngine(backend, &config, state.Position)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer p.Close()
+			if err := p.SetInitialVelocity(state.Velocity); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := p.Propagate(3000, 60)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := Propagate(state, earth, 3000, 60)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("%d точек, без физики %d", len(got), len(want))
+			}
+			for i := range got {
+				if got[i].Distance(want[i]) > 1e-6 {
+					t.Fatalf("точка %d: %+v, без физики %+v", i, got[i], want[i])
+				}
+			}
+
+			p.Close()
+			if _, err := p.Propagate(3000, 60); err != ErrFreed {
+				t.Errorf("после Close ошибка %v, ожидалась ErrFreed", err)
+			}
+		})
+	}
+}
+
+func TestPropagateSuborbital(t *testing.T) {
+	earth := EarthDefault()
+	state := protocol.RocketState{Position: earth.Position(0, 0, 100000), Velocity: protocol.Vector3{Y: 1000}}
+	positions, err := Propagate(state, earth, 3600, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(positions) >= 361 {
+		t.Fatalf("%d точек: траектория не оборвалась у поверхности", len(positions))
+	}
+	for i, position := range positions {
+		if position.Norm() <= earth.Radius {
+			t.Fatalf("точка %d под поверхностью", i)
+		}
+	}
+}
+
+func TestPropagateErrors(t *testing.T) {
+	earth := EarthDefault()
+	state := circularOrbit(earth, 400000, 51.6)
+	tests := []struct {
+		name           string
+		duration, step float64
+	}{
+		{name: "нулевой шаг", duration: 600, step: 0},
+		{name: "отрицательная длительность", duration: -1, step: 10},
+		{name: "слишком много точек", duration: 1e6, step: 1},
+		{name: "бесконечная длительность", duration: math.Inf(1), step: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Propagate(state, earth, tt.duration, tt.step); err == nil {
+				t.Error("ошибки нет")
+			}
+		})
+	}
+}
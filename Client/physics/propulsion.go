@@ -0,0 +1,105 @@
+package physics
+
+import (
+	"math"
+
+	"cosmodrom/protocol"
+)
+
+// propulsion - двигатели текущей ступени и дроссели последней команды: из них
+// считаются тяговооруженность, запас характеристической скорости и время
+// работы. Общая часть RocketPhysics и GoPhysics, вызывается под их мьютексом.
+type propulsion struct {
+	engines  []protocol.Engine
+	fuelType protocol.FuelType // Скорость истечения двигателей без расхода
+	command  []float64         // Последняя команда Update; буфер переиспользуется
+}
+
+func (p *propulsion) setEngines(engines []protocol.Engine) {
+	p.engines = append(p.engines[:0], engines...)
+}
+
+// setThrottles запоминает дроссели команды, приведенные к 0-1
+func (p *propulsion) setThrottles(throttles []float64) {
+	p.command = p.command[:0]
+	for _, throttle := range throttles {
+		p.command = append(p.command, clampThrottle(throttle))
+	}
+}
+
+// output - тяга (Н) и расход (кг/с) исправных двигателей при дросселях
+// throttles. Лишние дроссели и двигатели без дросселя не учитываются.
+func (p *propulsion) output(throttles []float64) (thrust, consumption float64) {
+	for i, throttle := range throttles {
+		if i >= len(p.engines) {
+			break
+		}
+		if p.engines[i].IsActive {
+			thrust += p.engines[i].Thrust * throttle
+			consumption += p.engines[i].FuelConsumption * throttle
+		}
+	}
+	return thrust, consumption
+}
+
+// exhaustVelocity - эффективная скорость истечения: тяга на расход при
+// текущих дросселях, а при заглушенных двигателях - при полной тяге всех
+// исправных. Если расход у двигателей не задан - типовая для топлива
+// (0 для неизвестного типа).
+func (p *propulsion) exhaustVelocity() float64 {
+	thrust, consumption := p.output(p.command)
+	if consumption <= 0 {
+		thrust, consumption = p.output(p.uniform(1))
+	}
+	if consumption <= 0 {
+		return p.fuelType.ExhaustVelocity()
+	}
+	return thrust / consumption
+}
+
+// uniform - одинаковый дроссель throttle на всех двигателях
+func (p *propulsion) uniform(throttle float64) []float64 {
+	throttles := make([]float64, len(p.engines))
+	for i := range throttles {
+		throttles[i] = throttle
+	}
+	return throttles
+}
+
+// thrustToWeight - тяга при текущих дросселях к весу на текущей высоте.
+// Без топлива тяга нулевая.
+func (p *propulsion) thrustToWeight(state protocol.RocketState, planet PlanetConfig) float64 {
+	if state.FuelRemaining <= 0 {
+		return 0
+	}
+	r2 := dot(state.Position, state.Position)
+	if r2 <= 0 || state.MassCurrent <= 0 {
+		return 0
+	}
+	weight := state.MassCurrent * protocol.GConstant * planet.Mass / r2
+	if weight <= 0 {
+		return 0
+	}
+	thrust, _ := p.output(p.command)
+	return thrust / weight
+}
+
+// deltaVRemaining - формула Циолковского: ve * ln(m / m_сух). Физика ведет
+// общий запас топлива, поэтому у многоступенчатой ракеты это оценка по
+// двигателям текущей ступени на все оставшееся топливо.
+func (p *propulsion) deltaVRemaining(state protocol.RocketState, massEmpty float64) float64 {
+	if massEmpty <= 0 || state.MassCurrent <= massEmpty {
+		return 0
+	}
+	return p.exhaustVelocity() * math.Log(state.MassCurrent/massEmpty)
+}
+
+// burnTimeRemaining - время до выработки топлива при дросселе throttle (0-1)
+// на всех исправных двигателях. +Inf, если топливо не расходуется.
+func (p *propulsion) burnTimeRemaining(fuel, throttle float64) float64 {
+	_, consumption := p.output(p.uniform(math.Max(0, math.Min(1, throttle))))
+	if consumption <= 0 {
+		return math.Inf(1)
+	}
+	return math.Max(0, fuel) / consumption
+}
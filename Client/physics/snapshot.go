@@ -0,0 +1,82 @@
+package physics
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"cosmodrom/protocol"
+)
+
+// SnapshotVersion - версия формата снимка физики. Снимки другой версии
+// Restore не принимает.
+const SnapshotVersion = 1
+
+// Snapshot - все, что нужно физике, чтобы продолжить полет с того же места:
+// состояние ракеты, планета, гравитационный разворот, текущая ступень и
+// дроссели последней команды. Формат общий для физики на C и на Go.
+type Snapshot struct {
+	Version     int                   `json:"version"`
+	State       protocol.RocketState  `json:"state"`
+	Planet      PlanetConfig          `json:"planet"`
+	GravityTurn GravityTurnConfig     `json:"gravity_turn"`
+	Config      protocol.RocketConfig `json:"config"` // Сухая масса и двигатели - текущей ступени
+	Throttles   []float64             `json:"throttles,omitempty"`
+}
+
+// DecodeSnapshot разбирает снимок Snapshot() и проверяет версию и значения
+func DecodeSnapshot(data []byte) (Snapshot, error) {
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return Snapshot{}, &PhysicsError{Kind: ErrorKindInvalid, Message: "снимок физики", Err: err}
+	}
+	if s.Version != SnapshotVersion {
+		return Snapshot{}, &PhysicsError{Kind: ErrorKindInvalid, Message: fmt.Sprintf("снимок физики версии %d, поддерживается %d", s.Version, SnapshotVersion)}
+	}
+	if s.Planet.Radius <= 0 || s.Planet.Mass <= 0 {
+		return Snapshot{}, &PhysicsError{Kind: ErrorKindInvalid, Message: "снимок физики: у планеты должны быть положительные радиус и масса"}
+	}
+	if s.Config.MassEmpty <= 0 || s.State.FuelRemaining < 0 {
+		return Snapshot{}, &PhysicsError{Kind: ErrorKindInvalid, Message: "снимок физики: неверная масса ракеты"}
+	}
+	return s, nil
+}
+
+// snapshotConfig - поля конфигурации, которые не меняются в полете
+func snapshotConfig(config *protocol.RocketConfig) protocol.RocketConfig {
+	return protocol.RocketConfig{
+		Name:            config.Name,
+		MassFuelMax:     config.MassFuelMax,
+		FuelType:        config.FuelType,
+		DragCoefficient: config.DragCoefficient,
+		CrossSection:    config.CrossSection,
+		Parachute:       config.Parachute,
+	}
+}
+
+func encodeSnapshot(s Snapshot) ([]byte, error) {
+	s.Version = SnapshotVersion
+	// В снимок идет только состояние физики, без прогноза орбиты и телеметрии
+	s.State = protocol.RocketState{
+		Position:      s.State.Position,
+		Velocity:      s.State.Velocity,
+		Acceleration:  s.State.Acceleration,
+		Altitude:      s.State.Altitude,
+		Speed:         s.State.Speed,
+		MassCurrent:   s.State.MassCurrent,
+		FuelRemaining: s.State.FuelRemaining,
+		InOrbit:       s.State.InOrbit,
+		Landed:        s.State.Landed,
+		Crashed:       s.State.Crashed,
+		Time:          s.State.Time,
+
+		Orientation:       s.State.Orientation,
+		ParachuteDeployed: s.State.ParachuteDeployed,
+		ParachuteFailed:   s.State.ParachuteFailed,
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		// NaN и Inf в состоянии JSON не кодирует
+		return nil, &PhysicsError{Kind: ErrorKindNonFinite, Message: "снимок физики", Err: err}
+	}
+	return data, nil
+}
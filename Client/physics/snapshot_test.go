@@ -0,0 +1,194 @@
+package physics
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"cosmodrom/protocol"
+)
+
+// snapshotFlight - подъем с гравитационным разворотом и сменой ступени на
+// T+0.5 с; шаги после снимка должны совпадать до бита
+func snapshotFlight(t *testing.T, p PhysicsEngine, from, steps int) {
+	t.Helper()
+	upper := []protocol.Engine{{Thrust: 900000, FuelConsumption: 300, IsActive: true}}
+	for i := from; i < from+steps; i++ {
+		if i == 50 {
+			if err := p.SetStage(8000, upper); err != nil {
+				t.Fatal(err)
+			}
+		}
+		pitch, err := p.CalculateOptimalPitch()
+		if err != nil {
+			t.Fatal(err)
+		}
+		throttle := 1.0 - float64(i%7)*0.05
+		throttles := []float64{throttle, throttle}
+		if i >= 50 {
+			throttles = throttles[:len(upper)]
+		}
+		if err := p.Update(&protocol.ControlCommand{EngineThrottle: throttles, Pitch: pitch, Yaw: 3}, 0.01); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	planet := PlanetConfig{Radius: 3389500, Mass: 6.417e23, AtmosphereHeight: 80000, SurfacePressure: 0.006, ScaleHeight: 11100, RotationPeriod: 88643}
+	config := testConfig(2)
+	start := planet.Position(18, 77, 10)
+
+	for _, backend := range availableBackends() {
+		t.Run(string(backend), func(t *testing.T) {
+			original, err := NewEngine(backend, &config, start)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer original.Close()
+			original.SetPlanet(planet)
+			original.SetInitialVelocity(planet.SurfaceVelocity(start))
+			original.SetGravityTurn(GravityTurnForOrbit(planet, 150000))
+			snapshotFlight(t, original, 0, 120)
+
+			data, err := original.Snapshot()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			fromSnapshot, err := NewEngineFromSnapshot(backend, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer fromSnapshot.Close()
+
+			// Restore поверх физики другой ракеты на другой планете
+			other := testConfig(1)
+			restored, err := NewEngine(backend, &other, EarthDefault().Position(0, 0, 0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer restored.Close()
+			if err := restored.Restore(data); err != nil {
+				t.Fatal(err)
+			}
+
+			want, _ := original.GetState()
+			for name, p := range map[string]PhysicsEngine{"из снимка": fromSnapshot, "Restore": restored} {
+				got, err := p.GetState()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("%s: состояние %+v, ожидалось %+v", name, got, want)
+				}
+				again, _ := p.Snapshot()
+				if !bytes.Equal(again, data) {
+					t.Errorf("%s: повторный снимок отличается:\n%s\n%s", name, again, data)
+				}
+			}
+
+			snapshotFlight(t, original, 120, 300)
+			snapshotFlight(t, fromSnapshot, 120, 300)
+			snapshotFlight(t, restored, 120, 300)
+			want, _ = original.GetState()
+			if want.Time < 4 || want.Altitude <= 10 {
+				t.Fatalf("ракета не взлетела: T+%.2f с, высота %.1f м", want.Time, want.Altitude)
+			}
+			for name, p := range map[string]PhysicsEngine{"из снимка": fromSnapshot, "Restore": restored} {
+				if got, _ := p.GetState(); !reflect.DeepEqual(got, want) {
+					t.Errorf("%s: через 300 шагов %+v, ожидалось %+v", name, got, want)
+				}
+				for _, estimate := range []func(PhysicsEngine) (float64, error){PhysicsEngine.ThrustToWeight, PhysicsEngine.DeltaVRemaining} {
+					got, _ := estimate(p)
+					expected, _ := estimate(original)
+					if got != expected {
+						t.Errorf("%s: оценка %g, ожидалась %g", name, got, expected)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSnapshotAcrossBackends(t *testing.T) {
+	config := testConfig(2)
+	backends := availableBackends()
+	for _, from := range backends {
+		p, err := NewEngine(from, &config, EarthDefault().Position(45, 63, 100))
+		if err != nil {
+			t.Fatal(err)
+		}
+		snapshotFlight(t, p, 0, 100)
+		data, err := p.Snapshot()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := p.GetState()
+		p.Close()
+
+		for _, to := range backends {
+			q, err := NewEngineFromSnapshot(to, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := q.GetState(); !reflect.DeepEqual(got, want) {
+				t.Errorf("%s -> %s: состояние %+v, ожидалось %+v", from, to, got, want)
+			}
+			q.Close()
+		}
+	}
+}
+
+func TestSnapshotErrors(t *testing.T) {
+	config := testConfig(1)
+	p, err := NewGoPhysics(&config, EarthDefault().Position(45, 63, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := p.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := DecodeSnapshot(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Version != SnapshotVersion || !reflect.DeepEqual(snapshot.Config.Engines, config.Engines) {
+		t.Errorf("снимок %+v", snapshot)
+	}
+
+	future := snapshot
+	future.Version = SnapshotVersion + 1
+	futureData, _ := json.Marshal(future)
+	noPlanet := snapshot
+	noPlanet.Planet = PlanetConfig{}
+	noPlanetData, _ := json.Marshal(noPlanet)
+
+	for name, bad := range map[string][]byte{
+		"пусто":         nil,
+		"не JSON":       []byte("rocket"),
+		"другая версия": futureData,
+		"без планеты":   noPlanetData,
+	} {
+		for _, backend := range availableBackends() {
+			if _, err := NewEngineFromSnapshot(backend, bad); err == nil {
+				t.Errorf("%s/%s: снимок принят", backend, name)
+			}
+		}
+		var physicsErr *PhysicsError
+		if err := p.Restore(bad); !errors.As(err, &physicsErr) {
+			t.Errorf("%s: Restore вернул %v, ожидалась PhysicsError", name, err)
+		}
+	}
+
+	p.Close()
+	if _, err := p.Snapshot(); !errors.Is(err, ErrFreed) {
+		t.Errorf("Snapshot после Close: ошибка %v, ожидалась ErrFreed", err)
+	}
+	if err := p.Restore(data); !errors.Is(err, ErrFreed) {
+		t.Errorf("Restore после Close: ошибка %v, ожидалась ErrFreed", err)
+	}
+}
@@ -0,0 +1,211 @@
+package rocketclient
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/client/physics"
+	"cosmodrom/protocol"
+)
+
+const (
+	standardGravity = 9.80665 // м/с2
+	abortGWindow    = 0.5     // с, сколько перегрузка должна держаться выше предела
+)
+
+// abortLimits - условия аварийного прекращения полета. Нулевое значение
+// выключает условие.
+type abortLimits struct {
+	maxG          float64 // Перегрузка в g
+	maxFlightTime float64 // с, по времени симуляции
+	minAltitude   float64 // м, ...которой ракета должна достичь
+	minAltitudeBy float64 // с, ...к этому моменту
+}
+
+func (l abortLimits) enabled() bool {
+	return l.maxG > 0 || l.maxFlightTime > 0 || l.minAltitudeBy > 0
+}
+
+// parseMinAltitudeAfter разбирает значение -min-altitude-after вида "метры@секунды"
+func parseMinAltitudeAfter(value string) (altitude, after float64, err error) {
+	alt, at, ok := strings.Cut(value, "@")
+	if !ok {
+		return 0, 0, fmt.Errorf("ожидается формат метры@секунды, например 1000@30, получено %q", value)
+	}
+
+	altitude, err = strconv.ParseFloat(alt, 64)
+	if err != nil || altitude <= 0 {
+		return 0, 0, fmt.Errorf("некорректная высота %q", alt)
+	}
+	after, err = strconv.ParseFloat(at, 64)
+	if err != nil || after <= 0 {
+		return 0, 0, fmt.Errorf("некорректное время %q", at)
+	}
+	return altitude, after, nil
+}
+
+// abortReason проверяет условия по истории состояний (последнее - текущее)
+// и возвращает сработавшее условие или пустую строку
+func abortReason(limits abortLimits, planet physics.PlanetConfig, history []protocol.RocketState) string {
+	if len(history) == 0 {
+		return ""
+	}
+	last := history[len(history)-1]
+
+	if limits.maxFlightTime > 0 && last.Time > limits.maxFlightTime {
+		return fmt.Sprintf("время полета превысило %.0f с", limits.maxFlightTime)
+	}
+
+	// Высота проверяется один раз, на шаге, пересекающем момент minAltitudeBy
+	if limits.minAltitudeBy > 0 && last.Time >= limits.minAltitudeBy && last.Altitude < limits.minAltitude {
+		if len(history) < 2 || history[len(history)-2].Time < limits.minAltitudeBy {
+			return fmt.Sprintf("высота %.0f м на T+%.0f с ниже %.0f м", last.Altitude, limits.minAltitudeBy, limits.minAltitude)
+		}
+	}
+
+	// Перегрузка должна держаться все окно, чтобы не сработать на скачке
+	// при отделении ступени
+	if limits.maxG > 0 && last.Time-history[0].Time >= abortGWindow {
+		minG := math.Inf(1)
+		for _, state := range history {
+			if last.Time-state.Time <= abortGWindow {
+				minG = math.Min(minG, gLoad(state, planet))
+			}
+		}
+		if minG > limits.maxG {
+			return fmt.Sprintf("перегрузка %.1f g выше %.1f g", minG, limits.maxG)
+		}
+	}
+	return ""
+}
+
+// gLoad - перегрузка в g: ускорение за вычетом гравитации
+func gLoad(state protocol.RocketState, planet physics.PlanetConfig) float64 {
+	p, a := state.Position, state.Acceleration
+	r := math.Sqrt(p.X*p.X + p.Y*p.Y + p.Z*p.Z)
+	if r == 0 {
+		return 0
+	}
+	g := protocol.GConstant * planet.Mass / (r * r * r)
+	x, y, z := a.X+g*p.X, a.Y+g*p.Y, a.Z+g*p.Z
+	return math.Sqrt(x*x+y*y+z*z) / standardGravity
+}
+
+// abortGuard хранит короткую историю состояний и следит за условиями.
+// После срабатывания тяга выключается до конца полета.
+type abortGuard struct {
+	limits  abortLimits
+	history []protocol.RocketState
+	reason  string
+	logger  *logging.Logger
+}
+
+func (g *abortGuard) aborted() bool {
+	return g.reason != ""
+}
+
+// update добавляет состояние в историю. Возвращает true на шаге, когда
+// сработало условие.
+func (g *abortGuard) update(state protocol.RocketState, planet physics.PlanetConfig) bool {
+	if !g.limits.enabled() || g.aborted() {
+		return false
+	}
+
+	// Окно истории чуть больше abortGWindow; срез переиспользуется
+	keep := 0
+	for keep < len(g.history) && state.Time-g.history[keep].Time > 2*abortGWindow {
+		keep++
+	}
+	g.history = append(g.history[:0], g.history[keep:]...)
+	g.history = append(g.history, state)
+
+	g.reason = abortReason(g.limits, planet, g.history)
+	if !g.aborted() {
+		return false
+	}
+	g.logger.With(logging.F("time", state.Time), logging.F("altitude", state.Altitude), logging.F("reason", g.reason)).
+		Error("flight_abort", logging.F("altitude_km", state.Altitude/1000.0))
+	return true
+}
+
+// apply выключает двигатели после срабатывания. Срез копируется, чтобы не
+// менять команду автопилота или сервера.
+func (g *abortGuard) apply(command *protocol.ControlCommand) {
+	if !g.aborted() {
+		return
+	}
+	command.EngineThrottle = make([]float64, len(command.EngineThrottle))
+}
+
+// logImpact пишет в журнал, куда упадет ракета с выключенными двигателями
+func (r *RocketClient) logImpact() {
+	impact, err := r.physics.PredictImpact()
+	switch {
+	case err != nil:
+		r.logger.Warn("impact_failed", logging.F("error", err))
+	case !impact.Impact:
+		r.logger.Info("impact_none")
+	default:
+		r.logger.Warn("impact",
+			logging.F("time_to_impact", impact.TimeToImpact),
+			logging.F("latitude", impact.Latitude),
+			logging.F("longitude", impact.Longitude),
+			logging.F("speed", impact.ImpactSpeed))
+	}
+}
+
+// phase - фаза для записи полета: после срабатывания условий "abort"
+func (r *RocketClient) phase() string {
+	if r.abort.aborted() {
+		return "abort"
+	}
+	return r.program.Phase()
+}
+
+// physicsFailure сообщает об ошибке физики. Если физика выдала NaN или
+// невозможное состояние, полет прекращается как аварийный: в журнал идут
+// последнее корректное состояние и параметры ракеты, серверу - abort, на
+// диск - черный ящик. Испорченное состояние в телеметрию не попадает.
+func (r *RocketClient) physicsFailure(err error) {
+	if !errors.Is(err, physics.ErrNonFinite) && !errors.Is(err, physics.ErrInconsistent) {
+		r.logger.Error("physics_error", logging.F("error", err))
+		return
+	}
+
+	last, ok := r.blackBox.last()
+	state := last.State
+	reason := "некорректное состояние физики"
+	r.logger.With(logging.F("time", state.Time), logging.F("altitude", state.Altitude), logging.F("reason", reason)).
+		Error("flight_abort_physics", logging.F("error", err))
+	if ok {
+		r.logger.Error("physics_last_state",
+			logging.F("time", state.Time),
+			logging.F("altitude", state.Altitude),
+			logging.F("speed", state.Speed),
+			logging.F("mass", state.MassCurrent),
+			logging.F("fuel", state.FuelRemaining),
+			logging.F("pitch", last.Command.Pitch),
+			logging.F("throttle", meanThrottle(last.Command.EngineThrottle)*100))
+	}
+	r.logger.Error("physics_config",
+		logging.F("name", r.config.Name),
+		logging.F("mass_empty", r.config.MassEmpty),
+		logging.F("fuel", r.config.MassFuel),
+		logging.F("fuel_max", r.config.MassFuelMax),
+		logging.F("drag", r.config.DragCoefficient),
+		logging.F("cross_section", r.config.CrossSection),
+		logging.F("engines", len(r.config.Engines)))
+
+	r.emit(EventAbort, reason)
+	r.sink.Abort(protocol.AbortMessage{
+		RocketID: r.ID,
+		Reason:   reason,
+		Time:     state.Time,
+		Altitude: state.Altitude,
+	})
+	r.dumpBlackBox()
+}
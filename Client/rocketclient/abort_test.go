@@ -0,0 +1,86 @@
+package rocketclient
+
+import (
+	"strings"
+	"testing"
+
+	"cosmodrom/client/physics"
+	"cosmodrom/protocol"
+)
+
+// abortState - состояние на высоте altitude над экватором с перегрузкой g
+func abortState(time, altitude, g float64) protocol.RocketState {
+	planet := physics.EarthDefault()
+	r := planet.Radius + altitude
+	gravity := protocol.GConstant * planet.Mass / (r * r)
+	return protocol.RocketState{
+		Time:         time,
+		Altitude:     altitude,
+		Position:     protocol.Vector3{X: r},
+		Acceleration: protocol.Vector3{X: g*standardGravity - gravity},
+	}
+}
+
+// abortHistory - шаги 0.1 с с from по to на высоте altitude с перегрузкой g
+func abortHistory(from, to, altitude, g float64) []protocol.RocketState {
+	var history []protocol.RocketState
+	for i := 0; from+float64(i)*0.1 <= to+1e-9; i++ {
+		history = append(history, abortState(from+float64(i)*0.1, altitude, g))
+	}
+	return history
+}
+
+func TestAbortReason(t *testing.T) {
+	limits := abortLimits{maxG: 5, maxFlightTime: 300, minAltitude: 1000, minAltitudeBy: 30}
+	spike := abortHistory(10, 11, 500, 2)
+	for i := 5; i < 8; i++ {
+		spike[i] = abortState(spike[i].Time, 500, 8) // 0.3 с выше предела
+	}
+
+	tests := []struct {
+		name    string
+		limits  abortLimits
+		history []protocol.RocketState
+		want    string // Подстрока причины, пусто - полет продолжается
+	}{
+		{name: "без истории", limits: limits},
+		{name: "в пределах", limits: limits, history: abortHistory(10, 11, 500, 2)},
+		{name: "условия выключены", history: []protocol.RocketState{abortState(1000, 0, 20)}},
+		{name: "время полета", limits: limits, history: []protocol.RocketState{abortState(300.1, 100000, 1)}, want: "время полета превысило 300 с"},
+		{name: "время полета на пределе", limits: limits, history: []protocol.RocketState{abortState(300, 100000, 1)}},
+		{name: "низко к сроку", limits: limits, history: abortHistory(29.9, 30, 900, 1), want: "высота 900 м на T+30 с ниже 1000 м"},
+		{name: "высоко к сроку", limits: limits, history: abortHistory(29.9, 30, 1100, 1)},
+		{name: "низко до срока", limits: limits, history: abortHistory(29.8, 29.9, 900, 1)},
+		// Высота проверяется только на шаге, пересекающем срок
+		{name: "низко после срока", limits: limits, history: abortHistory(30, 30.1, 900, 1)},
+		{name: "первый шаг после срока", limits: limits, history: []protocol.RocketState{abortState(31, 900, 1)}, want: "ниже 1000 м"},
+		{name: "перегрузка все окно", limits: limits, history: abortHistory(10, 10.5, 500, 6), want: "перегрузка 6.0 g выше 5.0 g"},
+		{name: "перегрузка на пределе", limits: limits, history: abortHistory(10, 10.5, 500, 5)},
+		{name: "скачок короче окна", limits: limits, history: spike},
+		{name: "история короче окна", limits: limits, history: abortHistory(10, 10.4, 500, 6)},
+		// Время проверяется раньше перегрузки
+		{name: "несколько условий", limits: limits, history: abortHistory(300, 301, 500, 6), want: "время полета"},
+	}
+
+	planet := physics.EarthDefault()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := abortReason(tt.limits, planet, tt.history)
+			if tt.want == "" && got != "" || !strings.Contains(got, tt.want) {
+				t.Errorf("причина %q, ожидалась %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMinAltitudeAfter(t *testing.T) {
+	altitude, after, err := parseMinAltitudeAfter("1000@30")
+	if err != nil || altitude != 1000 || after != 30 {
+		t.Errorf("1000@30: %g м, %g с, %v", altitude, after, err)
+	}
+	for _, value := range []string{"1000", "@30", "1000@", "-1@30", "1000@0", "abc@30"} {
+		if _, _, err := parseMinAltitudeAfter(value); err == nil {
+			t.Errorf("%q разобрано без ошибки", value)
+		}
+	}
+}
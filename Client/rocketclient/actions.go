@@ -0,0 +1,100 @@
+package rocketclient
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/protocol"
+)
+
+// actionEdges помнит действия команды прошлого шага. Действие выполняется
+// по переднему фронту: команда сервера держится -command-hold, автопилот
+// выставляет флаг на каждом шаге, а ступень при этом отделяется одна.
+type actionEdges struct {
+	parachute, stage, payload bool
+}
+
+// rising оставляет в command только действия, включившиеся на этом шаге
+func (e *actionEdges) rising(command *protocol.ControlCommand) {
+	last := *e
+	e.parachute, e.stage, e.payload = command.DeployParachute, command.StageSeparate, command.DeployPayload
+
+	command.DeployParachute = command.DeployParachute && !last.parachute
+	command.StageSeparate = command.StageSeparate && !last.stage
+	command.DeployPayload = command.DeployPayload && !last.payload
+}
+
+// actionNames перечисляет действия команды для журнала
+func actionNames(command protocol.ControlCommand) string {
+	var names []string
+	if command.StageSeparate {
+		names = append(names, "stage_separate")
+	}
+	if command.DeployPayload {
+		names = append(names, "deploy_payload")
+	}
+	if command.DeployParachute {
+		names = append(names, "deploy_parachute")
+	}
+	return strings.Join(names, ", ")
+}
+
+// checkActions снимает с команды невыполнимые действия (отделить
+// последнюю ступень, сбросить нагрузку, которой нет) с записью в журнал
+func (r *RocketClient) checkActions(command *protocol.ControlCommand) {
+	state := protocol.RocketState{Stage: r.staging.number(), PayloadDeployed: r.payloadDeployed}
+	for {
+		err := protocol.ValidateActions(command, &r.config, &state)
+		var invalid *protocol.ValidationError
+		if !errors.As(err, &invalid) {
+			return
+		}
+		r.logger.Warn("action_failed", logging.F("error", err))
+		switch invalid.Field {
+		case "stage_separate":
+			command.StageSeparate = false
+		case "deploy_payload":
+			command.DeployPayload = false
+		case "deploy_parachute":
+			command.DeployParachute = false
+		default:
+			return
+		}
+	}
+}
+
+// deployPayload отделяет полезную нагрузку: сухая масса ракеты
+// уменьшается на ее массу, двигатели и топливо не меняются
+func (r *RocketClient) deployPayload(state protocol.RocketState) error {
+	payload := r.config.Payload
+	dry := state.MassCurrent - state.FuelRemaining
+	if err := r.physics.SetStage(dry-payload.Mass, r.currentEngines()); err != nil {
+		return err
+	}
+	r.staging.release(payload.Mass)
+	r.payloadDeployed = true
+
+	name := payload.Name
+	if name == "" {
+		name = "полезная нагрузка"
+	}
+	r.logger.Info("payload_released",
+		logging.F("name", name),
+		logging.F("mass", payload.Mass),
+		logging.F("altitude_km", state.Altitude/1000.0),
+		logging.F("speed", state.Speed))
+	r.emit(EventPayload, fmt.Sprintf("%s, %.0f кг", name, payload.Mass))
+	return nil
+}
+
+// parachuteEvents сообщает о раскрытии и разрыве парашюта за шаг
+func (r *RocketClient) parachuteEvents(before, state protocol.RocketState) {
+	if state.ParachuteDeployed && !before.ParachuteDeployed {
+		r.emit(EventParachute, "раскрыт")
+	}
+	if state.ParachuteFailed && !before.ParachuteFailed {
+		r.emit(EventParachute, "порван")
+	}
+}
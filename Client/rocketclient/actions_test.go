@@ -0,0 +1,118 @@
+package rocketclient
+
+import (
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/protocol"
+)
+
+func actionTestClient(t *testing.T) (*RocketClient, *fakeClock) {
+	t.Helper()
+	engine := protocol.Engine{Thrust: 2e5, FuelConsumption: 60, IsActive: true}
+	config := DefaultConfig().Rocket
+	config.Engines = []protocol.Engine{engine}
+	config.MassEmpty, config.MassFuel = 3100, 10500
+	config.Stages = []protocol.Stage{
+		{MassEmpty: 2000, MassFuel: 8000, Engines: []protocol.Engine{engine}},
+		{MassEmpty: 800, MassFuel: 2000, Engines: []protocol.Engine{engine}},
+		{MassEmpty: 300, MassFuel: 500, Engines: []protocol.Engine{engine}},
+	}
+	config.Payload = &protocol.Payload{Name: "спутник", Mass: 100}
+	if err := protocol.ValidateRocketConfig(&config); err != nil {
+		t.Fatal(err)
+	}
+
+	r := newRocketClient("test", config, "", 10, 0.1)
+	r.setLogger(logging.New(io.Discard, logging.LevelInfo, false))
+	clock := &fakeClock{now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	r.clock = clock
+	if err := r.initPhysics(45, 63, 0.1, 200000); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.physics.Close() })
+	return r, clock
+}
+
+func (r *RocketClient) stepN(t *testing.T, n int) protocol.RocketState {
+	t.Helper()
+	var state protocol.RocketState
+	for i := 0; i < n; i++ {
+		var err error
+		if state, err = r.step(r.dt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return state
+}
+
+// Действие выполняется один раз по переднему фронту, сколько бы шагов
+// ни держалась команда, а невыполнимое действие снимается
+func TestStageSeparateCommand(t *testing.T) {
+	r, clock := actionTestClient(t)
+	r.stepN(t, 5)
+
+	r.setServerActions(protocol.ControlCommand{StageSeparate: true})
+	if state := r.stepN(t, 5); state.Stage != 2 {
+		t.Fatalf("ступень %d, ожидалась 2", state.Stage)
+	}
+	// Повтор, пока держится прошлая команда, - не новый фронт
+	r.setServerActions(protocol.ControlCommand{StageSeparate: true})
+	if state := r.stepN(t, 5); state.Stage != 2 {
+		t.Fatalf("повтор отделил ступень: %d", state.Stage)
+	}
+
+	clock.now = clock.now.Add(r.commandHold + time.Second)
+	r.stepN(t, 1)
+	r.setServerActions(protocol.ControlCommand{StageSeparate: true})
+	if state := r.stepN(t, 1); state.Stage != 3 {
+		t.Fatalf("ступень %d, ожидалась 3", state.Stage)
+	}
+
+	// Последнюю ступень не отделить
+	clock.now = clock.now.Add(r.commandHold + time.Second)
+	r.stepN(t, 1)
+	r.setServerActions(protocol.ControlCommand{StageSeparate: true})
+	if state := r.stepN(t, 1); state.Stage != 3 {
+		t.Errorf("отделена последняя ступень: %d", state.Stage)
+	}
+}
+
+func TestDeployPayloadCommand(t *testing.T) {
+	r, clock := actionTestClient(t)
+	before := r.stepN(t, 1)
+
+	r.setServerActions(protocol.ControlCommand{DeployPayload: true})
+	state := r.stepN(t, 1)
+	if !state.PayloadDeployed {
+		t.Fatal("нагрузка не отделена")
+	}
+	burned := before.FuelRemaining - state.FuelRemaining
+	if got := before.MassCurrent - state.MassCurrent - burned; math.Abs(got-100) > 1e-6 {
+		t.Errorf("масса уменьшилась на %.3f кг сверх топлива, ожидалось 100", got)
+	}
+
+	// Нагрузка отделяется один раз
+	clock.now = clock.now.Add(r.commandHold + time.Second)
+	mass := r.stepN(t, 1).MassCurrent
+	r.setServerActions(protocol.ControlCommand{DeployPayload: true})
+	if again := r.stepN(t, 1); again.MassCurrent < mass-50 {
+		t.Errorf("нагрузка отделена повторно: %.1f -> %.1f кг", mass, again.MassCurrent)
+	}
+}
+
+func TestActionEdges(t *testing.T) {
+	var edges actionEdges
+	held := []bool{true, true, false, true}
+	want := []bool{true, false, false, true}
+	for i := range held {
+		command := protocol.ControlCommand{DeployParachute: held[i], StageSeparate: held[i]}
+		edges.rising(&command)
+		if command.DeployParachute != want[i] || command.StageSeparate != want[i] {
+			t.Errorf("шаг %d: %+v, ожидалось %v", i, command, want[i])
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package rocketclient
+
+import (
+	"fmt"
+	"math"
+
+	"cosmodrom/client/logging"
+)
+
+const (
+	apoapsisPIDWindow    = 0.1   // Регулятор включается, когда до цели остается 10% апоцентра
+	apoapsisPIDTolerance = 0.001 // Недобор апоцентра в долях цели, при котором MECO
+)
+
+// pidGains - коэффициенты регулятора апоцентра. Ошибка - недобор апоцентра
+// в долях целевой высоты, выход - дроссель.
+type pidGains struct {
+	kp, ki, kd  float64
+	minThrottle float64 // Нижняя граница дросселя, как у реальных двигателей
+}
+
+func (g pidGains) enabled() bool {
+	return g.kp > 0 || g.ki > 0 || g.kd > 0
+}
+
+func (g pidGains) validate() error {
+	if g.kp < 0 || g.ki < 0 || g.kd < 0 {
+		return fmt.Errorf("коэффициенты регулятора апоцентра не могут быть отрицательными")
+	}
+	if g.minThrottle < 0 || g.minThrottle > 1 {
+		return fmt.Errorf("-min-throttle должен быть от 0 до 1")
+	}
+	return nil
+}
+
+// apoapsisController на позднем участке разгона снижает тягу по мере
+// приближения прогноза апоцентра к цели, чтобы MECO не проскакивало ее
+// на десятки километров
+type apoapsisController struct {
+	gains     pidGains
+	active    bool
+	integral  float64
+	lastError float64
+	lastTime  float64
+	logger    *logging.Logger
+}
+
+func newApoapsisController(gains pidGains, logger *logging.Logger) *apoapsisController {
+	if !gains.enabled() {
+		return nil
+	}
+	return &apoapsisController{gains: gains, logger: logger}
+}
+
+// throttle возвращает дроссель для прогноза apoapsis. До окна регулирования
+// и без регулятора (nil) - полная тяга.
+func (c *apoapsisController) throttle(apoapsis, target, now float64) float64 {
+	if c == nil || apoapsis < target*(1-apoapsisPIDWindow) {
+		return 1.0
+	}
+
+	e := (target - apoapsis) / target
+	if !c.active {
+		c.active = true
+		c.integral = 0
+		c.lastError = e
+		c.lastTime = now
+		c.logger.Info("apoapsis_control", logging.F("apoapsis_km", apoapsis/1000.0), logging.F("target_km", target/1000.0))
+	}
+
+	dt := now - c.lastTime
+	derivative := 0.0
+	if dt > 0 {
+		derivative = (e - c.lastError) / dt
+	}
+	c.lastError = e
+	c.lastTime = now
+
+	output := c.gains.kp*e + c.gains.ki*(c.integral+e*dt) + c.gains.kd*derivative
+	throttle := math.Max(c.gains.minThrottle, math.Min(1.0, output))
+
+	// Anti-windup: интеграл не копится, пока выход упирается в границу и
+	// ошибка гонит его дальше за нее
+	saturatedHigh := output >= 1.0 && e > 0
+	saturatedLow := output <= c.gains.minThrottle && e < 0
+	if !saturatedHigh && !saturatedLow {
+		c.integral += e * dt
+	}
+	return throttle
+}
+
+// reached - апоцентр достиг цели с точностью регулятора. Без регулятора
+// MECO только по достижении цели.
+func (c *apoapsisController) reached(apoapsis, target float64) bool {
+	if c == nil || !c.active {
+		return apoapsis >= target
+	}
+	return apoapsis >= target*(1-apoapsisPIDTolerance)
+}
+
+// reset вызывается на MECO: после повторного включения регулятор
+// начинает с нуля
+func (c *apoapsisController) reset() {
+	if c == nil {
+		return
+	}
+	c.active = false
+	c.integral = 0
+}
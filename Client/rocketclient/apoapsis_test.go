@@ -0,0 +1,84 @@
+package rocketclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"cosmodrom/client/logging"
+)
+
+// mecoApoapsis - прогноз апоцентра на MECO (км) в полете cfg без сервера.
+// Полет должен выйти на орбиту, а регулятор - включиться до MECO.
+func mecoApoapsis(t *testing.T, cfg Config) float64 {
+	t.Helper()
+	var log bytes.Buffer
+	cfg.ID = "apo"
+	cfg.Sink = &stateLog{}
+	cfg.Unpaced = true
+	cfg.Dt = 0.05
+	cfg.Logger = logging.New(&log, logging.LevelInfo, true)
+
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary, err := client.Launch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Outcome != OutcomeOrbit {
+		t.Fatalf("%s: исход %s", cfg.Rocket.Name, summary.Outcome)
+	}
+
+	controlled := false
+	for _, line := range strings.Split(log.String(), "\n") {
+		var record struct {
+			Code     string
+			Apoapsis float64 `json:"apoapsis_km"`
+		}
+		if json.Unmarshal([]byte(line), &record) != nil {
+			continue
+		}
+		switch record.Code {
+		case "apoapsis_control":
+			controlled = true
+		case "meco":
+			if !controlled {
+				t.Fatalf("%s: MECO без регулятора апоцентра", cfg.Rocket.Name)
+			}
+			return record.Apoapsis
+		}
+	}
+	t.Fatalf("%s: в журнале нет MECO", cfg.Rocket.Name)
+	return 0
+}
+
+// Регулятор подводит апоцентр к цели в пределах 1% у ракет с разной
+// тяговооруженностью; без него ракета по умолчанию проскакивает цель
+// на 3 км
+func TestApoapsisControllerReachesTarget(t *testing.T) {
+	heavy, err := PresetByName("heavy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name   string
+		config func(cfg *Config)
+	}{
+		{name: "ракета по умолчанию", config: func(cfg *Config) {}},
+		{name: "heavy", config: func(cfg *Config) { cfg.Rocket = heavy.Config() }},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.config(&cfg)
+			target := cfg.TargetOrbit / 1000.0
+			if apoapsis := mecoApoapsis(t, cfg); math.Abs(apoapsis-target) > 0.01*target {
+				t.Errorf("апоцентр на MECO %.1f км, цель %.0f км", apoapsis, target)
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package rocketclient
+
+import (
+	"math"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/client/physics"
+	"cosmodrom/protocol"
+)
+
+type AscentPhase string
+
+const (
+	PhaseAscent       AscentPhase = "ascent"        // Разгон до целевого апоцентра
+	PhaseCoast        AscentPhase = "coast"         // Двигатели выключены (MECO), полет к апоцентру
+	PhaseCircularize  AscentPhase = "circularize"   // Скругление орбиты в апоцентре
+	PhaseOrbit        AscentPhase = "orbit"         // Орбита стабильна, двигатели выключены
+	PhaseFuelDepleted AscentPhase = "fuel_depleted" // Топлива на скругление не хватило
+)
+
+const (
+	apoapsisTolerance = 2000.0 // м, допустимая просадка апоцентра на участке полета к нему
+	apoapsisLead      = 1000.0 // м, за сколько до апоцентра начинать скругление
+	horizontalPitch   = 90.0   // Тяга по горизонту (прогрейд в апоцентре)
+)
+
+// GravityTurnAutopilot - выведение на орбиту: гравитационный разворот с
+// разгоном до целевого апоцентра, полет к нему без тяги и скругление, а
+// затем, если задан, переход Хомана. Фазы переключаются по состоянию и
+// прогнозу орбиты; физику он спрашивает только о времени до апсид и о плане
+// скругления.
+type GravityTurnAutopilot struct {
+	target float64 // Целевая высота орбиты, м
+	planet physics.PlanetConfig
+	turn   physics.GravityTurnConfig
+	orbit  orbitPlanner
+	phase  AscentPhase
+	logger *logging.Logger
+
+	// Доля номинальной тяги ступени после отказов двигателей. Меньшая тяга
+	// растягивает скругление, поэтому оно начинается раньше.
+	thrustRatio float64
+
+	pid     *apoapsisController // nil - полная тяга до MECO
+	azimuth *azimuthSteering    // nil - рыскание не управляется
+
+	transfer *hohmannTransfer // Переход после выхода на орбиту; nil - полет заканчивается на орбите
+
+	throttle []float64 // Дроссели последней команды
+}
+
+func newGravityTurnAutopilot(target float64, planet physics.PlanetConfig, turn physics.GravityTurnConfig, orbit orbitPlanner, logger *logging.Logger) *GravityTurnAutopilot {
+	return &GravityTurnAutopilot{target: target, planet: planet, turn: turn, orbit: orbit, phase: PhaseAscent, logger: logger, thrustRatio: 1}
+}
+
+// Step ведет ракету по тангажу разворота на участке разгона, а вне его -
+// тягой по горизонту
+func (s *GravityTurnAutopilot) Step(state protocol.RocketState, dt float64) protocol.ControlCommand {
+	if state.FuelRemaining <= 0 && (s.phase == PhaseAscent || s.phase == PhaseCircularize) {
+		s.transition(PhaseFuelDepleted, state)
+	}
+	if state.FuelRemaining <= 0 && (s.phase == PhaseTransferBurn || s.phase == PhaseTransferCircularize) {
+		// Начальная орбита уже стабильна: полет заканчивается на той, что получилась
+		s.logger.Warn("transfer_fuel_out", logging.F("target_km", s.transfer.target/1000.0))
+		s.transition(PhaseOrbit, state)
+	}
+
+	switch s.phase {
+	case PhaseAscent:
+		if s.pid.reached(state.OrbitApoapsis, s.target) {
+			s.transition(PhaseCoast, state)
+		}
+
+	case PhaseCoast:
+		if state.OrbitApoapsis < s.target-apoapsisTolerance && state.OrbitApoapsis > 0 {
+			s.transition(PhaseAscent, state)
+		} else if state.Altitude >= state.OrbitApoapsis-apoapsisLead/s.thrustRatio || verticalSpeed(state) <= 0 {
+			s.transition(PhaseCircularize, state)
+		}
+
+	case PhaseCircularize:
+		if state.OrbitIsStable && state.OrbitPeriapsis > minOrbitPeriapsis(s.planet, s.target) {
+			s.transition(PhaseOrbit, state)
+			if s.transfer != nil {
+				s.transition(PhaseTransferBurn, state)
+			}
+		}
+
+	case PhaseTransferBurn:
+		if s.transfer.firstBurnDone(state) {
+			s.transition(PhaseTransferCoast, state)
+		}
+
+	case PhaseTransferCoast:
+		if s.transfer.atSecondBurn(state, s.predict()) {
+			s.transition(PhaseTransferCircularize, state)
+		}
+
+	case PhaseTransferCircularize:
+		if s.transfer.done(state) {
+			s.transition(PhaseOrbit, state)
+		}
+	}
+
+	throttle := 0.0
+	switch s.phase {
+	case PhaseAscent:
+		throttle = s.pid.throttle(state.OrbitApoapsis, s.target, state.Time)
+	case PhaseCircularize, PhaseTransferBurn, PhaseTransferCircularize:
+		throttle = 1.0
+	}
+	var command protocol.ControlCommand
+	switch s.phase {
+	case PhaseTransferBurn, PhaseTransferCoast, PhaseTransferCircularize:
+		s.transfer.attitude(&command, state)
+	default:
+		command.Pitch = horizontalPitch
+		if s.phase == PhaseAscent {
+			command.Pitch = s.turn.Pitch(state.Altitude)
+		}
+		if s.azimuth != nil {
+			command.Yaw = s.azimuth.yaw(state)
+		}
+	}
+	s.throttle = append(s.throttle[:0], throttle)
+	command.EngineThrottle = s.throttle
+	return command
+}
+
+// predict - полный прогноз орбиты физики: время до апсид и период, которых
+// нет в состоянии. Ошибку прогноза журналирует клиент при заполнении состояния.
+func (s *GravityTurnAutopilot) predict() physics.OrbitPrediction {
+	orbit, _ := s.orbit.PredictOrbit()
+	return orbit
+}
+
+func (s *GravityTurnAutopilot) Outcome() MissionOutcome {
+	if s.phase == PhaseOrbit {
+		return OutcomeOrbit
+	}
+	return ""
+}
+
+// setThrust пересчитывает долю тяги. MECO и повторное включение
+// определяются по прогнозу апоцентра, поэтому при меньшей тяге разгон
+// сам длится дольше.
+func (s *GravityTurnAutopilot) setThrust(thrust, nominal float64) {
+	s.thrustRatio = 1
+	if nominal > 0 {
+		s.thrustRatio = math.Max(thrust/nominal, 0.05)
+	}
+	if s.thrustRatio == 1 {
+		return
+	}
+
+	if thrust <= 0 {
+		s.logger.Error("all_engines_failed")
+		return
+	}
+	s.logger.Warn("thrust_reduced", logging.F("thrust_kn", thrust/1000.0), logging.F("ratio", s.thrustRatio*100))
+}
+
+func (s *GravityTurnAutopilot) Phase() string {
+	return string(s.phase)
+}
+
+func (s *GravityTurnAutopilot) transition(next AscentPhase, state protocol.RocketState) {
+	if s.phase == next {
+		return
+	}
+	s.phase = next
+
+	switch next {
+	case PhaseAscent:
+		s.logger.Info("apoapsis_dropped", logging.F("apoapsis_km", state.OrbitApoapsis/1000.0))
+	case PhaseCoast:
+		s.pid.reset()
+		s.logger.Info("meco",
+			logging.F("apoapsis_km", state.OrbitApoapsis/1000.0),
+			logging.F("altitude_km", state.Altitude/1000.0),
+			logging.F("time_to_apoapsis", s.predict().TimeToApoapsis))
+		s.logPlan()
+	case PhaseCircularize:
+		s.logger.Info("circularize", logging.F("altitude_km", state.Altitude/1000.0), logging.F("periapsis_km", state.OrbitPeriapsis/1000.0))
+	case PhaseOrbit:
+		s.logger.Info("orbit_reached",
+			logging.F("apoapsis_km", state.OrbitApoapsis/1000.0),
+			logging.F("periapsis_km", state.OrbitPeriapsis/1000.0),
+			logging.F("eccentricity", state.OrbitEccentricity),
+			logging.F("period_min", s.predict().Period/60.0),
+			logging.F("fuel", state.FuelRemaining))
+		if s.azimuth != nil {
+			s.logger.Info("orbit_inclination", logging.F("inclination", state.OrbitInclination), logging.F("target_inclination", s.azimuth.inclination))
+		}
+	case PhaseTransferBurn:
+		plan := s.transfer.plan
+		s.transfer.start = state.Time
+		s.logger.Info("transfer_plan",
+			logging.F("from_km", s.transfer.from/1000.0),
+			logging.F("target_km", s.transfer.target/1000.0),
+			logging.F("delta_v1", plan.DeltaV1),
+			logging.F("delta_v2", plan.DeltaV2),
+			logging.F("delta_v", plan.TotalDeltaV),
+			logging.F("transfer_min", plan.TransferTime/60.0))
+	case PhaseTransferCoast:
+		s.transfer.burnTime = state.Time - s.transfer.start
+		s.logger.Info("transfer_coast",
+			logging.F("burn_time", s.transfer.burnTime),
+			logging.F("apoapsis_km", state.OrbitApoapsis/1000.0),
+			logging.F("periapsis_km", state.OrbitPeriapsis/1000.0))
+	case PhaseTransferCircularize:
+		s.logger.Info("transfer_circularize", logging.F("altitude_km", state.Altitude/1000.0))
+	case PhaseFuelDepleted:
+		s.logger.Warn("fuel_depleted", logging.F("apoapsis_km", state.OrbitApoapsis/1000.0), logging.F("periapsis_km", state.OrbitPeriapsis/1000.0))
+	}
+}
+
+// logPlan выводит план скругления: сколько оно стоит и когда включаться
+func (s *GravityTurnAutopilot) logPlan() {
+	plan, err := s.orbit.PlanCircularization(s.target)
+	if err != nil {
+		s.logger.Warn("circularize_plan_failed", logging.F("error", err))
+		return
+	}
+	s.logger.Info("circularize_plan",
+		logging.F("delta_v", plan.DeltaV),
+		logging.F("burn_time", plan.BurnTime),
+		logging.F("time_to_ignition", plan.TimeToIgnition))
+	if !plan.Feasible {
+		s.logger.Warn("circularize_short", logging.F("delta_v", plan.DeltaV), logging.F("delta_v_remaining", plan.DeltaVRemaining))
+	}
+}
+
+// minOrbitPeriapsis - перицентр, при котором скругление закончено. Без
+// атмосферы стабильна любая орбита над поверхностью, поэтому берется половина
+// целевой высоты.
+func minOrbitPeriapsis(planet physics.PlanetConfig, target float64) float64 {
+	if planet.AtmosphereHeight == 0 {
+		return target * 0.5
+	}
+	return planet.AtmosphereHeight
+}
+
+// surfaceSpeed - скорость относительно вращающейся поверхности планеты
+func surfaceSpeed(state protocol.RocketState, planet physics.PlanetConfig) float64 {
+	ground := planet.SurfaceVelocity(state.Position)
+	return length(protocol.Vector3{
+		X: state.Velocity.X - ground.X,
+		Y: state.Velocity.Y - ground.Y,
+		Z: state.Velocity.Z - ground.Z,
+	})
+}
+
+// verticalSpeed - проекция скорости на направление от центра планеты
+func verticalSpeed(state protocol.RocketState) float64 {
+	p, v := state.Position, state.Velocity
+	r := p.X*p.X + p.Y*p.Y + p.Z*p.Z
+	if r == 0 {
+		return 0
+	}
+	return (p.X*v.X + p.Y*v.Y + p.Z*v.Z) / math.Sqrt(r)
+}
@@ -0,0 +1,187 @@
+package rocketclient
+
+import (
+	"io"
+	"testing"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/client/physics"
+	"cosmodrom/protocol"
+)
+
+// phaseChange - смена этапа программы полета на шаге с временем time
+type phaseChange struct {
+	time  float64
+	phase string
+}
+
+func newFakePhysics(t *testing.T, frames []physics.FakeFrame) *physics.FakePhysics {
+	t.Helper()
+	p, err := physics.NewFakePhysics(frames)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+// flyScripted проводит программу по кадрам физики p до времени duration,
+// как это делает клиент: прогноз орбиты в состоянии, команда автопилота в
+// физику. Возвращает смены этапов и состояния, по которым программа
+// выдала команды p.Commands.
+func flyScripted(t *testing.T, program Autopilot, p *physics.FakePhysics, duration, dt float64) ([]phaseChange, []protocol.RocketState) {
+	t.Helper()
+	var changes []phaseChange
+	var states []protocol.RocketState
+	for step := 0; float64(step)*dt < duration; step++ {
+		state, err := p.GetState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		orbit, err := p.PredictOrbit()
+		if err != nil {
+			t.Fatal(err)
+		}
+		setOrbit(&state, orbit)
+		phase := program.Phase()
+		command := program.Step(state, dt)
+		if program.Phase() != phase {
+			changes = append(changes, phaseChange{state.Time, program.Phase()})
+		}
+		states = append(states, state)
+		if err := p.Update(&command, dt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return changes, states
+}
+
+// ascentFrame - кадр выведения на высоте altitude с вертикальной скоростью
+// vertical и прогнозом апсид; перицентр выше атмосферы - орбита стабильна
+func ascentFrame(time, altitude, vertical, apoapsis, periapsis, fuel float64) physics.FakeFrame {
+	planet := physics.EarthDefault()
+	return physics.FakeFrame{
+		State: protocol.RocketState{
+			Time:          time,
+			Position:      protocol.Vector3{X: planet.Radius + altitude},
+			Velocity:      protocol.Vector3{X: vertical, Y: 7000},
+			Altitude:      altitude,
+			MassCurrent:   5000 + fuel,
+			FuelRemaining: fuel,
+		},
+		Orbit: &physics.OrbitPrediction{
+			Apoapsis:       apoapsis,
+			Periapsis:      periapsis,
+			IsStable:       periapsis > planet.AtmosphereHeight,
+			TimeToApoapsis: -1,
+			Period:         -1,
+		},
+	}
+}
+
+// Автопилот выведения переключает этапы по высоте и прогнозу апсид, а
+// команда каждого этапа - тангаж разворота или горизонт и полная тяга или
+// ее отсутствие
+func TestGravityTurnAutopilotPhases(t *testing.T) {
+	const target = 200000.0
+	nominal := []physics.FakeFrame{
+		ascentFrame(0, 100, 10, -1, 0, 8000),
+		ascentFrame(60, 40000, 800, 150000, -3e6, 5000),
+		ascentFrame(120, 90000, 1000, 205000, -1e6, 2000),
+		ascentFrame(150, 120000, 500, 204000, -1e6, 2000),
+		ascentFrame(200, 203500, 50, 204000, -5e5, 2000),
+		ascentFrame(260, 204000, 0, 205000, 150000, 500),
+	}
+
+	tests := []struct {
+		name    string
+		frames  []physics.FakeFrame
+		want    []phaseChange
+		outcome MissionOutcome
+	}{
+		{
+			name:    "штатное выведение",
+			frames:  nominal,
+			want:    []phaseChange{{120, "coast"}, {200, "circularize"}, {260, "orbit"}},
+			outcome: OutcomeOrbit,
+		},
+		{
+			name: "просадка апоцентра после MECO",
+			frames: []physics.FakeFrame{
+				nominal[0], nominal[1], nominal[2],
+				ascentFrame(150, 120000, 500, 190000, -1e6, 2000),
+				ascentFrame(170, 130000, 480, 201000, -1e6, 1800),
+				nominal[4], nominal[5],
+			},
+			want:    []phaseChange{{120, "coast"}, {150, "ascent"}, {170, "coast"}, {200, "circularize"}, {260, "orbit"}},
+			outcome: OutcomeOrbit,
+		},
+		{
+			name:   "топливо кончилось на разгоне",
+			frames: []physics.FakeFrame{nominal[0], ascentFrame(60, 40000, 800, 150000, -3e6, 0)},
+			want:   []phaseChange{{60, "fuel_depleted"}},
+		},
+		{
+			name: "топливо кончилось на скруглении",
+			frames: []physics.FakeFrame{
+				nominal[0], nominal[1], nominal[2], nominal[3], nominal[4],
+				ascentFrame(230, 204000, 20, 204500, 50000, 0),
+			},
+			want: []phaseChange{{120, "coast"}, {200, "circularize"}, {230, "fuel_depleted"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			planet := physics.EarthDefault()
+			turn := physics.GravityTurnForOrbit(planet, target)
+			p := newFakePhysics(t, tt.frames)
+			s := newGravityTurnAutopilot(target, planet, turn, p, logging.New(io.Discard, logging.LevelInfo, false))
+			changes, states := flyScripted(t, s, p, 300, 1)
+
+			if len(changes) != len(tt.want) {
+				t.Fatalf("смены этапов %v, ожидались %v", changes, tt.want)
+			}
+			for i, want := range tt.want {
+				if changes[i] != want {
+					t.Errorf("смена %d: %v, ожидалась %v", i+1, changes[i], want)
+				}
+			}
+			if s.Outcome() != tt.outcome {
+				t.Errorf("исход %q, ожидался %q", s.Outcome(), tt.outcome)
+			}
+
+			// Команда шага выдается по этапу после его смены на этом шаге
+			phases := phasesAt(changes, "ascent", len(states))
+			for i, command := range p.Commands() {
+				state := states[i]
+				pitch, throttle := horizontalPitch, 0.0
+				switch phases[i] {
+				case "ascent":
+					pitch, throttle = turn.Pitch(state.Altitude), 1
+				case "circularize":
+					throttle = 1
+				}
+				if command.Pitch != pitch || len(command.EngineThrottle) != 1 || command.EngineThrottle[0] != throttle {
+					t.Fatalf("T+%g с, этап %s: тангаж %g, дроссели %v; ожидались %g и %g",
+						state.Time, phases[i], command.Pitch, command.EngineThrottle, pitch, throttle)
+				}
+			}
+		})
+	}
+}
+
+// phasesAt - этап на каждом из steps шагов по сменам этапов при шаге 1 с
+func phasesAt(changes []phaseChange, initial string, steps int) []string {
+	phases := make([]string, steps)
+	phase := initial
+	for i := range phases {
+		for _, change := range changes {
+			if change.time == float64(i) {
+				phase = change.phase
+			}
+		}
+		phases[i] = phase
+	}
+	return phases
+}
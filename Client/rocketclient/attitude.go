@@ -0,0 +1,115 @@
+package rocketclient
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/client/physics"
+	"cosmodrom/protocol"
+)
+
+// Ниже этой высоты (и в атмосфере) скорость для prograde, retrograde и
+// radial_out берется относительно поверхности: на столе инерциальная скорость -
+// это вращение планеты, и prograde положил бы ракету на бок
+const attitudeSurfaceAltitude = 10000.0 // м
+
+// parseAttitude разбирает значение -attitude и поля attitude сценария:
+// prograde, retrograde, radial_out, none или surface_pitch:градусы
+func parseAttitude(value string) (protocol.AttitudeHold, error) {
+	mode, pitch, hasPitch := strings.Cut(strings.ReplaceAll(value, "-", "_"), ":")
+	hold := protocol.AttitudeHold{Mode: protocol.AttitudeMode(mode)}
+
+	if hold.Mode == protocol.AttitudeSurfacePitch {
+		if !hasPitch {
+			return hold, fmt.Errorf("для surface_pitch нужен угол, например surface_pitch:45")
+		}
+		var err error
+		if hold.Pitch, err = strconv.ParseFloat(pitch, 64); err != nil {
+			return hold, fmt.Errorf("некорректный угол %q", pitch)
+		}
+	} else if hasPitch {
+		return hold, fmt.Errorf("угол задается только для surface_pitch")
+	}
+
+	if err := protocol.ValidateAttitudeHold(&hold); err != nil {
+		return hold, err
+	}
+	return hold, nil
+}
+
+// attitudeControl удерживает ориентацию. Режим задают -attitude, сценарий и
+// команды сервера; он заменяет тангаж и рыскание действующей команды, будь
+// то автопилот или команда сервера.
+type attitudeControl struct {
+	hold   protocol.AttitudeHold
+	mu     sync.Mutex
+	logger *logging.Logger
+}
+
+func (a *attitudeControl) set(hold protocol.AttitudeHold, source string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if hold == a.hold {
+		return
+	}
+	a.hold = hold
+	switch hold.Mode {
+	case protocol.AttitudeNone, "":
+		a.logger.Info("attitude_released", logging.F("source", source))
+	case protocol.AttitudeSurfacePitch:
+		a.logger.Info("attitude_pitch", logging.F("pitch", hold.Pitch), logging.F("source", source))
+	default:
+		a.logger.Info("attitude_hold", logging.F("mode", hold.Mode), logging.F("source", source))
+	}
+}
+
+func (a *attitudeControl) apply(command *protocol.ControlCommand, state protocol.RocketState, planet physics.PlanetConfig) {
+	a.mu.Lock()
+	hold := a.hold
+	a.mu.Unlock()
+
+	var direction protocol.Vector3
+	switch hold.Mode {
+	case protocol.AttitudeNone, "":
+		return
+	case protocol.AttitudeSurfacePitch:
+		command.Pitch = hold.Pitch
+		command.Yaw = 0
+		return
+	case protocol.AttitudePrograde:
+		direction = attitudeVelocity(state, planet)
+	case protocol.AttitudeRetrograde:
+		direction = scale(attitudeVelocity(state, planet), -1)
+	case protocol.AttitudeRadialOut:
+		// Составляющая "вверх", перпендикулярная скорости
+		up := normalize(state.Position)
+		velocity := attitudeVelocity(state, planet)
+		direction = up
+		if length(velocity) > 1e-6 {
+			v := normalize(velocity)
+			direction = subtract(up, scale(v, dot(up, v)))
+		}
+	}
+
+	// Без скорости (ракета на столе) направление не определено - держим вертикаль
+	pitch, yaw, ok := physics.AttitudeToward(state.Position, direction)
+	if !ok {
+		pitch, yaw = 0, 0
+	}
+	command.Pitch = pitch
+	command.Yaw = yaw
+}
+
+// attitudeVelocity - скорость, по которой ориентируется ракета: у поверхности
+// и в атмосфере относительно поверхности, выше - инерциальная
+func attitudeVelocity(state protocol.RocketState, planet physics.PlanetConfig) protocol.Vector3 {
+	if state.Altitude < math.Max(planet.AtmosphereHeight, attitudeSurfaceAltitude) {
+		return subtract(state.Velocity, planet.SurfaceVelocity(state.Position))
+	}
+	return state.Velocity
+}
@@ -0,0 +1,102 @@
+package rocketclient
+
+import (
+	"sync"
+	"time"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/protocol"
+)
+
+const (
+	avoidThrottleTrim = 0.8              // Множитель тяги при уклонении
+	avoidDuration     = 10 * time.Second // Сколько действует уклонение после последнего предупреждения
+	avoidYawOffset    = 5.0              // Градусы рыскания при критическом сближении
+)
+
+// avoidance уменьшает тягу и при критическом сближении отворачивает ракету.
+// Применяется поверх программы полета к итоговой команде шага, поэтому после
+// окончания уклонения профиль выведения продолжается с того же места.
+type avoidance struct {
+	active   bool
+	critical bool
+	until    time.Time
+	other    *protocol.Vector3 // Позиция второй ракеты, если сервер ее прислал
+	logger   *logging.Logger
+	mu       sync.Mutex
+}
+
+func (a *avoidance) trigger(warning protocol.WarningMessage, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !warning.Severity.AtLeast(protocol.SeverityHigh) {
+		// Повторное предупреждение с меньшей опасностью снимает уклонение
+		if a.active {
+			a.active = false
+			a.logger.Info("avoid_cleared", logging.F("severity", warning.Severity))
+		}
+		return
+	}
+
+	critical := warning.Severity == protocol.SeverityCritical
+	if !a.active || critical != a.critical {
+		if critical {
+			a.logger.Warn("avoid_critical",
+				logging.F("other_rocket_id", warning.OtherRocketID),
+				logging.F("throttle", avoidThrottleTrim*100),
+				logging.F("yaw_offset", avoidYawOffset))
+		} else {
+			a.logger.Warn("avoid",
+				logging.F("other_rocket_id", warning.OtherRocketID),
+				logging.F("throttle", avoidThrottleTrim*100),
+				logging.F("duration", avoidDuration))
+		}
+	}
+
+	a.active = true
+	a.critical = critical
+	a.until = now.Add(avoidDuration)
+	a.other = warning.OtherPosition
+}
+
+// apply изменяет команду текущего шага. Срез дросселей копируется, чтобы не
+// менять команду автопилота.
+func (a *avoidance) apply(command *protocol.ControlCommand, state protocol.RocketState, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.active {
+		return
+	}
+	if now.After(a.until) {
+		a.active = false
+		a.logger.Info("avoid_timeout")
+		return
+	}
+
+	throttle := make([]float64, len(command.EngineThrottle))
+	for i, value := range command.EngineThrottle {
+		throttle[i] = value * avoidThrottleTrim
+	}
+	command.EngineThrottle = throttle
+
+	if a.critical {
+		command.Yaw += avoidYaw(state, a.other)
+	}
+}
+
+// avoidYaw выбирает сторону отворота: от второй ракеты относительно
+// направления полета, а без ее позиции - фиксированно вправо
+func avoidYaw(state protocol.RocketState, other *protocol.Vector3) float64 {
+	if other == nil || state.Speed < 1.0 {
+		return avoidYawOffset
+	}
+
+	up := normalize(state.Position)
+	side := cross(state.Velocity, up) // Правый борт
+	if dot(subtract(*other, state.Position), side) > 0 {
+		return -avoidYawOffset
+	}
+	return avoidYawOffset
+}
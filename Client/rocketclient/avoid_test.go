@@ -0,0 +1,82 @@
+package rocketclient
+
+import (
+	"testing"
+	"time"
+
+	"cosmodrom/protocol"
+)
+
+// Предупреждения сервера о сближении меняют команду шага, пока действует
+// уклонение, а после его снятия команда возвращается к прежней
+func TestAutoAvoid(t *testing.T) {
+	r, clock := actionTestClient(t)
+	r.autoAvoid = true
+	// Команда сервера держит тягу и ориентацию неизменными между шагами
+	hold := protocol.ControlCommand{EngineThrottle: []float64{0.5}, Pitch: 10, Yaw: 20}
+	r.setServerCommand(hold, nil)
+
+	warn := func(severity protocol.Severity, other *protocol.Vector3) {
+		r.handleWarning(protocol.Message{Type: protocol.MsgTypeWarning, Data: protocol.WarningMessage{
+			RocketID:      "test",
+			Code:          protocol.WarningCodeProximity,
+			Warning:       "сближение",
+			Severity:      severity,
+			OtherRocketID: "other",
+			OtherPosition: other,
+		}})
+	}
+	check := func(what string, throttle, yaw float64) {
+		t.Helper()
+		r.stepN(t, 1)
+		command := r.lastCommand
+		if len(command.EngineThrottle) != 1 || command.EngineThrottle[0] != throttle ||
+			command.Pitch != hold.Pitch || command.Yaw != yaw {
+			t.Fatalf("%s: дроссели %v, тангаж %g, рыскание %g; ожидались [%g], %g и %g",
+				what, command.EngineThrottle, command.Pitch, command.Yaw, throttle, hold.Pitch, yaw)
+		}
+	}
+
+	check("без предупреждений", 0.5, 20)
+
+	warn(protocol.SeverityMedium, nil)
+	check("средняя опасность", 0.5, 20)
+
+	warn(protocol.SeverityHigh, nil)
+	check("высокая опасность", 0.5*avoidThrottleTrim, 20)
+	if hold.EngineThrottle[0] != 0.5 {
+		t.Fatalf("уклонение изменило команду сервера: %v", hold.EngineThrottle)
+	}
+
+	warn(protocol.SeverityCritical, nil)
+	check("критическое сближение", 0.5*avoidThrottleTrim, 20+avoidYawOffset)
+
+	// Вторая ракета справа по борту: отворот влево
+	state, err := r.physics.GetState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	right := normalize(cross(state.Velocity, normalize(state.Position)))
+	other := protocol.Vector3{
+		X: state.Position.X + 1000*right.X,
+		Y: state.Position.Y + 1000*right.Y,
+		Z: state.Position.Z + 1000*right.Z,
+	}
+	warn(protocol.SeverityCritical, &other)
+	check("критическое сближение справа", 0.5*avoidThrottleTrim, 20-avoidYawOffset)
+
+	warn(protocol.SeverityLow, nil)
+	check("опасность снята", 0.5, 20)
+
+	// Без новых предупреждений уклонение кончается через avoidDuration
+	warn(protocol.SeverityHigh, nil)
+	check("снова высокая опасность", 0.5*avoidThrottleTrim, 20)
+	clock.now = clock.now.Add(avoidDuration + time.Second)
+	r.setServerCommand(hold, nil)
+	check("уклонение истекло", 0.5, 20)
+
+	// Без -auto-avoid предупреждения только записываются в журнал
+	r.autoAvoid = false
+	warn(protocol.SeverityCritical, nil)
+	check("без -auto-avoid", 0.5, 20)
+}
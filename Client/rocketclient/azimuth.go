@@ -0,0 +1,98 @@
+package rocketclient
+
+import (
+	"fmt"
+	"math"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/client/physics"
+	"cosmodrom/protocol"
+)
+
+// launchAzimuth - инерциальный азимут пуска (градусы от севера по часовой
+// стрелке) на орбиту с наклонением inclination со широты latitude:
+// sin(азимут) = cos(наклонение) / cos(широта). Берется пуск на север, через
+// восходящий узел. Наклонение меньше широты недостижимо без маневра.
+func launchAzimuth(latitude, inclination float64) (float64, error) {
+	if inclination < 0 || inclination > 180 {
+		return 0, fmt.Errorf("наклонение %.1f° вне диапазона 0-180°", inclination)
+	}
+	lat := math.Abs(latitude)
+	if inclination < lat-1e-9 || inclination > 180-lat+1e-9 {
+		return 0, fmt.Errorf("наклонение %.1f° недостижимо со широты %.1f°: допустимо от %.1f° до %.1f°",
+			inclination, latitude, lat, 180-lat)
+	}
+	sine := math.Cos(inclination*math.Pi/180.0) / math.Cos(lat*math.Pi/180.0)
+	return math.Asin(math.Max(-1, math.Min(1, sine))) * 180.0 / math.Pi, nil
+}
+
+// rotatingAzimuth поправляет инерциальный азимут на вращение планеты:
+// стартовая скорость surfaceSpeed уже направлена на восток, и набирать нужно
+// только разницу с орбитальной скоростью orbitalSpeed
+func rotatingAzimuth(azimuth, orbitalSpeed, surfaceSpeed float64) float64 {
+	rad := azimuth * math.Pi / 180.0
+	east := orbitalSpeed*math.Sin(rad) - surfaceSpeed
+	north := orbitalSpeed * math.Cos(rad)
+	return math.Atan2(east, north) * 180.0 / math.Pi
+}
+
+// azimuthSteering держит плоскость орбиты с заданным наклонением (-target-inclination).
+// Рыскание направляет горизонтальную тягу на недостающую скорость: разницу
+// между орбитальной скоростью по азимуту для текущей широты и горизонтальной
+// скоростью ракеты. На старте это азимут с поправкой на вращение планеты,
+// дальше - коррекция накопленного ухода плоскости.
+type azimuthSteering struct {
+	inclination  float64 // градусы
+	orbitalSpeed float64 // м/с, круговая скорость на целевой высоте
+}
+
+func newAzimuthSteering(inclination, latitude, targetAltitude float64, planet physics.PlanetConfig, logger *logging.Logger) (*azimuthSteering, error) {
+	azimuth, err := launchAzimuth(latitude, inclination)
+	if err != nil {
+		return nil, err
+	}
+	s := &azimuthSteering{
+		inclination:  inclination,
+		orbitalSpeed: math.Sqrt(protocol.GConstant * planet.Mass / (planet.Radius + targetAltitude)),
+	}
+	surface := planet.RotationRate() * planet.Radius * math.Cos(latitude*math.Pi/180.0)
+	logger.Info("launch_azimuth",
+		logging.F("inclination", inclination),
+		logging.F("azimuth", rotatingAzimuth(azimuth, s.orbitalSpeed, surface)),
+		logging.F("inertial_azimuth", azimuth))
+	return s, nil
+}
+
+// yaw - рыскание в осях движка (от востока к югу) для текущего состояния
+func (s *azimuthSteering) yaw(state protocol.RocketState) float64 {
+	up := normalize(state.Position)
+	east := cross(protocol.Vector3{Z: 1}, up)
+	if length(east) < 0.01 {
+		east = cross(protocol.Vector3{X: 1}, up)
+	}
+	east = normalize(east)
+	north := cross(up, east)
+
+	// Над широтами выше наклонения азимут не определен: держим восток или запад
+	latitude := math.Asin(math.Max(-1, math.Min(1, up.Z))) * 180.0 / math.Pi
+	azimuth, err := launchAzimuth(latitude, s.inclination)
+	if err != nil {
+		azimuth = 90.0
+		if s.inclination > 90 {
+			azimuth = -90.0
+		}
+	}
+	// После самой северной точки орбиты ракета идет на юг, азимут зеркальный
+	horizontal := subtract(state.Velocity, scale(up, dot(state.Velocity, up)))
+	if dot(horizontal, north) < -1.0 {
+		azimuth = 180.0 - azimuth
+	}
+	rad := azimuth * math.Pi / 180.0
+	target := scale(add(scale(north, math.Cos(rad)), scale(east, math.Sin(rad))), s.orbitalSpeed)
+
+	missing := subtract(target, horizontal)
+	if length(missing) < 1.0 {
+		missing = target
+	}
+	return math.Atan2(-dot(missing, north), dot(missing, east)) * 180.0 / math.Pi
+}
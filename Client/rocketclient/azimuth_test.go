@@ -0,0 +1,82 @@
+package rocketclient
+
+import (
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/client/physics"
+	"cosmodrom/protocol"
+)
+
+func TestLaunchAzimuth(t *testing.T) {
+	tests := []struct {
+		name        string
+		latitude    float64
+		inclination float64
+		want        float64
+		wantErr     string
+	}{
+		{name: "экваториальная с экватора", latitude: 0, inclination: 0, want: 90},
+		{name: "экваториальная с 45°", latitude: 45, inclination: 0, wantErr: "недостижимо"},
+		{name: "полярная с 45°", latitude: 45, inclination: 90, want: 0},
+		{name: "51.6° с 45°", latitude: 45, inclination: 51.6, want: 61.45},
+		{name: "51.6° с 45° ю.ш.", latitude: -45, inclination: 51.6, want: 61.45},
+		{name: "наклонение равно широте", latitude: 45, inclination: 45, want: 90},
+		{name: "ретроградная с 45°", latitude: 45, inclination: 128.4, want: -61.45},
+		{name: "больше 180°", latitude: 0, inclination: 190, wantErr: "вне диапазона"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := launchAzimuth(tt.latitude, tt.inclination)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ошибка %v, ожидалась содержащая %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(got-tt.want) > 0.01 {
+				t.Errorf("азимут %.3f°, ожидался %.2f°", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRotatingAzimuth(t *testing.T) {
+	// Полярная орбита с 45°: тяга отклоняется на запад, чтобы погасить
+	// восточную скорость вращения
+	got := rotatingAzimuth(0, 7784, 329)
+	if want := -math.Atan2(329, 7784) * 180 / math.Pi; math.Abs(got-want) > 1e-9 {
+		t.Errorf("азимут %.3f°, ожидался %.3f°", got, want)
+	}
+	// Без вращения поправки нет
+	if got := rotatingAzimuth(61.45, 7784, 0); math.Abs(got-61.45) > 1e-9 {
+		t.Errorf("азимут %.3f° без вращения, ожидался 61.45°", got)
+	}
+}
+
+func TestAzimuthSteeringYaw(t *testing.T) {
+	planet := physics.EarthDefault()
+	logger := logging.New(io.Discard, logging.LevelInfo, false)
+	steering, err := newAzimuthSteering(51.6, 45, 200000, planet, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// На столе горизонтальная скорость - вращение планеты, рыскание - это
+	// азимут с поправкой на вращение в осях движка (от востока к югу)
+	position := planet.Position(45, 63, 0)
+	state := protocol.RocketState{Position: position, Velocity: planet.SurfaceVelocity(position)}
+	azimuth, _ := launchAzimuth(45, 51.6)
+	surface := planet.RotationRate() * planet.Radius * math.Cos(45*math.Pi/180)
+	want := rotatingAzimuth(azimuth, steering.orbitalSpeed, surface) - 90
+	if got := steering.yaw(state); math.Abs(got-want) > 0.05 {
+		t.Errorf("рыскание на старте %.2f°, ожидалось %.2f°", got, want)
+	}
+}
@@ -0,0 +1,156 @@
+package rocketclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/protocol"
+)
+
+const (
+	blackBoxWindow   = 30.0 // с, сколько последних секунд полета хранится
+	blackBoxMessages = 16   // Сколько последних сообщений сервера хранится
+)
+
+type blackBoxSample struct {
+	State   protocol.RocketState    `json:"state"`
+	Command protocol.ControlCommand `json:"command"`
+}
+
+type blackBoxMessage struct {
+	ReceivedAt time.Time            `json:"received_at"`
+	Type       protocol.MessageType `json:"type"`
+	Data       interface{}          `json:"data"`
+}
+
+type blackBoxDump struct {
+	RocketID string                `json:"rocket_id"`
+	DumpedAt time.Time             `json:"dumped_at"`
+	Config   protocol.RocketConfig `json:"config"`
+	Samples  []blackBoxSample      `json:"samples"`
+	Messages []blackBoxMessage     `json:"messages"`
+}
+
+// blackBox хранит последние шаги физики в кольцевом буфере. Буфер и срезы
+// дросселей выделяются заранее, поэтому запись шага не выделяет память.
+// Шаги пишутся и выгружаются из цикла run, сообщения - из receiveMessages.
+type blackBox struct {
+	samples []blackBoxSample
+	next    int
+	full    bool
+
+	messages     [blackBoxMessages]blackBoxMessage
+	messageCount int
+	mu           sync.Mutex // Защищает messages
+}
+
+func newBlackBox(window, dt float64, engines int) *blackBox {
+	size := int(math.Ceil(window / dt))
+	if size < 1 {
+		size = 1
+	}
+	b := &blackBox{samples: make([]blackBoxSample, size)}
+	for i := range b.samples {
+		b.samples[i].Command.EngineThrottle = make([]float64, engines)
+	}
+	return b
+}
+
+func (b *blackBox) record(state protocol.RocketState, command protocol.ControlCommand) {
+	sample := &b.samples[b.next]
+	throttle := sample.Command.EngineThrottle
+	n := copy(throttle, command.EngineThrottle)
+
+	sample.State = state
+	sample.State.Guidance = nil
+	sample.Command = command
+	sample.Command.EngineThrottle = throttle[:n]
+
+	b.next++
+	if b.next == len(b.samples) {
+		b.next = 0
+		b.full = true
+	}
+}
+
+// ordered возвращает шаги от старого к новому
+func (b *blackBox) ordered() []blackBoxSample {
+	if !b.full {
+		return append(make([]blackBoxSample, 0, b.next), b.samples[:b.next]...)
+	}
+	out := make([]blackBoxSample, 0, len(b.samples))
+	out = append(out, b.samples[b.next:]...)
+	return append(out, b.samples[:b.next]...)
+}
+
+// last - последний записанный шаг
+func (b *blackBox) last() (blackBoxSample, bool) {
+	if !b.full && b.next == 0 {
+		return blackBoxSample{}, false
+	}
+	i := b.next - 1
+	if i < 0 {
+		i = len(b.samples) - 1
+	}
+	return b.samples[i], true
+}
+
+func (b *blackBox) recordMessage(msg protocol.Message, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.messages[b.messageCount%blackBoxMessages] = blackBoxMessage{ReceivedAt: now, Type: msg.Type, Data: msg.Data}
+	b.messageCount++
+}
+
+func (b *blackBox) recentMessages() []blackBoxMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.messageCount <= blackBoxMessages {
+		return append(make([]blackBoxMessage, 0, b.messageCount), b.messages[:b.messageCount]...)
+	}
+	start := b.messageCount % blackBoxMessages
+	out := make([]blackBoxMessage, 0, blackBoxMessages)
+	out = append(out, b.messages[start:]...)
+	return append(out, b.messages[:start]...)
+}
+
+// dump записывает содержимое черного ящика в blackbox_<id>_<время>.json.
+// Использует только собственные данные, поэтому не зависит от соединения и физики.
+func (b *blackBox) dump(rocketID string, config protocol.RocketConfig, now time.Time) (string, error) {
+	path := fmt.Sprintf("blackbox_%s_%s.json", rocketID, now.Format("20060102-150405"))
+
+	data, err := json.MarshalIndent(blackBoxDump{
+		RocketID: rocketID,
+		DumpedAt: now,
+		Config:   config,
+		Samples:  b.ordered(),
+		Messages: b.recentMessages(),
+	}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("не удалось сериализовать черный ящик: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("не удалось записать черный ящик: %w", err)
+	}
+	return path, nil
+}
+
+func (r *RocketClient) dumpBlackBox() {
+	// Пакетные прогоны не оставляют файлов на каждое падение
+	if r.launch.Unpaced {
+		return
+	}
+	path, err := r.blackBox.dump(r.ID, r.config, r.clock.Now())
+	if err != nil {
+		r.logger.Error("blackbox_failed", logging.F("error", err))
+		return
+	}
+	r.logger.Info("blackbox_saved", logging.F("window", blackBoxWindow), logging.F("path", path))
+}
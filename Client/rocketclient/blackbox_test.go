@@ -0,0 +1,131 @@
+package rocketclient
+
+import (
+	"encoding/json"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"cosmodrom/protocol"
+)
+
+// blackBoxTimes - время каждого шага в буфере от старого к новому
+func blackBoxTimes(samples []blackBoxSample) []float64 {
+	times := make([]float64, len(samples))
+	for i, sample := range samples {
+		times[i] = sample.State.Time
+	}
+	return times
+}
+
+func TestBlackBoxRing(t *testing.T) {
+	// Окно 0.5 с при шаге 0.1 с - пять шагов
+	b := newBlackBox(0.5, 0.1, 2)
+	if _, ok := b.last(); ok {
+		t.Fatal("пустой черный ящик вернул последний шаг")
+	}
+
+	throttle := []float64{1, 0.5}
+	record := func(from, to int) {
+		for i := from; i <= to; i++ {
+			throttle[0] = float64(i) / 10
+			state := protocol.RocketState{Time: float64(i), Guidance: &protocol.GuidanceStatus{}}
+			b.record(state, protocol.ControlCommand{EngineThrottle: throttle, Pitch: float64(i)})
+		}
+	}
+
+	record(1, 3)
+	if got := blackBoxTimes(b.ordered()); !slices.Equal(got, []float64{1, 2, 3}) {
+		t.Fatalf("до заполнения шаги %v, ожидались [1 2 3]", got)
+	}
+
+	// Ровно заполненный буфер и переход через конец
+	record(4, 5)
+	if got := blackBoxTimes(b.ordered()); !slices.Equal(got, []float64{1, 2, 3, 4, 5}) {
+		t.Fatalf("заполненный буфер %v", got)
+	}
+	record(6, 12)
+	samples := b.ordered()
+	if got := blackBoxTimes(samples); !slices.Equal(got, []float64{8, 9, 10, 11, 12}) {
+		t.Fatalf("после перехода через конец шаги %v, ожидались [8 ... 12]", got)
+	}
+	for _, sample := range samples {
+		i := sample.State.Time
+		// Дроссели скопированы: запись не видит последующих изменений среза
+		if sample.Command.Pitch != i || !slices.Equal(sample.Command.EngineThrottle, []float64{i / 10, 0.5}) {
+			t.Errorf("шаг %g: команда %+v", i, sample.Command)
+		}
+		if sample.State.Guidance != nil {
+			t.Errorf("шаг %g хранит состояние наведения", i)
+		}
+	}
+	if last, ok := b.last(); !ok || last.State.Time != 12 {
+		t.Errorf("последний шаг %g (%v), ожидался 12", last.State.Time, ok)
+	}
+
+	// После отделения ступени двигателей меньше, чем при создании буфера
+	b.record(protocol.RocketState{Time: 13}, protocol.ControlCommand{EngineThrottle: []float64{0.7}})
+	if last, _ := b.last(); !slices.Equal(last.Command.EngineThrottle, []float64{0.7}) {
+		t.Errorf("дроссели %v, ожидались [0.7]", last.Command.EngineThrottle)
+	}
+}
+
+func TestBlackBoxDump(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	b := newBlackBox(0.3, 0.1, 1)
+	for i := 1; i <= 7; i++ {
+		b.record(protocol.RocketState{Time: float64(i), Altitude: float64(i) * 100}, protocol.ControlCommand{EngineThrottle: []float64{1}})
+	}
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < blackBoxMessages+4; i++ {
+		msgType := protocol.MsgTypeCommand
+		if i%2 == 1 {
+			msgType = protocol.MsgTypeWarning
+		}
+		b.recordMessage(protocol.Message{Type: msgType}, start.Add(time.Duration(i)*time.Second))
+	}
+
+	path, err := b.dump("r1", protocol.RocketConfig{Name: "Ракета"}, start.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "blackbox_r1_20260301-120100.json" {
+		t.Errorf("файл %s", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dump blackBoxDump
+	if err := json.Unmarshal(data, &dump); err != nil {
+		t.Fatal(err)
+	}
+
+	if dump.RocketID != "r1" || dump.Config.Name != "Ракета" || !dump.DumpedAt.Equal(start.Add(time.Minute)) {
+		t.Errorf("заголовок выгрузки %+v", dump)
+	}
+	if got := blackBoxTimes(dump.Samples); !slices.Equal(got, []float64{5, 6, 7}) {
+		t.Errorf("шаги %v, ожидались [5 6 7]", got)
+	}
+	for _, sample := range dump.Samples {
+		if sample.State.Altitude != sample.State.Time*100 || !slices.Equal(sample.Command.EngineThrottle, []float64{1}) {
+			t.Errorf("шаг %+v", sample)
+		}
+	}
+	// Хранятся последние blackBoxMessages сообщений от старого к новому
+	if len(dump.Messages) != blackBoxMessages {
+		t.Fatalf("сообщений %d, ожидалось %d", len(dump.Messages), blackBoxMessages)
+	}
+	for i, msg := range dump.Messages {
+		wantAt := start.Add(time.Duration(i+4) * time.Second)
+		wantType := protocol.MsgTypeCommand
+		if (i+4)%2 == 1 {
+			wantType = protocol.MsgTypeWarning
+		}
+		if !msg.ReceivedAt.Equal(wantAt) || msg.Type != wantType {
+			t.Errorf("сообщение %d: %s в %v, ожидалось %s в %v", i, msg.Type, msg.ReceivedAt, wantType, wantAt)
+		}
+	}
+}
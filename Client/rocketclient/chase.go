@@ -0,0 +1,246 @@
+package rocketclient
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/client/physics"
+	"cosmodrom/protocol"
+)
+
+type ChasePhase string
+
+const (
+	ChasePhaseWait     ChasePhase = "wait"     // Телеметрии цели еще нет
+	ChasePhaseApproach ChasePhase = "approach" // Сближение с точкой позади цели
+	ChasePhaseStation  ChasePhase = "station"  // Скорость уравнена, удержание строя
+	ChasePhaseLost     ChasePhase = "lost"     // Цель пропала, ориентация удерживается
+)
+
+const (
+	chaseApproachGain   = 0.05            // 1/с, желаемая скорость сближения на метр до точки
+	chaseApproachSpeed  = 200.0           // м/с, предел скорости сближения
+	chaseResponseTime   = 5.0             // с, за сколько гасится ошибка скорости
+	chaseStationRadius  = 200.0           // м, ближе этого к точке строй считается занятым
+	chaseStaleAfter     = 5 * time.Second // Столько без телеметрии цели - цель потеряна
+	chaseObserverSuffix = "-chase"
+)
+
+// chaseProgram ведет ракету за другой ракетой (-mode chase). Телеметрию цели
+// дает отдельное соединение наблюдателя: сервер рассылает ее подписчикам.
+// Ракета идет к точке на offset метров позади цели вдоль ее скорости и
+// уравнивает скорость с точностью tolerance. Когда цель уходит с сервера или
+// перестает присылать телеметрию, ориентация замораживается, двигатели
+// выключаются до ее возвращения.
+type chaseProgram struct {
+	target    string
+	offset    float64 // м
+	tolerance float64 // м/с
+	thrust    float64 // Тяга исправных двигателей, Н
+	clock     protocol.Clock
+	logger    *logging.Logger
+
+	mu       sync.Mutex
+	state    protocol.RocketState // Последняя телеметрия цели
+	received time.Time            // Когда она получена; нулевое - телеметрии нет
+	left     string               // Причина rocket_left, пока цель не вернулась
+
+	// Дальше - только из цикла run
+	phase        ChasePhase
+	pitch, yaw   float64   // Ориентация на последнем шаге с целью
+	distance     float64   // м, до цели
+	closingSpeed float64   // м/с, положительная - сближение
+	known        bool      // distance и closingSpeed посчитаны
+	throttle     []float64 // Дроссели последней команды
+}
+
+func newChaseProgram(target string, offset, tolerance, thrust float64, clock protocol.Clock, logger *logging.Logger) *chaseProgram {
+	return &chaseProgram{
+		clock:     clock,
+		target:    target,
+		offset:    offset,
+		tolerance: tolerance,
+		thrust:    thrust,
+		logger:    logger,
+		phase:     ChasePhaseWait,
+	}
+}
+
+func (c *chaseProgram) setThrust(thrust, nominal float64) {
+	c.thrust = thrust
+}
+
+func (c *chaseProgram) Step(state protocol.RocketState, dt float64) protocol.ControlCommand {
+	target, ok := c.targetState(c.clock.Now())
+	if !ok {
+		return c.hold()
+	}
+	if c.phase == ChasePhaseLost {
+		c.logger.Info("chase_target_back", logging.F("target", c.target))
+	}
+
+	relative := subtract(target.Position, state.Position)
+	relativeVelocity := subtract(target.Velocity, state.Velocity)
+	c.distance = length(relative)
+	c.closingSpeed = 0
+	if c.distance > 0 {
+		c.closingSpeed = -dot(relative, relativeVelocity) / c.distance
+	}
+	c.known = true
+
+	// Точка строя - позади цели вдоль ее скорости
+	aim := target.Position
+	if length(target.Velocity) > 1.0 {
+		aim = subtract(aim, scale(normalize(target.Velocity), c.offset))
+	}
+	toAim := subtract(aim, state.Position)
+	approach := scale(toAim, chaseApproachGain)
+	if speed := length(approach); speed > chaseApproachSpeed {
+		approach = scale(approach, chaseApproachSpeed/speed)
+	}
+
+	// Гравитация действует на обе ракеты почти одинаково, поэтому тяга нужна
+	// только на разницу скоростей
+	velocityError := add(relativeVelocity, approach)
+	// Из строя выходим при вдвое большем отклонении, чтобы этап не дребезжал
+	margin := 1.0
+	if c.phase == ChasePhaseStation {
+		margin = 2.0
+	}
+	radius := math.Max(chaseStationRadius, 0.2*c.offset)
+	station := length(velocityError) < margin*c.tolerance && length(toAim) < margin*radius
+	if station && c.phase != ChasePhaseStation {
+		c.logger.Info("chase_station", logging.F("target", c.target), logging.F("distance", c.distance))
+	} else if !station && c.phase == ChasePhaseStation {
+		c.logger.Info("chase_approach", logging.F("target", c.target))
+	}
+	if station {
+		c.phase = ChasePhaseStation
+	} else {
+		c.phase = ChasePhaseApproach
+	}
+
+	throttle := 0.0
+	// Зона нечувствительности уже допуска, чтобы ошибка скорости укладывалась в него
+	if length(velocityError) >= c.tolerance/2 && c.thrust > 0 {
+		if pitch, yaw, ok := physics.AttitudeToward(state.Position, velocityError); ok {
+			c.pitch, c.yaw = pitch, yaw
+		}
+		acceleration := length(velocityError) / chaseResponseTime
+		throttle = math.Min(1.0, acceleration*state.MassCurrent/c.thrust)
+	}
+	c.throttle = append(c.throttle[:0], throttle)
+	return protocol.ControlCommand{EngineThrottle: c.throttle, Pitch: c.pitch, Yaw: c.yaw}
+}
+
+// hold замораживает ориентацию и выключает двигатели, пока цели нет
+func (c *chaseProgram) hold() protocol.ControlCommand {
+	c.known = false
+	return protocol.ControlCommand{Pitch: c.pitch, Yaw: c.yaw}
+}
+
+// targetState возвращает телеметрию цели, продвинутую на время с момента
+// получения. ok = false, если цели нет; потеря цели пишется в журнал один раз.
+func (c *chaseProgram) targetState(now time.Time) (protocol.RocketState, bool) {
+	c.mu.Lock()
+	state, received, left := c.state, c.received, c.left
+	c.mu.Unlock()
+
+	reason := ""
+	switch {
+	case left != "":
+		reason = fmt.Sprintf("цель покинула сервер (%s)", left)
+	case received.IsZero():
+		return state, false
+	case now.Sub(received) > chaseStaleAfter:
+		reason = fmt.Sprintf("нет телеметрии %v", now.Sub(received).Round(time.Second))
+	}
+	if reason != "" {
+		if c.phase != ChasePhaseLost {
+			c.phase = ChasePhaseLost
+			c.logger.Warn("chase_target_lost", logging.F("target", c.target), logging.F("reason", reason))
+		}
+		return state, false
+	}
+
+	elapsed := math.Max(now.Sub(received).Seconds(), 0) // Часы могли перевести назад
+	state.Position.X += state.Velocity.X * elapsed
+	state.Position.Y += state.Velocity.Y * elapsed
+	state.Position.Z += state.Velocity.Z * elapsed
+	return state, true
+}
+
+func (c *chaseProgram) Outcome() MissionOutcome {
+	return ""
+}
+
+func (c *chaseProgram) Phase() string {
+	return string(c.phase)
+}
+
+// relative - расстояние до цели и скорость сближения для строки состояния и
+// записи полета; ok = false, пока цели нет
+func (c *chaseProgram) relative() (distance, closingSpeed float64, ok bool) {
+	if c == nil || !c.known {
+		return 0, 0, false
+	}
+	return c.distance, c.closingSpeed, true
+}
+
+func (c *chaseProgram) statusFields() []logging.Field {
+	distance, closing, ok := c.relative()
+	if !ok {
+		return []logging.Field{logging.F("target_hidden", c.target)}
+	}
+	return []logging.Field{logging.F("target_distance_km", distance/1000.0), logging.F("closing", closing)}
+}
+
+// Subscribed, Message и Lost - ObserverHandler подписки на телеметрию цели
+func (c *chaseProgram) Subscribed() {
+	c.logger.Info("chase_subscribed", logging.F("target", c.target))
+}
+
+func (c *chaseProgram) Lost(err error) {
+	c.logger.Warn("chase_watch_lost", logging.F("error", err))
+}
+
+func (c *chaseProgram) Message(msg protocol.Message) {
+	switch msg.Type {
+	case protocol.MsgTypeBroadcast:
+		broadcast, err := protocol.DecodeData[protocol.BroadcastMessage](msg)
+		if err != nil || broadcast.RocketID != c.target {
+			return
+		}
+		c.mu.Lock()
+		c.state = broadcast.State
+		c.received = c.clock.Now()
+		c.left = ""
+		c.mu.Unlock()
+
+	case protocol.MsgTypeRocketJoined:
+		joined, err := protocol.DecodeData[protocol.RocketJoinedMessage](msg)
+		if err != nil || joined.RocketID != c.target {
+			return
+		}
+		c.logger.Info("chase_target_joined", logging.F("target", c.target), logging.F("name", joined.Name))
+		c.mu.Lock()
+		c.left = ""
+		c.mu.Unlock()
+
+	case protocol.MsgTypeRocketLeft:
+		left, err := protocol.DecodeData[protocol.RocketLeftMessage](msg)
+		if err != nil || left.RocketID != c.target {
+			return
+		}
+		reason := left.Reason
+		if reason == "" {
+			reason = "без причины"
+		}
+		c.mu.Lock()
+		c.left = reason
+		c.mu.Unlock()
+	}
+}
@@ -0,0 +1,112 @@
+package rocketclient
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/client/physics"
+)
+
+// checkpointInterval - как часто (по реальному времени) сохраняется снимок -checkpoint-file
+const checkpointInterval = 10 * time.Second
+
+// checkpointer периодически сохраняет снимок физики, чтобы прерванный полет
+// можно было продолжить с -resume-from. Файл заменяется атомарно, поэтому
+// при аварийном завершении в нем остается последний целый снимок. Как и
+// flightRecorder, после первой ошибки записи сохранение прекращается.
+type checkpointer struct {
+	path   string
+	next   time.Time
+	failed bool
+	logger *logging.Logger
+}
+
+func newCheckpointer(path string, logger *logging.Logger) *checkpointer {
+	if path == "" {
+		return nil
+	}
+	return &checkpointer{path: path, logger: logger}
+}
+
+// update сохраняет снимок, если с прошлого сохранения прошло checkpointInterval
+func (c *checkpointer) update(p physics.PhysicsEngine, now time.Time) {
+	if c == nil || c.failed || now.Before(c.next) {
+		return
+	}
+	c.next = now.Add(checkpointInterval)
+	c.save(p)
+}
+
+// save сохраняет снимок сразу; безопасен для nil
+func (c *checkpointer) save(p physics.PhysicsEngine) {
+	if c == nil || c.failed {
+		return
+	}
+	data, err := p.Snapshot()
+	if err == nil {
+		err = writeFileAtomic(c.path, data)
+	}
+	if err != nil {
+		c.failed = true
+		c.logger.Error("checkpoint_failed", logging.F("path", c.path), logging.F("error", err))
+	}
+}
+
+// writeFileAtomic пишет во временный файл рядом с path и переименовывает его
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	// CreateTemp создает файл с правами 0600, а снимок - обычный файл, как запись полета
+	err = tmp.Chmod(0o644)
+	if err == nil {
+		_, err = tmp.Write(data)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// resume продолжает полет из снимка -resume-from: физика, планета и ступень
+// берутся из снимка, автопилот подхватывает полет по текущему состоянию.
+// Предстартовый отсчет не нужен.
+func (r *RocketClient) resume(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("-resume-from: %w", err)
+	}
+	snapshot, err := physics.DecodeSnapshot(data)
+	if err != nil {
+		return fmt.Errorf("-resume-from: %w", err)
+	}
+	if snapshot.Config.Name != r.config.Name {
+		r.logger.Warn("checkpoint_vehicle_mismatch", logging.F("path", path), logging.F("saved", snapshot.Config.Name), logging.F("name", r.config.Name))
+	}
+	if err := r.physics.Restore(data); err != nil {
+		return fmt.Errorf("-resume-from: %w", err)
+	}
+	r.planet = snapshot.Planet
+	r.countdown = nil
+	r.staging.resume(snapshot.State.FuelRemaining)
+	r.command.EngineThrottle = make([]float64, len(snapshot.Config.Engines))
+	for i := range r.command.EngineThrottle {
+		r.command.EngineThrottle[i] = 1.0
+	}
+	r.logger.Info("checkpoint_resumed",
+		logging.F("time", snapshot.State.Time),
+		logging.F("path", path),
+		logging.F("altitude_km", snapshot.State.Altitude/1000.0),
+		logging.F("speed", snapshot.State.Speed))
+	return nil
+}
@@ -0,0 +1,911 @@
+package rocketclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/client/physics"
+	"cosmodrom/protocol"
+
+	"github.com/gorilla/websocket"
+)
+
+// RocketClient - одна ракета: физика, автопилот и связь с сервером.
+// Создается через New; Launch проводит полет от старта до исхода.
+type RocketClient struct {
+	ID            string // После Register с AutoID может отличаться от Config.ID
+	launch        Config // Параметры старта для Launch
+	script        *missionScript
+	logger        *logging.Logger // Журнал с ID ракеты, общий вывод у всех ракет флота
+	config        protocol.RocketConfig
+	physics       physics.PhysicsEngine
+	conn          *websocket.Conn
+	sink          TelemetrySink // Сервер или автономный режим
+	serverURL     string
+	dialer        *websocket.Dialer
+	codec         protocol.Codec // Формат сообщений на проводе
+	command       protocol.ControlCommand
+	lastCommand   protocol.ControlCommand // Команда последнего шага с отказами и ограничениями, для coast
+	burnTime      engineBurn              // Работа двигателей текущей ступени для engine_status
+	program       Autopilot
+	gravityTurn   physics.GravityTurnConfig // Программа разворота для выведения и сценария
+	staging       *staging                  // nil у одноступенчатой ракеты
+	failures      *engineFailures           // Имитация отказов двигателей, nil если выключена
+	maxQ          maxQGovernor
+	apoapsisGains pidGains // Регулятор апоцентра в режиме orbit
+	abort         abortGuard
+	guidance      waypointGuidance
+	chase         *chaseProgram // Программа полета в режиме chase, иначе nil
+	attitude      attitudeControl
+	guided        *protocol.GuidanceStatus // Последнее состояние наведения, только для run
+	autoAvoid     bool
+	avoid         avoidance
+	planet        physics.PlanetConfig // EarthDefault, если не задана -planet
+	noRotation    bool                 // Старт без скорости вращения планеты (-no-earth-rotation)
+	registered    bool
+	autoID        bool
+	telemetryHz   float64
+	dt            float64 // Шаг физики, с
+	warp          timeWarp
+	countdown     *countdown // Предстартовый отсчет, nil - старт сразу
+	burning       bool       // Двигатели работали на последнем шаге
+	orbitFailed   bool       // Ошибка прогноза орбиты уже в журнале
+
+	touchdownSpeed float64 // Скорость перед касанием: после него физика обнуляет скорость
+	stats          missionStats
+	recorder       *flightRecorder // Запись полета в CSV (-record), nil если выключена
+	checkpoint     *checkpointer   // Контрольные точки (-checkpoint-file), nil если выключены
+	blackBox       *blackBox
+	sensors        *sensorNoise // nil - автопилот видит точное состояние
+	finalState     protocol.RocketState
+	outcome        MissionOutcome // Причина остановки, выставляется один раз
+	outcomeMu      sync.Mutex
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	closeOnce sync.Once
+	clock     protocol.Clock // Метки времени сообщений и сроки команд; темп симуляции идет по таймерам
+
+	registerTimeout   time.Duration // Сколько ждать ответа на регистрацию
+	reconnectAttempts int           // 0 - без ограничения
+	reconnectMaxDelay time.Duration // Верхняя граница задержки между попытками
+	reconnecting      bool
+	serverErrors      atomic.Uint64   // Сообщений, отброшенных сервером (по сообщениям error)
+	connMu            sync.Mutex      // Защищает conn, registered и reconnecting
+	writeMu           sync.Mutex      // Сериализует запись в сокет: телеметрия и heartbeat идут из разных горутин
+	seqConn           *websocket.Conn // Соединение, к которому относится seq
+	seq               uint64          // Номер последнего отправленного сообщения, под writeMu
+	heartbeat         heartbeatMonitor
+
+	commandHold        time.Duration // Сколько команда сервера имеет приоритет над автопилотом
+	serverCommand      *protocol.ControlCommand
+	serverThrottleByID map[string]float64      // Дроссели команды сервера по ID двигателя, nil - списком
+	serverActions      protocol.ControlCommand // Действия команды сервера без дросселей
+	serverActionsUntil time.Time
+	actions            actionEdges // Действия команды прошлого шага, для переднего фронта
+	payloadDeployed    bool        // Полезная нагрузка отделена
+	serverCommandUntil time.Time
+	commandMu          sync.Mutex
+
+	events        chan Event
+	eventsMu      sync.Mutex // Защищает отправку в events и поля ниже
+	eventsClosed  bool
+	eventTime     float64
+	eventAltitude float64
+	eventPhase    string
+}
+
+func newRocketClient(id string, config protocol.RocketConfig, serverURL string, telemetryHz, dt float64) *RocketClient {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &RocketClient{
+		ID:                id,
+		config:            config,
+		serverURL:         serverURL,
+		dialer:            websocket.DefaultDialer,
+		codec:             protocol.JSON,
+		telemetryHz:       telemetryHz,
+		dt:                dt,
+		ctx:               ctx,
+		cancel:            cancel,
+		registerTimeout:   10 * time.Second,
+		reconnectAttempts: 10,
+		reconnectMaxDelay: 30 * time.Second,
+		heartbeat:         heartbeatMonitor{interval: 2 * time.Second, timeout: 5 * time.Second},
+		commandHold:       5 * time.Second,
+		stats:             missionStats{initialFuel: config.MassFuel},
+		blackBox:          newBlackBox(blackBoxWindow, dt, maxEngines(config)),
+		planet:            physics.EarthDefault(),
+		events:            make(chan Event, eventBuffer),
+		clock:             protocol.SystemClock,
+	}
+	r.sink = &websocketSink{r: r}
+	r.setLogger(logging.Default())
+	return r
+}
+
+// setLogger задает журнал клиента и его компонентов. Вызывается до initPhysics.
+func (r *RocketClient) setLogger(logger *logging.Logger) {
+	r.logger = logger
+	r.avoid.logger = logger
+	r.maxQ.logger = logger
+	r.abort.logger = logger
+	r.attitude.logger = logger
+	r.warp.logger = logger
+	r.guidance.logger = logger
+}
+
+func (r *RocketClient) dial() (*websocket.Conn, error) {
+	conn, _, err := r.dialer.Dial(r.serverURL, nil)
+	if err != nil {
+		return nil, dialError(err)
+	}
+	return conn, nil
+}
+
+// writeTo отправляет сообщение в conn. Запись в websocket не допускает
+// параллельных вызовов, поэтому все отправки идут через writeMu. Номер
+// сообщения Seq начинается с 1 в каждом новом соединении.
+func (r *RocketClient) writeTo(conn *websocket.Conn, msgType protocol.MessageType, data interface{}) error {
+	r.writeMu.Lock()
+	defer r.writeMu.Unlock()
+	if conn != r.seqConn {
+		r.seqConn, r.seq = conn, 0
+	}
+	r.seq++
+	return writeMessage(conn, r.codec, protocol.Message{
+		Type:      msgType,
+		Timestamp: r.clock.Now(),
+		Seq:       r.seq,
+		Data:      data,
+	})
+}
+
+// writeMessage кодирует msg кодеком и отправляет кадром его типа
+func writeMessage(conn *websocket.Conn, codec protocol.Codec, msg protocol.Message) error {
+	payload, err := codec.Encode(msg)
+	if err != nil {
+		return err
+	}
+	frame := websocket.TextMessage
+	if codec.Binary() {
+		frame = websocket.BinaryMessage
+	}
+	return conn.WriteMessage(frame, payload)
+}
+
+// readMessage читает кадр и декодирует его кодеком. Сервер отвечает тем же
+// кодеком, что и последний кадр клиента, поэтому тип кадра не проверяется.
+func readMessage(conn *websocket.Conn, codec protocol.Codec) (protocol.Message, error) {
+	_, payload, err := conn.ReadMessage()
+	if err != nil {
+		return protocol.Message{}, err
+	}
+	return codec.Decode(payload)
+}
+
+// Logger возвращает журнал ракеты
+func (r *RocketClient) Logger() *logging.Logger {
+	return r.logger
+}
+
+// Connect подключается к серверу Config.ServerURL. С Config.Vehicle он же
+// получает конфигурацию ракеты из каталога сервера.
+func (r *RocketClient) Connect() error {
+	conn, err := r.dial()
+	if err != nil {
+		return err
+	}
+
+	r.logger.Info("connected", logging.F("server", r.serverURL))
+	if r.launch.Vehicle != "" {
+		if err := r.fetchConfig(conn, r.launch.Vehicle); err != nil {
+			conn.Close()
+			return fmt.Errorf("запрос конфигурации %q: %w", r.launch.Vehicle, err)
+		}
+	}
+
+	r.connMu.Lock()
+	r.conn = conn
+	r.connMu.Unlock()
+	return nil
+}
+
+func (r *RocketClient) registerOnce() error {
+	r.connMu.Lock()
+	conn := r.conn
+	r.connMu.Unlock()
+
+	if err := r.register(conn); err != nil {
+		return err
+	}
+
+	r.connMu.Lock()
+	r.registered = true
+	r.connMu.Unlock()
+	return nil
+}
+
+func (r *RocketClient) initPhysics(latitude, longitude, altitude, targetOrbit float64) error {
+	planet := r.planet
+	if r.noRotation {
+		planet.RotationPeriod = 0
+	}
+	initialPos := planet.Position(latitude, longitude, altitude)
+
+	var err error
+	r.physics, err = physics.NewEngine(r.launch.Physics, &r.config, initialPos)
+	if err != nil {
+		return fmt.Errorf("Ошибка инициализации физики: %w", err)
+	}
+
+	if err := r.physics.SetPlanet(planet); err != nil {
+		return fmt.Errorf("Ошибка инициализации физики: %w", err)
+	}
+	r.planet = planet
+	r.physics.SetStrictCommands(r.launch.StrictCommands)
+	r.physics.SetSlewRate(r.launch.SlewRate)
+
+	// Ракета на столе движется вместе с поверхностью
+	surface := planet.SurfaceVelocity(initialPos)
+	if err := r.physics.SetInitialVelocity(surface); err != nil {
+		return fmt.Errorf("Ошибка инициализации физики: %w", err)
+	}
+	if planet.RotationPeriod != 0 {
+		r.logger.Info("surface_rotation", logging.F("speed", length(surface)))
+	}
+
+	gtConfig := physics.GravityTurnForOrbit(planet, targetOrbit)
+	r.physics.SetGravityTurn(gtConfig)
+	r.gravityTurn = gtConfig
+	r.program = newGravityTurnAutopilot(targetOrbit, planet, gtConfig, r.physics, r.logger)
+	r.staging = newStaging(r.config.Stages, r.logger)
+
+	r.command = protocol.ControlCommand{
+		EngineThrottle: make([]float64, len(r.config.Engines)),
+		Pitch:          0.0,
+		Yaw:            0.0,
+		Roll:           0.0,
+	}
+
+	for i := range r.command.EngineThrottle {
+		r.command.EngineThrottle[i] = 1.0
+	}
+
+	r.logger.Info("physics_ready")
+	if deltaV, err := r.physics.DeltaVRemaining(); err == nil {
+		burnTime, _ := r.physics.BurnTimeRemaining(1)
+		r.logger.Info("delta_v", logging.F("delta_v", deltaV), logging.F("burn_time", burnTime))
+	}
+	r.logger.Info("gravity_turn",
+		logging.F("target_orbit_km", targetOrbit/1000.0),
+		logging.F("turn_start", gtConfig.TurnStartAlt),
+		logging.F("turn_end_km", gtConfig.TurnEndAlt/1000.0))
+	return nil
+}
+
+// Launch проводит полет: инициализирует физику в точке старта, выбирает
+// программу полета, выполняет отсчет и симуляцию до исхода миссии или
+// отмены ctx. Перед Launch клиент с сервером должен пройти Connect и
+// Register. Клиент закрывается при возврате из Launch.
+func (r *RocketClient) Launch(ctx context.Context) (MissionSummary, error) {
+	if _, ok := r.sink.(*websocketSink); ok && !r.isRegistered() {
+		r.Close()
+		return MissionSummary{}, errors.New("ракета не зарегистрирована: перед Launch вызовите Connect и Register")
+	}
+	if err := r.prepare(); err != nil {
+		r.Close()
+		return MissionSummary{}, err
+	}
+
+	stop := context.AfterFunc(ctx, r.Stop)
+	defer stop()
+
+	r.run()
+	return r.Summary(), nil
+}
+
+func (r *RocketClient) isRegistered() bool {
+	r.connMu.Lock()
+	defer r.connMu.Unlock()
+	return r.registered
+}
+
+// prepare инициализирует физику в точке старта и выбирает программу полета
+func (r *RocketClient) prepare() error {
+	cfg := r.launch
+	if err := r.initPhysics(cfg.Latitude, cfg.Longitude, cfg.Altitude, cfg.TargetOrbit); err != nil {
+		return err
+	}
+	if cfg.ResumeFrom != "" {
+		if err := r.resume(cfg.ResumeFrom); err != nil {
+			return err
+		}
+	}
+
+	flightTarget := cfg.TargetOrbit
+	if cfg.Mode == FlightModeHop {
+		flightTarget = cfg.HopAltitude
+	}
+	if err := r.setFlightMode(cfg.Mode, flightTarget); err != nil {
+		return err
+	}
+	if r.script != nil {
+		r.program = newScriptProgram(r.script, cfg.TargetOrbit, r.planet, r.gravityTurn, &r.attitude, r.logger)
+	}
+	if cfg.Autopilot != nil {
+		r.program = cfg.Autopilot
+	}
+	if r.program != r.chase {
+		r.chase = nil
+	}
+	if cfg.ResumeFrom != "" && r.staging.number() > 1 {
+		r.failures.reset(r.staging.engines())
+		r.burnTime.reset(len(r.staging.engines()))
+		r.thrustChanged()
+	}
+	return nil
+}
+
+func (r *RocketClient) run() {
+	defer r.Close()
+
+	r.sink.Start()
+	if r.chase != nil {
+		go newObserver(r.ID+chaseObserverSuffix, r.dial, r.codec, r.clock, r.reconnectMaxDelay).Run(r.ctx, r.chase)
+	}
+	if !r.runCountdown() {
+		return
+	}
+
+	dt := r.dt
+	telemetryInterval := 1.0 / r.telemetryHz
+	lastTelemetry := time.Now()
+
+	// Без реального времени каждая итерация покрывает интервал телеметрии
+	// по времени симуляции, и кадр отправляется на каждой итерации
+	var ticks <-chan time.Time
+	unpacedSteps := int(math.Max(1, math.Round(telemetryInterval/dt)))
+	if !r.launch.Unpaced {
+		ticker := time.NewTicker(time.Duration(dt * float64(time.Second)))
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+	clock := newSimClock(dt, time.Now())
+
+	r.logger.Info("simulation_start", logging.F("rocket_id", r.ID))
+	r.logger.Info("simulation_config",
+		logging.F("name", r.config.Name),
+		logging.F("engines", len(r.config.Engines)),
+		logging.F("thrust_kn", r.config.Engines[0].Thrust/1000.0))
+	for _, warning := range protocol.EngineWarnings(&r.config) {
+		r.logger.Warn("engine_warning", logging.F("warning", warning))
+	}
+
+loop:
+	for {
+		steps := unpacedSteps
+		if r.launch.Unpaced {
+			if r.ctx.Err() != nil {
+				break loop
+			}
+		} else {
+			select {
+			case <-r.ctx.Done():
+				break loop
+			case <-ticks:
+			}
+
+			var dropped float64
+			steps, dropped = clock.advance(time.Now(), r.warp.update(r.finalState, r.burning))
+			if dropped > 0 {
+				r.logger.Debug("simulation_behind", logging.F("dropped", dropped))
+			}
+		}
+
+		var state protocol.RocketState
+		var err error
+		for i := 0; i < steps; i++ {
+			state, err = r.step(dt)
+			if err != nil || state.Landed || state.Crashed {
+				break
+			}
+			// При ускорении времени двигатели не работают, и команда до конца
+			// тика не меняется: остальные шаги физика делает одним вызовом
+			if rest := steps - i - 1; rest > 1 && r.warp.current > 1 && !r.burning {
+				state, err = r.coast(dt, rest)
+				break
+			}
+		}
+		if err != nil {
+			// Физику освобождает Close: если он вызван снаружи, это остановка, а не сбой
+			if r.ctx.Err() == nil {
+				r.physicsFailure(err)
+				r.finish(OutcomeAborted)
+			}
+			break loop
+		}
+		if steps == 0 {
+			continue
+		}
+		r.finalState = state
+		r.checkpoint.update(r.physics, time.Now())
+
+		if r.launch.Unpaced || time.Since(lastTelemetry).Seconds() >= telemetryInterval {
+			r.fillOrbit(&state)
+			state.Guidance = r.guided
+			state.EngineStatus = r.engineStatus()
+
+			// При потере связи телеметрия не отправляется, но симуляция продолжается
+			r.sink.Send(state)
+			lastTelemetry = time.Now()
+
+			if r.logger.Enabled(logging.LevelDebug) {
+				r.logger.Debug("autopilot_step",
+					logging.F("time", state.Time),
+					logging.F("phase", r.phase()),
+					logging.F("pitch", r.command.Pitch),
+					logging.F("yaw", r.command.Yaw),
+					logging.F("throttle", meanThrottle(r.command.EngineThrottle)*100),
+					logging.F("altitude_km", state.Altitude/1000.0),
+					logging.F("apoapsis_km", state.OrbitApoapsis/1000.0))
+			}
+		}
+
+		outcome := terminalOutcome(state, r.program.Outcome())
+		if outcome == "" {
+			continue
+		}
+		event := r.logger.With(logging.F("time", state.Time), logging.F("altitude", state.Altitude))
+		if r.abort.aborted() {
+			event.Error("flight_aborted",
+				logging.F("rocket_id", r.ID),
+				logging.F("reason", r.abort.reason),
+				logging.F("final_altitude", state.Altitude),
+				logging.F("final_speed", r.touchdownSpeed))
+			if outcome == OutcomeCrashed {
+				r.dumpBlackBox()
+			}
+			r.finish(OutcomeAborted)
+			break loop
+		}
+
+		switch outcome {
+		case OutcomeLanded:
+			event = event.With(logging.F("touchdown_speed", r.touchdownSpeed))
+			event.Info("landed", logging.F("rocket_id", r.ID))
+			r.logger.Info("touchdown", logging.F("final_altitude", state.Altitude), logging.F("final_speed", r.touchdownSpeed))
+			r.finish(outcome)
+			break loop
+
+		case OutcomeCrashed:
+			event = event.With(logging.F("touchdown_speed", r.touchdownSpeed))
+			event.Error("crashed", logging.F("rocket_id", r.ID))
+			r.logger.Info("touchdown", logging.F("final_altitude", state.Altitude), logging.F("final_speed", r.touchdownSpeed))
+			r.dumpBlackBox()
+			r.finish(outcome)
+			break loop
+
+		case OutcomeOrbit:
+			orbit := r.predictOrbit()
+			event.With(logging.F("apoapsis", orbit.Apoapsis), logging.F("periapsis", orbit.Periapsis)).
+				Info("orbit_achieved", logging.F("rocket_id", r.ID))
+			r.finish(outcome)
+			break loop
+		}
+	}
+}
+
+// step выполняет один шаг физики. Ошибка - только если физика освобождена.
+func (r *RocketClient) step(dt float64) (protocol.RocketState, error) {
+	before, err := r.physics.GetState()
+	if err != nil {
+		return before, err
+	}
+	input := r.sensors.read(before)
+	setOrbit(&input, r.predictOrbit())
+	r.setCommand(r.program.Step(input, dt))
+	r.guided = r.guidance.steer(&r.command, before)
+	q, err := r.physics.DynamicPressure()
+	if err != nil {
+		return before, err
+	}
+	r.maxQ.observe(q, before.Time)
+	r.maxQ.apply(&r.command, q)
+
+	command := r.activeCommand(r.command)
+	r.attitude.apply(&command, before, r.planet)
+	r.avoid.apply(&command, before, r.clock.Now())
+	failed := r.failures.update(before.Time+dt, dt, before.Altitude)
+	if failed {
+		r.thrustChanged()
+	}
+	r.failures.apply(&command)
+	r.abort.apply(&command)
+	r.actions.rising(&command)
+	r.checkActions(&command)
+	r.burning = meanThrottle(command.EngineThrottle) > 0
+	r.lastCommand = command
+	// coast повторяет команду без автопилота: действия уже выполнены
+	r.lastCommand.DeployParachute, r.lastCommand.StageSeparate, r.lastCommand.DeployPayload = false, false, false
+	if err := r.physics.Update(&command, dt); err != nil {
+		return before, err
+	}
+	r.burnTime.add(r.currentEngines(), command.EngineThrottle, dt, before.FuelRemaining > 0)
+
+	state, err := r.physics.GetState()
+	if err != nil {
+		return before, err
+	}
+	r.observe(state)
+	if failed {
+		r.emit(EventEngineFailure, "")
+	}
+	r.parachuteEvents(before, state)
+	separated, err := r.staging.update(r.physics, &r.command, state, command.StageSeparate)
+	if err != nil {
+		return state, err
+	}
+	if separated {
+		if state, err = r.physics.GetState(); err != nil {
+			return state, err
+		}
+		r.failures.reset(r.staging.engines())
+		r.burnTime.reset(len(r.staging.engines()))
+		r.thrustChanged()
+		r.emit(EventStaging, fmt.Sprintf("ступень %d", r.staging.number()))
+	}
+	if command.DeployPayload {
+		if err := r.deployPayload(state); err != nil {
+			return state, err
+		}
+		if state, err = r.physics.GetState(); err != nil {
+			return state, err
+		}
+	}
+	state.Stage = r.staging.number()
+	state.PayloadDeployed = r.payloadDeployed
+	r.afterStep(before, state, command, q)
+	return state, nil
+}
+
+// setCommand переносит команду автопилота в команду шага. Дроссели
+// раскладываются по двигателям текущей ступени: их число меняется только
+// при отделении ступени.
+func (r *RocketClient) setCommand(command protocol.ControlCommand) {
+	throttle := r.command.EngineThrottle
+	for i := range throttle {
+		throttle[i] = commandThrottle(command.EngineThrottle, i)
+	}
+	command.EngineThrottle = throttle
+	r.command = command
+}
+
+// coast делает n шагов физики с командой последнего шага за один вызов
+// RunSteps. Автопилот на этих шагах не вызывается, а запись полета и черный
+// ящик получают только последнее состояние.
+func (r *RocketClient) coast(dt float64, n int) (protocol.RocketState, error) {
+	before, err := r.physics.GetState()
+	if err != nil {
+		return before, err
+	}
+	command := r.lastCommand
+	state, done, err := r.physics.RunSteps(&command, dt, n)
+	if err != nil {
+		return before, err
+	}
+	if r.failures.update(state.Time, float64(done)*dt, state.Altitude) {
+		r.thrustChanged()
+		r.emit(EventEngineFailure, "")
+	}
+	r.observe(state)
+	q, err := r.physics.DynamicPressure()
+	if err != nil {
+		return state, err
+	}
+	r.maxQ.observe(q, state.Time)
+	state.Stage = r.staging.number()
+	state.PayloadDeployed = r.payloadDeployed
+	r.afterStep(before, state, command, q)
+	return state, nil
+}
+
+// afterStep проверяет условия прекращения полета и записывает шаг в
+// статистику, запись полета и черный ящик
+func (r *RocketClient) afterStep(before, state protocol.RocketState, command protocol.ControlCommand, q float64) {
+	fillFlightParameters(&state, r.planet, q)
+	if r.abort.update(state, r.planet) {
+		r.emit(EventAbort, r.abort.reason)
+		r.sink.Abort(protocol.AbortMessage{
+			RocketID: r.ID,
+			Reason:   r.abort.reason,
+			Time:     state.Time,
+			Altitude: state.Altitude,
+		})
+		r.logImpact()
+	}
+	if state.Landed || state.Crashed {
+		r.touchdownSpeed = surfaceSpeed(before, r.planet)
+	}
+	r.stats.update(state)
+	r.recorder.record(state, command, q, r.phase(), r.heartbeat.rtt(), r.chase)
+	r.blackBox.record(state, command)
+}
+
+// fillFlightParameters дополняет состояние перегрузкой, скоростным напором
+// q, вертикальной и путевой скоростью и точкой под ракетой
+func fillFlightParameters(state *protocol.RocketState, planet physics.PlanetConfig, q float64) {
+	ground := planet.GroundPoint(state.Position, state.Time)
+	state.Latitude, state.Longitude = ground.Latitude, ground.Longitude
+	state.GForce = finiteOr(gLoad(*state, planet), 0)
+	state.DynamicPressure = finiteOr(q, 0)
+	state.VerticalSpeed = finiteOr(state.Velocity.Dot(state.Position.Normalize()), 0)
+	state.GroundSpeed = finiteOr(planet.GroundSpeed(state.Position, state.Velocity), 0)
+}
+
+// fillOrbit дополняет телеметрию прогнозом орбиты и параметрами полета. Вызывается с частотой телеметрии, а не на каждом шаге физики.
+func (r *RocketClient) fillOrbit(state *protocol.RocketState) {
+	q, err := r.physics.DynamicPressure()
+	if err != nil {
+		q = 0
+	}
+	fillFlightParameters(state, r.planet, q)
+
+	setOrbit(state, r.predictOrbit())
+}
+
+// setOrbit переносит прогноз орбиты в поля Orbit* состояния
+func setOrbit(state *protocol.RocketState, orbit physics.OrbitPrediction) {
+	state.OrbitApoapsis = finiteOr(orbit.Apoapsis, -1) // -1: апоцентр не определен
+	state.OrbitPeriapsis = finiteOr(orbit.Periapsis, 0)
+	state.OrbitEccentricity = finiteOr(orbit.Eccentricity, 0)
+	state.OrbitRequiredVelocity = finiteOr(orbit.RequiredVelocity, 0)
+	state.OrbitIsStable = orbit.IsStable
+	state.OrbitInclination = finiteOr(orbit.Inclination, 0)
+	state.OrbitRAAN = finiteOr(orbit.LongitudeOfAscendingNode, 0)
+	state.OrbitArgPeriapsis = finiteOr(orbit.ArgumentOfPeriapsis, 0)
+}
+
+// predictOrbit - прогноз орбиты для автопилота и телеметрии. Ошибка прогноза
+// пишется в журнал один раз, дальше используется пустой прогноз.
+func (r *RocketClient) predictOrbit() physics.OrbitPrediction {
+	orbit, err := r.physics.PredictOrbit()
+	if err != nil && !r.orbitFailed {
+		r.orbitFailed = true
+		r.logger.Error("orbit_failed", logging.F("error", err))
+	}
+	return orbit
+}
+
+// NaN и Inf не сериализуются в JSON, вместо них отправляется запасное значение
+func finiteOr(value, fallback float64) float64 {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return fallback
+	}
+	return value
+}
+
+func (r *RocketClient) receiveMessages(conn *websocket.Conn) {
+	notRegistered := 0
+	for {
+		msg, err := readMessage(conn, r.codec)
+		if err != nil {
+			// После Stop соединение закрывается из Close, это не потеря связи
+			if r.ctx.Err() == nil {
+				r.connectionLost(conn, err)
+			}
+			return
+		}
+
+		switch msg.Type {
+		case protocol.MsgTypeCommand, protocol.MsgTypeWarning, protocol.MsgTypeTrajectory, protocol.MsgTypeShutdown, protocol.MsgTypeError:
+			r.blackBox.recordMessage(msg, r.clock.Now())
+		}
+
+		switch msg.Type {
+		case protocol.MsgTypeCommand:
+			r.handleCommand(msg)
+
+		case protocol.MsgTypeWarning:
+			r.handleWarning(msg)
+
+		case protocol.MsgTypeTrajectory:
+			r.handleTrajectory(msg)
+
+		case protocol.MsgTypeHeartbeat:
+			r.handleHeartbeat(msg)
+
+		case protocol.MsgTypeShutdown:
+			r.logger.Warn("shutdown_received")
+			r.finish(OutcomeAborted)
+
+		case protocol.MsgTypeError:
+			if r.handleError(msg) != protocol.ErrorCodeNotRegistered {
+				continue
+			}
+			// Сервер потерял регистрацию (например, после перезапуска), а
+			// соединение живо: переподключение зарегистрирует ракету заново
+			notRegistered++
+			if notRegistered >= notRegisteredLimit {
+				r.connectionLost(conn, errors.New("сервер не считает ракету зарегистрированной"))
+				return
+			}
+		}
+	}
+}
+
+// handleError пишет в журнал ошибку сервера и возвращает ее код
+func (r *RocketClient) handleError(msg protocol.Message) protocol.ErrorCode {
+	errMsg, err := protocol.DecodeData[protocol.ErrorMessage](msg)
+	if err != nil {
+		r.logger.Warn("message_decode_failed", logging.F("type", msg.Type), logging.F("error", err))
+		return ""
+	}
+
+	r.serverErrors.Add(1 + errMsg.Suppressed)
+	fields := []logging.Field{
+		logging.F("type", errMsg.RefType),
+		logging.F("seq", errMsg.RefSeq),
+		logging.F("code", errMsg.Code),
+		logging.F("detail", errMsg.Detail),
+	}
+	if errMsg.Suppressed > 0 {
+		fields = append(fields, logging.F("suppressed", errMsg.Suppressed))
+	}
+	r.logger.Error("server_dropped", fields...)
+	return errMsg.Code
+}
+
+func (r *RocketClient) handleCommand(msg protocol.Message) {
+	commandMsg, err := protocol.DecodeData[protocol.CommandMessage](msg)
+	if err != nil {
+		r.logger.Warn("message_decode_failed", logging.F("type", msg.Type), logging.F("error", err))
+		return
+	}
+
+	if err := protocol.ValidateCommand(&commandMsg); err != nil {
+		r.logger.Warn("command_invalid", logging.F("error", err))
+		return
+	}
+	byID := commandMsg.EngineThrottleByID
+	if commandMsg.Attitude != nil {
+		if err := protocol.ValidateAttitudeHold(commandMsg.Attitude); err != nil {
+			r.logger.Warn("attitude_invalid", logging.F("error", err))
+			return
+		}
+		r.attitude.set(*commandMsg.Attitude, "команда сервера")
+	}
+	// Команда только с ориентацией или действиями не меняет дроссели
+	if len(commandMsg.Command.EngineThrottle) == 0 && len(byID) == 0 {
+		if hasActions(commandMsg.Command) {
+			r.setServerActions(commandMsg.Command)
+			r.logger.Info("command_actions", logging.F("actions", actionNames(commandMsg.Command)))
+		}
+		if commandMsg.Attitude != nil || hasActions(commandMsg.Command) {
+			return
+		}
+	}
+
+	if r.countdown.active() {
+		command := commandMsg.Command
+		if len(byID) > 0 {
+			// Для зажигания важно только, есть ли тяга
+			command.EngineThrottle = slices.Collect(maps.Values(byID))
+		}
+		r.countdown.serverCommand(command)
+	}
+	r.setServerCommand(commandMsg.Command, byID)
+	r.logger.Info("command_received", logging.F("hold", r.commandHold))
+}
+
+func (r *RocketClient) handleWarning(msg protocol.Message) {
+	warningMsg, err := protocol.DecodeData[protocol.WarningMessage](msg)
+	if err != nil {
+		r.logger.Warn("message_decode_failed", logging.F("type", msg.Type), logging.F("error", err))
+		return
+	}
+
+	if err := protocol.ValidateWarning(&warningMsg); err != nil {
+		r.logger.Warn("warning_invalid", logging.F("error", err))
+		return
+	}
+
+	r.logger.Warn("warning", logging.F("severity", warningMsg.Severity), logging.F("warning", warningMsg.Warning))
+	r.emit(EventWarning, warningMsg.Warning)
+
+	switch warningMsg.Code {
+	case protocol.WarningCodeProximity:
+		if warningMsg.Distance > 0 {
+			r.logger.Info("proximity",
+				logging.F("other_rocket_id", warningMsg.OtherRocketID),
+				logging.F("distance", warningMsg.Distance),
+				logging.F("time_to_closest", warningMsg.TimeToClosest))
+		}
+		if r.autoAvoid {
+			r.avoid.trigger(warningMsg, r.clock.Now())
+		}
+	case protocol.WarningCodeGroundProximity:
+		if warningMsg.TimeToImpact > 0 {
+			r.logger.Warn("ground_proximity", logging.F("time_to_impact", warningMsg.TimeToImpact))
+		}
+	case protocol.WarningCodeFuelLow, protocol.WarningCodeLinkLatency, protocol.WarningCodePlausibility:
+		// Реакции нет, достаточно записи в журнале
+	default:
+		r.logger.Info("warning_unknown", logging.F("code", warningMsg.Code))
+	}
+}
+
+// Stop прерывает полет. Можно вызывать многократно и из любой горутины.
+func (r *RocketClient) Stop() {
+	r.finish(OutcomeInterrupted)
+}
+
+// finish запоминает первую причину остановки и останавливает клиент
+func (r *RocketClient) finish(outcome MissionOutcome) {
+	r.outcomeMu.Lock()
+	if r.outcome == "" {
+		r.outcome = outcome
+	}
+	r.outcomeMu.Unlock()
+	r.cancel()
+}
+
+// Summary подводит итог полета; вызывать после завершения Launch
+func (r *RocketClient) Summary() MissionSummary {
+	r.outcomeMu.Lock()
+	requested := r.outcome
+	r.outcomeMu.Unlock()
+
+	var reached MissionOutcome
+	if r.program != nil {
+		reached = r.program.Outcome()
+	}
+	summary := summarizeMission(r.finalState, r.stats, r.maxQ, reached, requested)
+	summary.ServerErrors = r.serverErrors.Load()
+	// Падение после аварийного прекращения полета - ожидаемый исход abort
+	if r.abort.aborted() && summary.Outcome != OutcomeInterrupted {
+		summary.Outcome = OutcomeAborted
+	}
+	return summary
+}
+
+// Close отправляет последний кадр телеметрии, отключается от сервера и
+// освобождает физику. Выполняется один раз, даже если вызван и из Launch, и снаружи.
+func (r *RocketClient) Close() {
+	r.closeOnce.Do(func() {
+		r.Stop()
+
+		if r.physics != nil {
+			// Ошибка - физику уже освободили, последний кадр не отправляется
+			if state, err := r.physics.GetState(); err == nil {
+				state.Stage = r.staging.number()
+				state.PayloadDeployed = r.payloadDeployed
+				state.EngineStatus = r.engineStatus()
+				r.fillOrbit(&state)
+				r.sink.Send(state)
+				// Итог полета получает орбиту последнего кадра
+				r.finalState = state
+			}
+		}
+
+		outcome := r.Summary().Outcome
+		r.sink.Close(string(outcome))
+		r.recorder.close()
+		if r.physics != nil {
+			r.checkpoint.save(r.physics)
+		}
+		r.emit(EventOutcome, string(outcome))
+		r.closeEvents()
+
+		if r.physics != nil {
+			r.physics.Close()
+		}
+	})
+}
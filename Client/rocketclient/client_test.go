@@ -0,0 +1,85 @@
+package rocketclient
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"cosmodrom/client/physics"
+	"cosmodrom/protocol"
+)
+
+func TestFillFlightParameters(t *testing.T) {
+	planet := physics.EarthDefault()
+	r := planet.Radius + 10000
+	g := protocol.GConstant * planet.Mass / (r * r)
+
+	tests := []struct {
+		name         string
+		acceleration protocol.Vector3
+		velocity     protocol.Vector3
+		wantG        float64
+		wantVertical float64
+	}{
+		// Ракета на оси z: вертикаль - ось z
+		{"свободное падение", protocol.Vector3{Z: -g}, protocol.Vector3{Z: -50}, 0, -50},
+		{"тяга 2 g вверх", protocol.Vector3{Z: 2*standardGravity - g}, protocol.Vector3{X: 300, Z: 120}, 2, 120},
+		{"покой", protocol.Vector3{}, protocol.Vector3{}, g / standardGravity, 0},
+	}
+
+	for _, tt := range tests {
+		state := protocol.RocketState{
+			Position:     protocol.Vector3{Z: r},
+			Velocity:     tt.velocity,
+			Acceleration: tt.acceleration,
+		}
+		fillFlightParameters(&state, planet, 1500)
+		if math.Abs(state.GForce-tt.wantG) > 1e-9 || math.Abs(state.VerticalSpeed-tt.wantVertical) > 1e-9 {
+			t.Errorf("%s: перегрузка %g g, вертикальная скорость %g; ожидалось %g, %g",
+				tt.name, state.GForce, state.VerticalSpeed, tt.wantG, tt.wantVertical)
+		}
+		if state.DynamicPressure != 1500 {
+			t.Errorf("%s: скоростной напор %g", tt.name, state.DynamicPressure)
+		}
+	}
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+// Расхождение часов с сервером оценивается по середине RTT и попадает
+// в журнал один раз за соединение
+func TestHeartbeatClockSkew(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	rtt := 100 * time.Millisecond
+	var h heartbeatMonitor
+	h.reset()
+
+	if _, first := h.clockSkew(now.Add(-rtt/2), now, rtt); first {
+		t.Error("синхронные часы приняты за расхождение")
+	}
+	if _, first := h.clockSkew(time.Time{}, now, rtt); first {
+		t.Error("ответ без метки времени принят за расхождение")
+	}
+	skew, first := h.clockSkew(now.Add(time.Minute), now, rtt)
+	if !first || skew != time.Minute+rtt/2 {
+		t.Errorf("расхождение %v (%v), ожидалось %v", skew, first, time.Minute+rtt/2)
+	}
+	if _, first := h.clockSkew(now.Add(time.Minute), now, rtt); first {
+		t.Error("расхождение отмечено повторно в том же соединении")
+	}
+
+	h.reset()
+	if _, first := h.clockSkew(now.Add(-time.Minute), now, rtt); !first {
+		t.Error("новое соединение: расхождение не отмечено")
+	}
+
+	// Часы ракеты перевели назад между отправкой и ответом
+	h.send(now)
+	if got, _, ok := h.received(1, now.Add(-time.Second)); !ok || got != 0 {
+		t.Errorf("RTT %v, ожидался 0", got)
+	}
+}
@@ -0,0 +1,94 @@
+package rocketclient
+
+import (
+	"maps"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/protocol"
+)
+
+// Команда от сервера имеет приоритет над автопилотом в течение commandHold,
+// затем управление возвращается автопилоту. Цикл run работает только с копией,
+// поэтому новая команда из receiveMessages не может изменить срез дросселей
+// посреди шага физики. Дроссели byID, если заданы, переводятся в список на
+// каждом шаге: так команда относится к двигателям текущей ступени.
+func (r *RocketClient) setServerCommand(command protocol.ControlCommand, byID map[string]float64) {
+	command.EngineThrottle = append([]float64(nil), command.EngineThrottle...)
+
+	r.commandMu.Lock()
+	r.serverCommand = &command
+	r.serverThrottleByID = maps.Clone(byID)
+	r.serverCommandUntil = r.clock.Now().Add(r.commandHold)
+	r.commandMu.Unlock()
+}
+
+// setServerActions принимает команду только с действиями, без дросселей:
+// управление остается у автопилота, а действия держатся commandHold, как
+// команда целиком. Повтор в это время не дает нового переднего фронта.
+func (r *RocketClient) setServerActions(command protocol.ControlCommand) {
+	r.commandMu.Lock()
+	r.serverActions = protocol.ControlCommand{
+		DeployParachute: command.DeployParachute,
+		StageSeparate:   command.StageSeparate,
+		DeployPayload:   command.DeployPayload,
+	}
+	r.serverActionsUntil = r.clock.Now().Add(r.commandHold)
+	r.commandMu.Unlock()
+}
+
+// hasActions сообщает, есть ли в команде дискретные действия
+func hasActions(command protocol.ControlCommand) bool {
+	return command.DeployParachute || command.StageSeparate || command.DeployPayload
+}
+
+// activeCommand возвращает команду для текущего шага: действующую команду
+// сервера или команду автопилота, вместе с действиями сервера
+func (r *RocketClient) activeCommand(autopilot protocol.ControlCommand) protocol.ControlCommand {
+	r.commandMu.Lock()
+	defer r.commandMu.Unlock()
+
+	command := r.controlCommand(autopilot)
+	if r.clock.Now().Before(r.serverActionsUntil) {
+		command.DeployParachute = command.DeployParachute || r.serverActions.DeployParachute
+		command.StageSeparate = command.StageSeparate || r.serverActions.StageSeparate
+		command.DeployPayload = command.DeployPayload || r.serverActions.DeployPayload
+	}
+	return command
+}
+
+// controlCommand - команда сервера, пока она действует, иначе автопилота.
+// Вызывается под commandMu.
+func (r *RocketClient) controlCommand(autopilot protocol.ControlCommand) protocol.ControlCommand {
+	if r.serverCommand == nil {
+		return autopilot
+	}
+	if r.clock.Now().After(r.serverCommandUntil) {
+		r.serverCommand = nil
+		r.logger.Info("command_expired")
+		return autopilot
+	}
+
+	command := *r.serverCommand
+	if r.serverThrottleByID != nil {
+		throttle, err := protocol.ResolveEngineThrottle(r.currentEngines(), r.serverThrottleByID)
+		if err != nil {
+			r.logger.Warn("command_failed", logging.F("error", err))
+			r.serverCommand = nil
+			return autopilot
+		}
+		command.EngineThrottle = throttle
+		return command
+	}
+
+	// Физика отклоняет команду не по числу двигателей: так бывает, если
+	// сервер ошибся или ступень отделилась, пока команда действует
+	if len(r.serverCommand.EngineThrottle) != len(autopilot.EngineThrottle) {
+		r.logger.Warn("command_engines_mismatch",
+			logging.F("command_engines", len(r.serverCommand.EngineThrottle)),
+			logging.F("engines", len(autopilot.EngineThrottle)))
+		r.serverCommand = nil
+		return autopilot
+	}
+	command.EngineThrottle = append([]float64(nil), command.EngineThrottle...)
+	return command
+}
@@ -0,0 +1,56 @@
+package rocketclient
+
+import (
+	"runtime"
+	"sync/atomic"
+	"testing"
+
+	"cosmodrom/protocol"
+)
+
+// Команды сервера приходят из receiveMessages, пока цикл полета читает их
+// на каждом шаге. Сервер, как и receiveMessages, переиспользует срез
+// дросселей после отправки, а шаг видит только целую команду: тангаж и
+// дроссель из одной и той же команды. Гонку ловит go test -race.
+func TestServerCommandConcurrentWithSteps(t *testing.T) {
+	r, _ := actionTestClient(t)
+	autopilot := protocol.ControlCommand{EngineThrottle: []float64{0.05}, Pitch: 1}
+
+	var sent atomic.Int64
+	stop, done := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(done)
+		throttle := []float64{0}
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			throttle[0] = float64(i%10) / 10
+			r.setServerCommand(protocol.ControlCommand{EngineThrottle: throttle, Pitch: throttle[0] * 90}, nil)
+			sent.Add(1)
+			runtime.Gosched()
+		}
+	}()
+	defer func() {
+		close(stop)
+		<-done
+	}()
+
+	// Шаги идут, пока сервер не пришлет достаточно команд вперемешку с ними.
+	// Gosched чередует горутины и на одном процессоре.
+	for step := 0; step < 200 || sent.Load() < 200; step++ {
+		if _, err := r.step(r.dt); err != nil {
+			t.Fatal(err)
+		}
+		command := r.activeCommand(autopilot)
+		if command.Pitch == autopilot.Pitch {
+			continue
+		}
+		if len(command.EngineThrottle) != 1 || command.Pitch != command.EngineThrottle[0]*90 {
+			t.Fatalf("шаг %d: тангаж %g и дроссели %v из разных команд", step, command.Pitch, command.EngineThrottle)
+		}
+		runtime.Gosched()
+	}
+}
@@ -0,0 +1,444 @@
+package rocketclient
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/client/physics"
+	"cosmodrom/protocol"
+)
+
+// Config - параметры ракеты и полета. Поля соответствуют флагам клиента,
+// DefaultConfig возвращает их значения по умолчанию.
+type Config struct {
+	ID        string
+	ServerURL string
+	Rocket    protocol.RocketConfig
+	Vehicle   string         // Имя ракеты в каталоге сервера: Connect заменяет Rocket ее конфигурацией
+	Codec     protocol.Codec // Формат сообщений сервера, nil - protocol.JSON
+
+	CACert             string // PEM-файл CA для wss://
+	InsecureSkipVerify bool   // Не проверять сертификат сервера
+
+	RegisterTimeout   time.Duration
+	AutoID            bool // При duplicate_id повторить регистрацию с суффиксом
+	ReconnectAttempts int  // 0 - без ограничения
+	ReconnectMaxDelay time.Duration
+	Heartbeat         time.Duration // 0 - без проверки связи
+	HeartbeatTimeout  time.Duration
+	CommandHold       time.Duration // Приоритет команды сервера над автопилотом
+	AutoAvoid         bool
+
+	Physics           physics.Backend      // Пустое - physics.DefaultBackend
+	StrictCommands    bool                 // Ошибка физики вместо приведения команды к диапазону
+	SlewRate          float64              // Скорость поворота ракеты °/с, 0 - мгновенный поворот
+	Planet            physics.PlanetConfig // Нулевое значение - Земля
+	NoRotation        bool
+	Latitude          float64
+	Longitude         float64
+	Altitude          float64
+	Mode              FlightMode
+	TargetOrbit       float64 // м
+	TargetInclination float64 // Градусы, отрицательное - рыскание не управляется
+	PostOrbitRaise    float64 // м, переход Хомана на эту высоту после выхода на орбиту; 0 - без перехода
+	HopAltitude       float64 // м, для FlightModeHop
+	Script            string  // YAML-сценарий вместо автопилота Mode
+	ChaseTarget       string  // ID ракеты-цели для FlightModeChase
+	ChaseOffset       float64 // м, отставание от цели вдоль ее скорости
+	ChaseTolerance    float64 // м/с, точность уравнивания скорости
+	Attitude          string  // Удержание ориентации, как во флаге -attitude
+	LaunchSite        string  // Название космодрома для цели миссии
+	Crewed            bool
+	MissionNote       string // Описание миссии для сервера
+	Autopilot         Autopilot
+
+	TelemetryHz     float64
+	Dt              float64
+	TimeWarp        float64
+	WarpMinAltitude float64
+	Countdown       time.Duration // 0 - старт сразу
+
+	Offline        bool          // Без сервера: телеметрия в журнал и TelemetryFile
+	TelemetryFile  string        // Только с Offline
+	Sink           TelemetrySink // Свой получатель телеметрии вместо сервера
+	RecordPath     string        // CSV-запись полета
+	RecordHz       float64
+	CheckpointFile string          // Снимок физики каждые 10 с для -resume-from
+	ResumeFrom     string          // Продолжить полет из снимка вместо старта
+	Logger         *logging.Logger // nil - logging.Default() с ID ракеты
+	Unpaced        bool            // Шаги физики подряд, без реального времени и черного ящика (только без сервера)
+
+	FailureRate  float64 // Вероятность отказа каждого двигателя в минуту
+	FailEngineAt string  // индекс@секунды
+	FailureSeed  int64   // 0 - по текущему времени
+
+	NoisePosition float64
+	NoiseVelocity float64
+	NoiseAltitude float64
+	NoiseDropout  float64
+	NoiseLatency  time.Duration
+	NoiseSeed     int64   // 0 - по текущему времени
+	SensorNoise   float64 // Относительная сигма шума высоты и скорости на входе автопилота
+
+	MaxQ             float64       // Па, 0 - без ограничения
+	MaxG             float64       // 0 - без ограничения
+	MaxFlightTime    time.Duration // 0 - без ограничения
+	MinAltitudeAfter string        // метры@секунды
+
+	ApoKp       float64
+	ApoKi       float64
+	ApoKd       float64
+	MinThrottle float64
+}
+
+// DefaultConfig - значения флагов клиента по умолчанию: одноступенчатая
+// ракета с Байконура на орбиту 200 км
+func DefaultConfig() Config {
+	return Config{
+		ServerURL: "ws://localhost:8080/ws",
+		Rocket: protocol.RocketConfig{
+			Name:            "Test Rocket",
+			MassEmpty:       20000.0,
+			MassFuel:        400000.0,
+			MassFuelMax:     400000.0,
+			FuelType:        protocol.FuelTypeKerosene,
+			DragCoefficient: 0.3,
+			CrossSection:    12.0,
+			Engines: []protocol.Engine{
+				{Thrust: 7600000.0, FuelConsumption: 2500.0, IsActive: true},
+			},
+		},
+		RegisterTimeout:   10 * time.Second,
+		ReconnectAttempts: 10,
+		ReconnectMaxDelay: 30 * time.Second,
+		Heartbeat:         2 * time.Second,
+		HeartbeatTimeout:  5 * time.Second,
+		CommandHold:       5 * time.Second,
+		Planet:            physics.EarthDefault(),
+		Latitude:          45.0,
+		Longitude:         63.0,
+		Altitude:          100.0,
+		Mode:              FlightModeOrbit,
+		TargetOrbit:       200000.0,
+		TargetInclination: -1,
+		HopAltitude:       3000.0,
+		ChaseOffset:       1000.0,
+		ChaseTolerance:    5.0,
+		TelemetryHz:       10.0,
+		Dt:                0.01,
+		TimeWarp:          1.0,
+		WarpMinAltitude:   100000.0,
+		RecordHz:          10.0,
+		ApoKp:             20.0,
+		ApoKi:             2.0,
+		ApoKd:             0.0,
+		MinThrottle:       0.4,
+	}
+}
+
+// mission - цель полета для регистрации. Высота и наклонение орбиты
+// берутся из -target-orbit (или -post-orbit-raise) и -target-inclination
+// только у автопилота orbit: у других режимов орбитальной цели нет.
+func (c Config) mission() *protocol.Mission {
+	mission := protocol.Mission{LaunchSite: c.LaunchSite, Crewed: c.Crewed, Description: c.MissionNote}
+	if c.Mode == FlightModeOrbit && c.Script == "" {
+		mission.TargetOrbit = c.TargetOrbit
+		if c.PostOrbitRaise > 0 {
+			mission.TargetOrbit = c.PostOrbitRaise
+		}
+		if c.TargetInclination >= 0 {
+			inclination := c.TargetInclination
+			mission.TargetInclination = &inclination
+		}
+	}
+	if mission == (protocol.Mission{}) {
+		return nil
+	}
+	return &mission
+}
+
+// Validate проверяет параметры без создания клиента. В сообщениях об
+// ошибках указаны флаги клиента, соответствующие полям.
+func (c Config) Validate() error {
+	if c.ID == "" {
+		return fmt.Errorf("не задан ID ракеты (-id)")
+	}
+	if len(c.Rocket.Engines) == 0 {
+		return fmt.Errorf("у ракеты нет двигателей")
+	}
+	if _, err := newDialer(c.tls()); err != nil {
+		return err
+	}
+	if err := physics.CheckBackend(c.Physics); err != nil {
+		return err
+	}
+	if c.Vehicle != "" && (c.Offline || c.Sink != nil) {
+		return fmt.Errorf("-vehicle берет конфигурацию с сервера и несовместим с -offline")
+	}
+	switch c.Mode {
+	case FlightModeOrbit, FlightModeHop:
+	case FlightModeChase:
+		if err := c.validateChase(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("неизвестный режим полета -mode: %s (ожидается orbit, hop или chase)", c.Mode)
+	}
+	if c.TargetInclination >= 0 {
+		if c.Mode != FlightModeOrbit || c.Script != "" {
+			return fmt.Errorf("-target-inclination работает только с автопилотом -mode orbit")
+		}
+		if _, err := launchAzimuth(c.Latitude, c.TargetInclination); err != nil {
+			return fmt.Errorf("-target-inclination: %w", err)
+		}
+	}
+	if c.PostOrbitRaise != 0 {
+		if c.Mode != FlightModeOrbit || c.Script != "" {
+			return fmt.Errorf("-post-orbit-raise работает только с автопилотом -mode orbit")
+		}
+		if c.PostOrbitRaise < 0 {
+			return fmt.Errorf("-post-orbit-raise должен быть положительным: %g м", c.PostOrbitRaise)
+		}
+		if c.PostOrbitRaise == c.TargetOrbit {
+			return fmt.Errorf("-post-orbit-raise совпадает с -target-orbit: переход не нужен")
+		}
+	}
+	if err := protocol.ValidateMission(c.mission()); err != nil {
+		return fmt.Errorf("цель миссии (-launch-site, -mission): %w", err)
+	}
+	if !(c.SlewRate >= 0) || math.IsInf(c.SlewRate, 1) {
+		return fmt.Errorf("-slew-rate должен быть конечным и неотрицательным: %g °/с", c.SlewRate)
+	}
+	if err := validateTiming(c.Dt, c.TelemetryHz, c.TimeWarp); err != nil {
+		return err
+	}
+	heartbeat := c.heartbeat()
+	if err := heartbeat.validate(); err != nil {
+		return err
+	}
+	if err := c.apoapsisGains().validate(); err != nil {
+		return err
+	}
+	if err := c.noise().validate(); err != nil {
+		return err
+	}
+	if !(c.SensorNoise >= 0) || math.IsInf(c.SensorNoise, 1) {
+		return fmt.Errorf("шум датчиков автопилота должен быть конечным и неотрицательным: %g", c.SensorNoise)
+	}
+	if c.Unpaced {
+		if !c.Offline && c.Sink == nil {
+			return fmt.Errorf("прогон без реального времени возможен только без сервера")
+		}
+		if c.Countdown > 0 {
+			return fmt.Errorf("-countdown несовместим с прогоном без реального времени")
+		}
+	}
+	if c.FailureRate < 0 {
+		return fmt.Errorf("-failure-rate не может быть отрицательной")
+	}
+	if _, err := c.scheduledFailure(); err != nil {
+		return err
+	}
+	if _, err := c.abortLimits(); err != nil {
+		return err
+	}
+	if _, err := c.attitudeHold(); err != nil {
+		return err
+	}
+	if c.ResumeFrom != "" {
+		if c.Mode == FlightModeChase {
+			return fmt.Errorf("-resume-from несовместим с -mode chase")
+		}
+		if _, err := os.Stat(c.ResumeFrom); err != nil {
+			return fmt.Errorf("-resume-from: %w", err)
+		}
+	}
+	if c.Script != "" {
+		if _, err := loadMissionScript(c.Script); err != nil {
+			return fmt.Errorf("-script: %w", err)
+		}
+	}
+	return nil
+}
+
+// validateChase проверяет параметры -mode chase: телеметрия цели приходит
+// только через сервер, а разные ускорения времени у ракет несопоставимы
+func (c Config) validateChase() error {
+	switch {
+	case c.ChaseTarget == "":
+		return fmt.Errorf("для -mode chase нужна цель -chase-target")
+	case c.ChaseTarget == c.ID:
+		return fmt.Errorf("-chase-target не может совпадать с -id")
+	case c.Offline:
+		return fmt.Errorf("-mode chase требует сервера и несовместим с -offline")
+	case c.TimeWarp != 1:
+		return fmt.Errorf("-mode chase несовместим с -time-warp")
+	case c.ChaseOffset < 0:
+		return fmt.Errorf("-chase-offset не может быть отрицательным")
+	case c.ChaseTolerance <= 0:
+		return fmt.Errorf("-chase-tolerance должен быть положительным")
+	}
+	return nil
+}
+
+func (c Config) tls() tlsOptions {
+	return tlsOptions{caCert: c.CACert, insecureSkipVerify: c.InsecureSkipVerify}
+}
+
+func (c Config) heartbeat() heartbeatMonitor {
+	return heartbeatMonitor{interval: c.Heartbeat, timeout: c.HeartbeatTimeout}
+}
+
+func (c Config) apoapsisGains() pidGains {
+	return pidGains{kp: c.ApoKp, ki: c.ApoKi, kd: c.ApoKd, minThrottle: c.MinThrottle}
+}
+
+func (c Config) noise() noiseOptions {
+	return noiseOptions{
+		positionSigma: c.NoisePosition,
+		velocitySigma: c.NoiseVelocity,
+		altitudeSigma: c.NoiseAltitude,
+		dropout:       c.NoiseDropout,
+		latency:       c.NoiseLatency,
+		seed:          c.NoiseSeed,
+	}
+}
+
+func (c Config) scheduledFailure() (*scheduledFailure, error) {
+	if c.FailEngineAt == "" {
+		return nil, nil
+	}
+	failAt, err := parseScheduledFailure(c.FailEngineAt)
+	if err != nil {
+		return nil, fmt.Errorf("-fail-engine-at: %w", err)
+	}
+	return failAt, nil
+}
+
+func (c Config) abortLimits() (abortLimits, error) {
+	limits := abortLimits{maxG: c.MaxG, maxFlightTime: c.MaxFlightTime.Seconds()}
+	if c.MinAltitudeAfter != "" {
+		var err error
+		limits.minAltitude, limits.minAltitudeBy, err = parseMinAltitudeAfter(c.MinAltitudeAfter)
+		if err != nil {
+			return limits, fmt.Errorf("-min-altitude-after: %w", err)
+		}
+	}
+	return limits, nil
+}
+
+func (c Config) attitudeHold() (protocol.AttitudeHold, error) {
+	if c.Attitude == "" {
+		return protocol.AttitudeHold{}, nil
+	}
+	hold, err := parseAttitude(c.Attitude)
+	if err != nil {
+		return hold, fmt.Errorf("-attitude: %w", err)
+	}
+	return hold, nil
+}
+
+// New создает клиента по параметрам cfg. Клиент не подключается к серверу:
+// для полета с сервером вызовите Connect и Register, затем Launch.
+// С Offline или своим Sink достаточно Launch.
+func New(cfg Config) (*RocketClient, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	failAt, _ := cfg.scheduledFailure()
+	limits, _ := cfg.abortLimits()
+	attitude, _ := cfg.attitudeHold()
+
+	logger := cfg.Logger
+	if logger == nil {
+		logger = logging.Default().WithRocket(cfg.ID)
+	}
+	if cfg.Planet == (physics.PlanetConfig{}) {
+		cfg.Planet = physics.EarthDefault()
+	}
+	if cfg.FailureSeed == 0 {
+		cfg.FailureSeed = time.Now().UnixNano()
+	}
+	noise := cfg.noise()
+	if noise.seed == 0 {
+		noise.seed = time.Now().UnixNano()
+	}
+
+	var err error
+	client := newRocketClient(cfg.ID, cfg.Rocket, cfg.ServerURL, cfg.TelemetryHz, cfg.Dt)
+	client.setLogger(logger)
+	client.launch = cfg
+	if client.dialer, err = newDialer(cfg.tls()); err != nil {
+		return nil, err
+	}
+	if cfg.InsecureSkipVerify && !cfg.Offline && cfg.Sink == nil {
+		logger.Warn("tls_insecure")
+	}
+	if cfg.Codec != nil {
+		client.codec = cfg.Codec
+	}
+	client.registerTimeout = cfg.RegisterTimeout
+	client.autoID = cfg.AutoID
+	client.reconnectAttempts = cfg.ReconnectAttempts
+	client.reconnectMaxDelay = cfg.ReconnectMaxDelay
+	client.heartbeat = cfg.heartbeat()
+	client.commandHold = cfg.CommandHold
+	client.autoAvoid = cfg.AutoAvoid
+	client.maxQ.limit = cfg.MaxQ
+	client.apoapsisGains = cfg.apoapsisGains()
+	if attitude.Mode != "" {
+		client.attitude.set(attitude, "-attitude")
+	}
+	client.abort.limits = limits
+	if cfg.Countdown > 0 {
+		client.countdown = newCountdown(cfg.Countdown, logger)
+	}
+	client.noRotation = cfg.NoRotation
+	client.planet = cfg.Planet
+	client.warp.factor = cfg.TimeWarp
+	client.warp.minAltitude = cfg.WarpMinAltitude
+	// Предел не дает серверу терять ракету между кадрами
+	if limit := serverWarpLimit(cfg.TelemetryHz); !cfg.Offline && cfg.Sink == nil && client.warp.factor > limit {
+		logger.Warn("time_warp_limited", logging.F("warp", limit))
+		client.warp.factor = limit
+	}
+
+	if cfg.Script != "" {
+		script, err := loadMissionScript(cfg.Script)
+		if err != nil {
+			return nil, fmt.Errorf("-script: %w", err)
+		}
+		client.script = script
+		logger.Info("script_loaded", logging.F("name", script.Name), logging.F("actions", len(script.Actions)))
+	}
+
+	switch {
+	case cfg.Sink != nil:
+		client.sink = cfg.Sink
+	case cfg.Offline:
+		sink, err := newOfflineSink(cfg.ID, cfg.TelemetryFile, client.clock, logger)
+		if err != nil {
+			return nil, err
+		}
+		client.sink = sink
+	}
+	if noise.enabled() {
+		client.sink = newNoisySink(client.sink, noise, logger)
+	}
+
+	client.sensors = newSensorNoise(cfg.SensorNoise, noise.seed)
+	client.failures = newEngineFailures(cfg.FailureRate, failAt, cfg.FailureSeed, cfg.Rocket.Engines, logger)
+
+	if cfg.RecordPath != "" {
+		recorder, err := newFlightRecorder(cfg.RecordPath, cfg.RecordHz, logger)
+		if err != nil {
+			return nil, err
+		}
+		client.recorder = recorder
+	}
+	client.checkpoint = newCheckpointer(cfg.CheckpointFile, logger)
+	return client, nil
+}
@@ -0,0 +1,199 @@
+package rocketclient
+
+import (
+	"sync"
+	"time"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/protocol"
+)
+
+type CountdownState string
+
+const (
+	CountdownCounting CountdownState = "counting" // Идет отсчет
+	CountdownHold     CountdownState = "hold"     // Отсчет остановлен, ракета на столе
+	CountdownLaunched CountdownState = "launched" // T-0, зажигание
+	CountdownScrubbed CountdownState = "scrubbed" // Пуск отменен
+)
+
+// countdown - предстартовый отсчет. Время уменьшается только в состоянии
+// counting; hold, resume и scrub можно вызывать из любой горутины
+// (сигналы, команды сервера), tick - из цикла run.
+type countdown struct {
+	remaining time.Duration
+	state     CountdownState
+	announced time.Duration // Последняя объявленная отметка T-
+	mu        sync.Mutex
+	logger    *logging.Logger
+}
+
+func newCountdown(duration time.Duration, logger *logging.Logger) *countdown {
+	return &countdown{remaining: duration, state: CountdownCounting, announced: countdownMark(duration), logger: logger}
+}
+
+// tick уменьшает оставшееся время на elapsed и возвращает новое состояние
+func (c *countdown) tick(elapsed time.Duration) CountdownState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.state != CountdownCounting {
+		return c.state
+	}
+
+	c.remaining -= elapsed
+	if c.remaining <= 0 {
+		c.remaining = 0
+		c.state = CountdownLaunched
+		c.logger.Info("countdown_ignition")
+		return c.state
+	}
+
+	if mark := countdownMark(c.remaining); mark < c.announced {
+		c.announced = mark
+		c.logger.Info("countdown_mark", logging.F("remaining", mark))
+	}
+	return c.state
+}
+
+// countdownMark - ближайшая объявляемая отметка не меньше remaining:
+// каждая минута, каждые 10 с последней минуты и каждая секунда последних 10
+func countdownMark(remaining time.Duration) time.Duration {
+	step := time.Minute
+	switch {
+	case remaining <= 10*time.Second:
+		step = time.Second
+	case remaining <= time.Minute:
+		step = 10 * time.Second
+	}
+	return ((remaining + step - 1) / step) * step
+}
+
+func (c *countdown) hold(reason string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.state != CountdownCounting {
+		return
+	}
+	c.state = CountdownHold
+	c.logger.Warn("countdown_hold", logging.F("remaining", c.remaining.Round(time.Second)), logging.F("reason", reason))
+}
+
+func (c *countdown) resume(reason string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.state != CountdownHold {
+		return
+	}
+	c.state = CountdownCounting
+	c.announced = c.remaining + time.Second
+	c.logger.Info("countdown_resumed", logging.F("remaining", c.remaining.Round(time.Second)), logging.F("reason", reason))
+}
+
+// toggle останавливает идущий отсчет или продолжает остановленный
+func (c *countdown) toggle(reason string) {
+	c.mu.Lock()
+	state := c.state
+	c.mu.Unlock()
+
+	if state == CountdownHold {
+		c.resume(reason)
+	} else {
+		c.hold(reason)
+	}
+}
+
+func (c *countdown) scrub(reason string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.state == CountdownLaunched || c.state == CountdownScrubbed {
+		return
+	}
+	c.state = CountdownScrubbed
+	c.logger.Warn("countdown_scrubbed", logging.F("remaining", c.remaining.Round(time.Second)), logging.F("reason", reason))
+}
+
+// active - отсчет еще не закончился зажиганием или отменой
+func (c *countdown) active() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state == CountdownCounting || c.state == CountdownHold
+}
+
+// serverCommand - команда сервера во время отсчета: нулевая тяга
+// останавливает отсчет, ненулевая продолжает его
+func (c *countdown) serverCommand(command protocol.ControlCommand) {
+	if meanThrottle(command.EngineThrottle) == 0 {
+		c.hold("команда сервера с нулевой тягой")
+	} else {
+		c.resume("команда сервера")
+	}
+}
+
+// runCountdown ведет отсчет до зажигания, отправляя телеметрию ракеты на
+// столе. Возвращает false, если пуск отменен или клиент остановлен.
+func (r *RocketClient) runCountdown() bool {
+	if r.countdown == nil {
+		return true
+	}
+
+	r.logger.Info("countdown_started", logging.F("remaining", r.countdown.remaining))
+	interval := time.Duration(float64(time.Second) / r.telemetryHz)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	last := time.Now()
+
+	for {
+		select {
+		case <-r.ctx.Done():
+			r.countdown.scrub("клиент остановлен")
+			return false
+		case now := <-ticker.C:
+			state := r.countdown.tick(now.Sub(last))
+			last = now
+
+			pad, err := r.physics.GetState()
+			if err != nil {
+				r.logger.Error("physics_error", logging.F("error", err))
+				r.finish(OutcomeAborted)
+				return false
+			}
+			r.finalState = pad
+			r.fillOrbit(&pad)
+			r.sink.Send(pad)
+
+			switch state {
+			case CountdownLaunched:
+				// Команда, остановившая отсчет, не должна держать двигатели выключенными после T-0
+				r.commandMu.Lock()
+				r.serverCommand = nil
+				r.commandMu.Unlock()
+				return true
+			case CountdownScrubbed:
+				r.finish(OutcomeScrubbed)
+				return false
+			}
+		}
+	}
+}
+
+// ToggleCountdown останавливает предстартовый отсчет или продолжает его.
+// Без Config.Countdown ничего не делает.
+func (r *RocketClient) ToggleCountdown(reason string) {
+	if r.countdown != nil {
+		r.countdown.toggle(reason)
+	}
+}
+
+// ScrubCountdown отменяет пуск, если отсчет еще идет
+func (r *RocketClient) ScrubCountdown(reason string) {
+	if r.countdown != nil {
+		r.countdown.scrub(reason)
+	}
+}
@@ -0,0 +1,136 @@
+package rocketclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/protocol"
+)
+
+// logCodes - коды записей JSON-журнала по порядку
+func logCodes(t *testing.T, log *bytes.Buffer) []string {
+	t.Helper()
+	var codes []string
+	for _, line := range strings.Split(strings.TrimSpace(log.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record struct{ Code string }
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("запись журнала %q: %v", line, err)
+		}
+		codes = append(codes, record.Code)
+	}
+	log.Reset()
+	return codes
+}
+
+func TestCountdownStateMachine(t *testing.T) {
+	throttle := func(value float64) protocol.ControlCommand {
+		return protocol.ControlCommand{EngineThrottle: []float64{value}}
+	}
+	type op struct {
+		name          string
+		do            func(c *countdown) CountdownState
+		wantState     CountdownState
+		wantRemaining time.Duration
+		wantLog       string // Коды записей журнала через запятую
+	}
+	tick := func(d time.Duration) func(c *countdown) CountdownState {
+		return func(c *countdown) CountdownState { return c.tick(d) }
+	}
+	call := func(f func(c *countdown)) func(c *countdown) CountdownState {
+		return func(c *countdown) CountdownState { f(c); return c.state }
+	}
+
+	tests := []struct {
+		name string
+		ops  []op
+	}{
+		{
+			name: "удержание и продолжение",
+			ops: []op{
+				{name: "отсчет", do: tick(15 * time.Second), wantState: CountdownCounting, wantRemaining: time.Minute, wantLog: "countdown_mark"},
+				{name: "удержание", do: call(func(c *countdown) { c.hold("оператор") }), wantState: CountdownHold, wantRemaining: time.Minute, wantLog: "countdown_hold"},
+				{name: "время на удержании стоит", do: tick(30 * time.Second), wantState: CountdownHold, wantRemaining: time.Minute},
+				{name: "повторное удержание", do: call(func(c *countdown) { c.hold("оператор") }), wantState: CountdownHold, wantRemaining: time.Minute},
+				{name: "продолжение", do: call(func(c *countdown) { c.resume("оператор") }), wantState: CountdownCounting, wantRemaining: time.Minute, wantLog: "countdown_resumed"},
+				{name: "повторное продолжение", do: call(func(c *countdown) { c.resume("оператор") }), wantState: CountdownCounting, wantRemaining: time.Minute},
+				// После продолжения текущая отметка объявляется снова
+				{name: "отсчет после продолжения", do: tick(500 * time.Millisecond), wantState: CountdownCounting, wantRemaining: 59500 * time.Millisecond, wantLog: "countdown_mark"},
+				{name: "переключение в удержание", do: call(func(c *countdown) { c.toggle("SIGUSR1") }), wantState: CountdownHold, wantRemaining: 59500 * time.Millisecond, wantLog: "countdown_hold"},
+				{name: "переключение в отсчет", do: call(func(c *countdown) { c.toggle("SIGUSR1") }), wantState: CountdownCounting, wantRemaining: 59500 * time.Millisecond, wantLog: "countdown_resumed"},
+				{name: "нулевая тяга сервера", do: call(func(c *countdown) { c.serverCommand(throttle(0)) }), wantState: CountdownHold, wantRemaining: 59500 * time.Millisecond, wantLog: "countdown_hold"},
+				{name: "тяга сервера", do: call(func(c *countdown) { c.serverCommand(throttle(1)) }), wantState: CountdownCounting, wantRemaining: 59500 * time.Millisecond, wantLog: "countdown_resumed"},
+				// За длинный тик объявляется только последняя отметка
+				{name: "длинный тик", do: tick(57 * time.Second), wantState: CountdownCounting, wantRemaining: 2500 * time.Millisecond, wantLog: "countdown_mark"},
+				{name: "зажигание", do: tick(3 * time.Second), wantState: CountdownLaunched, wantLog: "countdown_ignition"},
+				{name: "удержание после T-0", do: call(func(c *countdown) { c.hold("оператор") }), wantState: CountdownLaunched},
+				{name: "отмена после T-0", do: call(func(c *countdown) { c.scrub("оператор") }), wantState: CountdownLaunched},
+			},
+		},
+		{
+			name: "отмена на удержании",
+			ops: []op{
+				{name: "удержание", do: call(func(c *countdown) { c.hold("оператор") }), wantState: CountdownHold, wantRemaining: 75 * time.Second, wantLog: "countdown_hold"},
+				{name: "отмена", do: call(func(c *countdown) { c.scrub("SIGINT") }), wantState: CountdownScrubbed, wantRemaining: 75 * time.Second, wantLog: "countdown_scrubbed"},
+				{name: "продолжение после отмены", do: call(func(c *countdown) { c.resume("оператор") }), wantState: CountdownScrubbed, wantRemaining: 75 * time.Second},
+				{name: "тяга сервера после отмены", do: call(func(c *countdown) { c.serverCommand(throttle(1)) }), wantState: CountdownScrubbed, wantRemaining: 75 * time.Second},
+				{name: "отсчет после отмены", do: tick(time.Minute), wantState: CountdownScrubbed, wantRemaining: 75 * time.Second},
+				{name: "повторная отмена", do: call(func(c *countdown) { c.scrub("SIGINT") }), wantState: CountdownScrubbed, wantRemaining: 75 * time.Second},
+			},
+		},
+		{
+			name: "отмена во время отсчета",
+			ops: []op{
+				{name: "отсчет", do: tick(5 * time.Second), wantState: CountdownCounting, wantRemaining: 70 * time.Second},
+				{name: "отмена", do: call(func(c *countdown) { c.scrub("сервер") }), wantState: CountdownScrubbed, wantRemaining: 70 * time.Second, wantLog: "countdown_scrubbed"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log bytes.Buffer
+			c := newCountdown(75*time.Second, logging.New(&log, logging.LevelInfo, true))
+			for _, op := range tt.ops {
+				state := op.do(c)
+				if state != op.wantState || c.remaining != op.wantRemaining {
+					t.Fatalf("%s: %s, осталось %v; ожидалось %s и %v", op.name, state, c.remaining, op.wantState, op.wantRemaining)
+				}
+				active := state == CountdownCounting || state == CountdownHold
+				if c.active() != active {
+					t.Errorf("%s: active() %v в состоянии %s", op.name, c.active(), state)
+				}
+				if codes := strings.Join(logCodes(t, &log), ","); codes != op.wantLog {
+					t.Errorf("%s: журнал %q, ожидался %q", op.name, codes, op.wantLog)
+				}
+			}
+		})
+	}
+}
+
+func TestCountdownMark(t *testing.T) {
+	for _, tt := range []struct {
+		remaining, want time.Duration
+	}{
+		{10 * time.Minute, 10 * time.Minute},
+		{75 * time.Second, 2 * time.Minute},
+		{61 * time.Second, 2 * time.Minute},
+		{time.Minute, time.Minute},
+		{59500 * time.Millisecond, time.Minute},
+		{41 * time.Second, 50 * time.Second},
+		{11 * time.Second, 20 * time.Second},
+		{10 * time.Second, 10 * time.Second},
+		{2500 * time.Millisecond, 3 * time.Second},
+		{time.Millisecond, time.Second},
+	} {
+		if got := countdownMark(tt.remaining); got != tt.want {
+			t.Errorf("отметка для T-%v: T-%v, ожидалась T-%v", tt.remaining, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package rocketclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const handshakeTimeout = 45 * time.Second
+
+// tlsOptions - настройки TLS для wss:// (-ca-cert, -insecure-skip-verify)
+type tlsOptions struct {
+	caCert             string // PEM-файл с сертификатами CA в дополнение к системным
+	insecureSkipVerify bool
+}
+
+// newDialer создает dialer с настройками TLS. Прокси берется из окружения:
+// HTTPS_PROXY для wss://, HTTP_PROXY для ws://, с учетом NO_PROXY.
+func newDialer(opts tlsOptions) (*websocket.Dialer, error) {
+	dialer := &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: handshakeTimeout,
+	}
+	if opts.caCert == "" && !opts.insecureSkipVerify {
+		return dialer, nil
+	}
+
+	config := &tls.Config{InsecureSkipVerify: opts.insecureSkipVerify}
+	if opts.caCert != "" {
+		pem, err := os.ReadFile(opts.caCert)
+		if err != nil {
+			return nil, fmt.Errorf("-ca-cert: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("-ca-cert: в %s нет сертификатов в формате PEM", opts.caCert)
+		}
+		config.RootCAs = pool
+	}
+	dialer.TLSClientConfig = config
+	return dialer, nil
+}
+
+// dialError отличает отказ проверки сертификата сервера от сетевой ошибки:
+// первое лечится -ca-cert, второе - адресом сервера или прокси
+func dialError(err error) error {
+	var verification *tls.CertificateVerificationError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	var invalid x509.CertificateInvalidError
+	if errors.As(err, &verification) || errors.As(err, &unknownAuthority) ||
+		errors.As(err, &hostname) || errors.As(err, &invalid) {
+		return fmt.Errorf("Сертификат сервера не прошел проверку TLS (укажите CA через -ca-cert): %w", err)
+	}
+	return fmt.Errorf("Ошибка подключения к серверу: %w", err)
+}
@@ -0,0 +1,131 @@
+package rocketclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeCACert пишет самоподписанный сертификат CA в PEM-файл
+func writeCACert(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "cosmodrom test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNewDialer(t *testing.T) {
+	caCert := writeCACert(t)
+	notPEM := filepath.Join(t.TempDir(), "not.pem")
+	if err := os.WriteFile(notPEM, []byte("не сертификат"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		opts         tlsOptions
+		wantErr      string
+		wantTLS      bool
+		wantInsecure bool
+		wantRootCAs  bool
+	}{
+		{name: "по умолчанию", opts: tlsOptions{}},
+		{name: "без проверки", opts: tlsOptions{insecureSkipVerify: true}, wantTLS: true, wantInsecure: true},
+		{name: "свой CA", opts: tlsOptions{caCert: caCert}, wantTLS: true, wantRootCAs: true},
+		{name: "CA и без проверки", opts: tlsOptions{caCert: caCert, insecureSkipVerify: true}, wantTLS: true, wantInsecure: true, wantRootCAs: true},
+		{name: "нет файла", opts: tlsOptions{caCert: filepath.Join(t.TempDir(), "missing.pem")}, wantErr: "-ca-cert"},
+		{name: "не PEM", opts: tlsOptions{caCert: notPEM}, wantErr: "нет сертификатов"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialer, err := newDialer(tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ошибка %v, ожидалась содержащая %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if dialer.Proxy == nil {
+				t.Error("прокси из окружения не учитывается")
+			}
+			if dialer.HandshakeTimeout != handshakeTimeout {
+				t.Errorf("HandshakeTimeout = %v, ожидалось %v", dialer.HandshakeTimeout, handshakeTimeout)
+			}
+			config := dialer.TLSClientConfig
+			if (config != nil) != tt.wantTLS {
+				t.Fatalf("TLSClientConfig = %v, ожидался: %v", config, tt.wantTLS)
+			}
+			if config == nil {
+				return
+			}
+			if config.InsecureSkipVerify != tt.wantInsecure {
+				t.Errorf("InsecureSkipVerify = %v, ожидалось %v", config.InsecureSkipVerify, tt.wantInsecure)
+			}
+			if (config.RootCAs != nil) != tt.wantRootCAs {
+				t.Errorf("RootCAs задан: %v, ожидалось %v", config.RootCAs != nil, tt.wantRootCAs)
+			}
+		})
+	}
+}
+
+func TestDialError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		tls  bool
+	}{
+		{name: "неизвестный CA", err: &tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}, tls: true},
+		{name: "чужое имя", err: x509.HostnameError{Certificate: &x509.Certificate{}, Host: "example.com"}, tls: true},
+		{name: "просрочен", err: x509.CertificateInvalidError{Reason: x509.Expired}, tls: true},
+		{name: "сеть", err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := dialError(tt.err)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("исходная ошибка потеряна: %v", err)
+			}
+			if got := strings.Contains(err.Error(), "-ca-cert"); got != tt.tls {
+				t.Errorf("ошибка TLS: %v, ожидалось %v (%v)", got, tt.tls, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package rocketclient
+
+import "cosmodrom/protocol"
+
+// engineBurn ведет время работы двигателей текущей ступени и дроссели,
+// примененные на последнем шаге, для engine_status в телеметрии
+type engineBurn struct {
+	seconds  []float64 // Суммарное время работы по двигателям, с
+	throttle []float64 // Дроссели последнего шага после отказов и ограничений
+	fueled   bool      // На последнем шаге было топливо
+}
+
+// add учитывает шаг dt с дросселями throttle. Двигатель работает, если он
+// включен в конфигурации, дроссель больше нуля и в баках есть топливо.
+func (b *engineBurn) add(engines []protocol.Engine, throttle []float64, dt float64, fueled bool) {
+	if len(b.seconds) < len(engines) {
+		b.seconds = append(b.seconds, make([]float64, len(engines)-len(b.seconds))...)
+	}
+	b.throttle = append(b.throttle[:0], throttle...)
+	b.fueled = fueled
+	for i, engine := range engines {
+		if b.running(engine, i) {
+			b.seconds[i] += dt
+		}
+	}
+}
+
+func (b *engineBurn) running(engine protocol.Engine, index int) bool {
+	return b.fueled && engine.IsActive && index < len(b.throttle) && b.throttle[index] > 0
+}
+
+// reset начинает учет заново для двигателей новой ступени
+func (b *engineBurn) reset(engines int) {
+	b.seconds = make([]float64, engines)
+	b.throttle = b.throttle[:0]
+}
+
+// currentEngines - двигатели текущей ступени
+func (r *RocketClient) currentEngines() []protocol.Engine {
+	if r.staging != nil {
+		return r.staging.engines()
+	}
+	return r.config.Engines
+}
+
+// engineStatus собирает состояние двигателей текущей ступени для телеметрии
+func (r *RocketClient) engineStatus() []protocol.EngineStatus {
+	engines := r.currentEngines()
+	status := make([]protocol.EngineStatus, len(engines))
+	for i, engine := range engines {
+		status[i] = protocol.EngineStatus{
+			ID:     engine.ID,
+			Active: r.burnTime.running(engine, i),
+			Failed: r.failures.isFailed(i),
+		}
+		if i < len(r.burnTime.throttle) {
+			status[i].Throttle = r.burnTime.throttle[i]
+		}
+		if i < len(r.burnTime.seconds) {
+			status[i].BurnTime = r.burnTime.seconds[i]
+		}
+	}
+	return status
+}
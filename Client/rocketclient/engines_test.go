@@ -0,0 +1,87 @@
+package rocketclient
+
+import (
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/protocol"
+)
+
+func engineTestClient(t *testing.T) *RocketClient {
+	t.Helper()
+	config := DefaultConfig().Rocket
+	config.Engines = []protocol.Engine{
+		{ID: "left", Thrust: 1e5, IsActive: true},
+		{ID: "right", Thrust: 1e5, IsActive: true},
+		{Thrust: 1e5},
+	}
+	r := newRocketClient("test", config, "", 10, 0.1)
+	r.setLogger(logging.New(io.Discard, logging.LevelInfo, false))
+	return r
+}
+
+// Состояние двигателя складывается из примененного дросселя, отказа
+// и накопленного времени работы
+func TestEngineStatus(t *testing.T) {
+	r := engineTestClient(t)
+	failAt, err := parseScheduledFailure("right@1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.failures = newEngineFailures(0, failAt, 1, r.config.Engines, r.logger)
+
+	for step := 1; step <= 20; step++ {
+		now := float64(step) * r.dt
+		r.failures.update(now, r.dt, 0)
+		command := protocol.ControlCommand{EngineThrottle: []float64{0.8, 0.8, 0.8}}
+		r.failures.apply(&command)
+		r.burnTime.add(r.currentEngines(), command.EngineThrottle, r.dt, true)
+	}
+
+	status := r.engineStatus()
+	want := []protocol.EngineStatus{
+		{ID: "left", Throttle: 0.8, Active: true, BurnTime: 2},
+		{ID: "right", Throttle: 0, Failed: true, BurnTime: 0.9},
+		{Throttle: 0.8}, // Выключен в конфигурации
+	}
+	for i := range want {
+		got := status[i]
+		if got.ID != want[i].ID || got.Throttle != want[i].Throttle || got.Active != want[i].Active ||
+			got.Failed != want[i].Failed || math.Abs(got.BurnTime-want[i].BurnTime) > 1e-9 {
+			t.Errorf("двигатель %d: %+v, ожидалось %+v", i, got, want[i])
+		}
+	}
+
+	// Без топлива двигатель не работает, даже с открытым дросселем
+	r.burnTime.add(r.currentEngines(), []float64{1, 0, 0}, r.dt, false)
+	if status := r.engineStatus(); status[0].Active || math.Abs(status[0].BurnTime-2) > 1e-9 {
+		t.Errorf("без топлива: %+v", status[0])
+	}
+}
+
+func TestServerCommandByEngineID(t *testing.T) {
+	r := engineTestClient(t)
+	autopilot := protocol.ControlCommand{EngineThrottle: []float64{1, 1, 1}}
+
+	r.setServerCommand(protocol.ControlCommand{Pitch: 10}, map[string]float64{"right": 0.5})
+	command := r.activeCommand(autopilot)
+	if command.Pitch != 10 || len(command.EngineThrottle) != 3 || command.EngineThrottle[0] != 0 || command.EngineThrottle[1] != 0.5 {
+		t.Errorf("команда %+v, ожидались дроссели [0 0.5 0]", command)
+	}
+
+	// Двигателя нет на текущей ступени - управление у автопилота
+	r.setServerCommand(protocol.ControlCommand{}, map[string]float64{"center": 1})
+	if command := r.activeCommand(autopilot); command.EngineThrottle[0] != 1 || r.serverCommand != nil {
+		t.Errorf("команда %+v, ожидалась команда автопилота", command)
+	}
+
+	r.clock = &fakeClock{now: time.Now().Add(time.Hour)}
+	r.setServerCommand(protocol.ControlCommand{}, map[string]float64{"left": 1})
+	r.clock = &fakeClock{now: time.Now().Add(2 * time.Hour)}
+	if command := r.activeCommand(autopilot); command.EngineThrottle[1] != 1 {
+		t.Errorf("истекшая команда применена: %+v", command)
+	}
+}
@@ -0,0 +1,85 @@
+package rocketclient
+
+import "cosmodrom/protocol"
+
+type EventType string
+
+const (
+	EventPhase          EventType = "phase"           // Автопилот перешел к новому этапу
+	EventWarning        EventType = "warning"         // Предупреждение сервера
+	EventAbort          EventType = "abort"           // Аварийное прекращение полета
+	EventStaging        EventType = "staging"         // Отделение ступени
+	EventParachute      EventType = "parachute"       // Парашют раскрыт или порван
+	EventPayload        EventType = "payload"         // Полезная нагрузка отделена
+	EventEngineFailure  EventType = "engine_failure"  // Отказ двигателя
+	EventConnectionLost EventType = "connection_lost" // Потеряна связь с сервером
+	EventReconnected    EventType = "reconnected"     // Связь восстановлена
+	EventOutcome        EventType = "outcome"         // Полет завершен, последнее событие
+)
+
+// Event - событие полета. Time и Altitude - время симуляции и высота в
+// момент события; у событий связи это последнее известное состояние.
+type Event struct {
+	Type     EventType
+	Time     float64
+	Altitude float64
+	Phase    string
+	Message  string // Причина, текст предупреждения или исход миссии
+}
+
+// eventBuffer - сколько событий хранится, пока их не прочитали из Events
+const eventBuffer = 100
+
+// Events возвращает канал событий полета. Канал закрывается в Close после
+// события outcome. Если события не читают, новые отбрасываются: цикл
+// симуляции не ждет читателя.
+func (r *RocketClient) Events() <-chan Event {
+	return r.events
+}
+
+func (r *RocketClient) emit(eventType EventType, message string) {
+	r.eventsMu.Lock()
+	defer r.eventsMu.Unlock()
+
+	if r.eventsClosed {
+		return
+	}
+	event := Event{
+		Type:     eventType,
+		Time:     r.eventTime,
+		Altitude: r.eventAltitude,
+		Phase:    r.eventPhase,
+		Message:  message,
+	}
+	select {
+	case r.events <- event:
+	default:
+	}
+}
+
+// observe запоминает состояние для событий и сообщает о смене этапа.
+// Вызывается из step после каждого шага физики.
+func (r *RocketClient) observe(state protocol.RocketState) {
+	phase := r.phase()
+
+	r.eventsMu.Lock()
+	r.eventTime = state.Time
+	r.eventAltitude = state.Altitude
+	changed := phase != r.eventPhase
+	r.eventPhase = phase
+	r.eventsMu.Unlock()
+
+	if changed {
+		r.emit(EventPhase, phase)
+	}
+}
+
+func (r *RocketClient) closeEvents() {
+	r.eventsMu.Lock()
+	defer r.eventsMu.Unlock()
+
+	if !r.eventsClosed {
+		r.eventsClosed = true
+		close(r.events)
+	}
+}
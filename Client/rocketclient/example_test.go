@@ -0,0 +1,83 @@
+package rocketclient_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/client/rocketclient"
+	"cosmodrom/protocol"
+)
+
+// hopper включает двигатели на полсекунды, после чего ракета падает на
+// стартовый стол быстрее допустимой скорости посадки
+type hopper struct {
+	coasting bool
+}
+
+func (h *hopper) Step(state protocol.RocketState, dt float64) protocol.ControlCommand {
+	h.coasting = state.Time >= 0.5
+	if h.coasting {
+		return protocol.ControlCommand{EngineThrottle: []float64{0}}
+	}
+	return protocol.ControlCommand{EngineThrottle: []float64{1}}
+}
+
+func (h *hopper) Outcome() rocketclient.MissionOutcome {
+	return ""
+}
+
+func (h *hopper) Phase() string {
+	if h.coasting {
+		return "coast"
+	}
+	return "burn"
+}
+
+// frameCounter считает кадры телеметрии вместо отправки на сервер
+type frameCounter struct {
+	frames int
+}
+
+func (c *frameCounter) Start()                                {}
+func (c *frameCounter) Send(state protocol.RocketState) error { c.frames++; return nil }
+func (c *frameCounter) Abort(msg protocol.AbortMessage) error { return nil }
+func (c *frameCounter) Close(reason string)                   {}
+
+func Example() {
+	sink := &frameCounter{}
+
+	cfg := rocketclient.DefaultConfig()
+	cfg.ID = "example"
+	cfg.Sink = sink
+	cfg.Autopilot = &hopper{}
+	cfg.Logger = logging.New(io.Discard, logging.LevelInfo, false)
+	// Свободное падение идет с ускорением времени
+	cfg.TimeWarp = 100
+	cfg.WarpMinAltitude = 0
+
+	client, err := rocketclient.New(cfg)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	summary, err := client.Launch(context.Background())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	for event := range client.Events() {
+		if event.Type == rocketclient.EventPhase {
+			fmt.Println("этап:", event.Message)
+		}
+	}
+	fmt.Println("исход:", summary.Outcome)
+	fmt.Println("телеметрия отправлена:", sink.frames > 0)
+	// Output:
+	// этап: burn
+	// этап: coast
+	// исход: crashed
+	// телеметрия отправлена: true
+}
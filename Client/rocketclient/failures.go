@@ -0,0 +1,177 @@
+package rocketclient
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/protocol"
+)
+
+// scheduledFailure - детерминированный отказ двигателя index (или с ID id)
+// в момент T+at
+type scheduledFailure struct {
+	index int
+	id    string
+	at    float64 // с, время симуляции
+}
+
+// parseScheduledFailure разбирает значение -fail-engine-at вида
+// "индекс@секунды" или "ID@секунды"
+func parseScheduledFailure(value string) (*scheduledFailure, error) {
+	engine, at, ok := strings.Cut(value, "@")
+	if !ok || engine == "" {
+		return nil, fmt.Errorf("ожидается формат индекс@секунды или ID@секунды, например 0@45, получено %q", value)
+	}
+
+	failure := &scheduledFailure{}
+	if i, err := strconv.Atoi(engine); err == nil {
+		if i < 0 {
+			return nil, fmt.Errorf("некорректный индекс двигателя %q", engine)
+		}
+		failure.index = i
+	} else {
+		failure.id = engine
+	}
+	t, err := strconv.ParseFloat(at, 64)
+	if err != nil || t < 0 {
+		return nil, fmt.Errorf("некорректное время отказа %q", at)
+	}
+	failure.at = t
+	return failure, nil
+}
+
+// resolve возвращает номер двигателя среди engines, false - такого нет
+func (s *scheduledFailure) resolve(engines []protocol.Engine) (int, bool) {
+	if s.id == "" {
+		return s.index, s.index < len(engines)
+	}
+	for i, engine := range engines {
+		if engine.ID == s.id {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func (s *scheduledFailure) String() string {
+	if s.id != "" {
+		return s.id
+	}
+	return strconv.Itoa(s.index)
+}
+
+// engineFailures выключает двигатели навсегда: случайно с частотой rate отказов
+// на двигатель в минуту и/или в заданный момент. Генератор создается из seed,
+// поэтому при одинаковом seed и шаге физики отказы повторяются.
+type engineFailures struct {
+	rate      float64
+	scheduled *scheduledFailure
+	engines   []protocol.Engine // Двигатели текущей ступени, для имен в журнале
+	failed    []bool
+	rng       *rand.Rand
+	logger    *logging.Logger
+}
+
+func newEngineFailures(rate float64, scheduled *scheduledFailure, seed int64, engines []protocol.Engine, logger *logging.Logger) *engineFailures {
+	if rate <= 0 && scheduled == nil {
+		return nil
+	}
+	if rate > 0 {
+		logger.Info("failures_enabled", logging.F("rate", rate), logging.F("seed", seed))
+	}
+	return &engineFailures{
+		rate:      rate,
+		scheduled: scheduled,
+		engines:   engines,
+		failed:    make([]bool, len(engines)),
+		rng:       rand.New(rand.NewSource(seed)),
+		logger:    logger,
+	}
+}
+
+// update разыгрывает отказы за шаг dt, заканчивающийся в момент now.
+// Возвращает true, если отказал хотя бы один двигатель.
+func (f *engineFailures) update(now, dt float64, altitude float64) bool {
+	if f == nil {
+		return false
+	}
+
+	changed := false
+	if s := f.scheduled; s != nil && now >= s.at {
+		f.scheduled = nil
+		if index, ok := s.resolve(f.engines); ok {
+			changed = f.fail(index, now, altitude) || changed
+		} else {
+			f.logger.Warn("failure_no_engine", logging.F("engine", s), logging.F("engines", len(f.failed)))
+		}
+	}
+
+	if f.rate > 0 {
+		probability := 1 - math.Exp(-f.rate*dt/60.0)
+		for i := range f.failed {
+			if !f.failed[i] && f.rng.Float64() < probability {
+				changed = f.fail(i, now, altitude) || changed
+			}
+		}
+	}
+	return changed
+}
+
+func (f *engineFailures) fail(index int, now, altitude float64) bool {
+	if f.failed[index] {
+		return false
+	}
+	f.failed[index] = true
+	f.logger.Warn("engine_failed",
+		logging.F("engine", protocol.EngineName(f.engines, index)),
+		logging.F("number", index+1),
+		logging.F("engines", len(f.failed)),
+		logging.F("time", now),
+		logging.F("altitude_km", altitude/1000.0))
+	return true
+}
+
+// apply обнуляет дроссели отказавших двигателей. Срез копируется, чтобы не
+// менять команду автопилота или сервера.
+func (f *engineFailures) apply(command *protocol.ControlCommand) {
+	if f == nil {
+		return
+	}
+
+	throttle := append([]float64(nil), command.EngineThrottle...)
+	for i := range throttle {
+		if i < len(f.failed) && f.failed[i] {
+			throttle[i] = 0
+		}
+	}
+	command.EngineThrottle = throttle
+}
+
+// remainingThrust - суммарная тяга исправных активных двигателей
+func (f *engineFailures) remainingThrust(engines []protocol.Engine) float64 {
+	thrust := 0.0
+	for i, engine := range engines {
+		if engine.IsActive && (f == nil || i >= len(f.failed) || !f.failed[i]) {
+			thrust += engine.Thrust
+		}
+	}
+	return thrust
+}
+
+// isFailed сообщает, отказал ли двигатель index; false, если отказы выключены
+func (f *engineFailures) isFailed(index int) bool {
+	return f != nil && index < len(f.failed) && f.failed[index]
+}
+
+// reset начинает учет заново для двигателей новой ступени
+func (f *engineFailures) reset(engines []protocol.Engine) {
+	if f == nil {
+		return
+	}
+	f.engines = engines
+	f.failed = make([]bool, len(engines))
+}
@@ -0,0 +1,119 @@
+package rocketclient
+
+import (
+	"context"
+	"io"
+	"slices"
+	"testing"
+	"time"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/protocol"
+)
+
+// failureLog - моменты и номера отказов за steps шагов dt
+func failureLog(seed int64, engines, steps int, dt float64) []float64 {
+	f := newEngineFailures(2, nil, seed, make([]protocol.Engine, engines), logging.New(io.Discard, logging.LevelInfo, false))
+	var log []float64
+	for step := 1; step <= steps; step++ {
+		now := float64(step) * dt
+		before := slices.Clone(f.failed)
+		if !f.update(now, dt, 0) {
+			continue
+		}
+		for i := range f.failed {
+			if f.failed[i] && !before[i] {
+				log = append(log, now, float64(i))
+			}
+		}
+	}
+	return log
+}
+
+func TestEngineFailuresSeeded(t *testing.T) {
+	// 2 отказа в минуту на двигатель: за 2 минуты откажет почти каждый
+	first := failureLog(7, 9, 1200, 0.1)
+	if len(first) == 0 {
+		t.Fatal("за 2 минуты не отказал ни один двигатель")
+	}
+	if again := failureLog(7, 9, 1200, 0.1); !slices.Equal(first, again) {
+		t.Errorf("тот же seed дал другие отказы:\n%v\n%v", first, again)
+	}
+	if other := failureLog(8, 9, 1200, 0.1); slices.Equal(first, other) {
+		t.Errorf("другой seed дал те же отказы: %v", other)
+	}
+}
+
+// stateLog запоминает телеметрию вместо отправки на сервер
+type stateLog struct {
+	states []protocol.RocketState
+}
+
+func (s *stateLog) Start() {}
+func (s *stateLog) Send(state protocol.RocketState) error {
+	s.states = append(s.states, state)
+	return nil
+}
+func (s *stateLog) Abort(msg protocol.AbortMessage) error { return nil }
+func (s *stateLog) Close(reason string)                   {}
+
+// seededFlight - полет четырехдвигательной ракеты с отказами из seed
+func seededFlight(t *testing.T, seed int64) (MissionSummary, []protocol.RocketState) {
+	t.Helper()
+	sink := &stateLog{}
+	cfg := DefaultConfig()
+	cfg.ID = "seeded"
+	engine := protocol.Engine{Thrust: 1.9e6, FuelConsumption: 625, IsActive: true}
+	cfg.Rocket.Engines = []protocol.Engine{engine, engine, engine, engine}
+	cfg.Sink = sink
+	cfg.Unpaced = true
+	cfg.Dt = 0.05
+	cfg.FailureRate = 1
+	cfg.FailureSeed = seed
+	cfg.MaxFlightTime = 4 * time.Minute
+	cfg.Logger = logging.New(io.Discard, logging.LevelInfo, false)
+
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary, err := client.Launch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return summary, sink.states
+}
+
+// С тем же seed полет с отказами повторяется кадр в кадр
+func TestSeededFailureFlightRepeats(t *testing.T) {
+	summary, states := seededFlight(t, 42)
+	failed := 0
+	for _, engine := range states[len(states)-1].EngineStatus {
+		if engine.Failed {
+			failed++
+		}
+	}
+	if failed == 0 {
+		t.Fatal("за полет не отказал ни один двигатель")
+	}
+
+	again, repeated := seededFlight(t, 42)
+	if again != summary {
+		t.Errorf("итог повтора %+v, ожидался %+v", again, summary)
+	}
+	if len(repeated) != len(states) {
+		t.Fatalf("кадров %d, в первом полете %d", len(repeated), len(states))
+	}
+	for i := range states {
+		a, b := states[i], repeated[i]
+		if a.Time != b.Time || a.Position != b.Position || a.Velocity != b.Velocity ||
+			a.FuelRemaining != b.FuelRemaining || !slices.Equal(a.EngineStatus, b.EngineStatus) {
+			t.Fatalf("кадр %d расходится:\n%+v\n%+v", i, a, b)
+		}
+	}
+
+	// Другой seed - другие отказы
+	if _, other := seededFlight(t, 43); slices.Equal(other[len(other)-1].EngineStatus, states[len(states)-1].EngineStatus) {
+		t.Errorf("другой seed дал те же отказы: %+v", other[len(other)-1].EngineStatus)
+	}
+}
@@ -0,0 +1,116 @@
+package rocketclient
+
+import (
+	"fmt"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/client/physics"
+	"cosmodrom/protocol"
+)
+
+type FlightMode string
+
+const (
+	FlightModeOrbit FlightMode = "orbit" // Выведение на орбиту
+	FlightModeHop   FlightMode = "hop"   // Подскок с реактивной посадкой
+	FlightModeChase FlightMode = "chase" // Полет за другой ракетой
+)
+
+// Autopilot - программа полета. Step на каждом шаге физики длиной dt
+// возвращает команду по состоянию с датчиков, в котором прогноз орбиты уже
+// заполнен, как в телеметрии (поля Orbit*). Дроссели команды - как в
+// сценарии: одно значение действует на все двигатели ступени, недостающие в
+// списке выключены; срез может использоваться программой повторно до
+// следующего Step. После программы команду еще ограничивают max-Q,
+// ориентация, отказы и команда сервера. Встроенные программы выбираются
+// Config.Mode и Config.Script, свою можно передать в Config.Autopilot.
+type Autopilot interface {
+	Step(state protocol.RocketState, dt float64) protocol.ControlCommand
+	// Outcome - исход, достигнутый программой, или пустая строка, пока полет продолжается
+	Outcome() MissionOutcome
+	// Phase - название текущего этапа для журнала, записи полета и событий
+	Phase() string
+}
+
+// orbitPlanner - то, что нужно автопилоту выведения от физики сверх
+// состояния: время до апсид и планы маневров. Реализации - PhysicsEngine,
+// в тестах physics.FakePhysics.
+type orbitPlanner interface {
+	PredictOrbit() (physics.OrbitPrediction, error)
+	PlanCircularization(targetAlt float64) (physics.BurnPlan, error)
+}
+
+// thrustAware - программы, которые пересчитывают профиль при изменении
+// доступной тяги (отказ двигателя, отделение ступени)
+type thrustAware interface {
+	// thrust - тяга исправных двигателей, nominal - всех двигателей текущей ступени
+	setThrust(thrust, nominal float64)
+}
+
+// setFlightMode выбирает программу полета. targetAltitude - высота орбиты
+// для orbit и высота подъема для hop; параметры chase берутся из Config.
+func (r *RocketClient) setFlightMode(mode FlightMode, targetAltitude float64) error {
+	switch mode {
+	case FlightModeOrbit:
+		program := newGravityTurnAutopilot(targetAltitude, r.planet, r.gravityTurn, r.physics, r.logger)
+		program.pid = newApoapsisController(r.apoapsisGains, r.logger)
+		if cfg := r.launch; cfg.TargetInclination >= 0 {
+			azimuth, err := newAzimuthSteering(cfg.TargetInclination, cfg.Latitude, targetAltitude, r.planet, r.logger)
+			if err != nil {
+				return err
+			}
+			program.azimuth = azimuth
+		}
+		if cfg := r.launch; cfg.PostOrbitRaise > 0 {
+			plan, err := r.physics.PlanHohmann(targetAltitude, cfg.PostOrbitRaise)
+			if err != nil {
+				return fmt.Errorf("-post-orbit-raise: %w", err)
+			}
+			program.transfer = newHohmannTransfer(targetAltitude, cfg.PostOrbitRaise, plan)
+		}
+		r.program = program
+	case FlightModeHop:
+		hop := newHopAutopilot(targetAltitude, r.planet, totalThrust(r.config.Engines), r.logger)
+		hop.parachute = r.config.Parachute
+		r.program = hop
+		r.logger.Info("hop_mode", logging.F("target_altitude", targetAltitude))
+	case FlightModeChase:
+		cfg := r.launch
+		r.chase = newChaseProgram(cfg.ChaseTarget, cfg.ChaseOffset, cfg.ChaseTolerance, totalThrust(r.config.Engines), r.clock, r.logger)
+		r.program = r.chase
+		r.logger.Info("chase_mode", logging.F("offset", cfg.ChaseOffset), logging.F("target", cfg.ChaseTarget))
+	default:
+		return fmt.Errorf("неизвестный режим полета: %s (ожидается orbit, hop или chase)", mode)
+	}
+	return nil
+}
+
+// thrustChanged сообщает программе полета тягу исправных двигателей текущей ступени
+func (r *RocketClient) thrustChanged() {
+	engines := r.currentEngines()
+	if program, ok := r.program.(thrustAware); ok {
+		program.setThrust(r.failures.remainingThrust(engines), totalThrust(engines))
+	}
+}
+
+// commandThrottle - дроссель двигателя i по дросселям команды автопилота:
+// одно значение действует на все двигатели, недостающие в списке выключены
+func commandThrottle(throttle []float64, i int) float64 {
+	switch {
+	case len(throttle) == 1:
+		return throttle[0]
+	case i < len(throttle):
+		return throttle[i]
+	}
+	return 0
+}
+
+func totalThrust(engines []protocol.Engine) float64 {
+	thrust := 0.0
+	for _, engine := range engines {
+		if engine.IsActive {
+			thrust += engine.Thrust
+		}
+	}
+	return thrust
+}
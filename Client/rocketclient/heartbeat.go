@@ -0,0 +1,208 @@
+package rocketclient
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/protocol"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	heartbeatMaxMissed = 3  // Столько неотвеченных heartbeat подряд - потеря связи
+	heartbeatRTTWindow = 10 // Число ответов в скользящем среднем RTT
+)
+
+// heartbeatMonitor проверяет, что сервер еще получает сообщения ракеты:
+// запись в оборванное TCP-соединение долго не возвращает ошибку.
+// Раз в interval уходит heartbeat с новым nonce, сервер возвращает его обратно.
+type heartbeatMonitor struct {
+	interval time.Duration // 0 - heartbeat выключен
+	timeout  time.Duration // Сколько ждать ответа
+
+	mu      sync.Mutex
+	nonce   uint64
+	pending map[uint64]time.Time // Отправленные без ответа: nonce -> время отправки
+	missed  int                  // Неотвеченных подряд
+	samples []time.Duration      // Последние heartbeatRTTWindow измерений
+	next    int                  // Куда писать следующее измерение
+	skewed  bool                 // Расхождение часов с сервером уже в журнале этого соединения
+}
+
+func (h *heartbeatMonitor) enabled() bool {
+	return h != nil && h.interval > 0
+}
+
+func (h *heartbeatMonitor) validate() error {
+	if h.interval < 0 {
+		return fmt.Errorf("-heartbeat не может быть отрицательным")
+	}
+	if h.interval > 0 && h.timeout <= 0 {
+		return fmt.Errorf("-heartbeat-timeout должен быть положительным")
+	}
+	return nil
+}
+
+// reset забывает неотвеченные heartbeat прежнего соединения. RTT сохраняется.
+func (h *heartbeatMonitor) reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.pending = make(map[uint64]time.Time)
+	h.missed = 0
+	h.skewed = false
+}
+
+func (h *heartbeatMonitor) send(now time.Time) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.nonce++
+	h.pending[h.nonce] = now
+	return h.nonce
+}
+
+// received учитывает ответ сервера. Ответ на уже просроченный heartbeat
+// не учитывается: он все равно опоздал.
+func (h *heartbeatMonitor) received(nonce uint64, now time.Time) (rtt time.Duration, recovered, ok bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sent, ok := h.pending[nonce]
+	if !ok {
+		return 0, false, false
+	}
+	delete(h.pending, nonce)
+
+	rtt = max(now.Sub(sent), 0) // Часы могли перевести назад
+	if len(h.samples) < heartbeatRTTWindow {
+		h.samples = append(h.samples, rtt)
+	} else {
+		h.samples[h.next] = rtt
+	}
+	h.next = (h.next + 1) % heartbeatRTTWindow
+
+	recovered = h.missed > 0
+	h.missed = 0
+	return rtt, recovered, true
+}
+
+// clockSkew оценивает расхождение часов сервера с часами ракеты по метке
+// времени ответа: сервер ставит ее примерно посередине RTT. Возвращает
+// расхождение только в первый раз за соединение и только сверх
+// protocol.ClockSkewThreshold.
+func (h *heartbeatMonitor) clockSkew(serverTime, now time.Time, rtt time.Duration) (time.Duration, bool) {
+	if serverTime.IsZero() {
+		return 0, false
+	}
+	middle := now.Add(-rtt / 2)
+	if _, skewed := protocol.TransitDelay(serverTime, middle); !skewed {
+		return 0, false
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.skewed {
+		return 0, false
+	}
+	h.skewed = true
+	return serverTime.Sub(middle), true
+}
+
+// expire снимает heartbeat без ответа дольше timeout и возвращает, сколько
+// их пропущено подряд. Ноль - все ответы пришли вовремя.
+func (h *heartbeatMonitor) expire(now time.Time) (expired, missed int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for nonce, sent := range h.pending {
+		if now.Sub(sent) >= h.timeout {
+			delete(h.pending, nonce)
+			expired++
+		}
+	}
+	h.missed += expired
+	return expired, h.missed
+}
+
+// rtt - скользящее среднее времени ответа, 0 если ответов еще не было
+func (h *heartbeatMonitor) rtt() time.Duration {
+	if h == nil {
+		return 0
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.samples) == 0 {
+		return 0
+	}
+	var sum time.Duration
+	for _, sample := range h.samples {
+		sum += sample
+	}
+	return sum / time.Duration(len(h.samples))
+}
+
+// heartbeatLoop работает, пока conn - текущее соединение клиента.
+// Запускается вместе с receiveMessages для каждого соединения.
+func (r *RocketClient) heartbeatLoop(conn *websocket.Conn) {
+	if !r.heartbeat.enabled() {
+		return
+	}
+	r.heartbeat.reset()
+
+	ticker := time.NewTicker(r.heartbeat.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		r.connMu.Lock()
+		current := r.conn == conn
+		r.connMu.Unlock()
+		if !current {
+			return
+		}
+
+		now := r.clock.Now()
+		if expired, missed := r.heartbeat.expire(now); expired > 0 {
+			r.logger.Warn("heartbeat_missed", logging.F("timeout", r.heartbeat.timeout), logging.F("missed", missed))
+			if missed >= heartbeatMaxMissed {
+				r.connectionLost(conn, fmt.Errorf("нет ответа на heartbeat %d раз подряд", missed))
+				return
+			}
+		}
+
+		nonce := r.heartbeat.send(now)
+		err := r.writeTo(conn, protocol.MsgTypeHeartbeat, protocol.HeartbeatMessage{RocketID: r.ID, Nonce: nonce})
+		if err != nil {
+			r.connectionLost(conn, err)
+			return
+		}
+	}
+}
+
+func (r *RocketClient) handleHeartbeat(msg protocol.Message) {
+	heartbeatMsg, err := protocol.DecodeData[protocol.HeartbeatMessage](msg)
+	if err != nil {
+		r.logger.Warn("message_decode_failed", logging.F("type", msg.Type), logging.F("error", err))
+		return
+	}
+
+	now := r.clock.Now()
+	rtt, recovered, ok := r.heartbeat.received(heartbeatMsg.Nonce, now)
+	if !ok {
+		return
+	}
+	if recovered {
+		r.logger.Info("heartbeat_restored", logging.F("rtt_ms", rtt.Seconds()*1000))
+	}
+	if skew, first := r.heartbeat.clockSkew(msg.Timestamp, now, rtt); first {
+		r.logger.Warn("clock_skew", logging.F("skew", skew.Round(time.Millisecond)))
+	}
+}
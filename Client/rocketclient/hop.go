@@ -0,0 +1,145 @@
+package rocketclient
+
+import (
+	"math"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/client/physics"
+	"cosmodrom/protocol"
+)
+
+type HopPhase string
+
+const (
+	HopPhaseAscent    HopPhase = "ascent"    // Вертикальный подъем
+	HopPhaseCoast     HopPhase = "coast"     // Двигатели выключены, полет к вершине и падение
+	HopPhaseLanding   HopPhase = "landing"   // Тормозной импульс до касания
+	HopPhaseParachute HopPhase = "parachute" // Спуск на парашюте без двигателей
+)
+
+const (
+	touchdownSpeed = 2.0 // м/с, целевая скорость касания (физика считает посадкой < 5 м/с)
+	landingReserve = 0.8 // Доля тяги, на которую рассчитывается торможение (запас на регулирование)
+	landingMinAlt  = 3.0 // м, ниже этой высоты держим скорость касания
+	touchdownGain  = 5.0 // 1/с, коэффициент удержания скорости касания
+)
+
+// HopAutopilot выполняет подскок: подъем до целевой высоты, свободный полет
+// и посадку с постоянным торможением ("suicide burn"). Ракета с парашютом
+// спускается на нем, а двигатели включает, только если купол порвался.
+type HopAutopilot struct {
+	target    float64 // Высота подъема, м
+	planet    physics.PlanetConfig
+	thrust    float64             // Суммарная тяга активных двигателей, Н
+	parachute *protocol.Parachute // nil - посадка на двигателях
+	phase     HopPhase
+	logger    *logging.Logger
+
+	throttle []float64 // Дроссели последней команды
+}
+
+func newHopAutopilot(target float64, planet physics.PlanetConfig, thrust float64, logger *logging.Logger) *HopAutopilot {
+	return &HopAutopilot{target: target, planet: planet, thrust: thrust, phase: HopPhaseAscent, logger: logger}
+}
+
+// Step держит ракету вертикально (тангаж 0) и управляет только тягой и парашютом
+func (s *HopAutopilot) Step(state protocol.RocketState, dt float64) protocol.ControlCommand {
+	g := s.gravity(state.Altitude)
+	vertical := verticalSpeed(state)
+	throttle := 0.0
+	var command protocol.ControlCommand
+
+	switch s.phase {
+	case HopPhaseAscent:
+		throttle = 1.0
+		// Высота вершины баллистической траектории после выключения двигателей
+		if vertical > 0 && state.Altitude+vertical*vertical/(2*g) >= s.target {
+			s.phase = HopPhaseCoast
+			s.logger.Info("hop_cutoff", logging.F("altitude", state.Altitude), logging.F("vertical_speed", vertical))
+		}
+		if state.FuelRemaining <= 0 {
+			s.phase = HopPhaseCoast
+			s.logger.Info("hop_fuel_out", logging.F("altitude", state.Altitude))
+		}
+
+	case HopPhaseCoast:
+		if vertical < 0 && s.parachute != nil && !state.ParachuteFailed {
+			if state.Altitude <= s.parachute.DeployAltitude {
+				s.phase = HopPhaseParachute
+				s.logger.Info("hop_parachute", logging.F("altitude", state.Altitude), logging.F("speed", -vertical))
+			}
+		} else if vertical < 0 {
+			ignition := suicideBurnAltitude(-vertical, s.thrust*landingReserve, state.MassCurrent, g)
+			if state.Altitude <= ignition+landingMinAlt {
+				s.phase = HopPhaseLanding
+				s.logger.Info("hop_landing_burn", logging.F("altitude", state.Altitude), logging.F("speed", -vertical))
+			}
+		}
+
+	case HopPhaseLanding:
+		throttle = s.landingThrottle(state, vertical, g)
+
+	case HopPhaseParachute:
+		command.DeployParachute = true
+		if state.ParachuteFailed {
+			s.phase = HopPhaseCoast
+			s.logger.Warn("hop_parachute_torn", logging.F("speed", -vertical))
+		}
+	}
+
+	s.throttle = append(s.throttle[:0], throttle)
+	command.EngineThrottle = s.throttle
+	return command
+}
+
+// Исход подскока определяется по состоянию физики (посадка или крушение)
+func (s *HopAutopilot) Outcome() MissionOutcome {
+	return ""
+}
+
+func (s *HopAutopilot) setThrust(thrust, nominal float64) {
+	s.thrust = thrust
+	s.logger.Info("hop_thrust", logging.F("thrust_kn", thrust/1000.0))
+}
+
+func (s *HopAutopilot) Phase() string {
+	return string(s.phase)
+}
+
+// landingThrottle подбирает тягу так, чтобы погасить скорость до
+// touchdownSpeed к высоте landingMinAlt, а ниже держит скорость касания
+func (s *HopAutopilot) landingThrottle(state protocol.RocketState, vertical, g float64) float64 {
+	if s.thrust <= 0 {
+		return 0.0
+	}
+
+	descent := -vertical
+	var decel float64
+	if state.Altitude > 2*landingMinAlt && descent > touchdownSpeed {
+		decel = (descent*descent - touchdownSpeed*touchdownSpeed) / (2 * (state.Altitude - landingMinAlt))
+	} else {
+		decel = (descent - touchdownSpeed) * touchdownGain
+	}
+
+	throttle := state.MassCurrent * (decel + g) / s.thrust
+	return math.Max(0.0, math.Min(1.0, throttle))
+}
+
+func (s *HopAutopilot) gravity(altitude float64) float64 {
+	r := s.planet.Radius + altitude
+	return protocol.GConstant * s.planet.Mass / (r * r)
+}
+
+// suicideBurnAltitude - высота, на которой нужно включить двигатели на полную
+// тягу, чтобы погасить скорость падения speed к поверхности. Если тяга не
+// превышает вес, безопасная посадка невозможна и возвращается +Inf.
+func suicideBurnAltitude(speed, thrust, mass, gravity float64) float64 {
+	if mass <= 0 {
+		return math.Inf(1)
+	}
+	decel := thrust/mass - gravity
+	if decel <= 0 {
+		return math.Inf(1)
+	}
+	return speed * speed / (2 * decel)
+}
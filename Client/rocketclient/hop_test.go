@@ -0,0 +1,178 @@
+package rocketclient
+
+import (
+	"io"
+	"math"
+	"testing"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/client/physics"
+	"cosmodrom/protocol"
+)
+
+// TestHopParachute: метеоракета не может сесть на двигателях, но в подскоке
+// спускается на парашюте и садится
+func TestHopParachute(t *testing.T) {
+	planet := physics.EarthDefault()
+	preset, err := PresetByName("sounding")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := preset.Config()
+	start := planet.Position(45, 63, 0.1)
+	p, err := physics.NewGoPhysics(&config, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	if err := p.SetInitialVelocity(planet.SurfaceVelocity(start)); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newHopAutopilot(5000, planet, totalThrust(config.Engines), logging.New(io.Discard, logging.LevelInfo, false))
+	s.parachute = config.Parachute
+
+	const dt = 0.02
+	var state protocol.RocketState
+	for step := 0; step < int(2000/dt) && !state.Landed && !state.Crashed; step++ {
+		if state, err = p.GetState(); err != nil {
+			t.Fatal(err)
+		}
+		command := s.Step(state, dt)
+		if err := p.Update(&command, dt); err != nil {
+			t.Fatal(err)
+		}
+		if state, err = p.GetState(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if s.Phase() != string(HopPhaseParachute) || !state.ParachuteDeployed {
+		t.Errorf("фаза %s, парашют раскрыт %v", s.Phase(), state.ParachuteDeployed)
+	}
+	if !state.Landed {
+		t.Errorf("ракета не села: разбилась %v, высота %.0f м", state.Crashed, state.Altitude)
+	}
+}
+
+// hopFrame - кадр подскока на высоте altitude с вертикальной скоростью vertical
+func hopFrame(time, altitude, vertical float64, parachuteFailed bool) physics.FakeFrame {
+	planet := physics.EarthDefault()
+	return physics.FakeFrame{State: protocol.RocketState{
+		Time:            time,
+		Position:        protocol.Vector3{X: planet.Radius + altitude},
+		Velocity:        protocol.Vector3{X: vertical},
+		Altitude:        altitude,
+		MassCurrent:     1000,
+		FuelRemaining:   300,
+		ParachuteFailed: parachuteFailed,
+	}}
+}
+
+// Подскок на 1 км: отсечка по высоте вершины баллистической траектории,
+// тормозной импульс по высоте включения на 80% тяги, парашют на высоте
+// раскрытия и двигатели, если купол порвался
+func TestHopAutopilotPhases(t *testing.T) {
+	const thrust = 20000.0 // Н: торможение 20000*0.8/1000 - g = 6.2 м/с2
+	flight := []physics.FakeFrame{
+		hopFrame(0, 0, 0, false),
+		hopFrame(5, 200, 100, false),  // Вершина 710 м
+		hopFrame(8, 500, 110, false),  // Вершина 1117 м - отсечка
+		hopFrame(14, 1000, 0, false),  // Вершина
+		hopFrame(20, 800, -60, false), // Включение на 290 м
+		hopFrame(24, 280, -70, false), // Включение на 395 м
+	}
+
+	tests := []struct {
+		name      string
+		frames    []physics.FakeFrame
+		parachute *protocol.Parachute
+		want      []phaseChange
+	}{
+		{
+			name:   "посадка на двигателях",
+			frames: flight,
+			want:   []phaseChange{{8, "coast"}, {24, "landing"}},
+		},
+		{
+			name:      "спуск на парашюте",
+			frames:    flight,
+			parachute: &protocol.Parachute{DeployAltitude: 500},
+			want:      []phaseChange{{8, "coast"}, {24, "parachute"}},
+		},
+		{
+			name: "купол порвался",
+			frames: append(flight[:len(flight):len(flight)],
+				hopFrame(30, 150, -30, true), // Включение на 73 м
+				hopFrame(33, 60, -30, true)),
+			parachute: &protocol.Parachute{DeployAltitude: 500},
+			want:      []phaseChange{{8, "coast"}, {24, "parachute"}, {30, "coast"}, {33, "landing"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newFakePhysics(t, tt.frames)
+			s := newHopAutopilot(1000, physics.EarthDefault(), thrust, logging.New(io.Discard, logging.LevelInfo, false))
+			s.parachute = tt.parachute
+			changes, states := flyScripted(t, s, p, 40, 1)
+
+			if len(changes) != len(tt.want) {
+				t.Fatalf("смены этапов %v, ожидались %v", changes, tt.want)
+			}
+			for i, want := range tt.want {
+				if changes[i] != want {
+					t.Errorf("смена %d: %v, ожидалась %v", i+1, changes[i], want)
+				}
+			}
+
+			// Команда шага выдается по этапу до его смены на этом шаге
+			phases := phasesAt(changes, "ascent", len(states))
+			for i, command := range p.Commands() {
+				phase := "ascent"
+				if i > 0 {
+					phase = phases[i-1]
+				}
+				burning := command.EngineThrottle[0] > 0
+				if command.Pitch != 0 || burning != (phase == "ascent" || phase == "landing") ||
+					command.DeployParachute != (phase == "parachute") {
+					t.Fatalf("T+%g с, этап %s: тангаж %g, дроссели %v, парашют %v",
+						states[i].Time, phase, command.Pitch, command.EngineThrottle, command.DeployParachute)
+				}
+			}
+		})
+	}
+}
+
+// Высота включения при падении со скоростью 100 м/с, g = 10 м/с2 и массе
+// 1000 кг: v^2 / (2 * (TWR - 1) * g)
+func TestSuicideBurnAltitude(t *testing.T) {
+	const g, mass = 10.0, 1000.0
+	tests := []struct {
+		name  string
+		twr   float64 // Тяговооруженность на полной тяге
+		speed float64 // м/с
+		mass  float64 // кг
+		want  float64 // м
+	}{
+		{name: "TWR 11", twr: 11, speed: 100, mass: mass, want: 50},
+		{name: "TWR 3", twr: 3, speed: 100, mass: mass, want: 250},
+		{name: "TWR 2", twr: 2, speed: 100, mass: mass, want: 500},
+		{name: "TWR 1.5", twr: 1.5, speed: 100, mass: mass, want: 1000},
+		{name: "TWR 2, вдвое быстрее", twr: 2, speed: 200, mass: mass, want: 2000},
+		{name: "TWR 2, уже стоит", twr: 2, speed: 0, mass: mass, want: 0},
+		{name: "TWR 1: тяга равна весу", twr: 1, speed: 100, mass: mass, want: math.Inf(1)},
+		{name: "TWR 0.5", twr: 0.5, speed: 100, mass: mass, want: math.Inf(1)},
+		{name: "без массы", twr: 2, speed: 100, want: math.Inf(1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thrust := tt.twr * mass * g
+			got := suicideBurnAltitude(tt.speed, thrust, tt.mass, g)
+			if math.IsInf(tt.want, 1) != math.IsInf(got, 1) || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("высота включения %g м, ожидалась %g м", got, tt.want)
+			}
+		})
+	}
+}
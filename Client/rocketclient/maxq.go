@@ -0,0 +1,64 @@
+package rocketclient
+
+import (
+	"math"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/protocol"
+)
+
+const (
+	maxQGain        = 4.0 // Снижение дросселя на долю превышения предела
+	maxQMinThrottle = 0.4 // Нижняя граница дросселя при ограничении напора
+)
+
+// maxQGovernor снижает тягу, пока скоростной напор выше limit, и
+// запоминает максимальный напор полета (max-Q)
+type maxQGovernor struct {
+	limit    float64 // Па, 0 - без ограничения
+	limiting bool
+	maxQ     float64 // Па
+	maxQTime float64 // с
+	reported bool
+	logger   *logging.Logger
+}
+
+// observe обновляет max-Q. Максимум пишется в лог, когда напор упал
+// заметно ниже пика, то есть ракета его прошла.
+func (g *maxQGovernor) observe(q, now float64) {
+	if q > g.maxQ {
+		g.maxQ = q
+		g.maxQTime = now
+		g.reported = false
+		return
+	}
+	if !g.reported && g.maxQ > 1000 && q < 0.8*g.maxQ {
+		g.reported = true
+		g.logger.Info("max_q", logging.F("max_q_kpa", g.maxQ/1000.0), logging.F("time", g.maxQTime))
+	}
+}
+
+// apply уменьшает дроссели пропорционально превышению напора над limit,
+// но не ниже maxQMinThrottle, чтобы ракета не теряла вертикальную скорость
+func (g *maxQGovernor) apply(command *protocol.ControlCommand, q float64) {
+	if g.limit <= 0 || meanThrottle(command.EngineThrottle) == 0 {
+		return
+	}
+
+	if q <= g.limit {
+		if g.limiting {
+			g.limiting = false
+			g.logger.Debug("max_q_below", logging.F("limit_kpa", g.limit/1000.0))
+		}
+		return
+	}
+
+	if !g.limiting {
+		g.limiting = true
+		g.logger.Debug("max_q_above", logging.F("limit_kpa", g.limit/1000.0))
+	}
+	scale := math.Max(1-maxQGain*(q-g.limit)/g.limit, maxQMinThrottle)
+	for i := range command.EngineThrottle {
+		command.EngineThrottle[i] *= scale
+	}
+}
@@ -0,0 +1,101 @@
+package rocketclient
+
+import (
+	"context"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/protocol"
+)
+
+func TestMaxQGovernorApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    float64 // Па
+		q        float64 // Па
+		throttle []float64
+		want     []float64
+	}{
+		{name: "без ограничения", q: 90000, throttle: []float64{1, 0.5}, want: []float64{1, 0.5}},
+		{name: "ниже предела", limit: 30000, q: 29000, throttle: []float64{1, 0.5}, want: []float64{1, 0.5}},
+		{name: "на пределе", limit: 30000, q: 30000, throttle: []float64{1}, want: []float64{1}},
+		{name: "превышение на 10%", limit: 30000, q: 33000, throttle: []float64{1, 0.5}, want: []float64{0.6, 0.3}},
+		{name: "превышение на 50%", limit: 30000, q: 45000, throttle: []float64{1, 0.5}, want: []float64{maxQMinThrottle, maxQMinThrottle / 2}},
+		{name: "двигатели выключены", limit: 30000, q: 45000, throttle: []float64{0, 0}, want: []float64{0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := maxQGovernor{limit: tt.limit, logger: logging.New(io.Discard, logging.LevelInfo, false)}
+			command := protocol.ControlCommand{EngineThrottle: tt.throttle}
+			g.apply(&command, tt.q)
+			for i := range tt.want {
+				if math.Abs(command.EngineThrottle[i]-tt.want[i]) > 1e-9 {
+					t.Fatalf("дроссели %v, ожидались %v", command.EngineThrottle, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// denseFlight - выведение в атмосфере в 5 раз плотнее земной, прерванное
+// через 2 минуты
+func denseFlight(t *testing.T, maxQ float64) (MissionSummary, []protocol.RocketState) {
+	t.Helper()
+	sink := &stateLog{}
+	cfg := DefaultConfig()
+	cfg.ID = "dense"
+	cfg.Planet.SurfacePressure = 5
+	cfg.Sink = sink
+	cfg.Unpaced = true
+	cfg.Dt = 0.05
+	cfg.MaxQ = maxQ
+	cfg.MaxFlightTime = 2 * time.Minute
+	cfg.Logger = logging.New(io.Discard, logging.LevelInfo, false)
+
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary, err := client.Launch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return summary, sink.states
+}
+
+// poweredMaxQ - наибольший напор и наименьший дроссель при работающих двигателях
+func poweredMaxQ(states []protocol.RocketState) (maxQ, minThrottle float64) {
+	minThrottle = 1
+	for _, s := range states {
+		if len(s.EngineStatus) == 0 || !s.EngineStatus[0].Active {
+			continue
+		}
+		maxQ = math.Max(maxQ, s.DynamicPressure)
+		minThrottle = math.Min(minThrottle, s.EngineStatus[0].Throttle)
+	}
+	return maxQ, minThrottle
+}
+
+// В плотной атмосфере без ограничения напор на разгоне вдвое выше предела,
+// а регулятор держит его рядом с пределом, убирая тягу не ниже maxQMinThrottle
+func TestMaxQDenseAtmosphere(t *testing.T) {
+	_, free := denseFlight(t, 0)
+	freeQ, freeThrottle := poweredMaxQ(free)
+	if freeThrottle != 1 {
+		t.Fatalf("без ограничения дроссель снижался до %g", freeThrottle)
+	}
+
+	limit := freeQ / 2
+	_, limited := denseFlight(t, limit)
+	q, throttle := poweredMaxQ(limited)
+	// Пропорциональный регулятор оставляет статическую ошибку в несколько процентов
+	if q > 1.15*limit {
+		t.Errorf("напор на разгоне %.0f Па при пределе %.0f Па (без ограничения %.0f Па)", q, limit, freeQ)
+	}
+	if throttle >= 0.9 || throttle < maxQMinThrottle {
+		t.Errorf("наименьший дроссель %g, ожидался от %g до 0.9", throttle, maxQMinThrottle)
+	}
+}
@@ -0,0 +1,180 @@
+package rocketclient
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/protocol"
+)
+
+// noiseOptions - искажение отправляемой телеметрии (-noise-*). Физика и
+// решения автопилота (MECO, посадка) работают с точным состоянием, портится
+// только копия, которая уходит серверу или в файл телеметрии.
+type noiseOptions struct {
+	positionSigma float64       // м, по каждой оси
+	velocitySigma float64       // м/с, по каждой оси
+	altitudeSigma float64       // м
+	dropout       float64       // Вероятность потерять кадр телеметрии
+	latency       time.Duration // Задержка перед отправкой
+	seed          int64
+}
+
+func (o noiseOptions) enabled() bool {
+	return o.positionSigma > 0 || o.velocitySigma > 0 || o.altitudeSigma > 0 || o.dropout > 0 || o.latency > 0
+}
+
+func (o noiseOptions) validate() error {
+	if o.positionSigma < 0 || o.velocitySigma < 0 || o.altitudeSigma < 0 {
+		return fmt.Errorf("сигма шума не может быть отрицательной")
+	}
+	if o.dropout < 0 || o.dropout > 1 {
+		return fmt.Errorf("-noise-dropout должна быть от 0 до 1")
+	}
+	if o.latency < 0 {
+		return fmt.Errorf("-noise-latency не может быть отрицательной")
+	}
+	return nil
+}
+
+// telemetryNoise портит кадры телеметрии. Генератор создается из seed,
+// поэтому при одинаковом seed последовательность искажений повторяется.
+type telemetryNoise struct {
+	opts noiseOptions
+	rng  *rand.Rand
+}
+
+func newTelemetryNoise(opts noiseOptions) *telemetryNoise {
+	return &telemetryNoise{opts: opts, rng: rand.New(rand.NewSource(opts.seed))}
+}
+
+// degrade возвращает искаженную копию состояния; false - кадр потерян
+func (n *telemetryNoise) degrade(state protocol.RocketState) (protocol.RocketState, bool) {
+	if n.opts.dropout > 0 && n.rng.Float64() < n.opts.dropout {
+		return state, false
+	}
+
+	state.Position = n.perturb(state.Position, n.opts.positionSigma)
+	if n.opts.velocitySigma > 0 {
+		state.Velocity = n.perturb(state.Velocity, n.opts.velocitySigma)
+		state.Speed = length(state.Velocity)
+	}
+	state.Altitude += n.rng.NormFloat64() * n.opts.altitudeSigma
+	return state, true
+}
+
+func (n *telemetryNoise) perturb(v protocol.Vector3, sigma float64) protocol.Vector3 {
+	if sigma <= 0 {
+		return v
+	}
+	return protocol.Vector3{
+		X: v.X + n.rng.NormFloat64()*sigma,
+		Y: v.Y + n.rng.NormFloat64()*sigma,
+		Z: v.Z + n.rng.NormFloat64()*sigma,
+	}
+}
+
+// sensorNoise портит состояние, по которому решает автопилот: высота и
+// скорость умножаются на 1+N(0, sigma). Физика и телеметрия не меняются.
+// nil - датчики точные.
+type sensorNoise struct {
+	sigma float64
+	rng   *rand.Rand
+}
+
+func newSensorNoise(sigma float64, seed int64) *sensorNoise {
+	if sigma <= 0 {
+		return nil
+	}
+	return &sensorNoise{sigma: sigma, rng: rand.New(rand.NewSource(seed))}
+}
+
+func (n *sensorNoise) read(state protocol.RocketState) protocol.RocketState {
+	if n == nil {
+		return state
+	}
+	state.Altitude *= 1 + n.rng.NormFloat64()*n.sigma
+	scale := 1 + n.rng.NormFloat64()*n.sigma
+	state.Velocity = protocol.Vector3{X: state.Velocity.X * scale, Y: state.Velocity.Y * scale, Z: state.Velocity.Z * scale}
+	state.Speed *= scale
+	return state
+}
+
+// noisySink оборачивает sink: портит телеметрию и отправляет ее с задержкой.
+// Abort идет через ту же очередь, чтобы не обгонять телеметрию и не писать
+// во внутренний sink из двух горутин.
+type noisySink struct {
+	inner  TelemetrySink
+	noise  *telemetryNoise
+	queue  chan delayedSend
+	done   chan struct{}
+	logger *logging.Logger
+}
+
+type delayedSend struct {
+	due  time.Time
+	send func()
+}
+
+// Кадров в пути при большой задержке: 100 с телеметрии на 10 Гц
+const noiseQueueSize = 1000
+
+func newNoisySink(inner TelemetrySink, opts noiseOptions, logger *logging.Logger) *noisySink {
+	logger.Info("noise_enabled",
+		logging.F("position_sigma", opts.positionSigma),
+		logging.F("velocity_sigma", opts.velocitySigma),
+		logging.F("altitude_sigma", opts.altitudeSigma),
+		logging.F("dropout", opts.dropout*100),
+		logging.F("latency", opts.latency),
+		logging.F("seed", opts.seed))
+	s := &noisySink{
+		inner:  inner,
+		noise:  newTelemetryNoise(opts),
+		queue:  make(chan delayedSend, noiseQueueSize),
+		done:   make(chan struct{}),
+		logger: logger,
+	}
+	go s.deliver()
+	return s
+}
+
+func (s *noisySink) deliver() {
+	defer close(s.done)
+	for item := range s.queue {
+		time.Sleep(time.Until(item.due))
+		item.send()
+	}
+}
+
+func (s *noisySink) enqueue(send func()) {
+	s.queue <- delayedSend{due: time.Now().Add(s.noise.opts.latency), send: send}
+}
+
+func (s *noisySink) Start() {
+	s.inner.Start()
+}
+
+// Send ошибку отправки не возвращает: при задержке она становится известна
+// позже, а потерю связи внутренний sink обрабатывает сам
+func (s *noisySink) Send(state protocol.RocketState) error {
+	wire, ok := s.noise.degrade(state)
+	if !ok {
+		s.logger.Debug("noise_frame_dropped", logging.F("time", state.Time))
+		return nil
+	}
+	s.enqueue(func() { s.inner.Send(wire) })
+	return nil
+}
+
+func (s *noisySink) Abort(msg protocol.AbortMessage) error {
+	s.enqueue(func() { s.inner.Abort(msg) })
+	return nil
+}
+
+// Close дожидается отправки кадров в пути и закрывает внутренний sink
+func (s *noisySink) Close(reason string) {
+	close(s.queue)
+	<-s.done
+	s.inner.Close(reason)
+}
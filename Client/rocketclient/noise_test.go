@@ -0,0 +1,126 @@
+package rocketclient
+
+import (
+	"math"
+	"testing"
+
+	"cosmodrom/protocol"
+)
+
+func TestTelemetryNoiseSigma(t *testing.T) {
+	opts := noiseOptions{positionSigma: 50, velocitySigma: 2, altitudeSigma: 30, seed: 42}
+	noise := newTelemetryNoise(opts)
+	clean := protocol.RocketState{
+		Position: protocol.Vector3{X: 6371000, Y: 1000, Z: -2000},
+		Velocity: protocol.Vector3{X: 10, Y: 400, Z: 0},
+		Altitude: 12000,
+		Speed:    math.Hypot(10, 400),
+	}
+
+	const frames = 20000
+	var px, vy, alt stats
+	for i := 0; i < frames; i++ {
+		wire, ok := noise.degrade(clean)
+		if !ok {
+			t.Fatal("кадр потерян без -noise-dropout")
+		}
+		px.add(wire.Position.X - clean.Position.X)
+		vy.add(wire.Velocity.Y - clean.Velocity.Y)
+		alt.add(wire.Altitude - clean.Altitude)
+	}
+
+	for _, c := range []struct {
+		name  string
+		s     stats
+		sigma float64
+	}{
+		{"позиция", px, opts.positionSigma},
+		{"скорость", vy, opts.velocitySigma},
+		{"высота", alt, opts.altitudeSigma},
+	} {
+		// При 20000 отсчетах погрешность оценки около 0.5% сигмы для
+		// отклонения и 0.7% для среднего - допуск с большим запасом
+		if mean := c.s.mean(); math.Abs(mean) > 0.05*c.sigma {
+			t.Errorf("%s: среднее шума %.3f, ожидалось около 0", c.name, mean)
+		}
+		if sd := c.s.stddev(); math.Abs(sd-c.sigma) > 0.05*c.sigma {
+			t.Errorf("%s: сигма шума %.3f, ожидалось %.3f", c.name, sd, c.sigma)
+		}
+	}
+}
+
+func TestTelemetryNoiseDropout(t *testing.T) {
+	noise := newTelemetryNoise(noiseOptions{dropout: 0.2, seed: 7})
+
+	const frames = 20000
+	dropped := 0
+	for i := 0; i < frames; i++ {
+		if _, ok := noise.degrade(protocol.RocketState{}); !ok {
+			dropped++
+		}
+	}
+	if rate := float64(dropped) / frames; math.Abs(rate-0.2) > 0.02 {
+		t.Errorf("потеряно %.3f кадров, ожидалось 0.2", rate)
+	}
+}
+
+func TestTelemetryNoiseSeed(t *testing.T) {
+	opts := noiseOptions{positionSigma: 10, altitudeSigma: 5, dropout: 0.3, seed: 99}
+	a, b := newTelemetryNoise(opts), newTelemetryNoise(opts)
+	state := protocol.RocketState{Altitude: 1000}
+
+	for i := 0; i < 100; i++ {
+		wa, oka := a.degrade(state)
+		wb, okb := b.degrade(state)
+		if oka != okb || wa.Position != wb.Position || wa.Altitude != wb.Altitude {
+			t.Fatalf("кадр %d: искажения с одним seed различаются", i)
+		}
+	}
+	if state.Altitude != 1000 || state.Position != (protocol.Vector3{}) {
+		t.Errorf("исходное состояние изменено: %+v", state)
+	}
+}
+
+// Шум датчиков масштабирует высоту и скорость: относительная сигма
+// одинакова на любой высоте, а без шума автопилот видит точное состояние
+func TestSensorNoise(t *testing.T) {
+	state := protocol.RocketState{Altitude: 50000, Velocity: protocol.Vector3{X: 3000, Y: 4000}, Speed: 5000}
+	if got := newSensorNoise(0, 1).read(state); got.Altitude != state.Altitude || got.Velocity != state.Velocity {
+		t.Fatalf("без шума состояние изменено: %+v", got)
+	}
+
+	noise := newSensorNoise(0.01, 1)
+	var altitude, speed stats
+	for i := 0; i < 20000; i++ {
+		got := noise.read(state)
+		altitude.add(got.Altitude/state.Altitude - 1)
+		speed.add(got.Speed/state.Speed - 1)
+		if math.Abs(length(got.Velocity)-got.Speed) > 1e-6 {
+			t.Fatalf("скорость %g не совпадает с вектором %g", got.Speed, length(got.Velocity))
+		}
+	}
+	for name, s := range map[string]stats{"высота": altitude, "скорость": speed} {
+		if math.Abs(s.stddev()-0.01) > 0.001 || math.Abs(s.mean()) > 0.001 {
+			t.Errorf("%s: среднее %.4f, сигма %.4f, ожидалось 0 и 0.01", name, s.mean(), s.stddev())
+		}
+	}
+}
+
+type stats struct {
+	n, sum, sumSq float64
+}
+
+func (s *stats) add(x float64) {
+	s.n++
+	s.sum += x
+	s.sumSq += x * x
+}
+
+func (s stats) mean() float64 {
+	return s.sum / s.n
+}
+
+func (s stats) stddev() float64 {
+	m := s.mean()
+	return math.Sqrt(s.sumSq/s.n - m*m)
+}
@@ -0,0 +1,129 @@
+package rocketclient
+
+import (
+	"context"
+	"time"
+
+	"cosmodrom/protocol"
+
+	"github.com/gorilla/websocket"
+)
+
+// ObserverHandler получает события подписки наблюдателя. Все методы
+// вызываются из горутины Observer.Run по очереди.
+type ObserverHandler interface {
+	// Subscribed - подписка оформлена, в том числе заново после обрыва:
+	// сервер следом пришлет rocket_joined и broadcast всех текущих ракет
+	Subscribed()
+	Message(msg protocol.Message)
+	// Lost - соединение потеряно; до следующего Subscribed данные устарели
+	Lost(err error)
+}
+
+// ObserverConfig - параметры подписки наблюдателя без ракеты
+type ObserverConfig struct {
+	ServerURL          string
+	ObserverID         string
+	Labels             map[string]string // Фильтр ракет по меткам, nil - все ракеты
+	CACert             string
+	InsecureSkipVerify bool
+	ReconnectMaxDelay  time.Duration  // 0 - 30 с, как у ракеты
+	Codec              protocol.Codec // nil - protocol.JSON
+	Token              string         // Токен наблюдателя (-observer-token сервера)
+}
+
+// Observer держит подписку на события сервера: трансляции телеметрии,
+// подключения и отключения ракет. Ее используют режим chase и cmd/observer.
+type Observer struct {
+	id       string
+	labels   map[string]string
+	token    string
+	dial     func() (*websocket.Conn, error)
+	codec    protocol.Codec
+	clock    protocol.Clock
+	maxDelay time.Duration
+}
+
+// NewObserver создает наблюдателя. К серверу он подключается в Run.
+func NewObserver(cfg ObserverConfig) (*Observer, error) {
+	dialer, err := newDialer(tlsOptions{caCert: cfg.CACert, insecureSkipVerify: cfg.InsecureSkipVerify})
+	if err != nil {
+		return nil, err
+	}
+	dial := func() (*websocket.Conn, error) {
+		conn, _, err := dialer.Dial(cfg.ServerURL, nil)
+		if err != nil {
+			return nil, dialError(err)
+		}
+		return conn, nil
+	}
+	o := newObserver(cfg.ObserverID, dial, cfg.Codec, protocol.SystemClock, cfg.ReconnectMaxDelay)
+	o.labels = cfg.Labels
+	o.token = cfg.Token
+	return o, nil
+}
+
+func newObserver(id string, dial func() (*websocket.Conn, error), codec protocol.Codec, clock protocol.Clock, maxDelay time.Duration) *Observer {
+	if codec == nil {
+		codec = protocol.JSON
+	}
+	if maxDelay <= 0 {
+		maxDelay = DefaultConfig().ReconnectMaxDelay
+	}
+	return &Observer{id: id, dial: dial, codec: codec, clock: clock, maxDelay: maxDelay}
+}
+
+// Run держит подписку до отмены ctx. При обрыве, в том числе при перезапуске
+// сервера, наблюдатель переподключается с экспоненциальной задержкой и
+// подписывается заново. Соединение отдельное: у ракеты запись в него не
+// пересекается с телеметрией.
+func (o *Observer) Run(ctx context.Context, handler ObserverHandler) {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoffDelay(attempt, o.maxDelay)):
+			}
+		}
+
+		conn, err := o.dial()
+		if err != nil {
+			handler.Lost(err)
+			continue
+		}
+		stop := context.AfterFunc(ctx, func() { conn.Close() })
+		subscribed, err := o.subscribe(conn, handler)
+		stop()
+		conn.Close()
+		if ctx.Err() != nil {
+			return
+		}
+		handler.Lost(err)
+		if subscribed {
+			attempt = 0
+		}
+	}
+}
+
+// subscribe подписывается на события и передает их handler до ошибки
+// соединения. subscribed - подписка была отправлена.
+func (o *Observer) subscribe(conn *websocket.Conn, handler ObserverHandler) (subscribed bool, err error) {
+	err = writeMessage(conn, o.codec, protocol.Message{
+		Type:      protocol.MsgTypeSubscribe,
+		Timestamp: o.clock.Now(),
+		Data:      protocol.SubscribeMessage{ObserverID: o.id, Labels: o.labels, Token: o.token},
+	})
+	if err != nil {
+		return false, err
+	}
+	handler.Subscribed()
+
+	for {
+		msg, err := readMessage(conn, o.codec)
+		if err != nil {
+			return true, err
+		}
+		handler.Message(msg)
+	}
+}
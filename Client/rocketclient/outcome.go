@@ -0,0 +1,116 @@
+package rocketclient
+
+import (
+	"cosmodrom/client/logging"
+	"cosmodrom/protocol"
+)
+
+// MissionOutcome передается серверу как причина отключения и определяет код выхода
+type MissionOutcome string
+
+const (
+	OutcomeOrbit       MissionOutcome = "orbit"       // Орбита сформирована
+	OutcomeLanded      MissionOutcome = "landed"      // Мягкая посадка
+	OutcomeCrashed     MissionOutcome = "crashed"     // Ракета разбилась
+	OutcomeAborted     MissionOutcome = "aborted"     // Полет прерван (сервер, потеря связи, аварийное прекращение)
+	OutcomeInterrupted MissionOutcome = "interrupted" // Остановлен пользователем
+	OutcomeScrubbed    MissionOutcome = "scrubbed"    // Пуск отменен во время отсчета
+)
+
+func (o MissionOutcome) ExitCode() int {
+	switch o {
+	case OutcomeOrbit, OutcomeLanded:
+		return 0
+	case OutcomeCrashed:
+		return 2
+	case OutcomeAborted:
+		return 3
+	case OutcomeScrubbed:
+		return 4
+	default:
+		return 130
+	}
+}
+
+type missionStats struct {
+	initialFuel float64
+	maxAltitude float64
+	maxSpeed    float64
+}
+
+func (s *missionStats) update(state protocol.RocketState) {
+	if state.Altitude > s.maxAltitude {
+		s.maxAltitude = state.Altitude
+	}
+	if state.Speed > s.maxSpeed {
+		s.maxSpeed = state.Speed
+	}
+}
+
+type MissionSummary struct {
+	Outcome     MissionOutcome
+	MaxAltitude float64 // м
+	MaxSpeed    float64 // м/с
+	FuelUsed    float64 // кг
+	FlightTime  float64 // с, по времени симуляции
+	MaxQ        float64 // Па
+	MaxQTime    float64 // с
+	Apoapsis    float64 // м, по последнему состоянию; -1 - не определен
+	Periapsis   float64 // м
+
+	ServerErrors uint64 // Сообщений клиента, отброшенных сервером
+}
+
+// terminalOutcome возвращает исход, если по состоянию ракеты полет закончен.
+// reached - исход, о котором сообщила программа полета (например, орбита).
+func terminalOutcome(state protocol.RocketState, reached MissionOutcome) MissionOutcome {
+	switch {
+	case state.Crashed:
+		return OutcomeCrashed
+	case state.Landed:
+		return OutcomeLanded
+	default:
+		return reached
+	}
+}
+
+// summarizeMission подводит итог полета. requested - причина остановки
+// снаружи (сигнал, команда сервера), если полет не закончился сам.
+func summarizeMission(final protocol.RocketState, stats missionStats, maxQ maxQGovernor, reached, requested MissionOutcome) MissionSummary {
+	outcome := terminalOutcome(final, reached)
+	if outcome == "" {
+		outcome = requested
+	}
+	if outcome == "" {
+		outcome = OutcomeInterrupted
+	}
+
+	return MissionSummary{
+		Outcome:     outcome,
+		MaxAltitude: stats.maxAltitude,
+		MaxSpeed:    stats.maxSpeed,
+		FuelUsed:    stats.initialFuel - final.FuelRemaining,
+		FlightTime:  final.Time,
+		MaxQ:        maxQ.maxQ,
+		MaxQTime:    maxQ.maxQTime,
+		Apoapsis:    final.OrbitApoapsis,
+		Periapsis:   final.OrbitPeriapsis,
+	}
+}
+
+// Log пишет итог миссии в журнал
+func (s MissionSummary) Log(logger *logging.Logger) {
+	logger.Info("summary_outcome", logging.F("outcome", s.Outcome))
+	logger.Info("summary_flight",
+		logging.F("max_altitude_km", s.MaxAltitude/1000.0),
+		logging.F("max_speed", s.MaxSpeed),
+		logging.F("fuel_used", s.FuelUsed),
+		logging.F("flight_time", s.FlightTime))
+	logger.Info("max_q", logging.F("max_q_kpa", s.MaxQ/1000.0), logging.F("time", s.MaxQTime))
+	if s.Outcome == OutcomeOrbit {
+		logger.Info("summary_orbit", logging.F("apoapsis_km", s.Apoapsis/1000.0), logging.F("periapsis_km", s.Periapsis/1000.0))
+	}
+	if s.ServerErrors > 0 {
+		logger.Warn("summary_server_errors", logging.F("errors", s.ServerErrors))
+	}
+}
@@ -0,0 +1,74 @@
+package rocketclient
+
+import (
+	"testing"
+
+	"cosmodrom/protocol"
+)
+
+func TestSummarizeMission(t *testing.T) {
+	tests := []struct {
+		name      string
+		final     protocol.RocketState
+		reached   MissionOutcome // Исход программы полета
+		requested MissionOutcome // Причина остановки снаружи
+		want      MissionOutcome
+		wantCode  int
+	}{
+		{name: "орбита", reached: OutcomeOrbit, want: OutcomeOrbit, wantCode: 0},
+		{name: "посадка", final: protocol.RocketState{Landed: true}, want: OutcomeLanded, wantCode: 0},
+		{name: "разбилась", final: protocol.RocketState{Crashed: true}, want: OutcomeCrashed, wantCode: 2},
+		// Состояние ракеты важнее исхода программы и причины остановки
+		{name: "разбилась после орбиты", final: protocol.RocketState{Crashed: true}, reached: OutcomeOrbit, requested: OutcomeAborted, want: OutcomeCrashed, wantCode: 2},
+		{name: "посадка при прерывании", final: protocol.RocketState{Landed: true}, requested: OutcomeInterrupted, want: OutcomeLanded, wantCode: 0},
+		{name: "прерван сервером", requested: OutcomeAborted, want: OutcomeAborted, wantCode: 3},
+		{name: "аварийное прекращение программой", reached: OutcomeAborted, requested: OutcomeInterrupted, want: OutcomeAborted, wantCode: 3},
+		{name: "пуск отменен", requested: OutcomeScrubbed, want: OutcomeScrubbed, wantCode: 4},
+		{name: "сигнал", requested: OutcomeInterrupted, want: OutcomeInterrupted, wantCode: 130},
+		{name: "без причины", want: OutcomeInterrupted, wantCode: 130},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			final := tt.final
+			final.Time, final.FuelRemaining = 120, 400
+			final.OrbitApoapsis, final.OrbitPeriapsis = 210000, 190000
+			stats := missionStats{initialFuel: 1000, maxAltitude: 150000, maxSpeed: 7000}
+			maxQ := maxQGovernor{maxQ: 30000, maxQTime: 60}
+
+			summary := summarizeMission(final, stats, maxQ, tt.reached, tt.requested)
+			if summary.Outcome != tt.want {
+				t.Errorf("исход %q, ожидался %q", summary.Outcome, tt.want)
+			}
+			if code := summary.Outcome.ExitCode(); code != tt.wantCode {
+				t.Errorf("код выхода %d, ожидался %d", code, tt.wantCode)
+			}
+			if summary.FuelUsed != 600 || summary.FlightTime != 120 || summary.MaxAltitude != 150000 ||
+				summary.MaxSpeed != 7000 || summary.MaxQ != 30000 || summary.MaxQTime != 60 ||
+				summary.Apoapsis != 210000 || summary.Periapsis != 190000 {
+				t.Errorf("итог %+v", summary)
+			}
+		})
+	}
+}
+
+func TestTerminalOutcome(t *testing.T) {
+	tests := []struct {
+		name    string
+		state   protocol.RocketState
+		reached MissionOutcome
+		want    MissionOutcome
+	}{
+		{name: "полет продолжается", want: ""},
+		{name: "программа вышла на орбиту", reached: OutcomeOrbit, want: OutcomeOrbit},
+		{name: "посадка", state: protocol.RocketState{Landed: true}, reached: OutcomeOrbit, want: OutcomeLanded},
+		{name: "разбилась", state: protocol.RocketState{Crashed: true, Landed: true}, want: OutcomeCrashed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := terminalOutcome(tt.state, tt.reached); got != tt.want {
+				t.Errorf("исход %q, ожидался %q", got, tt.want)
+			}
+		})
+	}
+}
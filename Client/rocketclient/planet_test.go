@@ -0,0 +1,27 @@
+package rocketclient
+
+import (
+	"math"
+	"testing"
+
+	"cosmodrom/client/physics"
+	"cosmodrom/protocol"
+)
+
+// Та же ракета выходит на ту же орбиту 200 км над Луной за долю
+// характеристической скорости, нужной на Земле: гравитация в шесть раз
+// слабее, а атмосферы нет
+func TestMoonDeltaV(t *testing.T) {
+	earth := deltaVToOrbit(t, DefaultConfig())
+
+	cfg := DefaultConfig()
+	cfg.Planet = physics.MoonDefault()
+	moon := deltaVToOrbit(t, cfg)
+
+	// Меньше круговой скорости на высоте орбиты быть не может
+	r := cfg.Planet.Radius + cfg.TargetOrbit
+	circular := math.Sqrt(protocol.GConstant * cfg.Planet.Mass / r)
+	if moon < circular || moon > earth/3 {
+		t.Errorf("выход на орбиту Луны стоил %.0f м/с (круговая скорость %.0f м/с), Земли - %.0f м/с", moon, circular, earth)
+	}
+}
@@ -0,0 +1,165 @@
+package rocketclient
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"cosmodrom/client/physics"
+	"cosmodrom/protocol"
+)
+
+// Preset - встроенная конфигурация ракеты (флаг -preset)
+type Preset struct {
+	Name        string
+	Description string
+	Orbital     bool // Запаса delta-v хватает на низкую орбиту
+	build       func() protocol.RocketConfig
+}
+
+// Config возвращает новую копию конфигурации: ее можно менять, не затрагивая пресет
+func (p Preset) Config() protocol.RocketConfig {
+	config := p.build()
+	config.ApplyStages()
+	return config
+}
+
+var presets = []Preset{
+	{
+		Name:        "sounding",
+		Description: "Малая твердотопливная метеоракета, до орбиты не долетает",
+		build: func() protocol.RocketConfig {
+			return protocol.RocketConfig{
+				Name:            "Sounding",
+				MassEmpty:       300.0,
+				MassFuel:        1200.0,
+				MassFuelMax:     1200.0,
+				FuelType:        protocol.FuelTypeSolid,
+				Engines:         []protocol.Engine{presetEngine(60000.0, 230.0)},
+				DragCoefficient: 0.4,
+				CrossSection:    0.1,
+				// Купол на полную заправку (в подскоке топливо остается): спуск
+				// около 3.6 м/с у земли, физика считает посадкой касание < 5 м/с
+				Parachute: &protocol.Parachute{DeployAltitude: 3000.0, DragCoefficient: 1.5, Area: 1200.0},
+			}
+		},
+	},
+	{
+		Name:        "falcon-1ish",
+		Description: "Легкая двухступенчатая ракета на керосине",
+		Orbital:     true,
+		build: func() protocol.RocketConfig {
+			return protocol.RocketConfig{
+				Name:            "Falcon 1-ish",
+				FuelType:        protocol.FuelTypeKerosene,
+				DragCoefficient: 0.3,
+				CrossSection:    2.3,
+				Stages: []protocol.Stage{
+					{Name: "first", MassEmpty: 1800.0, MassFuel: 21000.0, Engines: []protocol.Engine{presetEngine(450000.0, 290.0)}},
+					{Name: "second", MassEmpty: 500.0, MassFuel: 3400.0, Engines: []protocol.Engine{presetEngine(31000.0, 317.0)}},
+				},
+			}
+		},
+	},
+	{
+		Name:        "heavy",
+		Description: "Тяжелая одноступенчатая ракета: большая тяговооруженность и запас топлива",
+		Orbital:     true,
+		build: func() protocol.RocketConfig {
+			engines := make([]protocol.Engine, 15)
+			for i := range engines {
+				engines[i] = presetEngine(2200000.0, 300.0)
+			}
+			return protocol.RocketConfig{
+				Name:            "Heavy",
+				MassEmpty:       60000.0,
+				MassFuel:        1500000.0,
+				MassFuelMax:     1500000.0,
+				FuelType:        protocol.FuelTypeKerosene,
+				Engines:         engines,
+				DragCoefficient: 0.3,
+				CrossSection:    40.0,
+			}
+		},
+	},
+	{
+		Name:        "ssto-h2",
+		Description: "Одноступенчатая ракета на жидком водороде",
+		Orbital:     true,
+		build: func() protocol.RocketConfig {
+			engines := make([]protocol.Engine, 3)
+			for i := range engines {
+				engines[i] = presetEngine(1200000.0, 430.0)
+			}
+			return protocol.RocketConfig{
+				Name:            "SSTO H2",
+				MassEmpty:       25000.0,
+				MassFuel:        225000.0,
+				MassFuelMax:     225000.0,
+				FuelType:        protocol.FuelTypeLiquidH2,
+				Engines:         engines,
+				DragCoefficient: 0.25,
+				CrossSection:    20.0,
+			}
+		},
+	},
+}
+
+// presetEngine - двигатель с расходом топлива по удельному импульсу (с)
+func presetEngine(thrust, isp float64) protocol.Engine {
+	return protocol.Engine{Thrust: thrust, FuelConsumption: thrust / (isp * standardGravity), IsActive: true}
+}
+
+// Presets возвращает встроенные конфигурации в порядке вывода -preset list
+func Presets() []Preset {
+	return append([]Preset(nil), presets...)
+}
+
+func PresetByName(name string) (Preset, error) {
+	names := make([]string, len(presets))
+	for i, preset := range presets {
+		if preset.Name == name {
+			return preset, nil
+		}
+		names[i] = preset.Name
+	}
+	return Preset{}, fmt.Errorf("неизвестный пресет %q (доступны: %s)", name, strings.Join(names, ", "))
+}
+
+// LiftoffTWR - тяговооруженность на старте: тяга активных двигателей первой
+// ступени к весу заправленной ракеты на поверхности планеты
+func LiftoffTWR(config protocol.RocketConfig, planet physics.PlanetConfig) float64 {
+	gravity := protocol.GConstant * planet.Mass / (planet.Radius * planet.Radius)
+	return totalThrust(config.Engines) / ((config.MassEmpty + config.MassFuel) * gravity)
+}
+
+// DeltaV - идеальная характеристическая скорость по формуле Циолковского,
+// сумма по ступеням. Скорость истечения ступени - тяга ее активных
+// двигателей, деленная на их расход.
+func DeltaV(config protocol.RocketConfig) float64 {
+	stages := config.Stages
+	if len(stages) == 0 {
+		stages = []protocol.Stage{{MassEmpty: config.MassEmpty, MassFuel: config.MassFuel, Engines: config.Engines}}
+	}
+
+	deltaV := 0.0
+	for i, stage := range stages {
+		upper := 0.0 // Масса верхних ступеней с топливом
+		for _, above := range stages[i+1:] {
+			upper += above.MassEmpty + above.MassFuel
+		}
+		consumption := 0.0
+		for _, engine := range stage.Engines {
+			if engine.IsActive {
+				consumption += engine.FuelConsumption
+			}
+		}
+		if consumption == 0 {
+			continue
+		}
+		exhaust := totalThrust(stage.Engines) / consumption
+		full := upper + stage.MassEmpty + stage.MassFuel
+		deltaV += exhaust * math.Log(full/(upper+stage.MassEmpty))
+	}
+	return deltaV
+}
@@ -0,0 +1,70 @@
+package rocketclient
+
+import (
+	"math"
+	"testing"
+
+	"cosmodrom/client/physics"
+	"cosmodrom/protocol"
+)
+
+func TestPresetsValid(t *testing.T) {
+	earth := physics.EarthDefault()
+	for _, preset := range Presets() {
+		t.Run(preset.Name, func(t *testing.T) {
+			config := preset.Config()
+			if err := protocol.ValidateRocketConfig(&config); err != nil {
+				t.Fatalf("ValidateRocketConfig: %v", err)
+			}
+			if warnings := protocol.EngineWarnings(&config); len(warnings) > 0 {
+				t.Errorf("двигатели не соответствуют топливу: %q", warnings)
+			}
+
+			twr := LiftoffTWR(config, earth)
+			deltaV := DeltaV(config)
+			if twr <= 1 {
+				t.Errorf("TWR на старте %.2f, ракета не оторвется от стола", twr)
+			}
+			if preset.Orbital && deltaV < protocol.OrbitalVelocity {
+				t.Errorf("delta-v %.0f м/с меньше орбитальной скорости у орбитального пресета", deltaV)
+			}
+			if !preset.Orbital && deltaV >= protocol.OrbitalVelocity {
+				t.Errorf("delta-v %.0f м/с хватает на орбиту, а пресет помечен как суборбитальный", deltaV)
+			}
+		})
+	}
+}
+
+func TestPresetConfigIsCopy(t *testing.T) {
+	preset, err := PresetByName("heavy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := preset.Config()
+	config.Engines[0].Thrust = 1
+
+	if preset.Config().Engines[0].Thrust == 1 {
+		t.Error("изменение конфигурации затронуло пресет")
+	}
+}
+
+func TestPresetByNameUnknown(t *testing.T) {
+	if _, err := PresetByName("saturn-v"); err == nil {
+		t.Error("ожидалась ошибка для неизвестного пресета")
+	}
+}
+
+func TestDeltaVStaged(t *testing.T) {
+	engine := protocol.Engine{Thrust: 3000, FuelConsumption: 1, IsActive: true} // Скорость истечения 3000 м/с
+	config := protocol.RocketConfig{
+		Stages: []protocol.Stage{
+			{MassEmpty: 100, MassFuel: 900, Engines: []protocol.Engine{engine}},
+			{MassEmpty: 10, MassFuel: 90, Engines: []protocol.Engine{engine}},
+		},
+	}
+	// Первая ступень: 1100 -> 200 кг, вторая: 100 -> 10 кг
+	want := 3000*math.Log(1100.0/200.0) + 3000*math.Log(10.0)
+	if got := DeltaV(config); math.Abs(got-want) > 1e-6 {
+		t.Errorf("DeltaV = %.3f, ожидалось %.3f", got, want)
+	}
+}
@@ -0,0 +1,119 @@
+package rocketclient
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/protocol"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	reconnectBaseDelay = 500 * time.Millisecond
+	notRegisteredLimit = 3 // Ошибок not_registered в одном соединении до повторной регистрации
+)
+
+// connectionLost переводит клиент в режим переподключения. Вызывается и из
+// websocketSink, и из receiveMessages - повторный вызов для того же
+// соединения игнорируется.
+func (r *RocketClient) connectionLost(conn *websocket.Conn, err error) {
+	r.connMu.Lock()
+	if r.conn != conn || r.reconnecting {
+		r.connMu.Unlock()
+		return
+	}
+	r.conn = nil
+	r.registered = false
+	r.reconnecting = true
+	r.connMu.Unlock()
+
+	conn.Close()
+	r.logger.Warn("connection_lost", logging.F("error", err))
+	r.emit(EventConnectionLost, err.Error())
+
+	go r.reconnectLoop()
+}
+
+// reconnectLoop переподключается с экспоненциальной задержкой и случайным
+// разбросом. Сервер не хранит сессии, поэтому после переподключения ракета
+// регистрируется заново, а телеметрия продолжается с текущего состояния физики.
+func (r *RocketClient) reconnectLoop() {
+	defer func() {
+		r.connMu.Lock()
+		r.reconnecting = false
+		r.connMu.Unlock()
+	}()
+
+	for attempt := 1; r.reconnectAttempts == 0 || attempt <= r.reconnectAttempts; attempt++ {
+		delay := backoffDelay(attempt, r.reconnectMaxDelay)
+		r.logger.Info("reconnect_wait", logging.F("delay", delay.Round(time.Millisecond)), logging.F("attempt", attempt))
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		conn, err := r.dial()
+		if err != nil {
+			r.logger.Warn("reconnect_failed", logging.F("attempt", attempt), logging.F("error", err))
+			continue
+		}
+
+		if err := r.register(conn); err != nil {
+			conn.Close()
+
+			var rejected *RejectedError
+			if errors.As(err, &rejected) && !retryableRejection(rejected.Code) {
+				r.logger.Error("reconnect_rejected", logging.F("error", err))
+				r.finish(OutcomeAborted)
+				return
+			}
+			r.logger.Warn("reconnect_failed", logging.F("attempt", attempt), logging.F("error", err))
+			continue
+		}
+
+		r.connMu.Lock()
+		if r.ctx.Err() != nil {
+			r.connMu.Unlock()
+			conn.Close()
+			return
+		}
+		r.conn = conn
+		r.registered = true
+		r.connMu.Unlock()
+
+		r.logger.Info("reconnected")
+		r.emit(EventReconnected, "")
+		go r.receiveMessages(conn)
+		go r.heartbeatLoop(conn)
+		return
+	}
+
+	r.logger.Error("reconnect_gave_up", logging.F("attempts", r.reconnectAttempts))
+	r.finish(OutcomeAborted)
+}
+
+// Старое соединение может еще числиться на сервере, поэтому duplicate_id
+// тоже повторяется
+func retryableRejection(code protocol.RejectCode) bool {
+	switch code {
+	case protocol.RejectCodeDuplicateID, protocol.RejectCodeDraining, protocol.RejectCodeServerFull:
+		return true
+	}
+	return false
+}
+
+func backoffDelay(attempt int, maxDelay time.Duration) time.Duration {
+	delay := reconnectBaseDelay
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	// Половина задержки фиксирована, вторая половина случайна
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
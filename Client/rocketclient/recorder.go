@@ -0,0 +1,152 @@
+package rocketclient
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/protocol"
+)
+
+var recorderHeader = []string{
+	"time", "altitude", "speed",
+	"pos_x", "pos_y", "pos_z",
+	"vel_x", "vel_y", "vel_z",
+	"acceleration", "mass", "fuel",
+	"pitch", "throttle", "dynamic_pressure", "phase", "rtt_ms",
+	"target_distance", "closing_speed",
+	"g_force", "vertical_speed",
+	"latitude", "longitude", "ground_speed",
+}
+
+// flightRecorder пишет состояние ракеты в CSV с заданной частотой по времени
+// симуляции. Ошибки записи не прерывают полет: после первой ошибки запись
+// прекращается, а ошибка пишется в лог.
+type flightRecorder struct {
+	path     string
+	file     *os.File
+	buf      *bufio.Writer
+	csv      *csv.Writer
+	interval float64 // с
+	next     float64 // Время симуляции следующей записи
+	row      []string
+	failed   bool
+	logger   *logging.Logger
+}
+
+func newFlightRecorder(path string, hz float64, logger *logging.Logger) (*flightRecorder, error) {
+	if hz <= 0 {
+		return nil, fmt.Errorf("частота записи -record-hz должна быть положительной, получено %g", hz)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать файл записи: %w", err)
+	}
+
+	buf := bufio.NewWriterSize(file, 64*1024)
+	rec := &flightRecorder{
+		path:     path,
+		file:     file,
+		buf:      buf,
+		csv:      csv.NewWriter(buf),
+		interval: 1.0 / hz,
+		row:      make([]string, len(recorderHeader)),
+		logger:   logger,
+	}
+	rec.write(recorderHeader)
+	return rec, nil
+}
+
+// record записывает строку, если подошло время очередного отсчета.
+// Последнее состояние (посадка, крушение) записывается всегда. rtt - время
+// ответа сервера, пусто в столбце, пока оно неизвестно. Расстояние до цели и
+// скорость сближения пишутся только в режиме chase, пока цель видна.
+func (f *flightRecorder) record(state protocol.RocketState, command protocol.ControlCommand, q float64, phase string, rtt time.Duration, chase *chaseProgram) {
+	if f == nil || f.failed {
+		return
+	}
+	final := state.Landed || state.Crashed
+	if state.Time < f.next && !final {
+		return
+	}
+	f.next = state.Time + f.interval
+	if f.next <= state.Time {
+		f.next = math.Nextafter(state.Time, math.Inf(1))
+	}
+
+	a := state.Acceleration
+	values := []float64{
+		state.Time, state.Altitude, state.Speed,
+		state.Position.X, state.Position.Y, state.Position.Z,
+		state.Velocity.X, state.Velocity.Y, state.Velocity.Z,
+		math.Sqrt(a.X*a.X + a.Y*a.Y + a.Z*a.Z), state.MassCurrent, state.FuelRemaining,
+		command.Pitch, meanThrottle(command.EngineThrottle), q,
+	}
+	for i, value := range values {
+		f.row[i] = strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	f.row[len(values)] = phase
+	f.row[len(values)+1] = ""
+	if rtt > 0 {
+		f.row[len(values)+1] = strconv.FormatFloat(rtt.Seconds()*1000, 'f', 1, 64)
+	}
+	f.row[len(values)+2], f.row[len(values)+3] = "", ""
+	if distance, closing, ok := chase.relative(); ok {
+		f.row[len(values)+2] = strconv.FormatFloat(distance, 'f', 1, 64)
+		f.row[len(values)+3] = strconv.FormatFloat(closing, 'f', 2, 64)
+	}
+	f.row[len(values)+4] = strconv.FormatFloat(state.GForce, 'f', -1, 64)
+	f.row[len(values)+5] = strconv.FormatFloat(state.VerticalSpeed, 'f', -1, 64)
+	f.row[len(values)+6] = strconv.FormatFloat(state.Latitude, 'f', -1, 64)
+	f.row[len(values)+7] = strconv.FormatFloat(state.Longitude, 'f', -1, 64)
+	f.row[len(values)+8] = strconv.FormatFloat(state.GroundSpeed, 'f', -1, 64)
+	f.write(f.row)
+}
+
+func (f *flightRecorder) write(row []string) {
+	if err := f.csv.Write(row); err != nil {
+		f.fail(err)
+	}
+}
+
+func (f *flightRecorder) fail(err error) {
+	f.failed = true
+	f.logger.Error("record_failed", logging.F("path", f.path), logging.F("error", err))
+}
+
+// close сбрасывает буфер на диск; безопасен для nil и повторного вызова
+func (f *flightRecorder) close() {
+	if f == nil || f.file == nil {
+		return
+	}
+
+	f.csv.Flush()
+	if err := f.csv.Error(); err != nil && !f.failed {
+		f.fail(err)
+	}
+	if err := f.file.Close(); err != nil && !f.failed {
+		f.fail(err)
+	}
+	f.file = nil
+
+	if !f.failed {
+		f.logger.Info("record_saved", logging.F("path", f.path))
+	}
+}
+
+func meanThrottle(throttle []float64) float64 {
+	if len(throttle) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, value := range throttle {
+		sum += value
+	}
+	return sum / float64(len(throttle))
+}
@@ -0,0 +1,97 @@
+package rocketclient
+
+import (
+	"encoding/csv"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/protocol"
+)
+
+// Запись 8 Гц полета с шагом 1/32 с (оба точны в двоичном виде): строки
+// той же ширины, что заголовок, значения в своих столбцах, время растет,
+// последнее состояние записано вне сетки отсчетов
+func TestFlightRecorderCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flight.csv")
+	rec, err := newFlightRecorder(path, 8, logging.New(io.Discard, logging.LevelInfo, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const dt, steps = 1.0 / 32, 97
+	command := protocol.ControlCommand{EngineThrottle: []float64{1, 0}}
+	for i := 0; i <= steps; i++ {
+		simTime := float64(i) * dt
+		state := protocol.RocketState{
+			Time:          simTime,
+			Altitude:      1000 * simTime,
+			Speed:         10 * simTime,
+			FuelRemaining: 500 - simTime,
+			VerticalSpeed: 9 * simTime,
+			Landed:        i == steps,
+		}
+		command.Pitch = 3 * simTime
+		rec.record(state, command, 100*simTime, "ascent", 25*time.Millisecond, nil)
+	}
+	rec.close()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	// csv.Reader сам проверяет, что у всех строк столько же полей, сколько в первой
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) == 0 || !slices.Equal(rows[0], recorderHeader) {
+		t.Fatalf("заголовок %v", rows[:min(len(rows), 1)])
+	}
+	// Отсчеты 0, 0.125, ..., 3 с и посадка на 3.03125 с
+	if len(rows)-1 != 26 {
+		t.Fatalf("записано %d строк, ожидалось 26", len(rows)-1)
+	}
+
+	column := func(row []string, name string) string {
+		return row[slices.Index(recorderHeader, name)]
+	}
+	number := func(row []string, name string) float64 {
+		t.Helper()
+		value, err := strconv.ParseFloat(column(row, name), 64)
+		if err != nil {
+			t.Fatalf("столбец %s: %v", name, err)
+		}
+		return value
+	}
+	near := func(a, b float64) bool { return a-b < 1e-9 && b-a < 1e-9 }
+
+	prev := -1.0
+	for i, row := range rows[1:] {
+		simTime := number(row, "time")
+		if simTime <= prev {
+			t.Fatalf("строка %d: время %g после %g", i+1, simTime, prev)
+		}
+		if i > 0 && i < len(rows)-2 && simTime-prev != 0.125 {
+			t.Errorf("строка %d: интервал %g с, ожидался 0.125 с", i+1, simTime-prev)
+		}
+		prev = simTime
+
+		if !near(number(row, "altitude"), 1000*simTime) || !near(number(row, "speed"), 10*simTime) ||
+			!near(number(row, "fuel"), 500-simTime) || !near(number(row, "pitch"), 3*simTime) ||
+			!near(number(row, "dynamic_pressure"), 100*simTime) || !near(number(row, "vertical_speed"), 9*simTime) ||
+			number(row, "throttle") != 0.5 || column(row, "phase") != "ascent" || column(row, "rtt_ms") != "25.0" ||
+			column(row, "target_distance") != "" || column(row, "closing_speed") != "" {
+			t.Fatalf("строка %d не совпадает с состоянием на %g с: %v", i+1, simTime, row)
+		}
+	}
+	if prev != steps*dt {
+		t.Errorf("последняя запись на %g с, ожидалась посадка на %g с", prev, steps*dt)
+	}
+}
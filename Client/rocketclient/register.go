@@ -0,0 +1,170 @@
+package rocketclient
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"time"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/protocol"
+
+	"github.com/gorilla/websocket"
+)
+
+// Ошибки регистрации различаются по типу: сервер отказал (RejectedError),
+// не ответил вовремя (RegisterTimeoutError) или соединение оборвалось
+// (TransportError). Проверять через errors.As.
+
+type RejectedError struct {
+	Code   protocol.RejectCode
+	Reason string
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("Регистрация отклонена [%s]: %s", e.Code, e.Reason)
+}
+
+type RegisterTimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *RegisterTimeoutError) Error() string {
+	return fmt.Sprintf("Сервер не ответил на регистрацию за %v", e.Timeout)
+}
+
+type TransportError struct {
+	Op  string
+	Err error
+}
+
+func (e *TransportError) Error() string {
+	return fmt.Sprintf("Ошибка %s: %v", e.Op, e.Err)
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// register отправляет регистрацию и ждет ответа не дольше registerTimeout.
+// Сообщения других типов (например, трансляции) до ответа пропускаются.
+func (r *RocketClient) register(conn *websocket.Conn) error {
+	msg := protocol.RegisterMessage{
+		RocketID: r.ID,
+		Config:   r.config,
+		Mission:  r.launch.mission(),
+	}
+	if err := r.writeTo(conn, protocol.MsgTypeRegister, msg); err != nil {
+		return &TransportError{Op: "отправки регистрации", Err: err}
+	}
+
+	conn.SetReadDeadline(time.Now().Add(r.registerTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+
+	for {
+		response, err := readMessage(conn, r.codec)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return &RegisterTimeoutError{Timeout: r.registerTimeout}
+			}
+			return &TransportError{Op: "чтения ответа", Err: err}
+		}
+
+		switch response.Type {
+		case protocol.MsgTypeAccepted:
+			// Регистрация уже состоялась: непонятный текст ответа ее не отменяет
+			acceptedMsg, err := protocol.DecodeData[protocol.AcceptedMessage](response)
+			if err != nil {
+				r.logger.Warn("accepted_decode_failed", logging.F("error", err))
+			}
+			r.logger.Info("registered", logging.F("message", acceptedMsg.Message), logging.F("conn_id", acceptedMsg.ConnectionID))
+			return nil
+
+		case protocol.MsgTypeRejected:
+			return rejection(response)
+		}
+	}
+}
+
+// rejection переводит ответ rejected в RejectedError
+func rejection(response protocol.Message) error {
+	rejectedMsg, err := protocol.DecodeData[protocol.RejectedMessage](response)
+	if err != nil {
+		return &TransportError{Op: "чтения отказа", Err: err}
+	}
+	return &RejectedError{Code: rejectedMsg.Code, Reason: rejectedMsg.Reason}
+}
+
+// Register регистрирует ракету на сервере после Connect. С Config.AutoID при
+// отказе duplicate_id регистрация повторяется один раз с ID, к которому
+// добавлен случайный суффикс; новый ID виден в поле ID.
+func (r *RocketClient) Register() error {
+	err := r.registerOnce()
+
+	var rejected *RejectedError
+	if !r.autoID || !errors.As(err, &rejected) || rejected.Code != protocol.RejectCodeDuplicateID {
+		return err
+	}
+
+	newID := fmt.Sprintf("%s-%d", r.ID, rand.Intn(10000))
+	r.logger.Warn("id_taken_retry", logging.F("rocket_id", r.ID), logging.F("new_id", newID))
+	r.ID = newID
+	r.logger.SetRocket(newID)
+	return r.registerOnce()
+}
+
+// fetchConfig запрашивает конфигурацию ракеты vehicle из каталога сервера и
+// заменяет ею конфигурацию клиента. Неизвестное имя - RejectedError с кодом
+// unknown_vehicle.
+func (r *RocketClient) fetchConfig(conn *websocket.Conn, vehicle string) error {
+	msg := protocol.ConfigRequestMessage{RocketID: r.ID, Vehicle: vehicle}
+	if err := r.writeTo(conn, protocol.MsgTypeConfigRequest, msg); err != nil {
+		return &TransportError{Op: "отправки запроса конфигурации", Err: err}
+	}
+
+	conn.SetReadDeadline(time.Now().Add(r.registerTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+
+	for {
+		response, err := readMessage(conn, r.codec)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return &RegisterTimeoutError{Timeout: r.registerTimeout}
+			}
+			return &TransportError{Op: "чтения ответа", Err: err}
+		}
+
+		switch response.Type {
+		case protocol.MsgTypeConfigResponse:
+			configMsg, err := protocol.DecodeData[protocol.ConfigResponseMessage](response)
+			if err != nil {
+				return &TransportError{Op: "чтения конфигурации", Err: err}
+			}
+			if err := protocol.ValidateRocketConfig(&configMsg.Config); err != nil {
+				return fmt.Errorf("сервер прислал некорректную конфигурацию: %w", err)
+			}
+			r.setRocketConfig(configMsg.Config)
+			r.logger.Info("vehicle_received",
+				logging.F("vehicle", vehicle),
+				logging.F("name", configMsg.Config.Name),
+				logging.F("engines", len(configMsg.Config.Engines)),
+				logging.F("mass_t", (configMsg.Config.MassEmpty+configMsg.Config.MassFuel)/1000.0))
+			return nil
+
+		case protocol.MsgTypeRejected:
+			return rejection(response)
+		}
+	}
+}
+
+// setRocketConfig заменяет конфигурацию до старта: от числа двигателей
+// зависят отказы, поэтому они создаются заново
+func (r *RocketClient) setRocketConfig(config protocol.RocketConfig) {
+	r.config = config
+	r.launch.Rocket = config
+	failAt, _ := r.launch.scheduledFailure()
+	r.failures = newEngineFailures(r.launch.FailureRate, failAt, r.launch.FailureSeed, config.Engines, r.logger)
+}
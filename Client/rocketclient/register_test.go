@@ -0,0 +1,272 @@
+package rocketclient
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/protocol"
+
+	"github.com/gorilla/websocket"
+)
+
+// catalogServer отвечает на config_request ракетой из catalog, как сервер с -config
+func catalogServer(t *testing.T, catalog map[string]protocol.RocketConfig) *httptest.Server {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var msg struct {
+			Type protocol.MessageType          `json:"type"`
+			Data protocol.ConfigRequestMessage `json:"data"`
+		}
+		if err := conn.ReadJSON(&msg); err != nil || msg.Type != protocol.MsgTypeConfigRequest {
+			return
+		}
+		response := protocol.Message{Type: protocol.MsgTypeRejected, Timestamp: time.Now(), Data: protocol.RejectedMessage{
+			RocketID: msg.Data.RocketID,
+			Code:     protocol.RejectCodeUnknownVehicle,
+			Reason:   "нет в каталоге",
+		}}
+		if config, ok := catalog[msg.Data.Vehicle]; ok {
+			response = protocol.Message{Type: protocol.MsgTypeConfigResponse, Timestamp: time.Now(), Data: protocol.ConfigResponseMessage{
+				RocketID: msg.Data.RocketID,
+				Vehicle:  msg.Data.Vehicle,
+				Config:   config,
+			}}
+		}
+		conn.WriteJSON(response)
+		conn.ReadMessage()
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestConnectFetchesVehicle(t *testing.T) {
+	preset, err := PresetByName("heavy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := catalogServer(t, map[string]protocol.RocketConfig{"heavy": preset.Config()})
+
+	tests := []struct {
+		name       string
+		vehicle    string
+		wantCode   protocol.RejectCode
+		wantConfig protocol.RocketConfig
+	}{
+		{name: "есть в каталоге", vehicle: "heavy", wantConfig: preset.Config()},
+		{name: "нет в каталоге", vehicle: "unknown", wantCode: protocol.RejectCodeUnknownVehicle},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.ID = "test"
+			cfg.ServerURL = "ws" + strings.TrimPrefix(server.URL, "http")
+			cfg.Vehicle = tt.vehicle
+			cfg.Logger = logging.New(io.Discard, logging.LevelInfo, false)
+			client, err := New(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+
+			err = client.Connect()
+			if tt.wantCode != "" {
+				var rejected *RejectedError
+				if !errors.As(err, &rejected) || rejected.Code != tt.wantCode {
+					t.Fatalf("ошибка %v, ожидался отказ %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if client.config.Name != tt.wantConfig.Name || len(client.config.Stages) != len(tt.wantConfig.Stages) {
+				t.Errorf("конфигурация %s (%d ступеней), ожидалась %s (%d)",
+					client.config.Name, len(client.config.Stages), tt.wantConfig.Name, len(tt.wantConfig.Stages))
+			}
+			if client.launch.Rocket.Name != tt.wantConfig.Name {
+				t.Errorf("параметры старта не обновлены: %s", client.launch.Rocket.Name)
+			}
+		})
+	}
+}
+
+func TestVehicleRequiresServer(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ID = "test"
+	cfg.Vehicle = "heavy"
+	cfg.Offline = true
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "-vehicle") {
+		t.Errorf("ошибка %v, ожидалась про -vehicle", err)
+	}
+}
+
+// Номера сообщений растут с 1 в каждом соединении: после переподключения
+// сервер видит новое соединение и новую последовательность
+func TestMessageSeqPerConnection(t *testing.T) {
+	seqs := make(chan uint64, 16)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var msg protocol.Message
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			seqs <- msg.Seq
+		}
+	}))
+	defer server.Close()
+
+	client := newRocketClient("test", DefaultConfig().Rocket, "ws"+strings.TrimPrefix(server.URL, "http"), 10, 0.01)
+	var got []uint64
+	for i := 0; i < 2; i++ {
+		conn, err := client.dial()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := 0; j < 3; j++ {
+			if err := client.writeTo(conn, protocol.MsgTypeTelemetry, protocol.TelemetryMessage{RocketID: "test"}); err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, <-seqs)
+		}
+		conn.Close()
+	}
+
+	want := []uint64{1, 2, 3, 1, 2, 3}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("номера %v, ожидалось %v", got, want)
+		}
+	}
+}
+
+// Метки времени сообщений берутся из часов клиента и дублируются
+// в миллисекундах
+func TestMessageTimestampFromClock(t *testing.T) {
+	raw := make(chan string, 1)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, data, err := conn.ReadMessage()
+		if err == nil {
+			raw <- string(data)
+		}
+	}))
+	defer server.Close()
+
+	client := newRocketClient("test", DefaultConfig().Rocket, "ws"+strings.TrimPrefix(server.URL, "http"), 10, 0.01)
+	client.clock = &fakeClock{now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	conn, err := client.dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err := client.writeTo(conn, protocol.MsgTypeTelemetry, protocol.TelemetryMessage{RocketID: "test"}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := <-raw
+	if !strings.Contains(got, `"timestamp":"2026-03-01T12:00:00Z"`) || !strings.Contains(got, `"timestamp_ms":1772366400000`) {
+		t.Errorf("метки времени не по часам клиента: %s", got)
+	}
+}
+
+// С Config.Codec клиент пишет двоичные кадры и читает ответ тем же кодеком
+func TestRegisterCBOR(t *testing.T) {
+	registered := make(chan protocol.Message, 1)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		frame, data, err := conn.ReadMessage()
+		if err != nil || frame != websocket.BinaryMessage {
+			return
+		}
+		msg, err := protocol.CBOR.Decode(data)
+		if err != nil {
+			return
+		}
+		registered <- msg
+		reply, _ := protocol.CBOR.Encode(protocol.Message{Type: protocol.MsgTypeAccepted, Data: protocol.AcceptedMessage{RocketID: "test"}})
+		conn.WriteMessage(websocket.BinaryMessage, reply)
+		conn.ReadMessage()
+	}))
+	defer server.Close()
+
+	cfg := DefaultConfig()
+	cfg.ID = "test"
+	cfg.ServerURL = "ws" + strings.TrimPrefix(server.URL, "http")
+	cfg.Codec = protocol.CBOR
+	cfg.Logger = logging.New(io.Discard, logging.LevelInfo, false)
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Register(); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := <-registered
+	register, ok := msg.Data.(protocol.RegisterMessage)
+	if msg.Type != protocol.MsgTypeRegister || !ok || register.Config.Name != cfg.Rocket.Name {
+		t.Errorf("сервер получил %s %#v, ожидалась регистрация", msg.Type, msg.Data)
+	}
+}
+
+// Цель миссии берется из параметров автопилота orbit
+func TestConfigMission(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.TargetInclination = 51.6
+	cfg.LaunchSite = "Байконур"
+	mission := cfg.mission()
+	if mission == nil || mission.TargetOrbit != cfg.TargetOrbit || mission.TargetInclination == nil ||
+		*mission.TargetInclination != 51.6 || mission.LaunchSite != "Байконур" {
+		t.Fatalf("миссия %+v", mission)
+	}
+
+	cfg.PostOrbitRaise = 400000
+	if mission := cfg.mission(); mission.TargetOrbit != 400000 {
+		t.Errorf("цель после перехода Хомана %.0f м, ожидалось 400000", mission.TargetOrbit)
+	}
+
+	// У подскока орбитальной цели нет, а без описания нет и миссии
+	cfg = DefaultConfig()
+	cfg.Mode = FlightModeHop
+	if mission := cfg.mission(); mission != nil {
+		t.Errorf("миссия подскока %+v", mission)
+	}
+	cfg.Crewed = true
+	if mission := cfg.mission(); mission == nil || mission.TargetOrbit != 0 || !mission.Crewed {
+		t.Errorf("пилотируемый подскок %+v", mission)
+	}
+}
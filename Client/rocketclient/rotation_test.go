@@ -0,0 +1,66 @@
+package rocketclient
+
+import (
+	"context"
+	"io"
+	"math"
+	"testing"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/client/physics"
+)
+
+// deltaVToOrbit - характеристическая скорость, затраченная на выход на
+// орбиту полетом cfg без сервера
+func deltaVToOrbit(t *testing.T, cfg Config) float64 {
+	t.Helper()
+	cfg.ID = "dv"
+	cfg.Sink = &stateLog{}
+	cfg.Unpaced = true
+	cfg.Dt = 0.05
+	cfg.Logger = logging.New(io.Discard, logging.LevelInfo, false)
+
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary, err := client.Launch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Outcome != OutcomeOrbit {
+		t.Fatalf("широта %g°, без вращения %v: исход %s", cfg.Latitude, cfg.NoRotation, summary.Outcome)
+	}
+
+	// Формула Циолковского: у ракеты по умолчанию один двигатель
+	engine := cfg.Rocket.Engines[0]
+	m0 := cfg.Rocket.MassEmpty + cfg.Rocket.MassFuel
+	return engine.Thrust / engine.FuelConsumption * math.Log(m0/(m0-summary.FuelUsed))
+}
+
+// Вращение Земли дает на экваторе около 465 м/с, на 60° - вдвое меньше,
+// поэтому с экватора на орбиту нужно меньше всего топлива
+func TestLaunchLatitudeDeltaV(t *testing.T) {
+	earth := physics.EarthDefault()
+	equatorSpeed := 2 * math.Pi * earth.Radius / earth.RotationPeriod
+	launch := func(latitude float64, noRotation bool) float64 {
+		cfg := DefaultConfig()
+		cfg.Latitude, cfg.NoRotation = latitude, noRotation
+		return deltaVToOrbit(t, cfg)
+	}
+
+	equator := launch(0, false)
+	north := launch(60, false)
+	still := launch(0, true)
+
+	// Без вращения экваториальный старт теряет всю скорость поверхности
+	if gain := still - equator; gain < 0.7*equatorSpeed || gain > 1.3*equatorSpeed {
+		t.Errorf("вращение сэкономило %.0f м/с на экваторе, ожидалось около %.0f м/с", gain, equatorSpeed)
+	}
+	// На 60° скорость поверхности меньше на equatorSpeed*(1-cos 60°)
+	lost := equatorSpeed * (1 - math.Cos(60*math.Pi/180))
+	if diff := north - equator; diff < 0.5*lost || diff > equatorSpeed {
+		t.Errorf("старт с 60° дороже экваториального на %.0f м/с, ожидалось около %.0f м/с (экватор %.0f, 60° %.0f)",
+			diff, lost, equator, north)
+	}
+}
@@ -0,0 +1,244 @@
+package rocketclient
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/client/physics"
+	"cosmodrom/protocol"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Именованные маневры сценария
+const (
+	ScriptActionMECO        = "meco"        // Выключить все двигатели
+	ScriptActionCircularize = "circularize" // Тяга по горизонту до стабильной орбиты
+)
+
+// missionScript - сценарий полета (-script): список действий по времени
+// полета вместо автопилота
+type missionScript struct {
+	Name    string         `yaml:"name"`
+	Actions []scriptAction `yaml:"actions"`
+}
+
+// scriptAction выполняется на первом шаге, где время полета не меньше At.
+// Незаданные поля не меняют команду.
+type scriptAction struct {
+	At       float64   `yaml:"at"`       // с от старта
+	Throttle []float64 `yaml:"throttle"` // Одно значение - для всех двигателей
+	Pitch    *float64  `yaml:"pitch"`    // Градусы от вертикали; без него - программа разворота
+	Yaw      *float64  `yaml:"yaw"`
+	Roll     *float64  `yaml:"roll"`
+	Action   string    `yaml:"action"`   // meco или circularize
+	Attitude string    `yaml:"attitude"` // Режим ориентации, как у -attitude
+}
+
+// loadMissionScript читает и проверяет сценарий до старта
+func loadMissionScript(path string) (*missionScript, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать сценарий: %w", err)
+	}
+
+	var script missionScript
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&script); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("некорректный сценарий %s: %w", path, err)
+	}
+	if err := script.validate(); err != nil {
+		return nil, fmt.Errorf("некорректный сценарий %s: %w", path, err)
+	}
+	return &script, nil
+}
+
+func (s *missionScript) validate() error {
+	if len(s.Actions) == 0 {
+		return fmt.Errorf("нет действий")
+	}
+
+	for i, action := range s.Actions {
+		if err := action.validate(); err != nil {
+			return fmt.Errorf("действие %d (T+%g): %w", i+1, action.At, err)
+		}
+	}
+
+	// Действия с одним временем выполняются в порядке файла
+	sort.SliceStable(s.Actions, func(i, j int) bool {
+		return s.Actions[i].At < s.Actions[j].At
+	})
+	return nil
+}
+
+func (a scriptAction) validate() error {
+	if a.At < 0 {
+		return fmt.Errorf("время не может быть отрицательным")
+	}
+	for _, throttle := range a.Throttle {
+		if throttle < 0 || throttle > 1 {
+			return fmt.Errorf("дроссель %g вне диапазона 0-1", throttle)
+		}
+	}
+
+	if a.Attitude != "" {
+		if _, err := parseAttitude(a.Attitude); err != nil {
+			return fmt.Errorf("attitude: %w", err)
+		}
+	}
+
+	switch a.Action {
+	case "":
+		if a.Throttle == nil && a.Pitch == nil && a.Yaw == nil && a.Roll == nil && a.Attitude == "" {
+			return fmt.Errorf("не задано ни одного поля")
+		}
+	case ScriptActionMECO, ScriptActionCircularize:
+		if a.Throttle != nil {
+			return fmt.Errorf("%s нельзя совмещать с throttle", a.Action)
+		}
+	default:
+		return fmt.Errorf("неизвестное действие %q, доступны %s и %s",
+			a.Action, ScriptActionMECO, ScriptActionCircularize)
+	}
+	return nil
+}
+
+// scriptProgram выполняет сценарий как программу полета. Команда сервера
+// перекрывает сценарий на время -command-hold, как и автопилот: действия,
+// время которых пришлось на это окно, выполняются вовремя, и их результат
+// виден после окончания команды сервера.
+type scriptProgram struct {
+	script   *missionScript
+	next     int // Индекс первого невыполненного действия
+	target   float64
+	planet   physics.PlanetConfig
+	turn     physics.GravityTurnConfig // Тангаж, пока сценарий его не задал
+	attitude *attitudeControl
+	logger   *logging.Logger
+
+	throttle         []float64 // nil - двигатели выключены
+	pitch, yaw, roll *float64
+
+	phase string // script, circularize, orbit или fuel_depleted
+}
+
+func newScriptProgram(script *missionScript, target float64, planet physics.PlanetConfig, turn physics.GravityTurnConfig, attitude *attitudeControl, logger *logging.Logger) *scriptProgram {
+	return &scriptProgram{script: script, target: target, planet: planet, turn: turn, attitude: attitude, logger: logger, phase: "script"}
+}
+
+func (p *scriptProgram) Step(state protocol.RocketState, dt float64) protocol.ControlCommand {
+	for p.next < len(p.script.Actions) && p.script.Actions[p.next].At <= state.Time {
+		p.run(p.script.Actions[p.next], state)
+		p.next++
+	}
+
+	if p.phase == "circularize" {
+		if state.FuelRemaining <= 0 {
+			p.phase = "fuel_depleted"
+			p.logger.Warn("script_fuel_out", logging.F("periapsis_km", state.OrbitPeriapsis/1000.0))
+		} else if state.OrbitIsStable && state.OrbitPeriapsis > minOrbitPeriapsis(p.planet, p.target) {
+			p.phase = "orbit"
+			p.throttle = nil
+			p.logger.Info("script_orbit", logging.F("apoapsis_km", state.OrbitApoapsis/1000.0), logging.F("periapsis_km", state.OrbitPeriapsis/1000.0))
+		}
+	}
+
+	// Дроссели сценария передаются как есть: у команды автопилота то же
+	// соглашение, а nil выключает все двигатели
+	command := protocol.ControlCommand{EngineThrottle: p.throttle, Pitch: p.turn.Pitch(state.Altitude)}
+	if p.pitch != nil {
+		command.Pitch = *p.pitch
+	}
+	if p.yaw != nil {
+		command.Yaw = *p.yaw
+	}
+	if p.roll != nil {
+		command.Roll = *p.roll
+	}
+	return command
+}
+
+func (p *scriptProgram) run(action scriptAction, state protocol.RocketState) {
+	if action.Throttle != nil {
+		p.throttle = action.Throttle
+	}
+	if action.Pitch != nil {
+		p.pitch = action.Pitch
+	}
+	if action.Yaw != nil {
+		p.yaw = action.Yaw
+	}
+	if action.Roll != nil {
+		p.roll = action.Roll
+	}
+	if action.Attitude != "" {
+		// Проверено при загрузке сценария
+		hold, _ := parseAttitude(action.Attitude)
+		p.attitude.set(hold, "сценарий")
+	}
+
+	switch action.Action {
+	case ScriptActionMECO:
+		p.throttle = nil
+		if p.phase == "circularize" {
+			p.phase = "script"
+		}
+	case ScriptActionCircularize:
+		pitch := horizontalPitch
+		p.pitch = &pitch
+		p.throttle = []float64{1}
+		p.phase = "circularize"
+	}
+
+	p.logger.Info("script_action",
+		logging.F("time", state.Time),
+		logging.F("planned", action.At),
+		logging.F("action", action),
+		logging.F("altitude_km", state.Altitude/1000.0))
+}
+
+func (p *scriptProgram) Outcome() MissionOutcome {
+	if p.phase == "orbit" {
+		return OutcomeOrbit
+	}
+	return ""
+}
+
+func (p *scriptProgram) Phase() string {
+	return p.phase
+}
+
+func (a scriptAction) String() string {
+	var buf bytes.Buffer
+	if a.Action != "" {
+		buf.WriteString(a.Action)
+	}
+	field := func(format string, args ...interface{}) {
+		if buf.Len() > 0 {
+			buf.WriteString(", ")
+		}
+		fmt.Fprintf(&buf, format, args...)
+	}
+	if a.Throttle != nil {
+		field("дроссели %v", a.Throttle)
+	}
+	if a.Pitch != nil {
+		field("тангаж %g", *a.Pitch)
+	}
+	if a.Yaw != nil {
+		field("рыскание %g", *a.Yaw)
+	}
+	if a.Roll != nil {
+		field("крен %g", *a.Roll)
+	}
+	if a.Attitude != "" {
+		field("ориентация %s", a.Attitude)
+	}
+	return buf.String()
+}
@@ -0,0 +1,138 @@
+package rocketclient
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/client/physics"
+	"cosmodrom/protocol"
+)
+
+// writeScript сохраняет сценарий во временный файл
+func writeScript(t *testing.T, text string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "mission.yaml")
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// Сценарий выполняется по времени полета: действия из файла в любом
+// порядке, с одним временем - в порядке файла; незаданные поля команды
+// сохраняются, circularize ведет до стабильной орбиты
+func TestScriptProgramFlight(t *testing.T) {
+	script, err := loadMissionScript(writeScript(t, `
+name: Тестовое выведение
+actions:
+  - at: 20
+    action: meco
+  - at: 0
+    throttle: [1.0]
+  - at: 10
+    pitch: 45
+    yaw: 5
+  - at: 10
+    attitude: prograde
+  - at: 10
+    pitch: 60
+  - at: 30
+    action: circularize
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := []float64{0, 10, 10, 10, 20, 30}; !slices.EqualFunc(script.Actions, got, func(a scriptAction, at float64) bool { return a.At == at }) {
+		t.Fatalf("порядок действий %v", script.Actions)
+	}
+
+	const target = 200000.0
+	planet := physics.EarthDefault()
+	turn := physics.GravityTurnForOrbit(planet, target)
+	p := newFakePhysics(t, []physics.FakeFrame{
+		ascentFrame(0, 100, 10, -1, 0, 8000),
+		ascentFrame(15, 20000, 800, 90000, -3e6, 6000),
+		ascentFrame(30, 150000, 300, 205000, -1e6, 2000),
+		ascentFrame(40, 204000, 0, 205000, 180000, 1000),
+	})
+	logger := logging.New(io.Discard, logging.LevelInfo, false)
+	attitude := &attitudeControl{logger: logger}
+	program := newScriptProgram(script, target, planet, turn, attitude, logger)
+
+	changes, states := flyScripted(t, program, p, 50, 1)
+	if want := []phaseChange{{30, "circularize"}, {40, "orbit"}}; !slices.Equal(changes, want) {
+		t.Fatalf("смены этапов %v, ожидались %v", changes, want)
+	}
+	if program.Outcome() != OutcomeOrbit {
+		t.Errorf("исход %q, ожидался орбита", program.Outcome())
+	}
+	if attitude.hold.Mode != protocol.AttitudePrograde {
+		t.Errorf("ориентация %q, ожидалась prograde", attitude.hold.Mode)
+	}
+
+	for i, command := range p.Commands() {
+		state := states[i]
+		var throttle []float64
+		pitch, yaw := turn.Pitch(state.Altitude), 0.0
+		switch {
+		case state.Time < 10:
+			throttle = []float64{1}
+		case state.Time < 20:
+			// Последнее из действий T+10 перекрывает тангаж первого
+			throttle, pitch, yaw = []float64{1}, 60, 5
+		case state.Time < 30:
+			pitch, yaw = 60, 5
+		case state.Time < 40:
+			throttle, pitch, yaw = []float64{1}, horizontalPitch, 5
+		default:
+			pitch, yaw = horizontalPitch, 5
+		}
+		if !slices.Equal(command.EngineThrottle, throttle) || command.Pitch != pitch || command.Yaw != yaw || command.Roll != 0 {
+			t.Fatalf("T+%g с: дроссели %v, тангаж %g, рыскание %g, крен %g; ожидались %v, %g, %g, 0",
+				state.Time, command.EngineThrottle, command.Pitch, command.Yaw, command.Roll, throttle, pitch, yaw)
+		}
+	}
+}
+
+func TestLoadMissionScriptErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   string // Подстрока ошибки
+	}{
+		{name: "пустой файл", script: "", want: "нет действий"},
+		{name: "без действий", script: "name: пусто\nactions: []\n", want: "нет действий"},
+		{name: "не YAML", script: "actions: [\n", want: "некорректный сценарий"},
+		{name: "неизвестное поле", script: "actions:\n  - at: 0\n    thrust: 1\n", want: "thrust"},
+		{name: "время не число", script: "actions:\n  - at: скоро\n    throttle: [1]\n", want: "некорректный сценарий"},
+		{name: "отрицательное время", script: "actions:\n  - at: -1\n    throttle: [1]\n", want: "действие 1 (T+-1): время не может быть отрицательным"},
+		{name: "дроссель больше 1", script: "actions:\n  - at: 0\n    throttle: [0.5, 1.5]\n", want: "дроссель 1.5 вне диапазона 0-1"},
+		{name: "отрицательный дроссель", script: "actions:\n  - at: 0\n    throttle: [-0.1]\n", want: "вне диапазона"},
+		{name: "пустое действие", script: "actions:\n  - at: 0\n    throttle: [1]\n  - at: 5\n", want: "действие 2 (T+5): не задано ни одного поля"},
+		{name: "неизвестный маневр", script: "actions:\n  - at: 0\n    action: land\n", want: `неизвестное действие "land"`},
+		{name: "маневр с дросселем", script: "actions:\n  - at: 0\n    action: meco\n    throttle: [1]\n", want: "meco нельзя совмещать с throttle"},
+		{name: "неизвестная ориентация", script: "actions:\n  - at: 0\n    attitude: sideways\n", want: "attitude:"},
+		{name: "угол не для surface_pitch", script: "actions:\n  - at: 0\n    attitude: prograde:10\n", want: "угол задается только для surface_pitch"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadMissionScript(writeScript(t, tt.script))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ошибка %v, ожидалась %q", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := loadMissionScript(filepath.Join(t.TempDir(), "нет.yaml")); err == nil || !strings.Contains(err.Error(), "не удалось прочитать") {
+		t.Errorf("отсутствующий файл: %v", err)
+	}
+	// Пример из репозитория проходит проверку
+	if _, err := loadMissionScript("../examples/mission.yaml"); err != nil {
+		t.Errorf("examples/mission.yaml: %v", err)
+	}
+}
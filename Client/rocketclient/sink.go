@@ -0,0 +1,258 @@
+package rocketclient
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/protocol"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	statusInterval   = 10.0  // с по времени симуляции между строками состояния
+	telemetryBacklog = 180.0 // с по времени симуляции, что копятся без связи
+)
+
+// TelemetrySink - куда уходят телеметрия и события полета. Цикл run не
+// знает, есть ли сервер: с ним работает websocketSink, без него offlineSink,
+// свой получатель задается в Config.Sink. Методы вызываются из одной горутины.
+type TelemetrySink interface {
+	// Start вызывается из Launch перед первым шагом физики
+	Start()
+	Send(state protocol.RocketState) error
+	Abort(msg protocol.AbortMessage) error
+	// Close завершает сеанс; reason - исход миссии
+	Close(reason string)
+}
+
+// statusLine печатает строку состояния раз в statusInterval
+type statusLine struct {
+	last    float64
+	started bool
+}
+
+// print печатает строку, если подошло время; suffix дописывается в конец
+func (l *statusLine) print(logger *logging.Logger, state protocol.RocketState, extra ...logging.Field) {
+	if l.started && state.Time-l.last < statusInterval {
+		return
+	}
+	l.started = true
+	l.last = state.Time
+	fields := append([]logging.Field{
+		logging.F("time", state.Time),
+		logging.F("altitude_km", state.Altitude/1000.0),
+		logging.F("speed", state.Speed),
+		logging.F("fuel", state.FuelRemaining),
+		logging.F("apoapsis_km", state.OrbitApoapsis/1000.0),
+		logging.F("periapsis_km", state.OrbitPeriapsis/1000.0),
+	}, extra...)
+	logger.Info("status", fields...)
+}
+
+// websocketSink отправляет телеметрию серверу через соединение клиента и
+// принимает команды. Потеря связи переводит клиент в переподключение.
+type websocketSink struct {
+	r      *RocketClient
+	status statusLine
+	// backlog - кадры, не отправленные без связи, за последние
+	// telemetryBacklog с; после переподключения уходят пакетами
+	backlog []protocol.RocketState
+}
+
+func (s *websocketSink) Start() {
+	r := s.r
+	r.connMu.Lock()
+	conn := r.conn
+	r.connMu.Unlock()
+	go r.receiveMessages(conn)
+	go r.heartbeatLoop(conn)
+}
+
+func (s *websocketSink) Send(state protocol.RocketState) error {
+	var extra []logging.Field
+	if s.r.heartbeat.enabled() {
+		if value := s.r.heartbeat.rtt(); value > 0 {
+			extra = append(extra, logging.F("rtt_ms", value.Seconds()*1000))
+		} else {
+			extra = append(extra, logging.F("rtt_unknown", true))
+		}
+	}
+	if s.r.chase != nil {
+		extra = append(extra, s.r.chase.statusFields()...)
+	}
+	if len(extra) > 0 {
+		s.status.print(s.r.logger, state, extra...)
+	}
+	if !s.connected() {
+		s.buffer(state)
+		return nil
+	}
+	if err := s.flush(); err != nil {
+		s.buffer(state)
+		return err
+	}
+	return s.write(protocol.MsgTypeTelemetry, protocol.TelemetryMessage{
+		RocketID: s.r.ID,
+		State:    state,
+	})
+}
+
+func (s *websocketSink) connected() bool {
+	s.r.connMu.Lock()
+	defer s.r.connMu.Unlock()
+	return s.r.registered && s.r.conn != nil
+}
+
+// buffer копит кадр до переподключения, отбрасывая кадры старше telemetryBacklog
+func (s *websocketSink) buffer(state protocol.RocketState) {
+	s.backlog = append(s.backlog, state)
+	drop := 0
+	for drop < len(s.backlog) && state.Time-s.backlog[drop].Time > telemetryBacklog {
+		drop++
+	}
+	if drop > 0 {
+		s.backlog = append(s.backlog[:0], s.backlog[drop:]...)
+	}
+}
+
+// flush досылает накопленные кадры пакетами telemetry_batch не больше
+// protocol.MaxBatchStates кадров. При ошибке неотправленное остается в буфере.
+func (s *websocketSink) flush() error {
+	if len(s.backlog) == 0 {
+		return nil
+	}
+	s.r.logger.Info("backlog_flush",
+		logging.F("frames", len(s.backlog)),
+		logging.F("first_time", s.backlog[0].Time),
+		logging.F("last_time", s.backlog[len(s.backlog)-1].Time))
+	for len(s.backlog) > 0 {
+		n := min(len(s.backlog), protocol.MaxBatchStates)
+		err := s.write(protocol.MsgTypeTelemetryBatch, protocol.BatchTelemetryMessage{
+			RocketID: s.r.ID,
+			States:   s.backlog[:n],
+		})
+		if err != nil {
+			return err
+		}
+		s.backlog = s.backlog[n:]
+	}
+	s.backlog = nil
+	return nil
+}
+
+func (s *websocketSink) Abort(msg protocol.AbortMessage) error {
+	return s.write(protocol.MsgTypeAbort, msg)
+}
+
+// write отправляет сообщение, если ракета зарегистрирована. При потере связи
+// сообщение не отправляется, но симуляция продолжается.
+func (s *websocketSink) write(msgType protocol.MessageType, data interface{}) error {
+	r := s.r
+	r.connMu.Lock()
+	conn, registered := r.conn, r.registered
+	r.connMu.Unlock()
+
+	if !registered || conn == nil {
+		return nil
+	}
+
+	if err := r.writeTo(conn, msgType, data); err != nil {
+		r.connectionLost(conn, err)
+		return err
+	}
+	return nil
+}
+
+func (s *websocketSink) Close(reason string) {
+	r := s.r
+	r.connMu.Lock()
+	defer r.connMu.Unlock()
+
+	if r.conn != nil {
+		_ = r.writeTo(r.conn, protocol.MsgTypeDisconnect, protocol.DisconnectMessage{
+			RocketID: r.ID,
+			Reason:   reason,
+		})
+		_ = r.conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+			time.Now().Add(time.Second))
+		r.conn.Close()
+		r.conn = nil
+	}
+}
+
+// offlineSink - режим без сервера (-offline): печатает строку состояния раз
+// в statusInterval и, если задан файл, пишет в него те же сообщения,
+// что ушли бы серверу, по одному JSON на строку
+type offlineSink struct {
+	id     string
+	clock  protocol.Clock
+	logger *logging.Logger
+	status statusLine
+
+	file *os.File
+	w    *bufio.Writer
+	err  error // Первая ошибка записи, после нее запись прекращается
+}
+
+func newOfflineSink(id, path string, clock protocol.Clock, logger *logging.Logger) (*offlineSink, error) {
+	s := &offlineSink{id: id, clock: clock, logger: logger}
+	if path == "" {
+		return s, nil
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось создать файл телеметрии: %w", err)
+	}
+	s.file = file
+	s.w = bufio.NewWriter(file)
+	logger.Info("telemetry_file", logging.F("path", path))
+	return s, nil
+}
+
+func (s *offlineSink) Start() {
+	s.logger.Info("offline_mode")
+}
+
+func (s *offlineSink) Send(state protocol.RocketState) error {
+	s.status.print(s.logger, state)
+	return s.write(protocol.MsgTypeTelemetry, protocol.TelemetryMessage{RocketID: s.id, State: state})
+}
+
+func (s *offlineSink) Abort(msg protocol.AbortMessage) error {
+	return s.write(protocol.MsgTypeAbort, msg)
+}
+
+func (s *offlineSink) write(msgType protocol.MessageType, data interface{}) error {
+	if s.w == nil || s.err != nil {
+		return nil
+	}
+
+	line, err := json.Marshal(protocol.Message{Type: msgType, Timestamp: s.clock.Now(), Data: data})
+	if err == nil {
+		_, err = s.w.Write(append(line, '\n'))
+	}
+	if err != nil {
+		s.err = err
+		s.logger.Error("telemetry_write_stopped", logging.F("error", err))
+	}
+	return err
+}
+
+func (s *offlineSink) Close(reason string) {
+	if s.file == nil {
+		return
+	}
+	s.write(protocol.MsgTypeDisconnect, protocol.DisconnectMessage{RocketID: s.id, Reason: reason})
+	if err := s.w.Flush(); err != nil && s.err == nil {
+		s.logger.Error("telemetry_write_failed", logging.F("error", err))
+	}
+	s.file.Close()
+	s.file = nil
+}
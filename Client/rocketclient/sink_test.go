@@ -0,0 +1,309 @@
+package rocketclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/protocol"
+
+	"github.com/gorilla/websocket"
+)
+
+// Без связи кадры копятся за последние telemetryBacklog с, а после
+// переподключения уходят пакетами не больше MaxBatchStates перед живым кадром
+func TestWebsocketSinkBacklog(t *testing.T) {
+	messages := make(chan protocol.Message, 16)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var msg protocol.Message
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			messages <- msg
+		}
+	}))
+	defer server.Close()
+
+	client := newRocketClient("test", DefaultConfig().Rocket, "ws"+strings.TrimPrefix(server.URL, "http"), 10, 0.01)
+	sink := client.sink.(*websocketSink)
+
+	// 2000 кадров по 0,1 с: в буфере остаются последние 180 с
+	const frames = 2000
+	for i := 0; i < frames; i++ {
+		if err := sink.Send(protocol.RocketState{Time: float64(i) / 10}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := int(telemetryBacklog*10) + 1; len(sink.backlog) != want {
+		t.Fatalf("в буфере %d кадров, ожидалось %d", len(sink.backlog), want)
+	}
+	first := sink.backlog[0].Time
+
+	conn, err := client.dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.connMu.Lock()
+	client.conn, client.registered = conn, true
+	client.connMu.Unlock()
+	defer conn.Close()
+
+	if err := sink.Send(protocol.RocketState{Time: frames / 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	var times []float64
+	for batch := 0; ; batch++ {
+		msg := <-messages
+		if msg.Type == protocol.MsgTypeTelemetry {
+			if len(times) != int(telemetryBacklog*10)+1 || batch != 2 {
+				t.Fatalf("живой кадр после %d пакетов и %d кадров", batch, len(times))
+			}
+			break
+		}
+		if msg.Type != protocol.MsgTypeTelemetryBatch {
+			t.Fatalf("неожиданное сообщение %s", msg.Type)
+		}
+		data, err := protocol.DecodeData[protocol.BatchTelemetryMessage](msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(data.States) > protocol.MaxBatchStates {
+			t.Fatalf("пакет из %d кадров", len(data.States))
+		}
+		for _, state := range data.States {
+			times = append(times, state.Time)
+		}
+	}
+	if times[0] != first || len(sink.backlog) != 0 {
+		t.Errorf("первый кадр T+%g, ожидалось T+%g; в буфере осталось %d", times[0], first, len(sink.backlog))
+	}
+}
+
+// Ошибки сервера считаются, а после notRegisteredLimit ошибок not_registered
+// клиент переподключается и регистрируется заново
+func TestNotRegisteredTriggersReregistration(t *testing.T) {
+	registered := make(chan int, 4)
+	connections := 0
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		connections++
+		n := connections
+
+		var msg protocol.Message
+		if err := conn.ReadJSON(&msg); err != nil || msg.Type != protocol.MsgTypeRegister {
+			return
+		}
+		conn.WriteJSON(protocol.Message{Type: protocol.MsgTypeAccepted, Data: protocol.AcceptedMessage{RocketID: "test"}})
+		registered <- n
+
+		if n == 1 {
+			for i := 0; i < notRegisteredLimit; i++ {
+				conn.WriteJSON(protocol.Message{Type: protocol.MsgTypeError, Data: protocol.ErrorMessage{
+					Code:       protocol.ErrorCodeNotRegistered,
+					Detail:     "телеметрия до регистрации ракеты",
+					RefType:    protocol.MsgTypeTelemetry,
+					Suppressed: 1,
+				}})
+			}
+		}
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	client := newRocketClient("test", DefaultConfig().Rocket, "ws"+strings.TrimPrefix(server.URL, "http"), 10, 0.01)
+	defer client.Stop()
+	conn, err := client.dial()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.register(conn); err != nil {
+		t.Fatal(err)
+	}
+	client.connMu.Lock()
+	client.conn, client.registered = conn, true
+	client.connMu.Unlock()
+	go client.receiveMessages(conn)
+
+	for _, want := range []int{1, 2} {
+		select {
+		case n := <-registered:
+			if n != want {
+				t.Fatalf("регистрация в соединении %d, ожидалось %d", n, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("нет регистрации в соединении %d", want)
+		}
+	}
+	if got, want := client.serverErrors.Load(), uint64(2*notRegisteredLimit); got != want {
+		t.Errorf("учтено ошибок сервера %d, ожидалось %d", got, want)
+	}
+}
+
+// readTelemetryFile разбирает файл -telemetry-file: по сообщению на строку
+func readTelemetryFile(t *testing.T, path string) []protocol.Message {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var messages []protocol.Message
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var msg protocol.Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			t.Fatalf("строка %d: %v", len(messages)+1, err)
+		}
+		messages = append(messages, msg)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return messages
+}
+
+// Кадры, аварийное сообщение и отключение читаются из файла такими же,
+// какими ушли в offlineSink
+func TestOfflineSinkRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "telemetry.jsonl")
+	clock := &fakeClock{now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	sink, err := newOfflineSink("r1", path, clock, logging.New(io.Discard, logging.LevelInfo, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent := []protocol.RocketState{
+		{Time: 0, Altitude: 100, Position: protocol.Vector3{X: 6371100}, MassCurrent: 420000, FuelRemaining: 400000, Stage: 1},
+		{Time: 0.1, Altitude: 100.4, Speed: 8.5, Position: protocol.Vector3{X: 6371100.4, Y: 3.2}, Velocity: protocol.Vector3{X: 8, Y: 2.9},
+			EngineStatus: []protocol.EngineStatus{{ID: "center", Throttle: 1, Active: true, BurnTime: 0.1}}, Stage: 1},
+		{Time: 0.2, Altitude: 101.7, Crashed: true, ParachuteDeployed: true, OrbitApoapsis: -1},
+	}
+	sink.Start()
+	for _, state := range sent {
+		if err := sink.Send(state); err != nil {
+			t.Fatal(err)
+		}
+	}
+	abort := protocol.AbortMessage{RocketID: "r1", Reason: "перегрузка", Time: 0.2, Altitude: 101.7}
+	if err := sink.Abort(abort); err != nil {
+		t.Fatal(err)
+	}
+	sink.Close(string(OutcomeCrashed))
+	sink.Close(string(OutcomeCrashed)) // Повторное закрытие ничего не пишет
+
+	messages := readTelemetryFile(t, path)
+	if len(messages) != len(sent)+2 {
+		t.Fatalf("в файле %d сообщений, ожидалось %d", len(messages), len(sent)+2)
+	}
+	for i, msg := range messages {
+		if !msg.Timestamp.Equal(clock.now) {
+			t.Errorf("сообщение %d: время %v, ожидалось %v", i, msg.Timestamp, clock.now)
+		}
+	}
+	for i, want := range sent {
+		msg := messages[i]
+		telemetry, err := protocol.DecodeDataStrict[protocol.TelemetryMessage](msg)
+		if err != nil || msg.Type != protocol.MsgTypeTelemetry {
+			t.Fatalf("кадр %d: %s, %v", i, msg.Type, err)
+		}
+		if telemetry.RocketID != "r1" || !reflect.DeepEqual(telemetry.State, want) {
+			t.Errorf("кадр %d:\n%+v\nожидался\n%+v", i, telemetry.State, want)
+		}
+	}
+	if got, err := protocol.DecodeDataStrict[protocol.AbortMessage](messages[len(sent)]); err != nil || got != abort {
+		t.Errorf("аварийное сообщение %+v, %v", got, err)
+	}
+	disconnect, err := protocol.DecodeDataStrict[protocol.DisconnectMessage](messages[len(sent)+1])
+	if err != nil || disconnect.RocketID != "r1" || disconnect.Reason != string(OutcomeCrashed) {
+		t.Errorf("отключение %+v, %v", disconnect, err)
+	}
+
+	// Без файла offlineSink только печатает строку состояния
+	sink, err = newOfflineSink("r1", "", clock, logging.New(io.Discard, logging.LevelInfo, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Send(sent[0]); err != nil {
+		t.Errorf("отправка без файла: %v", err)
+	}
+	sink.Close(string(OutcomeCrashed))
+}
+
+// Полет с -offline и -telemetry-file: в файле кадры по порядку времени и
+// отключение с исходом миссии
+func TestOfflineLaunchTelemetryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "telemetry.jsonl")
+	cfg := DefaultConfig()
+	cfg.ID = "offline"
+	cfg.Offline = true
+	cfg.TelemetryFile = path
+	cfg.Unpaced = true
+	cfg.Dt = 0.05
+	cfg.MaxFlightTime = 30 * time.Second
+	cfg.Logger = logging.New(io.Discard, logging.LevelInfo, false)
+
+	client, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary, err := client.Launch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := readTelemetryFile(t, path)
+	prev := -1.0
+	frames := 0
+	for _, msg := range messages[:len(messages)-1] {
+		if msg.Type != protocol.MsgTypeTelemetry {
+			continue
+		}
+		telemetry, err := protocol.DecodeDataStrict[protocol.TelemetryMessage](msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Close повторяет последний кадр, поэтому время только не убывает
+		if telemetry.RocketID != "offline" || telemetry.State.Time < prev {
+			t.Fatalf("кадр %s на T+%g после T+%g", telemetry.RocketID, telemetry.State.Time, prev)
+		}
+		prev = telemetry.State.Time
+		frames++
+	}
+	if frames == 0 || prev != summary.FlightTime {
+		t.Errorf("кадров %d, последний на T+%g; полет длился %g с", frames, prev, summary.FlightTime)
+	}
+	last := messages[len(messages)-1]
+	disconnect, err := protocol.DecodeDataStrict[protocol.DisconnectMessage](last)
+	if last.Type != protocol.MsgTypeDisconnect || err != nil || disconnect.Reason != string(summary.Outcome) {
+		t.Errorf("последнее сообщение %s: %+v, %v; исход %s", last.Type, disconnect, err, summary.Outcome)
+	}
+}
@@ -0,0 +1,122 @@
+package rocketclient
+
+import (
+	"cosmodrom/client/logging"
+	"cosmodrom/client/physics"
+	"cosmodrom/protocol"
+)
+
+// staging отделяет ступени по мере выработки топлива. Физика ведет один
+// общий запас топлива, поэтому ступень считается пустой, когда в баках
+// осталось только топливо верхних ступеней.
+type staging struct {
+	stages   []protocol.Stage
+	current  int
+	released float64 // Сброшено с верхней ступени помимо ступеней (полезная нагрузка), кг
+	logger   *logging.Logger
+}
+
+func newStaging(stages []protocol.Stage, logger *logging.Logger) *staging {
+	if len(stages) == 0 {
+		return nil
+	}
+	return &staging{stages: stages, logger: logger}
+}
+
+// number - номер текущей ступени с 1 для телеметрии, 0 без ступеней
+func (s *staging) number() int {
+	if s == nil {
+		return 0
+	}
+	return s.current + 1
+}
+
+// engines - двигатели текущей ступени
+func (s *staging) engines() []protocol.Engine {
+	return s.stages[s.current].Engines
+}
+
+// update отделяет текущую ступень, если ее топливо израсходовано или
+// force (команда StageSeparate), и переключает физику на двигатели
+// следующей. Несгоревшее топливо отделенной ступени сбрасывается вместе с
+// ней. Возвращает true при отделении.
+func (s *staging) update(p physics.PhysicsEngine, command *protocol.ControlCommand, state protocol.RocketState, force bool) (bool, error) {
+	if s == nil || s.current >= len(s.stages)-1 {
+		return false, nil
+	}
+
+	reserve := 0.0
+	for _, stage := range s.stages[s.current+1:] {
+		reserve += stage.MassFuel
+	}
+	if state.FuelRemaining > reserve && !force {
+		return false, nil
+	}
+
+	dropped := s.stages[s.current]
+	next := s.stages[s.current+1]
+
+	if leftover := state.FuelRemaining - reserve; leftover > 0 {
+		if err := p.JettisonFuel(leftover); err != nil {
+			return false, err
+		}
+		s.logger.Info("stage_commanded", logging.F("stage", s.current+1), logging.F("leftover", leftover))
+	}
+	massEmpty := -s.released
+	for _, stage := range s.stages[s.current+1:] {
+		massEmpty += stage.MassEmpty
+	}
+	if err := p.SetStage(massEmpty, next.Engines); err != nil {
+		return false, err
+	}
+	s.current++
+	command.EngineThrottle = make([]float64, len(next.Engines))
+
+	s.logger.Info("stage_separated",
+		logging.F("stage", s.current),
+		logging.F("name", dropped.Name),
+		logging.F("altitude_km", state.Altitude/1000.0),
+		logging.F("speed", state.Speed),
+		logging.F("dropped", dropped.MassEmpty),
+		logging.F("engines", len(next.Engines)),
+		logging.F("thrust_kn", totalThrust(next.Engines)/1000.0))
+	return true, nil
+}
+
+// release учитывает массу, сброшенную с верхней ступени, чтобы при
+// следующем отделении сухая масса не вернулась обратно
+func (s *staging) release(mass float64) {
+	if s != nil {
+		s.released += mass
+	}
+}
+
+// resume пропускает ступени, топливо которых уже выработано, без журнала и
+// без вызова физики: продолжая полет из снимка, физика уже на нужной ступени
+func (s *staging) resume(fuel float64) {
+	if s == nil {
+		return
+	}
+	for s.current < len(s.stages)-1 {
+		reserve := 0.0
+		for _, stage := range s.stages[s.current+1:] {
+			reserve += stage.MassFuel
+		}
+		if fuel > reserve {
+			return
+		}
+		s.current++
+	}
+}
+
+// maxEngines - наибольшее число двигателей среди ступеней, для буферов
+// дросселей, выделяемых заранее
+func maxEngines(config protocol.RocketConfig) int {
+	count := len(config.Engines)
+	for _, stage := range config.Stages {
+		if len(stage.Engines) > count {
+			count = len(stage.Engines)
+		}
+	}
+	return count
+}
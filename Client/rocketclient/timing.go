@@ -0,0 +1,108 @@
+package rocketclient
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/protocol"
+)
+
+const (
+	minDt          = 0.001
+	maxDt          = 0.1
+	minTelemetryHz = 0.1
+	maxTelemetryHz = 50.0
+
+	maxSubsteps = 10 // Больше шагов за тик не делаем, чтобы не уйти в спираль отставания
+
+	minTimeWarp = 1.0
+	maxTimeWarp = 100.0
+
+	// С сервером между кадрами телеметрии проходит не больше секунды времени
+	// симуляции: на столько вперед сервер продлевает траектории при проверке
+	// сближений (collisionCheckInterval)
+	maxServerFrameGap = 1.0 // с
+)
+
+func validateTiming(dt, telemetryHz, timeWarp float64) error {
+	if dt < minDt || dt > maxDt {
+		return fmt.Errorf("шаг физики -dt должен быть от %.3f до %.1f с, получено %g", minDt, maxDt, dt)
+	}
+	if telemetryHz < minTelemetryHz || telemetryHz > maxTelemetryHz {
+		return fmt.Errorf("частота телеметрии -telemetry-hz должна быть от %.1f до %.0f Гц, получено %g", minTelemetryHz, maxTelemetryHz, telemetryHz)
+	}
+	if timeWarp < minTimeWarp || timeWarp > maxTimeWarp {
+		return fmt.Errorf("ускорение времени -time-warp должно быть от %.0f до %.0f, получено %g", minTimeWarp, maxTimeWarp, timeWarp)
+	}
+	return nil
+}
+
+// serverWarpLimit - предел ускорения с сервером. Телеметрия уходит с частотой
+// telemetryHz по реальному времени, поэтому при ускорении warp между кадрами
+// проходит warp/telemetryHz секунд симуляции.
+func serverWarpLimit(telemetryHz float64) float64 {
+	return math.Max(minTimeWarp, math.Min(maxTimeWarp, maxServerFrameGap*telemetryHz))
+}
+
+// simClock переводит реальное время между тиками в число шагов физики.
+// Если тик пришел с опозданием, за него делается несколько шагов, и
+// симуляция не отстает от реального времени. При ускорении времени warp
+// шаг физики не меняется, растет число шагов за тик.
+type simClock struct {
+	dt      float64
+	pending float64 // Реальное время, еще не покрытое шагами физики, с
+	last    time.Time
+}
+
+func newSimClock(dt float64, now time.Time) *simClock {
+	return &simClock{dt: dt, last: now}
+}
+
+// advance возвращает число шагов для текущего тика и отброшенное время
+// симуляции, если отставание превысило maxSubsteps шагов (с учетом warp)
+func (c *simClock) advance(now time.Time, warp float64) (steps int, dropped float64) {
+	c.pending += now.Sub(c.last).Seconds() * warp
+	c.last = now
+
+	limit := int(math.Ceil(maxSubsteps * warp))
+	steps = int(c.pending / c.dt)
+	if steps > limit {
+		dropped = float64(steps-limit) * c.dt
+		steps = limit
+	}
+	c.pending -= float64(steps)*c.dt + dropped
+	return steps, dropped
+}
+
+// timeWarp выбирает ускорение времени на следующий тик. Во время работы
+// двигателей и ниже minAltitude симуляция идет в реальном времени, чтобы
+// не терять точность там, где важна динамика.
+type timeWarp struct {
+	factor      float64 // Запрошенное ускорение
+	minAltitude float64 // м
+	current     float64
+	logger      *logging.Logger
+}
+
+func (w *timeWarp) update(state protocol.RocketState, burning bool) float64 {
+	next, reason := w.factor, ""
+	switch {
+	case w.factor <= 1:
+	case burning:
+		next, reason = 1, "двигатели работают"
+	case state.Altitude < w.minAltitude:
+		next, reason = 1, fmt.Sprintf("высота ниже %.0f км", w.minAltitude/1000.0)
+	}
+
+	if next != w.current && w.current != 0 {
+		if next > 1 {
+			w.logger.Info("time_warp", logging.F("warp", next))
+		} else {
+			w.logger.Info("real_time", logging.F("reason", reason))
+		}
+	}
+	w.current = next
+	return next
+}
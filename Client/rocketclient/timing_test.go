@@ -0,0 +1,191 @@
+package rocketclient
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/protocol"
+)
+
+func TestSimClockAdvance(t *testing.T) {
+	// Шаг 0.25 с и интервалы, кратные 1/8 с, считаются без ошибок округления
+	const dt = 0.25
+	type tick struct {
+		after       time.Duration // Реальное время с прошлого тика
+		wantSteps   int
+		wantDropped float64 // с симуляции
+	}
+	tests := []struct {
+		name        string
+		warp        float64
+		ticks       []tick
+		wantPending float64
+	}{
+		{
+			name:  "тик вовремя",
+			warp:  1,
+			ticks: []tick{{after: 500 * time.Millisecond, wantSteps: 2}},
+		},
+		{
+			name: "остаток переходит на следующий тик",
+			warp: 1,
+			ticks: []tick{
+				{after: 375 * time.Millisecond, wantSteps: 1},
+				{after: 375 * time.Millisecond, wantSteps: 2},
+				{after: 125 * time.Millisecond, wantSteps: 0},
+			},
+			wantPending: 0.125,
+		},
+		{
+			name: "отставание больше maxSubsteps отбрасывается",
+			warp: 1,
+			ticks: []tick{
+				{after: 5 * time.Second, wantSteps: maxSubsteps, wantDropped: 2.5},
+				{after: 250 * time.Millisecond, wantSteps: 1},
+			},
+		},
+		{
+			name:  "ровно maxSubsteps шагов без потерь",
+			warp:  1,
+			ticks: []tick{{after: 2500 * time.Millisecond, wantSteps: maxSubsteps}},
+		},
+		{
+			name: "ускорение умножает шаги",
+			warp: 10,
+			ticks: []tick{
+				{after: 250 * time.Millisecond, wantSteps: 10},
+				{after: 125 * time.Millisecond, wantSteps: 5},
+			},
+		},
+		{
+			name: "предел шагов растет с ускорением",
+			warp: 10,
+			ticks: []tick{
+				{after: 5 * time.Second, wantSteps: 10 * maxSubsteps, wantDropped: 25},
+			},
+		},
+		{
+			name: "дробное ускорение: предел округляется вверх",
+			warp: 2.5,
+			ticks: []tick{
+				{after: 5 * time.Second, wantSteps: 25, wantDropped: 6.25},
+				{after: 125 * time.Millisecond, wantSteps: 1},
+			},
+			wantPending: 0.0625,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Unix(1000, 0)
+			clock := newSimClock(dt, now)
+			for i, tick := range tt.ticks {
+				now = now.Add(tick.after)
+				steps, dropped := clock.advance(now, tt.warp)
+				if steps != tick.wantSteps || dropped != tick.wantDropped {
+					t.Errorf("тик %d: шагов %d, отброшено %g с; ожидалось %d и %g с",
+						i, steps, dropped, tick.wantSteps, tick.wantDropped)
+				}
+			}
+			if clock.pending != tt.wantPending {
+				t.Errorf("остаток %g с, ожидался %g с", clock.pending, tt.wantPending)
+			}
+		})
+	}
+}
+
+func TestServerWarpLimit(t *testing.T) {
+	for _, tt := range []struct {
+		telemetryHz float64
+		want        float64
+	}{
+		{minTelemetryHz, minTimeWarp}, // Кадр реже раза в секунду - без ускорения
+		{1, 1},
+		{2.5, 2.5},
+		{10, 10},
+		{maxTelemetryHz, 50},
+		{200, maxTimeWarp},
+	} {
+		if got := serverWarpLimit(tt.telemetryHz); got != tt.want {
+			t.Errorf("%g Гц: предел x%g, ожидался x%g", tt.telemetryHz, got, tt.want)
+		}
+		// Между кадрами не больше maxServerFrameGap времени симуляции
+		if gap := serverWarpLimit(tt.telemetryHz) / tt.telemetryHz; tt.want > minTimeWarp && gap > maxServerFrameGap+1e-12 {
+			t.Errorf("%g Гц: между кадрами %g с симуляции", tt.telemetryHz, gap)
+		}
+	}
+
+	// Предел действует только с сервером
+	for _, offline := range []bool{false, true} {
+		cfg := DefaultConfig()
+		cfg.ID = "warp"
+		cfg.TelemetryHz, cfg.TimeWarp, cfg.Offline = 2, 50, offline
+		cfg.Logger = logging.New(io.Discard, logging.LevelInfo, false)
+		client, err := New(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := 2.0
+		if offline {
+			want = 50
+		}
+		if client.warp.factor != want {
+			t.Errorf("offline %v: ускорение x%g, ожидалось x%g", offline, client.warp.factor, want)
+		}
+		client.Close()
+	}
+}
+
+// Ускорение действует только без тяги и выше minAltitude; о каждом
+// переключении одна запись в журнале
+func TestTimeWarpUpdate(t *testing.T) {
+	var log bytes.Buffer
+	w := timeWarp{factor: 20, minAltitude: 100000, logger: logging.New(&log, logging.LevelInfo, true)}
+
+	steps := []struct {
+		name     string
+		altitude float64 // м
+		burning  bool
+		want     float64
+		wantLog  string // Код записи в журнале, пусто - записи нет
+	}{
+		// Первый тик задает режим без записи
+		{name: "старт", altitude: 0, burning: true, want: 1},
+		{name: "разгон выше предела", altitude: 150000, burning: true, want: 1},
+		{name: "пассивный участок ниже предела", altitude: 90000, want: 1},
+		{name: "пассивный участок", altitude: 150000, want: 20, wantLog: "time_warp"},
+		{name: "пассивный участок дальше", altitude: 200000, want: 20},
+		{name: "включение двигателей", altitude: 200000, burning: true, want: 1, wantLog: "real_time"},
+		{name: "выключение двигателей", altitude: 200000, want: 20, wantLog: "time_warp"},
+		{name: "спуск ниже предела", altitude: 99999, want: 1, wantLog: "real_time"},
+		{name: "ровно на пределе", altitude: 100000, want: 20, wantLog: "time_warp"},
+	}
+	for _, step := range steps {
+		log.Reset()
+		got := w.update(protocol.RocketState{Altitude: step.altitude}, step.burning)
+		if got != step.want {
+			t.Errorf("%s: ускорение x%g, ожидалось x%g", step.name, got, step.want)
+		}
+		logged := strings.Count(log.String(), "\n")
+		if step.wantLog == "" && logged != 0 ||
+			step.wantLog != "" && (logged != 1 || !strings.Contains(log.String(), `"code":"`+step.wantLog+`"`)) {
+			t.Errorf("%s: журнал %q, ожидалась запись %q", step.name, log.String(), step.wantLog)
+		}
+	}
+
+	// Без ускорения режим не переключается и не пишется в журнал
+	log.Reset()
+	w = timeWarp{factor: 1, minAltitude: 100000, logger: logging.New(&log, logging.LevelInfo, true)}
+	for _, burning := range []bool{true, false, true} {
+		if got := w.update(protocol.RocketState{Altitude: 200000}, burning); got != 1 {
+			t.Errorf("ускорение x%g без -time-warp", got)
+		}
+	}
+	if log.Len() != 0 {
+		t.Errorf("журнал без -time-warp: %q", log.String())
+	}
+}
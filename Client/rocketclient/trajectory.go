@@ -0,0 +1,121 @@
+package rocketclient
+
+import (
+	"math"
+	"sync"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/client/physics"
+	"cosmodrom/protocol"
+)
+
+const waypointCaptureRadius = 2000.0 // м, точка считается пройденной ближе этого расстояния
+
+// waypointGuidance ведет ракету по контрольным точкам траектории от сервера.
+// Пока точки есть, тангаж автопилота заменяется направлением на текущую точку.
+type waypointGuidance struct {
+	waypoints []protocol.Vector3
+	next      int
+	logger    *logging.Logger
+	mu        sync.Mutex
+}
+
+func (g *waypointGuidance) setWaypoints(waypoints []protocol.Vector3) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.waypoints = append([]protocol.Vector3(nil), waypoints...)
+	g.next = 0
+}
+
+// steer задает тангаж на текущую точку и возвращает состояние наведения.
+// nil означает, что траектории нет и команда не изменена.
+func (g *waypointGuidance) steer(command *protocol.ControlCommand, state protocol.RocketState) *protocol.GuidanceStatus {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.waypoints) == 0 {
+		return nil
+	}
+
+	for g.next < len(g.waypoints) {
+		to := subtract(g.waypoints[g.next], state.Position)
+		distance := length(to)
+		if distance < waypointCaptureRadius {
+			g.logger.Debug("waypoint_reached", logging.F("waypoint", g.next+1), logging.F("waypoints", len(g.waypoints)))
+			g.next++
+			continue
+		}
+		// Точка позади по направлению движения недостижима без разворота
+		if state.Speed > 1.0 && dot(to, state.Velocity) < 0 {
+			g.logger.Debug("waypoint_skipped", logging.F("waypoint", g.next+1), logging.F("waypoints", len(g.waypoints)))
+			g.next++
+			continue
+		}
+
+		// Рыскание тоже наводится на точку: движок учитывает его в направлении тяги
+		if pitch, yaw, ok := physics.AttitudeToward(state.Position, to); ok {
+			command.Pitch = pitch
+			command.Yaw = yaw
+		}
+		return &protocol.GuidanceStatus{
+			WaypointIndex: g.next,
+			WaypointCount: len(g.waypoints),
+			Distance:      distance,
+		}
+	}
+
+	g.logger.Info("trajectory_done")
+	g.waypoints = nil
+	return nil
+}
+
+func (r *RocketClient) handleTrajectory(msg protocol.Message) {
+	trajectoryMsg, err := protocol.DecodeData[protocol.TrajectoryMessage](msg)
+	if err != nil {
+		r.logger.Warn("message_decode_failed", logging.F("type", msg.Type), logging.F("error", err))
+		return
+	}
+
+	r.guidance.setWaypoints(trajectoryMsg.Waypoints)
+	if len(trajectoryMsg.Waypoints) == 0 {
+		r.logger.Info("trajectory_empty")
+		return
+	}
+	r.logger.Info("trajectory_received", logging.F("waypoints", len(trajectoryMsg.Waypoints)))
+}
+
+func add(a, b protocol.Vector3) protocol.Vector3 {
+	return protocol.Vector3{X: a.X + b.X, Y: a.Y + b.Y, Z: a.Z + b.Z}
+}
+
+func subtract(a, b protocol.Vector3) protocol.Vector3 {
+	return protocol.Vector3{X: a.X - b.X, Y: a.Y - b.Y, Z: a.Z - b.Z}
+}
+
+func dot(a, b protocol.Vector3) float64 {
+	return a.X*b.X + a.Y*b.Y + a.Z*b.Z
+}
+
+func cross(a, b protocol.Vector3) protocol.Vector3 {
+	return protocol.Vector3{
+		X: a.Y*b.Z - a.Z*b.Y,
+		Y: a.Z*b.X - a.X*b.Z,
+		Z: a.X*b.Y - a.Y*b.X,
+	}
+}
+
+func scale(v protocol.Vector3, k float64) protocol.Vector3 {
+	return protocol.Vector3{X: v.X * k, Y: v.Y * k, Z: v.Z * k}
+}
+
+func length(v protocol.Vector3) float64 {
+	return math.Sqrt(dot(v, v))
+}
+
+func normalize(v protocol.Vector3) protocol.Vector3 {
+	l := length(v)
+	if l == 0 {
+		return v
+	}
+	return protocol.Vector3{X: v.X / l, Y: v.Y / l, Z: v.Z / l}
+}
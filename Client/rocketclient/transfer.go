@@ -0,0 +1,76 @@
+package rocketclient
+
+import (
+	"cosmodrom/client/physics"
+	"cosmodrom/protocol"
+)
+
+// Фазы перехода Хомана после выхода на начальную орбиту (-post-orbit-raise)
+const (
+	PhaseTransferBurn        AscentPhase = "transfer_burn"        // Первый импульс: сход на переходную орбиту
+	PhaseTransferCoast       AscentPhase = "transfer_coast"       // Полет по переходной орбите к противоположной апсиде
+	PhaseTransferCircularize AscentPhase = "transfer_circularize" // Второй импульс: скругление на целевой высоте
+)
+
+// hohmannTransfer - переход Хомана с круговой орбиты from на круговую target.
+// Как и выведение, импульсы выполняются на полной тяге до нужной апсиды по
+// прогнозу орбиты, а не по расчетному приращению скорости: так переход
+// выдерживает и неточную начальную орбиту, и отказы двигателей.
+type hohmannTransfer struct {
+	from, target float64 // м
+	plan         physics.HohmannPlan
+
+	start    float64 // Время включения первого импульса (с)
+	burnTime float64 // Длительность первого импульса (с)
+}
+
+func newHohmannTransfer(from, target float64, plan physics.HohmannPlan) *hohmannTransfer {
+	return &hohmannTransfer{from: from, target: target, plan: plan}
+}
+
+func (t *hohmannTransfer) raising() bool {
+	return t.target > t.from
+}
+
+// firstBurnDone - противоположная апсида переходной орбиты дошла до цели
+func (t *hohmannTransfer) firstBurnDone(state protocol.RocketState) bool {
+	if t.raising() {
+		return state.OrbitApoapsis >= t.target
+	}
+	return state.OrbitPeriapsis <= t.target
+}
+
+// atSecondBurn - пора включать второй импульс. Второй импульс почти равен
+// первому, поэтому включение - за половину длительности первого до апсиды.
+// Если апсида уже пройдена (знак вертикальной скорости сменился), импульс
+// включается сразу; знак проверяется только на второй половине пути, у
+// исходной орбиты он не определен.
+func (t *hohmannTransfer) atSecondBurn(state protocol.RocketState, orbit physics.OrbitPrediction) bool {
+	lead := t.burnTime / 2
+	mid := (t.from + t.target) / 2
+	vs := verticalSpeed(state)
+	if t.raising() {
+		return (orbit.TimeToApoapsis >= 0 && orbit.TimeToApoapsis <= lead) || (state.Altitude > mid && vs <= 0)
+	}
+	return (orbit.TimeToPeriapsis >= 0 && orbit.TimeToPeriapsis <= lead) || (state.Altitude < mid && vs >= 0)
+}
+
+// done - вторая апсида подтянулась к целевой высоте
+func (t *hohmannTransfer) done(state protocol.RocketState) bool {
+	if t.raising() {
+		return state.OrbitPeriapsis >= t.target-apoapsisTolerance
+	}
+	return state.OrbitApoapsis <= t.target+apoapsisTolerance
+}
+
+// attitude - тяга по скорости при подъеме орбиты и против скорости при спуске
+func (t *hohmannTransfer) attitude(command *protocol.ControlCommand, state protocol.RocketState) {
+	direction := state.Velocity
+	if !t.raising() {
+		direction = scale(direction, -1)
+	}
+	if pitch, yaw, ok := physics.AttitudeToward(state.Position, direction); ok {
+		command.Pitch = pitch
+		command.Yaw = yaw
+	}
+}
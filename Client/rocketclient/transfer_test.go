@@ -0,0 +1,94 @@
+package rocketclient
+
+import (
+	"io"
+	"math"
+	"testing"
+
+	"cosmodrom/client/logging"
+	"cosmodrom/client/physics"
+	"cosmodrom/protocol"
+)
+
+// TestHohmannTransfer проводит автопилот выведения через переход Хомана с
+// круговой орбиты from на to и сверяет итог с аналитикой: орбита на целевой
+// высоте, израсходованное приращение скорости близко к расчетному.
+func TestHohmannTransfer(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to float64
+	}{
+		{name: "подъем 200 -> 400 км", from: 200000, to: 400000},
+		{name: "спуск 400 -> 200 км", from: 400000, to: 200000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			planet := physics.EarthDefault()
+			config := protocol.RocketConfig{
+				Name:        "Transfer",
+				MassEmpty:   20000,
+				MassFuel:    20000,
+				MassFuelMax: 20000,
+				Engines:     []protocol.Engine{{Thrust: 200000, FuelConsumption: 70, IsActive: true}},
+			}
+			p, err := physics.NewGoPhysics(&config, planet.Position(0, 0, tt.from))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer p.Close()
+			if err := p.SetPlanet(planet); err != nil {
+				t.Fatal(err)
+			}
+			r := planet.Radius + tt.from
+			p.SetInitialVelocity(protocol.Vector3{Y: math.Sqrt(protocol.GConstant * planet.Mass / r)})
+
+			plan, err := p.PlanHohmann(tt.from, tt.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := newGravityTurnAutopilot(tt.from, planet, physics.GravityTurnConfig{}, p, logging.New(io.Discard, logging.LevelInfo, false))
+			s.transfer = newHohmannTransfer(tt.from, tt.to, plan)
+			// Начальная орбита уже круговая: скругление сразу завершается
+			s.phase = PhaseCircularize
+
+			const dt = 0.02
+			var state protocol.RocketState
+			var orbit physics.OrbitPrediction
+			for step := 0; step < int(2*plan.TransferTime/dt); step++ {
+				if state, err = p.GetState(); err != nil {
+					t.Fatal(err)
+				}
+				if orbit, err = p.PredictOrbit(); err != nil {
+					t.Fatal(err)
+				}
+				setOrbit(&state, orbit)
+				command := s.Step(state, dt)
+				if s.Outcome() == OutcomeOrbit {
+					break
+				}
+				if err := p.Update(&command, dt); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if s.Outcome() != OutcomeOrbit {
+				t.Fatalf("переход не завершен: фаза %s, апоцентр %.1f км, перицентр %.1f км",
+					s.Phase(), orbit.Apoapsis/1000.0, orbit.Periapsis/1000.0)
+			}
+			for _, apsis := range []float64{orbit.Apoapsis, orbit.Periapsis} {
+				if math.Abs(apsis-tt.to) > 3000 {
+					t.Errorf("апсиды %.1f и %.1f км, ожидалась круговая орбита %.1f км",
+						orbit.Apoapsis/1000.0, orbit.Periapsis/1000.0, tt.to/1000.0)
+					break
+				}
+			}
+
+			ve := config.Engines[0].Thrust / config.Engines[0].FuelConsumption
+			spent := ve * math.Log((config.MassEmpty+config.MassFuel)/state.MassCurrent)
+			if math.Abs(spent-plan.TotalDeltaV) > 0.05*plan.TotalDeltaV {
+				t.Errorf("израсходовано %.1f м/с, по расчету %.1f м/с", spent, plan.TotalDeltaV)
+			}
+		})
+	}
+}
@@ -72,6 +72,23 @@ void rocket_free(RocketState* state) {
     }
 }
 
+// surface_velocity - скорость точки position, вращающейся вместе с планетой
+// (поверхность и атмосфера): omega x r
+Vector3 surface_velocity(const RocketState* state, const Vector3* position) {
+    Vector3 result = {
+        -state->rotation_rate * position->y,
+        state->rotation_rate * position->x,
+        0
+    };
+    return result;
+}
+
+// rocket_set_rotation задает вращение планеты для сопротивления атмосферы
+// и проверки посадки. Начальную скорость вращения задает вызывающий.
+void rocket_set_rotation(RocketState* state, double rotation_rate) {
+    state->rotation_rate = rotation_rate;
+}
+
 Vector3 calculate_gravity(const Vector3* position) {
     double distance = vector_magnitude(position);
     if (distance < EARTH_RADIUS) {
@@ -95,12 +112,15 @@ Vector3 calculate_drag(const RocketState* state, const RocketConfig* config) {
         return zero;
     }
 
-    double rho_0 = 1.225;
+    double rho_0 = SEA_LEVEL_DENSITY;
     double scale_height = 8500.0; 
 
     double rho = rho_0 * exp(-state->altitude / scale_height);
 
-    double velocity_magnitude = vector_magnitude(&state->velocity);
+    // Атмосфера вращается вместе с планетой, сопротивление - от скорости относительно воздуха
+    Vector3 wind = surface_velocity(state, &state->position);
+    Vector3 airspeed = vector_sub(&state->velocity, &wind);
+    double velocity_magnitude = vector_magnitude(&airspeed);
     if (velocity_magnitude < 1e-6) {
         Vector3 zero = {0, 0, 0};
         return zero;
@@ -109,7 +129,7 @@ Vector3 calculate_drag(const RocketState* state, const RocketConfig* config) {
     double drag_force = 0.5 * rho * velocity_magnitude * velocity_magnitude *
                         config->drag_coefficient * config->cross_section;
 
-    Vector3 velocity_direction = vector_normalize(&state->velocity);
+    Vector3 velocity_direction = vector_normalize(&airspeed);
     Vector3 drag = vector_scale(&velocity_direction, -drag_force);
 
     return drag;
@@ -136,20 +156,31 @@ Vector3 calculate_thrust(const RocketConfig* config, const ControlCommand* comma
 
     Vector3 radial_up = vector_normalize(position);
 
+    // Восток - по направлению вращения планеты вокруг оси z
     Vector3 z_axis = {0, 0, 1};
-    Vector3 east = vector_cross(&radial_up, &z_axis);
+    Vector3 east = vector_cross(&z_axis, &radial_up);
     double east_mag = vector_magnitude(&east);
     if (east_mag < 0.01) {
         Vector3 x_axis = {1, 0, 0};
-        east = vector_cross(&radial_up, &x_axis);
+        east = vector_cross(&x_axis, &radial_up);
     }
     east = vector_normalize(&east);
 
+    // Рыскание поворачивает горизонтальную составляющую от востока вправо
+    // (к югу), как курс; при pitch = 0 оно не влияет на тягу
+    Vector3 north = vector_cross(&radial_up, &east);
+    double yaw_rad = command->yaw * M_PI / 180.0;
+    Vector3 horizontal = {
+        east.x * cos(yaw_rad) - north.x * sin(yaw_rad),
+        east.y * cos(yaw_rad) - north.y * sin(yaw_rad),
+        east.z * cos(yaw_rad) - north.z * sin(yaw_rad)
+    };
+
     double pitch_rad = command->pitch * M_PI / 180.0;
     Vector3 thrust_dir = {
-        radial_up.x * cos(pitch_rad) + east.x * sin(pitch_rad),
-        radial_up.y * cos(pitch_rad) + east.y * sin(pitch_rad),
-        radial_up.z * cos(pitch_rad) + east.z * sin(pitch_rad)
+        radial_up.x * cos(pitch_rad) + horizontal.x * sin(pitch_rad),
+        radial_up.y * cos(pitch_rad) + horizontal.y * sin(pitch_rad),
+        radial_up.z * cos(pitch_rad) + horizontal.z * sin(pitch_rad)
     };
 
     total_thrust = vector_scale(&thrust_dir, thrust_magnitude);
@@ -202,9 +233,15 @@ void rocket_update(RocketState* state, const RocketConfig* config,
         return; 
     }
 
-    Vector3 gravity_force = calculate_gravity(&state->position);
+    // calculate_gravity возвращает ускорение, в силу переводим через массу
+    Vector3 gravity_accel = calculate_gravity(&state->position);
+    Vector3 gravity_force = vector_scale(&gravity_accel, state->mass_current);
     Vector3 drag_force = calculate_drag(state, config);
-    Vector3 thrust_force = calculate_thrust(config, command, &state->position);
+    // Без топлива двигатели не работают, какой бы ни была команда
+    Vector3 thrust_force = {0, 0, 0};
+    if (state->fuel_remaining > 0) {
+        thrust_force = calculate_thrust(config, command, &state->position);
+    }
 
     Vector3 total_force = vector_add(&gravity_force, &drag_force);
     total_force = vector_add(&total_force, &thrust_force);
@@ -234,12 +271,16 @@ void rocket_update(RocketState* state, const RocketConfig* config,
     state->altitude = distance - EARTH_RADIUS;
 
     if (check_ground_collision(state)) {
-        if (state->speed < 5.0) { 
+        // Скорость касания считается относительно вращающейся поверхности
+        Vector3 ground = surface_velocity(state, &state->position);
+        Vector3 relative = vector_sub(&state->velocity, &ground);
+        if (vector_magnitude(&relative) < 5.0) {
             state->landed = true;
         } else {
             state->crashed = true;
         }
-        state->velocity = (Vector3){0, 0, 0};
+        state->velocity = ground;
+        state->speed = vector_magnitude(&ground);
         state->acceleration = (Vector3){0, 0, 0};
         return;
     }
@@ -304,6 +345,18 @@ double orbital_velocity_at_altitude(const PlanetConfig* planet, double altitude)
     return sqrt(G_CONSTANT * planet->mass / r);
 }
 
+// atmosphere_density - плотность экспоненциальной атмосферы (кг/м3): ниже
+// поверхности как на поверхности, от atmosphere_height и выше ровно 0
+double atmosphere_density(const PlanetConfig* planet, double altitude) {
+    if (altitude < 0) {
+        altitude = 0;
+    }
+    if (altitude >= planet->atmosphere_height) {
+        return 0.0;
+    }
+    return planet->surface_pressure * SEA_LEVEL_DENSITY * exp(-altitude / planet->scale_height);
+}
+
 GravityTurnConfig gravity_turn_for_orbit(const PlanetConfig* planet, double target_orbit_altitude) {
     GravityTurnConfig config;
     config.target_altitude = target_orbit_altitude;
@@ -356,6 +409,10 @@ OrbitPrediction predict_orbit(const RocketState* state, const PlanetConfig* plan
 
     Vector3 h_vec = vector_cross(&state->position, &state->velocity);
     double h = vector_magnitude(&h_vec);
+    // Ось вращения планеты - z, поэтому наклонение - угол момента импульса к ней
+    if (h > 0) {
+        pred.inclination = acos(fmax(-1.0, fmin(1.0, h_vec.z / h))) * 180.0 / M_PI;
+    }
 
     double a;
     if (fabs(specific_energy) < 1e-10) {
@@ -397,24 +454,31 @@ void rocket_update_with_planet(RocketState* state, const RocketConfig* config,
     double distance = vector_magnitude(&state->position);
     Vector3 gravity_force = {0, 0, 0};
     if (distance > planet->radius) {
+        // Как и в rocket_update: ускорение свободного падения умножается на массу
         double gravity_magnitude = G_CONSTANT * planet->mass / (distance * distance);
         Vector3 direction = vector_normalize(&state->position);
-        gravity_force = vector_scale(&direction, -gravity_magnitude);
+        gravity_force = vector_scale(&direction, -gravity_magnitude * state->mass_current);
     }
 
     Vector3 drag_force = {0, 0, 0};
     if (state->altitude < planet->atmosphere_height && state->altitude > 0) {
-        double rho = planet->surface_pressure * 1.225 * exp(-state->altitude / planet->scale_height);
-        double velocity_magnitude = vector_magnitude(&state->velocity);
+        double rho = atmosphere_density(planet, state->altitude);
+        Vector3 wind = surface_velocity(state, &state->position);
+        Vector3 airspeed = vector_sub(&state->velocity, &wind);
+        double velocity_magnitude = vector_magnitude(&airspeed);
         if (velocity_magnitude > 1e-6) {
             double drag = 0.5 * rho * velocity_magnitude * velocity_magnitude *
                          config->drag_coefficient * config->cross_section;
-            Vector3 velocity_direction = vector_normalize(&state->velocity);
+            Vector3 velocity_direction = vector_normalize(&airspeed);
             drag_force = vector_scale(&velocity_direction, -drag);
         }
     }
 
-    Vector3 thrust_force = calculate_thrust(config, command, &state->position);
+    // Без топлива двигатели не работают, какой бы ни была команда
+    Vector3 thrust_force = {0, 0, 0};
+    if (state->fuel_remaining > 0) {
+        thrust_force = calculate_thrust(config, command, &state->position);
+    }
 
     Vector3 total_force = vector_add(&gravity_force, &drag_force);
     total_force = vector_add(&total_force, &thrust_force);
@@ -443,12 +507,16 @@ void rocket_update_with_planet(RocketState* state, const RocketConfig* config,
     state->altitude = distance - planet->radius;
 
     if (distance <= planet->radius) {
-        if (state->speed < 5.0) {
+        // Скорость касания считается относительно вращающейся поверхности
+        Vector3 ground = surface_velocity(state, &state->position);
+        Vector3 relative = vector_sub(&state->velocity, &ground);
+        if (vector_magnitude(&relative) < 5.0) {
             state->landed = true;
         } else {
             state->crashed = true;
         }
-        state->velocity = (Vector3){0, 0, 0};
+        state->velocity = ground;
+        state->speed = vector_magnitude(&ground);
         state->acceleration = (Vector3){0, 0, 0};
         return;
     }
@@ -458,3 +526,18 @@ void rocket_update_with_planet(RocketState* state, const RocketConfig* config,
 
     state->time += delta_time;
 }
+
+int rocket_update_n(RocketState* state, const RocketConfig* config,
+                    const ControlCommand* command, const PlanetConfig* planet,
+                    double delta_time, int steps) {
+    int done = 0;
+    while (done < steps && !state->landed && !state->crashed) {
+        bool had_fuel = state->fuel_remaining > 0;
+        rocket_update_with_planet(state, config, command, planet, delta_time);
+        done++;
+        if (had_fuel && state->fuel_remaining <= 0) {
+            break;
+        }
+    }
+    return done;
+}
@@ -31,6 +31,7 @@ typedef struct {
     double orbital_velocity;  // Текущая орбитальная скорость
     double required_velocity; // Необходимая скорость для круговой орбиты
     bool is_stable;           // Стабильна ли орбита (выше атмосферы)
+    double inclination;       // Наклонение к экватору планеты (градусы, 0-180)
 } OrbitPrediction;
 
 // Константы Земли по умолчанию
@@ -38,6 +39,11 @@ typedef struct {
 #define EARTH_MASS 5.972e24
 #define EARTH_ATMOSPHERE 100000.0
 #define EARTH_SCALE_HEIGHT 8500.0
+#define EARTH_ROTATION_PERIOD 86164.1 // Звездные сутки, с
+
+// Атмосфера на уровне моря при surface_pressure = 1.0
+#define SEA_LEVEL_DENSITY 1.225      // кг/м3
+#define SEA_LEVEL_PRESSURE 101325.0  // Па
 
 #ifndef M_PI
 #define M_PI 3.14159265358979323846
@@ -91,6 +97,8 @@ typedef struct {
     bool crashed;           // Разбилась ли
 
     double time;            // Время симуляции в секундах
+
+    double rotation_rate;   // Угловая скорость вращения планеты вокруг оси z, рад/с
 } RocketState;
 
 typedef struct {
@@ -117,6 +125,9 @@ Vector3 calculate_thrust(const RocketConfig* config, const ControlCommand* comma
 double calculate_fuel_consumption(const RocketConfig* config,
                                   const ControlCommand* command, double delta_time);
 
+Vector3 surface_velocity(const RocketState* state, const Vector3* position);
+void rocket_set_rotation(RocketState* state, double rotation_rate);
+
 bool check_ground_collision(const RocketState* state);
 bool check_orbital_stability(const RocketState* state);
 
@@ -148,6 +159,14 @@ void rocket_update_with_planet(RocketState* state, const RocketConfig* config,
                                const ControlCommand* command, const PlanetConfig* planet,
                                double delta_time);
 
+// rocket_update_n делает до steps шагов rocket_update_with_planet с одной
+// командой и возвращает число сделанных шагов. Останавливается после шага,
+// на котором ракета приземлилась, разбилась или выработала топливо.
+int rocket_update_n(RocketState* state, const RocketConfig* config,
+                    const ControlCommand* command, const PlanetConfig* planet,
+                    double delta_time, int steps);
+
 double orbital_velocity_at_altitude(const PlanetConfig* planet, double altitude);
+double atmosphere_density(const PlanetConfig* planet, double altitude);
 
 #endif // ROCKET_PHYSICS_H
@@ -0,0 +1,143 @@
+// Регрессионные проверки физического ядра: make check.
+// Каждая проверка ловит конкретную ошибку, которая уже была в движке.
+#include "rocket_physics.h"
+#include <math.h>
+#include <stdio.h>
+
+static int failures = 0;
+
+#define CHECK(cond, ...) do { \
+    if (!(cond)) { \
+        fprintf(stderr, "%s:%d: ", __FILE__, __LINE__); \
+        fprintf(stderr, __VA_ARGS__); \
+        fprintf(stderr, "\n"); \
+        failures++; \
+    } \
+} while (0)
+
+static Engine test_engines[1] = {
+    {.thrust = 500000.0, .fuel_consumption = 250.0, .is_active = true},
+};
+
+// test_config - одноступенчатая ракета массой mass_empty + mass_fuel
+static RocketConfig test_config(double mass_empty, double mass_fuel) {
+    RocketConfig config = {
+        .name = "Regression",
+        .mass_empty = mass_empty,
+        .mass_fuel = mass_fuel,
+        .mass_fuel_max = mass_fuel,
+        .fuel_type = FUEL_TYPE_KEROSENE,
+        .engines = test_engines,
+        .engine_count = 1,
+        .drag_coefficient = 0.5,
+        .cross_section = 10.0,
+    };
+    return config;
+}
+
+// Гравитация - ускорение, а не сила: ракета без тяги за 1 с выше атмосферы
+// набирает GM/r2 м/с к центру Земли при любой массе. Раньше ускорение
+// делилось на массу еще раз, и ракета почти не падала.
+static void test_free_fall(void) {
+    double throttle[1] = {0.0};
+    ControlCommand command = {.engine_throttle = throttle, .engine_count = 1};
+    double r = EARTH_RADIUS + 300000.0;
+    double g = G_CONSTANT * EARTH_MASS / (r * r);
+
+    double masses[] = {1000.0, 50000.0};
+    for (int i = 0; i < 2; i++) {
+        RocketConfig config = test_config(masses[i], 0.0);
+        RocketState* state = rocket_init(&config, (Vector3){r, 0, 0});
+        rocket_update(state, &config, &command, 1.0);
+        CHECK(fabs(state->velocity.x + g) < 1e-6 * g,
+              "масса %.0f кг: скорость после 1 с падения %.6f м/с, ожидалось %.6f",
+              masses[i], state->velocity.x, -g);
+        rocket_free(state);
+    }
+}
+
+// Тангаж 90 - горизонталь на восток, по вращению Земли вокруг оси z:
+// точка (R, 0, 0) движется к +y, точка (0, R, 0) - к -x. Раньше векторное
+// произведение было взято в обратном порядке, и ракета разгонялась на запад,
+// против вращения планеты.
+static void test_east_direction(void) {
+    double throttle[1] = {1.0};
+    ControlCommand command = {.engine_throttle = throttle, .engine_count = 1, .pitch = 90.0};
+    RocketConfig config = test_config(1000.0, 1000.0);
+    double thrust = test_engines[0].thrust;
+
+    Vector3 on_x = {EARTH_RADIUS, 0, 0};
+    Vector3 force = calculate_thrust(&config, &command, &on_x);
+    CHECK(fabs(force.y - thrust) < 1e-6 * thrust,
+          "тяга над (R, 0, 0): (%.1f, %.1f, %.1f), ожидалась (0, %.1f, 0)",
+          force.x, force.y, force.z, thrust);
+
+    Vector3 on_y = {0, EARTH_RADIUS, 0};
+    force = calculate_thrust(&config, &command, &on_y);
+    CHECK(fabs(force.x + thrust) < 1e-6 * thrust,
+          "тяга над (0, R, 0): (%.1f, %.1f, %.1f), ожидалась (%.1f, 0, 0)",
+          force.x, force.y, force.z, -thrust);
+}
+
+// То же для rocket_update_with_planet на безатмосферной планете размером с
+// Луну: за 1 с без тяги скорость к центру растет на GM/r2 при любой массе
+static void test_planet_free_fall(void) {
+    double throttle[1] = {0.0};
+    ControlCommand command = {.engine_throttle = throttle, .engine_count = 1};
+    PlanetConfig moon = planet_create(1737400.0, 7.342e22, 0.0, 0.0, 1.0);
+    double r = moon.radius + 100000.0;
+    double g = G_CONSTANT * moon.mass / (r * r);
+
+    double masses[] = {1000.0, 50000.0};
+    for (int i = 0; i < 2; i++) {
+        RocketConfig config = test_config(masses[i], 0.0);
+        RocketState* state = rocket_init(&config, (Vector3){r, 0, 0});
+        rocket_update_with_planet(state, &config, &command, &moon, 1.0);
+        CHECK(fabs(state->velocity.x + g) < 1e-6 * g,
+              "масса %.0f кг: скорость после 1 с падения на Луну %.6f м/с, ожидалось %.6f",
+              masses[i], state->velocity.x, -g);
+        rocket_free(state);
+    }
+}
+
+// Без топлива двигатели не работают: полная тяга с пустыми баками не
+// меняет свободного падения ни в rocket_update, ни в rocket_update_with_planet.
+// Раньше тяга считалась только по команде, и ракета летела без топлива.
+static void test_no_thrust_without_fuel(void) {
+    double throttle[1] = {1.0};
+    ControlCommand command = {.engine_throttle = throttle, .engine_count = 1};
+
+    double r = EARTH_RADIUS + 300000.0;
+    double g = G_CONSTANT * EARTH_MASS / (r * r);
+    RocketConfig config = test_config(1000.0, 0.0);
+    RocketState* state = rocket_init(&config, (Vector3){r, 0, 0});
+    rocket_update(state, &config, &command, 1.0);
+    CHECK(fabs(state->velocity.x + g) < 1e-6 * g,
+          "rocket_update без топлива: скорость %.6f м/с, ожидалось %.6f",
+          state->velocity.x, -g);
+    rocket_free(state);
+
+    PlanetConfig moon = planet_create(1737400.0, 7.342e22, 0.0, 0.0, 1.0);
+    r = moon.radius + 100000.0;
+    g = G_CONSTANT * moon.mass / (r * r);
+    state = rocket_init(&config, (Vector3){r, 0, 0});
+    rocket_update_with_planet(state, &config, &command, &moon, 1.0);
+    CHECK(fabs(state->velocity.x + g) < 1e-6 * g,
+          "rocket_update_with_planet без топлива: скорость %.6f м/с, ожидалось %.6f",
+          state->velocity.x, -g);
+    rocket_free(state);
+}
+
+int main(void) {
+    test_free_fall();
+    test_east_direction();
+    test_planet_free_fall();
+    test_no_thrust_without_fuel();
+
+    if (failures > 0) {
+        fprintf(stderr, "Проверок не прошло: %d\n", failures);
+        return 1;
+    }
+    printf("Регрессионные проверки пройдены\n");
+    return 0;
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"cosmodrom/protocol"
+)
+
+type ServerStatus struct {
+	Draining          bool `json:"draining"`
+	ShutdownWhenEmpty bool `json:"shutdown_when_empty"`
+	ActiveRockets     int  `json:"active_rockets"`
+	Observers         int  `json:"observers"`
+	// Записи журнала команд, не попавшие в audit.jsonl
+	AuditDropped uint64 `json:"audit_dropped"`
+
+	WSRateLimit *RateLimiterStats `json:"ws_rate_limit,omitempty"`
+}
+
+func (s *Server) status() ServerStatus {
+	s.mu.RLock()
+	status := ServerStatus{
+		Draining:          s.draining,
+		ShutdownWhenEmpty: s.shutdownWhenEmpty,
+		ActiveRockets:     len(s.rockets),
+		Observers:         len(s.observers),
+	}
+	s.mu.RUnlock()
+	status.AuditDropped = s.audit.Dropped()
+
+	if s.wsLimiter != nil {
+		stats := s.wsLimiter.Stats()
+		status.WSRateLimit = &stats
+	}
+	return status
+}
+
+func (s *Server) setDraining(draining, shutdownWhenEmpty bool) {
+	s.mu.Lock()
+	s.draining = draining
+	s.shutdownWhenEmpty = draining && shutdownWhenEmpty
+	active := len(s.rockets)
+	s.mu.Unlock()
+
+	if !draining {
+		serverLog("info", "drain_disabled", nil)
+		return
+	}
+
+	serverLog("warning", "drain_enabled", protocol.LogFields{"active": active})
+	if shutdownWhenEmpty && active == 0 {
+		go s.shutdown()
+	}
+}
+
+func (s *Server) shutdown() {
+	s.mu.RLock()
+	httpServer := s.httpServer
+	s.mu.RUnlock()
+	if httpServer == nil {
+		return
+	}
+	serverLog("info", "drain_shutdown", nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(ctx); err != nil {
+		serverLog("error", "shutdown_failed", protocol.LogFields{"error": err})
+	}
+}
+
+func (s *Server) handleDrain(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		s.setDraining(true, r.URL.Query().Get("shutdown_when_empty") == "true")
+	case http.MethodDelete:
+		s.setDraining(false, false)
+	default:
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.handleStatus(w, r)
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.status())
+}
@@ -0,0 +1,6 @@
+//go:build !unix
+
+package main
+
+// Без SIGUSR1 drain включается только через /api/admin/drain
+func (s *Server) watchDrainSignal() {}
@@ -0,0 +1,19 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// watchDrainSignal: SIGUSR1 включает drain, как POST /api/admin/drain
+func (s *Server) watchDrainSignal() {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGUSR1)
+	for range sigChan {
+		serverLog("info", "sigusr1", nil)
+		s.setDraining(true, false)
+	}
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"cosmodrom/protocol"
+)
+
+type CommandSource string
+
+const (
+	CommandSourceHTTP CommandSource = "http" // REST запрос оператора
+)
+
+const (
+	AuditStatusSent          = "sent"           // Команда записана в сокет ракеты
+	AuditStatusFailed        = "failed"         // Ошибка записи в сокет
+	AuditStatusUnknownRocket = "unknown_rocket" // Ракета не найдена
+)
+
+type AuditEntry struct {
+	ID        uint64                  `json:"id"`
+	Timestamp time.Time               `json:"timestamp"`
+	RocketID  string                  `json:"rocket_id"`
+	Source    CommandSource           `json:"source"`
+	Requester string                  `json:"requester,omitempty"`
+	Command   protocol.ControlCommand `json:"command"`
+	Attitude  *protocol.AttitudeHold  `json:"attitude,omitempty"`
+	// Дроссели по ID двигателя, если команда задала их так
+	EngineThrottleByID map[string]float64 `json:"engine_throttle_by_id,omitempty"`
+	Status             string             `json:"status"`
+	Error              string             `json:"error,omitempty"`
+}
+
+// Записи неизменяемы: дроссели и режим ориентации копируются при записи и
+// при чтении
+func (e AuditEntry) clone() AuditEntry {
+	e.Command.EngineThrottle = append([]float64(nil), e.Command.EngineThrottle...)
+	e.EngineThrottleByID = maps.Clone(e.EngineThrottleByID)
+	if e.Attitude != nil {
+		attitude := *e.Attitude
+		e.Attitude = &attitude
+	}
+	return e
+}
+
+type AuditLog struct {
+	entries []AuditEntry
+	maxSize int
+	nextID  uint64
+	fileCh  chan AuditEntry
+	dropped uint64 // Записей, не попавших в файл из-за переполненной очереди
+	mu      sync.RWMutex
+}
+
+func NewAuditLog(maxSize int) *AuditLog {
+	return &AuditLog{
+		entries: make([]AuditEntry, 0, maxSize),
+		maxSize: maxSize,
+	}
+}
+
+func (al *AuditLog) EnableFile(path string) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	ch := make(chan AuditEntry, 256)
+	al.mu.Lock()
+	al.fileCh = ch
+	al.mu.Unlock()
+
+	go func() {
+		defer file.Close()
+		encoder := json.NewEncoder(file)
+		for entry := range ch {
+			if err := encoder.Encode(entry); err != nil {
+				serverLog("error", "audit_write_failed", protocol.LogFields{"error": err})
+			}
+		}
+	}()
+
+	serverLog("info", "audit_file", protocol.LogFields{"path": path})
+	return nil
+}
+
+// Record не блокируется на диске: если очередь записи переполнена, запись
+// остается только в памяти
+func (al *AuditLog) Record(entry AuditEntry) AuditEntry {
+	entry = entry.clone()
+
+	al.mu.Lock()
+	al.nextID++
+	entry.ID = al.nextID
+	if len(al.entries) >= al.maxSize {
+		al.entries = al.entries[1:]
+	}
+	al.entries = append(al.entries, entry)
+	if al.fileCh != nil {
+		select {
+		case al.fileCh <- entry.clone():
+		default:
+			al.dropped++
+		}
+	}
+	al.mu.Unlock()
+
+	return entry.clone()
+}
+
+// Dropped - сколько записей не попало в файл журнала: они есть только в
+// памяти и пропадут после перезапуска
+func (al *AuditLog) Dropped() uint64 {
+	al.mu.RLock()
+	defer al.mu.RUnlock()
+	return al.dropped
+}
+
+func (al *AuditLog) GetByRocket(rocketID string, since time.Time) []AuditEntry {
+	al.mu.RLock()
+	defer al.mu.RUnlock()
+	result := make([]AuditEntry, 0)
+	for _, entry := range al.entries {
+		matchesRocket := rocketID == "" || entry.RocketID == rocketID
+		matchesTime := since.IsZero() || entry.Timestamp.After(since)
+		if matchesRocket && matchesTime {
+			result = append(result, entry.clone())
+		}
+	}
+	return result
+}
+
+func (s *Server) sendCommand(commandMsg protocol.CommandMessage, source CommandSource, requester string) AuditEntry {
+	rocketID := commandMsg.RocketID
+	entry := AuditEntry{
+		Timestamp: s.clock.Now(),
+		RocketID:  rocketID,
+		Source:    source,
+		Requester: requester,
+		Command:   commandMsg.Command,
+		Attitude:  commandMsg.Attitude,
+		Status:    AuditStatusSent,
+
+		EngineThrottleByID: commandMsg.EngineThrottleByID,
+	}
+
+	s.mu.RLock()
+	rocket, exists := s.rockets[rocketID]
+	s.mu.RUnlock()
+
+	if !exists {
+		entry.Status = AuditStatusUnknownRocket
+		return s.audit.Record(entry)
+	}
+
+	err := s.sendToRocket(rocket, protocol.MsgTypeCommand, commandMsg)
+	if err != nil {
+		entry.Status = AuditStatusFailed
+		entry.Error = err.Error()
+	}
+
+	entry = s.audit.Record(entry)
+	connLog(rocket.ConnID, rocketID, "info", "command_sent", protocol.LogFields{
+		"command_id": entry.ID, "source": source, "rocket_id": rocketID, "status": entry.Status,
+	})
+	return entry
+}
+
+func (s *Server) handleCommand(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "invalid command: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	var commandMsg protocol.CommandMessage
+	if err := protocol.DecodeStrict(body, &commandMsg); err != nil {
+		http.Error(w, "invalid command: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := protocol.ValidateCommand(&commandMsg); err != nil {
+		http.Error(w, "invalid command: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if commandMsg.Attitude != nil {
+		if err := protocol.ValidateAttitudeHold(commandMsg.Attitude); err != nil {
+			http.Error(w, "invalid command: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := s.checkActions(&commandMsg); err != nil {
+		http.Error(w, "command rejected: "+err.Error(), http.StatusConflict)
+		return
+	}
+
+	entry := s.sendCommand(commandMsg, CommandSourceHTTP, requester(r))
+
+	w.Header().Set("Content-Type", "application/json")
+	switch entry.Status {
+	case AuditStatusUnknownRocket:
+		w.WriteHeader(http.StatusNotFound)
+	case AuditStatusFailed:
+		w.WriteHeader(http.StatusBadGateway)
+	}
+	json.NewEncoder(w).Encode(entry)
+}
+
+// checkActions проверяет действия команды по последней телеметрии ракеты:
+// отделить последнюю ступень или сбросить нагрузку второй раз нельзя.
+// Неизвестную ракету проверяет sendCommand.
+func (s *Server) checkActions(commandMsg *protocol.CommandMessage) error {
+	s.mu.RLock()
+	rocket, exists := s.rockets[commandMsg.RocketID]
+	s.mu.RUnlock()
+	if !exists {
+		return nil
+	}
+
+	rocket.mu.RLock()
+	defer rocket.mu.RUnlock()
+	return protocol.ValidateActions(&commandMsg.Command, &rocket.Config, &rocket.State)
+}
+
+func (s *Server) handleAudit(w http.ResponseWriter, r *http.Request) {
+	rocketID := r.URL.Query().Get("rocket_id")
+
+	var since time.Time
+	if sinceStr := r.URL.Query().Get("since"); sinceStr != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, sinceStr)
+		if err == nil {
+			since = parsed
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.audit.GetByRocket(rocketID, since))
+}
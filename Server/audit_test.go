@@ -0,0 +1,170 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"cosmodrom/protocol"
+)
+
+func TestHandleCommandEngineIDs(t *testing.T) {
+	s := NewServer(protocol.JSON)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{name: "по ID", body: `{"rocket_id":"r1","command":{"pitch":5},"engine_throttle_by_id":{"center":0.5}}`, wantStatus: http.StatusNotFound},
+		{name: "списком и по ID", body: `{"rocket_id":"r1","command":{"engine_throttle":[1]},"engine_throttle_by_id":{"center":0.5}}`, wantStatus: http.StatusBadRequest},
+		{name: "дроссель больше 1", body: `{"rocket_id":"r1","engine_throttle_by_id":{"center":2}}`, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.handleCommand(w, httptest.NewRequest(http.MethodPost, "/api/command", strings.NewReader(tt.body)))
+			if w.Code != tt.wantStatus {
+				t.Errorf("статус %d, ожидался %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+		})
+	}
+
+	// Ракеты нет, но команда попала в журнал вместе с дросселями по ID
+	entries := s.audit.GetByRocket("r1", time.Time{})
+	if len(entries) != 1 || entries[0].EngineThrottleByID["center"] != 0.5 {
+		t.Errorf("журнал команд %+v", entries)
+	}
+}
+
+// Невыполнимое действие отклоняется по последней телеметрии ракеты
+func TestHandleCommandActions(t *testing.T) {
+	s := NewServer(protocol.JSON)
+	engine := protocol.Engine{Thrust: 1e5, FuelConsumption: 30, IsActive: true}
+	s.rockets["r1"] = &RocketConnection{
+		ID: "r1",
+		Config: protocol.RocketConfig{
+			Stages: []protocol.Stage{
+				{MassEmpty: 1000, MassFuel: 4000, Engines: []protocol.Engine{engine}},
+				{MassEmpty: 300, MassFuel: 700, Engines: []protocol.Engine{engine}},
+			},
+			Payload: &protocol.Payload{Mass: 50},
+		},
+		State: protocol.RocketState{Stage: 2},
+	}
+
+	for _, body := range []string{
+		`{"rocket_id":"r1","command":{"stage_separate":true}}`,
+		`{"rocket_id":"r1","command":{"deploy_parachute":true}}`,
+	} {
+		w := httptest.NewRecorder()
+		s.handleCommand(w, httptest.NewRequest(http.MethodPost, "/api/command", strings.NewReader(body)))
+		if w.Code != http.StatusConflict {
+			t.Errorf("%s: статус %d, ожидался 409", body, w.Code)
+		}
+	}
+	if entries := s.audit.GetByRocket("r1", time.Time{}); len(entries) != 0 {
+		t.Errorf("отклоненная команда попала в журнал: %+v", entries)
+	}
+
+	payload := protocol.CommandMessage{RocketID: "r1", Command: protocol.ControlCommand{DeployPayload: true}}
+	if err := s.checkActions(&payload); err != nil {
+		t.Errorf("сброс нагрузки отклонен: %v", err)
+	}
+	s.rockets["r1"].State.PayloadDeployed = true
+	if err := s.checkActions(&payload); err == nil {
+		t.Error("повторный сброс нагрузки принят")
+	}
+}
+
+// С токеном администратора команда без токена или с чужим токеном не
+// доходит до ракеты и не попадает в журнал
+func TestCommandRequiresAdminToken(t *testing.T) {
+	s := NewServer(protocol.JSON)
+	s.adminToken = "secret"
+	routes := s.routes()
+
+	body := `{"rocket_id":"r1","command":{"pitch":5}}`
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+	}{
+		{name: "без токена", wantStatus: http.StatusUnauthorized},
+		{name: "чужой токен", authorization: "Bearer guess", wantStatus: http.StatusUnauthorized},
+		{name: "токен администратора", authorization: "Bearer secret", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/command", strings.NewReader(body))
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			routes.ServeHTTP(w, r)
+			if w.Code != tt.wantStatus {
+				t.Errorf("статус %d, ожидался %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+		})
+	}
+
+	entries := s.audit.GetByRocket("r1", time.Time{})
+	if len(entries) != 1 {
+		t.Fatalf("в журнале %d команд, ожидалась одна с токеном: %+v", len(entries), entries)
+	}
+	// С токеном в журнал попадает личность администратора, а не адрес сокета
+	if entries[0].Requester != adminIdentity {
+		t.Errorf("requester %q, ожидался %q", entries[0].Requester, adminIdentity)
+	}
+
+	// Журнал команд тоже только для администратора
+	for _, tt := range []struct {
+		authorization string
+		wantStatus    int
+	}{
+		{wantStatus: http.StatusUnauthorized},
+		{authorization: "Bearer guess", wantStatus: http.StatusUnauthorized},
+		{authorization: "Bearer secret", wantStatus: http.StatusOK},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/api/audit?rocket_id=r1", nil)
+		if tt.authorization != "" {
+			r.Header.Set("Authorization", tt.authorization)
+		}
+		w := httptest.NewRecorder()
+		routes.ServeHTTP(w, r)
+		if w.Code != tt.wantStatus {
+			t.Errorf("/api/audit с %q: статус %d, ожидался %d", tt.authorization, w.Code, tt.wantStatus)
+		}
+	}
+}
+
+// Запись журнала не меняется вместе с командой, из которой она сделана
+func TestAuditLogCopiesAttitude(t *testing.T) {
+	al := NewAuditLog(10)
+	attitude := &protocol.AttitudeHold{Mode: protocol.AttitudeSurfacePitch, Pitch: 30}
+	returned := al.Record(AuditEntry{RocketID: "r1", Attitude: attitude})
+
+	attitude.Pitch = 60
+	returned.Attitude.Mode = protocol.AttitudeRetrograde
+	entries := al.GetByRocket("r1", time.Time{})
+	if len(entries) != 1 || *entries[0].Attitude != (protocol.AttitudeHold{Mode: protocol.AttitudeSurfacePitch, Pitch: 30}) {
+		t.Fatalf("в журнале %+v", entries)
+	}
+}
+
+// Записи, не попавшие в файл журнала, видны в /api/status
+func TestAuditDroppedInStatus(t *testing.T) {
+	s := NewServer(protocol.JSON)
+	s.audit.fileCh = make(chan AuditEntry) // Очередь файла, которую никто не читает
+	s.audit.Record(AuditEntry{RocketID: "r1"})
+	s.audit.Record(AuditEntry{RocketID: "r1"})
+
+	if status := s.status(); status.AuditDropped != 2 {
+		t.Errorf("audit_dropped %d, ожидалось 2", status.AuditDropped)
+	}
+	if entries := s.audit.GetByRocket("r1", time.Time{}); len(entries) != 2 {
+		t.Errorf("в памяти %d записей, ожидалось 2", len(entries))
+	}
+}
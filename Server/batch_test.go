@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"cosmodrom/protocol"
+)
+
+func batchMessage(t *testing.T, seq uint64, states ...string) protocol.Message {
+	t.Helper()
+	data := json.RawMessage(`{"rocket_id":"r1","states":[` + strings.Join(states, ",") + `]}`)
+	return protocol.Message{Type: protocol.MsgTypeTelemetryBatch, Seq: seq, Data: data}
+}
+
+// Кадры пакета учитываются по порядку, испорченный кадр пропускается,
+// а состоянием ракеты становится последний кадр
+func TestHandleTelemetryBatch(t *testing.T) {
+	s := NewServer(protocol.JSON)
+	rc := &RocketConnection{ID: "r1"}
+
+	s.handleTelemetryBatch(rc, batchMessage(t, 1,
+		`{"time":1,"altitude":100}`,
+		`{"time":"два"}`,
+		`{"time":3,"altitude":300}`,
+	))
+
+	if rc.Stats.TelemetryCount != 2 {
+		t.Errorf("учтено кадров %d, ожидалось 2", rc.Stats.TelemetryCount)
+	}
+	if rc.State.Time != 3 || rc.State.Altitude != 300 {
+		t.Errorf("состояние %+v, ожидался последний кадр", rc.State)
+	}
+	if rc.broadcastSeq != 1 {
+		t.Errorf("broadcastSeq %d, ожидалась одна рассылка на пакет", rc.broadcastSeq)
+	}
+
+	// Повтор пакета отбрасывается целиком
+	s.handleTelemetryBatch(rc, batchMessage(t, 1, `{"time":4}`))
+	if rc.Stats.DroppedFrames != 1 || rc.State.Time != 3 {
+		t.Errorf("повтор: отброшено %d, время %g", rc.Stats.DroppedFrames, rc.State.Time)
+	}
+}
+
+func TestHandleTelemetryBatchLimit(t *testing.T) {
+	s := NewServer(protocol.JSON)
+	rc := &RocketConnection{ID: "r1"}
+
+	states := make([]string, protocol.MaxBatchStates+1)
+	for i := range states {
+		states[i] = `{"time":1}`
+	}
+	s.handleTelemetryBatch(rc, batchMessage(t, 1, states...))
+
+	if rc.Stats.TelemetryCount != 0 || rc.broadcastSeq != 0 {
+		t.Errorf("пакет сверх предела принят: %d кадров", rc.Stats.TelemetryCount)
+	}
+}
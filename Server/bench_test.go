@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"cosmodrom/protocol"
+
+	"github.com/gorilla/websocket"
+)
+
+// benchState - кадр телеметрии, как его шлет клиент на участке выведения
+var benchState = protocol.RocketState{
+	Position:              protocol.Vector3{X: 4521340.5, Y: 2260670.25, Z: 4436512.75},
+	Velocity:              protocol.Vector3{X: -1520.5, Y: 3040.25, Z: 1.5},
+	Acceleration:          protocol.Vector3{X: 5.5, Y: 12.25, Z: -0.5},
+	Altitude:              85123.5,
+	Speed:                 3400.75,
+	MassCurrent:           41250.5,
+	FuelRemaining:         21250.5,
+	Time:                  123.45,
+	OrbitApoapsis:         150321.5,
+	OrbitPeriapsis:        -5012345.5,
+	OrbitEccentricity:     0.87,
+	OrbitRequiredVelocity: 7790.5,
+	OrbitInclination:      51.6,
+	GForce:                2.4,
+	DynamicPressure:       1250.5,
+	VerticalSpeed:         850.25,
+	Latitude:              45.9,
+	Longitude:             63.3,
+	GroundSpeed:           3100.5,
+	EngineStatus: []protocol.EngineStatus{
+		{ID: "rd-1", Throttle: 1, Active: true, BurnTime: 123.45},
+		{ID: "rd-2", Throttle: 1, Active: true, BurnTime: 123.45},
+	},
+}
+
+// telemetryFrames собирает кадры telemetry с растущим seq в один буфер без
+// выделений памяти: сервер отбрасывает повторный seq
+type telemetryFrames struct {
+	prefix, suffix []byte
+	buf            []byte
+}
+
+func newTelemetryFrames(b testing.TB, rocketID string) *telemetryFrames {
+	data, err := json.Marshal(protocol.TelemetryMessage{RocketID: rocketID, State: benchState})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return &telemetryFrames{
+		prefix: []byte(`{"type":"telemetry","timestamp":"2026-01-01T00:00:00Z","seq":`),
+		suffix: append(append([]byte(`,"data":`), data...), '}'),
+	}
+}
+
+func (f *telemetryFrames) frame(seq uint64) []byte {
+	f.buf = append(f.buf[:0], f.prefix...)
+	f.buf = strconv.AppendUint(f.buf, seq, 10)
+	f.buf = append(f.buf, f.suffix...)
+	return f.buf
+}
+
+// benchServer - сервер с зарегистрированной ракетой и наблюдателями: на
+// каждое третье соединение наблюдателя приходится CBOR
+func benchServer(b testing.TB, observers int) (*Server, *clientSession) {
+	quietLog(b)
+	s := NewServer(protocol.JSON)
+	s.msgRate = 0
+
+	for i := 0; i < observers; i++ {
+		session := s.newClientSession(testConn(b), fmt.Sprintf("observer-%d", i))
+		subscribe, frameType := []byte(fmt.Sprintf(`{"type":"subscribe","data":{"observer_id":"o%d"}}`, i)), websocket.TextMessage
+		if i%3 == 2 {
+			payload, err := protocol.CBOR.Encode(protocol.Message{Type: protocol.MsgTypeSubscribe, Data: protocol.SubscribeMessage{ObserverID: fmt.Sprintf("o%d", i)}})
+			if err != nil {
+				b.Fatal(err)
+			}
+			subscribe, frameType = payload, websocket.BinaryMessage
+		}
+		s.handleMessage(session, frameType, subscribe)
+	}
+
+	rocket := s.newClientSession(testConn(b), "rocket")
+	s.handleMessage(rocket, websocket.TextMessage, []byte(fuzzRegister))
+	if rocket.rocket == nil {
+		b.Fatal("ракета не зарегистрирована")
+	}
+	return s, rocket
+}
+
+// Кадр телеметрии от разбора до рассылки всем наблюдателям
+func BenchmarkTelemetryBroadcast(b *testing.B) {
+	for _, observers := range []int{0, 1, 10, 100} {
+		b.Run(fmt.Sprintf("observers=%d", observers), func(b *testing.B) {
+			s, rocket := benchServer(b, observers)
+			frames := newTelemetryFrames(b, rocket.rocket.ID)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				s.handleMessage(rocket, websocket.TextMessage, frames.frame(uint64(i)+2))
+			}
+		})
+	}
+}
+
+// Без наблюдателей кадр телеметрии выделяет память только под сами данные:
+// значение Data и срез двигателей. Конверт, буфер Data и значение для
+// разбора берутся из пулов, кадр рассылки не упаковывается.
+func TestTelemetryAllocsWithoutObservers(t *testing.T) {
+	if raceEnabled {
+		t.Skip("с -race sync.Pool не переиспользует значения")
+	}
+	s, rocket := benchServer(t, 0)
+	frames := newTelemetryFrames(t, rocket.rocket.ID)
+	seq := uint64(2)
+	allocs := testing.AllocsPerRun(1000, func() {
+		s.handleMessage(rocket, websocket.TextMessage, frames.frame(seq))
+		seq++
+	})
+	if allocs > 3 {
+		t.Errorf("%g выделений памяти на кадр, ожидалось не больше 3", allocs)
+	}
+}
+
+// Рассылка готового кадра без разбора телеметрии
+func BenchmarkBroadcastToObservers(b *testing.B) {
+	for _, observers := range []int{1, 10, 100} {
+		b.Run(fmt.Sprintf("observers=%d", observers), func(b *testing.B) {
+			s, rocket := benchServer(b, observers)
+			message := protocol.BroadcastMessage{RocketID: rocket.rocket.ID, Name: "Тест", State: benchState}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				s.broadcastToObservers(rocket.rocket.ID, nil, protocol.MsgTypeBroadcast, uint64(i), message)
+			}
+		})
+	}
+}
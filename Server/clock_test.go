@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"cosmodrom/protocol"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func telemetryAt(seq uint64, sent time.Time) protocol.Message {
+	return protocol.Message{
+		Type:      protocol.MsgTypeTelemetry,
+		Timestamp: sent,
+		Seq:       seq,
+		Data:      protocol.TelemetryMessage{RocketID: "r1", State: protocol.RocketState{Time: float64(seq)}},
+	}
+}
+
+// Задержка доставки считается по меткам времени ракеты, спешащие часы
+// ракеты дают 0, а расхождение отмечается один раз за соединение
+func TestTelemetryTransitDelay(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	s := NewServer(protocol.JSON)
+	s.clock = clock
+	rc := &RocketConnection{ID: "r1"}
+
+	s.handleTelemetry(rc, telemetryAt(1, clock.now.Add(-40*time.Millisecond)))
+	if rc.Stats.TransitDelayMs != 40 || rc.skewLogged {
+		t.Fatalf("задержка %g мс (расхождение %v), ожидалось 40 мс", rc.Stats.TransitDelayMs, rc.skewLogged)
+	}
+	if !rc.LastUpdate.Equal(clock.now) {
+		t.Errorf("LastUpdate %v не по часам сервера", rc.LastUpdate)
+	}
+
+	frame, ok, _ := rc.record(clock.now, telemetryAt(2, clock.now.Add(time.Minute)), []protocol.RocketState{{Time: 2}})
+	if !ok || rc.Stats.TransitDelayMs != 0 {
+		t.Fatalf("спешащие часы: задержка %g мс", rc.Stats.TransitDelayMs)
+	}
+	if frame.clockSkew != -time.Minute || !rc.skewLogged {
+		t.Errorf("расхождение %v, ожидалось -1m", frame.clockSkew)
+	}
+
+	frame, _, _ = rc.record(clock.now, telemetryAt(3, clock.now.Add(time.Minute)), []protocol.RocketState{{Time: 3}})
+	if frame.clockSkew != 0 {
+		t.Errorf("расхождение отмечено повторно: %v", frame.clockSkew)
+	}
+
+	// Старый клиент без меток времени задержку не меняет
+	rc.record(clock.now, telemetryAt(4, time.Time{}), []protocol.RocketState{{Time: 4}})
+	if rc.Stats.TransitDelayMs != 0 || rc.Stats.TelemetryCount != 4 {
+		t.Errorf("без метки: задержка %g мс, кадров %d", rc.Stats.TransitDelayMs, rc.Stats.TelemetryCount)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"cosmodrom/protocol"
+
+	"github.com/gorilla/websocket"
+)
+
+func readFrame(t *testing.T, conn *websocket.Conn, codec protocol.Codec) protocol.Message {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	frame, payload, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if frame != frameType(codec) {
+		t.Fatalf("кадр %d, ожидался %d", frame, frameType(codec))
+	}
+	msg, err := codec.Decode(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+// Ракета с -codec cbor регистрируется двоичными кадрами и получает ответы
+// в CBOR, а наблюдатель на том же сервере продолжает говорить JSON
+func TestServerCBORConnection(t *testing.T) {
+	s := NewServer(protocol.CBOR)
+	observer := dialTestServer(t, s)
+	if err := observer.WriteJSON(protocol.Message{Type: protocol.MsgTypeSubscribe,
+		Data: protocol.SubscribeMessage{ObserverID: "o1"}}); err != nil {
+		t.Fatal(err)
+	}
+	// Подписка обрабатывается до регистрации ракеты
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		s.mu.RLock()
+		subscribed := len(s.observers) == 1
+		s.mu.RUnlock()
+		if subscribed || time.Now().After(deadline) {
+			break
+		}
+	}
+
+	rocket := dialTestServer(t, s)
+	register, err := protocol.CBOR.Encode(protocol.Message{Type: protocol.MsgTypeRegister, Data: protocol.RegisterMessage{
+		RocketID: "r1",
+		Config: protocol.RocketConfig{Name: "Тест", MassEmpty: 1000, MassFuel: 500, MassFuelMax: 500, DragCoefficient: 0.3, CrossSection: 1,
+			Engines: []protocol.Engine{{Thrust: 30000, FuelConsumption: 10, IsActive: true}}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rocket.WriteMessage(websocket.BinaryMessage, register); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := readFrame(t, rocket, protocol.CBOR)
+	if accepted, ok := msg.Data.(protocol.AcceptedMessage); msg.Type != protocol.MsgTypeAccepted || !ok || accepted.RocketID != "r1" {
+		t.Fatalf("ответ %s %#v, ожидался accepted", msg.Type, msg.Data)
+	}
+	msg = readFrame(t, observer, protocol.JSON)
+	if joined, ok := msg.Data.(protocol.RocketJoinedMessage); msg.Type != protocol.MsgTypeRocketJoined || !ok || joined.Config.Name != "Тест" {
+		t.Fatalf("наблюдатель получил %s %#v, ожидался rocket_joined", msg.Type, msg.Data)
+	}
+}
+
+func TestServerRejectsBinaryWithJSONCodec(t *testing.T) {
+	conn := dialTestServer(t, NewServer(protocol.JSON))
+	payload, _ := protocol.CBOR.Encode(protocol.Message{Type: protocol.MsgTypeHeartbeat})
+	if err := conn.WriteMessage(websocket.BinaryMessage, payload); err != nil {
+		t.Fatal(err)
+	}
+	if errMsg := readError(t, conn); errMsg.Code != protocol.ErrorCodeDecode || !strings.Contains(errMsg.Detail, "двоичные") {
+		t.Errorf("ошибка %+v, ожидался decode_error о двоичных кадрах", errMsg)
+	}
+}
+
+// Испорченный кадр в CBOR-пакете не теряет остальные: кодек оставляет
+// данные в общем виде, и сервер разбирает кадры по одному
+func TestHandleTelemetryBatchCBOR(t *testing.T) {
+	s := NewServer(protocol.CBOR)
+	rc := &RocketConnection{ID: "r1"}
+
+	payload, err := protocol.CBOR.Encode(protocol.Message{Type: protocol.MsgTypeTelemetryBatch, Seq: 1, Data: map[string]interface{}{
+		"rocket_id": "r1",
+		"states":    []interface{}{map[string]interface{}{"time": 1.0}, map[string]interface{}{"time": "два"}, map[string]interface{}{"time": 3.0}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := protocol.CBOR.Decode(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.handleTelemetryBatch(rc, msg); err != nil {
+		t.Fatal(err)
+	}
+	if rc.Stats.TelemetryCount != 2 || rc.State.Time != 3 {
+		t.Errorf("учтено кадров %d, время %g", rc.Stats.TelemetryCount, rc.State.Time)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"cosmodrom/protocol"
+
+	"gopkg.in/yaml.v3"
+)
+
+// serverConfig - файл -config. Пока в нем только каталог ракет.
+type serverConfig struct {
+	// Vehicles - конфигурации, которые клиент запрашивает по имени (-vehicle).
+	// Поля ракеты те же, что в регистрации: mass_empty, engines, stages и т.д.
+	Vehicles map[string]protocol.RocketConfig
+}
+
+// loadServerConfig читает YAML и проверяет каждую ракету каталога так же,
+// как регистрацию. Ошибка в любой ракете останавливает запуск сервера.
+func loadServerConfig(path string) (serverConfig, error) {
+	config := serverConfig{Vehicles: make(map[string]protocol.RocketConfig)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, fmt.Errorf("не удалось прочитать %s: %w", path, err)
+	}
+
+	var raw struct {
+		Vehicles map[string]map[string]interface{} `yaml:"vehicles"`
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&raw); err != nil {
+		return config, fmt.Errorf("некорректный файл %s: %w", path, err)
+	}
+
+	names := make([]string, 0, len(raw.Vehicles))
+	for name := range raw.Vehicles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		vehicle, err := decodeVehicle(raw.Vehicles[name])
+		if err != nil {
+			return config, fmt.Errorf("vehicles.%s: %w", name, err)
+		}
+		if vehicle.Name == "" {
+			vehicle.Name = name
+		}
+		if vehicle.MassFuelMax == 0 {
+			vehicle.MassFuelMax = vehicle.MassFuel
+		}
+		vehicle.ApplyStages()
+		if err := protocol.ValidateRocketConfig(&vehicle); err != nil {
+			return config, fmt.Errorf("vehicles.%s: %w", name, err)
+		}
+		config.Vehicles[name] = vehicle
+	}
+	return config, nil
+}
+
+// decodeVehicle переводит ракету из YAML в RocketConfig через JSON, чтобы
+// имена полей совпадали с протоколом; неизвестные поля - ошибка
+func decodeVehicle(raw map[string]interface{}) (protocol.RocketConfig, error) {
+	var vehicle protocol.RocketConfig
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return vehicle, err
+	}
+	err = protocol.DecodeStrict(data, &vehicle)
+	return vehicle, err
+}
+
+// handleConfigRequest отвечает конфигурацией ракеты из каталога. Клиент
+// присылает запрос до регистрации, поэтому ответ идет прямо в соединение.
+func (s *Server) handleConfigRequest(session *clientSession, codec protocol.Codec, msg protocol.Message) error {
+	connID := session.connID
+	request, err := protocol.DecodeData[protocol.ConfigRequestMessage](msg)
+	if err != nil {
+		return err
+	}
+
+	vehicle, ok := s.vehicles[request.Vehicle]
+	if !ok {
+		s.sendToSession(session, codec, protocol.MsgTypeRejected, protocol.RejectedMessage{
+			RocketID: request.RocketID,
+			Code:     protocol.RejectCodeUnknownVehicle,
+			Reason:   fmt.Sprintf("ракеты %q нет в каталоге сервера", request.Vehicle),
+		})
+		connLog(connID, "", "warning", "vehicle_unknown", protocol.LogFields{"rocket_id": request.RocketID, "vehicle": request.Vehicle})
+		return nil
+	}
+
+	s.sendToSession(session, codec, protocol.MsgTypeConfigResponse, protocol.ConfigResponseMessage{
+		RocketID: request.RocketID,
+		Vehicle:  request.Vehicle,
+		Config:   vehicle,
+	})
+	connLog(connID, "", "info", "vehicle_sent", protocol.LogFields{"rocket_id": request.RocketID, "vehicle": request.Vehicle})
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "server.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadServerConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "плоская ракета", content: `
+vehicles:
+  test:
+    mass_empty: 20000
+    mass_fuel: 400000
+    fuel_type: kerosene
+    drag_coefficient: 0.3
+    cross_section: 12
+    engines:
+      - {thrust: 7600000, fuel_consumption: 2500, is_active: true}
+`},
+		{name: "ступени", content: `
+vehicles:
+  two-stage:
+    name: Two Stage
+    drag_coefficient: 0.3
+    cross_section: 3
+    stages:
+      - {mass_empty: 1800, mass_fuel: 21000, engines: [{thrust: 450000, fuel_consumption: 160, is_active: true}]}
+      - {mass_empty: 500, mass_fuel: 3400, engines: [{thrust: 31000, fuel_consumption: 10, is_active: true}]}
+`},
+		{name: "пустой каталог", content: "vehicles: {}\n"},
+		{name: "неизвестный раздел", content: "rockets: {}\n", wantErr: "rockets"},
+		{name: "неизвестное поле ракеты", content: `
+vehicles:
+  test:
+    mass: 1000
+`, wantErr: "vehicles.test"},
+		{name: "опечатка в двигателе ступени", content: `
+vehicles:
+  test:
+    stages:
+      - {mass_empty: 500, mass_fuel: 3400, engines: [{thrust: 31000, fuel_consumtion: 10}]}
+`, wantErr: `vehicles.test: неизвестное поле "stages[0].engines[0].fuel_consumtion"`},
+		{name: "некорректная ракета", content: `
+vehicles:
+  broken:
+    mass_empty: 1000
+    cross_section: 1
+`, wantErr: "vehicles.broken: engines"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := loadServerConfig(writeConfig(t, tt.content))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ошибка %v, ожидалась содержащая %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, vehicle := range config.Vehicles {
+				if vehicle.Name == "" {
+					t.Errorf("у ракеты %s пустое название", name)
+				}
+				if len(vehicle.Engines) == 0 {
+					t.Errorf("у ракеты %s нет двигателей после ApplyStages", name)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadServerConfigDefaults(t *testing.T) {
+	config, err := loadServerConfig(writeConfig(t, `
+vehicles:
+  test:
+    mass_empty: 20000
+    mass_fuel: 400000
+    cross_section: 12
+    engines: [{thrust: 7600000, fuel_consumption: 2500, is_active: true}]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vehicle := config.Vehicles["test"]
+	if vehicle.Name != "test" {
+		t.Errorf("название %q, ожидалось имя в каталоге", vehicle.Name)
+	}
+	if vehicle.MassFuelMax != vehicle.MassFuel {
+		t.Errorf("mass_fuel_max %.0f, ожидалось равным mass_fuel", vehicle.MassFuelMax)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cosmodrom/protocol"
+)
+
+func TestCORS(t *testing.T) {
+	const allowed, foreign = "https://mcc.example", "https://evil.example"
+
+	tests := []struct {
+		name          string
+		origins       []string
+		adminToken    string
+		observerToken string
+		method        string
+		origin        string
+		preflight     bool
+		wantStatus    int
+		wantOrigin    string // Access-Control-Allow-Origin, пусто - заголовка нет
+	}{
+		{name: "preflight разрешенного источника", origins: []string{allowed}, method: http.MethodOptions, origin: allowed, preflight: true, wantStatus: http.StatusNoContent, wantOrigin: allowed},
+		{name: "preflight чужого источника", origins: []string{allowed}, method: http.MethodOptions, origin: foreign, preflight: true, wantStatus: http.StatusForbidden},
+		{name: "запрос разрешенного источника", origins: []string{allowed}, method: http.MethodGet, origin: allowed, wantStatus: http.StatusOK, wantOrigin: allowed},
+		// Сервер отвечает, но без заголовка браузер не отдаст ответ странице
+		{name: "запрос чужого источника", origins: []string{allowed}, method: http.MethodGet, origin: foreign, wantStatus: http.StatusOK},
+		{name: "без списка источников", method: http.MethodGet, origin: foreign, wantStatus: http.StatusOK, wantOrigin: "*"},
+		{name: "без списка, но с токеном администратора", adminToken: "secret", method: http.MethodGet, origin: foreign, wantStatus: http.StatusOK, wantOrigin: foreign},
+		{name: "без списка, но с токеном наблюдателя", observerToken: "watch", method: http.MethodGet, origin: foreign, wantStatus: http.StatusOK, wantOrigin: foreign},
+		{name: "не браузер", origins: []string{allowed}, method: http.MethodGet, wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer(protocol.JSON)
+			s.allowedOrigins = tt.origins
+			s.adminToken = tt.adminToken
+			s.observerToken = tt.observerToken
+
+			r := httptest.NewRequest(tt.method, "/api/status", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if tt.preflight {
+				r.Header.Set("Access-Control-Request-Method", http.MethodPost)
+				r.Header.Set("Access-Control-Request-Headers", "authorization, content-type")
+			}
+			w := httptest.NewRecorder()
+			s.routes().ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("статус %d, ожидался %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin %q, ожидался %q", got, tt.wantOrigin)
+			}
+			if tt.wantOrigin != "" && tt.wantOrigin != "*" && w.Header().Get("Vary") != "Origin" {
+				t.Errorf("ответ для источника %s без Vary: Origin", tt.wantOrigin)
+			}
+			if tt.preflight && tt.wantStatus == http.StatusNoContent {
+				if w.Header().Get("Access-Control-Allow-Methods") != "GET, POST, DELETE, OPTIONS" ||
+					w.Header().Get("Access-Control-Allow-Headers") != "Content-Type, Authorization" {
+					t.Errorf("preflight разрешает методы %q и заголовки %q",
+						w.Header().Get("Access-Control-Allow-Methods"), w.Header().Get("Access-Control-Allow-Headers"))
+				}
+			}
+		})
+	}
+}
+
+// Панель подключается к /ws со своего хоста при любом списке источников,
+// чужая страница - только из списка
+func TestWebSocketOrigin(t *testing.T) {
+	s := NewServer(protocol.JSON)
+	s.allowedOrigins = []string{"https://mcc.example"}
+
+	for _, tt := range []struct {
+		origin string
+		want   bool
+	}{
+		{"", true},
+		{"http://example.com", true}, // Хост запроса httptest
+		{"https://mcc.example", true},
+		{"https://evil.example", false},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/ws", nil)
+		if tt.origin != "" {
+			r.Header.Set("Origin", tt.origin)
+		}
+		if got := s.checkWebSocketOrigin(r); got != tt.want {
+			t.Errorf("источник %q: разрешен %v, ожидалось %v", tt.origin, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"os"
+
+	"cosmodrom/protocol"
+)
+
+// Панель на / собрана из шаблона templates/index.html и файлов static/,
+// вшитых в бинарник. -static-dir подменяет их каталогом с тем же
+// устройством.
+//
+//go:embed templates static
+var dashboardFiles embed.FS
+
+// dashboardConfig - значения сервера, которые шаблон передает в скрипт панели
+// как объект dashboardConfig
+type dashboardConfig struct {
+	WSPath        string `json:"ws_path"`
+	StreamPath    string `json:"stream_path"`
+	ReconnectMs   int    `json:"reconnect_ms"` // Пауза перед переподключением к /ws
+	LogPollMs     int    `json:"log_poll_ms"`  // Период опроса /api/logs
+	SummaryPath   string `json:"summary_path"`
+	SummaryPollMs int    `json:"summary_poll_ms"` // Период опроса /api/summary
+	TokenRequired bool   `json:"token_required"`  // Сервер запущен с -observer-token
+}
+
+type dashboard struct {
+	files fs.FS
+	index *template.Template // nil - шаблон читается заново на каждый запрос
+}
+
+// newDashboard открывает файлы панели: вшитые при пустом dir, иначе из
+// каталога dir. Шаблон из каталога перечитывается на каждый запрос, чтобы
+// правки были видны без перезапуска.
+func newDashboard(dir string) (*dashboard, error) {
+	if dir == "" {
+		index, err := template.ParseFS(dashboardFiles, "templates/index.html")
+		if err != nil {
+			return nil, err
+		}
+		return &dashboard{files: dashboardFiles, index: index}, nil
+	}
+
+	d := &dashboard{files: os.DirFS(dir)}
+	if _, err := d.template(); err != nil {
+		return nil, err
+	}
+	if _, err := fs.Stat(d.files, "static"); err != nil {
+		return nil, fmt.Errorf("нет каталога static: %w", err)
+	}
+	return d, nil
+}
+
+func (d *dashboard) template() (*template.Template, error) {
+	if d.index != nil {
+		return d.index, nil
+	}
+	return template.ParseFS(d.files, "templates/index.html")
+}
+
+func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
+	index, err := s.dashboard.template()
+	if err != nil {
+		serverLog("error", "dashboard_template_failed", protocol.LogFields{"error": err})
+		http.Error(w, "dashboard template error", http.StatusInternalServerError)
+		return
+	}
+
+	// Страница собирается в буфер, чтобы ошибка шаблона не оставила
+	// половину страницы с кодом 200
+	var page bytes.Buffer
+	if err := index.Execute(&page, dashboardConfig{
+		WSPath:        "/ws",
+		StreamPath:    "/api/stream",
+		ReconnectMs:   3000,
+		LogPollMs:     2000,
+		SummaryPath:   "/api/summary",
+		SummaryPollMs: 5000,
+		TokenRequired: s.observerToken != "",
+	}); err != nil {
+		serverLog("error", "dashboard_template_failed", protocol.LogFields{"error": err})
+		http.Error(w, "dashboard template error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(page.Bytes())
+}
+
+func (s *Server) handleStatic(w http.ResponseWriter, r *http.Request) {
+	static, err := fs.Sub(s.dashboard.files, "static")
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	http.StripPrefix("/static/", http.FileServerFS(static)).ServeHTTP(w, r)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cosmodrom/protocol"
+)
+
+func get(t *testing.T, handler http.Handler, path string) (int, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	body, _ := io.ReadAll(rec.Body)
+	return rec.Code, string(body)
+}
+
+// Вшитая панель: шаблон получает значения сервера, скрипт и стили
+// раздаются из static/
+func TestDashboardEmbedded(t *testing.T) {
+	s := NewServer(protocol.JSON)
+	s.observerToken = "secret"
+	mux := s.routes()
+
+	code, page := get(t, mux, "/")
+	if code != http.StatusOK {
+		t.Fatalf("/ ответил %d", code)
+	}
+	for _, want := range []string{
+		"<title>Cosmodrom - Центр управления</title>",
+		`"ws_path":"/ws"`,
+		`"token_required":true`,
+		`"log_poll_ms":2000`,
+		`<script src="/static/dashboard.js">`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("на странице нет %s", want)
+		}
+	}
+
+	code, script := get(t, mux, "/static/dashboard.js")
+	if code != http.StatusOK || !strings.Contains(script, "dashboardConfig.ws_path") {
+		t.Errorf("dashboard.js: %d", code)
+	}
+	if code, css := get(t, mux, "/static/dashboard.css"); code != http.StatusOK || !strings.Contains(css, ".header") {
+		t.Errorf("dashboard.css: %d", code)
+	}
+	if code, _ := get(t, mux, "/static/missing.js"); code != http.StatusNotFound {
+		t.Errorf("несуществующий файл: %d", code)
+	}
+}
+
+// -static-dir подменяет панель без пересборки, а правка шаблона видна без
+// перезапуска
+func TestDashboardStaticDir(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := newDashboard(dir); err == nil {
+		t.Fatal("пустой каталог принят")
+	}
+	write("templates/index.html", `<h1>Своя панель</h1><script>const c = {{.}};</script>`)
+	write("static/app.js", "console.log('ok');")
+
+	s := NewServer(protocol.JSON)
+	dashboard, err := newDashboard(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.dashboard = dashboard
+	mux := s.routes()
+
+	if _, page := get(t, mux, "/"); !strings.Contains(page, "Своя панель") || !strings.Contains(page, `"reconnect_ms":3000`) {
+		t.Errorf("страница из каталога: %s", page)
+	}
+	if code, script := get(t, mux, "/static/app.js"); code != http.StatusOK || script != "console.log('ok');" {
+		t.Errorf("app.js: %d %q", code, script)
+	}
+	if code, _ := get(t, mux, "/static/dashboard.js"); code != http.StatusNotFound {
+		t.Errorf("вшитый файл раздается вместо каталога: %d", code)
+	}
+
+	write("templates/index.html", `<h1>Новая версия</h1>`)
+	if _, page := get(t, mux, "/"); !strings.Contains(page, "Новая версия") {
+		t.Errorf("правка шаблона не видна: %s", page)
+	}
+	write("templates/index.html", `{{.Missing}}`)
+	if code, _ := get(t, mux, "/"); code != http.StatusInternalServerError {
+		t.Errorf("ошибка шаблона: %d", code)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strings"
+)
+
+type DebugVars struct {
+	Goroutines  int    `json:"goroutines"`
+	HeapAlloc   uint64 `json:"heap_alloc"`
+	HeapInuse   uint64 `json:"heap_inuse"`
+	HeapObjects uint64 `json:"heap_objects"`
+	Sys         uint64 `json:"sys"`
+	NumGC       uint32 `json:"num_gc"`
+	Rockets     int    `json:"rockets"`
+	Observers   int    `json:"observers"`
+}
+
+func (s *Server) registerDebugRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/pprof/", s.requireAdmin(pprof.Index))
+	mux.HandleFunc("/debug/pprof/cmdline", s.requireAdmin(pprof.Cmdline))
+	mux.HandleFunc("/debug/pprof/profile", s.requireAdmin(pprof.Profile))
+	mux.HandleFunc("/debug/pprof/symbol", s.requireAdmin(pprof.Symbol))
+	mux.HandleFunc("/debug/pprof/trace", s.requireAdmin(pprof.Trace))
+	mux.HandleFunc("/debug/vars", s.requireAdmin(s.handleDebugVars))
+}
+
+func (s *Server) handleDebugVars(w http.ResponseWriter, r *http.Request) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	s.mu.RLock()
+	vars := DebugVars{
+		Goroutines:  runtime.NumGoroutine(),
+		HeapAlloc:   mem.HeapAlloc,
+		HeapInuse:   mem.HeapInuse,
+		HeapObjects: mem.HeapObjects,
+		Sys:         mem.Sys,
+		NumGC:       mem.NumGC,
+		Rockets:     len(s.rockets),
+		Observers:   len(s.observers),
+	}
+	s.mu.RUnlock()
+
+	writeJSON(w, http.StatusOK, vars)
+}
+
+// adminIdentity - кто отправил запрос с токеном администратора. Токен
+// один на сервер, поэтому и личность одна
+const adminIdentity = "admin"
+
+type adminIdentityKey struct{}
+
+// requester - кто отправил запрос для журнала: личность из токена
+// администратора, без токена - адрес клиента
+func requester(r *http.Request) string {
+	if identity, ok := r.Context().Value(adminIdentityKey{}).(string); ok {
+		return identity
+	}
+	return r.RemoteAddr
+}
+
+// requireAdmin пропускает запрос только с токеном администратора
+// (Authorization: Bearer <token>), если токен задан
+func (s *Server) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.adminToken == "" {
+			next(w, r)
+			return
+		}
+
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeJSONError(w, http.StatusUnauthorized, "admin token required")
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), adminIdentityKey{}, adminIdentity)))
+	}
+}
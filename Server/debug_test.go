@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cosmodrom/protocol"
+)
+
+func TestDebugRoutes(t *testing.T) {
+	tests := []struct {
+		name          string
+		debug         bool
+		authorization string
+		wantStatus    int
+	}{
+		{name: "без -debug", authorization: "Bearer secret", wantStatus: http.StatusNotFound},
+		{name: "без токена", debug: true, wantStatus: http.StatusUnauthorized},
+		{name: "чужой токен", debug: true, authorization: "Bearer guess", wantStatus: http.StatusUnauthorized},
+		{name: "токен администратора", debug: true, authorization: "Bearer secret", wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer(protocol.JSON)
+			s.debug = tt.debug
+			s.adminToken = "secret"
+			routes := s.routes()
+
+			for _, path := range []string{"/debug/vars", "/debug/pprof/", "/debug/pprof/cmdline"} {
+				r := httptest.NewRequest(http.MethodGet, path, nil)
+				if tt.authorization != "" {
+					r.Header.Set("Authorization", tt.authorization)
+				}
+				w := httptest.NewRecorder()
+				routes.ServeHTTP(w, r)
+				if w.Code != tt.wantStatus {
+					t.Errorf("%s: статус %d, ожидался %d", path, w.Code, tt.wantStatus)
+				}
+				if w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") != "Bearer" {
+					t.Errorf("%s: 401 без WWW-Authenticate: Bearer", path)
+				}
+			}
+		})
+	}
+}
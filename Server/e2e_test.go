@@ -0,0 +1,156 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"cosmodrom/protocol"
+	"cosmodrom/server/internal/testutil"
+)
+
+// startTestServer запускает сервер со всеми маршрутами на свободном порту.
+// Проверка сближений идет чаще обычного, чтобы тесты не ждали секунду.
+func startTestServer(t *testing.T) string {
+	t.Helper()
+	s := NewServer(protocol.JSON)
+	s.collisionCheckInterval = 20 * time.Millisecond
+	return testutil.StartTestServer(t, s)
+}
+
+func TestEndToEndDuplicateID(t *testing.T) {
+	url := startTestServer(t)
+	observer := testutil.NewTestObserver(t, url)
+
+	first := testutil.NewTestRocket(t, url)
+	first.MustRegister(testutil.RocketConfig("Первая"))
+	observer.ExpectMessage(protocol.MsgTypeRocketJoined, 0)
+
+	second := testutil.NewTestRocket(t, url)
+	second.ID = first.ID
+	msg := second.Register(testutil.RocketConfig("Вторая"))
+	rejected, ok := msg.Data.(protocol.RejectedMessage)
+	if !ok || rejected.Code != protocol.RejectCodeDuplicateID {
+		t.Fatalf("ответ %s %+v, ожидался rejected %s", msg.Type, msg.Data, protocol.RejectCodeDuplicateID)
+	}
+
+	// Отказ не трогает зарегистрированную ракету, а после ее отключения ID
+	// снова свободен
+	first.Close()
+	observer.ExpectMessage(protocol.MsgTypeRocketLeft, 0)
+	second.MustRegister(testutil.RocketConfig("Вторая"))
+}
+
+func TestEndToEndObserverReplay(t *testing.T) {
+	url := startTestServer(t)
+	rocket := testutil.NewTestRocket(t, url)
+	rocket.MustRegister(testutil.RocketConfig("Восток"))
+
+	observer := testutil.NewTestObserver(t, url)
+	msg := observer.ExpectMessage(protocol.MsgTypeRocketJoined, 0)
+	joined := msg.Data.(protocol.RocketJoinedMessage)
+	if joined.RocketID != rocket.ID || joined.Name != "Восток" || joined.Config.MassEmpty != 1000 {
+		t.Errorf("rocket_joined %+v", joined)
+	}
+	broadcast := observer.ExpectMessage(protocol.MsgTypeBroadcast, 0).Data.(protocol.BroadcastMessage)
+	if broadcast.RocketID != rocket.ID {
+		t.Errorf("broadcast ракеты %s, ожидалась %s", broadcast.RocketID, rocket.ID)
+	}
+
+	// После подписки телеметрия приходит наблюдателю рассылкой
+	rocket.SendTelemetry(protocol.RocketState{Time: 1, Altitude: 1500})
+	broadcast = observer.ExpectMessage(protocol.MsgTypeBroadcast, 0).Data.(protocol.BroadcastMessage)
+	if broadcast.State.Altitude != 1500 {
+		t.Errorf("высота в рассылке %v", broadcast.State.Altitude)
+	}
+}
+
+func TestEndToEndRocketLeft(t *testing.T) {
+	url := startTestServer(t)
+	observer := testutil.NewTestObserver(t, url)
+	rocket := testutil.NewTestRocket(t, url)
+	rocket.MustRegister(testutil.RocketConfig("Союз"))
+	observer.ExpectMessage(protocol.MsgTypeRocketJoined, 0)
+
+	rocket.Close()
+	left := observer.ExpectMessage(protocol.MsgTypeRocketLeft, 0).Data.(protocol.RocketLeftMessage)
+	if left.RocketID != rocket.ID || left.Reason != "disconnected" {
+		t.Errorf("rocket_left %+v", left)
+	}
+}
+
+func TestEndToEndCollisionWarning(t *testing.T) {
+	url := startTestServer(t)
+	const radius = 6.371e6
+
+	// Ракеты в 200 м друг от друга над поверхностью, вторая ближе к первой,
+	// чем minSafeDistance/4
+	a := testutil.NewTestRocket(t, url)
+	a.MustRegister(testutil.RocketConfig("А"))
+	a.SendTelemetry(protocol.RocketState{Time: 1, Altitude: 1000, Position: protocol.Vector3{X: radius + 1000}})
+	b := testutil.NewTestRocket(t, url)
+	b.MustRegister(testutil.RocketConfig("Б"))
+	b.SendTelemetry(protocol.RocketState{Time: 1, Altitude: 1000, Position: protocol.Vector3{X: radius + 1000, Y: 200}})
+
+	for _, tc := range []struct {
+		rocket *testutil.TestRocket
+		other  string
+	}{{a, b.ID}, {b, a.ID}} {
+		warning := tc.rocket.ExpectMessage(protocol.MsgTypeWarning, 0).Data.(protocol.WarningMessage)
+		if warning.Code != protocol.WarningCodeProximity || warning.OtherRocketID != tc.other {
+			t.Fatalf("ракета %s: предупреждение %+v", tc.rocket.ID, warning)
+		}
+		if warning.Severity != protocol.SeverityCritical || warning.Distance < 199 || warning.Distance > 201 {
+			t.Errorf("ракета %s: опасность %s, расстояние %.1f м", tc.rocket.ID, warning.Severity, warning.Distance)
+		}
+	}
+}
+
+// Ракета и наблюдатель на одном соединении: в сокет одновременно пишут
+// рассылка телеметрии другой ракеты, команды сервера и эхо heartbeat из
+// горутины чтения. Без общей блокировки записи сессии gorilla/websocket
+// паникует на одновременной записи, а go test -race видит гонку.
+func TestEndToEndConcurrentWrites(t *testing.T) {
+	s := NewServer(protocol.JSON)
+	url := testutil.StartTestServer(t, s)
+
+	dual := testutil.NewTestRocket(t, url)
+	dual.MustRegister(testutil.RocketConfig("Ракета-наблюдатель"))
+	dual.Send(protocol.MsgTypeSubscribe, protocol.SubscribeMessage{ObserverID: "observer-" + dual.ID})
+	dual.ExpectMessage(protocol.MsgTypeRocketJoined, 0)
+	other := testutil.NewTestRocket(t, url)
+	other.MustRegister(testutil.RocketConfig("Соседняя"))
+
+	// Сообщений меньше буфера тестового клиента, чтобы сервер не ждал чтения
+	const count = 50
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < count; i++ {
+			s.sendCommand(protocol.CommandMessage{RocketID: dual.ID, Command: protocol.ControlCommand{Pitch: float64(i)}}, CommandSourceHTTP, "test")
+		}
+	}()
+	for i := 1; i <= count; i++ {
+		other.SendTelemetry(protocol.RocketState{Time: float64(i), Altitude: 1000})
+		dual.Send(protocol.MsgTypeHeartbeat, protocol.HeartbeatMessage{RocketID: dual.ID, Nonce: uint64(i)})
+	}
+	wg.Wait()
+
+	commands, lastFrame := 0, false
+	for commands < count || !lastFrame {
+		msg := dual.Expect(0, "команды и последний кадр соседней ракеты", func(msg protocol.Message) bool {
+			return msg.Type == protocol.MsgTypeCommand || msg.Type == protocol.MsgTypeBroadcast
+		})
+		if msg.Type == protocol.MsgTypeCommand {
+			commands++
+		} else if broadcast := msg.Data.(protocol.BroadcastMessage); broadcast.RocketID == other.ID && broadcast.State.Time == count {
+			lastFrame = true
+		}
+	}
+	for _, entry := range s.audit.GetByRocket(dual.ID, time.Time{}) {
+		if entry.Status != AuditStatusSent {
+			t.Fatalf("команда %d: статус %s, %s", entry.ID, entry.Status, entry.Error)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"cosmodrom/protocol"
+
+	"github.com/gorilla/websocket"
+)
+
+const errorInterval = time.Second // Не чаще одной ошибки с тем же кодом
+
+// errorReporter сообщает клиенту об отброшенных сообщениях. Ошибки с одним
+// кодом отправляются не чаще раза в errorInterval, чтобы клиент с ошибкой в
+// каждом кадре телеметрии не получал поток ответов.
+type errorReporter struct {
+	server     *Server
+	conn       *websocket.Conn
+	writeMu    *sync.Mutex    // Блокировка записи сессии
+	codec      protocol.Codec // Кодек последнего кадра клиента
+	connID     string
+	rocket     *RocketConnection // После регистрации ошибка идет кодеком ракеты
+	last       map[protocol.ErrorCode]time.Time
+	suppressed map[protocol.ErrorCode]uint64
+}
+
+func newErrorReporter(s *Server, conn *websocket.Conn, writeMu *sync.Mutex, connID string) *errorReporter {
+	return &errorReporter{
+		server:     s,
+		conn:       conn,
+		writeMu:    writeMu,
+		connID:     connID,
+		last:       make(map[protocol.ErrorCode]time.Time),
+		suppressed: make(map[protocol.ErrorCode]uint64),
+	}
+}
+
+func (e *errorReporter) report(code protocol.ErrorCode, ref protocol.Message, detail string) {
+	now := e.server.clock.Now()
+	if now.Sub(e.last[code]) < errorInterval {
+		e.suppressed[code]++
+		return
+	}
+
+	errMsg := protocol.ErrorMessage{
+		Code:       code,
+		Detail:     detail,
+		RefType:    ref.Type,
+		RefSeq:     ref.Seq,
+		Suppressed: e.suppressed[code],
+	}
+	e.last[code] = now
+	e.suppressed[code] = 0
+
+	rocketID := ""
+	if e.rocket != nil {
+		rocketID = e.rocket.ID
+	}
+	fields := protocol.LogFields{"type": ref.Type, "code": code, "detail": detail}
+	if errMsg.Suppressed > 0 {
+		fields["suppressed"] = errMsg.Suppressed
+	}
+	connLog(e.connID, rocketID, "warning", "message_dropped", fields)
+
+	if e.rocket != nil {
+		e.server.sendToRocket(e.rocket, protocol.MsgTypeError, errMsg)
+		return
+	}
+	e.writeMu.Lock()
+	e.server.sendMessage(e.conn, e.codec, protocol.MsgTypeError, errMsg)
+	e.writeMu.Unlock()
+}
+
+// messageLimiter - token bucket входящих сообщений одного соединения.
+// Используется только из горутины чтения, поэтому без блокировки.
+type messageLimiter struct {
+	rate   float64 // Сообщений в секунду, 0 - без лимита
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newMessageLimiter(rate float64, burst int) *messageLimiter {
+	return &messageLimiter{rate: rate, burst: float64(burst), tokens: float64(burst)}
+}
+
+func (l *messageLimiter) allow(now time.Time) bool {
+	if l.rate <= 0 {
+		return true
+	}
+	if !l.last.IsZero() {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"cosmodrom/protocol"
+
+	"github.com/gorilla/websocket"
+)
+
+func dialTestServer(t *testing.T, s *Server) *websocket.Conn {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(s.handleWebSocket))
+	t.Cleanup(server.Close)
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func readError(t *testing.T, conn *websocket.Conn) protocol.ErrorMessage {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg protocol.Message
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Type != protocol.MsgTypeError {
+		t.Fatalf("получено %s, ожидалось error", msg.Type)
+	}
+	errMsg, err := protocol.DecodeData[protocol.ErrorMessage](msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return errMsg
+}
+
+func TestServerReportsDroppedMessages(t *testing.T) {
+	conn := dialTestServer(t, NewServer(protocol.JSON))
+
+	steps := []struct {
+		payload string
+		code    protocol.ErrorCode
+		refType protocol.MessageType
+	}{
+		{payload: `{"type":`, code: protocol.ErrorCodeDecode},
+		{payload: `{"type":"telemetry","seq":7,"data":{}}`, code: protocol.ErrorCodeNotRegistered, refType: protocol.MsgTypeTelemetry},
+		{payload: `{"type":"launch"}`, code: protocol.ErrorCodeUnknownType, refType: "launch"},
+		{payload: `{"type":"subscribe","data":{"labels":"все"}}`, code: protocol.ErrorCodeDecode, refType: protocol.MsgTypeSubscribe},
+	}
+	for i, step := range steps {
+		// Второй decode_error в ту же секунду был бы подавлен
+		if i == len(steps)-1 {
+			time.Sleep(errorInterval)
+		}
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(step.payload)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i, step := range steps {
+		got := readError(t, conn)
+		if got.Code != step.code || got.RefType != step.refType {
+			t.Errorf("шаг %d: %s для %q, ожидалось %s для %q", i, got.Code, got.RefType, step.code, step.refType)
+		}
+	}
+}
+
+// Ошибки с одним кодом не чаще раза в секунду, пропущенные считаются в
+// suppressed следующей ошибки; сообщения сверх лимита соединения отбрасываются
+func TestServerErrorThrottleAndRateLimit(t *testing.T) {
+	s := NewServer(protocol.JSON)
+	s.msgRate, s.msgBurst = 0.001, 3
+	conn := dialTestServer(t, s)
+
+	for i := 0; i < 5; i++ {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"heartbeat","data":{}}`)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := readError(t, conn); got.Code != protocol.ErrorCodeNotRegistered || got.Suppressed != 0 {
+		t.Errorf("первая ошибка %+v", got)
+	}
+	if got := readError(t, conn); got.Code != protocol.ErrorCodeRateLimited {
+		t.Errorf("ожидался rate_limited, получено %+v", got)
+	}
+
+	time.Sleep(errorInterval)
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"heartbeat","data":{}}`))
+	if got := readError(t, conn); got.Code != protocol.ErrorCodeRateLimited || got.Suppressed != 1 {
+		t.Errorf("ожидался rate_limited с suppressed 1, получено %+v", got)
+	}
+}
+
+// Опечатка в регистрации - отказ unknown_field с путем к полю
+func TestRegisterUnknownField(t *testing.T) {
+	conn := dialTestServer(t, NewServer(protocol.JSON))
+
+	payload := `{"type":"register","data":{"rocket_id":"r1","config":{"name":"Тест","mass_empty":1000,"mass_fuel":500,` +
+		`"drag_coefficient":0.3,"cross_section":1,"engines":[{"thrust":30000,"fuel_consumption":10,"trust_vector":1}]}}}`
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(payload)); err != nil {
+		t.Fatal(err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg protocol.Message
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatal(err)
+	}
+	rejected, err := protocol.DecodeData[protocol.RejectedMessage](msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Type != protocol.MsgTypeRejected || rejected.Code != protocol.RejectCodeUnknownField ||
+		!strings.Contains(rejected.Reason, "config.engines[0].trust_vector") {
+		t.Errorf("ответ %s %+v, ожидался отказ unknown_field", msg.Type, rejected)
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"cosmodrom/protocol"
+)
+
+const exportChunk = 256 // Кадров, копируемых из истории за одну блокировку ракеты
+
+// exportColumn - столбец CSV: путь к полю RocketState по индексам
+type exportColumn struct {
+	name  string
+	index []int
+}
+
+// exportColumns - столбцы CSV по тегам json полей RocketState: вложенные
+// структуры разворачиваются через "_" (position_x, orientation_pitch),
+// списки пишутся одной ячейкой JSON (engine_status)
+var exportColumns = stateColumns(reflect.TypeOf(protocol.RocketState{}), "", nil)
+
+func stateColumns(t reflect.Type, prefix string, index []int) []exportColumn {
+	var columns []exportColumn
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		path := append(append([]int{}, index...), i)
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct {
+			columns = append(columns, stateColumns(fieldType, prefix+name+"_", path)...)
+			continue
+		}
+		columns = append(columns, exportColumn{name: prefix + name, index: path})
+	}
+	return columns
+}
+
+// cell - значение столбца; пусто, если по пути встретился nil
+func (c exportColumn) cell(state *protocol.RocketState) string {
+	v := reflect.ValueOf(state).Elem()
+	for _, i := range c.index {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return ""
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+
+	switch v.Kind() {
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64)
+	case reflect.Int:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.String:
+		return v.String()
+	case reflect.Slice:
+		if v.Len() == 0 {
+			return ""
+		}
+		data, _ := json.Marshal(v.Interface())
+		return string(data)
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// exportSample - строка NDJSON: поля RocketState на верхнем уровне
+type exportSample struct {
+	Timestamp time.Time `json:"timestamp"`
+	protocol.RocketState
+}
+
+// handleTelemetryExport выгружает историю ракеты по кадру на строку:
+// telemetry.csv или telemetry.ndjson. ?since= и ?until= (RFC3339) - по
+// времени приема кадра сервером, включительно.
+//
+// Выгружаются кадры, принятые до начала запроса: история читается
+// порциями под блокировкой ракеты и сразу уходит клиенту, поэтому память
+// не зависит от объема, а отключение ракеты во время выгрузки лишь
+// завершает ее на последнем принятом кадре.
+func (s *Server) handleTelemetryExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var bounds [2]time.Time // since, until
+	for i, name := range []string{"since", "until"} {
+		value := r.URL.Query().Get(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, name+": ожидается время RFC3339")
+			return
+		}
+		bounds[i] = parsed
+	}
+	since, until := bounds[0], bounds[1]
+
+	rocketID := r.PathValue("id")
+	s.mu.RLock()
+	rocket, exists := s.rockets[rocketID]
+	s.mu.RUnlock()
+	if !exists {
+		writeJSONError(w, http.StatusNotFound, "rocket not found: "+rocketID)
+		return
+	}
+
+	rocket.mu.RLock()
+	history := rocket.history
+	var end uint64
+	if history != nil {
+		end = history.next
+	}
+	rocket.mu.RUnlock()
+	if history == nil {
+		writeJSONError(w, http.StatusNotFound, "telemetry history disabled (-history-size 0)")
+		return
+	}
+
+	csvFormat := strings.HasSuffix(r.URL.Path, ".csv")
+	extension := "ndjson"
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	if csvFormat {
+		extension = "csv"
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-telemetry.%s"`, exportFileName(rocketID), extension))
+
+	var records *csv.Writer
+	var row []string
+	if csvFormat {
+		records = csv.NewWriter(w)
+		row = make([]string, 0, len(exportColumns)+1)
+		row = append(row, "timestamp")
+		for _, column := range exportColumns {
+			row = append(row, column.name)
+		}
+		records.Write(row)
+	}
+
+	flusher, _ := w.(http.Flusher)
+	chunk := make([]historySample, 0, exportChunk)
+	for from := uint64(0); from < end; {
+		rocket.mu.RLock()
+		chunk, from = history.read(from, end, chunk)
+		rocket.mu.RUnlock()
+		if len(chunk) == 0 {
+			break
+		}
+
+		for i := range chunk {
+			sample := &chunk[i]
+			if (!since.IsZero() && sample.At.Before(since)) || (!until.IsZero() && sample.At.After(until)) {
+				continue
+			}
+			if records == nil {
+				if err := writeNDJSONSample(w, sample); err != nil {
+					return // Клиент закрыл соединение
+				}
+				continue
+			}
+			row[0] = sample.At.UTC().Format(time.RFC3339Nano)
+			for i, column := range exportColumns {
+				row[i+1] = column.cell(&sample.State)
+			}
+			records.Write(row)
+		}
+
+		if records != nil {
+			if records.Flush(); records.Error() != nil {
+				return
+			}
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if r.Context().Err() != nil {
+			return
+		}
+	}
+	if records != nil {
+		records.Flush()
+	}
+}
+
+func writeNDJSONSample(w io.Writer, sample *historySample) error {
+	data, err := json.Marshal(exportSample{Timestamp: sample.At.UTC(), RocketState: sample.State})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// exportFileName оставляет в ID ракеты только безопасные для имени файла
+// символы
+func exportFileName(rocketID string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r == '.' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, rocketID)
+}
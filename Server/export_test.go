@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"cosmodrom/protocol"
+)
+
+// Кольцевой буфер отдает кадры по порядку и пропускает вытесненные
+func TestTelemetryHistoryRead(t *testing.T) {
+	h := newTelemetryHistory(3)
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		h.add(start.Add(time.Duration(i)*time.Second), protocol.RocketState{Time: float64(i)})
+	}
+
+	chunk, next := h.read(0, h.next, make([]historySample, 0, 2))
+	if len(chunk) != 2 || chunk[0].State.Time != 2 || chunk[1].State.Time != 3 || next != 4 {
+		t.Fatalf("первая порция %+v, следующий %d", chunk, next)
+	}
+	chunk, next = h.read(next, h.next, chunk)
+	if len(chunk) != 1 || chunk[0].State.Time != 4 || next != 5 {
+		t.Fatalf("вторая порция %+v, следующий %d", chunk, next)
+	}
+	if chunk, _ = h.read(next, h.next, chunk); len(chunk) != 0 {
+		t.Errorf("после конца %d кадров", len(chunk))
+	}
+}
+
+func exportTestServer(t *testing.T) *Server {
+	t.Helper()
+	s := NewServer(protocol.JSON)
+	rc := &RocketConnection{ID: "r/1", history: newTelemetryHistory(10)}
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 1; i <= 3; i++ {
+		rc.record(start.Add(time.Duration(i)*time.Second), protocol.Message{Seq: uint64(i)}, []protocol.RocketState{{
+			Time:         float64(i),
+			Altitude:     float64(i * 1000),
+			Position:     protocol.Vector3{X: float64(i)},
+			EngineStatus: []protocol.EngineStatus{{ID: "center", Throttle: 1, Active: true}},
+		}})
+	}
+	s.rockets[rc.ID] = rc
+	return s
+}
+
+func TestTelemetryExportCSV(t *testing.T) {
+	s := exportTestServer(t)
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/rockets/r%2F1/telemetry.csv?since=2026-03-01T12:00:02Z", nil)
+	req.SetPathValue("id", "r/1")
+	s.handleTelemetryExport(rec, req)
+
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/csv; charset=utf-8" {
+		t.Fatalf("ответ %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if disposition := rec.Header().Get("Content-Disposition"); disposition != `attachment; filename="r_1-telemetry.csv"` {
+		t.Errorf("Content-Disposition %q", disposition)
+	}
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("%d строк, ожидалось заголовок и 2 кадра", len(rows))
+	}
+
+	cells := map[string]string{}
+	for i, name := range rows[0] {
+		cells[name] = rows[1][i]
+	}
+	for name, want := range map[string]string{
+		"timestamp":     "2026-03-01T12:00:02Z",
+		"altitude":      "2000",
+		"position_x":    "2",
+		"engine_status": `[{"id":"center","throttle":1,"active":true,"burn_time":0}]`,
+	} {
+		if cells[name] != want {
+			t.Errorf("%s = %q, ожидалось %q", name, cells[name], want)
+		}
+	}
+	if value, ok := cells["orientation_pitch"]; !ok || value != "" {
+		t.Errorf("orientation_pitch = %q (есть %v), ожидалась пустая ячейка", value, ok)
+	}
+}
+
+func TestTelemetryExportNDJSON(t *testing.T) {
+	s := exportTestServer(t)
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/rockets/r%2F1/telemetry.ndjson?until=2026-03-01T12:00:02Z", nil)
+	req.SetPathValue("id", "r/1")
+	s.handleTelemetryExport(rec, req)
+
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("ответ %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var samples []exportSample
+	for lines := bufio.NewScanner(rec.Body); lines.Scan(); {
+		var sample exportSample
+		if err := json.Unmarshal(lines.Bytes(), &sample); err != nil {
+			t.Fatal(err)
+		}
+		samples = append(samples, sample)
+	}
+	if len(samples) != 2 || samples[0].Altitude != 1000 || samples[1].Time != 2 {
+		t.Fatalf("кадры %+v", samples)
+	}
+	if !samples[1].Timestamp.Equal(time.Date(2026, 3, 1, 12, 0, 2, 0, time.UTC)) {
+		t.Errorf("метка времени %v", samples[1].Timestamp)
+	}
+}
+
+func TestTelemetryExportErrors(t *testing.T) {
+	s := exportTestServer(t)
+	s.rockets["r2"] = &RocketConnection{ID: "r2"}
+	for _, tc := range []struct {
+		id, query string
+		code      int
+	}{
+		{"r/1", "?since=вчера", http.StatusBadRequest},
+		{"r3", "", http.StatusNotFound},
+		{"r2", "", http.StatusNotFound}, // История отключена
+	} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/rockets/x/telemetry.csv"+tc.query, nil)
+		req.SetPathValue("id", tc.id)
+		s.handleTelemetryExport(rec, req)
+		if rec.Code != tc.code {
+			t.Errorf("%s%s: ответ %d, ожидался %d", tc.id, tc.query, rec.Code, tc.code)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"cosmodrom/protocol"
+
+	"github.com/gorilla/websocket"
+)
+
+// testConn - серверная сторона соединения WebSocket для обработчиков.
+// Клиентская сторона выбрасывает ответы сервера прямо из сокета, не
+// разбирая кадры, чтобы замеры памяти считали только сервер.
+func testConn(f testing.TB) *websocket.Conn {
+	conns := make(chan *websocket.Conn, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			f.Error(err)
+			return
+		}
+		conns <- conn
+	}))
+	f.Cleanup(server.Close)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Cleanup(func() { client.Close() })
+	go io.Copy(io.Discard, client.UnderlyingConn())
+	conn := <-conns
+	f.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// quietLog отключает журнал сервера: при фаззинге и замерах он только тормозит
+func quietLog(f testing.TB) {
+	log.SetOutput(io.Discard)
+	f.Cleanup(func() { log.SetOutput(os.Stderr) })
+}
+
+// fuzzFrames прогоняет кадры через handleMessage одного соединения и
+// проверяет, что списки ракет и наблюдателей согласованы, а после обрыва
+// связи от соединения ничего не осталось
+func fuzzFrames(t *testing.T, conn *websocket.Conn, frames [][]byte) {
+	s := NewServer(protocol.JSON)
+	session := s.newClientSession(conn, "fuzz")
+	for _, frame := range frames {
+		done := s.handleMessage(session, websocket.TextMessage, frame)
+		checkServerState(t, s)
+		if done {
+			// После disconnect и unsubscribe сервер закрывает соединение,
+			// дальнейшие кадры - уже новое соединение
+			s.closeSession(session, errClientLeft)
+			checkServerState(t, s)
+			session = s.newClientSession(conn, "fuzz")
+		}
+	}
+	s.closeSession(session, io.EOF)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.rockets) != 0 || len(s.observers) != 0 {
+		t.Fatalf("после отключения остались ракеты %v и наблюдатели %v", keys(s.rockets), keys(s.observers))
+	}
+	if summary := s.fleet.summary(); summary.Rockets != 0 {
+		t.Fatalf("после отключения в сводке парка %d ракет", summary.Rockets)
+	}
+}
+
+func checkServerState(t *testing.T, s *Server) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for id, rocket := range s.rockets {
+		if rocket == nil || rocket.ID != id {
+			t.Fatalf("ракета под ключом %q: %+v", id, rocket)
+		}
+	}
+	for id, observer := range s.observers {
+		if observer == nil || observer.ID != id {
+			t.Fatalf("наблюдатель под ключом %q: %+v", id, observer)
+		}
+	}
+	if summary := s.fleet.summary(); summary.Rockets != len(s.rockets) {
+		t.Fatalf("в сводке парка %d ракет, в списке %d", summary.Rockets, len(s.rockets))
+	}
+}
+
+func keys[V any](m map[string]V) []string {
+	result := make([]string, 0, len(m))
+	for key := range m {
+		result = append(result, key)
+	}
+	return result
+}
+
+const fuzzRegister = `{"type":"register","seq":1,"data":{"rocket_id":"r1","config":{"name":"Тест","mass_empty":1000,"mass_fuel":500,"mass_fuel_max":500,"drag_coefficient":0.3,"cross_section":1,"engines":[{"thrust":30000,"fuel_consumption":10,"is_active":true}]}}}`
+
+// Кадры одного соединения через перевод строки: ни один не роняет сервер
+// и не оставляет ракет и наблюдателей после обрыва связи
+func FuzzHandleMessage(f *testing.F) {
+	quietLog(f)
+	conn := testConn(f)
+
+	f.Add([]byte(fuzzRegister))
+	f.Add([]byte(fuzzRegister + "\n" + `{"type":"telemetry","seq":2,"data":{"rocket_id":"r1","state":{"time":1,"altitude":100}}}` + "\n" + `{"type":"disconnect","seq":3}`))
+	f.Add([]byte(fuzzRegister + "\n" + `{"type":"telemetry_batch","seq":2,"data":{"rocket_id":"r1","states":[{"time":1},null,7]}}`))
+	f.Add([]byte(fuzzRegister + "\n" + `{"type":"abort","seq":2,"data":{"reason":"тест"}}` + "\n" + `{"type":"heartbeat","seq":3,"data":{"nonce":1}}`))
+	f.Add([]byte(`{"type":"subscribe","data":{"observer_id":"o1","rocket_ids":["r1"]}}` + "\n" + fuzzRegister + "\n" + `{"type":"unsubscribe"}`))
+	f.Add([]byte(`{"type":"config_request","data":{"name":"нет такой"}}`))
+	f.Add([]byte(`{"type":"telemetry","data":null}`))
+	f.Add([]byte(`{"type":`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fuzzFrames(t, conn, bytes.Split(data, []byte("\n")))
+	})
+}
+
+// Враждебные данные сообщения любого типа: null, данные не той формы,
+// огромные строки и глубокая вложенность. Сообщение приходит соединению
+// без регистрации, зарегистрированной ракете и наблюдателю.
+func FuzzHandleMessageData(f *testing.F) {
+	quietLog(f)
+	conn := testConn(f)
+
+	types := []protocol.MessageType{
+		protocol.MsgTypeRegister, protocol.MsgTypeTelemetry, protocol.MsgTypeTelemetryBatch,
+		protocol.MsgTypeAbort, protocol.MsgTypeHeartbeat, protocol.MsgTypeConfigRequest,
+		protocol.MsgTypeSubscribe, protocol.MsgTypeUnsubscribe, protocol.MsgTypeDisconnect,
+	}
+	hostile := []string{
+		`null`,
+		`[1,2,3]`,
+		`"строка"`,
+		`{"rocket_id":null,"config":null,"state":null,"states":null}`,
+		`{"rocket_id":"` + strings.Repeat("я", 1<<16) + `"}`,
+		strings.Repeat(`{"a":`, 2000) + `1` + strings.Repeat(`}`, 2000),
+		strings.Repeat(`[`, 5000) + strings.Repeat(`]`, 5000),
+		`{"observer_id":"","labels":{"":""},"rocket_ids":[""]}`,
+		`{"states":[` + strings.Repeat(`{"time":1},`, 1500) + `{"time":2}]}`,
+	}
+	for _, msgType := range types {
+		for _, data := range hostile {
+			f.Add(string(msgType), []byte(data))
+		}
+	}
+	f.Fuzz(func(t *testing.T, msgType string, data []byte) {
+		if !json.Valid(data) {
+			return
+		}
+		frame, err := json.Marshal(protocol.Message{Type: protocol.MessageType(msgType), Seq: 2, Data: json.RawMessage(data)})
+		if err != nil {
+			return
+		}
+		subscribe := []byte(`{"type":"subscribe","data":{"observer_id":"o1"}}`)
+		for _, prefix := range [][][]byte{nil, {[]byte(fuzzRegister)}, {subscribe}} {
+			fuzzFrames(t, conn, append(prefix, frame))
+		}
+	})
+}
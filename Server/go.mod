@@ -2,4 +2,11 @@ module cosmodrom/server
 
 go 1.25.5
 
-require github.com/gorilla/websocket v1.5.3
+require (
+	github.com/gorilla/websocket v1.5.3
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require cosmodrom/protocol v0.0.0
+
+replace cosmodrom/protocol => ../protocol
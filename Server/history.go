@@ -0,0 +1,56 @@
+package main
+
+import (
+	"time"
+
+	"cosmodrom/protocol"
+)
+
+const defaultHistorySize = 36000 // Час телеметрии при 10 кадрах в секунду
+
+type historySample struct {
+	At    time.Time // Когда кадр принят сервером
+	State protocol.RocketState
+}
+
+// telemetryHistory - кольцевой буфер последних кадров ракеты. Кадры
+// нумеруются с 0 по порядку приема; старые вытесняются новыми.
+// Защищен блокировкой ракеты.
+type telemetryHistory struct {
+	samples []historySample // Растет до size, потом перезаписывается по кругу
+	size    int
+	next    uint64 // Номер следующего кадра, он же число принятых
+}
+
+func newTelemetryHistory(size int) *telemetryHistory {
+	return &telemetryHistory{size: size}
+}
+
+func (h *telemetryHistory) add(at time.Time, state protocol.RocketState) {
+	sample := historySample{At: at, State: state}
+	if len(h.samples) < h.size {
+		h.samples = append(h.samples, sample)
+	} else {
+		h.samples[h.next%uint64(h.size)] = sample
+	}
+	h.next++
+}
+
+// oldest - номер самого старого кадра в буфере
+func (h *telemetryHistory) oldest() uint64 {
+	return h.next - uint64(len(h.samples))
+}
+
+// read копирует в dst кадры с номера from (или с самого старого, если from
+// уже вытеснен) до end, не больше cap(dst). Возвращает номер следующего
+// непрочитанного кадра.
+func (h *telemetryHistory) read(from, end uint64, dst []historySample) ([]historySample, uint64) {
+	dst = dst[:0]
+	if oldest := h.oldest(); from < oldest {
+		from = oldest
+	}
+	for ; from < end && len(dst) < cap(dst); from++ {
+		dst = append(dst, h.samples[from%uint64(h.size)])
+	}
+	return dst, from
+}
@@ -0,0 +1,225 @@
+// Package testutil запускает сервер в процессе теста и подключает к нему
+// ракеты и наблюдателей по WebSocket, как настоящие клиенты.
+package testutil
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cosmodrom/protocol"
+
+	"github.com/gorilla/websocket"
+)
+
+// DefaultTimeout - сколько ExpectMessage ждет сообщение, если timeout 0
+const DefaultTimeout = 2 * time.Second
+
+// Server - сервер, который StartTestServer запускает на свободном порту.
+// Пакет сервера - main, поэтому тип сервера сюда не импортируется.
+type Server interface {
+	Serve(listener net.Listener) error
+	Close() error
+}
+
+// StartTestServer запускает server на 127.0.0.1 со свободным портом и
+// возвращает адрес WebSocket, например "ws://127.0.0.1:41234/ws". Сервер
+// останавливается в конце теста.
+func StartTestServer(t testing.TB, server Server) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- server.Serve(listener) }()
+	t.Cleanup(func() {
+		server.Close()
+		if err := <-done; err != nil {
+			t.Errorf("сервер остановлен с ошибкой: %v", err)
+		}
+	})
+	return "ws://" + listener.Addr().String() + "/ws"
+}
+
+// Client - соединение WebSocket, которое читает сообщения сервера в фоне
+type Client struct {
+	t        testing.TB
+	conn     *websocket.Conn
+	messages chan protocol.Message
+	readErr  chan error
+	done     chan struct{}
+	close    sync.Once
+	seq      uint64
+}
+
+// Dial подключается к url; соединение закрывается в конце теста
+func Dial(t testing.TB, url string) *Client {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Client{
+		t:        t,
+		conn:     conn,
+		messages: make(chan protocol.Message, 256),
+		readErr:  make(chan error, 1),
+		done:     make(chan struct{}),
+	}
+	go c.readLoop()
+	t.Cleanup(c.Close)
+	return c
+}
+
+func (c *Client) readLoop() {
+	defer close(c.messages)
+	for {
+		_, payload, err := c.conn.ReadMessage()
+		if err != nil {
+			c.readErr <- err
+			return
+		}
+		msg, err := protocol.JSON.Decode(payload)
+		if err != nil {
+			c.readErr <- fmt.Errorf("сообщение сервера не разобрано: %w: %s", err, payload)
+			return
+		}
+		select {
+		case c.messages <- msg:
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// Send отправляет сообщение с очередным Seq
+func (c *Client) Send(msgType protocol.MessageType, data interface{}) {
+	c.t.Helper()
+	c.seq++
+	err := c.conn.WriteJSON(protocol.Message{Type: msgType, Timestamp: time.Now(), Seq: c.seq, Data: data})
+	if err != nil {
+		c.t.Fatalf("отправка %s: %v", msgType, err)
+	}
+}
+
+// ExpectMessage ждет сообщение msgType, пропуская сообщения других типов
+// (broadcast, heartbeat), и возвращает его с Data конкретного типа
+func (c *Client) ExpectMessage(msgType protocol.MessageType, timeout time.Duration) protocol.Message {
+	c.t.Helper()
+	return c.expect(timeout, string(msgType), func(msg protocol.Message) bool { return msg.Type == msgType })
+}
+
+// Expect ждет сообщение, которое подходит под match, пропуская остальные;
+// what - описание ожидаемого для сообщения об ошибке
+func (c *Client) Expect(timeout time.Duration, what string, match func(protocol.Message) bool) protocol.Message {
+	c.t.Helper()
+	return c.expect(timeout, what, match)
+}
+
+func (c *Client) expect(timeout time.Duration, what string, match func(protocol.Message) bool) protocol.Message {
+	c.t.Helper()
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	deadline := time.After(timeout)
+	var skipped []string
+	for {
+		select {
+		case msg, ok := <-c.messages:
+			if !ok {
+				c.t.Fatalf("ожидалось %s, соединение закрыто: %v", what, <-c.readErr)
+			}
+			if match(msg) {
+				return msg
+			}
+			skipped = append(skipped, string(msg.Type))
+		case <-deadline:
+			c.t.Fatalf("за %v не пришло %s, получены: [%s]", timeout, what, strings.Join(skipped, " "))
+		}
+	}
+}
+
+// Close закрывает соединение без disconnect, как при обрыве связи
+func (c *Client) Close() {
+	c.close.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+var lastID atomic.Int64
+
+// nextID - уникальный в процессе ID ракеты или наблюдателя
+func nextID(prefix string) string {
+	return fmt.Sprintf("%s-%d", prefix, lastID.Add(1))
+}
+
+// TestRocket - ракета: регистрация и телеметрия
+type TestRocket struct {
+	*Client
+	ID string
+}
+
+// NewTestRocket подключает ракету без регистрации; ID можно заменить до
+// Register
+func NewTestRocket(t testing.TB, url string) *TestRocket {
+	t.Helper()
+	return &TestRocket{Client: Dial(t, url), ID: nextID("rocket")}
+}
+
+// RocketConfig - исправная конфигурация небольшой ракеты
+func RocketConfig(name string) protocol.RocketConfig {
+	return protocol.RocketConfig{
+		Name:            name,
+		MassEmpty:       1000,
+		MassFuel:        500,
+		MassFuelMax:     500,
+		DragCoefficient: 0.3,
+		CrossSection:    1,
+		Engines:         []protocol.Engine{{Thrust: 30000, FuelConsumption: 10, IsActive: true}},
+	}
+}
+
+// Register отправляет register и возвращает ответ сервера: accepted или
+// rejected
+func (r *TestRocket) Register(config protocol.RocketConfig) protocol.Message {
+	r.t.Helper()
+	r.Send(protocol.MsgTypeRegister, protocol.RegisterMessage{RocketID: r.ID, Config: config})
+	return r.expect(0, "ответ на register", func(msg protocol.Message) bool {
+		return msg.Type == protocol.MsgTypeAccepted || msg.Type == protocol.MsgTypeRejected
+	})
+}
+
+// MustRegister регистрирует ракету и проваливает тест при отказе
+func (r *TestRocket) MustRegister(config protocol.RocketConfig) {
+	r.t.Helper()
+	if msg := r.Register(config); msg.Type != protocol.MsgTypeAccepted {
+		r.t.Fatalf("ракета %s не зарегистрирована: %s %+v", r.ID, msg.Type, msg.Data)
+	}
+}
+
+// SendTelemetry отправляет кадр телеметрии
+func (r *TestRocket) SendTelemetry(state protocol.RocketState) {
+	r.t.Helper()
+	r.Send(protocol.MsgTypeTelemetry, protocol.TelemetryMessage{RocketID: r.ID, State: state})
+}
+
+// TestObserver - наблюдатель, подписанный на события всех ракет
+type TestObserver struct {
+	*Client
+	ID string
+}
+
+// NewTestObserver подключается и отправляет subscribe. Подтверждения у
+// подписки нет: сервер сразу присылает rocket_joined по текущим ракетам.
+func NewTestObserver(t testing.TB, url string) *TestObserver {
+	t.Helper()
+	o := &TestObserver{Client: Dial(t, url), ID: nextID("observer")}
+	o.Send(protocol.MsgTypeSubscribe, protocol.SubscribeMessage{ObserverID: o.ID})
+	return o
+}
@@ -0,0 +1,92 @@
+package main
+
+import "cosmodrom/protocol"
+
+// logCatalog - тексты записей журнала сервера по коду. Код и поля записи
+// уходят в /api/logs, текст собирается на языке -lang.
+var logCatalog = protocol.LogCatalog{
+	// Запуск и остановка
+	"server_started":  {RU: "Сервер запущен на {addr}", EN: "Server started on {addr}"},
+	"server_stopped":  {RU: "Сервер остановлен", EN: "Server stopped"},
+	"flag_invalid":    {RU: "Ошибка в {flag}: {error}", EN: "Invalid {flag}: {error}"},
+	"dashboard_dir":   {RU: "Панель из каталога {dir}", EN: "Dashboard from directory {dir}"},
+	"vehicles_loaded": {RU: "Каталог ракет из {path}: {count} шт.", EN: "Vehicle catalog from {path}: {count} vehicles"},
+	"audit_open_failed": {RU: "Ошибка открытия журнала команд: {error}",
+		EN: "Failed to open command log: {error}"},
+	"audit_file":         {RU: "Журнал команд записывается в {path}", EN: "Command log is written to {path}"},
+	"audit_write_failed": {RU: "Ошибка записи журнала команд: {error}", EN: "Failed to write command log: {error}"},
+	"dashboard_template_failed": {RU: "Ошибка шаблона панели: {error}",
+		EN: "Dashboard template error: {error}"},
+
+	// Режим drain
+	"drain_enabled": {RU: "Режим drain включен, активных ракет: {active}", EN: "Drain mode on, active rockets: {active}"},
+	"drain_disabled": {RU: "Режим drain выключен, регистрация ракет возобновлена",
+		EN: "Drain mode off, rocket registration resumed"},
+	"drain_shutdown": {RU: "Активных ракет не осталось, остановка сервера...",
+		EN: "No active rockets left, shutting down..."},
+	"shutdown_failed": {RU: "Ошибка остановки сервера: {error}", EN: "Server shutdown failed: {error}"},
+	"sigusr1":         {RU: "Получен SIGUSR1", EN: "Received SIGUSR1"},
+
+	// Соединения
+	"ws_upgrade_failed": {RU: "Ошибка при обновлении до WebSocket: {error}", EN: "WebSocket upgrade failed: {error}"},
+	"conn_opened":       {RU: "Новое подключение от {remote_addr}", EN: "New connection from {remote_addr}"},
+	"message_dropped": {RU: "Сообщение {type:%q} отброшено ({code}): {detail}[[; еще {suppressed} с тем же кодом]]",
+		EN: "Message {type:%q} dropped ({code}): {detail}[[; {suppressed} more with the same code]]"},
+	"message_encode_failed": {RU: "Ошибка кодирования сообщения {type}: {error}", EN: "Failed to encode {type} message: {error}"},
+	"message_send_failed":   {RU: "Ошибка отправки сообщения: {error}", EN: "Failed to send message: {error}"},
+
+	// Ракеты
+	"rocket_registered": {
+		RU: "Ракета {rocket_id} ({name}) зарегистрирована[[, цель: орбита {target_orbit_km:%.0f} км]][[, {target_inclination:%.1f}°]][[, космодром {launch_site}]][[, пилотируемая{crewed:}]]",
+		EN: "Rocket {rocket_id} ({name}) registered[[, target: {target_orbit_km:%.0f} km orbit]][[, {target_inclination:%.1f}°]][[, launch site {launch_site}]][[, crewed{crewed:}]]",
+	},
+	"rocket_rejected":           {RU: "Ракета {rocket_id} отклонена: {error}", EN: "Rocket {rocket_id} rejected: {error}"},
+	"rocket_rejected_draining":  {RU: "Ракета {rocket_id} отклонена: сервер в режиме drain", EN: "Rocket {rocket_id} rejected: server is draining"},
+	"rocket_rejected_duplicate": {RU: "Ракета {rocket_id} отклонена: ID уже зарегистрирован", EN: "Rocket {rocket_id} rejected: ID already registered"},
+	"engine_warning":            {RU: "Ракета {rocket_id}: двигатель {warning}", EN: "Rocket {rocket_id}: engine {warning}"},
+	"vehicle_sent":              {RU: "Ракете {rocket_id} выдана конфигурация {vehicle:%q}", EN: "Sent vehicle {vehicle:%q} to rocket {rocket_id}"},
+	"vehicle_unknown": {RU: "Ракета {rocket_id} запросила неизвестную конфигурацию {vehicle:%q}",
+		EN: "Rocket {rocket_id} requested unknown vehicle {vehicle:%q}"},
+	"rocket_disconnected":         {RU: "Ракета {rocket_id} отключилась: {error}", EN: "Rocket {rocket_id} disconnected: {error}"},
+	"rocket_disconnect_requested": {RU: "Ракета {rocket_id} запросила отключение", EN: "Rocket {rocket_id} requested disconnect"},
+	"rocket_removed":              {RU: "Ракета {rocket_id} ({name}) удалена из списка", EN: "Rocket {rocket_id} ({name}) removed"},
+	"rocket_aborted": {RU: "Ракета {rocket_id} прекратила полет: {reason} (T+{time:%.1f} с, высота {altitude_km:%.2f} км)",
+		EN: "Rocket {rocket_id} aborted: {reason} (T+{time:%.1f} s, altitude {altitude_km:%.2f} km)"},
+	"mission_achieved": {
+		RU: "Ракета {rocket_id}: цель миссии достигнута: орбита {periapsis_km:%.0f} x {apoapsis_km:%.0f} км, наклонение {inclination:%.1f}° (цель {target_orbit_km:%.0f} км[[, {target_inclination:%.1f}°]])",
+		EN: "Rocket {rocket_id}: mission target achieved: {periapsis_km:%.0f} x {apoapsis_km:%.0f} km orbit, inclination {inclination:%.1f}° (target {target_orbit_km:%.0f} km[[, {target_inclination:%.1f}°]])",
+	},
+	"command_sent": {RU: "Команда #{command_id} ({source}) отправлена ракете {rocket_id}: {status}",
+		EN: "Command #{command_id} ({source}) sent to rocket {rocket_id}: {status}"},
+
+	// Телеметрия
+	"telemetry_progress": {RU: "Высота={altitude_km:%.2f} км, скорость={speed:%.1f} м/с, топливо={fuel:%.0f} кг",
+		EN: "Altitude={altitude_km:%.2f} km, speed={speed:%.1f} m/s, fuel={fuel:%.0f} kg"},
+	"telemetry_batch": {RU: "Получен пакет телеметрии: {frames} кадров, T+{first_time:%.1f}..{last_time:%.1f} с",
+		EN: "Received telemetry batch: {frames} frames, T+{first_time:%.1f}..{last_time:%.1f} s"},
+	"telemetry_batch_too_large": {RU: "Пакет телеметрии из {frames} кадров больше предела {limit}, отброшен",
+		EN: "Telemetry batch of {frames} frames exceeds the limit of {limit}, dropped"},
+	"telemetry_batch_frame_skipped": {RU: "Кадр {index} пакета телеметрии пропущен: {error}",
+		EN: "Telemetry batch frame {index} skipped: {error}"},
+	"telemetry_out_of_order": {RU: "Телеметрия #{seq} отброшена: повтор или не по порядку (последняя #{last_seq})",
+		EN: "Telemetry #{seq} dropped: duplicate or out of order (last #{last_seq})"},
+	"telemetry_gap": {RU: "Пропущено {gap} сообщений перед #{seq}: возможна потеря телеметрии",
+		EN: "{gap} messages missing before #{seq}: telemetry may be lost"},
+	"clock_skew": {RU: "Часы ракеты расходятся с сервером на {skew}: задержка доставки по меткам времени неточна",
+		EN: "Rocket clock is off from the server by {skew}: transit delay from timestamps is inaccurate"},
+	"proximity": {RU: "Ракеты {rocket_id} и {other_rocket_id} на расстоянии {distance:%.1f} м",
+		EN: "Rockets {rocket_id} and {other_rocket_id} are {distance:%.1f} m apart"},
+	"proximity_rocket": {RU: "Сближение с {other_rocket_id}: {distance:%.1f} м", EN: "Approach with {other_rocket_id}: {distance:%.1f} m"},
+
+	// Наблюдатели
+	"observer_subscribed":   {RU: "Наблюдатель {observer_id} подписался на события", EN: "Observer {observer_id} subscribed to events"},
+	"observer_unsubscribed": {RU: "Наблюдатель {observer_id} отписался", EN: "Observer {observer_id} unsubscribed"},
+	"observer_disconnected": {RU: "Наблюдатель {observer_id} отключился: {error}", EN: "Observer {observer_id} disconnected: {error}"},
+	"observer_removed":      {RU: "Наблюдатель {observer_id} удален из списка", EN: "Observer {observer_id} removed"},
+	"observer_too_slow": {RU: "Наблюдатель {observer_id} не успевает читать поток событий, отключен",
+		EN: "Observer {observer_id} cannot keep up with the event stream, disconnected"},
+	"observer_send_failed": {RU: "Ошибка отправки сообщения наблюдателю {observer_id}: {error}",
+		EN: "Failed to send message to observer {observer_id}: {error}"},
+	"stream_connected": {RU: "Наблюдатель {observer_id} подключился к потоку событий с {remote_addr}",
+		EN: "Observer {observer_id} connected to the event stream from {remote_addr}"},
+}
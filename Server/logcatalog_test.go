@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"cosmodrom/protocol"
+)
+
+func TestLogCatalogComplete(t *testing.T) {
+	if err := logCatalog.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Каждый код, который передают serverLog, connLog и fatalLog, есть в
+// каталоге, и в каталоге нет неиспользуемых кодов
+func TestLogCatalogCodesUsed(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	codeArg := map[string]int{"serverLog": 1, "connLog": 3, "fatalLog": 0}
+	used := map[string]bool{}
+	fset := token.NewFileSet()
+	for _, path := range files {
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			fn, ok := call.Fun.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			index, logs := codeArg[fn.Name]
+			if !logs || len(call.Args) <= index {
+				return true
+			}
+			if ident, ok := call.Args[index].(*ast.Ident); ok && ident.Name == "code" {
+				return true // Сами функции журнала передают код дальше
+			}
+			literal, ok := call.Args[index].(*ast.BasicLit)
+			if !ok {
+				t.Errorf("%s: код записи не строковая константа", fset.Position(call.Pos()))
+				return true
+			}
+			code, _ := strconv.Unquote(literal.Value)
+			if _, ok := logCatalog[code]; !ok {
+				t.Errorf("%s: кода %q нет в каталоге", fset.Position(call.Pos()), code)
+			}
+			used[code] = true
+			return true
+		})
+	}
+	for code := range logCatalog {
+		if !used[code] {
+			t.Errorf("код %q не используется", code)
+		}
+	}
+}
+
+func TestLogEntryLanguage(t *testing.T) {
+	defer func(lang protocol.LogLang) { logLang = lang }(logLang)
+	logLang = protocol.LogLangEN
+
+	entry := newLogEntry("warning", "proximity_rocket", protocol.LogFields{"other_rocket_id": "r2", "distance": 812.34})
+	if entry.Message != "Approach with r2: 812.3 m" || entry.Code != "proximity_rocket" {
+		t.Errorf("запись %+v", entry)
+	}
+	entry = newLogEntry("info", "no_such_code", protocol.LogFields{"rocket_id": "r1", "error": errors.New("обрыв")})
+	if entry.Message != "no_such_code error=обрыв rocket_id=r1" {
+		t.Errorf("неизвестный код: %q", entry.Message)
+	}
+}
+
+// /api/logs отдает код и поля записи и по ?lang= пересобирает текст
+func TestHandleLogsLanguage(t *testing.T) {
+	connLog("logtest", "r1", "warning", "rocket_aborted", protocol.LogFields{
+		"rocket_id": "r1", "reason": "перегрузка", "time": 42.0, "altitude_km": 12.5,
+	})
+
+	for _, tc := range []struct{ query, want string }{
+		{"", "Ракета r1 прекратила полет: перегрузка (T+42.0 с, высота 12.50 км)"},
+		{"&lang=en", "Rocket r1 aborted: перегрузка (T+42.0 s, altitude 12.50 km)"},
+	} {
+		rec := httptest.NewRecorder()
+		NewServer(protocol.JSON).handleLogs(rec, httptest.NewRequest(http.MethodGet, "/api/logs?conn_id=logtest"+tc.query, nil))
+		var logs []LogEntry
+		if err := json.NewDecoder(rec.Body).Decode(&logs); err != nil {
+			t.Fatal(err)
+		}
+		if len(logs) != 1 || logs[0].Message != tc.want {
+			t.Fatalf("%q: записи %+v", tc.query, logs)
+		}
+		if logs[0].Code != "rocket_aborted" || logs[0].Fields["altitude_km"] != 12.5 {
+			t.Errorf("код %q, поля %v", logs[0].Code, logs[0].Fields)
+		}
+	}
+}
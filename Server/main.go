@@ -1,25 +1,35 @@
 package main
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
-	"math"
+	"net"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
-	"cosmodrom/server/protocol"
+	"cosmodrom/protocol"
 
 	"github.com/gorilla/websocket"
 )
 
 type LogEntry struct {
-	Timestamp time.Time `json:"timestamp"`
-	Message   string    `json:"message"`
-	Level     string    `json:"level"`
-	RocketID  string    `json:"rocket_id,omitempty"`
+	Timestamp time.Time          `json:"timestamp"`
+	Message   string             `json:"message"` // Текст на языке -lang
+	Level     string             `json:"level"`
+	Code      string             `json:"code"`             // Код записи из logCatalog
+	Fields    protocol.LogFields `json:"fields,omitempty"` // Поля для текста на другом языке
+	RocketID  string             `json:"rocket_id,omitempty"`
+	ConnID    string             `json:"conn_id,omitempty"`
 }
 
 type LogBuffer struct {
@@ -35,19 +45,10 @@ func NewLogBuffer(maxSize int) *LogBuffer {
 	}
 }
 
-func (lb *LogBuffer) Add(level, message string) {
-	lb.AddWithRocket(level, message, "")
-}
-
-func (lb *LogBuffer) AddWithRocket(level, message, rocketID string) {
+func (lb *LogBuffer) AddEntry(entry LogEntry) {
 	lb.mu.Lock()
 	defer lb.mu.Unlock()
-	entry := LogEntry{
-		Timestamp: time.Now(),
-		Message:   message,
-		Level:     level,
-		RocketID:  rocketID,
-	}
+	entry.Timestamp = time.Now()
 	if len(lb.entries) >= lb.maxSize {
 		lb.entries = lb.entries[1:]
 	}
@@ -88,313 +89,897 @@ func (lb *LogBuffer) GetByRocket(rocketID string, since time.Time) []LogEntry {
 	return result
 }
 
-var serverLogs = NewLogBuffer(500)
+func (lb *LogBuffer) GetByConnection(connID string, since time.Time) []LogEntry {
+	lb.mu.RLock()
+	defer lb.mu.RUnlock()
+	var result []LogEntry
+	for _, entry := range lb.entries {
+		if entry.ConnID == connID && (since.IsZero() || entry.Timestamp.After(since)) {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+var (
+	serverLogs = NewLogBuffer(500)
+	logLang    = protocol.LogLangRU // Язык текста журнала, -lang
+)
 
-func serverLog(level, format string, args ...interface{}) {
-	msg := fmt.Sprintf(format, args...)
-	log.Print(msg)
-	serverLogs.Add(level, msg)
+// serverLog пишет запись с кодом из logCatalog; текст собирается из полей
+func serverLog(level, code string, fields protocol.LogFields) {
+	entry := newLogEntry(level, code, fields)
+	log.Print(entry.Message)
+	serverLogs.AddEntry(entry)
 }
 
-func rocketLog(rocketID, level, format string, args ...interface{}) {
-	msg := fmt.Sprintf(format, args...)
-	log.Print(msg)
-	serverLogs.AddWithRocket(level, msg, rocketID)
+// connLog пишет запись с ID WebSocket-соединения, чтобы связать все события
+// одного подключения, включая те, что произошли до регистрации ракеты
+func connLog(connID, rocketID, level, code string, fields protocol.LogFields) {
+	entry := newLogEntry(level, code, fields)
+	entry.RocketID = rocketID
+	entry.ConnID = connID
+	log.Printf("[%s] %s", connID, entry.Message)
+	serverLogs.AddEntry(entry)
 }
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		return true
-	},
+// fatalLog пишет ошибку запуска и завершает процесс
+func fatalLog(code string, fields protocol.LogFields) {
+	serverLog("error", code, fields)
+	os.Exit(1)
+}
+
+func newLogEntry(level, code string, fields protocol.LogFields) LogEntry {
+	for key, value := range fields {
+		fields[key] = protocol.LogValue(value)
+	}
+	return LogEntry{
+		Message: logCatalog.Format(logLang, code, fields),
+		Level:   level,
+		Code:    code,
+		Fields:  fields,
+	}
+}
+
+func newConnID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return hex.EncodeToString(b)
 }
 
 type RocketConnection struct {
-	ID         string
-	Conn       *websocket.Conn
-	Config     protocol.RocketConfig
-	State      protocol.RocketState
-	LastUpdate time.Time
-	mu         sync.RWMutex
+	ID             string
+	ConnID         string
+	Conn           *websocket.Conn
+	Codec          protocol.Codec // Кодек, которым ракета зарегистрировалась
+	Config         protocol.RocketConfig
+	Mission        *protocol.Mission // Цель полета из регистрации, nil у старых клиентов
+	State          protocol.RocketState
+	LastUpdate     time.Time
+	ConnectedAt    time.Time
+	Stats          RocketStats
+	recentWarnings []WarningRecord
+	sequence       sequenceTracker   // Порядок сообщений ракеты по Message.Seq
+	broadcastSeq   uint64            // Номер последнего кадра ракеты, разосланного наблюдателям
+	skewLogged     bool              // Расхождение часов ракеты уже записано в лог
+	missionDone    bool              // Событие target_achieved уже разослано
+	history        *telemetryHistory // Последние кадры для выгрузки, nil - без истории
+	mu             sync.RWMutex
+	writeMu        *sync.Mutex // Блокировка записи в сокет, общая с сессией /ws
 }
 
 type ObserverConnection struct {
 	ID         string
-	Conn       *websocket.Conn
+	ConnID     string
+	Conn       *websocket.Conn // nil у наблюдателя /api/stream
+	Codec      protocol.Codec
+	Labels     map[string]string   // Фильтр ракет по меткам
+	RocketIDs  map[string]struct{} // Фильтр ракет по ID, nil - все
 	LastUpdate time.Time
-	mu         sync.RWMutex
+	stream     *eventStream // Очередь событий SSE вместо Conn
+	writeMu    *sync.Mutex  // Блокировка записи в Conn, общая с сессией /ws
+}
+
+// matches - события ракеты rocketID с метками labels нужны наблюдателю
+func (o *ObserverConnection) matches(rocketID string, labels map[string]string) bool {
+	if o.RocketIDs != nil {
+		if _, ok := o.RocketIDs[rocketID]; !ok {
+			return false
+		}
+	}
+	return protocol.MatchLabels(o.Labels, labels)
+}
+
+// rocketFilter - фильтр по ID из списка, nil для пустого списка
+func rocketFilter(ids []string) map[string]struct{} {
+	if len(ids) == 0 {
+		return nil
+	}
+	filter := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		filter[id] = struct{}{}
+	}
+	return filter
 }
 
 type Server struct {
 	rockets                map[string]*RocketConnection
 	observers              map[string]*ObserverConnection
+	observerList           []*ObserverConnection // Снимок observers для рассылки: пересобирается при подписке и отписке, не меняется после
 	mu                     sync.RWMutex
 	collisionCheckInterval time.Duration
 	minSafeDistance        float64
+	audit                  *AuditLog
+	wsLimiter              *IPRateLimiter
+	allowedOrigins         []string
+	adminToken             string // Токен для /api/command, /api/audit, /api/admin/* и /debug/*, пусто - без проверки
+	observerToken          string // Токен подписки наблюдателей и /api/stream, пусто - без проверки
+	debug                  bool   // Включить /debug/pprof и /debug/vars
+	upgrader               websocket.Upgrader
+	draining               bool    // Новые ракеты не принимаются, текущие летят до конца
+	msgRate                float64 // Лимит входящих сообщений одного соединения в секунду, 0 - без лимита
+	msgBurst               int
+	shutdownWhenEmpty      bool // Остановить сервер, когда в режиме drain не останется ракет
+	httpServer             *http.Server
+	vehicles               map[string]protocol.RocketConfig // Каталог ракет из -config
+	clock                  protocol.Clock
+	codec                  protocol.Codec // Кодек двоичных кадров; текстовые кадры всегда JSON
+	dashboard              *dashboard     // Шаблон и файлы панели на /
+	fleet                  *fleetTracker  // Сводка парка для /api/summary
+	historySize            int            // Кадров в истории ракеты, 0 - без истории
+	startedAt              time.Time
 }
 
-func NewServer() *Server {
-	return &Server{
+// NewServer создает сервер. codec разбирает кадры BinaryMessage: с
+// protocol.JSON сервер принимает только текстовые кадры.
+func NewServer(codec protocol.Codec) *Server {
+	s := &Server{
 		rockets:                make(map[string]*RocketConnection),
 		observers:              make(map[string]*ObserverConnection),
 		collisionCheckInterval: 1 * time.Second,
 		minSafeDistance:        1000.0,
+		audit:                  NewAuditLog(1000),
+		msgRate:                100,
+		msgBurst:               200,
+		clock:                  protocol.SystemClock,
+		codec:                  codec,
+		fleet:                  newFleetTracker(),
+		historySize:            defaultHistorySize,
+		startedAt:              protocol.SystemClock.Now(),
 	}
+	dashboard, err := newDashboard("")
+	if err != nil {
+		panic(err) // Вшитый шаблон проверяется тестами
+	}
+	s.dashboard = dashboard
+	s.upgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin:     s.checkWebSocketOrigin,
+	}
+	return s
 }
 
 func (s *Server) Start(port string) error {
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		return err
+	}
+	go s.watchDrainSignal()
+	return s.Serve(listener)
+}
+
+// Serve обслуживает соединения listener до Close или остановки в режиме
+// drain. Тесты запускают так сервер на свободном порту.
+func (s *Server) Serve(listener net.Listener) error {
+	stop := make(chan struct{})
+	defer close(stop)
+	go s.collisionCheckLoop(stop)
 
-	go s.collisionCheckLoop()
+	httpServer := &http.Server{Handler: s.routes()}
+	s.mu.Lock()
+	s.httpServer = httpServer
+	s.mu.Unlock()
 
-	http.HandleFunc("/ws", s.handleWebSocket)
-	http.HandleFunc("/rockets", s.handleRocketList)
-	http.HandleFunc("/", s.handleIndex)
+	serverLog("info", "server_started", protocol.LogFields{"addr": listener.Addr().String()})
+	if err := httpServer.Serve(listener); err != http.ErrServerClosed {
+		return err
+	}
+	serverLog("info", "server_stopped", nil)
+	return nil
+}
+
+// Close останавливает Serve сразу, не дожидаясь запросов. Соединения
+// WebSocket закрывают сами клиенты.
+func (s *Server) Close() error {
+	s.mu.RLock()
+	httpServer := s.httpServer
+	s.mu.RUnlock()
+	if httpServer == nil {
+		return nil
+	}
+	return httpServer.Close()
+}
 
-	http.HandleFunc("/api/logs", s.handleLogs)
+func (s *Server) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/ws", s.handleWebSocket)
+	mux.HandleFunc("/rockets", s.withCORS(s.handleRocketList))
+	mux.HandleFunc("/{$}", s.handleIndex)
+	mux.HandleFunc("/static/", s.handleStatic)
+
+	mux.HandleFunc("/api/logs", s.withCORS(s.handleLogs))
+	mux.HandleFunc("/api/rockets/{id}", s.withCORS(s.handleRocketDetails))
+	mux.HandleFunc("/api/rockets/{id}/telemetry.csv", s.withCORS(s.handleTelemetryExport))
+	mux.HandleFunc("/api/rockets/{id}/telemetry.ndjson", s.withCORS(s.handleTelemetryExport))
+	mux.HandleFunc("/api/command", s.withCORS(s.requireAdmin(s.handleCommand)))
+	mux.HandleFunc("/api/stream", s.withCORS(s.handleStream))
+	mux.HandleFunc("/api/audit", s.withCORS(s.requireAdmin(s.handleAudit)))
+	mux.HandleFunc("/api/status", s.withCORS(s.handleStatus))
+	mux.HandleFunc("/api/summary", s.withCORS(s.handleSummary))
+	mux.HandleFunc("/api/admin/drain", s.withCORS(s.requireAdmin(s.handleDrain)))
+
+	if s.debug {
+		s.registerDebugRoutes(mux)
+	}
 
-	addr := ":" + port
-	serverLog("info", "Сервер запущен на %s", addr)
-	return http.ListenAndServe(addr, nil)
+	return mux
 }
 
 func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+	if !s.wsLimiter.Allow(remoteIP(r.RemoteAddr)) {
+		http.Error(w, "too many connection attempts", http.StatusTooManyRequests)
+		return
+	}
+
+	conn, err := s.upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		serverLog("error", "Ошибка при обновлении до WebSocket: %v", err)
+		serverLog("error", "ws_upgrade_failed", protocol.LogFields{"error": err})
 		return
 	}
 
-	serverLog("info", "Новое подключение от %s", conn.RemoteAddr())
+	connID := newConnID()
+	connLog(connID, "", "info", "conn_opened", protocol.LogFields{"remote_addr": conn.RemoteAddr()})
 
-	go s.handleClient(conn)
+	go s.handleClient(conn, connID)
 }
 
-func (s *Server) handleClient(conn *websocket.Conn) {
+func (s *Server) handleClient(conn *websocket.Conn, connID string) {
 	defer conn.Close()
 
-	var rocketConn *RocketConnection
-	var observerConn *ObserverConnection
-
+	session := s.newClientSession(conn, connID)
 	for {
-		_, msgBytes, err := conn.ReadMessage()
+		frameType, msgBytes, err := conn.ReadMessage()
 		if err != nil {
-			if rocketConn != nil {
-				serverLog("warning", "Ракета %s отключилась: %v", rocketConn.ID, err)
-				s.removeRocket(rocketConn.ID)
-			}
-			if observerConn != nil {
-				serverLog("info", "Наблюдатель %s отключился: %v", observerConn.ID, err)
-				s.removeObserver(observerConn.ID)
-			}
+			s.closeSession(session, err)
+			return
+		}
+		if s.handleMessage(session, frameType, msgBytes) {
+			s.closeSession(session, errClientLeft)
+			return
+		}
+	}
+}
+
+// errClientLeft - причина в журнале для того, что осталось от соединения
+// после disconnect или unsubscribe: ракета может быть и наблюдателем
+var errClientLeft = errors.New("клиент закрыл соединение")
+
+// clientSession - состояние соединения /ws между сообщениями: ракета или
+// наблюдатель, которых оно зарегистрировало, ответы error и лимит сообщений.
+// В сокет пишут горутина чтения, рассылка наблюдателям и проверка сближений,
+// а gorilla/websocket допускает только одного писателя, поэтому любая запись
+// в соединение - ответ, команда ракете или кадр наблюдателю - идет под
+// writeMu сессии.
+type clientSession struct {
+	conn     *websocket.Conn
+	connID   string
+	rocket   *RocketConnection
+	observer *ObserverConnection
+	errs     *errorReporter
+	limiter  *messageLimiter
+	writeMu  sync.Mutex
+}
+
+func (s *Server) newClientSession(conn *websocket.Conn, connID string) *clientSession {
+	session := &clientSession{
+		conn:    conn,
+		connID:  connID,
+		limiter: newMessageLimiter(s.msgRate, s.msgBurst),
+	}
+	session.errs = newErrorReporter(s, conn, &session.writeMu, connID)
+	return session
+}
+
+// closeSession убирает ракету и наблюдателя соединения, когда оно закрыто
+func (s *Server) closeSession(session *clientSession, err error) {
+	if session.rocket != nil {
+		connLog(session.connID, "", "warning", "rocket_disconnected", protocol.LogFields{"rocket_id": session.rocket.ID, "error": err})
+		s.removeRocket(session.rocket.ID)
+	}
+	if session.observer != nil {
+		connLog(session.connID, "", "info", "observer_disconnected", protocol.LogFields{"observer_id": session.observer.ID, "error": err})
+		s.removeObserver(session.observer.ID)
+	}
+}
+
+// handleMessage разбирает кадр клиента и передает его обработчику типа.
+// true - клиент попросил отключиться, и соединение закрывается.
+func (s *Server) handleMessage(session *clientSession, frameType int, msgBytes []byte) bool {
+	connID, errs := session.connID, session.errs
+
+	// Ответ идет тем же кодеком, что и последний кадр клиента
+	codec, err := s.frameCodec(frameType)
+	if err != nil {
+		errs.report(protocol.ErrorCodeDecode, protocol.Message{}, err.Error())
+		return false
+	}
+	errs.codec = codec
+	msg, err := codec.Decode(msgBytes)
+	if err != nil {
+		errs.report(protocol.ErrorCodeDecode, protocol.Message{}, err.Error())
+		return false
+	}
+	if !session.limiter.allow(s.clock.Now()) {
+		errs.report(protocol.ErrorCodeRateLimited, msg, "превышен лимит сообщений соединения")
+		return false
+	}
+
+	switch msg.Type {
+	case protocol.MsgTypeConfigRequest:
+		err = s.handleConfigRequest(session, codec, msg)
+
+	case protocol.MsgTypeRegister:
+		// Вторая регистрация заменила бы ракету соединения, и первая
+		// осталась бы в списке после отключения
+		if session.rocket != nil {
+			errs.report(protocol.ErrorCodeAlreadyRegistered, msg, "ракета "+session.rocket.ID+" уже зарегистрирована в этом соединении")
 			break
 		}
+		session.rocket, err = s.handleRegister(session, codec, msg)
+		errs.rocket = session.rocket
+
+	case protocol.MsgTypeTelemetry:
+		if session.rocket != nil {
+			err = s.handleTelemetry(session.rocket, msg)
+		} else {
+			errs.report(protocol.ErrorCodeNotRegistered, msg, "телеметрия до регистрации ракеты")
+		}
 
-		var msg protocol.Message
-		if err := json.Unmarshal(msgBytes, &msg); err != nil {
-			serverLog("error", "Ошибка декодирования сообщения: %v", err)
-			continue
+	case protocol.MsgTypeTelemetryBatch:
+		if session.rocket != nil {
+			err = s.handleTelemetryBatch(session.rocket, msg)
+		} else {
+			errs.report(protocol.ErrorCodeNotRegistered, msg, "телеметрия до регистрации ракеты")
 		}
 
-		switch msg.Type {
-		case protocol.MsgTypeRegister:
-			rocketConn = s.handleRegister(conn, msg)
+	case protocol.MsgTypeAbort:
+		if session.rocket != nil {
+			err = s.handleAbort(session.rocket, msg)
+		} else {
+			errs.report(protocol.ErrorCodeNotRegistered, msg, "abort до регистрации ракеты")
+		}
 
-		case protocol.MsgTypeTelemetry:
-			if rocketConn != nil {
-				s.handleTelemetry(rocketConn, msg)
-			}
+	case protocol.MsgTypeHeartbeat:
+		if session.rocket != nil {
+			// Эхо без разбора: клиент сам сверяет nonce и считает время ответа
+			s.sendToRocket(session.rocket, protocol.MsgTypeHeartbeat, msg.Data)
+		} else {
+			errs.report(protocol.ErrorCodeNotRegistered, msg, "heartbeat до регистрации ракеты")
+		}
 
-		case protocol.MsgTypeDisconnect:
-			if rocketConn != nil {
-				serverLog("info", "Ракета %s запросила отключение", rocketConn.ID)
-				s.removeRocket(rocketConn.ID)
-				return
-			}
+	case protocol.MsgTypeDisconnect:
+		if session.rocket != nil {
+			connLog(connID, "", "info", "rocket_disconnect_requested", protocol.LogFields{"rocket_id": session.rocket.ID})
+			s.removeRocket(session.rocket.ID)
+			session.rocket = nil
+			return true
+		}
 
-		case protocol.MsgTypeSubscribe:
-			observerConn = s.handleSubscribe(conn, msg)
+	case protocol.MsgTypeSubscribe:
+		if session.observer != nil {
+			errs.report(protocol.ErrorCodeAlreadyRegistered, msg, "наблюдатель "+session.observer.ID+" уже подписан в этом соединении")
+			break
+		}
+		var unauthorized bool
+		session.observer, unauthorized, err = s.handleSubscribe(session, codec, msg)
+		if unauthorized {
+			errs.report(protocol.ErrorCodeUnauthorized, msg, "неверный токен наблюдателя")
+		}
 
-		case protocol.MsgTypeUnsubscribe:
-			if observerConn != nil {
-				log.Printf("Наблюдатель %s отписался", observerConn.ID)
-				s.removeObserver(observerConn.ID)
-				return
-			}
+	case protocol.MsgTypeUnsubscribe:
+		if session.observer != nil {
+			connLog(connID, "", "info", "observer_unsubscribed", protocol.LogFields{"observer_id": session.observer.ID})
+			s.removeObserver(session.observer.ID)
+			session.observer = nil
+			return true
 		}
+
+	default:
+		errs.report(protocol.ErrorCodeUnknownType, msg, "неизвестный тип сообщения")
+	}
+
+	if err != nil {
+		errs.report(protocol.ErrorCodeDecode, msg, err.Error())
 	}
+	return false
 }
 
-func (s *Server) handleRegister(conn *websocket.Conn, msg protocol.Message) *RocketConnection {
-	data, _ := json.Marshal(msg.Data)
-	var registerMsg protocol.RegisterMessage
-	if err := json.Unmarshal(data, &registerMsg); err != nil {
-		serverLog("error", "Ошибка декодирования регистрации: %v", err)
-		return nil
+// handleRegister регистрирует ракету. Ошибка - только если сообщение не
+// разобрано; об отказе клиент узнает из rejected, и ракета тогда nil.
+func (s *Server) handleRegister(session *clientSession, codec protocol.Codec, msg protocol.Message) (*RocketConnection, error) {
+	connID := session.connID
+	// Регистрацию пишут и вручную, поэтому опечатка в имени поля - отказ,
+	// а не ракета с нулевым значением
+	registerMsg, err := protocol.DecodeDataStrict[protocol.RegisterMessage](msg)
+	var unknown *protocol.UnknownFieldError
+	if errors.As(err, &unknown) {
+		s.sendToSession(session, codec, protocol.MsgTypeRejected, protocol.RejectedMessage{
+			RocketID: registerMsg.RocketID,
+			Code:     protocol.RejectCodeUnknownField,
+			Reason:   err.Error(),
+		})
+		connLog(connID, "", "warning", "rocket_rejected", protocol.LogFields{"rocket_id": registerMsg.RocketID, "error": err})
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
 	}
 
 	if err := protocol.ValidateRocketConfig(&registerMsg.Config); err != nil {
-		s.sendMessage(conn, protocol.MsgTypeRejected, protocol.RejectedMessage{
+		s.sendToSession(session, codec, protocol.MsgTypeRejected, protocol.RejectedMessage{
 			RocketID: registerMsg.RocketID,
+			Code:     protocol.RejectCodeInvalidConfig,
 			Reason:   err.Error(),
 		})
-		return nil
+		connLog(connID, "", "warning", "rocket_rejected", protocol.LogFields{"rocket_id": registerMsg.RocketID, "error": err})
+		return nil, nil
 	}
-
-	s.mu.RLock()
-	_, exists := s.rockets[registerMsg.RocketID]
-	s.mu.RUnlock()
-
-	if exists {
-		s.sendMessage(conn, protocol.MsgTypeRejected, protocol.RejectedMessage{
+	if err := protocol.ValidateMission(registerMsg.Mission); err != nil {
+		s.sendToSession(session, codec, protocol.MsgTypeRejected, protocol.RejectedMessage{
 			RocketID: registerMsg.RocketID,
-			Reason:   "ракета с таким ID уже зарегистрирована",
+			Code:     protocol.RejectCodeInvalidConfig,
+			Reason:   err.Error(),
 		})
-		return nil
+		connLog(connID, "", "warning", "rocket_rejected", protocol.LogFields{"rocket_id": registerMsg.RocketID, "error": err})
+		return nil, nil
+	}
+	for _, warning := range protocol.EngineWarnings(&registerMsg.Config) {
+		connLog(connID, registerMsg.RocketID, "warning", "engine_warning", protocol.LogFields{"rocket_id": registerMsg.RocketID, "warning": warning})
 	}
 
 	rocketConn := &RocketConnection{
-		ID:         registerMsg.RocketID,
-		Conn:       conn,
-		Config:     registerMsg.Config,
-		LastUpdate: time.Now(),
+		ID:          registerMsg.RocketID,
+		ConnID:      connID,
+		Conn:        session.conn,
+		Codec:       codec,
+		Config:      registerMsg.Config,
+		Mission:     registerMsg.Mission,
+		LastUpdate:  s.clock.Now(),
+		ConnectedAt: s.clock.Now(),
+		writeMu:     &session.writeMu,
+	}
+	if s.historySize > 0 {
+		rocketConn.history = newTelemetryHistory(s.historySize)
 	}
 
+	// Флаг drain проверяется под той же блокировкой, что и добавление ракеты
 	s.mu.Lock()
-	s.rockets[registerMsg.RocketID] = rocketConn
+	_, exists := s.rockets[registerMsg.RocketID]
+	draining := s.draining
+	if !exists && !draining {
+		s.rockets[registerMsg.RocketID] = rocketConn
+		s.fleet.join(registerMsg.RocketID)
+	}
 	s.mu.Unlock()
 
-	s.sendMessage(conn, protocol.MsgTypeAccepted, protocol.AcceptedMessage{
-		RocketID: registerMsg.RocketID,
-		Message:  "Регистрация успешна. Вы можете начинать запуск.",
+	if draining {
+		s.sendToSession(session, codec, protocol.MsgTypeRejected, protocol.RejectedMessage{
+			RocketID: registerMsg.RocketID,
+			Code:     protocol.RejectCodeDraining,
+			Reason:   "server draining",
+		})
+		connLog(connID, "", "warning", "rocket_rejected_draining", protocol.LogFields{"rocket_id": registerMsg.RocketID})
+		return nil, nil
+	}
+
+	if exists {
+		s.sendToSession(session, codec, protocol.MsgTypeRejected, protocol.RejectedMessage{
+			RocketID: registerMsg.RocketID,
+			Code:     protocol.RejectCodeDuplicateID,
+			Reason:   "ракета с таким ID уже зарегистрирована",
+		})
+		connLog(connID, "", "warning", "rocket_rejected_duplicate", protocol.LogFields{"rocket_id": registerMsg.RocketID})
+		return nil, nil
+	}
+
+	s.sendToSession(session, codec, protocol.MsgTypeAccepted, protocol.AcceptedMessage{
+		RocketID:     registerMsg.RocketID,
+		Message:      "Регистрация успешна. Вы можете начинать запуск.",
+		ConnectionID: connID,
 	})
 
-	s.broadcastToObservers(protocol.MsgTypeRocketJoined, protocol.RocketJoinedMessage{
+	s.broadcastToObservers(registerMsg.RocketID, registerMsg.Config.Labels, protocol.MsgTypeRocketJoined, 0, protocol.RocketJoinedMessage{
 		RocketID: registerMsg.RocketID,
 		Name:     registerMsg.Config.Name,
 		Config:   registerMsg.Config,
+		Mission:  registerMsg.Mission,
 	})
 
-	serverLog("info", "Ракета %s (%s) зарегистрирована", registerMsg.RocketID, registerMsg.Config.Name)
+	connLog(connID, "", "info", "rocket_registered", missionLogFields(protocol.LogFields{
+		"rocket_id": registerMsg.RocketID, "name": registerMsg.Config.Name,
+	}, registerMsg.Mission))
 
-	return rocketConn
+	return rocketConn, nil
 }
 
-func (s *Server) handleTelemetry(rocketConn *RocketConnection, msg protocol.Message) {
-	data, _ := json.Marshal(msg.Data)
-	var telemetryMsg protocol.TelemetryMessage
-	if err := json.Unmarshal(data, &telemetryMsg); err != nil {
-		serverLog("error", "Ошибка декодирования телеметрии: %v", err)
-		return
+func (s *Server) handleTelemetry(rocketConn *RocketConnection, msg protocol.Message) error {
+	telemetryMsg, err := protocol.DecodeData[protocol.TelemetryMessage](msg)
+	if err != nil {
+		return err
 	}
+	s.applyTelemetry(rocketConn, msg, []protocol.RocketState{telemetryMsg.State})
+	return nil
+}
 
-	rocketConn.mu.Lock()
-	rocketConn.State = telemetryMsg.State
-	rocketConn.LastUpdate = time.Now()
-	rocketName := rocketConn.Config.Name
-	rocketConn.mu.Unlock()
+// batchTelemetry - telemetry_batch с кадрами в исходном виде: кадры
+// декодируются по одному, чтобы испорченный кадр не терял весь пакет
+type batchTelemetry struct {
+	RocketID string            `json:"rocket_id"`
+	States   []json.RawMessage `json:"states"`
+}
 
-	s.broadcastToObservers(protocol.MsgTypeBroadcast, protocol.BroadcastMessage{
-		RocketID: rocketConn.ID,
-		Name:     rocketName,
-		State:    telemetryMsg.State,
+// handleTelemetryBatch обрабатывает кадры пакета по порядку, как отдельную
+// телеметрию, но наблюдателям рассылает только последний
+func (s *Server) handleTelemetryBatch(rocketConn *RocketConnection, msg protocol.Message) error {
+	states, err := batchStates(rocketConn, msg)
+	if err != nil {
+		return err
+	}
+	if len(states) == 0 {
+		return nil
+	}
+	connLog(rocketConn.ConnID, rocketConn.ID, "info", "telemetry_batch", protocol.LogFields{
+		"frames": len(states), "first_time": states[0].Time, "last_time": states[len(states)-1].Time,
 	})
+	s.applyTelemetry(rocketConn, msg, states)
+	return nil
+}
+
+// batchStates возвращает кадры пакета. Кодек уже разобрал пакет целиком,
+// если все кадры верны; иначе кадры декодируются по одному, и испорченные
+// пропускаются. Пакет больше MaxBatchStates отбрасывается.
+func batchStates(rocketConn *RocketConnection, msg protocol.Message) ([]protocol.RocketState, error) {
+	tooLarge := func(count int) bool {
+		if count <= protocol.MaxBatchStates {
+			return false
+		}
+		connLog(rocketConn.ConnID, rocketConn.ID, "warning", "telemetry_batch_too_large", protocol.LogFields{
+			"frames": count, "limit": protocol.MaxBatchStates,
+		})
+		return true
+	}
+
+	if batch, ok := msg.Data.(protocol.BatchTelemetryMessage); ok {
+		if tooLarge(len(batch.States)) {
+			return nil, nil
+		}
+		return batch.States, nil
+	}
+	batch, err := protocol.DecodeData[batchTelemetry](msg)
+	if err != nil || tooLarge(len(batch.States)) {
+		return nil, err
+	}
 
-	if int(telemetryMsg.State.Time)%10 == 0 {
-		rocketLog(rocketConn.ID, "info", "Высота=%.2f км, скорость=%.1f м/с, топливо=%.0f кг",
-			telemetryMsg.State.Altitude/1000.0,
-			telemetryMsg.State.Speed,
-			telemetryMsg.State.FuelRemaining)
+	states := make([]protocol.RocketState, 0, len(batch.States))
+	for i, raw := range batch.States {
+		var state protocol.RocketState
+		if err := json.Unmarshal(raw, &state); err != nil {
+			connLog(rocketConn.ConnID, rocketConn.ID, "warning", "telemetry_batch_frame_skipped", protocol.LogFields{"index": i, "error": err})
+			continue
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// applyTelemetry учитывает кадры ракеты и рассылает наблюдателям последний.
+// Сообщение с повторным или устаревшим seq отбрасывается целиком.
+func (s *Server) applyTelemetry(rocketConn *RocketConnection, msg protocol.Message, states []protocol.RocketState) {
+	frame, ok, gap := rocketConn.record(s.clock.Now(), msg, states)
+	if !ok {
+		connLog(rocketConn.ConnID, rocketConn.ID, "warning", "telemetry_out_of_order", protocol.LogFields{"seq": msg.Seq, "last_seq": frame.lastSeq})
+		return
+	}
+	if gap > seqGapThreshold {
+		connLog(rocketConn.ConnID, rocketConn.ID, "warning", "telemetry_gap", protocol.LogFields{"gap": gap, "seq": msg.Seq})
+	}
+	if frame.clockSkew != 0 {
+		connLog(rocketConn.ConnID, rocketConn.ID, "warning", "clock_skew", protocol.LogFields{"skew": frame.clockSkew.Round(time.Millisecond)})
+	}
+
+	s.fleet.telemetry(rocketConn.ID, states)
+
+	state := frame.message.State
+	// Без наблюдателей кадр не упаковывается в interface{}: иначе это
+	// выделение памяти на каждый кадр телеметрии
+	if s.hasObservers() {
+		s.broadcastToObservers(rocketConn.ID, rocketConn.Config.Labels, protocol.MsgTypeBroadcast, frame.broadcastSeq, frame.message)
+	}
+	if event, ok := rocketConn.checkMission(); ok {
+		connLog(rocketConn.ConnID, rocketConn.ID, "info", "mission_achieved", missionLogFields(protocol.LogFields{
+			"rocket_id":    rocketConn.ID,
+			"periapsis_km": state.OrbitPeriapsis / 1000.0,
+			"apoapsis_km":  state.OrbitApoapsis / 1000.0,
+			"inclination":  state.OrbitInclination,
+		}, rocketConn.Mission))
+		s.broadcastToObservers(rocketConn.ID, rocketConn.Config.Labels, protocol.MsgTypeMissionEvent, 0, event)
+	}
+
+	if int(state.Time)%10 == 0 {
+		connLog(rocketConn.ConnID, rocketConn.ID, "info", "telemetry_progress", protocol.LogFields{
+			"altitude_km": state.Altitude / 1000.0,
+			"speed":       state.Speed,
+			"fuel":        state.FuelRemaining,
+		})
+	}
+}
+
+// handleAbort фиксирует аварийное прекращение полета. Ракета остается в
+// списке и продолжает телеметрию до падения или посадки.
+func (s *Server) handleAbort(rocketConn *RocketConnection, msg protocol.Message) error {
+	abortMsg, err := protocol.DecodeData[protocol.AbortMessage](msg)
+	if err != nil {
+		return err
 	}
+	abortMsg.RocketID = rocketConn.ID
+
+	connLog(rocketConn.ConnID, rocketConn.ID, "warning", "rocket_aborted", protocol.LogFields{
+		"rocket_id": rocketConn.ID, "reason": abortMsg.Reason, "time": abortMsg.Time, "altitude_km": abortMsg.Altitude / 1000.0,
+	})
+	rocketConn.addWarning(s.clock.Now(), "", "abort: "+abortMsg.Reason, protocol.SeverityCritical)
+	s.fleet.abort(rocketConn.ID)
+	s.broadcastToObservers(rocketConn.ID, rocketConn.Config.Labels, protocol.MsgTypeAbort, 0, abortMsg)
+	return nil
 }
 
 func (s *Server) removeRocket(rocketID string) {
 	s.mu.Lock()
 	rocket, exists := s.rockets[rocketID]
 	delete(s.rockets, rocketID)
+	if exists {
+		s.fleet.leave(rocketID)
+	}
+	shutdown := s.draining && s.shutdownWhenEmpty && len(s.rockets) == 0
 	s.mu.Unlock()
 
 	if exists {
-		s.broadcastToObservers(protocol.MsgTypeRocketLeft, protocol.RocketLeftMessage{
+		s.broadcastToObservers(rocketID, rocket.Config.Labels, protocol.MsgTypeRocketLeft, 0, protocol.RocketLeftMessage{
 			RocketID: rocketID,
 			Reason:   "disconnected",
 		})
-		serverLog("info", "Ракета %s (%s) удалена из списка", rocketID, rocket.Config.Name)
+		connLog(rocket.ConnID, "", "info", "rocket_removed", protocol.LogFields{"rocket_id": rocketID, "name": rocket.Config.Name})
+
+		if shutdown {
+			go s.shutdown()
+		}
 	}
 }
 
-func (s *Server) handleSubscribe(conn *websocket.Conn, msg protocol.Message) *ObserverConnection {
-	data, _ := json.Marshal(msg.Data)
-	var subscribeMsg protocol.SubscribeMessage
-	if err := json.Unmarshal(data, &subscribeMsg); err != nil {
-		serverLog("error", "Ошибка декодирования подписки: %v", err)
-		return nil
+// handleSubscribe подписывает наблюдателя. unauthorized - токен не
+// подошел, и наблюдатель не подписан.
+func (s *Server) handleSubscribe(session *clientSession, codec protocol.Codec, msg protocol.Message) (observerConn *ObserverConnection, unauthorized bool, err error) {
+	connID := session.connID
+	subscribeMsg, err := protocol.DecodeData[protocol.SubscribeMessage](msg)
+	if err != nil {
+		return nil, false, err
+	}
+	if !s.observerAuthorized(subscribeMsg.Token) {
+		return nil, true, nil
 	}
 
-	observerConn := &ObserverConnection{
+	observerConn = &ObserverConnection{
 		ID:         subscribeMsg.ObserverID,
-		Conn:       conn,
-		LastUpdate: time.Now(),
+		ConnID:     connID,
+		Conn:       session.conn,
+		Codec:      codec,
+		Labels:     subscribeMsg.Labels,
+		RocketIDs:  rocketFilter(subscribeMsg.RocketIDs),
+		LastUpdate: s.clock.Now(),
+		writeMu:    &session.writeMu,
 	}
 
-	s.mu.Lock()
-	s.observers[subscribeMsg.ObserverID] = observerConn
-	s.mu.Unlock()
+	s.addObserver(observerConn)
 
 	s.sendCurrentRocketsToObserver(observerConn)
 
-	serverLog("info", "Наблюдатель %s подписался на события", subscribeMsg.ObserverID)
-	return observerConn
+	connLog(connID, "", "info", "observer_subscribed", protocol.LogFields{"observer_id": subscribeMsg.ObserverID})
+	return observerConn, false, nil
+}
+
+func (s *Server) addObserver(observer *ObserverConnection) {
+	s.mu.Lock()
+	s.observers[observer.ID] = observer
+	s.updateObserverList()
+	s.mu.Unlock()
 }
 
 func (s *Server) removeObserver(observerID string) {
 	s.mu.Lock()
-	delete(s.observers, observerID)
+	observer, exists := s.observers[observerID]
+	if exists {
+		delete(s.observers, observerID)
+		s.updateObserverList()
+	}
 	s.mu.Unlock()
-	serverLog("info", "Наблюдатель %s удален из списка", observerID)
+
+	if exists && observer.stream != nil {
+		observer.stream.close()
+	}
+
+	if exists {
+		connLog(observer.ConnID, "", "info", "observer_removed", protocol.LogFields{"observer_id": observerID})
+	}
 }
 
+// sendCurrentRocketsToObserver отправляет новому наблюдателю ракеты, которые
+// уже летят. Сообщения собираются под блокировками, а пишутся после них:
+// медленный наблюдатель не держит s.mu, пока сокет принимает кадры.
 func (s *Server) sendCurrentRocketsToObserver(observer *ObserverConnection) {
+	var joined []protocol.RocketJoinedMessage
+	var states []protocol.BroadcastMessage
 	s.mu.RLock()
-	defer s.mu.RUnlock()
-
 	for _, rocket := range s.rockets {
+		if !observer.matches(rocket.ID, rocket.Config.Labels) {
+			continue
+		}
 		rocket.mu.RLock()
-		s.sendMessage(observer.Conn, protocol.MsgTypeRocketJoined, protocol.RocketJoinedMessage{
+		joined = append(joined, protocol.RocketJoinedMessage{
 			RocketID: rocket.ID,
 			Name:     rocket.Config.Name,
 			Config:   rocket.Config,
+			Mission:  rocket.Mission,
 		})
-		s.sendMessage(observer.Conn, protocol.MsgTypeBroadcast, protocol.BroadcastMessage{
+		states = append(states, protocol.BroadcastMessage{
 			RocketID: rocket.ID,
 			Name:     rocket.Config.Name,
 			State:    rocket.State,
 		})
 		rocket.mu.RUnlock()
 	}
+	s.mu.RUnlock()
+
+	if observer.writeMu != nil {
+		observer.writeMu.Lock()
+		defer observer.writeMu.Unlock()
+	}
+	for i := range joined {
+		s.sendToObserver(observer, protocol.MsgTypeRocketJoined, joined[i])
+		s.sendToObserver(observer, protocol.MsgTypeBroadcast, states[i])
+	}
 }
 
-func (s *Server) broadcastToObservers(msgType protocol.MessageType, data interface{}) {
-	s.mu.RLock()
-	observers := make([]*ObserverConnection, 0, len(s.observers))
+// sendToObserver отправляет сообщение одному наблюдателю. Вызывается под
+// observer.writeMu.
+func (s *Server) sendToObserver(observer *ObserverConnection, msgType protocol.MessageType, data interface{}) error {
+	if observer.stream == nil {
+		return s.sendMessage(observer.Conn, observer.Codec, msgType, data)
+	}
+	payload, err := s.encodeMessage(protocol.JSON, msgType, s.clock.Now(), 0, data)
+	if err != nil {
+		return err
+	}
+	observer.stream.push(msgType, payload)
+	return nil
+}
+
+// updateObserverList пересобирает снимок наблюдателей; вызывается под mu
+func (s *Server) updateObserverList() {
+	list := make([]*ObserverConnection, 0, len(s.observers))
 	for _, obs := range s.observers {
-		observers = append(observers, obs)
+		list = append(list, obs)
+	}
+	s.observerList = list
+}
+
+func (s *Server) hasObservers() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.observerList) > 0
+}
+
+// encodedPayload - конверт рассылки в кодеке codec в буфере из envelopePool
+type encodedPayload struct {
+	codec   protocol.Codec
+	buf     *[]byte
+	payload []byte
+}
+
+// encodedPayloads - конверты одной рассылки; кодеков обычно один-два,
+// поэтому поиск перебором
+type encodedPayloads []encodedPayload
+
+func (p encodedPayloads) find(codec protocol.Codec) ([]byte, bool) {
+	for _, encoded := range p {
+		if encoded.codec == codec {
+			return encoded.payload, true
+		}
 	}
+	return nil, false
+}
+
+// release возвращает буферы конвертов в пул
+func (p encodedPayloads) release() {
+	for _, encoded := range p {
+		*encoded.buf = encoded.payload[:0]
+		envelopePool.Put(encoded.buf)
+	}
+}
+
+// envelopePool - буферы конвертов рассылки: телеметрия идет от каждой
+// ракеты каждый шаг, и новый буфер на каждый кадр заметен сборщику мусора
+var envelopePool = sync.Pool{New: func() interface{} { return new([]byte) }}
+
+// broadcastToObservers рассылает сообщение ракеты rocketID наблюдателям,
+// чей фильтр подходит к ракете. seq - номер кадра ракеты у сервера, 0 - без
+// номера. Наблюдатель /api/stream, который не успевает читать, отключается.
+func (s *Server) broadcastToObservers(rocketID string, rocketLabels map[string]string, msgType protocol.MessageType, seq uint64, data interface{}) {
+	s.mu.RLock()
+	observers := s.observerList
 	s.mu.RUnlock()
 
+	// Конверт кодируется один раз на кодек и переиспользуется для всех
+	// наблюдателей с этим кодеком. WriteMessage копирует его в буфер
+	// соединения, и буфер возвращается в пул после рассылки; очередь
+	// потока хранит конверт дольше и получает копию.
+	at := s.clock.Now()
+	var encoded [2]encodedPayload
+	payloads := encodedPayloads(encoded[:0])
 	for _, obs := range observers {
-		obs.mu.Lock()
-		s.sendMessage(obs.Conn, msgType, data)
-		obs.mu.Unlock()
+		if !obs.matches(rocketID, rocketLabels) {
+			continue
+		}
+		payload, ok := payloads.find(obs.Codec)
+		if !ok {
+			buf := envelopePool.Get().(*[]byte)
+			var err error
+			if payload, err = s.appendMessage((*buf)[:0], obs.Codec, msgType, at, seq, data); err != nil {
+				envelopePool.Put(buf)
+				payloads.release()
+				serverLog("error", "message_encode_failed", protocol.LogFields{"type": msgType, "error": err})
+				return
+			}
+			payloads = append(payloads, encodedPayload{obs.Codec, buf, payload})
+		}
+		if obs.stream != nil {
+			if !obs.stream.push(msgType, bytes.Clone(payload)) {
+				connLog(obs.ConnID, "", "warning", "observer_too_slow", protocol.LogFields{"observer_id": obs.ID})
+				s.removeObserver(obs.ID)
+			}
+			continue
+		}
+
+		obs.writeMu.Lock()
+		if err := obs.Conn.WriteMessage(frameType(obs.Codec), payload); err != nil {
+			serverLog("error", "observer_send_failed", protocol.LogFields{"observer_id": obs.ID, "error": err})
+		}
+		obs.writeMu.Unlock()
 	}
+	payloads.release()
 }
 
-func (s *Server) collisionCheckLoop() {
+func (s *Server) collisionCheckLoop(stop <-chan struct{}) {
 	ticker := time.NewTicker(s.collisionCheckInterval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		s.checkCollisions()
+	for {
+		select {
+		case <-ticker.C:
+			s.checkCollisions()
+		case <-stop:
+			return
+		}
 	}
 }
 
@@ -406,6 +991,8 @@ func (s *Server) checkCollisions() {
 	}
 	s.mu.RUnlock()
 
+	now := s.clock.Now()
+	var alerts []ProximityAlert
 	for i := 0; i < len(rockets); i++ {
 		for j := i + 1; j < len(rockets); j++ {
 			rocket1 := rockets[i]
@@ -413,73 +1000,162 @@ func (s *Server) checkCollisions() {
 
 			rocket1.mu.RLock()
 			rocket2.mu.RLock()
-
-			distance := calculateDistance(rocket1.State.Position, rocket2.State.Position)
+			position1, position2 := rocket1.State.Position, rocket2.State.Position
+			velocity1, velocity2 := rocket1.State.Velocity, rocket2.State.Velocity
+			rocket1.mu.RUnlock()
+			rocket2.mu.RUnlock()
+			// Наибольшее сближение до следующей проверки: быстрые ракеты могут
+			// пройти мимо друг друга между двумя проверками
+			tca, distance := protocol.ClosestApproach(position1, velocity1, position2, velocity2, s.collisionCheckInterval.Seconds())
 
 			if distance < s.minSafeDistance {
-				severity := "medium"
+				severity := protocol.SeverityMedium
 				if distance < s.minSafeDistance/2 {
-					severity = "high"
+					severity = protocol.SeverityHigh
 				}
 				if distance < s.minSafeDistance/4 {
-					severity = "critical"
+					severity = protocol.SeverityCritical
+				}
+
+				when := ""
+				if tca > 0 {
+					when = fmt.Sprintf(" через %.1f с", tca)
 				}
 
-				warning1 := fmt.Sprintf("Опасное сближение с ракетой %s! Расстояние: %.1f м", rocket2.ID, distance)
-				s.sendMessage(rocket1.Conn, protocol.MsgTypeWarning, protocol.WarningMessage{
-					RocketID: rocket1.ID,
-					Warning:  warning1,
-					Severity: severity,
+				warning1 := fmt.Sprintf("Опасное сближение с ракетой %s%s! Расстояние: %.1f м", rocket2.ID, when, distance)
+				s.sendToRocket(rocket1, protocol.MsgTypeWarning, protocol.WarningMessage{
+					RocketID:      rocket1.ID,
+					Code:          protocol.WarningCodeProximity,
+					Warning:       warning1,
+					Severity:      severity,
+					OtherRocketID: rocket2.ID,
+					OtherPosition: &position2,
+					Distance:      distance,
+					TimeToClosest: tca,
 				})
 
-				warning2 := fmt.Sprintf("Опасное сближение с ракетой %s! Расстояние: %.1f м", rocket1.ID, distance)
-				s.sendMessage(rocket2.Conn, protocol.MsgTypeWarning, protocol.WarningMessage{
-					RocketID: rocket2.ID,
-					Warning:  warning2,
-					Severity: severity,
+				warning2 := fmt.Sprintf("Опасное сближение с ракетой %s%s! Расстояние: %.1f м", rocket1.ID, when, distance)
+				s.sendToRocket(rocket2, protocol.MsgTypeWarning, protocol.WarningMessage{
+					RocketID:      rocket2.ID,
+					Code:          protocol.WarningCodeProximity,
+					Warning:       warning2,
+					Severity:      severity,
+					OtherRocketID: rocket1.ID,
+					OtherPosition: &position1,
+					Distance:      distance,
+					TimeToClosest: tca,
 				})
 
+				rocket1.addWarning(now, protocol.WarningCodeProximity, warning1, severity)
+				rocket2.addWarning(now, protocol.WarningCodeProximity, warning2, severity)
+				alerts = append(alerts, proximityAlert(rocket1.ID, rocket2.ID, distance, tca, severity))
+
 				// Логируем предупреждение для обеих ракет
-				rocketLog(rocket1.ID, "warning", "Сближение с %s: %.1f м", rocket2.ID, distance)
-				rocketLog(rocket2.ID, "warning", "Сближение с %s: %.1f м", rocket1.ID, distance)
-				serverLog("warning", "Ракеты %s и %s на расстоянии %.1f м", rocket1.ID, rocket2.ID, distance)
+				connLog(rocket1.ConnID, rocket1.ID, "warning", "proximity_rocket", protocol.LogFields{"other_rocket_id": rocket2.ID, "distance": distance})
+				connLog(rocket2.ConnID, rocket2.ID, "warning", "proximity_rocket", protocol.LogFields{"other_rocket_id": rocket1.ID, "distance": distance})
+				serverLog("warning", "proximity", protocol.LogFields{"rocket_id": rocket1.ID, "other_rocket_id": rocket2.ID, "distance": distance})
 			}
-
-			rocket1.mu.RUnlock()
-			rocket2.mu.RUnlock()
 		}
 	}
+	s.fleet.setAlerts(alerts)
+}
+
+// frameCodec выбирает кодек по типу кадра: текст - всегда JSON, чтобы
+// панель и визуализатор работали с любым -codec, двоичные кадры - кодек
+// сервера, если он двоичный
+func (s *Server) frameCodec(messageType int) (protocol.Codec, error) {
+	switch {
+	case messageType == websocket.TextMessage:
+		return protocol.JSON, nil
+	case messageType == websocket.BinaryMessage && s.codec.Binary():
+		return s.codec, nil
+	}
+	return nil, fmt.Errorf("двоичные кадры не принимаются: сервер запущен с -codec %v", s.codec)
+}
+
+// frameType - тип кадра WebSocket для сообщений кодека
+func frameType(codec protocol.Codec) int {
+	if codec != nil && codec.Binary() {
+		return websocket.BinaryMessage
+	}
+	return websocket.TextMessage
 }
 
-func calculateDistance(p1, p2 protocol.Vector3) float64 {
-	dx := p1.X - p2.X
-	dy := p1.Y - p2.Y
-	dz := p1.Z - p2.Z
-	return math.Sqrt(dx*dx + dy*dy + dz*dz)
+// encodeMessage кодирует конверт; nil - JSON (соединения, созданные до
+// выбора кодека, например в тестах)
+func (s *Server) encodeMessage(codec protocol.Codec, msgType protocol.MessageType, at time.Time, seq uint64, data interface{}) ([]byte, error) {
+	if codec == nil {
+		codec = protocol.JSON
+	}
+	return codec.Encode(protocol.Message{
+		Type:      msgType,
+		Timestamp: at,
+		Seq:       seq,
+		Data:      data,
+	})
 }
 
-func (s *Server) sendMessage(conn *websocket.Conn, msgType protocol.MessageType, data interface{}) {
-	msg := protocol.Message{
+// appendMessage - encodeMessage, который дописывает конверт в dst
+func (s *Server) appendMessage(dst []byte, codec protocol.Codec, msgType protocol.MessageType, at time.Time, seq uint64, data interface{}) ([]byte, error) {
+	if codec == nil {
+		codec = protocol.JSON
+	}
+	return protocol.AppendEncode(codec, dst, protocol.Message{
 		Type:      msgType,
-		Timestamp: time.Now(),
+		Timestamp: at,
+		Seq:       seq,
 		Data:      data,
+	})
+}
+
+func (s *Server) writeMessage(conn *websocket.Conn, codec protocol.Codec, payload []byte) error {
+	if err := conn.WriteMessage(frameType(codec), payload); err != nil {
+		serverLog("error", "message_send_failed", protocol.LogFields{"error": err})
+		return err
 	}
+	return nil
+}
 
-	if err := conn.WriteJSON(msg); err != nil {
-		serverLog("error", "Ошибка отправки сообщения: %v", err)
+func (s *Server) sendMessage(conn *websocket.Conn, codec protocol.Codec, msgType protocol.MessageType, data interface{}) error {
+	payload, err := s.encodeMessage(codec, msgType, s.clock.Now(), 0, data)
+	if err != nil {
+		serverLog("error", "message_encode_failed", protocol.LogFields{"type": msgType, "error": err})
+		return err
 	}
+	return s.writeMessage(conn, codec, payload)
+}
+
+// sendToSession отвечает клиенту соединения до регистрации ракеты или
+// подписки наблюдателя, например rejected или config_response
+func (s *Server) sendToSession(session *clientSession, codec protocol.Codec, msgType protocol.MessageType, data interface{}) error {
+	session.writeMu.Lock()
+	defer session.writeMu.Unlock()
+	return s.sendMessage(session.conn, codec, msgType, data)
+}
+
+func (s *Server) sendToRocket(rocket *RocketConnection, msgType protocol.MessageType, data interface{}) error {
+	rocket.writeMu.Lock()
+	defer rocket.writeMu.Unlock()
+	return s.sendMessage(rocket.Conn, rocket.Codec, msgType, data)
 }
 
 func (s *Server) handleRocketList(w http.ResponseWriter, r *http.Request) {
+	selector := parseLabelSelector(r.URL.Query()["label"])
+
 	s.mu.RLock()
 	rockets := make([]protocol.RocketInfo, 0, len(s.rockets))
 	for _, rocket := range s.rockets {
+		if !protocol.MatchLabels(selector, rocket.Config.Labels) {
+			continue
+		}
 		rocket.mu.RLock()
 		rockets = append(rockets, protocol.RocketInfo{
 			RocketID: rocket.ID,
 			Name:     rocket.Config.Name,
+			Labels:   rocket.Config.Labels,
 			State:    rocket.State,
 			Config:   rocket.Config,
+			Mission:  rocket.Mission,
 		})
 		rocket.mu.RUnlock()
 	}
@@ -489,9 +1165,23 @@ func (s *Server) handleRocketList(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(rockets)
 }
 
+// parseLabelSelector разбирает параметры вида label=team=red (несколько - через И)
+func parseLabelSelector(params []string) map[string]string {
+	if len(params) == 0 {
+		return nil
+	}
+	selector := make(map[string]string, len(params))
+	for _, param := range params {
+		key, value, _ := strings.Cut(param, "=")
+		selector[key] = value
+	}
+	return selector
+}
+
 func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
 	sinceStr := r.URL.Query().Get("since")
 	rocketID := r.URL.Query().Get("rocket_id") // Новый параметр для фильтрации
+	connID := r.URL.Query().Get("conn_id")
 
 	var since time.Time
 	if sinceStr != "" {
@@ -501,652 +1191,88 @@ func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	logs := serverLogs.GetByRocket(rocketID, since)
+	var logs []LogEntry
+	if connID != "" {
+		logs = serverLogs.GetByConnection(connID, since)
+	} else {
+		logs = serverLogs.GetByRocket(rocketID, since)
+	}
+	// ?lang= пересобирает текст на другом языке, записи в буфере не меняются
+	if lang, err := protocol.ParseLogLang(r.URL.Query().Get("lang")); err == nil && lang != logLang {
+		for i := range logs {
+			logs[i].Message = logCatalog.Format(lang, logs[i].Code, logs[i].Fields)
+		}
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(logs)
 }
 
-func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
-	html := `<!DOCTYPE html>
-<html lang="ru">
-<head>
-    <title>Cosmodrom - Центр управления</title>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <style>
-        * { margin: 0; padding: 0; box-sizing: border-box; }
-        body {
-            font-family: 'Courier New', monospace;
-            background: #0a0e17;
-            color: #c8d6e5;
-            height: 100vh;
-            overflow: hidden;
-        }
-        .header {
-            background: linear-gradient(135deg, #0d1b2a, #1b2838);
-            border-bottom: 1px solid #1e3a5f;
-            padding: 12px 24px;
-            display: flex;
-            align-items: center;
-            justify-content: space-between;
-        }
-        .header h1 {
-            font-size: 18px;
-            color: #4fc3f7;
-            letter-spacing: 2px;
-            text-transform: uppercase;
-        }
-        .header .status {
-            display: flex;
-            align-items: center;
-            gap: 8px;
-            font-size: 12px;
-        }
-        .header .status .dot {
-            width: 8px; height: 8px;
-            border-radius: 50%;
-            background: #4caf50;
-            animation: pulse 2s infinite;
-        }
-        @keyframes pulse {
-            0%, 100% { opacity: 1; }
-            50% { opacity: 0.4; }
-        }
-        .container {
-            display: flex;
-            height: calc(100vh - 50px);
-        }
-        .sidebar {
-            width: 280px;
-            min-width: 280px;
-            background: #0d1117;
-            border-right: 1px solid #1e3a5f;
-            display: flex;
-            flex-direction: column;
-        }
-        .sidebar-header {
-            padding: 12px 16px;
-            border-bottom: 1px solid #1e3a5f;
-            font-size: 11px;
-            color: #4fc3f7;
-            text-transform: uppercase;
-            letter-spacing: 1px;
-        }
-        .rocket-list {
-            flex: 1;
-            overflow-y: auto;
-            padding: 8px;
-        }
-        .rocket-item {
-            padding: 10px 12px;
-            border-radius: 6px;
-            margin-bottom: 4px;
-            cursor: pointer;
-            transition: background 0.2s;
-            border: 1px solid transparent;
-        }
-        .rocket-item:hover {
-            background: #161b22;
-            border-color: #1e3a5f;
-        }
-        .rocket-item.selected {
-            background: #1a2332;
-            border-color: #4fc3f7;
-        }
-        .rocket-item .name {
-            font-size: 13px;
-            font-weight: bold;
-            color: #e6edf3;
-            margin-bottom: 4px;
-        }
-        .rocket-item .id {
-            font-size: 10px;
-            color: #6e7681;
-        }
-        .rocket-item .mini-stats {
-            display: flex;
-            gap: 12px;
-            margin-top: 6px;
-            font-size: 10px;
-        }
-        .rocket-item .mini-stats span {
-            color: #8b949e;
-        }
-        .rocket-item .mini-stats .val {
-            color: #58a6ff;
-        }
-        .status-badge {
-            display: inline-block;
-            padding: 1px 6px;
-            border-radius: 3px;
-            font-size: 9px;
-            font-weight: bold;
-            text-transform: uppercase;
-            margin-left: 6px;
-        }
-        .status-flight { background: #1a4a2e; color: #4caf50; }
-        .status-orbit { background: #1a3a4a; color: #4fc3f7; }
-        .status-landed { background: #3a3a1a; color: #ffb74d; }
-        .status-crashed { background: #4a1a1a; color: #ef5350; }
-        .main-content {
-            flex: 1;
-            display: flex;
-            flex-direction: column;
-            overflow: hidden;
-        }
-        .tabs {
-            display: flex;
-            background: #0d1117;
-            border-bottom: 1px solid #1e3a5f;
-        }
-        .tab {
-            padding: 10px 20px;
-            font-size: 12px;
-            color: #8b949e;
-            cursor: pointer;
-            border-bottom: 2px solid transparent;
-            transition: all 0.2s;
-            text-transform: uppercase;
-            letter-spacing: 1px;
-        }
-        .tab:hover { color: #c8d6e5; }
-        .tab.active {
-            color: #4fc3f7;
-            border-bottom-color: #4fc3f7;
-        }
-        .tab-content {
-            flex: 1;
-            overflow: hidden;
-            display: none;
-        }
-        .tab-content.active { display: flex; flex-direction: column; }
-
-        /* Telemetry panel */
-        .telemetry-grid {
-            display: grid;
-            grid-template-columns: repeat(3, 1fr);
-            gap: 12px;
-            padding: 16px;
-            overflow-y: auto;
-        }
-        .telemetry-card {
-            background: #161b22;
-            border: 1px solid #1e3a5f;
-            border-radius: 8px;
-            padding: 14px;
-        }
-        .telemetry-card .label {
-            font-size: 10px;
-            color: #6e7681;
-            text-transform: uppercase;
-            letter-spacing: 1px;
-            margin-bottom: 6px;
-        }
-        .telemetry-card .value {
-            font-size: 24px;
-            font-weight: bold;
-            color: #4fc3f7;
-        }
-        .telemetry-card .unit {
-            font-size: 12px;
-            color: #6e7681;
-            margin-left: 4px;
-        }
-        .telemetry-card.wide {
-            grid-column: span 3;
-        }
-        .fuel-bar-container {
-            width: 100%;
-            height: 8px;
-            background: #21262d;
-            border-radius: 4px;
-            margin-top: 8px;
-            overflow: hidden;
-        }
-        .fuel-bar {
-            height: 100%;
-            border-radius: 4px;
-            transition: width 0.3s;
-            background: linear-gradient(90deg, #ef5350, #ffb74d, #4caf50);
-        }
-        .no-rocket-selected {
-            display: flex;
-            align-items: center;
-            justify-content: center;
-            height: 100%;
-            color: #6e7681;
-            font-size: 14px;
-        }
-
-        /* Logs panel */
-        .log-container {
-            flex: 1;
-            overflow-y: auto;
-            padding: 12px 16px;
-            font-size: 12px;
-            line-height: 1.8;
-        }
-        .log-entry {
-            padding: 2px 0;
-            border-bottom: 1px solid #161b22;
-            display: flex;
-            gap: 12px;
-        }
-        .log-entry .log-time {
-            color: #6e7681;
-            white-space: nowrap;
-            min-width: 80px;
-        }
-        .log-entry .log-level {
-            font-weight: bold;
-            min-width: 60px;
-            text-transform: uppercase;
-            font-size: 10px;
-            padding-top: 2px;
-        }
-        .log-level.info { color: #4fc3f7; }
-        .log-level.warning { color: #ffb74d; }
-        .log-level.error { color: #ef5350; }
-        .log-entry .log-msg { color: #c8d6e5; }
-
-        .server-tab-label { position: relative; }
-
-        ::-webkit-scrollbar { width: 6px; }
-        ::-webkit-scrollbar-track { background: #0d1117; }
-        ::-webkit-scrollbar-thumb { background: #1e3a5f; border-radius: 3px; }
-        ::-webkit-scrollbar-thumb:hover { background: #2a4a6f; }
-    </style>
-</head>
-<body>
-    <div class="header">
-        <h1>Cosmodrom - Центр управления</h1>
-        <div class="status">
-            <div class="dot" id="ws-dot"></div>
-            <span id="ws-status">Подключение...</span>
-            <span style="margin-left: 16px; color: #6e7681;">Ракет: <span id="rocket-count" style="color: #4fc3f7;">0</span></span>
-        </div>
-    </div>
-    <div class="container">
-        <div class="sidebar">
-            <div class="sidebar-header" onclick="deselectRocket()" style="cursor: pointer;" title="Клик для просмотра серверных логов">Активные ракеты</div>
-            <div class="rocket-list" id="rocket-list">
-                <div style="padding: 20px; color: #6e7681; text-align: center; font-size: 12px;">
-                    Нет активных ракет
-                </div>
-            </div>
-        </div>
-        <div class="main-content">
-            <div class="tabs">
-                <div class="tab active" data-tab="telemetry">Телеметрия</div>
-                <div class="tab server-tab-label" data-tab="logs">Логи сервера</div>
-            </div>
-            <div class="tab-content active" id="tab-telemetry">
-                <div class="no-rocket-selected" id="no-rocket-msg">
-                    Выберите ракету из списка слева
-                </div>
-                <div class="telemetry-grid" id="telemetry-grid" style="display: none;">
-                    <div class="telemetry-card">
-                        <div class="label">Высота</div>
-                        <div><span class="value" id="t-altitude">0.00</span><span class="unit">км</span></div>
-                    </div>
-                    <div class="telemetry-card">
-                        <div class="label">Скорость</div>
-                        <div><span class="value" id="t-speed">0.0</span><span class="unit">м/с</span></div>
-                    </div>
-                    <div class="telemetry-card">
-                        <div class="label">Ускорение</div>
-                        <div><span class="value" id="t-accel">0.0</span><span class="unit">G</span></div>
-                    </div>
-                    <div class="telemetry-card">
-                        <div class="label">Масса</div>
-                        <div><span class="value" id="t-mass">0</span><span class="unit">кг</span></div>
-                    </div>
-                    <div class="telemetry-card">
-                        <div class="label">Время полёта</div>
-                        <div><span class="value" id="t-time">0</span><span class="unit">с</span></div>
-                    </div>
-                    <div class="telemetry-card">
-                        <div class="label">Статус</div>
-                        <div><span class="value" id="t-status" style="font-size: 16px;">-</span></div>
-                    </div>
-                    <div class="telemetry-card wide">
-                        <div class="label">Топливо (<span id="t-fuel-pct">0</span>%)</div>
-                        <div><span class="value" id="t-fuel" style="font-size: 18px;">0</span><span class="unit">кг</span></div>
-                        <div class="fuel-bar-container">
-                            <div class="fuel-bar" id="t-fuel-bar" style="width: 0%"></div>
-                        </div>
-                    </div>
-                    <div class="telemetry-card">
-                        <div class="label">Позиция X</div>
-                        <div><span class="value" id="t-px" style="font-size: 14px;">0</span><span class="unit">м</span></div>
-                    </div>
-                    <div class="telemetry-card">
-                        <div class="label">Позиция Y</div>
-                        <div><span class="value" id="t-py" style="font-size: 14px;">0</span><span class="unit">м</span></div>
-                    </div>
-                    <div class="telemetry-card">
-                        <div class="label">Позиция Z</div>
-                        <div><span class="value" id="t-pz" style="font-size: 14px;">0</span><span class="unit">м</span></div>
-                    </div>
-                    <div class="telemetry-card wide" style="background: linear-gradient(135deg, #1a2332, #0d1b2a); border-color: #4fc3f7;">
-                        <div class="label" style="color: #4fc3f7;">Предсказание орбиты</div>
-                        <div style="display: grid; grid-template-columns: 1fr 1fr 1fr; gap: 16px; margin-top: 8px;">
-                            <div>
-                                <div class="label">Апоцентр</div>
-                                <div><span class="value" id="t-apoapsis" style="font-size: 18px;">-</span><span class="unit">км</span></div>
-                            </div>
-                            <div>
-                                <div class="label">Перицентр</div>
-                                <div><span class="value" id="t-periapsis" style="font-size: 18px;">-</span><span class="unit">км</span></div>
-                            </div>
-                            <div>
-                                <div class="label">Орб. скорость</div>
-                                <div><span class="value" id="t-orbital-v" style="font-size: 18px;">-</span><span class="unit">м/с</span></div>
-                            </div>
-                        </div>
-                        <div style="margin-top: 12px; display: flex; align-items: center; gap: 12px;">
-                            <span class="label">Статус орбиты:</span>
-                            <span id="t-orbit-status" class="status-badge" style="font-size: 12px;">НЕ ОПРЕДЕЛЕНА</span>
-                        </div>
-                    </div>
-                </div>
-            </div>
-            <div class="tab-content" id="tab-logs">
-                <div class="log-container" id="log-container"></div>
-            </div>
-        </div>
-    </div>
-
-    <script>
-        const rockets = {};
-        let selectedRocketId = null;
-        let ws = null;
-        let logPollTimer = null;
-        let lastLogTime = null;
-
-        function connectWS() {
-            const protocol = location.protocol === 'https:' ? 'wss:' : 'ws:';
-            ws = new WebSocket(protocol + '//' + location.host + '/ws');
-
-            ws.onopen = () => {
-                document.getElementById('ws-dot').style.background = '#4caf50';
-                document.getElementById('ws-status').textContent = 'Подключено';
-                ws.send(JSON.stringify({
-                    type: 'subscribe',
-                    timestamp: new Date().toISOString(),
-                    data: { observer_id: 'web-dashboard-' + Math.random().toString(36).substr(2, 6) }
-                }));
-            };
-
-            ws.onclose = () => {
-                document.getElementById('ws-dot').style.background = '#ef5350';
-                document.getElementById('ws-status').textContent = 'Отключено';
-                setTimeout(connectWS, 3000);
-            };
-
-            ws.onerror = () => {
-                document.getElementById('ws-dot').style.background = '#ef5350';
-                document.getElementById('ws-status').textContent = 'Ошибка';
-            };
-
-            ws.onmessage = (event) => {
-                const msg = JSON.parse(event.data);
-                handleMessage(msg);
-            };
-        }
-
-        function handleMessage(msg) {
-            switch (msg.type) {
-                case 'rocket_joined':
-                    rockets[msg.data.rocket_id] = {
-                        id: msg.data.rocket_id,
-                        name: msg.data.name,
-                        config: msg.data.config,
-                        state: null
-                    };
-                    renderRocketList();
-                    break;
-
-                case 'broadcast':
-                    if (rockets[msg.data.rocket_id]) {
-                        rockets[msg.data.rocket_id].state = msg.data.state;
-                        rockets[msg.data.rocket_id].name = msg.data.name;
-                    } else {
-                        rockets[msg.data.rocket_id] = {
-                            id: msg.data.rocket_id,
-                            name: msg.data.name,
-                            config: null,
-                            state: msg.data.state
-                        };
-                    }
-                    renderRocketList();
-                    if (msg.data.rocket_id === selectedRocketId) {
-                        renderTelemetry(rockets[msg.data.rocket_id]);
-                    }
-                    break;
-
-                case 'rocket_left':
-                    delete rockets[msg.data.rocket_id];
-                    if (msg.data.rocket_id === selectedRocketId) {
-                        deselectRocket();
-                    }
-                    renderRocketList();
-                    break;
-
-                case 'warning':
-                    break;
-            }
-            document.getElementById('rocket-count').textContent = Object.keys(rockets).length;
-        }
-
-        function getStatusInfo(state) {
-            if (!state) return { text: 'ОЖИДАНИЕ', cls: 'flight' };
-            if (state.crashed) return { text: 'КРУШЕНИЕ', cls: 'crashed' };
-            if (state.landed) return { text: 'ПОСАДКА', cls: 'landed' };
-            if (state.in_orbit) return { text: 'ОРБИТА', cls: 'orbit' };
-            return { text: 'ПОЛЁТ', cls: 'flight' };
-        }
-
-        function renderRocketList() {
-            const list = document.getElementById('rocket-list');
-            const ids = Object.keys(rockets);
-            if (ids.length === 0) {
-                list.innerHTML = '<div style="padding: 20px; color: #6e7681; text-align: center; font-size: 12px;">Нет активных ракет</div>';
-                return;
-            }
-
-            list.innerHTML = ids.map(id => {
-                const r = rockets[id];
-                const st = getStatusInfo(r.state);
-                const alt = r.state ? (r.state.altitude / 1000).toFixed(1) : '0.0';
-                const spd = r.state ? r.state.speed.toFixed(0) : '0';
-                const sel = id === selectedRocketId ? 'selected' : '';
-                return '<div class="rocket-item ' + sel + '" onclick="selectRocket(\'' + id + '\')">' +
-                    '<div class="name">' + escapeHtml(r.name) +
-                    '<span class="status-badge status-' + st.cls + '">' + st.text + '</span></div>' +
-                    '<div class="id">' + escapeHtml(id) + '</div>' +
-                    '<div class="mini-stats"><span>ALT: <span class="val">' + alt + ' км</span></span>' +
-                    '<span>SPD: <span class="val">' + spd + ' м/с</span></span></div></div>';
-            }).join('');
-        }
-
-        function selectRocket(id) {
-            selectedRocketId = id;
-            document.getElementById('no-rocket-msg').style.display = 'none';
-            document.getElementById('telemetry-grid').style.display = 'grid';
-            renderRocketList();
-            if (rockets[id]) renderTelemetry(rockets[id]);
-            // Переключаем логи на выбранную ракету
-            switchLogView(id);
-            updateLogTabLabel();
-        }
-
-        function deselectRocket() {
-            selectedRocketId = null;
-            document.getElementById('no-rocket-msg').style.display = 'flex';
-            document.getElementById('telemetry-grid').style.display = 'none';
-            renderRocketList();
-            // Возвращаемся к серверным логам
-            switchLogView(null);
-            updateLogTabLabel();
-        }
-
-        function updateLogTabLabel() {
-            const tabLabel = document.querySelector('.tab[data-tab="logs"]');
-            if (selectedRocketId && rockets[selectedRocketId]) {
-                tabLabel.textContent = 'Логи: ' + rockets[selectedRocketId].name;
-            } else {
-                tabLabel.textContent = 'Логи сервера';
-            }
-        }
-
-        function renderTelemetry(rocket) {
-            const s = rocket.state;
-            if (!s) return;
-
-            document.getElementById('t-altitude').textContent = (s.altitude / 1000).toFixed(2);
-            document.getElementById('t-speed').textContent = s.speed.toFixed(1);
-
-            const accelMag = Math.sqrt(
-                s.acceleration.x * s.acceleration.x +
-                s.acceleration.y * s.acceleration.y +
-                s.acceleration.z * s.acceleration.z
-            );
-            document.getElementById('t-accel').textContent = (accelMag / 9.81).toFixed(2);
-            document.getElementById('t-mass').textContent = s.mass_current.toFixed(0);
-            document.getElementById('t-time').textContent = s.time.toFixed(1);
-
-            const st = getStatusInfo(s);
-            const statusEl = document.getElementById('t-status');
-            statusEl.textContent = st.text;
-            statusEl.className = 'value status-badge status-' + st.cls;
-            statusEl.style.fontSize = '16px';
-
-            document.getElementById('t-fuel').textContent = s.fuel_remaining.toFixed(0);
-            const maxFuel = rocket.config ? rocket.config.mass_fuel_max : s.fuel_remaining;
-            const pct = maxFuel > 0 ? (s.fuel_remaining / maxFuel * 100) : 0;
-            document.getElementById('t-fuel-pct').textContent = pct.toFixed(1);
-            document.getElementById('t-fuel-bar').style.width = pct + '%';
-
-            document.getElementById('t-px').textContent = s.position.x.toFixed(0);
-            document.getElementById('t-py').textContent = s.position.y.toFixed(0);
-            document.getElementById('t-pz').textContent = s.position.z.toFixed(0);
-
-            // Орбитальные данные
-            const apoapsis = s.orbit_apoapsis;
-            const periapsis = s.orbit_periapsis;
-            const reqV = s.orbit_required_velocity;
-            const isStable = s.orbit_is_stable;
-
-            if (apoapsis && apoapsis > 0) {
-                document.getElementById('t-apoapsis').textContent = (apoapsis / 1000).toFixed(1);
-            } else {
-                document.getElementById('t-apoapsis').textContent = '-';
-            }
-
-            if (periapsis !== undefined) {
-                document.getElementById('t-periapsis').textContent = (periapsis / 1000).toFixed(1);
-            } else {
-                document.getElementById('t-periapsis').textContent = '-';
-            }
-
-            if (reqV && reqV > 0) {
-                document.getElementById('t-orbital-v').textContent = reqV.toFixed(0);
-            } else {
-                document.getElementById('t-orbital-v').textContent = '-';
-            }
-
-            const orbitStatusEl = document.getElementById('t-orbit-status');
-            if (s.in_orbit) {
-                orbitStatusEl.textContent = 'СТАБИЛЬНАЯ ОРБИТА';
-                orbitStatusEl.className = 'status-badge status-orbit';
-            } else if (isStable) {
-                orbitStatusEl.textContent = 'ВЫХОД НА ОРБИТУ';
-                orbitStatusEl.className = 'status-badge status-orbit';
-            } else if (periapsis !== undefined && periapsis > 0) {
-                orbitStatusEl.textContent = 'СУБОРБИТАЛЬНАЯ';
-                orbitStatusEl.className = 'status-badge status-landed';
-            } else {
-                orbitStatusEl.textContent = 'БАЛЛИСТИЧЕСКАЯ';
-                orbitStatusEl.className = 'status-badge status-crashed';
-            }
-        }
-
-        let currentLogRocketId = null; // Текущий фильтр логов (null = серверные логи)
-
-        function pollLogs() {
-            let url = '/api/logs';
-            const params = [];
-            if (lastLogTime) {
-                params.push('since=' + encodeURIComponent(lastLogTime));
-            }
-            if (currentLogRocketId) {
-                params.push('rocket_id=' + encodeURIComponent(currentLogRocketId));
-            }
-            if (params.length > 0) {
-                url += '?' + params.join('&');
-            }
-            fetch(url)
-                .then(r => r.json())
-                .then(logs => {
-                    if (!logs || logs.length === 0) return;
-                    const container = document.getElementById('log-container');
-                    logs.forEach(entry => {
-                        const div = document.createElement('div');
-                        div.className = 'log-entry';
-                        const t = new Date(entry.timestamp);
-                        const timeStr = t.toLocaleTimeString('ru-RU');
-                        div.innerHTML =
-                            '<span class="log-time">' + timeStr + '</span>' +
-                            '<span class="log-level ' + entry.level + '">' + entry.level + '</span>' +
-                            '<span class="log-msg">' + escapeHtml(entry.message) + '</span>';
-                        container.appendChild(div);
-                        lastLogTime = entry.timestamp;
-                    });
-                    container.scrollTop = container.scrollHeight;
-                })
-                .catch(() => {});
-        }
-
-        function switchLogView(rocketId) {
-            // Переключение между серверными логами и логами ракеты
-            currentLogRocketId = rocketId;
-            lastLogTime = null; // Сброс времени для загрузки всех логов
-            document.getElementById('log-container').innerHTML = ''; // Очистка
-            pollLogs(); // Загрузка логов
-        }
-
-        function escapeHtml(str) {
-            const div = document.createElement('div');
-            div.textContent = str;
-            return div.innerHTML;
-        }
-
-        // Tabs
-        document.querySelectorAll('.tab').forEach(tab => {
-            tab.addEventListener('click', () => {
-                document.querySelectorAll('.tab').forEach(t => t.classList.remove('active'));
-                document.querySelectorAll('.tab-content').forEach(c => c.classList.remove('active'));
-                tab.classList.add('active');
-                document.getElementById('tab-' + tab.dataset.tab).classList.add('active');
-            });
-        });
-
-        connectWS();
-        pollLogs();
-        logPollTimer = setInterval(pollLogs, 2000);
-    </script>
-</body>
-</html>`
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	w.Write([]byte(html))
-}
-
 func main() {
 	port := flag.String("port", "8080", "Порт для сервера")
+	recordDir := flag.String("record-dir", "", "Директория для журналов (audit.jsonl)")
+	wsRate := flag.Float64("ws-rate", 5.0, "Лимит подключений к /ws в секунду с одного IP (0 - без лимита)")
+	wsBurst := flag.Int("ws-burst", 20, "Допустимый всплеск подключений с одного IP")
+	wsWhitelist := flag.String("ws-whitelist", "", "IP или подсети без лимита подключений, через запятую")
+	msgRate := flag.Float64("msg-rate", 100, "Лимит входящих сообщений одного соединения в секунду (0 - без лимита)")
+	msgBurst := flag.Int("msg-burst", 200, "Допустимый всплеск сообщений одного соединения")
+	allowedOrigins := flag.String("allowed-origins", "", "Разрешенные источники для CORS и WebSocket, через запятую (пусто - все)")
+	adminToken := flag.String("admin-token", "", "Токен администратора для /api/command, /api/audit, /api/admin/* и /debug/*")
+	observerToken := flag.String("observer-token", "", "Токен наблюдателей: поле token в subscribe и доступ к /api/stream")
+	debug := flag.Bool("debug", false, "Включить /debug/pprof/ и /debug/vars")
+	configPath := flag.String("config", "", "YAML-файл с каталогом ракет (vehicles:)")
+	codecName := flag.String("codec", "json", "Кодек двоичных кадров: json (только текст) или cbor")
+	historySize := flag.Int("history-size", defaultHistorySize, "Кадров телеметрии в истории ракеты для выгрузки (0 - без истории)")
+	staticDir := flag.String("static-dir", "", "Каталог с templates/index.html и static/ вместо вшитой панели")
+	lang := flag.String("lang", "ru", "Язык журнала: ru или en (коды и поля записей в /api/logs не зависят от языка)")
 	flag.Parse()
 
-	server := NewServer()
-	log.Fatal(server.Start(*port))
+	var err error
+	if logLang, err = protocol.ParseLogLang(*lang); err != nil {
+		log.Fatalf("Ошибка в -lang: %v", err)
+	}
+
+	codec, err := protocol.CodecByName(*codecName)
+	if err != nil {
+		fatalLog("flag_invalid", protocol.LogFields{"flag": "-codec", "error": err})
+	}
+	server := NewServer(codec)
+	server.allowedOrigins = parseOrigins(*allowedOrigins)
+	server.adminToken = *adminToken
+	server.observerToken = *observerToken
+	server.debug = *debug
+	server.msgRate = *msgRate
+	server.msgBurst = *msgBurst
+	server.historySize = *historySize
+
+	if *staticDir != "" {
+		if server.dashboard, err = newDashboard(*staticDir); err != nil {
+			fatalLog("flag_invalid", protocol.LogFields{"flag": "-static-dir", "error": err})
+		}
+		serverLog("info", "dashboard_dir", protocol.LogFields{"dir": *staticDir})
+	}
+
+	if *configPath != "" {
+		config, err := loadServerConfig(*configPath)
+		if err != nil {
+			fatalLog("flag_invalid", protocol.LogFields{"flag": "-config", "error": err})
+		}
+		server.vehicles = config.Vehicles
+		serverLog("info", "vehicles_loaded", protocol.LogFields{"path": *configPath, "count": len(config.Vehicles)})
+	}
+
+	limiter, err := NewIPRateLimiter(*wsRate, *wsBurst, 4096, strings.Split(*wsWhitelist, ","))
+	if err != nil {
+		fatalLog("flag_invalid", protocol.LogFields{"flag": "-ws-whitelist", "error": err})
+	}
+	server.wsLimiter = limiter
+
+	if *recordDir != "" {
+		if err := server.audit.EnableFile(filepath.Join(*recordDir, "audit.jsonl")); err != nil {
+			fatalLog("audit_open_failed", protocol.LogFields{"error": err})
+		}
+	}
+	if err := server.Start(*port); err != nil {
+		log.Fatal(err)
+	}
 }
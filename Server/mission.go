@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"cosmodrom/protocol"
+)
+
+const (
+	missionOrbitTolerance       = 0.05 // Допуск апоцентра и перицентра от высоты цели
+	missionInclinationTolerance = 1.0  // Допуск наклонения в градусах
+)
+
+// missionAchieved сравнивает орбиту из телеметрии с целью миссии: орбита
+// устойчива, апоцентр и перицентр в пределах допуска от высоты цели,
+// наклонение - в пределах допуска, если оно задано
+func missionAchieved(mission *protocol.Mission, state *protocol.RocketState) bool {
+	if mission == nil || mission.TargetOrbit <= 0 || !state.OrbitIsStable {
+		return false
+	}
+	tolerance := missionOrbitTolerance * mission.TargetOrbit
+	if math.Abs(state.OrbitApoapsis-mission.TargetOrbit) > tolerance ||
+		math.Abs(state.OrbitPeriapsis-mission.TargetOrbit) > tolerance {
+		return false
+	}
+	if inclination := mission.TargetInclination; inclination != nil &&
+		math.Abs(state.OrbitInclination-*inclination) > missionInclinationTolerance {
+		return false
+	}
+	return true
+}
+
+// checkMission возвращает событие target_achieved, когда орбита ракеты
+// впервые совпала с целью миссии. Повторно событие не создается.
+func (rc *RocketConnection) checkMission() (protocol.MissionEventMessage, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if rc.missionDone || !missionAchieved(rc.Mission, &rc.State) {
+		return protocol.MissionEventMessage{}, false
+	}
+	rc.missionDone = true
+	return protocol.MissionEventMessage{
+		RocketID: rc.ID,
+		Event:    protocol.MissionEventTargetAchieved,
+		Time:     rc.State.Time,
+		Message: fmt.Sprintf("цель миссии достигнута: орбита %.0f x %.0f км, наклонение %.1f° (цель %s)",
+			rc.State.OrbitPeriapsis/1000.0, rc.State.OrbitApoapsis/1000.0, rc.State.OrbitInclination, missionTarget(rc.Mission)),
+	}, true
+}
+
+// missionTarget описывает орбитальную цель для журнала: "400 км, 51.6°"
+func missionTarget(mission *protocol.Mission) string {
+	target := fmt.Sprintf("%.0f км", mission.TargetOrbit/1000.0)
+	if mission.TargetInclination != nil {
+		target += fmt.Sprintf(", %.1f°", *mission.TargetInclination)
+	}
+	return target
+}
+
+// missionLogFields добавляет к полям записи журнала цель миссии; поля без
+// значения не добавляются, и шаблон опускает их часть
+func missionLogFields(fields protocol.LogFields, mission *protocol.Mission) protocol.LogFields {
+	if mission == nil {
+		return fields
+	}
+	if mission.TargetOrbit > 0 {
+		fields["target_orbit_km"] = mission.TargetOrbit / 1000.0
+		if mission.TargetInclination != nil {
+			fields["target_inclination"] = *mission.TargetInclination
+		}
+	}
+	if mission.LaunchSite != "" {
+		fields["launch_site"] = mission.LaunchSite
+	}
+	if mission.Crewed {
+		fields["crewed"] = true
+	}
+	return fields
+}
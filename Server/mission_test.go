@@ -0,0 +1,80 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"cosmodrom/protocol"
+
+	"github.com/gorilla/websocket"
+)
+
+// Событие target_achieved появляется один раз, когда орбита совпала с целью
+func TestMissionTargetAchieved(t *testing.T) {
+	inclination := 51.6
+	s := NewServer(protocol.JSON)
+	rc := &RocketConnection{ID: "r1", Mission: &protocol.Mission{TargetOrbit: 400000, TargetInclination: &inclination}}
+
+	orbit := func(seq uint64, periapsis, inclination float64) protocol.Message {
+		msg := telemetryAt(seq, time.Time{})
+		msg.Data = protocol.TelemetryMessage{RocketID: "r1", State: protocol.RocketState{
+			Time:             float64(seq),
+			OrbitIsStable:    periapsis > 0,
+			OrbitApoapsis:    405000,
+			OrbitPeriapsis:   periapsis,
+			OrbitInclination: inclination,
+		}}
+		return msg
+	}
+
+	steps := []struct {
+		name string
+		msg  protocol.Message
+		want bool
+	}{
+		{name: "суборбитальная траектория", msg: orbit(1, 0, 51.6)},
+		{name: "перицентр ниже цели", msg: orbit(2, 250000, 51.6)},
+		{name: "не то наклонение", msg: orbit(3, 398000, 45)},
+		{name: "цель достигнута", msg: orbit(4, 398000, 51.9), want: true},
+	}
+	for _, step := range steps {
+		s.handleTelemetry(rc, step.msg)
+		if rc.missionDone != step.want {
+			t.Fatalf("%s: missionDone = %v", step.name, rc.missionDone)
+		}
+	}
+	if _, ok := rc.checkMission(); ok {
+		t.Error("событие target_achieved повторилось")
+	}
+
+	// Без цели орбиты событий нет
+	if missionAchieved(&protocol.Mission{Description: "подскок"}, &rc.State) {
+		t.Error("цель достигнута у миссии без орбиты")
+	}
+}
+
+func TestRegisterInvalidMission(t *testing.T) {
+	conn := dialTestServer(t, NewServer(protocol.JSON))
+
+	payload := `{"type":"register","data":{"rocket_id":"r1","config":{"name":"Тест","mass_empty":1000,"mass_fuel":500,"mass_fuel_max":500,` +
+		`"drag_coefficient":0.3,"cross_section":1,"engines":[{"thrust":30000,"fuel_consumption":10,"is_active":true}]},` +
+		`"mission":{"target_orbit":400000,"target_inclination":200}}}`
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(payload)); err != nil {
+		t.Fatal(err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg protocol.Message
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatal(err)
+	}
+	rejected, err := protocol.DecodeData[protocol.RejectedMessage](msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Type != protocol.MsgTypeRejected || rejected.Code != protocol.RejectCodeInvalidConfig ||
+		!strings.Contains(rejected.Reason, "mission.target_inclination") {
+		t.Errorf("ответ %s %+v, ожидался отказ invalid_config", msg.Type, rejected)
+	}
+}
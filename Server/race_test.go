@@ -0,0 +1,7 @@
+//go:build race
+
+package main
+
+// raceEnabled - тесты собраны с -race: детектор гонок случайно
+// выбрасывает значения из sync.Pool, и счет выделений памяти не точен
+const raceEnabled = true
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"container/list"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+type RateLimiterStats struct {
+	Allowed     uint64 `json:"allowed"`
+	Rejected    uint64 `json:"rejected"`
+	Whitelisted uint64 `json:"whitelisted"`
+	Evicted     uint64 `json:"evicted"`
+	TrackedIPs  int    `json:"tracked_ips"`
+}
+
+type ipBucket struct {
+	ip     string
+	tokens float64
+	last   time.Time
+}
+
+// IPRateLimiter - token bucket на каждый IP. Число отслеживаемых IP
+// ограничено, самые давние вытесняются по LRU.
+type IPRateLimiter struct {
+	rate       float64 // Токенов в секунду
+	burst      float64 // Емкость ведра
+	maxEntries int
+	whitelist  []*net.IPNet
+	buckets    map[string]*list.Element
+	lru        *list.List
+	stats      RateLimiterStats
+	mu         sync.Mutex
+}
+
+func NewIPRateLimiter(rate float64, burst int, maxEntries int, whitelist []string) (*IPRateLimiter, error) {
+	limiter := &IPRateLimiter{
+		rate:       rate,
+		burst:      float64(burst),
+		maxEntries: maxEntries,
+		buckets:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+
+	for _, entry := range whitelist {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if strings.Contains(entry, ":") {
+				entry += "/128"
+			} else {
+				entry += "/32"
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, err
+		}
+		limiter.whitelist = append(limiter.whitelist, network)
+	}
+
+	return limiter, nil
+}
+
+func (l *IPRateLimiter) isWhitelisted(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range l.whitelist {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *IPRateLimiter) Allow(ip string) bool {
+	if l == nil || l.rate <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.isWhitelisted(ip) {
+		l.stats.Whitelisted++
+		return true
+	}
+
+	now := time.Now()
+	var bucket *ipBucket
+	if elem, ok := l.buckets[ip]; ok {
+		l.lru.MoveToFront(elem)
+		bucket = elem.Value.(*ipBucket)
+		bucket.tokens += now.Sub(bucket.last).Seconds() * l.rate
+		if bucket.tokens > l.burst {
+			bucket.tokens = l.burst
+		}
+		bucket.last = now
+	} else {
+		bucket = &ipBucket{ip: ip, tokens: l.burst, last: now}
+		l.buckets[ip] = l.lru.PushFront(bucket)
+		for l.lru.Len() > l.maxEntries {
+			oldest := l.lru.Back()
+			l.lru.Remove(oldest)
+			delete(l.buckets, oldest.Value.(*ipBucket).ip)
+			l.stats.Evicted++
+		}
+	}
+
+	if bucket.tokens < 1 {
+		l.stats.Rejected++
+		return false
+	}
+	bucket.tokens--
+	l.stats.Allowed++
+	return true
+}
+
+func (l *IPRateLimiter) Stats() RateLimiterStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	stats := l.stats
+	stats.TrackedIPs = l.lru.Len()
+	return stats
+}
+
+func remoteIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
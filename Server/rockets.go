@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"cosmodrom/protocol"
+)
+
+const maxRecentWarnings = 10
+
+type RocketStats struct {
+	TelemetryCount uint64  `json:"telemetry_count"`
+	MaxAltitude    float64 `json:"max_altitude"`   // м
+	MaxSpeed       float64 `json:"max_speed"`      // м/с
+	FlightTime     float64 `json:"flight_time"`    // с, по времени симуляции
+	DroppedFrames  uint64  `json:"dropped_frames"` // Телеметрия, отброшенная как повтор или не по порядку
+	// TransitDelayMs - задержка доставки последнего сообщения по меткам
+	// времени ракеты и сервера, мс. При спешащих часах ракеты - 0.
+	TransitDelayMs float64 `json:"transit_delay_ms"`
+}
+
+func (st *RocketStats) update(state *protocol.RocketState) {
+	st.TelemetryCount++
+	if state.Altitude > st.MaxAltitude {
+		st.MaxAltitude = state.Altitude
+	}
+	if state.Speed > st.MaxSpeed {
+		st.MaxSpeed = state.Speed
+	}
+	st.FlightTime = state.Time
+}
+
+// telemetryFrame - последний кадр ракеты для рассылки наблюдателям
+type telemetryFrame struct {
+	message      protocol.BroadcastMessage
+	broadcastSeq uint64
+	lastSeq      uint64 // Последний принятый seq ракеты
+	// clockSkew - расхождение часов ракеты и сервера сверх
+	// protocol.ClockSkewThreshold, впервые замеченное в соединении
+	clockSkew time.Duration
+}
+
+// record проверяет seq сообщения и учитывает его кадры по порядку.
+// false - сообщение отброшено как повтор или устаревшее.
+func (rc *RocketConnection) record(now time.Time, msg protocol.Message, states []protocol.RocketState) (telemetryFrame, bool, uint64) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	ok, gap := rc.sequence.accept(msg.Seq)
+	if !ok {
+		rc.Stats.DroppedFrames += uint64(len(states))
+		return telemetryFrame{lastSeq: rc.sequence.last}, false, 0
+	}
+	for i := range states {
+		rc.Stats.update(&states[i])
+		if rc.history != nil {
+			rc.history.add(now, states[i])
+		}
+	}
+	rc.State = states[len(states)-1]
+	rc.LastUpdate = now
+	rc.broadcastSeq++
+
+	frame := telemetryFrame{
+		message:      protocol.BroadcastMessage{RocketID: rc.ID, Name: rc.Config.Name, State: rc.State},
+		broadcastSeq: rc.broadcastSeq,
+		lastSeq:      rc.sequence.last,
+	}
+	if !msg.Timestamp.IsZero() {
+		delay, skewed := protocol.TransitDelay(msg.Timestamp, now)
+		rc.Stats.TransitDelayMs = float64(delay) / float64(time.Millisecond)
+		if skewed && !rc.skewLogged {
+			rc.skewLogged = true
+			frame.clockSkew = now.Sub(msg.Timestamp)
+		}
+	}
+	return frame, true, gap
+}
+
+type WarningRecord struct {
+	Timestamp time.Time            `json:"timestamp"`
+	Code      protocol.WarningCode `json:"code,omitempty"` // Пусто у записей об abort
+	Warning   string               `json:"warning"`
+	Severity  protocol.Severity    `json:"severity"`
+}
+
+func (rc *RocketConnection) addWarning(now time.Time, code protocol.WarningCode, warning string, severity protocol.Severity) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if len(rc.recentWarnings) >= maxRecentWarnings {
+		rc.recentWarnings = rc.recentWarnings[1:]
+	}
+	rc.recentWarnings = append(rc.recentWarnings, WarningRecord{
+		Timestamp: now,
+		Code:      code,
+		Warning:   warning,
+		Severity:  severity,
+	})
+}
+
+type OrbitInfo struct {
+	Apoapsis         float64 `json:"apoapsis"`
+	Periapsis        float64 `json:"periapsis"`
+	Eccentricity     float64 `json:"eccentricity"`
+	RequiredVelocity float64 `json:"required_velocity"`
+	IsStable         bool    `json:"is_stable"`
+	Inclination      float64 `json:"inclination"`
+	RAAN             float64 `json:"raan"`
+	ArgPeriapsis     float64 `json:"arg_periapsis"`
+}
+
+type RocketDetails struct {
+	protocol.RocketInfo
+	RemoteAddr    string      `json:"remote_addr"`
+	ConnectedAt   time.Time   `json:"connected_at"`
+	LastUpdate    time.Time   `json:"last_update"`
+	LastUpdateAge float64     `json:"last_update_age"` // с
+	Stats         RocketStats `json:"stats"`
+	Orbit         OrbitInfo   `json:"orbit"`
+	// Engines - двигатели текущей ступени из последней телеметрии
+	// (state.engine_status), отдельно для ?fields=engines
+	Engines        []protocol.EngineStatus `json:"engines"`
+	RecentWarnings []WarningRecord         `json:"recent_warnings"`
+}
+
+func (rc *RocketConnection) details(now time.Time) RocketDetails {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	return RocketDetails{
+		RocketInfo: protocol.RocketInfo{
+			RocketID: rc.ID,
+			Name:     rc.Config.Name,
+			Labels:   rc.Config.Labels,
+			State:    rc.State,
+			Config:   rc.Config,
+			Mission:  rc.Mission,
+		},
+		RemoteAddr:    rc.Conn.RemoteAddr().String(),
+		ConnectedAt:   rc.ConnectedAt,
+		LastUpdate:    rc.LastUpdate,
+		LastUpdateAge: math.Max(now.Sub(rc.LastUpdate).Seconds(), 0), // Часы сервера могли перевести назад
+		Stats:         rc.Stats,
+		Orbit: OrbitInfo{
+			Apoapsis:         rc.State.OrbitApoapsis,
+			Periapsis:        rc.State.OrbitPeriapsis,
+			Eccentricity:     rc.State.OrbitEccentricity,
+			RequiredVelocity: rc.State.OrbitRequiredVelocity,
+			IsStable:         rc.State.OrbitIsStable,
+			Inclination:      rc.State.OrbitInclination,
+			RAAN:             rc.State.OrbitRAAN,
+			ArgPeriapsis:     rc.State.OrbitArgPeriapsis,
+		},
+		Engines:        append([]protocol.EngineStatus{}, rc.State.EngineStatus...),
+		RecentWarnings: append([]WarningRecord{}, rc.recentWarnings...),
+	}
+}
+
+func (s *Server) handleRocketDetails(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	rocketID := r.PathValue("id")
+	s.mu.RLock()
+	rocket, exists := s.rockets[rocketID]
+	s.mu.RUnlock()
+
+	if !exists {
+		writeJSONError(w, http.StatusNotFound, "rocket not found: "+rocketID)
+		return
+	}
+
+	fields := r.URL.Query().Get("fields")
+	if fields == "" {
+		writeJSON(w, http.StatusOK, rocket.details(s.clock.Now()))
+		return
+	}
+
+	trimmed, err := selectFields(rocket.details(s.clock.Now()), strings.Split(fields, ","))
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, trimmed)
+}
+
+// selectFields оставляет в JSON-объекте только перечисленные поля верхнего уровня
+func selectFields(v interface{}, fields []string) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]json.RawMessage, len(fields))
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if value, ok := all[field]; ok {
+			result[field] = value
+		}
+	}
+	return result, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
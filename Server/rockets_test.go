@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"cosmodrom/protocol"
+)
+
+// detailsTestServer - сервер с ракетами r1 и r/1 по одному кадру телеметрии
+func detailsTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	s := NewServer(protocol.JSON)
+	for _, id := range []string{"r1", "r/1"} {
+		rc := &RocketConnection{ID: id, Conn: testConn(t), Config: protocol.RocketConfig{Name: "Ракета " + id}}
+		rc.record(time.Now(), protocol.Message{Seq: 1}, []protocol.RocketState{{Time: 5, Altitude: 1200, Speed: 150}})
+		s.rockets[id] = rc
+	}
+	server := httptest.NewServer(s.routes())
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRocketDetailsEndpoint(t *testing.T) {
+	server := detailsTestServer(t)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantRocket string   // rocket_id в ответе 200
+		wantFields []string // Поля ответа 200 с ?fields=, nil - все
+	}{
+		{name: "ракета", method: http.MethodGet, path: "/api/rockets/r1", wantStatus: http.StatusOK, wantRocket: "r1"},
+		{name: "выбранные поля", method: http.MethodGet, path: "/api/rockets/r1?fields=stats,%20orbit,unknown", wantStatus: http.StatusOK, wantFields: []string{"orbit", "stats"}},
+		{name: "ID с экранированным слешем", method: http.MethodGet, path: "/api/rockets/r%2F1", wantStatus: http.StatusOK, wantRocket: "r/1"},
+		{name: "неизвестная ракета", method: http.MethodGet, path: "/api/rockets/r2", wantStatus: http.StatusNotFound},
+		{name: "пустой ID", method: http.MethodGet, path: "/api/rockets/", wantStatus: http.StatusNotFound},
+		{name: "не GET", method: http.MethodPost, path: "/api/rockets/r1", wantStatus: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, server.URL+tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("статус %d, ожидался %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			data, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantFields != nil {
+				var body map[string]json.RawMessage
+				if err := json.Unmarshal(data, &body); err != nil {
+					t.Fatal(err)
+				}
+				if got := slices.Sorted(maps.Keys(body)); !slices.Equal(got, tt.wantFields) {
+					t.Errorf("поля %v, ожидались %v", got, tt.wantFields)
+				}
+				return
+			}
+			var details RocketDetails
+			if err := json.Unmarshal(data, &details); err != nil {
+				t.Fatal(err)
+			}
+			if details.RocketID != tt.wantRocket || details.Stats.TelemetryCount != 1 || details.State.Altitude != 1200 || details.RemoteAddr == "" {
+				t.Errorf("подробности %+v", details)
+			}
+		})
+	}
+}
+
+// ID с неверным %-кодированием отклоняется до обработчика
+func TestRocketDetailsMalformedID(t *testing.T) {
+	server := detailsTestServer(t)
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	fmt.Fprintf(conn, "GET /api/rockets/r%%zz HTTP/1.1\r\nHost: %s\r\n\r\n", server.Listener.Addr())
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("статус %d, ожидался 400", resp.StatusCode)
+	}
+}
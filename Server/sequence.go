@@ -0,0 +1,29 @@
+package main
+
+// seqGapThreshold - пропуск номеров, после которого в журнал пишется
+// возможная потеря сообщений. Между кадрами телеметрии идут heartbeat и
+// другие сообщения ракеты, поэтому небольшой пропуск - норма.
+const seqGapThreshold = 50
+
+// sequenceTracker проверяет порядок сообщений ракеты по Message.Seq в
+// пределах одного соединения
+type sequenceTracker struct {
+	last uint64
+}
+
+// accept решает, принять ли сообщение с номером seq. Повтор и сообщение
+// старше последнего принятого отбрасываются. gap - сколько номеров
+// пропущено перед seq. Номер 0 (старый клиент) принимается без проверки.
+func (t *sequenceTracker) accept(seq uint64) (ok bool, gap uint64) {
+	if seq == 0 {
+		return true, 0
+	}
+	if seq <= t.last {
+		return false, 0
+	}
+	if t.last > 0 {
+		gap = seq - t.last - 1
+	}
+	t.last = seq
+	return true, gap
+}
@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestSequenceTracker(t *testing.T) {
+	steps := []struct {
+		seq     uint64
+		wantOK  bool
+		wantGap uint64
+	}{
+		{seq: 1, wantOK: true},
+		{seq: 2, wantOK: true},
+		{seq: 2, wantOK: false},            // Повтор
+		{seq: 5, wantOK: true, wantGap: 2}, // Пропущены 3 и 4
+		{seq: 4, wantOK: false},            // Опоздавшее сообщение
+		{seq: 0, wantOK: true},             // Старый клиент без номеров
+		{seq: 6, wantOK: true},
+	}
+
+	var tracker sequenceTracker
+	for _, step := range steps {
+		ok, gap := tracker.accept(step.seq)
+		if ok != step.wantOK || gap != step.wantGap {
+			t.Errorf("seq %d: принято %v, пропуск %d; ожидалось %v, %d", step.seq, ok, gap, step.wantOK, step.wantGap)
+		}
+	}
+}
+
+// Первое сообщение с номером не считается пропуском, даже если до него
+// были сообщения без проверки (регистрация старым клиентом)
+func TestSequenceTrackerFirst(t *testing.T) {
+	var tracker sequenceTracker
+	if ok, gap := tracker.accept(40); !ok || gap != 0 {
+		t.Errorf("первое сообщение: принято %v, пропуск %d", ok, gap)
+	}
+}
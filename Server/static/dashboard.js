@@ -0,0 +1,379 @@
+const rockets = {};
+let selectedRocketId = null;
+let ws = null;
+let logPollTimer = null;
+let lastLogTime = null;
+// dashboardConfig подставляет сервер в шаблоне templates/index.html
+const observerToken = new URLSearchParams(location.search).get('token');
+
+function connectWS() {
+    const protocol = location.protocol === 'https:' ? 'wss:' : 'ws:';
+    ws = new WebSocket(protocol + '//' + location.host + dashboardConfig.ws_path);
+
+    ws.onopen = () => {
+        document.getElementById('ws-dot').style.background = '#4caf50';
+        document.getElementById('ws-status').textContent =
+            dashboardConfig.token_required && !observerToken ? 'Нужен токен: добавьте ?token= к адресу' : 'Подключено';
+        ws.send(JSON.stringify({
+            type: 'subscribe',
+            timestamp: new Date().toISOString(),
+            data: {
+                observer_id: 'web-dashboard-' + Math.random().toString(36).substr(2, 6),
+                token: observerToken || undefined
+            }
+        }));
+    };
+
+    ws.onclose = () => {
+        document.getElementById('ws-dot').style.background = '#ef5350';
+        document.getElementById('ws-status').textContent = 'Отключено';
+        setTimeout(connectWS, dashboardConfig.reconnect_ms);
+    };
+
+    ws.onerror = () => {
+        document.getElementById('ws-dot').style.background = '#ef5350';
+        document.getElementById('ws-status').textContent = 'Ошибка';
+    };
+
+    ws.onmessage = (event) => {
+        const msg = JSON.parse(event.data);
+        handleMessage(msg);
+    };
+}
+
+function handleMessage(msg) {
+    switch (msg.type) {
+        case 'rocket_joined':
+            rockets[msg.data.rocket_id] = {
+                id: msg.data.rocket_id,
+                name: msg.data.name,
+                config: msg.data.config,
+                mission: msg.data.mission || null,
+                state: null
+            };
+            renderRocketList();
+            break;
+
+        case 'mission_event':
+            if (rockets[msg.data.rocket_id] && msg.data.event === 'target_achieved') {
+                rockets[msg.data.rocket_id].missionAchieved = true;
+                if (msg.data.rocket_id === selectedRocketId) {
+                    renderTelemetry(rockets[msg.data.rocket_id]);
+                }
+            }
+            break;
+
+        case 'broadcast':
+            if (rockets[msg.data.rocket_id]) {
+                rockets[msg.data.rocket_id].state = msg.data.state;
+                rockets[msg.data.rocket_id].name = msg.data.name;
+            } else {
+                rockets[msg.data.rocket_id] = {
+                    id: msg.data.rocket_id,
+                    name: msg.data.name,
+                    config: null,
+                    state: msg.data.state
+                };
+            }
+            renderRocketList();
+            if (msg.data.rocket_id === selectedRocketId) {
+                renderTelemetry(rockets[msg.data.rocket_id]);
+            }
+            break;
+
+        case 'rocket_left':
+            delete rockets[msg.data.rocket_id];
+            if (msg.data.rocket_id === selectedRocketId) {
+                deselectRocket();
+            }
+            renderRocketList();
+            break;
+
+        case 'warning':
+            break;
+    }
+    document.getElementById('rocket-count').textContent = Object.keys(rockets).length;
+}
+
+function getStatusInfo(state) {
+    if (!state) return { text: 'ОЖИДАНИЕ', cls: 'flight' };
+    if (state.crashed) return { text: 'КРУШЕНИЕ', cls: 'crashed' };
+    if (state.landed) return { text: 'ПОСАДКА', cls: 'landed' };
+    if (state.in_orbit) return { text: 'ОРБИТА', cls: 'orbit' };
+    return { text: 'ПОЛЁТ', cls: 'flight' };
+}
+
+function renderRocketList() {
+    const list = document.getElementById('rocket-list');
+    const ids = Object.keys(rockets);
+    if (ids.length === 0) {
+        list.innerHTML = '<div style="padding: 20px; color: #6e7681; text-align: center; font-size: 12px;">Нет активных ракет</div>';
+        return;
+    }
+
+    list.innerHTML = ids.map(id => {
+        const r = rockets[id];
+        const st = getStatusInfo(r.state);
+        const alt = r.state ? (r.state.altitude / 1000).toFixed(1) : '0.0';
+        const spd = r.state ? r.state.speed.toFixed(0) : '0';
+        const sel = id === selectedRocketId ? 'selected' : '';
+        return '<div class="rocket-item ' + sel + '" onclick="selectRocket(\'' + id + '\')">' +
+            '<div class="name">' + escapeHtml(r.name) +
+            '<span class="status-badge status-' + st.cls + '">' + st.text + '</span></div>' +
+            '<div class="id">' + escapeHtml(id) + '</div>' +
+            '<div class="mini-stats"><span>ALT: <span class="val">' + alt + ' км</span></span>' +
+            '<span>SPD: <span class="val">' + spd + ' м/с</span></span></div></div>';
+    }).join('');
+}
+
+function selectRocket(id) {
+    selectedRocketId = id;
+    document.getElementById('no-rocket-msg').style.display = 'none';
+    document.getElementById('telemetry-grid').style.display = 'grid';
+    renderRocketList();
+    if (rockets[id]) renderTelemetry(rockets[id]);
+    // Переключаем логи на выбранную ракету
+    switchLogView(id);
+    updateLogTabLabel();
+}
+
+function deselectRocket() {
+    selectedRocketId = null;
+    document.getElementById('no-rocket-msg').style.display = 'flex';
+    document.getElementById('telemetry-grid').style.display = 'none';
+    renderRocketList();
+    // Возвращаемся к серверным логам
+    switchLogView(null);
+    updateLogTabLabel();
+}
+
+function updateLogTabLabel() {
+    const tabLabel = document.querySelector('.tab[data-tab="logs"]');
+    if (selectedRocketId && rockets[selectedRocketId]) {
+        tabLabel.textContent = 'Логи: ' + rockets[selectedRocketId].name;
+    } else {
+        tabLabel.textContent = 'Логи сервера';
+    }
+}
+
+// Широта и долгота в градусах: 45.920° с.ш., 63.342° в.д.
+function formatLatLon(lat, lon) {
+    return Math.abs(lat).toFixed(3) + '° ' + (lat >= 0 ? 'с.ш.' : 'ю.ш.') + ', ' +
+        Math.abs(lon).toFixed(3) + '° ' + (lon >= 0 ? 'в.д.' : 'з.д.');
+}
+
+// Двигатели текущей ступени: ID (или номер), дроссель, время работы
+function renderEngines(engines) {
+    const el = document.getElementById('t-engines');
+    if (!engines || engines.length === 0) {
+        el.textContent = '-';
+        return;
+    }
+    el.innerHTML = engines.map((e, i) => {
+        const name = escapeHtml(e.id || ('#' + i));
+        const state = e.failed ? '<span style="color: #f85149;">отказ</span>' :
+            (e.active ? '<span style="color: #3fb950;">работает</span>' : '<span style="color: #6e7681;">выключен</span>');
+        return '<div>' + name + ': ' + state + ', ' + ((e.throttle || 0) * 100).toFixed(0) + '%, ' +
+            (e.burn_time || 0).toFixed(1) + ' с</div>';
+    }).join('');
+}
+
+function renderMission(rocket) {
+    const el = document.getElementById('t-mission');
+    const m = rocket.mission;
+    if (!m) {
+        el.textContent = '-';
+        return;
+    }
+    const parts = [];
+    if (m.target_orbit) {
+        let target = 'орбита ' + (m.target_orbit / 1000).toFixed(0) + ' км';
+        if (m.target_inclination !== undefined) {
+            target += ', ' + m.target_inclination.toFixed(1) + '°';
+        }
+        parts.push(target + (rocket.missionAchieved ? ' (достигнута)' : ''));
+    }
+    if (m.launch_site) parts.push(m.launch_site);
+    if (m.crewed) parts.push('пилотируемая');
+    if (m.description) parts.push(m.description);
+    el.textContent = parts.length ? parts.join(' · ') : '-';
+}
+
+function renderTelemetry(rocket) {
+    const s = rocket.state;
+    if (!s) return;
+
+    document.getElementById('t-altitude').textContent = (s.altitude / 1000).toFixed(2);
+    document.getElementById('t-speed').textContent = s.speed.toFixed(1);
+
+    // Нулевые значения в JSON не передаются, как и у старых клиентов
+    document.getElementById('t-gforce').textContent = (s.g_force || 0).toFixed(2);
+    document.getElementById('t-q').textContent = ((s.dynamic_pressure || 0) / 1000).toFixed(1);
+    document.getElementById('t-vspeed').textContent = (s.vertical_speed || 0).toFixed(1);
+    document.getElementById('t-gspeed').textContent = (s.ground_speed || 0).toFixed(1);
+    document.getElementById('t-latlon').textContent = formatLatLon(s.latitude || 0, s.longitude || 0);
+    document.getElementById('t-mass').textContent = s.mass_current.toFixed(0);
+    document.getElementById('t-time').textContent = s.time.toFixed(1);
+
+    const st = getStatusInfo(s);
+    const statusEl = document.getElementById('t-status');
+    statusEl.textContent = st.text;
+    statusEl.className = 'value status-badge status-' + st.cls;
+    statusEl.style.fontSize = '16px';
+
+    document.getElementById('t-fuel').textContent = s.fuel_remaining.toFixed(0);
+    const maxFuel = rocket.config ? rocket.config.mass_fuel_max : s.fuel_remaining;
+    const pct = maxFuel > 0 ? (s.fuel_remaining / maxFuel * 100) : 0;
+    document.getElementById('t-fuel-pct').textContent = pct.toFixed(1);
+    document.getElementById('t-fuel-bar').style.width = pct + '%';
+
+    renderEngines(s.engine_status);
+    renderMission(rocket);
+
+    document.getElementById('t-px').textContent = s.position.x.toFixed(0);
+    document.getElementById('t-py').textContent = s.position.y.toFixed(0);
+    document.getElementById('t-pz').textContent = s.position.z.toFixed(0);
+
+    // Орбитальные данные
+    const apoapsis = s.orbit_apoapsis;
+    const periapsis = s.orbit_periapsis;
+    const reqV = s.orbit_required_velocity;
+    const isStable = s.orbit_is_stable;
+
+    if (apoapsis && apoapsis > 0) {
+        document.getElementById('t-apoapsis').textContent = (apoapsis / 1000).toFixed(1);
+    } else {
+        document.getElementById('t-apoapsis').textContent = '-';
+    }
+
+    if (periapsis !== undefined) {
+        document.getElementById('t-periapsis').textContent = (periapsis / 1000).toFixed(1);
+    } else {
+        document.getElementById('t-periapsis').textContent = '-';
+    }
+
+    if (reqV && reqV > 0) {
+        document.getElementById('t-orbital-v').textContent = reqV.toFixed(0);
+    } else {
+        document.getElementById('t-orbital-v').textContent = '-';
+    }
+
+    const orbitStatusEl = document.getElementById('t-orbit-status');
+    if (s.in_orbit) {
+        orbitStatusEl.textContent = 'СТАБИЛЬНАЯ ОРБИТА';
+        orbitStatusEl.className = 'status-badge status-orbit';
+    } else if (isStable) {
+        orbitStatusEl.textContent = 'ВЫХОД НА ОРБИТУ';
+        orbitStatusEl.className = 'status-badge status-orbit';
+    } else if (periapsis !== undefined && periapsis > 0) {
+        orbitStatusEl.textContent = 'СУБОРБИТАЛЬНАЯ';
+        orbitStatusEl.className = 'status-badge status-landed';
+    } else {
+        orbitStatusEl.textContent = 'БАЛЛИСТИЧЕСКАЯ';
+        orbitStatusEl.className = 'status-badge status-crashed';
+    }
+}
+
+let currentLogRocketId = null; // Текущий фильтр логов (null = серверные логи)
+
+const fleetStatusNames = {
+    waiting: 'ожидание', flight: 'полёт', orbit: 'орбита',
+    landed: 'посадка', crashed: 'крушение', aborted: 'авария'
+};
+
+function formatUptime(seconds) {
+    const h = Math.floor(seconds / 3600);
+    const m = Math.floor(seconds % 3600 / 60);
+    return h > 0 ? h + ' ч ' + m + ' мин' : m + ' мин';
+}
+
+// Сводка парка для строки в шапке
+function pollSummary() {
+    fetch(dashboardConfig.summary_path)
+        .then(r => r.json())
+        .then(summary => {
+            const parts = Object.keys(fleetStatusNames)
+                .filter(status => summary.by_status[status])
+                .map(status => fleetStatusNames[status] + ': <b>' + summary.by_status[status] + '</b>');
+            if (summary.highest) {
+                parts.push('выше всех: <b>' + escapeHtml(summary.highest.rocket_id) + '</b> ' +
+                    (summary.highest.altitude / 1000).toFixed(1) + ' км');
+            }
+            if (summary.fastest) {
+                parts.push('быстрее всех: <b>' + escapeHtml(summary.fastest.rocket_id) + '</b> ' +
+                    summary.fastest.speed.toFixed(0) + ' м/с');
+            }
+            parts.push('топливо: <b>' + (summary.fuel_burned / 1000).toFixed(1) + '</b> т');
+            if (summary.proximity_alerts.length > 0) {
+                parts.push('<span class="alert">сближений: ' + summary.proximity_alerts.length + '</span>');
+            }
+            parts.push('наблюдателей: <b>' + summary.observers + '</b>');
+            parts.push('работает ' + formatUptime(summary.uptime));
+            document.getElementById('fleet-summary').innerHTML = parts.join(' · ');
+        })
+        .catch(() => {});
+}
+
+function pollLogs() {
+    let url = '/api/logs';
+    const params = [];
+    if (lastLogTime) {
+        params.push('since=' + encodeURIComponent(lastLogTime));
+    }
+    if (currentLogRocketId) {
+        params.push('rocket_id=' + encodeURIComponent(currentLogRocketId));
+    }
+    if (params.length > 0) {
+        url += '?' + params.join('&');
+    }
+    fetch(url)
+        .then(r => r.json())
+        .then(logs => {
+            if (!logs || logs.length === 0) return;
+            const container = document.getElementById('log-container');
+            logs.forEach(entry => {
+                const div = document.createElement('div');
+                div.className = 'log-entry';
+                const t = new Date(entry.timestamp);
+                const timeStr = t.toLocaleTimeString('ru-RU');
+                div.innerHTML =
+                    '<span class="log-time">' + timeStr + '</span>' +
+                    '<span class="log-level ' + entry.level + '">' + entry.level + '</span>' +
+                    '<span class="log-msg">' + escapeHtml(entry.message) + '</span>';
+                container.appendChild(div);
+                lastLogTime = entry.timestamp;
+            });
+            container.scrollTop = container.scrollHeight;
+        })
+        .catch(() => {});
+}
+
+function switchLogView(rocketId) {
+    // Переключение между серверными логами и логами ракеты
+    currentLogRocketId = rocketId;
+    lastLogTime = null; // Сброс времени для загрузки всех логов
+    document.getElementById('log-container').innerHTML = ''; // Очистка
+    pollLogs(); // Загрузка логов
+}
+
+function escapeHtml(str) {
+    const div = document.createElement('div');
+    div.textContent = str;
+    return div.innerHTML;
+}
+
+// Tabs
+document.querySelectorAll('.tab').forEach(tab => {
+    tab.addEventListener('click', () => {
+        document.querySelectorAll('.tab').forEach(t => t.classList.remove('active'));
+        document.querySelectorAll('.tab-content').forEach(c => c.classList.remove('active'));
+        tab.classList.add('active');
+        document.getElementById('tab-' + tab.dataset.tab).classList.add('active');
+    });
+});
+
+connectWS();
+pollLogs();
+logPollTimer = setInterval(pollLogs, dashboardConfig.log_poll_ms);
+pollSummary();
+setInterval(pollSummary, dashboardConfig.summary_poll_ms);
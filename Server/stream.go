@@ -0,0 +1,154 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"cosmodrom/protocol"
+)
+
+const (
+	streamBuffer    = 64               // Событий в очереди наблюдателя /api/stream
+	streamKeepAlive = 15 * time.Second // Комментарий-пинг, чтобы прокси не закрывали поток
+)
+
+// streamEventNames - имена событий SSE. Аварии и события миссии приходят
+// одним событием alert, тип сообщения остается в поле type конверта.
+var streamEventNames = map[protocol.MessageType]string{
+	protocol.MsgTypeRocketJoined: "rocket_joined",
+	protocol.MsgTypeBroadcast:    "broadcast",
+	protocol.MsgTypeRocketLeft:   "rocket_left",
+	protocol.MsgTypeAbort:        "alert",
+	protocol.MsgTypeMissionEvent: "alert",
+}
+
+type streamEvent struct {
+	name    string
+	payload []byte
+}
+
+// eventStream - очередь событий наблюдателя /api/stream. Рассылка не ждет
+// медленного читателя: переполненная очередь закрывается, и наблюдатель
+// отключается.
+type eventStream struct {
+	events    chan streamEvent
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newEventStream() *eventStream {
+	return &eventStream{
+		events: make(chan streamEvent, streamBuffer),
+		done:   make(chan struct{}),
+	}
+}
+
+// push ставит событие в очередь. false - очередь переполнена или закрыта.
+// Типы без события SSE пропускаются.
+func (e *eventStream) push(msgType protocol.MessageType, payload []byte) bool {
+	name, ok := streamEventNames[msgType]
+	if !ok {
+		return true
+	}
+	select {
+	case <-e.done:
+		return false
+	default:
+	}
+	select {
+	case e.events <- streamEvent{name: name, payload: payload}:
+		return true
+	default:
+		e.close()
+		return false
+	}
+}
+
+func (e *eventStream) close() {
+	e.closeOnce.Do(func() { close(e.done) })
+}
+
+// observerAuthorized проверяет токен наблюдателя; без -observer-token
+// подписка открыта всем
+func (s *Server) observerAuthorized(token string) bool {
+	if s.observerToken == "" {
+		return true
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.observerToken)) == 1
+}
+
+// handleStream - GET /api/stream: события наблюдателя в формате
+// Server-Sent Events. Фильтры: ?rocket_id= и ?label=ключ=значение (можно
+// повторять), токен - заголовок Authorization: Bearer или ?token=.
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSONError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	query := r.URL.Query()
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		token = query.Get("token")
+	}
+	if !s.observerAuthorized(token) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeJSONError(w, http.StatusUnauthorized, "observer token required")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	connID := newConnID()
+	stream := newEventStream()
+	observer := &ObserverConnection{
+		ID:         "sse-" + connID,
+		ConnID:     connID,
+		Codec:      protocol.JSON,
+		Labels:     parseLabelSelector(query["label"]),
+		RocketIDs:  rocketFilter(query["rocket_id"]),
+		LastUpdate: s.clock.Now(),
+		stream:     stream,
+	}
+
+	s.addObserver(observer)
+	defer s.removeObserver(observer.ID)
+
+	s.sendCurrentRocketsToObserver(observer)
+	connLog(connID, "", "info", "stream_connected", protocol.LogFields{"observer_id": observer.ID, "remote_addr": r.RemoteAddr})
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case event := <-stream.events:
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.name, event.payload); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-stream.done:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
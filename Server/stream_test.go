@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"cosmodrom/protocol"
+)
+
+type sseEvent struct {
+	name string
+	msg  protocol.Message
+}
+
+// openStream подключается к /api/stream и возвращает чтение событий по одному
+func openStream(t *testing.T, s *Server, query string) func() sseEvent {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(s.handleStream))
+	t.Cleanup(server.Close)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	t.Cleanup(cancel)
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/stream"+query, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("ответ %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	lines := bufio.NewScanner(resp.Body)
+	return func() sseEvent {
+		t.Helper()
+		var event sseEvent
+		for lines.Scan() {
+			line := lines.Text()
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				event.name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				msg, err := protocol.JSON.Decode([]byte(strings.TrimPrefix(line, "data: ")))
+				if err != nil {
+					t.Fatal(err)
+				}
+				event.msg = msg
+			case line == "" && event.name != "":
+				return event
+			}
+		}
+		t.Fatalf("поток оборвался: %v", lines.Err())
+		return event
+	}
+}
+
+func addTestRocket(s *Server, id string, labels map[string]string) {
+	s.mu.Lock()
+	s.rockets[id] = &RocketConnection{ID: id, Config: protocol.RocketConfig{Name: "Ракета " + id, Labels: labels}}
+	s.mu.Unlock()
+}
+
+func TestStreamEvents(t *testing.T) {
+	s := NewServer(protocol.JSON)
+	addTestRocket(s, "r1", nil)
+	next := openStream(t, s, "")
+
+	event := next()
+	if joined, ok := event.msg.Data.(protocol.RocketJoinedMessage); event.name != "rocket_joined" || !ok || joined.Config.Name != "Ракета r1" {
+		t.Fatalf("первое событие %s %#v", event.name, event.msg.Data)
+	}
+	if event := next(); event.name != "broadcast" || event.msg.Type != protocol.MsgTypeBroadcast {
+		t.Fatalf("второе событие %s %s", event.name, event.msg.Type)
+	}
+
+	s.broadcastToObservers("r1", nil, protocol.MsgTypeAbort, 7, protocol.AbortMessage{RocketID: "r1", Reason: "max-g"})
+	event = next()
+	if abort, ok := event.msg.Data.(protocol.AbortMessage); event.name != "alert" || !ok || abort.Reason != "max-g" || event.msg.Seq != 7 {
+		t.Errorf("авария пришла как %s %#v #%d", event.name, event.msg.Data, event.msg.Seq)
+	}
+	s.broadcastToObservers("r1", nil, protocol.MsgTypeRocketLeft, 0, protocol.RocketLeftMessage{RocketID: "r1", Reason: "disconnected"})
+	if event := next(); event.name != "rocket_left" {
+		t.Errorf("отключение пришло как %s", event.name)
+	}
+}
+
+// Фильтры по ID и меткам: события чужих ракет в поток не попадают
+func TestStreamFilters(t *testing.T) {
+	s := NewServer(protocol.JSON)
+	addTestRocket(s, "r1", map[string]string{"team": "red"})
+	addTestRocket(s, "r2", map[string]string{"team": "red"})
+	addTestRocket(s, "r3", map[string]string{"team": "blue"})
+	next := openStream(t, s, "?rocket_id=r2&rocket_id=r3&label=team=red")
+
+	for _, want := range []string{"rocket_joined", "broadcast"} {
+		if event := next(); event.name != want {
+			t.Fatalf("событие %s, ожидалось %s", event.name, want)
+		}
+	}
+
+	s.broadcastToObservers("r1", map[string]string{"team": "red"}, protocol.MsgTypeBroadcast, 0, protocol.BroadcastMessage{RocketID: "r1"})
+	s.broadcastToObservers("r3", map[string]string{"team": "blue"}, protocol.MsgTypeBroadcast, 0, protocol.BroadcastMessage{RocketID: "r3"})
+	s.broadcastToObservers("r2", map[string]string{"team": "red"}, protocol.MsgTypeRocketLeft, 0, protocol.RocketLeftMessage{RocketID: "r2"})
+	if event := next(); event.name != "rocket_left" || event.msg.Data.(protocol.RocketLeftMessage).RocketID != "r2" {
+		t.Errorf("после фильтра пришло %s %#v", event.name, event.msg.Data)
+	}
+}
+
+func TestStreamToken(t *testing.T) {
+	s := NewServer(protocol.JSON)
+	s.observerToken = "secret"
+
+	rec := httptest.NewRecorder()
+	s.handleStream(rec, httptest.NewRequest(http.MethodGet, "/api/stream?token=wrong", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("неверный токен: %d", rec.Code)
+	}
+	openStream(t, s, "?token=secret")
+
+	// Подписка по WebSocket без токена отклоняется ошибкой unauthorized
+	conn := dialTestServer(t, s)
+	if err := conn.WriteJSON(protocol.Message{Type: protocol.MsgTypeSubscribe, Data: protocol.SubscribeMessage{ObserverID: "o1"}}); err != nil {
+		t.Fatal(err)
+	}
+	if errMsg := readError(t, conn); errMsg.Code != protocol.ErrorCodeUnauthorized {
+		t.Errorf("ошибка %+v, ожидалась unauthorized", errMsg)
+	}
+	s.mu.RLock()
+	_, subscribed := s.observers["o1"]
+	s.mu.RUnlock()
+	if subscribed {
+		t.Error("наблюдатель без токена подписан")
+	}
+}
+
+// Наблюдатель, который не успевает читать, отключается, а рассылка не ждет
+func TestStreamSlowConsumer(t *testing.T) {
+	s := NewServer(protocol.JSON)
+	observer := &ObserverConnection{ID: "sse-slow", Codec: protocol.JSON, stream: newEventStream()}
+	s.addObserver(observer)
+
+	for i := 0; i <= streamBuffer; i++ {
+		s.broadcastToObservers("r1", nil, protocol.MsgTypeBroadcast, uint64(i), protocol.BroadcastMessage{RocketID: "r1"})
+	}
+	select {
+	case <-observer.stream.done:
+	default:
+		t.Fatal("переполненный поток не закрыт")
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.observers) != 0 {
+		t.Errorf("медленный наблюдатель остался в списке")
+	}
+}
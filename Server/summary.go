@@ -0,0 +1,232 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"cosmodrom/protocol"
+)
+
+// Статусы ракет в сводке
+const (
+	fleetWaiting = "waiting" // Зарегистрирована, телеметрии еще нет
+	fleetFlight  = "flight"
+	fleetOrbit   = "orbit"
+	fleetLanded  = "landed"
+	fleetCrashed = "crashed"
+	fleetAborted = "aborted" // Прислала abort и еще не приземлилась
+)
+
+type FleetLeader struct {
+	RocketID string  `json:"rocket_id"`
+	Altitude float64 `json:"altitude"` // м
+	Speed    float64 `json:"speed"`    // м/с
+}
+
+// ProximityAlert - пара ракет в опасном сближении по последней проверке
+type ProximityAlert struct {
+	RocketIDs     [2]string         `json:"rocket_ids"`
+	Distance      float64           `json:"distance"`        // м, наибольшее сближение
+	TimeToClosest float64           `json:"time_to_closest"` // с
+	Severity      protocol.Severity `json:"severity"`
+}
+
+type FleetSummary struct {
+	Rockets         int              `json:"rockets"`
+	ByStatus        map[string]int   `json:"by_status"`
+	Highest         *FleetLeader     `json:"highest,omitempty"`
+	Fastest         *FleetLeader     `json:"fastest,omitempty"`
+	FuelBurned      float64          `json:"fuel_burned"` // кг за время работы сервера, включая отключившиеся ракеты
+	ProximityAlerts []ProximityAlert `json:"proximity_alerts"`
+	Observers       int              `json:"observers"`
+	StartedAt       time.Time        `json:"started_at"`
+	Uptime          float64          `json:"uptime"` // с
+}
+
+type fleetRocket struct {
+	status   string
+	aborted  bool
+	hasFuel  bool // fuel - остаток из телеметрии
+	altitude float64
+	speed    float64
+	fuel     float64
+}
+
+// fleetTracker ведет сводку парка по мере прихода телеметрии, чтобы
+// /api/summary не обходил ракеты под их блокировками. Под f.mu не берутся
+// ни s.mu, ни блокировки ракет; join и leave вызываются под s.mu, чтобы
+// сводка не расходилась со списком ракет.
+type fleetTracker struct {
+	mu         sync.Mutex
+	rockets    map[string]*fleetRocket
+	counts     map[string]int
+	fuelBurned float64
+	alerts     []ProximityAlert
+}
+
+func newFleetTracker() *fleetTracker {
+	return &fleetTracker{
+		rockets: make(map[string]*fleetRocket),
+		counts:  make(map[string]int),
+	}
+}
+
+func (f *fleetTracker) join(rocketID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rockets[rocketID] = &fleetRocket{status: fleetWaiting}
+	f.counts[fleetWaiting]++
+}
+
+// telemetry учитывает принятые кадры ракеты. Сожженное топливо - сумма
+// убыли остатка между соседними кадрами.
+func (f *fleetTracker) telemetry(rocketID string, states []protocol.RocketState) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rocket, ok := f.rockets[rocketID]
+	if !ok || len(states) == 0 {
+		return
+	}
+	for i := range states {
+		if rocket.hasFuel && states[i].FuelRemaining < rocket.fuel {
+			f.fuelBurned += rocket.fuel - states[i].FuelRemaining
+		}
+		rocket.fuel = states[i].FuelRemaining
+		rocket.hasFuel = true
+	}
+	last := &states[len(states)-1]
+	rocket.altitude = last.Altitude
+	rocket.speed = last.Speed
+	f.setStatus(rocket, fleetStatus(last, rocket.aborted))
+}
+
+func (f *fleetTracker) abort(rocketID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if rocket, ok := f.rockets[rocketID]; ok && !rocket.aborted {
+		rocket.aborted = true
+		if rocket.status != fleetLanded && rocket.status != fleetCrashed {
+			f.setStatus(rocket, fleetAborted)
+		}
+	}
+}
+
+func (f *fleetTracker) leave(rocketID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rocket, ok := f.rockets[rocketID]
+	if !ok {
+		return
+	}
+	f.counts[rocket.status]--
+	delete(f.rockets, rocketID)
+
+	alerts := f.alerts[:0]
+	for _, alert := range f.alerts {
+		if alert.RocketIDs[0] != rocketID && alert.RocketIDs[1] != rocketID {
+			alerts = append(alerts, alert)
+		}
+	}
+	f.alerts = alerts
+}
+
+// setAlerts заменяет сближения результатом очередной проверки. Пары с
+// ракетой, отключившейся во время проверки, отбрасываются.
+func (f *fleetTracker) setAlerts(alerts []ProximityAlert) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.alerts = f.alerts[:0]
+	for _, alert := range alerts {
+		_, ok1 := f.rockets[alert.RocketIDs[0]]
+		_, ok2 := f.rockets[alert.RocketIDs[1]]
+		if ok1 && ok2 {
+			f.alerts = append(f.alerts, alert)
+		}
+	}
+}
+
+// proximityAlert - сближение пары с ID по порядку
+func proximityAlert(id1, id2 string, distance, tca float64, severity protocol.Severity) ProximityAlert {
+	if id2 < id1 {
+		id1, id2 = id2, id1
+	}
+	return ProximityAlert{RocketIDs: [2]string{id1, id2}, Distance: distance, TimeToClosest: tca, Severity: severity}
+}
+
+func (f *fleetTracker) setStatus(rocket *fleetRocket, status string) {
+	if rocket.status == status {
+		return
+	}
+	f.counts[rocket.status]--
+	f.counts[status]++
+	rocket.status = status
+}
+
+func fleetStatus(state *protocol.RocketState, aborted bool) string {
+	switch {
+	case state.Crashed:
+		return fleetCrashed
+	case state.Landed:
+		return fleetLanded
+	case aborted:
+		return fleetAborted
+	case state.InOrbit:
+		return fleetOrbit
+	}
+	return fleetFlight
+}
+
+// summary - согласованный снимок сводки. Лидеры ищутся по сводке, без
+// обращения к ракетам; при равенстве выигрывает меньший ID.
+func (f *fleetTracker) summary() FleetSummary {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	summary := FleetSummary{
+		Rockets:         len(f.rockets),
+		ByStatus:        make(map[string]int, len(f.counts)),
+		FuelBurned:      f.fuelBurned,
+		ProximityAlerts: append([]ProximityAlert{}, f.alerts...),
+	}
+	for status, count := range f.counts {
+		if count > 0 {
+			summary.ByStatus[status] = count
+		}
+	}
+
+	ids := make([]string, 0, len(f.rockets))
+	for id, rocket := range f.rockets {
+		if rocket.status != fleetWaiting {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		rocket := f.rockets[id]
+		leader := &FleetLeader{RocketID: id, Altitude: rocket.altitude, Speed: rocket.speed}
+		if summary.Highest == nil || rocket.altitude > summary.Highest.Altitude {
+			summary.Highest = leader
+		}
+		if summary.Fastest == nil || rocket.speed > summary.Fastest.Speed {
+			summary.Fastest = leader
+		}
+	}
+	return summary
+}
+
+func (s *Server) handleSummary(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	summary := s.fleet.summary()
+	s.mu.RLock()
+	summary.Observers = len(s.observers)
+	s.mu.RUnlock()
+	summary.StartedAt = s.startedAt
+	summary.Uptime = s.clock.Now().Sub(s.startedAt).Seconds()
+	writeJSON(w, http.StatusOK, summary)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"cosmodrom/protocol"
+)
+
+func TestFleetSummary(t *testing.T) {
+	s := NewServer(protocol.JSON)
+	clock := &fakeClock{now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	s.clock = clock
+	s.startedAt = clock.now.Add(-90 * time.Minute)
+	s.observers["o1"] = &ObserverConnection{ID: "o1"}
+
+	for _, id := range []string{"r1", "r2", "r3"} {
+		s.fleet.join(id)
+	}
+	s.fleet.telemetry("r1", []protocol.RocketState{
+		{FuelRemaining: 1000, Altitude: 1000, Speed: 100},
+		{FuelRemaining: 900, Altitude: 50000, Speed: 1500},
+	})
+	s.fleet.telemetry("r1", []protocol.RocketState{{FuelRemaining: 850, Altitude: 100000, Speed: 2000}})
+	s.fleet.telemetry("r2", []protocol.RocketState{{FuelRemaining: 500, Altitude: 390000, Speed: 7700}})
+	s.fleet.telemetry("r2", []protocol.RocketState{{FuelRemaining: 400, Altitude: 400000, Speed: 7600, InOrbit: true}})
+	s.fleet.abort("r1")
+	s.fleet.setAlerts([]ProximityAlert{proximityAlert("r2", "r1", 800, 1.5, protocol.SeverityMedium)})
+
+	rec := httptest.NewRecorder()
+	s.handleSummary(rec, httptest.NewRequest(http.MethodGet, "/api/summary", nil))
+	var summary FleetSummary
+	if err := json.NewDecoder(rec.Body).Decode(&summary); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := map[string]int{fleetAborted: 1, fleetOrbit: 1, fleetWaiting: 1}; !reflect.DeepEqual(summary.ByStatus, want) {
+		t.Errorf("по статусам %v, ожидалось %v", summary.ByStatus, want)
+	}
+	if summary.Highest == nil || summary.Highest.RocketID != "r2" || summary.Highest.Altitude != 400000 {
+		t.Errorf("выше всех %+v", summary.Highest)
+	}
+	if summary.Fastest == nil || summary.Fastest.RocketID != "r2" || summary.Fastest.Speed != 7600 {
+		t.Errorf("быстрее всех %+v", summary.Fastest)
+	}
+	if summary.FuelBurned != 250 {
+		t.Errorf("сожжено %.0f кг, ожидалось 250", summary.FuelBurned)
+	}
+	if len(summary.ProximityAlerts) != 1 || summary.ProximityAlerts[0].RocketIDs != [2]string{"r1", "r2"} {
+		t.Errorf("сближения %+v", summary.ProximityAlerts)
+	}
+	if summary.Rockets != 3 || summary.Observers != 1 || summary.Uptime != 5400 {
+		t.Errorf("ракет %d, наблюдателей %d, работает %.0f с", summary.Rockets, summary.Observers, summary.Uptime)
+	}
+
+	// Отключившаяся ракета уходит из счетчиков и сближений, но ее топливо
+	// остается в итоге сессии
+	s.fleet.leave("r1")
+	s.fleet.telemetry("r1", []protocol.RocketState{{FuelRemaining: 0}})
+	summary = s.fleet.summary()
+	if want := map[string]int{fleetOrbit: 1, fleetWaiting: 1}; !reflect.DeepEqual(summary.ByStatus, want) || summary.Rockets != 2 {
+		t.Errorf("после отключения %v", summary.ByStatus)
+	}
+	if len(summary.ProximityAlerts) != 0 || summary.FuelBurned != 250 {
+		t.Errorf("после отключения сближений %d, сожжено %.0f кг", len(summary.ProximityAlerts), summary.FuelBurned)
+	}
+
+	s.fleet.telemetry("r2", []protocol.RocketState{{Landed: true}})
+	if status := s.fleet.summary().ByStatus; status[fleetLanded] != 1 || status[fleetOrbit] != 0 {
+		t.Errorf("после посадки %v", status)
+	}
+}
+
+// Сводка следует за регистрацией и отключением ракеты через WebSocket
+func TestFleetSummaryRegistration(t *testing.T) {
+	s := NewServer(protocol.JSON)
+	conn := dialTestServer(t, s)
+	if err := conn.WriteJSON(protocol.Message{Type: protocol.MsgTypeRegister, Data: protocol.RegisterMessage{
+		RocketID: "r1",
+		Config: protocol.RocketConfig{Name: "Тест", MassEmpty: 1000, MassFuel: 500, MassFuelMax: 500, DragCoefficient: 0.3, CrossSection: 1,
+			Engines: []protocol.Engine{{Thrust: 30000, FuelConsumption: 10, IsActive: true}}},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if msg := readFrame(t, conn, protocol.JSON); msg.Type != protocol.MsgTypeAccepted {
+		t.Fatalf("ответ %s", msg.Type)
+	}
+	if summary := s.fleet.summary(); summary.Rockets != 1 || summary.ByStatus[fleetWaiting] != 1 {
+		t.Errorf("после регистрации %+v", summary)
+	}
+
+	conn.Close()
+	for deadline := time.Now().Add(2 * time.Second); s.fleet.summary().Rockets != 0; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("ракета осталась в сводке после отключения")
+		}
+	}
+}
@@ -0,0 +1,830 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cborCodec - подмножество CBOR (RFC 8949), которого хватает сообщениям
+// протокола: числа, строки, массивы и словари определенной длины, true,
+// false и null. Поля структур называются как в JSON (теги json, omitempty),
+// числа с плавающей точкой всегда 64-битные, время - наносекунды Unix.
+// Теги CBOR и значения неопределенной длины не поддерживаются. Сообщения
+// больше cborMaxMessage, массивы и словари больше cborMaxItems элементов и
+// вложенность глубже cborMaxDepth не кодируются и не разбираются.
+type cborCodec struct{}
+
+func (cborCodec) Binary() bool   { return true }
+func (cborCodec) String() string { return "cbor" }
+
+const (
+	cborUint   = 0
+	cborNegint = 1
+	cborBytes  = 2
+	cborText   = 3
+	cborArray  = 4
+	cborMap    = 5
+	cborTag    = 6
+	cborSimple = 7
+
+	cborFalse   = 0xf4
+	cborTrue    = 0xf5
+	cborNull    = 0xf6
+	cborFloat64 = 0xfb
+
+	cborMaxDepth   = 64       // Глубже вложенных значений в протоколе нет
+	cborMaxItems   = 1 << 16  // Элементов в массиве или словаре: с запасом больше флота и траектории
+	cborMaxMessage = 16 << 20 // байт
+)
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// Encode пишет конверт словарем type, timestamp, seq, data; нулевые
+// timestamp и seq пропускаются
+func (c cborCodec) Encode(msg Message) ([]byte, error) {
+	payload, err := c.AppendEncode(make([]byte, 0, 256), msg)
+	if err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// AppendEncode - Encode, который дописывает конверт в dst
+func (cborCodec) AppendEncode(dst []byte, msg Message) ([]byte, error) {
+	e := cborEncoder{buf: dst}
+	fields := 2
+	if !msg.Timestamp.IsZero() {
+		fields++
+	}
+	if msg.Seq != 0 {
+		fields++
+	}
+	e.head(cborMap, uint64(fields))
+	e.text("type")
+	e.text(string(msg.Type))
+	if !msg.Timestamp.IsZero() {
+		e.text("timestamp")
+		e.int(msg.Timestamp.UnixNano())
+	}
+	if msg.Seq != 0 {
+		e.text("seq")
+		e.head(cborUint, msg.Seq)
+	}
+	e.text("data")
+	if err := e.encode(reflect.ValueOf(msg.Data), 0); err != nil {
+		return dst, fmt.Errorf("cbor: данные сообщения %s: %w", msg.Type, err)
+	}
+	if size := len(e.buf) - len(dst); size > cborMaxMessage {
+		return dst, fmt.Errorf("cbor: сообщение %s %d байт больше предела %d", msg.Type, size, cborMaxMessage)
+	}
+	return e.buf, nil
+}
+
+func (cborCodec) Decode(data []byte) (Message, error) {
+	var msg Message
+	if len(data) > cborMaxMessage {
+		return msg, fmt.Errorf("cbor: сообщение %d байт больше предела %d", len(data), cborMaxMessage)
+	}
+	d := cborDecoder{data: data}
+	major, fields, err := d.head()
+	if err != nil {
+		return msg, err
+	}
+	if major != cborMap {
+		return msg, errors.New("cbor: сообщение должно быть словарем")
+	}
+
+	// data может идти раньше type: сначала запоминаются его границы
+	var payload []byte
+	for i := uint64(0); i < fields; i++ {
+		key, err := d.text()
+		if err != nil {
+			return msg, err
+		}
+		switch key {
+		case "type":
+			msgType, err := d.text()
+			if err != nil {
+				return msg, fmt.Errorf("cbor: type: %w", err)
+			}
+			msg.Type = MessageType(msgType)
+		case "timestamp":
+			var nanos int64
+			if err := d.decode(reflect.ValueOf(&nanos).Elem(), "timestamp", 0); err != nil {
+				return msg, err
+			}
+			msg.Timestamp = time.Unix(0, nanos).UTC()
+		case "seq":
+			if err := d.decode(reflect.ValueOf(&msg.Seq).Elem(), "seq", 0); err != nil {
+				return msg, err
+			}
+		case "data":
+			start := d.pos
+			if err := d.skip(0); err != nil {
+				return msg, err
+			}
+			payload = data[start:d.pos]
+		default:
+			if err := d.skip(0); err != nil {
+				return msg, err
+			}
+		}
+	}
+	if d.pos != len(data) {
+		return msg, fmt.Errorf("cbor: %d лишних байт после сообщения", len(data)-d.pos)
+	}
+	if len(payload) == 0 || payload[0] == cborNull {
+		return msg, nil
+	}
+
+	if entry, ok := dataTypes[msg.Type]; ok {
+		value := reflect.New(entry.typ).Elem()
+		typed := cborDecoder{data: payload, strict: entry.strict}
+		if err := typed.decode(value, "", 0); err == nil {
+			msg.Data = value.Interface()
+			return msg, nil
+		}
+	}
+	// Незнакомый тип или неподходящие данные - в общем виде, как у JSON
+	generic := cborDecoder{data: payload}
+	msg.Data, err = generic.generic(0)
+	return msg, err
+}
+
+type cborEncoder struct {
+	buf []byte
+}
+
+func (e *cborEncoder) head(major byte, arg uint64) {
+	major <<= 5
+	switch {
+	case arg < 24:
+		e.buf = append(e.buf, major|byte(arg))
+	case arg <= math.MaxUint8:
+		e.buf = append(e.buf, major|24, byte(arg))
+	case arg <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, major|25), uint16(arg))
+	case arg <= math.MaxUint32:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, major|26), uint32(arg))
+	default:
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, major|27), arg)
+	}
+}
+
+func (e *cborEncoder) text(s string) {
+	e.head(cborText, uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *cborEncoder) int(n int64) {
+	if n < 0 {
+		e.head(cborNegint, uint64(-1-n))
+		return
+	}
+	e.head(cborUint, uint64(n))
+}
+
+func (e *cborEncoder) encode(v reflect.Value, depth int) error {
+	if depth > cborMaxDepth {
+		return errors.New("слишком глубокая вложенность")
+	}
+	if !v.IsValid() {
+		e.buf = append(e.buf, cborNull)
+		return nil
+	}
+	if v.Type() == timeType {
+		e.int(v.Interface().(time.Time).UnixNano())
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			e.buf = append(e.buf, cborNull)
+			return nil
+		}
+		// Интерфейс не уровень вложенности CBOR: глубина у кодера и
+		// декодера считается одинаково. Указатель считается, чтобы цикл
+		// указателей не уходил в бесконечную рекурсию.
+		if v.Kind() == reflect.Interface {
+			return e.encode(v.Elem(), depth)
+		}
+		return e.encode(v.Elem(), depth+1)
+	case reflect.Bool:
+		if v.Bool() {
+			e.buf = append(e.buf, cborTrue)
+		} else {
+			e.buf = append(e.buf, cborFalse)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.int(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.head(cborUint, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, cborFloat64), math.Float64bits(v.Float()))
+	case reflect.String:
+		e.text(v.String())
+	case reflect.Slice:
+		if v.IsNil() {
+			e.buf = append(e.buf, cborNull)
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			e.head(cborBytes, uint64(v.Len()))
+			e.buf = append(e.buf, v.Bytes()...)
+			return nil
+		}
+		return e.array(v, depth)
+	case reflect.Array:
+		return e.array(v, depth)
+	case reflect.Map:
+		if v.IsNil() {
+			e.buf = append(e.buf, cborNull)
+			return nil
+		}
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("ключи словаря %s не строки", v.Type())
+		}
+		if v.Len() > cborMaxItems {
+			return fmt.Errorf("%d элементов больше предела %d", v.Len(), cborMaxItems)
+		}
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		e.head(cborMap, uint64(len(keys)))
+		for _, key := range keys {
+			e.text(key.String())
+			if err := e.encode(v.MapIndex(key), depth+1); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		fields := cborFields(v.Type())
+		present := make([]reflect.Value, len(fields))
+		count := 0
+		for i, field := range fields {
+			value, ok := fieldByIndex(v, field.index)
+			if !ok || (field.omitEmpty && isEmptyValue(value)) {
+				continue
+			}
+			present[i] = value
+			count++
+		}
+		e.head(cborMap, uint64(count))
+		for i, field := range fields {
+			if !present[i].IsValid() {
+				continue
+			}
+			e.text(field.name)
+			if err := e.encode(present[i], depth+1); err != nil {
+				return fmt.Errorf("%s: %w", field.name, err)
+			}
+		}
+	default:
+		return fmt.Errorf("тип %s не поддерживается", v.Type())
+	}
+	return nil
+}
+
+func (e *cborEncoder) array(v reflect.Value, depth int) error {
+	if v.Len() > cborMaxItems {
+		return fmt.Errorf("%d элементов больше предела %d", v.Len(), cborMaxItems)
+	}
+	e.head(cborArray, uint64(v.Len()))
+	for i := 0; i < v.Len(); i++ {
+		if err := e.encode(v.Index(i), depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cborField - поле структуры под именем из тега json
+type cborField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+var cborFieldCache sync.Map // reflect.Type -> []cborField
+
+// cborFields перечисляет поля структуры так же, как encoding/json: без
+// json:"-" и неэкспортируемых, с полями встроенных структур без тега
+func cborFields(t reflect.Type) []cborField {
+	if cached, ok := cborFieldCache.Load(t); ok {
+		return cached.([]cborField)
+	}
+	var fields []cborField
+	seen := make(map[string]bool)
+	var collect func(t reflect.Type, index []int)
+	collect = func(t reflect.Type, index []int) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := field.Tag.Get("json")
+			name, options, _ := strings.Cut(tag, ",")
+			if name == "-" && options == "" {
+				continue
+			}
+			fieldIndex := append(append([]int{}, index...), i)
+			if field.Anonymous && name == "" {
+				embedded := field.Type
+				if embedded.Kind() == reflect.Pointer {
+					embedded = embedded.Elem()
+				}
+				if embedded.Kind() == reflect.Struct {
+					collect(embedded, fieldIndex)
+					continue
+				}
+			}
+			if !field.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			fields = append(fields, cborField{name: name, index: fieldIndex, omitEmpty: strings.Contains(options, "omitempty")})
+		}
+	}
+	collect(t, nil)
+	cborFieldCache.Store(t, fields)
+	return fields
+}
+
+// fieldByIndex - поле по пути index; false, если по пути nil-указатель на
+// встроенную структуру
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, n := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(n)
+	}
+	return v, true
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
+
+type cborDecoder struct {
+	data   []byte
+	pos    int
+	strict bool // Незнакомое поле структуры - ошибка *UnknownFieldError
+}
+
+var errCBORShort = errors.New("cbor: сообщение оборвано")
+
+// head читает заголовок значения: основной тип и аргумент (длину или число).
+// У простых значений (основной тип 7) аргумент - дополнительная информация.
+func (d *cborDecoder) head() (byte, uint64, error) {
+	if d.pos >= len(d.data) {
+		return 0, 0, errCBORShort
+	}
+	initial := d.data[d.pos]
+	d.pos++
+	major, info := initial>>5, initial&0x1f
+	if major == cborSimple && info >= 25 && info <= 27 {
+		return major, uint64(info), nil // Аргумент float читает float()
+	}
+	size := 0
+	switch {
+	case info < 24:
+		return major, uint64(info), nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		return 0, 0, fmt.Errorf("cbor: значения неопределенной длины не поддерживаются (байт %#x)", initial)
+	}
+	if len(d.data)-d.pos < size {
+		return 0, 0, errCBORShort
+	}
+	var arg uint64
+	for _, b := range d.data[d.pos : d.pos+size] {
+		arg = arg<<8 | uint64(b)
+	}
+	d.pos += size
+	return major, arg, nil
+}
+
+// float читает число с плавающей точкой размера info (25-27)
+func (d *cborDecoder) float(info uint64) (float64, error) {
+	size := 2 << (info - 25)
+	if len(d.data)-d.pos < size {
+		return 0, errCBORShort
+	}
+	bits := d.data[d.pos : d.pos+size]
+	d.pos += size
+	switch size {
+	case 2:
+		return float16(binary.BigEndian.Uint16(bits)), nil
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(bits))), nil
+	}
+	return math.Float64frombits(binary.BigEndian.Uint64(bits)), nil
+}
+
+// float16 переводит половинную точность IEEE 754 в float64
+func float16(bits uint16) float64 {
+	exponent := int(bits>>10) & 0x1f
+	mantissa := float64(bits & 0x3ff)
+	var value float64
+	switch exponent {
+	case 0:
+		value = math.Ldexp(mantissa, -24)
+	case 0x1f:
+		if mantissa == 0 {
+			value = math.Inf(1)
+		} else {
+			value = math.NaN()
+		}
+	default:
+		value = math.Ldexp(mantissa+1024, exponent-25)
+	}
+	if bits&0x8000 != 0 {
+		return -value
+	}
+	return value
+}
+
+// length проверяет, что в остатке сообщения хватит байт на n элементов по
+// min байт: длина из заголовка не должна заставлять выделять лишнюю память
+func (d *cborDecoder) length(n uint64, min int) (int, error) {
+	if n > uint64((len(d.data)-d.pos)/min) {
+		return 0, errCBORShort
+	}
+	return int(n), nil
+}
+
+// items - length для массивов и словарей, не больше cborMaxItems элементов
+func (d *cborDecoder) items(n uint64, min int) (int, error) {
+	count, err := d.length(n, min)
+	if err != nil {
+		return 0, err
+	}
+	if count > cborMaxItems {
+		return 0, fmt.Errorf("cbor: %d элементов больше предела %d", count, cborMaxItems)
+	}
+	return count, nil
+}
+
+func (d *cborDecoder) text() (string, error) {
+	major, arg, err := d.head()
+	if err != nil {
+		return "", err
+	}
+	if major != cborText {
+		return "", fmt.Errorf("cbor: ожидалась строка, основной тип %d", major)
+	}
+	n, err := d.length(arg, 1)
+	if err != nil {
+		return "", err
+	}
+	s := string(d.data[d.pos : d.pos+n])
+	d.pos += n
+	return s, nil
+}
+
+func (d *cborDecoder) peekNull() bool {
+	if d.pos < len(d.data) && d.data[d.pos] == cborNull {
+		d.pos++
+		return true
+	}
+	return false
+}
+
+func (d *cborDecoder) decode(v reflect.Value, path string, depth int) error {
+	if depth > cborMaxDepth {
+		return errors.New("cbor: слишком глубокая вложенность")
+	}
+	if d.peekNull() {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	if v.Type() == timeType {
+		var nanos int64
+		if err := d.decode(reflect.ValueOf(&nanos).Elem(), path, depth); err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(time.Unix(0, nanos).UTC()))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return d.decode(v.Elem(), path, depth+1)
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return d.mismatch(path, v.Type())
+		}
+		value, err := d.generic(depth)
+		if err != nil {
+			return err
+		}
+		if value != nil {
+			v.Set(reflect.ValueOf(value))
+		}
+		return nil
+	}
+
+	start := d.pos
+	major, arg, err := d.head()
+	if err != nil {
+		return err
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		if major != cborSimple || (arg != cborFalse&0x1f && arg != cborTrue&0x1f) {
+			return d.mismatchAt(start, path, v.Type())
+		}
+		v.SetBool(arg == cborTrue&0x1f)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		switch {
+		case major == cborUint && arg <= math.MaxInt64:
+			n = int64(arg)
+		case major == cborNegint && arg <= math.MaxInt64:
+			n = -1 - int64(arg)
+		default:
+			return d.mismatchAt(start, path, v.Type())
+		}
+		if v.OverflowInt(n) {
+			return fmt.Errorf("cbor: %s: %d не помещается в %s", path, n, v.Type())
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if major != cborUint || v.OverflowUint(arg) {
+			return d.mismatchAt(start, path, v.Type())
+		}
+		v.SetUint(arg)
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch {
+		case major == cborSimple && arg >= 25 && arg <= 27:
+			if f, err = d.float(arg); err != nil {
+				return err
+			}
+		case major == cborUint:
+			f = float64(arg)
+		case major == cborNegint:
+			f = -1 - float64(arg)
+		default:
+			return d.mismatchAt(start, path, v.Type())
+		}
+		v.SetFloat(f)
+	case reflect.String:
+		if major != cborText {
+			return d.mismatchAt(start, path, v.Type())
+		}
+		d.pos = start
+		s, err := d.text()
+		if err != nil {
+			return err
+		}
+		v.SetString(s)
+	case reflect.Slice:
+		if major == cborBytes && v.Type().Elem().Kind() == reflect.Uint8 {
+			n, err := d.length(arg, 1)
+			if err != nil {
+				return err
+			}
+			v.SetBytes(append([]byte{}, d.data[d.pos:d.pos+n]...))
+			d.pos += n
+			return nil
+		}
+		if major != cborArray {
+			return d.mismatchAt(start, path, v.Type())
+		}
+		n, err := d.items(arg, 1)
+		if err != nil {
+			return err
+		}
+		slice := reflect.MakeSlice(v.Type(), n, n)
+		for i := 0; i < n; i++ {
+			if err := d.decode(slice.Index(i), fmt.Sprintf("%s[%d]", path, i), depth+1); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	case reflect.Array:
+		if major != cborArray {
+			return d.mismatchAt(start, path, v.Type())
+		}
+		n, err := d.items(arg, 1)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.Zero(v.Type()))
+		for i := 0; i < n; i++ {
+			if i >= v.Len() {
+				if err := d.skip(depth + 1); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := d.decode(v.Index(i), fmt.Sprintf("%s[%d]", path, i), depth+1); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if major != cborMap || v.Type().Key().Kind() != reflect.String {
+			return d.mismatchAt(start, path, v.Type())
+		}
+		n, err := d.items(arg, 2)
+		if err != nil {
+			return err
+		}
+		m := reflect.MakeMapWithSize(v.Type(), n)
+		for i := 0; i < n; i++ {
+			key, err := d.text()
+			if err != nil {
+				return err
+			}
+			value := reflect.New(v.Type().Elem()).Elem()
+			if err := d.decode(value, joinFieldPath(path, key), depth+1); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), value)
+		}
+		v.Set(m)
+	case reflect.Struct:
+		if major != cborMap {
+			return d.mismatchAt(start, path, v.Type())
+		}
+		n, err := d.items(arg, 2)
+		if err != nil {
+			return err
+		}
+		fields := cborFields(v.Type())
+		for i := 0; i < n; i++ {
+			key, err := d.text()
+			if err != nil {
+				return err
+			}
+			field, ok := lookupField(fields, key)
+			if !ok {
+				if d.strict {
+					return &UnknownFieldError{Path: joinFieldPath(path, key)}
+				}
+				if err := d.skip(depth + 1); err != nil {
+					return err
+				}
+				continue
+			}
+			target := v
+			for j, n := range field.index {
+				if j > 0 && target.Kind() == reflect.Pointer {
+					if target.IsNil() {
+						target.Set(reflect.New(target.Type().Elem()))
+					}
+					target = target.Elem()
+				}
+				target = target.Field(n)
+			}
+			if err := d.decode(target, joinFieldPath(path, key), depth+1); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("cbor: %s: тип %s не поддерживается", path, v.Type())
+	}
+	return nil
+}
+
+// lookupField ищет поле по имени, как encoding/json - сначала точно, затем
+// без учета регистра
+func lookupField(fields []cborField, key string) (cborField, bool) {
+	for _, field := range fields {
+		if field.name == key {
+			return field, true
+		}
+	}
+	for _, field := range fields {
+		if strings.EqualFold(field.name, key) {
+			return field, true
+		}
+	}
+	return cborField{}, false
+}
+
+func (d *cborDecoder) mismatch(path string, t reflect.Type) error {
+	return fmt.Errorf("cbor: %s: значение не подходит к типу %s", path, t)
+}
+
+func (d *cborDecoder) mismatchAt(start int, path string, t reflect.Type) error {
+	if path == "" {
+		path = "data"
+	}
+	return fmt.Errorf("cbor: %s: значение (байт %#x) не подходит к типу %s", path, d.data[start], t)
+}
+
+// generic разбирает значение в общем виде, как encoding/json в interface{}:
+// словари - map[string]interface{}, массивы - []interface{}, числа - float64
+func (d *cborDecoder) generic(depth int) (interface{}, error) {
+	if depth > cborMaxDepth {
+		return nil, errors.New("cbor: слишком глубокая вложенность")
+	}
+	start := d.pos
+	major, arg, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+	switch major {
+	case cborUint:
+		return float64(arg), nil
+	case cborNegint:
+		return -1 - float64(arg), nil
+	case cborBytes:
+		n, err := d.length(arg, 1)
+		if err != nil {
+			return nil, err
+		}
+		value := append([]byte{}, d.data[d.pos:d.pos+n]...)
+		d.pos += n
+		return value, nil
+	case cborText:
+		d.pos = start
+		return d.text()
+	case cborArray:
+		n, err := d.items(arg, 1)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]interface{}, n)
+		for i := range values {
+			if values[i], err = d.generic(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	case cborMap:
+		n, err := d.items(arg, 2)
+		if err != nil {
+			return nil, err
+		}
+		values := make(map[string]interface{}, n)
+		for i := 0; i < n; i++ {
+			key, err := d.text()
+			if err != nil {
+				return nil, err
+			}
+			if values[key], err = d.generic(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	case cborSimple:
+		switch {
+		case arg == cborFalse&0x1f:
+			return false, nil
+		case arg == cborTrue&0x1f:
+			return true, nil
+		case arg == cborNull&0x1f:
+			return nil, nil
+		case arg >= 25 && arg <= 27:
+			return d.float(arg)
+		}
+	}
+	return nil, fmt.Errorf("cbor: неподдерживаемое значение (байт %#x)", d.data[start])
+}
+
+// skip пропускает значение целиком
+func (d *cborDecoder) skip(depth int) error {
+	_, err := d.generic(depth)
+	return err
+}
@@ -0,0 +1,33 @@
+package protocol
+
+import "time"
+
+// Clock - источник текущего времени. Сервер и клиент берут "сейчас" только
+// через него, чтобы тесты могли управлять временем.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// SystemClock - часы операционной системы
+var SystemClock Clock = systemClock{}
+
+// ClockSkewThreshold - расхождение часов отправителя и получателя, после
+// которого задержки по меткам времени считаются недостоверными
+const ClockSkewThreshold = 2 * time.Second
+
+// TransitDelay - задержка доставки сообщения, отправленного в sent по часам
+// отправителя и полученного в received по часам получателя. Часы разных
+// машин расходятся, поэтому отрицательная задержка обрезается до нуля,
+// а skewed сообщает, что расхождение больше ClockSkewThreshold.
+func TransitDelay(sent, received time.Time) (delay time.Duration, skewed bool) {
+	delay = received.Sub(sent)
+	skewed = delay > ClockSkewThreshold || delay < -ClockSkewThreshold
+	if delay < 0 {
+		delay = 0
+	}
+	return delay, skewed
+}
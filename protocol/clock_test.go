@@ -0,0 +1,86 @@
+package protocol
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMessageTimestampJSON(t *testing.T) {
+	at := time.Date(2026, 3, 1, 12, 0, 0, 123456789, time.UTC)
+	data, err := json.Marshal(Message{Type: MsgTypeHeartbeat, Timestamp: at})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"timestamp":"2026-03-01T12:00:00.123456789Z"`) ||
+		!strings.Contains(string(data), `"timestamp_ms":1772366400123`) {
+		t.Fatalf("нет одной из меток времени: %s", data)
+	}
+
+	tests := []struct {
+		name string
+		raw  string
+		want time.Time
+	}{
+		{name: "обе метки", raw: `{"type":"heartbeat","timestamp":"2026-03-01T12:00:00.123456789Z","timestamp_ms":1772366400123}`,
+			want: at.Truncate(time.Millisecond)},
+		{name: "только RFC3339", raw: `{"type":"heartbeat","timestamp":"2026-03-01T12:00:00.123456789Z"}`, want: at},
+		{name: "только миллисекунды", raw: `{"type":"heartbeat","timestamp_ms":1772366400123}`, want: at.Truncate(time.Millisecond)},
+		{name: "миллисекунды важнее", raw: `{"type":"heartbeat","timestamp":"2020-01-01T00:00:00Z","timestamp_ms":1772366400123}`,
+			want: at.Truncate(time.Millisecond)},
+		{name: "неразборчивая строка при миллисекундах", raw: `{"type":"heartbeat","timestamp":"вчера","timestamp_ms":1772366400123}`,
+			want: at.Truncate(time.Millisecond)},
+		{name: "без метки", raw: `{"type":"heartbeat"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := readMessage(t, tt.raw)
+			if !msg.Timestamp.Equal(tt.want) {
+				t.Errorf("время %v, ожидалось %v", msg.Timestamp, tt.want)
+			}
+			if msg.Type != MsgTypeHeartbeat {
+				t.Errorf("тип %q", msg.Type)
+			}
+		})
+	}
+
+	var msg Message
+	if err := json.Unmarshal([]byte(`{"type":"heartbeat","timestamp":"вчера"}`), &msg); err == nil {
+		t.Error("неразборчивая метка без timestamp_ms принята")
+	}
+}
+
+func TestMessageDecodesIntoData(t *testing.T) {
+	var heartbeat HeartbeatMessage
+	msg := Message{Data: &heartbeat}
+	if err := json.Unmarshal([]byte(`{"type":"heartbeat","seq":3,"data":{"rocket_id":"r1","nonce":7}}`), &msg); err != nil {
+		t.Fatal(err)
+	}
+	if heartbeat.RocketID != "r1" || heartbeat.Nonce != 7 || msg.Seq != 3 {
+		t.Errorf("данные %+v, seq %d", heartbeat, msg.Seq)
+	}
+}
+
+func TestTransitDelay(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		sent       time.Time
+		wantDelay  time.Duration
+		wantSkewed bool
+	}{
+		{name: "обычная задержка", sent: now.Add(-50 * time.Millisecond), wantDelay: 50 * time.Millisecond},
+		{name: "часы отправителя немного спешат", sent: now.Add(300 * time.Millisecond)},
+		{name: "часы отправителя сильно спешат", sent: now.Add(time.Minute), wantSkewed: true},
+		{name: "часы отправителя отстают", sent: now.Add(-time.Minute), wantDelay: time.Minute, wantSkewed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, skewed := TransitDelay(tt.sent, now)
+			if delay != tt.wantDelay || skewed != tt.wantSkewed {
+				t.Errorf("задержка %v (расхождение %v), ожидалось %v (%v)", delay, skewed, tt.wantDelay, tt.wantSkewed)
+			}
+		})
+	}
+}
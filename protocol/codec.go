@@ -0,0 +1,195 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Codec - формат сообщений на проводе. Decode сразу разбирает Data в тип,
+// зарегистрированный для Message.Type (DataType), поэтому DecodeData
+// получает готовое значение без повторного кодирования. Если Data не
+// подходит к типу, оно остается в исходном виде, и ошибку с путем к полю
+// вернет DecodeData или DecodeDataStrict получателя.
+type Codec interface {
+	Encode(msg Message) ([]byte, error)
+	Decode(data []byte) (Message, error)
+	// Binary - сообщения двоичные: по WebSocket они идут кадрами
+	// BinaryMessage, а не TextMessage
+	Binary() bool
+}
+
+// AppendEncoder - кодек, который дописывает конверт в чужой буфер: так
+// буфер переиспользуется между сообщениями
+type AppendEncoder interface {
+	AppendEncode(dst []byte, msg Message) ([]byte, error)
+}
+
+// AppendEncode дописывает конверт msg в dst кодеком codec. Кодек без
+// AppendEncoder кодирует как обычно, и результат копируется в dst.
+func AppendEncode(codec Codec, dst []byte, msg Message) ([]byte, error) {
+	if encoder, ok := codec.(AppendEncoder); ok {
+		return encoder.AppendEncode(dst, msg)
+	}
+	payload, err := codec.Encode(msg)
+	if err != nil {
+		return dst, err
+	}
+	return append(dst, payload...), nil
+}
+
+var (
+	JSON Codec = jsonCodec{} // Текстовый формат по умолчанию, его понимают панель и визуализатор
+	CBOR Codec = cborCodec{} // Двоичный формат RFC 8949 с теми же именами полей
+)
+
+// CodecByName возвращает кодек по имени из флага -codec
+func CodecByName(name string) (Codec, error) {
+	switch name {
+	case "", "json":
+		return JSON, nil
+	case "cbor":
+		return CBOR, nil
+	}
+	return nil, fmt.Errorf("неизвестный кодек %q (ожидается json или cbor)", name)
+}
+
+// dataType - тип Data сообщения. strict - незнакомое поле не пропускается
+// молча: значение остается в исходном виде для DecodeDataStrict.
+type dataType struct {
+	typ    reflect.Type
+	strict bool
+	values *sync.Pool // Указатели на typ для jsonCodec.Decode, обнуленные
+}
+
+var dataTypes = map[MessageType]dataType{
+	MsgTypeRegister:       {typ: reflect.TypeFor[RegisterMessage](), strict: true},
+	MsgTypeTelemetry:      {typ: reflect.TypeFor[TelemetryMessage]()},
+	MsgTypeTelemetryBatch: {typ: reflect.TypeFor[BatchTelemetryMessage]()},
+	MsgTypeDisconnect:     {typ: reflect.TypeFor[DisconnectMessage]()},
+	MsgTypeAbort:          {typ: reflect.TypeFor[AbortMessage]()},
+	MsgTypeHeartbeat:      {typ: reflect.TypeFor[HeartbeatMessage]()},
+	MsgTypeConfigRequest:  {typ: reflect.TypeFor[ConfigRequestMessage]()},
+	MsgTypeAccepted:       {typ: reflect.TypeFor[AcceptedMessage]()},
+	MsgTypeRejected:       {typ: reflect.TypeFor[RejectedMessage]()},
+	MsgTypeCommand:        {typ: reflect.TypeFor[CommandMessage]()},
+	MsgTypeWarning:        {typ: reflect.TypeFor[WarningMessage]()},
+	MsgTypeTrajectory:     {typ: reflect.TypeFor[TrajectoryMessage]()},
+	MsgTypeRocketList:     {typ: reflect.TypeFor[RocketListMessage]()},
+	MsgTypeConfigResponse: {typ: reflect.TypeFor[ConfigResponseMessage]()},
+	MsgTypeError:          {typ: reflect.TypeFor[ErrorMessage]()},
+	MsgTypeSubscribe:      {typ: reflect.TypeFor[SubscribeMessage]()},
+	MsgTypeUnsubscribe:    {typ: reflect.TypeFor[UnsubscribeMessage]()},
+	MsgTypeBroadcast:      {typ: reflect.TypeFor[BroadcastMessage]()},
+	MsgTypeRocketJoined:   {typ: reflect.TypeFor[RocketJoinedMessage]()},
+	MsgTypeRocketLeft:     {typ: reflect.TypeFor[RocketLeftMessage]()},
+	MsgTypeMissionEvent:   {typ: reflect.TypeFor[MissionEventMessage]()},
+}
+
+func init() {
+	for msgType, entry := range dataTypes {
+		entry.values = &sync.Pool{New: func() interface{} { return reflect.New(entry.typ).Interface() }}
+		dataTypes[msgType] = entry
+	}
+}
+
+// DataType - тип Data сообщений msgType; nil, если тип не зарегистрирован
+// (например, shutdown без данных)
+func DataType(msgType MessageType) reflect.Type {
+	return dataTypes[msgType].typ
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Binary() bool   { return false }
+func (jsonCodec) String() string { return "json" }
+
+func (jsonCodec) Encode(msg Message) ([]byte, error) {
+	return json.Marshal(msg)
+}
+
+// AppendEncode пишет конверт энкодером прямо в буфер вызывающего, без
+// промежуточных срезов Marshal и MarshalJSON
+func (jsonCodec) AppendEncode(dst []byte, msg Message) ([]byte, error) {
+	e := jsonEncoders.Get().(*jsonEncoder)
+	e.buf = *bytes.NewBuffer(dst)
+	err := e.enc.Encode(msg.wire())
+	encoded := bytes.TrimSuffix(e.buf.Bytes(), []byte("\n"))
+	e.buf = bytes.Buffer{} // Буфер вызывающего не остается в пуле
+	jsonEncoders.Put(e)
+	if err != nil {
+		return dst, err
+	}
+	return encoded, nil
+}
+
+// jsonEncoder - json.Encoder, привязанный к своему буферу: энкодер
+// создается один раз и живет в пуле
+type jsonEncoder struct {
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+var jsonEncoders = sync.Pool{New: func() interface{} {
+	e := &jsonEncoder{}
+	e.enc = json.NewEncoder(&e.buf)
+	return e
+}}
+
+// envelopes - конверты для Decode: Data читается в емкость буфера из
+// пула, и на кадр телеметрии не выделяется ни конверт, ни копия данных
+var envelopes = sync.Pool{New: func() interface{} { return new(envelopeJSON[json.RawMessage]) }}
+
+// maxPooledData - буфер Data больше этого не возвращается в пул: редкий
+// большой кадр не должен держать память
+const maxPooledData = 64 << 10
+
+// Decode разбирает конверт с Data в исходном виде и затем Data в тип,
+// зарегистрированный для Message.Type. Исходный вид попадает в сообщение
+// копией, только если тип неизвестен или Data к нему не подошел.
+func (jsonCodec) Decode(data []byte) (Message, error) {
+	envelope := envelopes.Get().(*envelopeJSON[json.RawMessage])
+	defer func() {
+		if cap(envelope.Data) <= maxPooledData {
+			envelopes.Put(envelope)
+		}
+	}()
+	*envelope = envelopeJSON[json.RawMessage]{Data: envelope.Data[:0]}
+
+	if err := json.Unmarshal(data, envelope); err != nil {
+		return Message{}, err
+	}
+	msg, err := envelope.message()
+	if err != nil {
+		return Message{}, err
+	}
+	raw := envelope.Data
+	if len(raw) == 0 || string(raw) == "null" {
+		return msg, nil
+	}
+
+	entry, ok := dataTypes[msg.Type]
+	if !ok {
+		msg.Data = json.RawMessage(bytes.Clone(raw))
+		return msg, nil
+	}
+	// Значение из пула копируется в msg.Data и обнуляется: в пуле не
+	// остается ссылок на данные сообщения
+	target := entry.values.Get()
+	value := reflect.ValueOf(target).Elem()
+	if entry.strict {
+		err = DecodeStrict(raw, target)
+	} else {
+		err = json.Unmarshal(raw, target)
+	}
+	if err == nil {
+		msg.Data = value.Interface()
+	} else {
+		msg.Data = json.RawMessage(bytes.Clone(raw))
+	}
+	value.SetZero()
+	entry.values.Put(target)
+	return msg, nil
+}
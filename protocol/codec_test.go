@@ -0,0 +1,348 @@
+package protocol
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var codecs = []Codec{JSON, CBOR}
+
+func sampleState() RocketState {
+	return RocketState{
+		Position:         Vector3{X: 6371000, Y: -12.5, Z: 0.25},
+		Velocity:         Vector3{X: 1, Y: 7600.125, Z: -3},
+		Altitude:         400000,
+		Speed:            7660.5,
+		MassCurrent:      21000,
+		FuelRemaining:    1000,
+		InOrbit:          true,
+		Time:             612.4,
+		OrbitApoapsis:    405000,
+		OrbitPeriapsis:   398000,
+		OrbitIsStable:    true,
+		OrbitInclination: 51.6,
+		GForce:           0.01,
+		Latitude:         -12.75,
+		Longitude:        170.5,
+		Stage:            2,
+		EngineStatus:     []EngineStatus{{ID: "center", Throttle: 0.8, Active: true, BurnTime: 42.5}, {Failed: true}},
+		Guidance:         &GuidanceStatus{WaypointIndex: 1, WaypointCount: 3, Distance: 1500},
+		Orientation:      &Orientation{Quaternion: Quaternion{W: 1}, Pitch: 90},
+		PayloadDeployed:  true,
+	}
+}
+
+func sampleConfig() RocketConfig {
+	config := validConfig()
+	config.Labels = map[string]string{"team": "red"}
+	config.Engines[0].ID = "center"
+	config.Stages = []Stage{{MassEmpty: 1000, MassFuel: 5000, Engines: config.Engines}}
+	config.Parachute = &Parachute{DeployAltitude: 3000, DragCoefficient: 1.5, Area: 1200}
+	config.Payload = &Payload{Name: "спутник", Mass: 100}
+	return config
+}
+
+// codecSamples - по значению Data на каждый зарегистрированный тип сообщения
+func codecSamples() map[MessageType]interface{} {
+	inclination := 51.6
+	return map[MessageType]interface{}{
+		MsgTypeRegister: RegisterMessage{RocketID: "r1", Config: sampleConfig(),
+			Mission: &Mission{TargetOrbit: 400000, TargetInclination: &inclination, LaunchSite: "Байконур"}},
+		MsgTypeTelemetry:      TelemetryMessage{RocketID: "r1", State: sampleState()},
+		MsgTypeTelemetryBatch: BatchTelemetryMessage{RocketID: "r1", States: []RocketState{sampleState(), {Time: 1}}},
+		MsgTypeDisconnect:     DisconnectMessage{RocketID: "r1", Reason: "конец полета"},
+		MsgTypeAbort:          AbortMessage{RocketID: "r1", Reason: "отказ", Time: 12.5, Altitude: 3000},
+		MsgTypeHeartbeat:      HeartbeatMessage{RocketID: "r1", Nonce: 1<<63 + 7},
+		MsgTypeConfigRequest:  ConfigRequestMessage{RocketID: "r1", Vehicle: "heavy"},
+		MsgTypeAccepted:       AcceptedMessage{RocketID: "r1", Message: "ок", ConnectionID: "c1"},
+		MsgTypeRejected:       RejectedMessage{RocketID: "r1", Code: RejectCodeDuplicateID, Reason: "занят"},
+		MsgTypeCommand: CommandMessage{RocketID: "r1", Command: ControlCommand{Pitch: -5, StageSeparate: true},
+			Attitude: &AttitudeHold{Mode: AttitudeSurfacePitch, Pitch: 45}, EngineThrottleByID: map[string]float64{"center": 0.5}},
+		MsgTypeWarning: WarningMessage{RocketID: "r1", Code: WarningCodeProximity, Warning: "сближение", Severity: SeverityHigh,
+			OtherRocketID: "r2", OtherPosition: &Vector3{X: 1}, Distance: 800},
+		MsgTypeTrajectory: TrajectoryMessage{RocketID: "r1", Waypoints: []Vector3{{X: 1}, {Y: 2}}},
+		MsgTypeRocketList: RocketListMessage{Rockets: []RocketInfo{{RocketID: "r1", Name: "Тест", State: sampleState(),
+			Config: sampleConfig(), Mission: &Mission{Crewed: true}}}},
+		MsgTypeConfigResponse: ConfigResponseMessage{RocketID: "r1", Vehicle: "heavy", Config: sampleConfig()},
+		MsgTypeError:          ErrorMessage{Code: ErrorCodeDecode, Detail: "не разобрано", RefType: MsgTypeTelemetry, RefSeq: 3, Suppressed: 2},
+		MsgTypeSubscribe:      SubscribeMessage{ObserverID: "o1", Labels: map[string]string{"team": ""}, RocketIDs: []string{"r1"}, Token: "t"},
+		MsgTypeUnsubscribe:    UnsubscribeMessage{ObserverID: "o1"},
+		MsgTypeBroadcast:      BroadcastMessage{RocketID: "r1", Name: "Тест", State: sampleState()},
+		MsgTypeRocketJoined:   RocketJoinedMessage{RocketID: "r1", Name: "Тест", Config: sampleConfig()},
+		MsgTypeRocketLeft:     RocketLeftMessage{RocketID: "r1", Reason: "disconnected"},
+		MsgTypeMissionEvent:   MissionEventMessage{RocketID: "r1", Event: MissionEventTargetAchieved, Time: 612.4, Message: "цель"},
+	}
+}
+
+// Каждый тип сообщения проходит через оба кодека без потерь, и Data
+// приходит уже нужного типа
+func TestCodecRoundTrip(t *testing.T) {
+	samples := codecSamples()
+	for msgType := range dataTypes {
+		if _, ok := samples[msgType]; !ok {
+			t.Errorf("нет образца для типа %s", msgType)
+		}
+	}
+
+	// JSON передает время с точностью до миллисекунды (timestamp_ms)
+	at := time.Date(2026, 3, 1, 12, 0, 0, 123000000, time.UTC)
+	for _, codec := range codecs {
+		for msgType, data := range samples {
+			t.Run(codec.(interface{ String() string }).String()+"/"+string(msgType), func(t *testing.T) {
+				encoded, err := codec.Encode(Message{Type: msgType, Timestamp: at, Seq: 42, Data: data})
+				if err != nil {
+					t.Fatal(err)
+				}
+				appended, err := AppendEncode(codec, []byte("prefix"), Message{Type: msgType, Timestamp: at, Seq: 42, Data: data})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.HasPrefix(appended, []byte("prefix")) || !bytes.Equal(appended[len("prefix"):], encoded) {
+					t.Errorf("AppendEncode\n%q\nотличается от Encode\n%q", appended, encoded)
+				}
+				msg, err := codec.Decode(encoded)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if msg.Type != msgType || !msg.Timestamp.Equal(at) || msg.Seq != 42 {
+					t.Errorf("конверт %s %v #%d", msg.Type, msg.Timestamp, msg.Seq)
+				}
+				if !reflect.DeepEqual(msg.Data, data) {
+					t.Errorf("данные\n%#v\nожидалось\n%#v", msg.Data, data)
+				}
+			})
+		}
+	}
+}
+
+func TestCodecUnknownType(t *testing.T) {
+	for _, codec := range codecs {
+		encoded, err := codec.Encode(Message{Type: "future", Data: map[string]interface{}{"x": 1.5, "tags": []interface{}{"a"}}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg, err := codec.Decode(encoded)
+		if err != nil {
+			t.Fatalf("%v: %v", codec, err)
+		}
+		// Незнакомый тип разбирается получателем через DecodeData
+		data, err := DecodeData[struct {
+			X    float64  `json:"x"`
+			Tags []string `json:"tags"`
+		}](msg)
+		if err != nil || data.X != 1.5 || len(data.Tags) != 1 {
+			t.Errorf("%v: %+v, %v", codec, data, err)
+		}
+
+		encoded, _ = codec.Encode(Message{Type: MsgTypeShutdown})
+		if msg, err := codec.Decode(encoded); err != nil || msg.Data != nil {
+			t.Errorf("%v: сообщение без данных: %+v, %v", codec, msg, err)
+		}
+	}
+}
+
+// Опечатка в регистрации не теряется: Data остается в исходном виде, и
+// DecodeDataStrict находит поле, а DecodeData его пропускает
+func TestCodecStrictRegister(t *testing.T) {
+	raw := map[string]interface{}{
+		"rocket_id": "r1",
+		"config":    map[string]interface{}{"name": "Тест", "mass_fule": 10.0},
+	}
+	for _, codec := range codecs {
+		encoded, err := codec.Encode(Message{Type: MsgTypeRegister, Data: raw})
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg, err := codec.Decode(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := msg.Data.(RegisterMessage); ok {
+			t.Fatalf("%v: регистрация с опечаткой разобрана молча", codec)
+		}
+		_, err = DecodeDataStrict[RegisterMessage](msg)
+		var unknown *UnknownFieldError
+		if !errors.As(err, &unknown) || unknown.Path != "config.mass_fule" {
+			t.Errorf("%v: ошибка %v, ожидалось неизвестное поле config.mass_fule", codec, err)
+		}
+		if register, err := DecodeData[RegisterMessage](msg); err != nil || register.Config.Name != "Тест" {
+			t.Errorf("%v: нестрогий разбор %+v, %v", codec, register, err)
+		}
+	}
+}
+
+func TestCBORRejectsMalformed(t *testing.T) {
+	valid, err := CBOR.Encode(Message{Type: MsgTypeTelemetry, Data: TelemetryMessage{RocketID: "r1", State: sampleState()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{name: "пусто", data: nil, want: "оборвано"},
+		{name: "оборвано", data: valid[:len(valid)/2], want: "оборвано"},
+		{name: "лишние байты", data: append(append([]byte{}, valid...), 0), want: "лишних"},
+		{name: "не словарь", data: []byte{0x80}, want: "словарем"},
+		{name: "огромный массив", data: []byte{0xa1, 0x64, 'd', 'a', 't', 'a', 0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, want: "оборвано"},
+		{name: "неопределенная длина", data: []byte{0xbf, 0xff}, want: "неопределенной"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := CBOR.Decode(tt.data); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ошибка %v, ожидалось %q", err, tt.want)
+			}
+		})
+	}
+
+	if len(valid) >= len(mustJSON(t)) {
+		t.Errorf("CBOR %d байт не короче JSON %d байт", len(valid), len(mustJSON(t)))
+	}
+}
+
+// cborData - конверт CBOR без типа с данными payload
+func cborData(payload ...byte) []byte {
+	return append([]byte{0xa1, 0x64, 'd', 'a', 't', 'a'}, payload...)
+}
+
+// cborNested - depth массивов из одного элемента вокруг пустого массива:
+// пустой массив на глубине depth
+func cborNested(depth int) []byte {
+	return append(bytes.Repeat([]byte{0x81}, depth), 0x80)
+}
+
+// cborZeros - массив из n нулей
+func cborZeros(n int) []byte {
+	return append([]byte{0x9a, byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n)}, make([]byte, n)...)
+}
+
+// Предел длины сообщения, числа элементов и вложенности: на пределе
+// сообщение разбирается, за ним - ошибка, и кодер не пишет того, что не
+// разберет декодер
+func TestCBORLimits(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string // Подстрока ошибки, пусто - разбирается
+	}{
+		{name: "элементов на пределе", data: cborData(cborZeros(cborMaxItems)...)},
+		{name: "элементов больше предела", data: cborData(cborZeros(cborMaxItems + 1)...), want: "элементов больше предела"},
+		{name: "словарь больше предела", data: cborData(append([]byte{0xba, 0, 1, 0, 1}, bytes.Repeat([]byte{0x60, 0}, cborMaxItems+1)...)...), want: "элементов больше предела"},
+		{name: "вложенность на пределе", data: cborData(cborNested(cborMaxDepth)...)},
+		{name: "вложенность больше предела", data: cborData(cborNested(cborMaxDepth + 1)...), want: "глубокая вложенность"},
+		{name: "сообщение больше предела", data: cborData(append([]byte{0x5a, 1, 0, 0, 0}, make([]byte, cborMaxMessage)...)...), want: "больше предела"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CBOR.Decode(tt.data)
+			if tt.want == "" && err != nil || tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+				t.Errorf("ошибка %v, ожидалось %q", err, tt.want)
+			}
+		})
+	}
+
+	for name, data := range map[string]interface{}{
+		"элементов больше предела": make([]int, cborMaxItems+1),
+		"словарь больше предела":   make(map[string]int, cborMaxItems+1),
+		"сообщение больше предела": make([]byte, cborMaxMessage),
+	} {
+		if m, ok := data.(map[string]int); ok {
+			for i := 0; i <= cborMaxItems; i++ {
+				m[fmt.Sprint(i)] = i
+			}
+		}
+		if _, err := CBOR.Encode(Message{Type: "test", Data: data}); err == nil || !strings.Contains(err.Error(), "больше предела") {
+			t.Errorf("%s: кодирование без ошибки: %v", name, err)
+		}
+	}
+}
+
+func mustJSON(t *testing.T) []byte {
+	data, err := JSON.Encode(Message{Type: MsgTypeTelemetry, Data: TelemetryMessage{RocketID: "r1", State: sampleState()}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// Декодер не падает и не выделяет память по длине из заголовка на любых
+// байтах, а разобранное сообщение кодируется обратно
+func FuzzCodecDecode(f *testing.F) {
+	for _, codec := range codecs {
+		for msgType, data := range codecSamples() {
+			encoded, err := codec.Encode(Message{Type: msgType, Seq: 1, Data: data})
+			if err != nil {
+				f.Fatal(err)
+			}
+			f.Add(encoded)
+		}
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, codec := range codecs {
+			msg, err := codec.Decode(data)
+			if err != nil {
+				continue
+			}
+			if _, err := CBOR.Encode(msg); err != nil {
+				t.Errorf("%v: разобранное сообщение не кодируется: %v", codec, err)
+			}
+		}
+	})
+}
+
+// Разобранное декодером CBOR кодируется обратно, а после одного повтора
+// кодирование больше не меняется: декодер не пропускает того, что не
+// может записать кодер
+func FuzzCBORDecode(f *testing.F) {
+	for msgType, data := range codecSamples() {
+		encoded, err := CBOR.Encode(Message{Type: msgType, Seq: 1, Timestamp: time.Unix(1, 0), Data: data})
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(encoded)
+		f.Add(encoded[:len(encoded)-1])
+	}
+	f.Add(cborData(cborNested(cborMaxDepth)...))
+	f.Add(cborData(cborNested(cborMaxDepth + 1)...))
+	f.Add(cborData(0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff))
+	f.Add(cborData(0xa2, 0x61, 'a', 0xf9, 0x7e, 0x00, 0x61, 'a', 0x40))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		msg, err := CBOR.Decode(data)
+		if err != nil {
+			return
+		}
+		first, err := CBOR.Encode(msg)
+		if err != nil {
+			t.Fatalf("разобранное сообщение не кодируется: %v", err)
+		}
+		again, err := CBOR.Decode(first)
+		if err != nil {
+			t.Fatalf("закодированное заново сообщение не разбирается: %v", err)
+		}
+		second, err := CBOR.Encode(again)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if again.Type != msg.Type || again.Seq != msg.Seq || !again.Timestamp.Equal(msg.Timestamp) {
+			t.Errorf("конверт %v/%d/%v, ожидался %v/%d/%v", again.Type, again.Seq, again.Timestamp, msg.Type, msg.Seq, msg.Timestamp)
+		}
+		if third, err := CBOR.Encode(mustDecode(t, second)); err != nil || !bytes.Equal(third, second) {
+			t.Errorf("кодирование не устоялось: %x, затем %x (%v)", second, third, err)
+		}
+	})
+}
+
+func mustDecode(t *testing.T, data []byte) Message {
+	t.Helper()
+	msg, err := CBOR.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
@@ -0,0 +1,204 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DecodeData возвращает Data сообщения как T. Codec.Decode уже разбирает
+// Data в зарегистрированный тип, и значение типа T или *T возвращается как
+// есть. json.RawMessage (данные, не подошедшие к типу) разбирается в T
+// напрямую, а map[string]interface{} после чтения Message через
+// encoding/json кодируется заново. Незнакомые поля пропускаются - так
+// старые получатели понимают новые сообщения.
+func DecodeData[T any](msg Message) (T, error) {
+	return decodeData[T](msg, false)
+}
+
+// DecodeDataStrict - как DecodeData, но незнакомое поле считается ошибкой
+func DecodeDataStrict[T any](msg Message) (T, error) {
+	return decodeData[T](msg, true)
+}
+
+func decodeData[T any](msg Message, strict bool) (T, error) {
+	switch data := msg.Data.(type) {
+	case nil:
+		var value T
+		return value, fmt.Errorf("сообщение %s без данных", msg.Type)
+	case T:
+		return data, nil
+	case *T:
+		if data != nil {
+			return *data, nil
+		}
+		var value T
+		return value, fmt.Errorf("сообщение %s без данных", msg.Type)
+	}
+	return unmarshalData[T](msg, strict)
+}
+
+// unmarshalData разбирает Data, которое кодек не привел к T. Отдельная
+// функция: значение, в которое пишет Unmarshal, уходит в кучу, а быстрый
+// путь decodeData обходится без выделений памяти.
+func unmarshalData[T any](msg Message, strict bool) (T, error) {
+	var value T
+	raw, ok := msg.Data.(json.RawMessage)
+	if !ok {
+		var err error
+		if raw, err = json.Marshal(msg.Data); err != nil {
+			return value, fmt.Errorf("данные сообщения %s: %w", msg.Type, err)
+		}
+	}
+	if bytes.Equal(raw, []byte("null")) {
+		return value, fmt.Errorf("сообщение %s без данных", msg.Type)
+	}
+	var err error
+	if strict {
+		err = DecodeStrict(raw, &value)
+	} else {
+		err = json.Unmarshal(raw, &value)
+	}
+	if err != nil {
+		return value, fmt.Errorf("данные сообщения %s: %w", msg.Type, err)
+	}
+	return value, nil
+}
+
+// UnknownFieldError - в JSON есть поле, которого нет в типе. Path - путь
+// от корня документа, например config.engines[0].thrust_kn
+type UnknownFieldError struct {
+	Path string
+}
+
+func (e *UnknownFieldError) Error() string {
+	return fmt.Sprintf("неизвестное поле %q", e.Path)
+}
+
+// DecodeStrict разбирает JSON в v и считает незнакомое поле на любой
+// глубине ошибкой *UnknownFieldError. Так опечатка в конфигурации или
+// скрипте (mass_fule) не превращается молча в нулевое значение.
+func DecodeStrict(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(v)
+	if err == nil {
+		return nil
+	}
+
+	// encoding/json называет только само поле, путь к нему ищется отдельно
+	name, ok := strings.CutPrefix(err.Error(), "json: unknown field ")
+	if !ok {
+		return err
+	}
+	if path := unknownFieldPath(data, reflect.TypeOf(v)); path != "" {
+		return &UnknownFieldError{Path: path}
+	}
+	if unquoted, err := strconv.Unquote(name); err == nil {
+		name = unquoted
+	}
+	return &UnknownFieldError{Path: name}
+}
+
+// unknownFieldPath обходит документ вместе с типом и возвращает путь к
+// первому незнакомому полю; ключи объектов перебираются по алфавиту
+func unknownFieldPath(data []byte, t reflect.Type) string {
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return ""
+	}
+	return findUnknownField(document, t, "")
+}
+
+func findUnknownField(value interface{}, t reflect.Type, path string) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		switch t.Kind() {
+		case reflect.Struct:
+			fields := jsonFields(t)
+			for _, key := range keys {
+				field, ok := fields[strings.ToLower(key)]
+				if !ok {
+					return joinFieldPath(path, key)
+				}
+				if found := findUnknownField(value[key], field, joinFieldPath(path, key)); found != "" {
+					return found
+				}
+			}
+		case reflect.Map:
+			for _, key := range keys {
+				if found := findUnknownField(value[key], t.Elem(), joinFieldPath(path, key)); found != "" {
+					return found
+				}
+			}
+		}
+
+	case []interface{}:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return ""
+		}
+		for i, item := range value {
+			if found := findUnknownField(item, t.Elem(), path+"["+strconv.Itoa(i)+"]"); found != "" {
+				return found
+			}
+		}
+	}
+	return ""
+}
+
+// jsonFields - типы полей структуры по имени в JSON в нижнем регистре
+// (encoding/json сопоставляет имена без учета регистра), с полями
+// встроенных структур
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for embeddedName, embeddedType := range jsonFields(embedded) {
+					if _, ok := fields[embeddedName]; !ok {
+						fields[embeddedName] = embeddedType
+					}
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[strings.ToLower(name)] = field.Type
+	}
+	return fields
+}
+
+func joinFieldPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
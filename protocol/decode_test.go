@@ -0,0 +1,136 @@
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// readMessage имитирует получение: Data после чтения - map[string]interface{}
+func readMessage(t *testing.T, raw string) Message {
+	t.Helper()
+	var msg Message
+	if err := json.Unmarshal([]byte(raw), &msg); err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func TestDecodeData(t *testing.T) {
+	msg := readMessage(t, `{"type":"warning","data":{"rocket_id":"r1","warning":"сближение","severity":"high","future":1}}`)
+	warning, err := DecodeData[WarningMessage](msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if warning.RocketID != "r1" || warning.Severity != "high" {
+		t.Errorf("декодировано %+v", warning)
+	}
+
+	if _, err := DecodeDataStrict[WarningMessage](msg); err == nil || !strings.Contains(err.Error(), `"future"`) {
+		t.Errorf("строгий режим: ошибка %v, ожидалось незнакомое поле future", err)
+	}
+}
+
+// Отправитель в том же процессе кладет в Data готовую структуру
+func TestDecodeDataTyped(t *testing.T) {
+	heartbeat := HeartbeatMessage{Nonce: 7}
+	for _, data := range []interface{}{heartbeat, &heartbeat} {
+		got, err := DecodeData[HeartbeatMessage](Message{Type: MsgTypeHeartbeat, Data: data})
+		if err != nil || got != heartbeat {
+			t.Errorf("Data %T: %+v, %v", data, got, err)
+		}
+	}
+}
+
+func TestDecodeDataErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  Message
+		want string
+	}{
+		{"без данных", Message{Type: MsgTypeTelemetry}, "сообщение telemetry без данных"},
+		{"nil-указатель", Message{Type: MsgTypeTelemetry, Data: (*TelemetryMessage)(nil)}, "без данных"},
+		{"не объект", readMessage(t, `{"type":"register","data":"ракета"}`), "данные сообщения register"},
+		{"чужой тип поля", readMessage(t, `{"type":"register","data":{"rocket_id":42}}`), "данные сообщения register"},
+		{"не кодируется", Message{Type: MsgTypeCommand, Data: make(chan int)}, "данные сообщения command"},
+	}
+
+	for _, tt := range tests {
+		if _, err := DecodeData[RegisterMessage](tt.msg); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: ошибка %v, ожидалось %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func FuzzDecodeData(f *testing.F) {
+	f.Add(`{"type":"register","data":{"rocket_id":"r1","config":{"engines":[{"thrust":1}]}}}`)
+	f.Add(`{"type":"telemetry","data":null}`)
+	f.Add(`{"type":"command","data":[1,2]}`)
+	f.Fuzz(func(t *testing.T, raw string) {
+		var msg Message
+		if json.Unmarshal([]byte(raw), &msg) != nil {
+			return
+		}
+		// Любые данные либо декодируются, либо дают ошибку, но не панику
+		DecodeData[RegisterMessage](msg)
+		DecodeDataStrict[CommandMessage](msg)
+		DecodeData[TelemetryMessage](msg)
+	})
+}
+
+// Незнакомое поле на любой глубине называется полным путем
+func TestDecodeStrictUnknownField(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"верхний уровень", `{"rocket_id":"r1","rocekt":"x"}`, "rocekt"},
+		{"конфигурация", `{"rocket_id":"r1","config":{"mass_fule":1000}}`, "config.mass_fule"},
+		{"двигатель", `{"config":{"engines":[{"thrust":1},{"thrust":1,"thrust_kn":2}]}}`, "config.engines[1].thrust_kn"},
+		{"двигатель ступени", `{"config":{"stages":[{"mass_empty":1,"engines":[{"isp":300}]}]}}`, "config.stages[0].engines[0].isp"},
+		{"парашют", `{"config":{"parachute":{"area":10,"drag":1}}}`, "config.parachute.drag"},
+	}
+
+	for _, tt := range tests {
+		var register RegisterMessage
+		err := DecodeStrict([]byte(tt.raw), &register)
+		var unknown *UnknownFieldError
+		if !errors.As(err, &unknown) || unknown.Path != tt.want {
+			t.Errorf("%s: ошибка %v, ожидалось поле %s", tt.name, err, tt.want)
+		}
+	}
+
+	// Регистр имени поля не важен, как и в encoding/json
+	var register RegisterMessage
+	if err := DecodeStrict([]byte(`{"Rocket_ID":"r1","config":{"Engines":[{"THRUST":1}]}}`), &register); err != nil {
+		t.Errorf("известные поля в другом регистре: %v", err)
+	}
+
+	msg := readMessage(t, `{"type":"register","data":{"rocket_id":"r1","config":{"engines":[{"thrust_kn":1}]}}}`)
+	_, err := DecodeDataStrict[RegisterMessage](msg)
+	var unknown *UnknownFieldError
+	if !errors.As(err, &unknown) || unknown.Path != "config.engines[0].thrust_kn" {
+		t.Errorf("DecodeDataStrict: ошибка %v", err)
+	}
+}
+
+// Старые клиенты присылают в engine_status исправность двигателей
+func TestEngineStatusLegacy(t *testing.T) {
+	var state RocketState
+	if err := json.Unmarshal([]byte(`{"engine_status":[true,false]}`), &state); err != nil {
+		t.Fatal(err)
+	}
+	if len(state.EngineStatus) != 2 || state.EngineStatus[0].Failed || !state.EngineStatus[1].Failed {
+		t.Errorf("состояние %+v", state.EngineStatus)
+	}
+
+	if err := json.Unmarshal([]byte(`{"engine_status":[{"id":"center","throttle":0.5,"active":true,"burn_time":12}]}`), &state); err != nil {
+		t.Fatal(err)
+	}
+	want := EngineStatus{ID: "center", Throttle: 0.5, Active: true, BurnTime: 12}
+	if len(state.EngineStatus) != 1 || state.EngineStatus[0] != want {
+		t.Errorf("состояние %+v, ожидалось %+v", state.EngineStatus, want)
+	}
+}
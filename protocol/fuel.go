@@ -0,0 +1,76 @@
+package protocol
+
+import (
+	"fmt"
+	"math"
+)
+
+// StandardGravity - g0 в определении удельного импульса (м/с2)
+const StandardGravity = 9.80665
+
+// Во сколько раз удельный импульс двигателя (тяга на расход) может
+// отличаться от типового для топлива, прежде чем EngineWarnings сочтет
+// двигатель ошибкой в конфигурации
+const ispTolerance = 2.0
+
+// Isp - типовой удельный импульс топлива в вакууме (с); 0 для неизвестного
+// или не заданного типа
+func (f FuelType) Isp() float64 {
+	switch f {
+	case FuelTypeKerosene:
+		return 300
+	case FuelTypeLiquidH2:
+		return 450
+	case FuelTypeSolid:
+		return 250
+	}
+	return 0
+}
+
+// ExhaustVelocity - эффективная скорость истечения Isp * g0 (м/с)
+func (f FuelType) ExhaustVelocity() float64 {
+	return f.Isp() * StandardGravity
+}
+
+// EngineFromIsp - исправный двигатель тягой thrust (Н) с расходом по
+// типовому удельному импульсу топлива fuelType. Для неизвестного топлива
+// расход 0: двигатель не тратит топливо.
+func EngineFromIsp(thrust float64, fuelType FuelType) Engine {
+	engine := Engine{Thrust: thrust, IsActive: true}
+	if ve := fuelType.ExhaustVelocity(); ve > 0 {
+		engine.FuelConsumption = thrust / ve
+	}
+	return engine
+}
+
+// EngineWarnings - предупреждения о двигателях ракеты и ее ступеней, чей
+// удельный импульс отличается от типового для config.FuelType больше чем в
+// ispTolerance раз. Такая конфигурация допустима, но скорее всего тяга или
+// расход указаны в других единицах. Двигатели без расхода не проверяются.
+func EngineWarnings(config *RocketConfig) []string {
+	nominal := config.FuelType.Isp()
+	if nominal <= 0 {
+		return nil
+	}
+
+	var warnings []string
+	check := func(where string, engines []Engine) {
+		for i, engine := range engines {
+			if engine.Thrust <= 0 || engine.FuelConsumption <= 0 {
+				continue
+			}
+			isp := engine.Thrust / (engine.FuelConsumption * StandardGravity)
+			if ratio := isp / nominal; math.Abs(math.Log(ratio)) > math.Log(ispTolerance) {
+				warnings = append(warnings, fmt.Sprintf("%s[%d]: удельный импульс %.0f с, типовой для %s %.0f с",
+					where, i, isp, config.FuelType, nominal))
+			}
+		}
+	}
+	if len(config.Stages) == 0 {
+		check("engines", config.Engines)
+	}
+	for i, stage := range config.Stages {
+		check(fmt.Sprintf("stages[%d].engines", i), stage.Engines)
+	}
+	return warnings
+}
@@ -0,0 +1,64 @@
+package protocol
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestFuelTypeIsp(t *testing.T) {
+	if !(FuelTypeLiquidH2.Isp() > FuelTypeKerosene.Isp() && FuelTypeKerosene.Isp() > FuelTypeSolid.Isp()) {
+		t.Errorf("удельный импульс H2 %.0f, керосина %.0f, твердого %.0f: ожидалось H2 > керосин > твердое",
+			FuelTypeLiquidH2.Isp(), FuelTypeKerosene.Isp(), FuelTypeSolid.Isp())
+	}
+	if isp := FuelType("").Isp(); isp != 0 {
+		t.Errorf("Isp без типа топлива = %v, ожидался 0", isp)
+	}
+}
+
+func TestEngineFromIsp(t *testing.T) {
+	for _, fuel := range []FuelType{FuelTypeKerosene, FuelTypeLiquidH2, FuelTypeSolid} {
+		engine := EngineFromIsp(1e6, fuel)
+		if !engine.IsActive || engine.Thrust != 1e6 {
+			t.Errorf("%s: двигатель %+v", fuel, engine)
+		}
+		if ve := engine.Thrust / engine.FuelConsumption; math.Abs(ve-fuel.ExhaustVelocity()) > 1e-9*ve {
+			t.Errorf("%s: скорость истечения %.3f м/с, ожидалась %.3f", fuel, ve, fuel.ExhaustVelocity())
+		}
+	}
+	if engine := EngineFromIsp(1e6, "plasma"); engine.FuelConsumption != 0 {
+		t.Errorf("неизвестное топливо: расход %v, ожидался 0", engine.FuelConsumption)
+	}
+}
+
+func TestEngineWarnings(t *testing.T) {
+	kerosene := EngineFromIsp(1e6, FuelTypeKerosene)
+	config := RocketConfig{
+		FuelType: FuelTypeKerosene,
+		Engines: []Engine{
+			kerosene,
+			{Thrust: 1e6, FuelConsumption: 1e6 / (500 * StandardGravity), IsActive: true}, // в пределах 2 раз
+			{Thrust: 1e6, FuelConsumption: 3, IsActive: true},                             // Isp 34000 с
+			{Thrust: 1e6, IsActive: true},                                                 // расход не задан
+		},
+	}
+	warnings := EngineWarnings(&config)
+	if len(warnings) != 1 || !strings.HasPrefix(warnings[0], "engines[2]") {
+		t.Errorf("предупреждения %q, ожидалось одно про engines[2]", warnings)
+	}
+
+	config.Stages = []Stage{
+		{Engines: []Engine{kerosene}},
+		{Engines: []Engine{{Thrust: 1e5, FuelConsumption: 1e3, IsActive: true}}}, // Isp 10 с
+	}
+	config.ApplyStages()
+	warnings = EngineWarnings(&config)
+	if len(warnings) != 1 || !strings.HasPrefix(warnings[0], "stages[1].engines[0]") {
+		t.Errorf("предупреждения %q, ожидалось одно про stages[1].engines[0]", warnings)
+	}
+
+	config.FuelType = ""
+	if warnings := EngineWarnings(&config); warnings != nil {
+		t.Errorf("без типа топлива предупреждения %q", warnings)
+	}
+}
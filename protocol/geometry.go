@@ -0,0 +1,17 @@
+package protocol
+
+import "math"
+
+// ClosestApproach - момент tca (0..maxT, с) и расстояние minDist (м)
+// наибольшего сближения двух тел, летящих прямолинейно и равномерно из
+// позиций p1, p2 со скоростями v1, v2. Если тела расходятся или летят с
+// одинаковой скоростью, сближение - сейчас: tca = 0.
+func ClosestApproach(p1, v1, p2, v2 Vector3, maxT float64) (tca float64, minDist float64) {
+	dp := p2.Sub(p1)
+	dv := v2.Sub(v1)
+
+	if speed2 := dv.Dot(dv); speed2 > 0 && maxT > 0 {
+		tca = math.Max(0, math.Min(maxT, -dp.Dot(dv)/speed2))
+	}
+	return tca, dp.Add(dv.Scale(tca)).Norm()
+}
@@ -0,0 +1,74 @@
+package protocol
+
+import (
+	"math"
+	"testing"
+)
+
+func TestClosestApproach(t *testing.T) {
+	tests := []struct {
+		name     string
+		p1, v1   Vector3
+		p2, v2   Vector3
+		maxT     float64
+		wantTCA  float64
+		wantDist float64
+	}{
+		{
+			name: "встречные курсы со смещением",
+			p1:   Vector3{X: -1000}, v1: Vector3{X: 100},
+			p2: Vector3{X: 1000, Y: 30}, v2: Vector3{X: -100},
+			maxT: 60, wantTCA: 10, wantDist: 30,
+		},
+		{
+			name: "пересечение под прямым углом",
+			p1:   Vector3{X: -300}, v1: Vector3{X: 30},
+			p2: Vector3{Y: -400}, v2: Vector3{Y: 40},
+			maxT: 60, wantTCA: 10, wantDist: 0,
+		},
+		{
+			name: "сближение позже горизонта",
+			p1:   Vector3{X: -1000}, v1: Vector3{X: 100},
+			p2: Vector3{X: 1000, Y: 30}, v2: Vector3{X: -100},
+			maxT: 4, wantTCA: 4, wantDist: math.Hypot(1200, 30),
+		},
+		{
+			name: "расходятся",
+			p1:   Vector3{X: -10}, v1: Vector3{X: -5},
+			p2: Vector3{X: 10}, v2: Vector3{X: 5},
+			maxT: 60, wantTCA: 0, wantDist: 20,
+		},
+		{
+			name: "параллельно с одной скоростью",
+			p1:   Vector3{Z: 7}, v1: Vector3{X: 7800, Y: 10},
+			p2: Vector3{Y: 50, Z: 7}, v2: Vector3{X: 7800, Y: 10},
+			maxT: 60, wantTCA: 0, wantDist: 50,
+		},
+		{
+			name: "без горизонта",
+			p1:   Vector3{X: -1000}, v1: Vector3{X: 100},
+			p2: Vector3{X: 1000}, v2: Vector3{X: -100},
+			maxT: 0, wantTCA: 0, wantDist: 2000,
+		},
+		{
+			name: "в одной точке",
+			p1:   Vector3{X: 1, Y: 2, Z: 3}, v1: Vector3{X: 1},
+			p2: Vector3{X: 1, Y: 2, Z: 3}, v2: Vector3{Y: 1},
+			maxT: 60, wantTCA: 0, wantDist: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tca, dist := ClosestApproach(tt.p1, tt.v1, tt.p2, tt.v2, tt.maxT)
+			if math.Abs(tca-tt.wantTCA) > 1e-9 || math.Abs(dist-tt.wantDist) > 1e-9 {
+				t.Errorf("сближение через %g с на %g м, ожидалось через %g с на %g м", tca, dist, tt.wantTCA, tt.wantDist)
+			}
+			// Перестановка тел ничего не меняет
+			tca2, dist2 := ClosestApproach(tt.p2, tt.v2, tt.p1, tt.v1, tt.maxT)
+			if tca2 != tca || math.Abs(dist2-dist) > 1e-9 {
+				t.Errorf("с переставленными телами через %g с на %g м", tca2, dist2)
+			}
+		})
+	}
+}
@@ -0,0 +1,3 @@
+module cosmodrom/protocol
+
+go 1.25.5
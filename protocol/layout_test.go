@@ -0,0 +1,58 @@
+package protocol
+
+import (
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Протокол - один модуль на сервер и клиент. Тест падает, если где-то в
+// репозитории снова появилась копия пакета или импорт старых путей
+// cosmodrom/server/protocol и cosmodrom/client/protocol.
+func TestSingleProtocolPackage(t *testing.T) {
+	root, err := filepath.Abs("..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	self, err := filepath.Abs(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fset := token.NewFileSet()
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if entry.Name() == ".git" || path == self {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(fset, path, nil, parser.ImportsOnly)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		if file.Name.Name == "protocol" {
+			t.Errorf("%s: копия пакета protocol, используйте cosmodrom/protocol", rel)
+		}
+		for _, spec := range file.Imports {
+			if imported := strings.Trim(spec.Path.Value, `"`); strings.HasSuffix(imported, "/protocol") && imported != "cosmodrom/protocol" {
+				t.Errorf("%s: импорт %s вместо cosmodrom/protocol", rel, imported)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
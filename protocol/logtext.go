@@ -0,0 +1,184 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// LogLang - язык текста журнала
+type LogLang string
+
+const (
+	LogLangRU LogLang = "ru"
+	LogLangEN LogLang = "en"
+)
+
+func ParseLogLang(s string) (LogLang, error) {
+	switch lang := LogLang(s); lang {
+	case LogLangRU, LogLangEN:
+		return lang, nil
+	}
+	return "", fmt.Errorf("неизвестный язык журнала %q (ожидается ru или en)", s)
+}
+
+// LogFields - именованные значения записи журнала. Текст записи
+// собирается из них по шаблону кода, поэтому потребители журнала могут
+// показать запись на своем языке.
+type LogFields map[string]interface{}
+
+// LogTemplate - шаблоны записи на каждом языке. {name} подставляет поле
+// name, {name:%.1f} - поле в формате fmt, {name:} только требует поле.
+// Часть в [[...]] выводится, только если есть все поля, которые в ней
+// упомянуты: так записываются необязательные подробности.
+type LogTemplate struct {
+	RU string
+	EN string
+}
+
+func (t LogTemplate) Text(lang LogLang) string {
+	if lang == LogLangEN {
+		return t.EN
+	}
+	return t.RU
+}
+
+// LogCatalog - шаблоны записей журнала по коду
+type LogCatalog map[string]LogTemplate
+
+// Format собирает текст записи. Запись с неизвестным кодом выводится как
+// код и поля "key=value" по алфавиту, чтобы не потерять данные.
+func (c LogCatalog) Format(lang LogLang, code string, fields LogFields) string {
+	template, ok := c[code]
+	if !ok {
+		return dumpLogFields(code, fields)
+	}
+
+	var b strings.Builder
+	text := template.Text(lang)
+	for {
+		start := strings.Index(text, "[[")
+		end := strings.Index(text[max(start, 0):], "]]")
+		if start < 0 || end < 0 {
+			writeLogText(&b, text, fields)
+			return b.String()
+		}
+		writeLogText(&b, text[:start], fields)
+		if optional := text[start+2 : start+end]; hasLogFields(optional, fields) {
+			writeLogText(&b, optional, fields)
+		}
+		text = text[start+end+2:]
+	}
+}
+
+// Validate проверяет, что у каждого кода есть оба перевода и что они
+// упоминают одни и те же поля
+func (c LogCatalog) Validate() error {
+	codes := make([]string, 0, len(c))
+	for code := range c {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	var problems []string
+	for _, code := range codes {
+		template := c[code]
+		switch {
+		case template.RU == "" || template.EN == "":
+			problems = append(problems, code+": нет перевода")
+		case !slices.Equal(template.Placeholders(LogLangRU), template.Placeholders(LogLangEN)):
+			problems = append(problems, fmt.Sprintf("%s: поля ru %v, en %v", code,
+				template.Placeholders(LogLangRU), template.Placeholders(LogLangEN)))
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func writeLogText(b *strings.Builder, text string, fields LogFields) {
+	scanLogTemplate(text, func(literal, name, format string, hasFormat bool) {
+		b.WriteString(literal)
+		if name == "" {
+			return
+		}
+		value, ok := fields[name]
+		switch {
+		case !ok:
+			b.WriteString("{" + name + "}")
+		case format != "":
+			fmt.Fprintf(b, format, value)
+		case !hasFormat:
+			fmt.Fprint(b, value)
+		}
+	})
+}
+
+func hasLogFields(text string, fields LogFields) bool {
+	present := true
+	scanLogTemplate(text, func(_, name, _ string, _ bool) {
+		if _, ok := fields[name]; name != "" && !ok {
+			present = false
+		}
+	})
+	return present
+}
+
+// Placeholders - имена полей, которые упоминает шаблон, по алфавиту
+func (t LogTemplate) Placeholders(lang LogLang) []string {
+	var names []string
+	scanLogTemplate(t.Text(lang), func(_, name, _ string, _ bool) {
+		if name != "" {
+			names = append(names, name)
+		}
+	})
+	sort.Strings(names)
+	return names
+}
+
+// scanLogTemplate вызывает fn для текста перед каждой подстановкой и самой
+// подстановки; последний вызов - с остатком текста и пустым name
+func scanLogTemplate(text string, fn func(literal, name, format string, hasFormat bool)) {
+	for {
+		start := strings.IndexByte(text, '{')
+		end := strings.IndexByte(text[max(start, 0):], '}')
+		if start < 0 || end < 0 {
+			fn(text, "", "", false)
+			return
+		}
+		name, format, hasFormat := strings.Cut(text[start+1:start+end], ":")
+		fn(text[:start], name, format, hasFormat)
+		text = text[start+end+1:]
+	}
+}
+
+func dumpLogFields(code string, fields LogFields) string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(code)
+	for _, key := range keys {
+		fmt.Fprintf(&b, " %s=%v", key, fields[key])
+	}
+	return b.String()
+}
+
+// LogValue приводит значение поля к виду, который одинаково читается в
+// тексте и в JSON: ошибки и fmt.Stringer (time.Duration, net.Addr) -
+// строкой, остальное как есть
+func LogValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+	return value
+}
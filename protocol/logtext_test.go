@@ -0,0 +1,87 @@
+package protocol
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLogCatalogFormat(t *testing.T) {
+	catalog := LogCatalog{
+		"approach": {RU: "Сближение с {other}: {distance:%.1f} м", EN: "Approach with {other}: {distance:%.1f} m"},
+		"started":  {RU: "Сервер запущен", EN: "Server started"},
+		"joined": {RU: "Ракета {name} зарегистрирована[[, цель {orbit_km:%.0f} км]][[, пилотируемая{crewed:}]]",
+			EN: "Rocket {name} registered[[, target {orbit_km:%.0f} km]][[, crewed{crewed:}]]"},
+	}
+	fields := LogFields{"other": "r2", "distance": 812.345}
+
+	tests := []struct {
+		name   string
+		lang   LogLang
+		code   string
+		fields LogFields
+		want   string
+	}{
+		{name: "ru", lang: LogLangRU, code: "approach", fields: fields, want: "Сближение с r2: 812.3 м"},
+		{name: "en", lang: LogLangEN, code: "approach", fields: fields, want: "Approach with r2: 812.3 m"},
+		{name: "без полей", lang: LogLangEN, code: "started", want: "Server started"},
+		{name: "нет поля", lang: LogLangRU, code: "approach", fields: LogFields{"other": "r2"}, want: "Сближение с r2: {distance} м"},
+		{name: "необязательные части", lang: LogLangRU, code: "joined", fields: LogFields{"name": "Союз", "crewed": true},
+			want: "Ракета Союз зарегистрирована, пилотируемая"},
+		{name: "все части", lang: LogLangEN, code: "joined", fields: LogFields{"name": "Soyuz", "orbit_km": 400.0, "crewed": true},
+			want: "Rocket Soyuz registered, target 400 km, crewed"},
+		{name: "неизвестный код", lang: LogLangEN, code: "mystery", fields: LogFields{"b": 2, "a": "x"}, want: "mystery a=x b=2"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := catalog.Format(tc.lang, tc.code, tc.fields); got != tc.want {
+				t.Errorf("%q, ожидалось %q", got, tc.want)
+			}
+		})
+	}
+
+	if got := catalog["approach"].Placeholders(LogLangEN); !reflect.DeepEqual(got, []string{"distance", "other"}) {
+		t.Errorf("подстановки %v", got)
+	}
+}
+
+func TestLogCatalogValidate(t *testing.T) {
+	catalog := LogCatalog{
+		"ok":      {RU: "Высота {altitude:%.1f} км", EN: "Altitude {altitude} km"},
+		"missing": {RU: "Только по-русски"},
+		"fields":  {RU: "Ракета {rocket_id}", EN: "Rocket {id}"},
+	}
+	err := catalog.Validate()
+	if err == nil || err.Error() != "fields: поля ru [rocket_id], en [id]; missing: нет перевода" {
+		t.Errorf("ошибка %v", err)
+	}
+	delete(catalog, "missing")
+	delete(catalog, "fields")
+	if err := catalog.Validate(); err != nil {
+		t.Errorf("исправный каталог: %v", err)
+	}
+}
+
+func TestParseLogLang(t *testing.T) {
+	for _, s := range []string{"ru", "en"} {
+		if lang, err := ParseLogLang(s); err != nil || string(lang) != s {
+			t.Errorf("%s: %v, %v", s, lang, err)
+		}
+	}
+	if _, err := ParseLogLang("de"); err == nil {
+		t.Error("неизвестный язык принят")
+	}
+}
+
+func TestLogValue(t *testing.T) {
+	if got := LogValue(errors.New("обрыв")); got != "обрыв" {
+		t.Errorf("ошибка: %#v", got)
+	}
+	if got := LogValue(1500 * time.Millisecond); got != "1.5s" {
+		t.Errorf("длительность: %#v", got)
+	}
+	if got := LogValue(42.5); got != 42.5 {
+		t.Errorf("число: %#v", got)
+	}
+}
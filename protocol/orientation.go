@@ -0,0 +1,88 @@
+package protocol
+
+import "math"
+
+// Quaternion - единичный кватернион поворота W + Xi + Yj + Zk
+type Quaternion struct {
+	W float64 `json:"w"`
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+}
+
+// Orientation - ориентация ракеты. Quaternion переводит связанные оси
+// ракеты (x - продольная ось к носу) в оси планеты, в которых задана
+// Position; при нулевых углах нос смотрит в местный зенит, ось y - на
+// восток, ось z - на север. Углы - те же, что в ControlCommand.
+type Orientation struct {
+	Quaternion Quaternion `json:"quaternion"`
+	Pitch      float64    `json:"pitch"` // Тангаж от местной вертикали, градусы
+	Yaw        float64    `json:"yaw"`   // Рыскание от востока к югу, градусы
+	Roll       float64    `json:"roll"`  // Крен вокруг продольной оси, градусы
+}
+
+// IdentityQuaternion - поворот на нулевой угол
+var IdentityQuaternion = Quaternion{W: 1}
+
+// QuaternionFromAxisAngle - поворот на angle радиан вокруг оси axis по
+// правилу правой руки
+func QuaternionFromAxisAngle(axis Vector3, angle float64) Quaternion {
+	axis = axis.Normalize()
+	s := math.Sin(angle / 2)
+	return Quaternion{W: math.Cos(angle / 2), X: axis.X * s, Y: axis.Y * s, Z: axis.Z * s}
+}
+
+// QuaternionFromBasis - поворот, переводящий оси x, y, z в правую
+// ортонормированную тройку векторов x, y, z
+func QuaternionFromBasis(x, y, z Vector3) Quaternion {
+	// Матрица поворота со столбцами x, y, z; ветка выбирается по
+	// наибольшей компоненте, чтобы не делить на малое число
+	var q Quaternion
+	trace := x.X + y.Y + z.Z
+	switch {
+	case trace > 0:
+		s := 2 * math.Sqrt(1+trace)
+		q = Quaternion{W: s / 4, X: (y.Z - z.Y) / s, Y: (z.X - x.Z) / s, Z: (x.Y - y.X) / s}
+	case x.X > y.Y && x.X > z.Z:
+		s := 2 * math.Sqrt(1+x.X-y.Y-z.Z)
+		q = Quaternion{W: (y.Z - z.Y) / s, X: s / 4, Y: (y.X + x.Y) / s, Z: (z.X + x.Z) / s}
+	case y.Y > z.Z:
+		s := 2 * math.Sqrt(1+y.Y-x.X-z.Z)
+		q = Quaternion{W: (z.X - x.Z) / s, X: (y.X + x.Y) / s, Y: s / 4, Z: (z.Y + y.Z) / s}
+	default:
+		s := 2 * math.Sqrt(1+z.Z-x.X-y.Y)
+		q = Quaternion{W: (x.Y - y.X) / s, X: (z.X + x.Z) / s, Y: (z.Y + y.Z) / s, Z: s / 4}
+	}
+	return q.Normalize()
+}
+
+// Mul - композиция поворотов: сначала r, затем q
+func (q Quaternion) Mul(r Quaternion) Quaternion {
+	return Quaternion{
+		W: q.W*r.W - q.X*r.X - q.Y*r.Y - q.Z*r.Z,
+		X: q.W*r.X + q.X*r.W + q.Y*r.Z - q.Z*r.Y,
+		Y: q.W*r.Y - q.X*r.Z + q.Y*r.W + q.Z*r.X,
+		Z: q.W*r.Z + q.X*r.Y - q.Y*r.X + q.Z*r.W,
+	}
+}
+
+// Conjugate - обратный поворот для единичного кватерниона
+func (q Quaternion) Conjugate() Quaternion {
+	return Quaternion{W: q.W, X: -q.X, Y: -q.Y, Z: -q.Z}
+}
+
+// Normalize - кватернион единичной длины; нулевой становится IdentityQuaternion
+func (q Quaternion) Normalize() Quaternion {
+	n := math.Sqrt(q.W*q.W + q.X*q.X + q.Y*q.Y + q.Z*q.Z)
+	if n == 0 {
+		return IdentityQuaternion
+	}
+	return Quaternion{W: q.W / n, X: q.X / n, Y: q.Y / n, Z: q.Z / n}
+}
+
+// Rotate поворачивает вектор v
+func (q Quaternion) Rotate(v Vector3) Vector3 {
+	u := Vector3{X: q.X, Y: q.Y, Z: q.Z}
+	t := u.Cross(v).Scale(2)
+	return v.Add(t.Scale(q.W)).Add(u.Cross(t))
+}
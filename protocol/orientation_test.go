@@ -0,0 +1,53 @@
+package protocol
+
+import (
+	"math"
+	"testing"
+)
+
+func TestQuaternionRotate(t *testing.T) {
+	tests := []struct {
+		name string
+		q    Quaternion
+		v    Vector3
+		want Vector3
+	}{
+		{"без поворота", IdentityQuaternion, Vector3{X: 1, Y: 2, Z: 3}, Vector3{X: 1, Y: 2, Z: 3}},
+		{"90° вокруг z", QuaternionFromAxisAngle(Vector3{Z: 1}, math.Pi/2), Vector3{X: 1}, Vector3{Y: 1}},
+		{"90° вокруг x", QuaternionFromAxisAngle(Vector3{X: 2}, math.Pi/2), Vector3{Y: 1}, Vector3{Z: 1}},
+		{"180° вокруг y", QuaternionFromAxisAngle(Vector3{Y: 1}, math.Pi), Vector3{X: 1, Z: 1}, Vector3{X: -1, Z: -1}},
+		{
+			"композиция: сначала x, затем z",
+			QuaternionFromAxisAngle(Vector3{Z: 1}, math.Pi/2).Mul(QuaternionFromAxisAngle(Vector3{X: 1}, math.Pi/2)),
+			Vector3{Y: 1}, Vector3{Z: 1},
+		},
+		{
+			"обратный поворот",
+			QuaternionFromAxisAngle(Vector3{X: 1, Y: 1}, 1).Conjugate().Mul(QuaternionFromAxisAngle(Vector3{X: 1, Y: 1}, 1)),
+			Vector3{X: 3, Y: -1, Z: 2}, Vector3{X: 3, Y: -1, Z: 2},
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.q.Rotate(tt.v); got.Distance(tt.want) > 1e-12 {
+			t.Errorf("%s: %+v, ожидалось %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestQuaternionFromBasis(t *testing.T) {
+	// Повороты на разные углы попадают в разные ветки формулы
+	for _, angle := range []float64{0, 0.3, 2, math.Pi - 1e-3, math.Pi} {
+		for _, axis := range []Vector3{{X: 1}, {Y: 1}, {Z: 1}, {X: 1, Y: -2, Z: 0.5}} {
+			q := QuaternionFromAxisAngle(axis, angle)
+			got := QuaternionFromBasis(q.Rotate(Vector3{X: 1}), q.Rotate(Vector3{Y: 1}), q.Rotate(Vector3{Z: 1}))
+			for _, v := range []Vector3{{X: 1}, {Y: 1}, {Z: 1}} {
+				if got.Rotate(v).Distance(q.Rotate(v)) > 1e-9 {
+					t.Errorf("ось %+v, угол %.3f: %+v переходит в %+v, ожидалось %+v", axis, angle, v, got.Rotate(v), q.Rotate(v))
+				}
+			}
+		}
+	}
+	if q := (Quaternion{}).Normalize(); q != IdentityQuaternion {
+		t.Errorf("нулевой кватернион нормализован в %+v", q)
+	}
+}
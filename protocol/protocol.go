@@ -0,0 +1,925 @@
+package protocol
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type MessageType string
+
+const (
+	MsgTypeRegister       MessageType = "register"        // Регистрация ракеты
+	MsgTypeTelemetry      MessageType = "telemetry"       // Телеметрия состояния ракеты
+	MsgTypeTelemetryBatch MessageType = "telemetry_batch" // Несколько кадров телеметрии, накопленных без связи
+	MsgTypeDisconnect     MessageType = "disconnect"      // Отключение ракеты
+	MsgTypeAbort          MessageType = "abort"           // Аварийное прекращение полета
+	MsgTypeHeartbeat      MessageType = "heartbeat"       // Проверка связи, сервер возвращает сообщение обратно
+	MsgTypeConfigRequest  MessageType = "config_request"  // Запрос конфигурации ракеты из каталога сервера
+
+	MsgTypeAccepted       MessageType = "accepted"        // Регистрация принята
+	MsgTypeRejected       MessageType = "rejected"        // Регистрация отклонена
+	MsgTypeCommand        MessageType = "command"         // Команда управления
+	MsgTypeWarning        MessageType = "warning"         // Предупреждение
+	MsgTypeShutdown       MessageType = "shutdown"        // Команда на выключение
+	MsgTypeTrajectory     MessageType = "trajectory"      // Рекомендуемая траектория
+	MsgTypeRocketList     MessageType = "rocket_list"     // Список активных ракет
+	MsgTypeConfigResponse MessageType = "config_response" // Конфигурация ракеты из каталога
+	MsgTypeError          MessageType = "error"           // Сообщение клиента отброшено сервером
+
+	MsgTypeSubscribe    MessageType = "subscribe"     // Подписка на события (от визуализатора)
+	MsgTypeUnsubscribe  MessageType = "unsubscribe"   // Отписка от событий
+	MsgTypeBroadcast    MessageType = "broadcast"     // Рассылка телеметрии наблюдателям
+	MsgTypeRocketJoined MessageType = "rocket_joined" // Новая ракета подключилась
+	MsgTypeRocketLeft   MessageType = "rocket_left"   // Ракета отключилась
+	MsgTypeMissionEvent MessageType = "mission_event" // Событие миссии: цель полета достигнута
+)
+
+type FuelType string
+
+const (
+	FuelTypeKerosene FuelType = "kerosene"
+	FuelTypeLiquidH2 FuelType = "liquid_h2"
+	FuelTypeSolid    FuelType = "solid"
+)
+
+type Vector3 struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+}
+
+type Engine struct {
+	ID              string  `json:"id,omitempty"`     // Имя двигателя для журнала и команд, уникально в конфигурации
+	Thrust          float64 `json:"thrust"`           // Тяга в Ньютонах
+	FuelConsumption float64 `json:"fuel_consumption"` // Расход топлива кг/с
+	IsActive        bool    `json:"is_active"`        // Активен ли двигатель
+	Gimbal          bool    `json:"gimbal,omitempty"` // Двигатель на карданном подвесе (справочно, физика тягу не отклоняет)
+}
+
+// EngineName - ID двигателя или его номер, если ID не задан
+func EngineName(engines []Engine, index int) string {
+	if index < len(engines) && engines[index].ID != "" {
+		return engines[index].ID
+	}
+	return "#" + strconv.Itoa(index)
+}
+
+type RocketConfig struct {
+	Name            string   `json:"name"`             // Название ракеты
+	MassEmpty       float64  `json:"mass_empty"`       // Масса пустой ракеты в кг
+	MassFuel        float64  `json:"mass_fuel"`        // Текущая масса топлива в кг
+	MassFuelMax     float64  `json:"mass_fuel_max"`    // Максимальная масса топлива в кг
+	FuelType        FuelType `json:"fuel_type"`        // Тип топлива
+	Engines         []Engine `json:"engines"`          // Массив двигателей
+	DragCoefficient float64  `json:"drag_coefficient"` // Коэффициент сопротивления
+	CrossSection    float64  `json:"cross_section"`    // Площадь поперечного сечения м2
+
+	Labels map[string]string `json:"labels,omitempty"` // Метки для группировки (команда, класс ракеты)
+
+	Parachute *Parachute `json:"parachute,omitempty"` // Парашют для посадки без двигателей
+	Payload   *Payload   `json:"payload,omitempty"`   // Полезная нагрузка, отделяемая командой DeployPayload
+
+	// Ступени снизу вверх. Если заданы, плоские поля описывают ракету на
+	// старте (см. ApplyStages) - так конфигурацию понимают и старые клиенты.
+	Stages []Stage `json:"stages,omitempty"`
+}
+
+// Stage - ступень ракеты. Отделяется, когда израсходовано ее топливо.
+type Stage struct {
+	Name      string   `json:"name,omitempty"`
+	MassEmpty float64  `json:"mass_empty"` // Сухая масса ступени в кг
+	MassFuel  float64  `json:"mass_fuel"`  // Топливо ступени в кг
+	Engines   []Engine `json:"engines"`    // Двигатели ступени
+}
+
+// Parachute - парашют. Раскрывается командой DeployParachute; если воздушная
+// скорость больше ParachuteMaxSpeed, парашют рвется (ParachuteFailed).
+type Parachute struct {
+	DeployAltitude  float64 `json:"deploy_altitude"`  // Высота раскрытия автопилотом на спуске в м
+	DragCoefficient float64 `json:"drag_coefficient"` // Коэффициент сопротивления купола
+	Area            float64 `json:"area"`             // Площадь купола м2
+}
+
+// Payload - полезная нагрузка. Ее масса входит в mass_empty (у ракеты со
+// ступенями - в сухую массу последней ступени) и сбрасывается при отделении.
+type Payload struct {
+	Name string  `json:"name,omitempty"`
+	Mass float64 `json:"mass"` // кг
+}
+
+// ParachuteMaxSpeed - наибольшая воздушная скорость раскрытия парашюта (м/с)
+const ParachuteMaxSpeed = 250.0
+
+// ApplyStages заполняет плоские поля по ступеням: сухая масса и топливо -
+// суммы по всем ступеням, двигатели - первой ступени
+func (c *RocketConfig) ApplyStages() {
+	if len(c.Stages) == 0 {
+		return
+	}
+
+	c.MassEmpty, c.MassFuel = 0, 0
+	for _, stage := range c.Stages {
+		c.MassEmpty += stage.MassEmpty
+		c.MassFuel += stage.MassFuel
+	}
+	c.MassFuelMax = c.MassFuel
+	c.Engines = append([]Engine(nil), c.Stages[0].Engines...)
+}
+
+type RocketState struct {
+	Position      Vector3 `json:"position"`       // Позиция в метрах
+	Velocity      Vector3 `json:"velocity"`       // Скорость в м/с
+	Acceleration  Vector3 `json:"acceleration"`   // Ускорение в м/с2
+	Altitude      float64 `json:"altitude"`       // Высота над поверхностью Земли в м
+	Speed         float64 `json:"speed"`          // Скорость (модуль вектора) в м/с
+	MassCurrent   float64 `json:"mass_current"`   // Текущая масса в кг
+	FuelRemaining float64 `json:"fuel_remaining"` // Оставшееся топливо в кг
+	InOrbit       bool    `json:"in_orbit"`       // Находится ли на орбите
+	Landed        bool    `json:"landed"`         // Приземлилась ли
+	Crashed       bool    `json:"crashed"`        // Разбилась ли
+	Time          float64 `json:"time"`           // Время симуляции в секундах
+
+	OrbitApoapsis         float64 `json:"orbit_apoapsis"`          // Апоцентр (м), -1 если не определен
+	OrbitPeriapsis        float64 `json:"orbit_periapsis"`         // Перицентр (м)
+	OrbitEccentricity     float64 `json:"orbit_eccentricity"`      // Эксцентриситет
+	OrbitRequiredVelocity float64 `json:"orbit_required_velocity"` // Необходимая скорость для круговой орбиты
+	OrbitIsStable         bool    `json:"orbit_is_stable"`         // Стабильна ли орбита
+	OrbitInclination      float64 `json:"orbit_inclination"`       // Наклонение (градусы)
+	OrbitRAAN             float64 `json:"orbit_raan"`              // Долгота восходящего узла (градусы), 0 у экваториальной
+	OrbitArgPeriapsis     float64 `json:"orbit_arg_periapsis"`     // Аргумент перицентра (градусы), 0 у круговой
+
+	// Параметры полета; старые клиенты их не шлют
+	GForce          float64 `json:"g_force,omitempty"`          // Перегрузка в g: ускорение без гравитации
+	DynamicPressure float64 `json:"dynamic_pressure,omitempty"` // Скоростной напор в Па
+	VerticalSpeed   float64 `json:"vertical_speed,omitempty"`   // Проекция скорости на местную вертикаль в м/с, вверх положительная
+
+	// Точка под ракетой с учетом вращения планеты; старые клиенты их не шлют
+	Latitude    float64 `json:"latitude,omitempty"`     // Градусы, -90..90
+	Longitude   float64 `json:"longitude,omitempty"`    // Градусы, -180..180
+	GroundSpeed float64 `json:"ground_speed,omitempty"` // Горизонтальная скорость относительно поверхности, м/с
+
+	Stage        int             `json:"stage,omitempty"`         // Номер текущей ступени с 1, 0 у одноступенчатой ракеты
+	EngineStatus []EngineStatus  `json:"engine_status,omitempty"` // Двигатели текущей ступени по порядку
+	Guidance     *GuidanceStatus `json:"guidance,omitempty"`      // Следование траектории сервера, если активно
+
+	Orientation *Orientation `json:"orientation,omitempty"` // Ориентация ракеты; старые клиенты ее не шлют
+
+	ParachuteDeployed bool `json:"parachute_deployed,omitempty"` // Парашют раскрыт
+	ParachuteFailed   bool `json:"parachute_failed,omitempty"`   // Парашют порван: раскрыт на слишком большой скорости
+	PayloadDeployed   bool `json:"payload_deployed,omitempty"`   // Полезная нагрузка отделена
+}
+
+// EngineStatus - состояние двигателя текущей ступени
+type EngineStatus struct {
+	ID       string  `json:"id,omitempty"`
+	Throttle float64 `json:"throttle"`         // Дроссель последнего шага после отказов и ограничений
+	Active   bool    `json:"active"`           // Двигатель создает тягу
+	Failed   bool    `json:"failed,omitempty"` // Отказ при имитации отказов
+	BurnTime float64 `json:"burn_time"`        // Суммарное время работы на текущей ступени в с
+}
+
+// UnmarshalJSON принимает и прежний формат engine_status - исправность
+// двигателя true/false. Объект сразу разбирается как объект: неудачная
+// попытка прочитать bool стоила бы ошибки на каждый двигатель каждого кадра.
+func (s *EngineStatus) UnmarshalJSON(data []byte) error {
+	if len(data) == 0 || data[0] != '{' {
+		var healthy bool
+		if err := json.Unmarshal(data, &healthy); err == nil {
+			*s = EngineStatus{Failed: !healthy}
+			return nil
+		}
+	}
+	type plain EngineStatus
+	return json.Unmarshal(data, (*plain)(s))
+}
+
+type GuidanceStatus struct {
+	WaypointIndex int     `json:"waypoint_index"` // Номер текущей контрольной точки (с 0)
+	WaypointCount int     `json:"waypoint_count"` // Всего точек в траектории
+	Distance      float64 `json:"distance"`       // Расстояние до текущей точки в м
+}
+
+type ControlCommand struct {
+	EngineThrottle []float64 `json:"engine_throttle"` // Дроссели двигателей (0.0 - 1.0)
+	Pitch          float64   `json:"pitch"`           // Угол тангажа
+	Yaw            float64   `json:"yaw"`             // Угол рыскания
+	Roll           float64   `json:"roll"`            // Угол крена
+
+	// Действия выполняются один раз по переднему фронту: команда, которая
+	// держится несколько шагов или повторяется подряд, срабатывает однажды
+	DeployParachute bool `json:"deploy_parachute,omitempty"` // Раскрыть парашют
+	StageSeparate   bool `json:"stage_separate,omitempty"`   // Отделить текущую ступень, даже если в ней осталось топливо
+	DeployPayload   bool `json:"deploy_payload,omitempty"`   // Отделить полезную нагрузку
+}
+
+// ValidateActions проверяет, что действия команды выполнимы для ракеты
+// config в состоянии state: есть следующая ступень, полезная нагрузка еще
+// на борту, парашют есть в конфигурации. Повторное раскрытие парашюта не
+// ошибка - оно ничего не меняет.
+func ValidateActions(command *ControlCommand, config *RocketConfig, state *RocketState) error {
+	if command.StageSeparate {
+		// Stage 0 - одноступенчатая ракета или телеметрии еще не было
+		if stage := max(state.Stage, 1); stage >= len(config.Stages) {
+			return &ValidationError{Field: "stage_separate", Message: "нет ступени для отделения", Index: -1}
+		}
+	}
+	if command.DeployPayload {
+		if config.Payload == nil {
+			return &ValidationError{Field: "deploy_payload", Message: "у ракеты нет полезной нагрузки", Index: -1}
+		}
+		if state.PayloadDeployed {
+			return &ValidationError{Field: "deploy_payload", Message: "полезная нагрузка уже отделена", Index: -1}
+		}
+	}
+	if command.DeployParachute && config.Parachute == nil {
+		return &ValidationError{Field: "deploy_parachute", Message: "у ракеты нет парашюта", Index: -1}
+	}
+	return nil
+}
+
+type Message struct {
+	Type      MessageType `json:"type"`
+	Timestamp time.Time   `json:"timestamp"`
+	// TimestampMs - то же время в Unix-миллисекундах для клиентов, которым
+	// неудобно разбирать RFC3339 с наносекундами. При кодировании
+	// заполняется из Timestamp, при чтении имеет приоритет над ним.
+	TimestampMs int64 `json:"timestamp_ms,omitempty"`
+	// Seq - номер сообщения отправителя, растет с 1 в каждом соединении.
+	// 0 (старые клиенты) - порядок не проверяется.
+	Seq  uint64      `json:"seq,omitempty"`
+	Data interface{} `json:"data"`
+}
+
+// messageJSON - Message без собственных методов JSON
+type messageJSON Message
+
+// wire - конверт в том виде, в каком он уходит в JSON: timestamp_ms
+// заполняется из Timestamp
+func (m Message) wire() messageJSON {
+	if m.TimestampMs == 0 && !m.Timestamp.IsZero() {
+		m.TimestampMs = m.Timestamp.UnixMilli()
+	}
+	return messageJSON(m)
+}
+
+func (m Message) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.wire())
+}
+
+func (m *Message) UnmarshalJSON(data []byte) error {
+	raw := envelopeJSON[interface{}]{Data: m.Data} // Data с указателем заполняется на месте, как у encoding/json
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	msg, err := raw.message()
+	if err != nil {
+		return err
+	}
+	msg.Data = raw.Data
+	*m = msg
+	return nil
+}
+
+// envelopeJSON - конверт при чтении. Data - поле типа D: jsonCodec.Decode
+// читает его в json.RawMessage без промежуточного interface{}.
+type envelopeJSON[D any] struct {
+	Type MessageType `json:"type"`
+	// Строка читается отдельно: при наличии timestamp_ms неразборчивый
+	// timestamp не ошибка
+	Timestamp   lenientTimestamp `json:"timestamp"`
+	TimestampMs int64            `json:"timestamp_ms"`
+	Seq         uint64           `json:"seq"`
+	Data        D                `json:"data"`
+}
+
+// message - конверт без Data; timestamp_ms важнее timestamp
+func (e *envelopeJSON[D]) message() (Message, error) {
+	timestamp, err := e.Timestamp.time, e.Timestamp.err
+	if e.TimestampMs != 0 {
+		timestamp, err = time.UnixMilli(e.TimestampMs).UTC(), nil
+	}
+	if err != nil {
+		return Message{}, fmt.Errorf("timestamp: %w", err)
+	}
+	return Message{Type: e.Type, Timestamp: timestamp, TimestampMs: e.TimestampMs, Seq: e.Seq}, nil
+}
+
+// lenientTimestamp - строка timestamp конверта, разобранная на месте, без
+// копии исходных байтов. Ошибка разбора запоминается, а не прерывает
+// чтение конверта.
+type lenientTimestamp struct {
+	time time.Time
+	err  error
+}
+
+func (t *lenientTimestamp) UnmarshalJSON(data []byte) error {
+	if string(data) != "null" {
+		t.err = t.time.UnmarshalJSON(data)
+	}
+	return nil
+}
+
+type RegisterMessage struct {
+	RocketID string       `json:"rocket_id"`
+	Config   RocketConfig `json:"config"`
+	Mission  *Mission     `json:"mission,omitempty"` // Цель полета, у старых клиентов нет
+}
+
+// Mission - цель полета, которую ракета сообщает при регистрации. Сервер
+// показывает ее наблюдателям и сравнивает с орбитой из телеметрии.
+type Mission struct {
+	TargetOrbit       float64  `json:"target_orbit,omitempty"`       // Высота круговой орбиты в м, 0 - без орбитальной цели
+	TargetInclination *float64 `json:"target_inclination,omitempty"` // Наклонение в градусах, nil - любое
+	LaunchSite        string   `json:"launch_site,omitempty"`
+	Crewed            bool     `json:"crewed,omitempty"`
+	Description       string   `json:"description,omitempty"`
+}
+
+const (
+	MaxTargetOrbit           = 1e8 // м, дальше Луны орбит не бывает
+	MaxLaunchSiteLength      = 64
+	MaxMissionDescriptionLen = 256
+)
+
+// ValidateMission проверяет цель полета из регистрации. nil - цели нет
+// или она корректна.
+func ValidateMission(mission *Mission) error {
+	if mission == nil {
+		return nil
+	}
+	var errs ValidationErrors
+	add := func(field, message string) {
+		errs = append(errs, &ValidationError{Field: "mission." + field, Message: message, Index: -1})
+	}
+	if mission.TargetOrbit < 0 || mission.TargetOrbit > MaxTargetOrbit || math.IsNaN(mission.TargetOrbit) {
+		add("target_orbit", fmt.Sprintf("высота орбиты должна быть от 0 до %.0f км", MaxTargetOrbit/1000))
+	}
+	if inclination := mission.TargetInclination; inclination != nil && !(*inclination >= 0 && *inclination <= 180) {
+		add("target_inclination", "наклонение должно быть от 0 до 180°")
+	}
+	if utf8.RuneCountInString(mission.LaunchSite) > MaxLaunchSiteLength {
+		add("launch_site", "название космодрома длиннее "+strconv.Itoa(MaxLaunchSiteLength)+" символов")
+	}
+	if utf8.RuneCountInString(mission.Description) > MaxMissionDescriptionLen {
+		add("description", "описание длиннее "+strconv.Itoa(MaxMissionDescriptionLen)+" символов")
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ConfigRequestMessage - запрос конфигурации ракеты по имени из каталога
+// сервера. Отправляется после подключения, до регистрации; ответ -
+// config_response или rejected с кодом unknown_vehicle.
+type ConfigRequestMessage struct {
+	RocketID string `json:"rocket_id"`
+	Vehicle  string `json:"vehicle"`
+}
+
+type ConfigResponseMessage struct {
+	RocketID string       `json:"rocket_id"`
+	Vehicle  string       `json:"vehicle"`
+	Config   RocketConfig `json:"config"`
+}
+
+type TelemetryMessage struct {
+	RocketID string      `json:"rocket_id"`
+	State    RocketState `json:"state"`
+}
+
+// MaxBatchStates - предел кадров в одном telemetry_batch. Больший пакет
+// сервер отбрасывает целиком, поэтому отправитель делит очередь на части.
+const MaxBatchStates = 1000
+
+// BatchTelemetryMessage - кадры телеметрии по порядку, от старых к новым
+type BatchTelemetryMessage struct {
+	RocketID string        `json:"rocket_id"`
+	States   []RocketState `json:"states"`
+}
+
+type CommandMessage struct {
+	RocketID string         `json:"rocket_id"`
+	Command  ControlCommand `json:"command"`
+	Attitude *AttitudeHold  `json:"attitude,omitempty"` // Режим ориентации, nil - не менять
+	// EngineThrottleByID - дроссели по ID двигателя вместо command.engine_throttle.
+	// Двигатели текущей ступени, которых нет в списке, выключаются.
+	EngineThrottleByID map[string]float64 `json:"engine_throttle_by_id,omitempty"`
+}
+
+// ValidateCommand проверяет дроссели команды по ID. Дроссели задаются либо
+// списком, либо по ID двигателя, но не обоими способами сразу.
+func ValidateCommand(msg *CommandMessage) error {
+	if len(msg.EngineThrottleByID) > 0 && len(msg.Command.EngineThrottle) > 0 {
+		return &ValidationError{Field: "engine_throttle_by_id", Message: "нельзя задавать вместе с command.engine_throttle", Index: -1}
+	}
+	ids := make([]string, 0, len(msg.EngineThrottleByID))
+	for id := range msg.EngineThrottleByID {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if throttle := msg.EngineThrottleByID[id]; id == "" || throttle < 0 || throttle > 1 {
+			return &ValidationError{Field: "engine_throttle_by_id." + id, Message: "ожидается непустой ID и дроссель от 0 до 1", Index: -1}
+		}
+	}
+	return nil
+}
+
+// ResolveEngineThrottle переводит дроссели по ID в список по порядку
+// двигателей engines. Неизвестный ID - ошибка: команда могла быть
+// рассчитана на другую ступень.
+func ResolveEngineThrottle(engines []Engine, byID map[string]float64) ([]float64, error) {
+	throttle := make([]float64, len(engines))
+	found := 0
+	for i, engine := range engines {
+		if value, ok := byID[engine.ID]; ok && engine.ID != "" {
+			throttle[i] = value
+			found++
+		}
+	}
+	if found == len(byID) {
+		return throttle, nil
+	}
+
+	var unknown []string
+	for id := range byID {
+		known := false
+		for _, engine := range engines {
+			known = known || engine.ID == id
+		}
+		if !known {
+			unknown = append(unknown, id)
+		}
+	}
+	sort.Strings(unknown)
+	return nil, fmt.Errorf("нет двигателей %s на текущей ступени", strings.Join(unknown, ", "))
+}
+
+// AttitudeMode - режим удержания ориентации: тангаж и рыскание на каждом
+// шаге пересчитываются из скорости и позиции ракеты
+type AttitudeMode string
+
+const (
+	AttitudeNone         AttitudeMode = "none"          // Ориентацию задает программа полета или команда
+	AttitudePrograde     AttitudeMode = "prograde"      // Тяга по вектору скорости
+	AttitudeRetrograde   AttitudeMode = "retrograde"    // Тяга против вектора скорости
+	AttitudeRadialOut    AttitudeMode = "radial_out"    // Перпендикулярно скорости, от планеты
+	AttitudeSurfacePitch AttitudeMode = "surface_pitch" // Постоянный тангаж от местной вертикали
+)
+
+type AttitudeHold struct {
+	Mode  AttitudeMode `json:"mode"`
+	Pitch float64      `json:"pitch,omitempty"` // Градусы от вертикали для surface_pitch
+}
+
+type AcceptedMessage struct {
+	RocketID     string `json:"rocket_id"`
+	Message      string `json:"message"`
+	ConnectionID string `json:"connection_id,omitempty"` // ID соединения в логах сервера
+}
+
+type RejectCode string
+
+const (
+	RejectCodeDuplicateID     RejectCode = "duplicate_id"     // Ракета с таким ID уже зарегистрирована
+	RejectCodeInvalidConfig   RejectCode = "invalid_config"   // Конфигурация не прошла проверку
+	RejectCodeServerFull      RejectCode = "server_full"      // Достигнут лимит ракет
+	RejectCodeAuthFailed      RejectCode = "auth_failed"      // Ошибка авторизации
+	RejectCodeVersionMismatch RejectCode = "version_mismatch" // Несовместимая версия протокола
+	RejectCodeDraining        RejectCode = "draining"         // Сервер не принимает новые ракеты
+	RejectCodeUnknownVehicle  RejectCode = "unknown_vehicle"  // Ракеты с таким именем нет в каталоге сервера
+	RejectCodeUnknownField    RejectCode = "unknown_field"    // В регистрации поле, которого нет в протоколе (опечатка)
+)
+
+type RejectedMessage struct {
+	RocketID string     `json:"rocket_id"`
+	Code     RejectCode `json:"code"`   // Код причины для автоматической обработки
+	Reason   string     `json:"reason"` // Причина для человека
+}
+
+// ErrorCode - почему сервер отбросил сообщение клиента
+type ErrorCode string
+
+const (
+	ErrorCodeDecode            ErrorCode = "decode_error"       // Сообщение или его данные не разобраны
+	ErrorCodeUnknownType       ErrorCode = "unknown_type"       // Сервер не знает такого типа сообщения
+	ErrorCodeNotRegistered     ErrorCode = "not_registered"     // Сообщение ракеты до регистрации
+	ErrorCodeRateLimited       ErrorCode = "rate_limited"       // Превышен лимит сообщений соединения
+	ErrorCodeUnauthorized      ErrorCode = "unauthorized"       // Подписка без токена наблюдателя
+	ErrorCodeAlreadyRegistered ErrorCode = "already_registered" // Повторная регистрация или подписка в том же соединении
+)
+
+// ErrorMessage - ответ на отброшенное сообщение. Сервер отправляет не больше
+// одной ошибки с тем же кодом в секунду, остальные считает в Suppressed.
+type ErrorMessage struct {
+	Code       ErrorCode   `json:"code"`
+	Detail     string      `json:"detail"`               // Подробности для человека
+	RefType    MessageType `json:"ref_type,omitempty"`   // Тип отброшенного сообщения, если известен
+	RefSeq     uint64      `json:"ref_seq,omitempty"`    // Его seq
+	Suppressed uint64      `json:"suppressed,omitempty"` // Ошибок с этим кодом, не отправленных с прошлого раза
+}
+
+// WarningCode - вид предупреждения. Клиент выбирает реакцию по коду, а не
+// по тексту Warning; неизвестный код он только пишет в журнал.
+type WarningCode string
+
+const (
+	WarningCodeProximity       WarningCode = "proximity"        // Опасное сближение с другой ракетой
+	WarningCodeGroundProximity WarningCode = "ground_proximity" // Скорое столкновение с поверхностью
+	WarningCodeFuelLow         WarningCode = "fuel_low"         // Топливо на исходе
+	WarningCodeLinkLatency     WarningCode = "link_latency"     // Телеметрия приходит с большой задержкой
+	WarningCodePlausibility    WarningCode = "plausibility"     // Телеметрия физически неправдоподобна
+)
+
+type Severity string
+
+const (
+	SeverityLow      Severity = "low"
+	SeverityMedium   Severity = "medium"
+	SeverityHigh     Severity = "high"
+	SeverityCritical Severity = "critical"
+)
+
+// AtLeast сообщает, не ниже ли важность, чем other. Неизвестная важность
+// ниже любой известной.
+func (s Severity) AtLeast(other Severity) bool {
+	return s.rank() >= other.rank()
+}
+
+func (s Severity) rank() int {
+	switch s {
+	case SeverityLow:
+		return 1
+	case SeverityMedium:
+		return 2
+	case SeverityHigh:
+		return 3
+	case SeverityCritical:
+		return 4
+	}
+	return 0
+}
+
+type WarningMessage struct {
+	RocketID string      `json:"rocket_id"`
+	Code     WarningCode `json:"code"`
+	Warning  string      `json:"warning"` // Текст для человека
+	Severity Severity    `json:"severity"`
+
+	// proximity
+	OtherRocketID string   `json:"other_rocket_id,omitempty"` // Вторая ракета
+	OtherPosition *Vector3 `json:"other_position,omitempty"`  // Ее позиция в момент проверки
+	Distance      float64  `json:"distance,omitempty"`        // Наименьшее расстояние, м
+	TimeToClosest float64  `json:"time_to_closest,omitempty"` // Через сколько секунд наибольшее сближение
+
+	// ground_proximity
+	TimeToImpact float64 `json:"time_to_impact,omitempty"` // Секунд до поверхности
+}
+
+type TrajectoryMessage struct {
+	RocketID  string    `json:"rocket_id"`
+	Waypoints []Vector3 `json:"waypoints"` // Контрольные точки траектории
+}
+
+type RocketInfo struct {
+	RocketID string            `json:"rocket_id"`
+	Name     string            `json:"name"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	State    RocketState       `json:"state"`
+	Config   RocketConfig      `json:"config"`
+	Mission  *Mission          `json:"mission,omitempty"`
+}
+
+type RocketListMessage struct {
+	Rockets []RocketInfo `json:"rockets"`
+}
+
+type DisconnectMessage struct {
+	RocketID string `json:"rocket_id"`
+	Reason   string `json:"reason"`
+}
+
+// AbortMessage - ракета прекратила полет по условию безопасности. Телеметрия
+// продолжается до падения или посадки, затем приходит disconnect с причиной aborted.
+type AbortMessage struct {
+	RocketID string  `json:"rocket_id"`
+	Reason   string  `json:"reason"`   // Сработавшее условие
+	Time     float64 `json:"time"`     // Время симуляции в секундах
+	Altitude float64 `json:"altitude"` // Высота в м
+}
+
+// HeartbeatMessage - проверка связи. Сервер отвечает тем же сообщением,
+// по Nonce клиент находит отправленный запрос и измеряет время ответа.
+type HeartbeatMessage struct {
+	RocketID string `json:"rocket_id"`
+	Nonce    uint64 `json:"nonce"`
+}
+
+type SubscribeMessage struct {
+	ObserverID string            `json:"observer_id"`
+	Labels     map[string]string `json:"labels,omitempty"`     // Получать события только ракет с этими метками
+	RocketIDs  []string          `json:"rocket_ids,omitempty"` // И только ракет с этими ID, пусто - любых
+	Token      string            `json:"token,omitempty"`      // Токен наблюдателя, если сервер его требует
+}
+
+type UnsubscribeMessage struct {
+	ObserverID string `json:"observer_id"`
+}
+
+type BroadcastMessage struct {
+	RocketID string      `json:"rocket_id"`
+	Name     string      `json:"name"`
+	State    RocketState `json:"state"`
+}
+
+type RocketJoinedMessage struct {
+	RocketID string       `json:"rocket_id"`
+	Name     string       `json:"name"`
+	Config   RocketConfig `json:"config"`
+	Mission  *Mission     `json:"mission,omitempty"`
+}
+
+type RocketLeftMessage struct {
+	RocketID string `json:"rocket_id"`
+	Reason   string `json:"reason"`
+}
+
+type MissionEventType string
+
+const (
+	MissionEventTargetAchieved MissionEventType = "target_achieved" // Орбита совпала с целью миссии
+)
+
+// MissionEventMessage - событие миссии, которое сервер находит, сравнивая
+// телеметрию с целью из регистрации
+type MissionEventMessage struct {
+	RocketID string           `json:"rocket_id"`
+	Event    MissionEventType `json:"event"`
+	Time     float64          `json:"time"` // Время полета, с
+	Message  string           `json:"message"`
+}
+
+const (
+	EarthRadius      = 6371000.0 // м
+	EarthMass        = 5.972e24  // кг
+	GConstant        = 6.674e-11 // м3/(кг*с2)
+	OrbitalVelocity  = 7900.0    // м/с
+	AtmosphereHeight = 100000.0  // м
+)
+
+// ValidateRocketConfig проверяет конфигурацию целиком и возвращает все
+// найденные проблемы сразу (ValidationErrors), чтобы их можно было
+// исправить за один раз. nil - конфигурация корректна.
+func ValidateRocketConfig(config *RocketConfig) error {
+	var errs ValidationErrors
+	add := func(field, message string, index int) {
+		errs = append(errs, &ValidationError{Field: field, Message: message, Index: index})
+	}
+
+	if config.Name == "" {
+		add("name", "название ракеты не может быть пустым", -1)
+	}
+	if config.MassEmpty <= 0 {
+		add("mass_empty", "масса пустой ракеты должна быть положительной", -1)
+	}
+	if config.MassFuel < 0 {
+		add("mass_fuel", "масса топлива не может быть отрицательной", -1)
+	}
+	if config.MassFuelMax < config.MassFuel {
+		add("mass_fuel_max", "максимальная масса топлива должна быть >= текущей массе", -1)
+	}
+
+	if len(config.Engines) == 0 {
+		add("engines", "ракета должна иметь хотя бы один двигатель", -1)
+	}
+	for i, engine := range config.Engines {
+		if engine.Thrust <= 0 {
+			add("engines", "тяга двигателя должна быть положительной", i)
+		}
+		if engine.FuelConsumption < 0 {
+			add("engines", "расход топлива не может быть отрицательным", i)
+		}
+	}
+	for _, i := range duplicateEngineIDs(config.Engines) {
+		add("engines", "ID двигателя "+strconv.Quote(config.Engines[i].ID)+" уже занят", i)
+	}
+
+	if config.DragCoefficient < 0 {
+		add("drag_coefficient", "коэффициент сопротивления не может быть отрицательным", -1)
+	}
+	if config.CrossSection <= 0 {
+		add("cross_section", "площадь сечения должна быть положительной", -1)
+	}
+
+	errs = append(errs, validateStages(config)...)
+	errs = append(errs, validateParachute(config.Parachute)...)
+	errs = append(errs, validatePayload(config)...)
+
+	if len(config.Labels) > MaxLabels {
+		add("labels", "слишком много меток (максимум 16)", -1)
+	}
+	// Ключи по порядку, чтобы список ошибок не менялся от запуска к запуску
+	keys := make([]string, 0, len(config.Labels))
+	for key := range config.Labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if key == "" || len(key) > MaxLabelLength {
+			add("labels."+key, "длина ключа метки должна быть от 1 до 63 символов", -1)
+		}
+		if len(config.Labels[key]) > MaxLabelLength {
+			add("labels."+key, "длина значения метки не должна превышать 63 символа", -1)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func ValidateAttitudeHold(hold *AttitudeHold) error {
+	switch hold.Mode {
+	case AttitudeNone, AttitudePrograde, AttitudeRetrograde, AttitudeRadialOut:
+		return nil
+	case AttitudeSurfacePitch:
+		if hold.Pitch < 0 || hold.Pitch > 180 {
+			return &ValidationError{Field: "attitude.pitch", Message: "тангаж должен быть от 0 до 180 градусов", Index: -1}
+		}
+		return nil
+	}
+	return &ValidationError{Field: "attitude.mode", Message: "неизвестный режим ориентации " + string(hold.Mode), Index: -1}
+}
+
+// ValidateWarning проверяет важность предупреждения; код не проверяется,
+// чтобы старый клиент принимал новые виды предупреждений
+func ValidateWarning(warning *WarningMessage) error {
+	if warning.Severity.rank() == 0 {
+		return &ValidationError{Field: "severity", Message: "неизвестная важность " + strconv.Quote(string(warning.Severity)), Index: -1}
+	}
+	return nil
+}
+
+// validateStages проверяет ступени и их согласованность с плоскими полями
+func validateStages(config *RocketConfig) ValidationErrors {
+	if len(config.Stages) == 0 {
+		return nil
+	}
+
+	var errs ValidationErrors
+	massEmpty, massFuel := 0.0, 0.0
+	for i, stage := range config.Stages {
+		if stage.MassEmpty <= 0 {
+			errs = append(errs, &ValidationError{Field: "stages", Message: "сухая масса ступени должна быть положительной", Index: i})
+		}
+		if stage.MassFuel < 0 {
+			errs = append(errs, &ValidationError{Field: "stages", Message: "масса топлива ступени не может быть отрицательной", Index: i})
+		}
+		if len(stage.Engines) == 0 {
+			errs = append(errs, &ValidationError{Field: "stages", Message: "ступень должна иметь хотя бы один двигатель", Index: i})
+		}
+		for _, engine := range stage.Engines {
+			if engine.Thrust <= 0 || engine.FuelConsumption < 0 {
+				errs = append(errs, &ValidationError{Field: "stages", Message: "двигатели ступени должны иметь положительную тягу и неотрицательный расход", Index: i})
+				break
+			}
+		}
+		massEmpty += stage.MassEmpty
+		massFuel += stage.MassFuel
+	}
+
+	// ID уникальны во всей ракете, а не только в ступени: по ним двигатель
+	// ищут в журнале после отделения ступеней
+	var engines []Engine
+	var stageOf []int
+	for i, stage := range config.Stages {
+		engines = append(engines, stage.Engines...)
+		for range stage.Engines {
+			stageOf = append(stageOf, i)
+		}
+	}
+	for _, i := range duplicateEngineIDs(engines) {
+		errs = append(errs, &ValidationError{Field: "stages", Message: "ID двигателя " + strconv.Quote(engines[i].ID) + " уже занят", Index: stageOf[i]})
+	}
+
+	// Допуск на округление при передаче через JSON
+	if math.Abs(config.MassEmpty-massEmpty) > 1e-6*massEmpty {
+		errs = append(errs, &ValidationError{Field: "mass_empty", Message: "должна равняться сумме сухих масс ступеней", Index: -1})
+	}
+	if math.Abs(config.MassFuel-massFuel) > 1e-6*massFuel {
+		errs = append(errs, &ValidationError{Field: "mass_fuel", Message: "должна равняться сумме топлива ступеней", Index: -1})
+	}
+	return errs
+}
+
+// duplicateEngineIDs возвращает номера двигателей, ID которых уже встречался
+// раньше в списке. Пустой ID не проверяется.
+func duplicateEngineIDs(engines []Engine) []int {
+	var duplicates []int
+	seen := make(map[string]bool, len(engines))
+	for i, engine := range engines {
+		if engine.ID == "" {
+			continue
+		}
+		if seen[engine.ID] {
+			duplicates = append(duplicates, i)
+		}
+		seen[engine.ID] = true
+	}
+	return duplicates
+}
+
+// validatePayload проверяет, что полезная нагрузка помещается в сухую массу
+// ракеты, которая остается после отделения всех ступеней
+func validatePayload(config *RocketConfig) ValidationErrors {
+	payload := config.Payload
+	if payload == nil {
+		return nil
+	}
+	dry := config.MassEmpty
+	if len(config.Stages) > 0 {
+		dry = config.Stages[len(config.Stages)-1].MassEmpty
+	}
+	if payload.Mass <= 0 || payload.Mass >= dry {
+		return ValidationErrors{&ValidationError{Field: "payload.mass", Message: "масса нагрузки должна быть положительной и меньше сухой массы последней ступени", Index: -1}}
+	}
+	return nil
+}
+
+// validateParachute проверяет парашют, если он есть
+func validateParachute(parachute *Parachute) ValidationErrors {
+	if parachute == nil {
+		return nil
+	}
+	var errs ValidationErrors
+	if parachute.DragCoefficient <= 0 {
+		errs = append(errs, &ValidationError{Field: "parachute.drag_coefficient", Message: "коэффициент сопротивления купола должен быть положительным", Index: -1})
+	}
+	if parachute.Area <= 0 {
+		errs = append(errs, &ValidationError{Field: "parachute.area", Message: "площадь купола должна быть положительной", Index: -1})
+	}
+	if parachute.DeployAltitude < 0 {
+		errs = append(errs, &ValidationError{Field: "parachute.deploy_altitude", Message: "высота раскрытия не может быть отрицательной", Index: -1})
+	}
+	return errs
+}
+
+const (
+	MaxLabels      = 16
+	MaxLabelLength = 63
+)
+
+// MatchLabels проверяет, что все метки селектора присутствуют среди меток ракеты.
+// Пустое значение в селекторе означает "метка с любым значением".
+func MatchLabels(selector, labels map[string]string) bool {
+	for key, want := range selector {
+		got, ok := labels[key]
+		if !ok || (want != "" && got != want) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidationError - проблема в одном поле конфигурации. Index - номер
+// двигателя или ступени; -1, если поле не список.
+type ValidationError struct {
+	Field   string
+	Message string
+	Index   int
+}
+
+func (e *ValidationError) Error() string {
+	if e.Index >= 0 {
+		return e.Field + "[" + strconv.Itoa(e.Index) + "]: " + e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// ValidationErrors - все проблемы конфигурации в порядке проверки.
+// errors.As находит в нем отдельные *ValidationError.
+type ValidationErrors []*ValidationError
+
+func (errs ValidationErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (errs ValidationErrors) Unwrap() []error {
+	unwrapped := make([]error, len(errs))
+	for i, err := range errs {
+		unwrapped[i] = err
+	}
+	return unwrapped
+}
@@ -0,0 +1,237 @@
+package protocol
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func validConfig() RocketConfig {
+	return RocketConfig{
+		Name:         "Тест",
+		MassEmpty:    1000,
+		MassFuel:     5000,
+		MassFuelMax:  5000,
+		CrossSection: 1,
+		Engines:      []Engine{{Thrust: 100000, FuelConsumption: 30}},
+	}
+}
+
+func TestValidationErrorIndex(t *testing.T) {
+	tests := []struct {
+		err  ValidationError
+		want string
+	}{
+		{ValidationError{Field: "engines", Message: "нет тяги", Index: 0}, "engines[0]: нет тяги"},
+		{ValidationError{Field: "engines", Message: "нет тяги", Index: 12}, "engines[12]: нет тяги"},
+		{ValidationError{Field: "name", Message: "пусто", Index: -1}, "name: пусто"},
+	}
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("%q, ожидалось %q", got, tt.want)
+		}
+	}
+}
+
+func TestValidateRocketConfigAllProblems(t *testing.T) {
+	config := validConfig()
+	if err := ValidateRocketConfig(&config); err != nil {
+		t.Fatalf("корректная конфигурация: %v", err)
+	}
+
+	config.Name = ""
+	config.Engines = append(config.Engines, Engine{Thrust: 0}, Engine{Thrust: 1, FuelConsumption: -1})
+	config.CrossSection = 0
+	config.Labels = map[string]string{"b": strings.Repeat("x", 64), "a": strings.Repeat("x", 64)}
+
+	err := ValidateRocketConfig(&config)
+	var problems ValidationErrors
+	if !errors.As(err, &problems) {
+		t.Fatalf("ошибка %T, ожидалась ValidationErrors", err)
+	}
+	want := "name: название ракеты не может быть пустым; " +
+		"engines[1]: тяга двигателя должна быть положительной; " +
+		"engines[2]: расход топлива не может быть отрицательным; " +
+		"cross_section: площадь сечения должна быть положительной; " +
+		"labels.a: длина значения метки не должна превышать 63 символа; " +
+		"labels.b: длина значения метки не должна превышать 63 символа"
+	if err.Error() != want {
+		t.Errorf("ошибка\n%s\nожидалась\n%s", err, want)
+	}
+
+	// Отдельные проблемы доступны через errors.As
+	var first *ValidationError
+	if !errors.As(err, &first) || first.Field != "name" {
+		t.Errorf("errors.As нашел %+v, ожидалось поле name", first)
+	}
+}
+
+func TestValidateStagesAllProblems(t *testing.T) {
+	config := validConfig()
+	config.Stages = []Stage{
+		{MassEmpty: 0, MassFuel: 100, Engines: []Engine{{Thrust: 1}}},
+		{MassEmpty: 10, MassFuel: -1},
+	}
+
+	err := ValidateRocketConfig(&config)
+	for _, want := range []string{
+		"stages[0]: сухая масса",
+		"stages[1]: масса топлива ступени",
+		"stages[1]: ступень должна иметь",
+		"mass_empty: должна равняться",
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ошибка %v не содержит %q", err, want)
+		}
+	}
+}
+
+func TestValidateWarning(t *testing.T) {
+	for _, severity := range []Severity{SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical} {
+		if err := ValidateWarning(&WarningMessage{Code: "future_kind", Severity: severity}); err != nil {
+			t.Errorf("%s: %v", severity, err)
+		}
+	}
+	for _, severity := range []Severity{"", "HIGH", "fatal"} {
+		if err := ValidateWarning(&WarningMessage{Code: WarningCodeProximity, Severity: severity}); err == nil {
+			t.Errorf("важность %q принята", severity)
+		}
+	}
+
+	if !SeverityCritical.AtLeast(SeverityHigh) || SeverityMedium.AtLeast(SeverityHigh) || Severity("fatal").AtLeast(SeverityLow) {
+		t.Errorf("неверный порядок важности")
+	}
+}
+
+func TestValidateEngineIDs(t *testing.T) {
+	config := validConfig()
+	config.Engines = []Engine{{ID: "center", Thrust: 1}, {Thrust: 1}, {Thrust: 1}, {ID: "center", Thrust: 1}}
+	err := ValidateRocketConfig(&config)
+	if err == nil || err.Error() != `engines[3]: ID двигателя "center" уже занят` {
+		t.Errorf("ошибка %v, ожидался повтор engines[3]", err)
+	}
+
+	// Двигатели первой ступени повторяются в плоском engines - это не повтор
+	config = validConfig()
+	config.Stages = []Stage{
+		{MassEmpty: 500, MassFuel: 4000, Engines: []Engine{{ID: "first", Thrust: 1}}},
+		{MassEmpty: 500, MassFuel: 1000, Engines: []Engine{{ID: "first", Thrust: 1}}},
+	}
+	config.ApplyStages()
+	err = ValidateRocketConfig(&config)
+	if err == nil || err.Error() != `stages[1]: ID двигателя "first" уже занят` {
+		t.Errorf("ошибка %v, ожидался повтор во второй ступени", err)
+	}
+}
+
+func TestCommandByEngineID(t *testing.T) {
+	engines := []Engine{{ID: "left"}, {}, {ID: "right"}}
+
+	throttle, err := ResolveEngineThrottle(engines, map[string]float64{"right": 0.5, "left": 1})
+	if err != nil || len(throttle) != 3 || throttle[0] != 1 || throttle[1] != 0 || throttle[2] != 0.5 {
+		t.Errorf("дроссели %v (%v), ожидалось [1 0 0.5]", throttle, err)
+	}
+	if _, err := ResolveEngineThrottle(engines, map[string]float64{"left": 1, "vernier": 1}); err == nil || !strings.Contains(err.Error(), "vernier") {
+		t.Errorf("ошибка %v, ожидался неизвестный vernier", err)
+	}
+
+	tests := []struct {
+		name string
+		msg  CommandMessage
+		ok   bool
+	}{
+		{name: "по ID", msg: CommandMessage{EngineThrottleByID: map[string]float64{"left": 0.3}}, ok: true},
+		{name: "списком", msg: CommandMessage{Command: ControlCommand{EngineThrottle: []float64{1}}}, ok: true},
+		{name: "обоими способами", msg: CommandMessage{Command: ControlCommand{EngineThrottle: []float64{1}}, EngineThrottleByID: map[string]float64{"left": 1}}},
+		{name: "дроссель больше 1", msg: CommandMessage{EngineThrottleByID: map[string]float64{"left": 1.5}}},
+		{name: "пустой ID", msg: CommandMessage{EngineThrottleByID: map[string]float64{"": 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateCommand(&tt.msg); (err == nil) != tt.ok {
+				t.Errorf("ошибка %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateActions(t *testing.T) {
+	twoStages := validConfig()
+	twoStages.Stages = []Stage{
+		{MassEmpty: 500, MassFuel: 4000, Engines: []Engine{{Thrust: 1}}},
+		{MassEmpty: 500, MassFuel: 1000, Engines: []Engine{{Thrust: 1}}},
+	}
+	withPayload := validConfig()
+	withPayload.Payload = &Payload{Name: "спутник", Mass: 100}
+
+	tests := []struct {
+		name    string
+		command ControlCommand
+		config  RocketConfig
+		state   RocketState
+		wantErr string
+	}{
+		{name: "отделение первой ступени", command: ControlCommand{StageSeparate: true}, config: twoStages, state: RocketState{Stage: 1}},
+		{name: "отделение до телеметрии", command: ControlCommand{StageSeparate: true}, config: twoStages},
+		{name: "отделение последней ступени", command: ControlCommand{StageSeparate: true}, config: twoStages, state: RocketState{Stage: 2}, wantErr: "stage_separate"},
+		{name: "отделение без ступеней", command: ControlCommand{StageSeparate: true}, config: validConfig(), wantErr: "stage_separate"},
+		{name: "нагрузка", command: ControlCommand{DeployPayload: true}, config: withPayload},
+		{name: "нагрузка уже отделена", command: ControlCommand{DeployPayload: true}, config: withPayload, state: RocketState{PayloadDeployed: true}, wantErr: "deploy_payload"},
+		{name: "нет нагрузки", command: ControlCommand{DeployPayload: true}, config: validConfig(), wantErr: "deploy_payload"},
+		{name: "нет парашюта", command: ControlCommand{DeployParachute: true}, config: validConfig(), wantErr: "deploy_parachute"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateActions(&tt.command, &tt.config, &tt.state)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.HasPrefix(err.Error(), tt.wantErr)) {
+				t.Errorf("ошибка %v, ожидалось %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidatePayload(t *testing.T) {
+	config := validConfig()
+	config.Payload = &Payload{Mass: 100}
+	if err := ValidateRocketConfig(&config); err != nil {
+		t.Fatalf("нагрузка 100 кг: %v", err)
+	}
+
+	config.Payload.Mass = config.MassEmpty
+	if err := ValidateRocketConfig(&config); err == nil || !strings.Contains(err.Error(), "payload.mass") {
+		t.Errorf("нагрузка тяжелее ракеты: %v", err)
+	}
+}
+
+func TestValidateMission(t *testing.T) {
+	inclination := func(degrees float64) *float64 { return &degrees }
+	tests := []struct {
+		name      string
+		mission   *Mission
+		wantField string
+	}{
+		{name: "нет цели", mission: nil},
+		{name: "орбита 400 км", mission: &Mission{TargetOrbit: 400000, TargetInclination: inclination(51.6), LaunchSite: "Байконур", Crewed: true}},
+		{name: "без орбиты", mission: &Mission{Description: "суборбитальный подскок"}},
+		{name: "отрицательная высота", mission: &Mission{TargetOrbit: -1}, wantField: "mission.target_orbit"},
+		{name: "дальше Луны", mission: &Mission{TargetOrbit: 2 * MaxTargetOrbit}, wantField: "mission.target_orbit"},
+		{name: "наклонение больше 180", mission: &Mission{TargetInclination: inclination(200)}, wantField: "mission.target_inclination"},
+		{name: "отрицательное наклонение", mission: &Mission{TargetInclination: inclination(-1)}, wantField: "mission.target_inclination"},
+		{name: "длинное описание", mission: &Mission{Description: strings.Repeat("я", MaxMissionDescriptionLen+1)}, wantField: "mission.description"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateMission(tt.mission)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Errorf("ошибка для корректной цели: %v", err)
+				}
+				return
+			}
+			var invalid *ValidationError
+			if !errors.As(err, &invalid) || invalid.Field != tt.wantField {
+				t.Errorf("ошибка %v, ожидалось поле %s", err, tt.wantField)
+			}
+		})
+	}
+}
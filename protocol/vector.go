@@ -0,0 +1,57 @@
+package protocol
+
+import "math"
+
+func (v Vector3) Add(u Vector3) Vector3 {
+	return Vector3{X: v.X + u.X, Y: v.Y + u.Y, Z: v.Z + u.Z}
+}
+
+func (v Vector3) Sub(u Vector3) Vector3 {
+	return Vector3{X: v.X - u.X, Y: v.Y - u.Y, Z: v.Z - u.Z}
+}
+
+func (v Vector3) Scale(k float64) Vector3 {
+	return Vector3{X: v.X * k, Y: v.Y * k, Z: v.Z * k}
+}
+
+func (v Vector3) Dot(u Vector3) float64 {
+	return v.X*u.X + v.Y*u.Y + v.Z*u.Z
+}
+
+func (v Vector3) Cross(u Vector3) Vector3 {
+	return Vector3{
+		X: v.Y*u.Z - v.Z*u.Y,
+		Y: v.Z*u.X - v.X*u.Z,
+		Z: v.X*u.Y - v.Y*u.X,
+	}
+}
+
+// Norm - длина вектора
+func (v Vector3) Norm() float64 {
+	return math.Sqrt(v.Dot(v))
+}
+
+// Distance - расстояние между точками v и u
+func (v Vector3) Distance(u Vector3) float64 {
+	return v.Sub(u).Norm()
+}
+
+// Normalize - единичный вектор того же направления; нулевой вектор
+// направления не имеет и возвращается как есть
+func (v Vector3) Normalize() Vector3 {
+	n := v.Norm()
+	if n == 0 {
+		return Vector3{}
+	}
+	return v.Scale(1 / n)
+}
+
+// IsFinite - в координатах нет NaN и Inf
+func (v Vector3) IsFinite() bool {
+	for _, c := range []float64{v.X, v.Y, v.Z} {
+		if math.IsNaN(c) || math.IsInf(c, 0) {
+			return false
+		}
+	}
+	return true
+}
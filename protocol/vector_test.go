@@ -0,0 +1,65 @@
+package protocol
+
+import (
+	"math"
+	"testing"
+)
+
+func TestVector3Arithmetic(t *testing.T) {
+	a := Vector3{X: 1, Y: 2, Z: 3}
+	b := Vector3{X: -4, Y: 5, Z: 0.5}
+
+	vectors := []struct {
+		name      string
+		got, want Vector3
+	}{
+		{"Add", a.Add(b), Vector3{X: -3, Y: 7, Z: 3.5}},
+		{"Sub", a.Sub(b), Vector3{X: 5, Y: -3, Z: 2.5}},
+		{"Scale", a.Scale(-2), Vector3{X: -2, Y: -4, Z: -6}},
+		{"Cross", a.Cross(b), Vector3{X: 2*0.5 - 3*5, Y: 3*-4 - 1*0.5, Z: 1*5 - 2*-4}},
+		{"Cross x*y", Vector3{X: 1}.Cross(Vector3{Y: 1}), Vector3{Z: 1}},
+		{"Normalize", Vector3{X: 3, Z: -4}.Normalize(), Vector3{X: 0.6, Z: -0.8}},
+		{"Normalize нулевого", Vector3{}.Normalize(), Vector3{}},
+	}
+	for _, v := range vectors {
+		if v.got.Distance(v.want) > 1e-12 {
+			t.Errorf("%s: %+v, ожидалось %+v", v.name, v.got, v.want)
+		}
+	}
+
+	scalars := []struct {
+		name      string
+		got, want float64
+	}{
+		{"Dot", a.Dot(b), -4 + 10 + 1.5},
+		{"Norm", Vector3{X: 2, Y: -3, Z: 6}.Norm(), 7},
+		{"Norm нулевого", Vector3{}.Norm(), 0},
+		{"Distance", a.Distance(Vector3{X: 4, Y: 6, Z: 3}), 5},
+		{"Cross перпендикулярен a", a.Cross(b).Dot(a), 0},
+		{"Cross перпендикулярен b", a.Cross(b).Dot(b), 0},
+		{"Normalize единичный", b.Normalize().Norm(), 1},
+	}
+	for _, s := range scalars {
+		if math.Abs(s.got-s.want) > 1e-12 {
+			t.Errorf("%s: %g, ожидалось %g", s.name, s.got, s.want)
+		}
+	}
+}
+
+func TestVector3IsFinite(t *testing.T) {
+	tests := []struct {
+		v    Vector3
+		want bool
+	}{
+		{Vector3{X: 1, Y: -2, Z: 1e300}, true},
+		{Vector3{}, true},
+		{Vector3{X: math.NaN()}, false},
+		{Vector3{Y: math.Inf(1)}, false},
+		{Vector3{Z: math.Inf(-1)}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.v.IsFinite(); got != tt.want {
+			t.Errorf("IsFinite(%+v) = %v, ожидалось %v", tt.v, got, tt.want)
+		}
+	}
+}